@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"filippo.io/age"
+)
+
+// encryption: age uses filippo.io/age with X25519 recipients and
+// identities. Slots decrypt with age and rage, and keys from age-keygen
+// work as-is.
+
+// ageIntro is the first line of every age v1 file
+const ageIntro = "age-encryption.org/v1\n"
+
+// ageKeys are the parsed keys for encryption: age. Identities are only
+// needed to pull, so a machine that only pushes can leave them out.
+type ageKeys struct {
+	recipients []age.Recipient
+	identities []age.Identity
+}
+
+func parseAgeRecipient(s string) (*age.X25519Recipient, error) {
+	r, err := age.ParseX25519Recipient(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid age recipient %q: %w", s, err)
+	}
+	return r, nil
+}
+
+// parseAgeIdentities reads an identity file as written by age-keygen:
+// one AGE-SECRET-KEY-1... per line, with # comments and blank lines
+func parseAgeIdentities(r io.Reader) ([]age.Identity, error) {
+	return age.ParseIdentities(r)
+}
+
+// loadAgeKeys parses sync.recipients and reads sync.identity_file for
+// encryption: age. Without recipients, slots are encrypted to the
+// identities' own public keys. Returns nil for other encryption settings.
+func loadAgeKeys(cfg *SyncConfig) (*ageKeys, error) {
+	if cfg.Encryption != "age" {
+		return nil, nil
+	}
+	keys := &ageKeys{}
+	for _, s := range cfg.Recipients {
+		r, err := parseAgeRecipient(s)
+		if err != nil {
+			return nil, err
+		}
+		keys.recipients = append(keys.recipients, r)
+	}
+	if cfg.IdentityFile != "" {
+		f, err := os.Open(cfg.IdentityFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading age identity file: %w", err)
+		}
+		defer func() { _ = f.Close() }()
+		if keys.identities, err = parseAgeIdentities(f); err != nil {
+			return nil, fmt.Errorf("age identity file %s: %w", cfg.IdentityFile, err)
+		}
+	}
+	if len(keys.recipients) == 0 {
+		for _, id := range keys.identities {
+			if x, ok := id.(*age.X25519Identity); ok {
+				keys.recipients = append(keys.recipients, x.Recipient())
+			}
+		}
+	}
+	if len(keys.recipients) == 0 {
+		return nil, errors.New("encryption: age requires sync.recipients or sync.identity_file")
+	}
+	return keys, nil
+}
+
+// validateAgeConfig checks the sync settings for encryption: age. The
+// identity file isn't read, since it may only exist where slots are pulled.
+func validateAgeConfig(cfg *SyncConfig) error {
+	if cfg.Encryption != "age" {
+		return nil
+	}
+	if len(cfg.Recipients) == 0 && cfg.IdentityFile == "" {
+		return errors.New("encryption: age requires sync.recipients or sync.identity_file")
+	}
+	for _, s := range cfg.Recipients {
+		if _, err := parseAgeRecipient(s); err != nil {
+			return err
+		}
+	}
+	if cfg.Dedup {
+		// Blob names are keyed by the passphrase under aes256; age has no
+		// shared secret, so they would reveal the content's hash
+		return errors.New("sync.dedup can't be combined with encryption: age")
+	}
+	return nil
+}
+
+// ageEncrypt encrypts data to every recipient in the age v1 format
+func ageEncrypt(data []byte, recipients []age.Recipient) ([]byte, error) {
+	if len(recipients) == 0 {
+		return nil, errors.New("no age recipients")
+	}
+	var out bytes.Buffer
+	w, err := age.Encrypt(&out, recipients...)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// ageDecrypt decrypts age v1 data with the first identity that matches
+// one of its recipient stanzas
+func ageDecrypt(data []byte, identities []age.Identity) ([]byte, error) {
+	r, err := age.Decrypt(bytes.NewReader(data), identities...)
+	if err != nil {
+		var noMatch *age.NoIdentityMatchError
+		if errors.As(err, &noMatch) {
+			return nil, errors.New("no age identity matches the slot's recipients")
+		}
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
+
+// isAgeEncrypted reports whether data starts with the age v1 header
+func isAgeEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(ageIntro))
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+)
+
+// The age test key (32 bytes of 0x42) and its published recipient
+const (
+	testAgeIdentity  = "AGE-SECRET-KEY-1GFPYYSJZGFPYYSJZGFPYYSJZGFPYYSJZGFPYYSJZGFPYYSJZGFPQ4EGAEX"
+	testAgeRecipient = "age1zvkyg2lqzraa2lnjvqej32nkuu0ues2s82hzrye869xeexvn73equnujwj"
+)
+
+// newTestAgeIdentity generates a random X25519 identity
+func newTestAgeIdentity(t *testing.T) *age.X25519Identity {
+	t.Helper()
+	id, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+func TestParseAgeKeys(t *testing.T) {
+	ids, err := parseAgeIdentities(strings.NewReader(testAgeIdentity + "\n"))
+	if err != nil || len(ids) != 1 {
+		t.Fatalf("parseAgeIdentities = %d, %v", len(ids), err)
+	}
+	id := ids[0].(*age.X25519Identity)
+	if got := id.Recipient().String(); got != testAgeRecipient {
+		t.Errorf("recipient = %s, want %s", got, testAgeRecipient)
+	}
+	if got := id.String(); got != testAgeIdentity {
+		t.Errorf("identity round trip = %s", got)
+	}
+	if r, err := parseAgeRecipient(testAgeRecipient); err != nil || r.String() != testAgeRecipient {
+		t.Errorf("parseAgeRecipient = %v, %v", r, err)
+	}
+	if _, err := parseAgeRecipient(testAgeIdentity); err == nil {
+		t.Error("an identity should not parse as a recipient")
+	}
+}
+
+func TestAgeEncryptDecrypt(t *testing.T) {
+	alice, bob, eve := newTestAgeIdentity(t), newTestAgeIdentity(t), newTestAgeIdentity(t)
+	recipients := []age.Recipient{alice.Recipient(), bob.Recipient()}
+
+	const chunk = 64 * 1024 // age's STREAM chunk size
+	for _, size := range []int{0, 1, chunk - 1, chunk, chunk + 1, 3*chunk + 7} {
+		data := make([]byte, size)
+		_, _ = rand.Read(data)
+		enc, err := ageEncrypt(data, recipients)
+		if err != nil {
+			t.Fatalf("size %d: encrypt: %v", size, err)
+		}
+		if !isAgeEncrypted(enc) {
+			t.Fatalf("size %d: missing age header", size)
+		}
+		for _, id := range []*age.X25519Identity{alice, bob} {
+			got, err := ageDecrypt(enc, []age.Identity{eve, id})
+			if err != nil {
+				t.Fatalf("size %d: decrypt: %v", size, err)
+			}
+			if !bytes.Equal(got, data) {
+				t.Fatalf("size %d: round trip mismatch", size)
+			}
+		}
+		if _, err := ageDecrypt(enc, []age.Identity{eve}); err == nil || !strings.Contains(err.Error(), "no age identity") {
+			t.Errorf("size %d: decrypt without a matching identity: %v", size, err)
+		}
+	}
+}
+
+func TestAgeDecryptTampered(t *testing.T) {
+	id := newTestAgeIdentity(t)
+	enc, err := ageEncrypt([]byte("secret notes"), []age.Recipient{id.Recipient()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Flip a byte in the MAC line, then in the payload
+	macAt := bytes.Index(enc, []byte("--- ")) + 5
+	payloadAt := len(enc) - 3
+	for _, at := range []int{macAt, payloadAt} {
+		bad := bytes.Clone(enc)
+		bad[at] ^= 0x01
+		if _, err := ageDecrypt(bad, []age.Identity{id}); err == nil {
+			t.Errorf("tampering at %d went undetected", at)
+		}
+	}
+	if _, err := ageDecrypt(enc[:len(enc)-1], []age.Identity{id}); err == nil {
+		t.Error("truncated payload went undetected")
+	}
+}
+
+func TestParseAgeIdentities(t *testing.T) {
+	file := "# created: 2026-01-01T00:00:00Z\n# public key: " + testAgeRecipient + "\n" + testAgeIdentity + "\n\n"
+	ids, err := parseAgeIdentities(strings.NewReader(file))
+	if err != nil || len(ids) != 1 {
+		t.Fatalf("parseAgeIdentities = %d, %v", len(ids), err)
+	}
+	if _, err := parseAgeIdentities(strings.NewReader("# nothing here\n")); err == nil {
+		t.Error("expected an error for a file without identities")
+	}
+	if _, err := parseAgeIdentities(strings.NewReader("AGE-SECRET-KEY-1BOGUS\n")); err == nil || !strings.Contains(err.Error(), "line 1") {
+		t.Errorf("err = %v, want a line number", err)
+	}
+}
+
+func TestValidateAgeConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     SyncConfig
+		wantErr string
+	}{
+		{"recipients", SyncConfig{Encryption: "age", Recipients: []string{testAgeRecipient}}, ""},
+		{"identity file", SyncConfig{Encryption: "age", IdentityFile: "/keys.txt"}, ""},
+		{"no keys", SyncConfig{Encryption: "age"}, "requires sync.recipients"},
+		{"bad recipient", SyncConfig{Encryption: "age", Recipients: []string{"age1nope"}}, "invalid age recipient"},
+		{"dedup", SyncConfig{Encryption: "age", Recipients: []string{testAgeRecipient}, Dedup: true}, "sync.dedup"},
+		{"not age", SyncConfig{Encryption: "aes256"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateAgeConfig(&tt.cfg)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// Test slots pushed with encryption: age pull with the identity file, and
+// that slots pushed with aes256 still pull after switching
+func TestLocalBackendAgeEncryption(t *testing.T) {
+	dir := t.TempDir()
+	identityFile := filepath.Join(t.TempDir(), "keys.txt")
+	if err := os.WriteFile(identityFile, []byte(testAgeIdentity+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// aes256 slot from before the switch
+	cleanup := setupSlotsTestConfig(t, slotsConfigAt(dir, "  encryption: aes256\n  passphrase: secret\n"))
+	backend, err := newRemoteBackendFromConfig()
+	if err != nil {
+		t.Fatalf("backend: %v", err)
+	}
+	if err := backend.Push("old", []byte("aes data"), nil); err != nil {
+		t.Fatalf("push: %v", err)
+	}
+	cleanup()
+
+	// A machine that only pushes needs no identity
+	cleanup = setupSlotsTestConfig(t, slotsConfigAt(dir, "  encryption: age\n  recipients:\n    - "+testAgeRecipient+"\n"))
+	backend, err = newRemoteBackendFromConfig()
+	if err != nil {
+		t.Fatalf("backend: %v", err)
+	}
+	content := []byte(strings.Repeat("kubeconfig\n", 300))
+	if err := backend.Push("kube", content, nil); err != nil {
+		t.Fatalf("push: %v", err)
+	}
+	if _, _, err := backend.Pull("kube"); err == nil || !strings.Contains(err.Error(), "identity_file") {
+		t.Errorf("pull without an identity: err = %v", err)
+	}
+	cleanup()
+
+	stored, err := os.ReadFile(filepath.Join(dir, "kube.pb"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var payload SlotPayload
+	if err := json.Unmarshal(stored, &payload); err != nil || payload.Encryption != "age" {
+		t.Errorf("payload doesn't record the age scheme: %s", stored)
+	}
+
+	defer setupSlotsTestConfig(t, slotsConfigAt(dir, "  encryption: age\n  identity_file: "+identityFile+"\n  passphrase: secret\n"))()
+	backend, err = newRemoteBackendFromConfig()
+	if err != nil {
+		t.Fatalf("backend: %v", err)
+	}
+	got, _, err := backend.Pull("kube")
+	if err != nil || !bytes.Equal(got, content) {
+		t.Fatalf("pull age slot: %v", err)
+	}
+	got, _, err = backend.Pull("old")
+	if err != nil || string(got) != "aes data" {
+		t.Errorf("pull aes256 slot after switching to age: %q, %v", got, err)
+	}
+
+	out := captureOutput(func() {
+		if err := cmdShow([]string{"kube", "--meta"}); err != nil {
+			t.Errorf("show --meta: %v", err)
+		}
+	})
+	if !strings.Contains(out, "encrypted:  true (age)") {
+		t.Errorf("show --meta:\n%s", out)
+	}
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// tarMIME is the MIME type of slots pushed with push -f or --tar
+const tarMIME = "application/x-tar"
+
+// isTarArchive reports whether data starts with a POSIX tar header, which
+// http.DetectContentType doesn't recognize
+func isTarArchive(data []byte) bool {
+	return len(data) >= 512 && bytes.HasPrefix(data[257:], []byte("ustar"))
+}
+
+// buildSlotArchive bundles files (stored under their base names) and
+// directory trees (stored under the directory's name) into a tar archive.
+// It returns the archive and the number of files in it.
+func buildSlotArchive(files, dirs []string) ([]byte, int, error) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	seen := map[string]string{}
+	count := 0
+	add := func(src, name string, info fs.FileInfo) error {
+		if prev, ok := seen[name]; ok {
+			return fmt.Errorf("%s and %s would both be stored as %q", prev, src, name)
+		}
+		seen[name] = src
+		hdr, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return fmt.Errorf("%s: %w", src, err)
+		}
+		hdr.Name = name
+		// Owners mean nothing on the machine that extracts the slot
+		hdr.Uid, hdr.Gid, hdr.Uname, hdr.Gname = 0, 0, "", ""
+		if info.IsDir() {
+			hdr.Name += "/"
+			return tw.WriteHeader(hdr)
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		f, err := os.Open(src)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = f.Close() }()
+		if _, err := io.Copy(tw, f); err != nil {
+			return fmt.Errorf("reading %s: %w", src, err)
+		}
+		count++
+		return nil
+	}
+
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, 0, err
+		}
+		if !info.Mode().IsRegular() {
+			return nil, 0, fmt.Errorf("%s is not a regular file (use --tar for directories)", file)
+		}
+		if err := add(file, filepath.Base(file), info); err != nil {
+			return nil, 0, err
+		}
+	}
+	for _, dir := range dirs {
+		root := filepath.Clean(dir)
+		info, err := os.Stat(root)
+		if err != nil {
+			return nil, 0, err
+		}
+		if !info.IsDir() {
+			return nil, 0, fmt.Errorf("%s is not a directory (use -f for files)", dir)
+		}
+		parent := filepath.Dir(root)
+		err = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			if !info.IsDir() && !info.Mode().IsRegular() {
+				fmt.Fprintf(os.Stderr, "warning: skipping %s (not a regular file)\n", p)
+				return nil
+			}
+			rel, err := filepath.Rel(parent, p)
+			if err != nil {
+				return err
+			}
+			return add(p, filepath.ToSlash(rel), info)
+		})
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, 0, err
+	}
+	if count == 0 {
+		return nil, 0, errors.New("no files to archive")
+	}
+	return buf.Bytes(), count, nil
+}
+
+// extractSlotArchive unpacks a tar archive into dir, creating it if
+// needed. Entries that would land outside dir (absolute paths, "..",
+// links) reject the whole archive before anything is written, as do files
+// that already exist unless force is set; writing through a symlink
+// already in dir stops extraction at that entry. It returns the number of
+// files written.
+func extractSlotArchive(data []byte, dir string, force bool) (int, error) {
+	if err := checkSlotArchive(data); err != nil {
+		return 0, err
+	}
+	if !force {
+		if err := checkArchiveTargets(data, dir); err != nil {
+			return 0, err
+		}
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return 0, err
+	}
+	tr := tar.NewReader(bytes.NewReader(data))
+	count := 0
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return count, nil
+		}
+		if err != nil {
+			return count, fmt.Errorf("reading archive: %w", err)
+		}
+		rel, err := archiveEntryPath(hdr.Name)
+		if err != nil {
+			return count, err
+		}
+		if rel == "." {
+			continue
+		}
+		target := filepath.Join(dir, rel)
+		if err := checkNoSymlinks(dir, rel); err != nil {
+			return count, err
+		}
+		perm := fs.FileMode(hdr.Mode).Perm()
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, perm|0700); err != nil {
+				return count, err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return count, err
+			}
+			flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+			if force {
+				flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+			}
+			f, err := os.OpenFile(target, flags, perm|0600)
+			if err != nil {
+				if os.IsExist(err) {
+					return count, fmt.Errorf("%s already exists (use --force to overwrite it)", target)
+				}
+				return count, err
+			}
+			_, err = io.Copy(f, tr)
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				return count, fmt.Errorf("writing %s: %w", target, err)
+			}
+			count++
+		}
+	}
+}
+
+// checkArchiveTargets fails if any file in the archive already exists
+// under dir, so extraction without --force writes nothing rather than
+// stopping partway
+func checkArchiveTargets(data []byte, dir string) error {
+	tr := tar.NewReader(bytes.NewReader(data))
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("reading archive: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		rel, err := archiveEntryPath(hdr.Name)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dir, rel)
+		if _, err := os.Lstat(target); err == nil {
+			return fmt.Errorf("%s already exists (use --force to overwrite it)", target)
+		}
+	}
+}
+
+// checkSlotArchive vets every entry's name and type without writing
+func checkSlotArchive(data []byte) error {
+	tr := tar.NewReader(bytes.NewReader(data))
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("reading archive: %w", err)
+		}
+		if _, err := archiveEntryPath(hdr.Name); err != nil {
+			return err
+		}
+		if hdr.Typeflag != tar.TypeDir && hdr.Typeflag != tar.TypeReg {
+			return fmt.Errorf("archive entry %q is not a regular file or directory; refusing to extract", hdr.Name)
+		}
+	}
+}
+
+// archiveEntryPath validates an archive entry name and returns it as a
+// relative, OS-specific path
+func archiveEntryPath(name string) (string, error) {
+	slashed := strings.ReplaceAll(name, `\`, "/")
+	clean := path.Clean(slashed)
+	if path.IsAbs(slashed) || filepath.IsAbs(name) || filepath.VolumeName(name) != "" ||
+		clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", fmt.Errorf("archive entry %q points outside the target directory; refusing to extract", name)
+	}
+	return filepath.FromSlash(clean), nil
+}
+
+// checkNoSymlinks makes sure no existing component of rel under dir is a
+// symlink, so an entry can't be written through one to somewhere else
+func checkNoSymlinks(dir, rel string) error {
+	p := dir
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		p = filepath.Join(p, part)
+		info, err := os.Lstat(p)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if info.Mode()&fs.ModeSymlink != 0 {
+			return fmt.Errorf("%s is a symlink; refusing to extract through it", p)
+		}
+	}
+	return nil
+}
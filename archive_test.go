@@ -0,0 +1,241 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPushPullArchive(t *testing.T) {
+	dir := t.TempDir()
+	defer setupSlotsTestConfig(t, slotsConfigAt(dir, ""))()
+	clipPath := useFileClipboard(t, "untouched")
+
+	src := t.TempDir()
+	first := filepath.Join(src, "first.txt")
+	second := filepath.Join(src, "second.bin")
+	if err := os.WriteFile(first, []byte("hello\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(second, []byte{0, 1, 2, 0xff}, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	out := captureOutput(func() {
+		if err := cmdPush([]string{"bundle", "-f", first, "--file", second}); err != nil {
+			t.Fatalf("push: %v", err)
+		}
+	})
+	if !strings.Contains(out, "pushed 2 file(s)") {
+		t.Errorf("push output = %q", out)
+	}
+	var payload SlotPayload
+	if err := json.Unmarshal([]byte(readFileString(t, filepath.Join(dir, "bundle.pb"))), &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.MIME != tarMIME {
+		t.Errorf("mime = %q, want %q", payload.MIME, tarMIME)
+	}
+
+	dest := filepath.Join(t.TempDir(), "out")
+	captureOutput(func() {
+		if err := cmdPull([]string{"bundle", "--extract", dest}); err != nil {
+			t.Fatalf("pull --extract: %v", err)
+		}
+	})
+	if got := readFileString(t, filepath.Join(dest, "first.txt")); got != "hello\n" {
+		t.Errorf("first.txt = %q", got)
+	}
+	if got := readFileString(t, filepath.Join(dest, "second.bin")); got != "\x00\x01\x02\xff" {
+		t.Errorf("second.bin = %q", got)
+	}
+	if got := readFileString(t, clipPath); got != "untouched" {
+		t.Errorf("clipboard = %q, extract shouldn't change it", got)
+	}
+
+	// Extracting again needs --force
+	if err := cmdPull([]string{"bundle", "--extract", dest}); err == nil {
+		t.Error("pull --extract over existing files should fail")
+	}
+	captureOutput(func() {
+		if err := cmdPull([]string{"bundle", "--extract", dest, "--force"}); err != nil {
+			t.Errorf("pull --extract --force: %v", err)
+		}
+	})
+}
+
+func TestPushArchiveDirectory(t *testing.T) {
+	dir := t.TempDir()
+	defer setupSlotsTestConfig(t, slotsConfigAt(dir, ""))()
+	useFileClipboard(t, "")
+
+	src := filepath.Join(t.TempDir(), "project")
+	if err := os.MkdirAll(filepath.Join(src, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "a.txt"), []byte("a"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "sub", "b.txt"), []byte("b"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	captureOutput(func() {
+		if err := cmdPush([]string{"tree", "--tar", src + "/"}); err != nil {
+			t.Fatalf("push --tar: %v", err)
+		}
+	})
+
+	dest := t.TempDir()
+	captureOutput(func() {
+		if err := cmdPull([]string{"tree", "--extract", dest}); err != nil {
+			t.Fatalf("pull --extract: %v", err)
+		}
+	})
+	if got := readFileString(t, filepath.Join(dest, "project", "sub", "b.txt")); got != "b" {
+		t.Errorf("project/sub/b.txt = %q", got)
+	}
+
+	// Text slots aren't archives
+	captureOutput(func() {
+		if err := cmdPush([]string{"note", "hi", "--copy"}); err != nil {
+			t.Fatalf("push --copy: %v", err)
+		}
+	})
+	if err := cmdPull([]string{"note", "--extract", dest}); err == nil || !strings.Contains(err.Error(), "not a tar archive") {
+		t.Errorf("extracting a text slot: err = %v", err)
+	}
+}
+
+func TestPushArchiveErrors(t *testing.T) {
+	src := t.TempDir()
+	a := filepath.Join(src, "a", "same.txt")
+	b := filepath.Join(src, "b", "same.txt")
+	for _, p := range []string{a, b} {
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte("x"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, _, err := buildSlotArchive([]string{a, b}, nil); err == nil || !strings.Contains(err.Error(), "both be stored") {
+		t.Errorf("duplicate names: err = %v", err)
+	}
+	if _, _, err := buildSlotArchive([]string{src}, nil); err == nil || !strings.Contains(err.Error(), "--tar") {
+		t.Errorf("-f with a directory: err = %v", err)
+	}
+	if err := cmdPush([]string{"x", "-f", a, "--copy"}); err == nil || !strings.Contains(err.Error(), "--copy") {
+		t.Errorf("-f --copy: err = %v", err)
+	}
+	if err := cmdPull([]string{"x", "--extract", src, "--rich"}); err == nil || !strings.Contains(err.Error(), "--extract") {
+		t.Errorf("--extract --rich: err = %v", err)
+	}
+}
+
+// tarOf builds an archive from raw headers, the way a hostile one might be
+func tarOf(t *testing.T, entries ...*tar.Header) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, hdr := range entries {
+		if hdr.Typeflag == tar.TypeReg {
+			hdr.Size = int64(len("pwned"))
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if hdr.Typeflag == tar.TypeReg {
+			_, _ = tw.Write([]byte("pwned"))
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestExtractSlotArchiveRejectsTraversal(t *testing.T) {
+	tests := []struct {
+		name  string
+		entry *tar.Header
+	}{
+		{"parent", &tar.Header{Name: "../escape.txt", Typeflag: tar.TypeReg, Mode: 0644}},
+		{"nested parent", &tar.Header{Name: "ok/../../escape.txt", Typeflag: tar.TypeReg, Mode: 0644}},
+		{"absolute", &tar.Header{Name: "/tmp/escape.txt", Typeflag: tar.TypeReg, Mode: 0644}},
+		{"backslashes", &tar.Header{Name: `..\escape.txt`, Typeflag: tar.TypeReg, Mode: 0644}},
+		{"symlink", &tar.Header{Name: "link", Linkname: "/etc", Typeflag: tar.TypeSymlink}},
+		{"hardlink", &tar.Header{Name: "hard", Linkname: "/etc/passwd", Typeflag: tar.TypeLink}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			dest := filepath.Join(root, "dest")
+			// A good entry first: nothing may be written when a later one is bad
+			data := tarOf(t, &tar.Header{Name: "good.txt", Typeflag: tar.TypeReg, Mode: 0644}, tt.entry)
+			if _, err := extractSlotArchive(data, dest, false); err == nil {
+				t.Fatal("expected an error")
+			}
+			if _, err := os.Stat(filepath.Join(dest, "good.txt")); !os.IsNotExist(err) {
+				t.Error("good.txt was extracted from a rejected archive")
+			}
+			if _, err := os.Stat(filepath.Join(root, "escape.txt")); !os.IsNotExist(err) {
+				t.Error("entry escaped the target directory")
+			}
+		})
+	}
+}
+
+func TestExtractSlotArchiveThroughSymlink(t *testing.T) {
+	root := t.TempDir()
+	outside := filepath.Join(root, "outside")
+	dest := filepath.Join(root, "dest")
+	if err := os.MkdirAll(outside, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(dest, "sub")); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+	data := tarOf(t, &tar.Header{Name: "sub/escape.txt", Typeflag: tar.TypeReg, Mode: 0644})
+	if _, err := extractSlotArchive(data, dest, false); err == nil || !strings.Contains(err.Error(), "symlink") {
+		t.Errorf("err = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(outside, "escape.txt")); !os.IsNotExist(err) {
+		t.Error("entry was written through the symlink")
+	}
+}
+
+func TestExtractSlotArchiveExistingFile(t *testing.T) {
+	dest := t.TempDir()
+	existing := filepath.Join(dest, "b.txt")
+	if err := os.WriteFile(existing, []byte("mine"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	data := tarOf(t,
+		&tar.Header{Name: "a.txt", Typeflag: tar.TypeReg, Mode: 0644},
+		&tar.Header{Name: "b.txt", Typeflag: tar.TypeReg, Mode: 0644})
+
+	if _, err := extractSlotArchive(data, dest, false); err == nil || !strings.Contains(err.Error(), "--force") {
+		t.Errorf("err = %v, want an already exists error", err)
+	}
+	if got, _ := os.ReadFile(existing); string(got) != "mine" {
+		t.Errorf("existing file = %q, want it untouched", got)
+	}
+	if _, err := os.Stat(filepath.Join(dest, "a.txt")); !os.IsNotExist(err) {
+		t.Error("a.txt was extracted although the archive was refused")
+	}
+
+	n, err := extractSlotArchive(data, dest, true)
+	if err != nil || n != 2 {
+		t.Fatalf("with force: n = %d, err = %v", n, err)
+	}
+	if got, _ := os.ReadFile(existing); string(got) != "pwned" {
+		t.Errorf("existing file = %q, want it overwritten", got)
+	}
+}
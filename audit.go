@@ -0,0 +1,238 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"path/filepath"
+	"time"
+)
+
+// AuditConfig enables an append-only log of slot and peer operations.
+// Unlike history it is never trimmed.
+type AuditConfig struct {
+	Enabled bool   `yaml:"enabled,omitempty"`  // log to ~/.config/pipeboard/audit.log
+	File    string `yaml:"file,omitempty"`     // log path (setting it enables the log)
+	HMACKey string `yaml:"hmac_key,omitempty"` // chain records with HMAC-SHA256 so edits are detectable
+}
+
+// AuditRecord is one line of the audit log
+type AuditRecord struct {
+	Time    time.Time `json:"time"`
+	Op      string    `json:"op"`
+	Slot    string    `json:"slot,omitempty"`
+	Peer    string    `json:"peer,omitempty"`
+	Size    int64     `json:"size"`
+	User    string    `json:"user"`
+	Outcome string    `json:"outcome"` // "ok" or "error"
+	Error   string    `json:"error,omitempty"`
+	Prev    string    `json:"prev,omitempty"` // mac of the preceding record
+	MAC     string    `json:"mac,omitempty"`
+}
+
+// auditTailSize is how much of the log end is read to find the last
+// record's mac; records are far smaller than this
+const auditTailSize = 64 << 10
+
+// auditLogPath returns the configured audit log path, or "" if auditing
+// is off
+func (a *AuditConfig) auditLogPath() string {
+	if a == nil {
+		return ""
+	}
+	if a.File != "" {
+		return a.File
+	}
+	if !a.Enabled {
+		return ""
+	}
+	configDir := os.Getenv("XDG_CONFIG_HOME")
+	if configDir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		configDir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(configDir, "pipeboard", "audit.log")
+}
+
+// recordAudit appends rec to the audit log when auditing is enabled,
+// filling in the time, user and outcome from opErr. A log that can't be
+// written is reported on stderr; it never fails the operation itself.
+func recordAudit(rec AuditRecord, opErr error) {
+	cfg, err := loadConfigForAliases()
+	if err != nil || cfg.Audit.auditLogPath() == "" {
+		return
+	}
+	rec.Time = time.Now().UTC()
+	rec.User = auditUser()
+	rec.Outcome = "ok"
+	if opErr != nil {
+		rec.Outcome = "error"
+		rec.Error = opErr.Error()
+	}
+	if err := appendAuditRecord(cfg.Audit.auditLogPath(), cfg.Audit.HMACKey, rec); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: audit log: %v\n", err)
+	}
+}
+
+// auditUser returns the local account name
+func auditUser() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+func appendAuditRecord(path, key string, rec AuditRecord) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if key != "" {
+		prev, err := lastAuditMAC(f)
+		if err != nil {
+			return err
+		}
+		rec.Prev = prev
+		if rec.MAC, err = auditMAC(key, rec); err != nil {
+			return err
+		}
+	}
+
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
+
+// auditMAC returns the hex HMAC-SHA256 of rec with its mac field empty.
+// Since rec.Prev holds the previous record's mac, each mac covers the
+// whole chain before it.
+func auditMAC(key string, rec AuditRecord) (string, error) {
+	rec.MAC = ""
+	body, err := json.Marshal(rec)
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// lastAuditMAC returns the mac of the last record in the log, or "" for
+// an empty log
+func lastAuditMAC(f *os.File) (string, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+	offset := info.Size() - auditTailSize
+	if offset < 0 {
+		offset = 0
+	}
+	tail := make([]byte, info.Size()-offset)
+	if _, err := f.ReadAt(tail, offset); err != nil && err != io.EOF {
+		return "", err
+	}
+	lines := bytes.Split(bytes.TrimRight(tail, "\n"), []byte("\n"))
+	last := lines[len(lines)-1]
+	if len(last) == 0 {
+		return "", nil
+	}
+	var rec AuditRecord
+	if err := json.Unmarshal(last, &rec); err != nil {
+		return "", fmt.Errorf("last record in %s is unreadable: %w", f.Name(), err)
+	}
+	return rec.MAC, nil
+}
+
+// verifyAuditLog checks the HMAC chain of the log at path. Records
+// written before a key was configured carry no mac and are counted
+// separately; once the chain starts, every record must extend it.
+func verifyAuditLog(path, key string, r io.Reader) (signed, unsigned int, err error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64<<10), 16<<20)
+	prev := ""
+	for n := 1; scanner.Scan(); n++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var rec AuditRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return signed, unsigned, fmt.Errorf("%s:%d: unreadable record: %w", path, n, err)
+		}
+		if rec.MAC == "" {
+			if signed > 0 {
+				return signed, unsigned, fmt.Errorf("%s:%d: record has no mac inside the chain", path, n)
+			}
+			unsigned++
+			continue
+		}
+		if rec.Prev != prev {
+			return signed, unsigned, fmt.Errorf("%s:%d: chain broken (a record was removed or reordered)", path, n)
+		}
+		want, err := auditMAC(key, rec)
+		if err != nil {
+			return signed, unsigned, err
+		}
+		if !hmac.Equal([]byte(want), []byte(rec.MAC)) {
+			return signed, unsigned, fmt.Errorf("%s:%d: mac mismatch (record modified or wrong hmac_key)", path, n)
+		}
+		prev = rec.MAC
+		signed++
+	}
+	return signed, unsigned, scanner.Err()
+}
+
+func cmdAudit(args []string) error {
+	const usage = "usage: pipeboard audit verify"
+	if len(args) != 1 || args[0] != "verify" {
+		return errors.New(usage)
+	}
+
+	cfg, err := loadConfigForAliases()
+	if err != nil {
+		return err
+	}
+	path := cfg.Audit.auditLogPath()
+	if path == "" {
+		return errors.New("audit log is not enabled (set audit.enabled or audit.file)")
+	}
+	if cfg.Audit.HMACKey == "" {
+		return errors.New("audit.hmac_key is not set; the log has no chain to verify")
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	signed, unsigned, err := verifyAuditLog(path, cfg.Audit.HMACKey, f)
+	if err != nil {
+		return err
+	}
+	if unsigned > 0 {
+		printInfo("audit log ok: %d records verified (%d earlier records unsigned)\n", signed, unsigned)
+	} else {
+		printInfo("audit log ok: %d records verified\n", signed)
+	}
+	return nil
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// readAuditLog parses the JSON-lines audit log at path
+func readAuditLog(t *testing.T, path string) []AuditRecord {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading audit log: %v", err)
+	}
+	var records []AuditRecord
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var rec AuditRecord
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatalf("audit line %q is not JSON: %v", line, err)
+		}
+		records = append(records, rec)
+	}
+	return records
+}
+
+// Test push, pull and rm each append a record with the expected fields
+func TestAuditRecordsSlotOperations(t *testing.T) {
+	useTerminalStdout(t)
+	logPath := filepath.Join(t.TempDir(), "audit.log")
+	defer setupSlotsTestConfig(t, slotsConfigAt(t.TempDir(), "audit:\n  file: "+logPath+"\n"))()
+	useFileClipboard(t, "hello audit")
+
+	captureOutput(func() {
+		if err := cmdPush([]string{"notes"}); err != nil {
+			t.Errorf("push: %v", err)
+		}
+		if err := cmdPull([]string{"notes"}); err != nil {
+			t.Errorf("pull: %v", err)
+		}
+		if err := cmdRm([]string{"notes"}); err != nil {
+			t.Errorf("rm: %v", err)
+		}
+		if err := cmdPull([]string{"missing"}); err == nil {
+			t.Error("pull of a missing slot should fail")
+		}
+	})
+
+	records := readAuditLog(t, logPath)
+	want := []struct {
+		op, slot, outcome string
+		size              int64
+	}{
+		{"push", "notes", "ok", 11},
+		{"pull", "notes", "ok", 11},
+		{"rm", "notes", "ok", 0},
+		{"pull", "missing", "error", 0},
+	}
+	if len(records) != len(want) {
+		t.Fatalf("got %d audit records, want %d: %+v", len(records), len(want), records)
+	}
+	for i, w := range want {
+		rec := records[i]
+		if rec.Op != w.op || rec.Slot != w.slot || rec.Outcome != w.outcome || rec.Size != w.size {
+			t.Errorf("record %d = %+v, want op=%s slot=%s outcome=%s size=%d", i, rec, w.op, w.slot, w.outcome, w.size)
+		}
+		if rec.User == "" || rec.Time.IsZero() {
+			t.Errorf("record %d missing user or time: %+v", i, rec)
+		}
+		if rec.MAC != "" {
+			t.Errorf("record %d has a mac without hmac_key", i)
+		}
+	}
+	if records[3].Error == "" {
+		t.Error("failed pull should record its error")
+	}
+}
+
+// Test send records the peer and size
+func TestAuditRecordsSend(t *testing.T) {
+	mockDir := t.TempDir()
+	if err := os.WriteFile(mockDir+"/ssh", []byte("#!/bin/sh\ncat >/dev/null\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", mockDir+":"+os.Getenv("PATH"))
+	logPath := filepath.Join(t.TempDir(), "audit.log")
+	defer setupPeerTestConfig(t, "version: 1\npeers:\n  dev:\n    ssh: user@host\naudit:\n  file: "+logPath+"\n")()
+	useFileClipboard(t, "to the peer")
+
+	captureOutput(func() {
+		if err := cmdSend([]string{"dev"}); err != nil {
+			t.Errorf("send: %v", err)
+		}
+		// A dry run sends nothing and isn't audited
+		if err := cmdSend([]string{"dev", "--dry-run"}); err != nil {
+			t.Errorf("send --dry-run: %v", err)
+		}
+	})
+
+	records := readAuditLog(t, logPath)
+	if len(records) != 1 {
+		t.Fatalf("got %d audit records, want 1: %+v", len(records), records)
+	}
+	if rec := records[0]; rec.Op != "send" || rec.Peer != "dev" || rec.Size != 11 || rec.Outcome != "ok" {
+		t.Errorf("send record = %+v", rec)
+	}
+}
+
+// Test nothing is written unless the audit log is enabled
+func TestAuditDisabledWritesNothing(t *testing.T) {
+	useTerminalStdout(t)
+	defer setupSlotsTestConfig(t, slotsConfigAt(t.TempDir(), ""))()
+	useFileClipboard(t, "quiet")
+
+	captureOutput(func() {
+		_ = cmdPush([]string{"notes"})
+		_ = cmdPull([]string{"notes"})
+		_ = cmdRm([]string{"notes"})
+	})
+
+	path := filepath.Join(os.Getenv("XDG_CONFIG_HOME"), "pipeboard", "audit.log")
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("audit log written while disabled (stat err: %v)", err)
+	}
+}
+
+// Test audit.enabled logs to the default path under the config dir
+func TestAuditEnabledDefaultPath(t *testing.T) {
+	defer setupSlotsTestConfig(t, slotsConfigAt(t.TempDir(), "audit:\n  enabled: true\n"))()
+	useFileClipboard(t, "x")
+
+	captureOutput(func() { _ = cmdPush([]string{"notes"}) })
+
+	path := filepath.Join(os.Getenv("XDG_CONFIG_HOME"), "pipeboard", "audit.log")
+	if records := readAuditLog(t, path); len(records) != 1 || records[0].Op != "push" {
+		t.Errorf("records = %+v", records)
+	}
+}
+
+// Test the HMAC chain verifies and detects edited or removed records
+func TestAuditHMACChain(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "audit.log")
+	defer setupSlotsTestConfig(t, slotsConfigAt(t.TempDir(), "audit:\n  file: "+logPath+"\n  hmac_key: s3cret\n"))()
+	useFileClipboard(t, "chained")
+
+	captureOutput(func() {
+		_ = cmdPush([]string{"a"})
+		_ = cmdPush([]string{"b"})
+		_ = cmdRm([]string{"a"})
+	})
+
+	records := readAuditLog(t, logPath)
+	if len(records) != 3 {
+		t.Fatalf("got %d records, want 3", len(records))
+	}
+	if records[0].Prev != "" || records[1].Prev != records[0].MAC || records[2].Prev != records[1].MAC {
+		t.Errorf("records not chained: %+v", records)
+	}
+
+	out := captureOutput(func() {
+		if err := cmdAudit([]string{"verify"}); err != nil {
+			t.Errorf("audit verify: %v", err)
+		}
+	})
+	if !strings.Contains(out, "3 records verified") {
+		t.Errorf("verify output = %q", out)
+	}
+
+	data, _ := os.ReadFile(logPath)
+	lines := strings.SplitAfter(strings.TrimSuffix(string(data), "\n"), "\n")
+
+	edited := strings.Replace(string(data), `"size":7`, `"size":8`, 1)
+	if _, _, err := verifyAuditLog(logPath, "s3cret", strings.NewReader(edited)); err == nil || !strings.Contains(err.Error(), "mac mismatch") {
+		t.Errorf("edited record: err = %v, want mac mismatch", err)
+	}
+
+	removed := lines[0] + lines[2]
+	if _, _, err := verifyAuditLog(logPath, "s3cret", strings.NewReader(removed)); err == nil || !strings.Contains(err.Error(), "chain broken") {
+		t.Errorf("removed record: err = %v, want chain broken", err)
+	}
+
+	if _, _, err := verifyAuditLog(logPath, "wrong", bytes.NewReader(data)); err == nil {
+		t.Error("wrong key should fail verification")
+	}
+}
+
+// Test records written before hmac_key was set are reported as unsigned
+func TestAuditVerifyUnsignedPrefix(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "audit.log")
+	slotDir := t.TempDir()
+	cleanup := setupSlotsTestConfig(t, slotsConfigAt(slotDir, "audit:\n  file: "+logPath+"\n"))
+	useFileClipboard(t, "x")
+	captureOutput(func() { _ = cmdPush([]string{"a"}) })
+	cleanup()
+
+	defer setupSlotsTestConfig(t, slotsConfigAt(slotDir, "audit:\n  file: "+logPath+"\n  hmac_key: k\n"))()
+	captureOutput(func() { _ = cmdPush([]string{"b"}) })
+
+	out := captureOutput(func() {
+		if err := cmdAudit([]string{"verify"}); err != nil {
+			t.Errorf("audit verify: %v", err)
+		}
+	})
+	if !strings.Contains(out, "1 records verified (1 earlier records unsigned)") {
+		t.Errorf("verify output = %q", out)
+	}
+}
+
+// Test audit verify needs an enabled log with a key
+func TestCmdAuditVerifyRequiresConfig(t *testing.T) {
+	defer setupSlotsTestConfig(t, "version: 1\n")()
+	if err := cmdAudit([]string{"verify"}); err == nil || !strings.Contains(err.Error(), "not enabled") {
+		t.Errorf("err = %v, want not enabled", err)
+	}
+	if err := cmdAudit(nil); err == nil || !strings.Contains(err.Error(), "usage") {
+		t.Errorf("err = %v, want usage", err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"runtime"
 	"strings"
 	"sync"
@@ -33,6 +34,7 @@ const (
 	BackendX11     BackendKind = "x11-xclip"
 	BackendWSL     BackendKind = "wsl-clip"
 	BackendWindows BackendKind = "windows-clip"
+	BackendTermux  BackendKind = "termux-api"
 	BackendUnknown BackendKind = "unknown"
 )
 
@@ -43,9 +45,14 @@ type Backend struct {
 	ClearCmd      []string // if empty, use CopyCmd with empty stdin
 	ImageCopyCmd  []string // for copying images (PNG)
 	ImagePasteCmd []string // for pasting images (PNG)
-	Notes         string
-	Missing       []string
-	EnvSource     string
+	// Rich text flavors keyed by MIME type (see richFlavors); FlavorsCmd
+	// lists the offered types one per line, if the platform can enumerate them
+	FlavorsCmd      []string
+	FlavorPasteCmds map[string][]string
+	FlavorCopyCmds  map[string][]string
+	Notes           string
+	Missing         []string
+	EnvSource       string
 }
 
 func detectBackend() (*Backend, error) {
@@ -59,27 +66,15 @@ func detectBackend() (*Backend, error) {
 			debugLog("detected backend: %s", b.Kind)
 		}
 		return b, err
-	case "linux":
-		// Try Wayland, then X11, then WSL-friendly
-		// Only use a backend if its required tools are available
-		if b := detectWayland(); b != nil && len(b.Missing) == 0 {
-			debugLog("detected backend: %s (env: %s)", b.Kind, b.EnvSource)
-			return b, nil
+	case "linux", "android":
+		// Go built on Termux reports android
+		order := defaultLinuxBackendOrder
+		if cfg, err := loadConfigForAliases(); err != nil {
+			debugLog("clipboard order: %v", err)
+		} else if order, err = cfg.getClipboardOrder(); err != nil {
+			return nil, err
 		}
-		if b := detectX11(); b != nil && len(b.Missing) == 0 {
-			debugLog("detected backend: %s (env: %s)", b.Kind, b.EnvSource)
-			return b, nil
-		}
-		if b := detectWSL(); b != nil && len(b.Missing) == 0 {
-			debugLog("detected backend: %s", b.Kind)
-			return b, nil
-		}
-		debugLog("no suitable backend found")
-		return &Backend{
-			Kind: BackendUnknown,
-			Notes: "No Wayland/X11/WSL clipboard command found. " +
-				"Install wl-clipboard or xclip/xsel, or configure clip.exe for WSL.",
-		}, nil
+		return detectLinux(order), nil
 	case "windows":
 		// Native Windows – try clip + powershell
 		b, err := detectWindows()
@@ -92,6 +87,81 @@ func detectBackend() (*Backend, error) {
 	}
 }
 
+// defaultLinuxBackendOrder tries the native tools first. Under WSLg they
+// talk to the Windows clipboard too, and unlike clip.exe they keep Unicode
+// intact, so clip.exe is only the fallback. Termux has no display server
+// unless one is started, so its tools come last.
+var defaultLinuxBackendOrder = []string{"wayland", "x11", "wsl", "termux"}
+
+// linuxBackendDetectors maps defaults.clipboard_order names to detectors
+var linuxBackendDetectors = map[string]func() *Backend{
+	"wayland": detectWayland,
+	"x11":     detectX11,
+	"wsl":     detectWSL,
+	"termux":  detectTermux,
+}
+
+// wslReleasePath is read to detect WSL; a variable so tests can fake it
+var wslReleasePath = "/proc/sys/kernel/osrelease"
+
+// isWSL reports whether we're running under the Windows Subsystem for Linux
+func isWSL() bool {
+	if os.Getenv("WSL_DISTRO_NAME") != "" {
+		return true
+	}
+	release, err := os.ReadFile(wslReleasePath)
+	return err == nil && strings.Contains(strings.ToLower(string(release)), "microsoft")
+}
+
+// isWSLg reports whether WSL has a display server (WSLg), so the native
+// Linux clipboard tools can reach the Windows clipboard
+func isWSLg() bool {
+	return isWSL() && (os.Getenv("WAYLAND_DISPLAY") != "" || os.Getenv("DISPLAY") != "")
+}
+
+// detectLinux returns the first backend in order whose tools are available
+func detectLinux(order []string) *Backend {
+	wslg := isWSLg()
+	for _, name := range order {
+		b := linuxBackendDetectors[name]()
+		if b == nil || len(b.Missing) > 0 {
+			continue
+		}
+		if wslg {
+			if b.Kind == BackendWSL {
+				b.Notes = "WSLg detected, but no native clipboard tools were found; clip.exe may mangle Unicode. " +
+					"Install wl-clipboard to use the native clipboard."
+			} else {
+				b.Notes = "WSLg detected: using the native Linux clipboard (clip.exe is the fallback)."
+			}
+		}
+		debugLog("detected backend: %s (env: %s)", b.Kind, b.EnvSource)
+		return b
+	}
+	debugLog("no suitable backend found")
+	if isTermux() {
+		return &Backend{
+			Kind:  BackendUnknown,
+			Notes: "Termux detected, but termux-clipboard-get/set were not found. " + installHint(BackendTermux) + ".",
+		}
+	}
+	return &Backend{
+		Kind: BackendUnknown,
+		Notes: "No Wayland/X11/WSL clipboard command found. " +
+			"Install wl-clipboard or xclip/xsel, or configure clip.exe for WSL.",
+	}
+}
+
+// isTermux reports whether we're running in Termux on Android, whose
+// PREFIX points into the app's data directory
+func isTermux() bool {
+	if strings.Contains(os.Getenv("PREFIX"), "com.termux") {
+		return true
+	}
+	path, err := exec.LookPath("termux-clipboard-get")
+	return err == nil && strings.Contains(filepath.Dir(path), "com.termux")
+}
+
 func detectDarwin() (*Backend, error) {
 	missing := []string{}
 	if !hasCmd("pbcopy") {
@@ -134,14 +204,18 @@ func detectDarwin() (*Backend, error) {
 		imageCopyCmd = []string{"impbcopy", "-"}
 	}
 
+	// pbpaste falls back to plain text when there's no RTF, and pbcopy
+	// recognizes RTF by its header; there's no way to list types
 	return &Backend{
-		Kind:          BackendDarwin,
-		CopyCmd:       []string{"pbcopy"},
-		PasteCmd:      []string{"pbpaste"},
-		ImageCopyCmd:  imageCopyCmd,
-		ImagePasteCmd: imagePasteCmd,
-		Missing:       missing,
-		Notes:         "For image support, install pngpaste and impbcopy (brew install pngpaste impbcopy)",
+		Kind:            BackendDarwin,
+		CopyCmd:         []string{"pbcopy"},
+		PasteCmd:        []string{"pbpaste"},
+		ImageCopyCmd:    imageCopyCmd,
+		ImagePasteCmd:   imagePasteCmd,
+		FlavorPasteCmds: map[string][]string{"text/rtf": {"pbpaste", "-Prefer", "rtf"}},
+		FlavorCopyCmds:  map[string][]string{"text/rtf": {"pbcopy"}},
+		Missing:         missing,
+		Notes:           "For image support, install pngpaste and impbcopy (brew install pngpaste impbcopy)",
 	}, nil
 }
 
@@ -163,8 +237,15 @@ func detectWayland() *Backend {
 		ClearCmd:      []string{"wl-copy", "--clear"},
 		ImageCopyCmd:  []string{"wl-copy", "--type", "image/png"},
 		ImagePasteCmd: []string{"wl-paste", "--type", "image/png"},
-		Missing:       missing,
-		EnvSource:     "WAYLAND_DISPLAY",
+		FlavorsCmd:    []string{"wl-paste", "--list-types"},
+		FlavorPasteCmds: flavorCmds(func(mime string) []string {
+			return []string{"wl-paste", "--type", mime}
+		}),
+		FlavorCopyCmds: flavorCmds(func(mime string) []string {
+			return []string{"wl-copy", "--type", mime}
+		}),
+		Missing:   missing,
+		EnvSource: "WAYLAND_DISPLAY",
 	}
 }
 
@@ -177,6 +258,13 @@ func detectX11() *Backend {
 	pasteCmd := []string{"xclip", "-selection", "clipboard", "-o"}
 	imageCopyCmd := []string{"xclip", "-selection", "clipboard", "-t", "image/png"}
 	imagePasteCmd := []string{"xclip", "-selection", "clipboard", "-t", "image/png", "-o"}
+	flavorsCmd := []string{"xclip", "-selection", "clipboard", "-t", "TARGETS", "-o"}
+	flavorPasteCmds := flavorCmds(func(mime string) []string {
+		return []string{"xclip", "-selection", "clipboard", "-t", mime, "-o"}
+	})
+	flavorCopyCmds := flavorCmds(func(mime string) []string {
+		return []string{"xclip", "-selection", "clipboard", "-t", mime}
+	})
 
 	if !hasCmd("xclip") {
 		if hasCmd("xsel") {
@@ -185,19 +273,25 @@ func detectX11() *Backend {
 			// xsel doesn't support images well, clear image commands
 			imageCopyCmd = nil
 			imagePasteCmd = nil
+			flavorsCmd = nil
+			flavorPasteCmds = nil
+			flavorCopyCmds = nil
 		} else {
 			missing = append(missing, "xclip/xsel")
 		}
 	}
 
 	return &Backend{
-		Kind:          BackendX11,
-		CopyCmd:       copyCmd,
-		PasteCmd:      pasteCmd,
-		ImageCopyCmd:  imageCopyCmd,
-		ImagePasteCmd: imagePasteCmd,
-		Missing:       missing,
-		EnvSource:     "DISPLAY",
+		Kind:            BackendX11,
+		CopyCmd:         copyCmd,
+		PasteCmd:        pasteCmd,
+		ImageCopyCmd:    imageCopyCmd,
+		ImagePasteCmd:   imagePasteCmd,
+		FlavorsCmd:      flavorsCmd,
+		FlavorPasteCmds: flavorPasteCmds,
+		FlavorCopyCmds:  flavorCopyCmds,
+		Missing:         missing,
+		EnvSource:       "DISPLAY",
 	}
 }
 
@@ -227,6 +321,29 @@ func detectWSL() *Backend {
 	}
 }
 
+// detectTermux uses the termux-api package's clipboard commands, which
+// reach the Android clipboard through the Termux:API app
+func detectTermux() *Backend {
+	if !isTermux() {
+		return nil
+	}
+	missing := []string{}
+	if !hasCmd("termux-clipboard-set") {
+		missing = append(missing, "termux-clipboard-set")
+	}
+	if !hasCmd("termux-clipboard-get") {
+		missing = append(missing, "termux-clipboard-get")
+	}
+	return &Backend{
+		Kind:      BackendTermux,
+		CopyCmd:   []string{"termux-clipboard-set"},
+		PasteCmd:  []string{"termux-clipboard-get"},
+		Missing:   missing,
+		EnvSource: "PREFIX",
+		Notes:     "Termux clipboard via termux-api; the Termux:API app must be installed too. Image copy/paste not supported.",
+	}
+}
+
 func detectWindows() (*Backend, error) {
 	missing := []string{}
 	if !hasCmd("clip") && !hasCmd("clip.exe") {
@@ -289,11 +406,71 @@ func installHint(kind BackendKind) string {
 		return "pbcopy/pbpaste should be available by default on macOS"
 	case BackendWSL, BackendWindows:
 		return "Ensure clip.exe and powershell.exe are in your PATH"
+	case BackendTermux:
+		return "Install the Termux:API app and run: pkg install termux-api"
 	default:
 		return "Run 'pipeboard doctor' for more information"
 	}
 }
 
+// fixPackage returns the package that provides the missing clipboard tools
+// for b, or "" when there's nothing pipeboard knows how to install. An
+// unknown Linux backend is matched to the display server it's running under.
+func fixPackage(b *Backend) string {
+	kind := b.Kind
+	if kind == BackendUnknown && runtime.GOOS == "linux" {
+		switch {
+		case os.Getenv("WAYLAND_DISPLAY") != "":
+			kind = BackendWayland
+		case os.Getenv("DISPLAY") != "":
+			kind = BackendX11
+		}
+	} else if len(b.Missing) == 0 {
+		return ""
+	}
+	switch kind {
+	case BackendWayland:
+		return "wl-clipboard"
+	case BackendX11:
+		return "xclip"
+	default:
+		return ""
+	}
+}
+
+// packageManagers are tried in order by packageInstallCmd, each with the
+// arguments that install a package without further questions
+var packageManagers = []struct {
+	name string
+	args []string
+	sudo bool
+}{
+	{"apt-get", []string{"install", "-y"}, true},
+	{"dnf", []string{"install", "-y"}, true},
+	{"yum", []string{"install", "-y"}, true},
+	{"pacman", []string{"-S", "--noconfirm"}, true},
+	{"zypper", []string{"install", "-y"}, true},
+	{"apk", []string{"add"}, true},
+	{"brew", []string{"install"}, false},
+}
+
+// packageInstallCmd returns the command that installs pkg with the first
+// package manager found in PATH, prefixed with sudo unless running as
+// root, or nil if there's no package manager pipeboard knows
+func packageInstallCmd(pkg string, root bool) []string {
+	for _, pm := range packageManagers {
+		if !hasCmd(pm.name) {
+			continue
+		}
+		argv := append(append([]string{pm.name}, pm.args...), pkg)
+		if pm.sudo && !root {
+			argv = append([]string{"sudo"}, argv...)
+		}
+		return argv
+	}
+	return nil
+}
+
 // missingToolsError returns a formatted error with installation hints
 func missingToolsError(b *Backend) error {
 	return fmt.Errorf("backend %s is missing required tools: %s\n       Hint: %s",
@@ -1,8 +1,10 @@
 package main
 
 import (
+	"bytes"
 	"crypto/rand"
 	"crypto/sha256"
+	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
@@ -154,6 +156,47 @@ func BenchmarkDecompress10KB(b *testing.B) {
 	}
 }
 
+// slotBenchmarkData is a few hundred KB of log-like text, the kind of
+// payload sync.compression is meant for
+func slotBenchmarkData() []byte {
+	var buf bytes.Buffer
+	for i := 0; buf.Len() < 300*1024; i++ {
+		fmt.Fprintf(&buf, "2025-01-02T15:04:%02dZ INFO request id=%d path=/api/v1/slots/%d status=200 bytes=%d\n", i%60, i, i%97, i*31%4096)
+	}
+	return buf.Bytes()
+}
+
+// Benchmark sync.compression algorithms on the same payload; the stored
+// size is reported as a metric
+func BenchmarkSlotCompression(b *testing.B) {
+	data := slotBenchmarkData()
+	for _, algo := range []string{compressionGzip, compressionZstd} {
+		b.Run(algo, func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			var stored []byte
+			for i := 0; i < b.N; i++ {
+				stored, _, _ = compressSlotData(data, algo)
+			}
+			b.ReportMetric(float64(len(stored)), "stored-bytes")
+		})
+	}
+}
+
+func BenchmarkSlotDecompression(b *testing.B) {
+	data := slotBenchmarkData()
+	for _, algo := range []string{compressionGzip, compressionZstd} {
+		stored, _, field := compressSlotData(data, algo)
+		b.Run(algo, func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			for i := 0; i < b.N; i++ {
+				if _, err := decompressSlotData(stored, field); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
 // Benchmark MIME detection
 func BenchmarkDetectMIME(b *testing.B) {
 	data := []byte(`{"key": "value", "number": 123}`)
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// charsetAuto selects the source charset from a BOM, the slot's recorded
+// MIME charset, or the content itself
+const charsetAuto = "auto"
+
+// transcodeToUTF8 converts slot content in charset (a name such as
+// "utf-16le" or "latin1", or "auto") to UTF-8. It returns the charset it
+// decoded from, or "" when the content was left as-is: already UTF-8, or
+// not text when detecting automatically.
+func transcodeToUTF8(data []byte, charset, mimeType string) ([]byte, string, error) {
+	if strings.EqualFold(charset, charsetAuto) {
+		if charset = detectCharset(data, mimeType); charset == "" {
+			return data, "", nil
+		}
+	}
+	enc, err := htmlindex.Get(charset)
+	if err != nil {
+		return nil, "", fmt.Errorf("unknown charset %q", charset)
+	}
+	name, err := htmlindex.Name(enc)
+	if err != nil {
+		name = strings.ToLower(charset)
+	}
+	if name == "utf-8" {
+		// Nothing to convert; just drop a byte order mark
+		return bytes.TrimPrefix(data, utf8BOM), name, nil
+	}
+
+	// A leading BOM overrides the named charset, since it's authoritative
+	out, _, err := transform.Bytes(unicode.BOMOverride(enc.NewDecoder()), data)
+	if err != nil {
+		return nil, "", fmt.Errorf("decoding %s: %w", name, err)
+	}
+	return out, name, nil
+}
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// detectCharset guesses the charset of text content, returning "" when
+// there is nothing to convert. A BOM wins, then a charset recorded in the
+// MIME type at push time. NUL-interleaved text is taken as UTF-16LE, UTF-8
+// is left alone and other text as Windows-1252 (a Latin-1 superset).
+func detectCharset(data []byte, mimeType string) string {
+	switch {
+	case bytes.HasPrefix(data, utf8BOM):
+		return "utf-8"
+	case bytes.HasPrefix(data, []byte{0xFF, 0xFE}):
+		return "utf-16le"
+	case bytes.HasPrefix(data, []byte{0xFE, 0xFF}):
+		return "utf-16be"
+	}
+
+	mediaType, params, _ := mime.ParseMediaType(mimeType)
+	if cs := params["charset"]; cs != "" && !strings.EqualFold(cs, "utf-8") {
+		if _, err := htmlindex.Get(cs); err == nil {
+			return cs
+		}
+	}
+	// ASCII in UTF-16 is valid UTF-8 too, so check for it first
+	if looksLikeUTF16LE(data) {
+		return "utf-16le"
+	}
+	if utf8.Valid(data) {
+		return ""
+	}
+	// Binary content isn't text in any charset
+	if mediaType != "" && !strings.HasPrefix(mediaType, "text/") {
+		return ""
+	}
+	return "windows-1252"
+}
+
+// looksLikeUTF16LE reports whether most high bytes are NUL, as in mostly
+// ASCII text encoded as UTF-16LE without a BOM
+func looksLikeUTF16LE(data []byte) bool {
+	if len(data) < 2 || len(data)%2 != 0 {
+		return false
+	}
+	zeros := 0
+	for i := 1; i < len(data); i += 2 {
+		if data[i] == 0 && data[i-1] != 0 {
+			zeros++
+		}
+	}
+	return zeros*4 >= len(data)/2*3
+}
+
+// applyCharset transcodes pulled slot content for --charset, returning the
+// data and its MIME type afterwards
+func applyCharset(data []byte, charset, mimeType, slot string) ([]byte, string, error) {
+	converted, from, err := transcodeToUTF8(data, charset, mimeType)
+	if err != nil {
+		return nil, "", fmt.Errorf("slot %q: %w", slot, err)
+	}
+	if from == "" {
+		debugLog("slot %q needs no charset conversion (%s)", slot, mimeType)
+		return data, mimeType, nil
+	}
+	debugLog("transcoded slot %q from %s to utf-8 (%d to %d bytes)", slot, from, len(data), len(converted))
+	return converted, detectMIME(converted), nil
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+// utf16le encodes text as UTF-16LE
+func utf16le(s string) []byte {
+	var b []byte
+	for _, c := range utf16.Encode([]rune(s)) {
+		b = append(b, byte(c), byte(c>>8))
+	}
+	return b
+}
+
+func TestTranscodeToUTF8(t *testing.T) {
+	bom := []byte{0xFF, 0xFE}
+	tests := []struct {
+		name     string
+		data     []byte
+		charset  string
+		mime     string
+		want     string
+		wantFrom string
+	}{
+		{"utf-16le bom auto", append(bom, utf16le("héllo")...), "auto", "", "", "utf-16le"},
+		{"utf-16be bom auto", []byte{0xFE, 0xFF, 0, 'h', 0, 'i'}, "auto", "", "hi", "utf-16be"},
+		{"utf-16le no bom auto", utf16le("hello world"), "auto", "application/octet-stream", "hello world", "utf-16le"},
+		{"utf-8 bom stripped", []byte("\xEF\xBB\xBFhi"), "auto", "", "hi", "utf-8"},
+		{"utf-8 untouched", []byte("café"), "auto", "text/plain; charset=utf-8", "café", ""},
+		{"latin1 auto", []byte("caf\xe9"), "auto", "text/plain; charset=utf-8", "café", "windows-1252"},
+		{"latin1 named", []byte("caf\xe9"), "latin1", "", "café", "windows-1252"},
+		{"mime charset", []byte("caf\xe9"), "auto", "text/plain; charset=iso-8859-2", "café", "iso-8859-2"},
+		{"named charset with bom", append(bom, utf16le("ok")...), "utf-16le", "", "ok", "utf-16le"},
+		{"binary untouched", []byte{0x89, 'P', 'N', 'G', 0xff}, "auto", "image/png", "\x89PNG\xff", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, from, err := transcodeToUTF8(tt.data, tt.charset, tt.mime)
+			if err != nil {
+				t.Fatalf("transcodeToUTF8: %v", err)
+			}
+			want := tt.want
+			if want == "" {
+				want = "héllo"
+			}
+			if string(got) != want || from != tt.wantFrom {
+				t.Errorf("got %q from %q, want %q from %q", got, from, want, tt.wantFrom)
+			}
+		})
+	}
+
+	if _, _, err := transcodeToUTF8([]byte("x"), "klingon", ""); err == nil || !strings.Contains(err.Error(), "unknown charset") {
+		t.Errorf("expected unknown charset error, got %v", err)
+	}
+}
+
+// Test a UTF-16LE slot pushed from Windows pulls and shows as UTF-8
+func TestCmdPullCharset(t *testing.T) {
+	useTerminalStdout(t)
+	defer setupSlotsTestConfig(t, "version: 1\nsync:\n  backend: local\n")()
+
+	backend, err := newRemoteBackendFromConfig()
+	if err != nil {
+		t.Fatalf("backend: %v", err)
+	}
+	windows := append([]byte{0xFF, 0xFE}, utf16le("line one\r\nline two\r\n")...)
+	if err := backend.Push("win", windows, map[string]string{}); err != nil {
+		t.Fatalf("push: %v", err)
+	}
+
+	clip := useFileClipboard(t, "")
+	quietMode = true
+	defer func() { quietMode = false }()
+
+	if err := cmdPull([]string{"win", "--charset", "auto"}); err != nil {
+		t.Fatalf("pull --charset auto: %v", err)
+	}
+	if got, _ := os.ReadFile(clip); string(got) != "line one\r\nline two\r\n" {
+		t.Errorf("clipboard = %q, want UTF-8 text", got)
+	}
+
+	// --lines works on the transcoded text
+	out := captureOutput(func() {
+		if err := cmdShow([]string{"win", "--charset", "utf-16le", "--lines", "2", "--no-pager"}); err != nil {
+			t.Fatalf("show --charset: %v", err)
+		}
+	})
+	if out != "line two\r\n" {
+		t.Errorf("show output = %q", out)
+	}
+
+	// Without the flag the bytes are pulled as stored
+	if err := cmdPull([]string{"win"}); err != nil {
+		t.Fatalf("pull: %v", err)
+	}
+	if got, _ := os.ReadFile(clip); string(got) != string(windows) {
+		t.Error("pull without --charset should not transcode")
+	}
+
+	if err := cmdPull([]string{"win", "--charset"}); err == nil || !strings.Contains(err.Error(), "requires") {
+		t.Errorf("expected missing value error, got %v", err)
+	}
+}
@@ -1,8 +1,13 @@
 package main
 
 import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+
+	"golang.org/x/term"
 )
 
 // ANSI color codes for terminal output
@@ -32,29 +37,81 @@ func useColor() bool {
 
 // commandHelp provides per-command help text
 var commandHelp = map[string]string{
-	"copy": `Usage: pipeboard copy [text] [--image]
+	"copy": `Usage: pipeboard copy [text...] [--image] [--image-file <path>] [--verify] [--tee]
+                      [--append|--prepend [--separator <s>]] [--sep <s>|--nul]
+                      [--encode <enc>] [--print-status [--json]] [--]
 
 Copy text or image to clipboard.
 
 Options:
   --image, -i    Copy PNG image from stdin instead of text
+  --image-file <path>
+                 Copy a PNG file as an image (other formats are rejected)
+  --verify       Read the clipboard back and warn if the backend changed
+                 the content (e.g. CRLF conversion, dropped bytes)
+  --tee          Also write the content to stdout unchanged, so copy can
+                 sit in the middle of a pipeline
+  --encode <enc> Encode the text as base64, hex, or url (percent-encoded)
+                 before copying it
+  --print-status Print "ok <bytes>" to stdout once the clipboard tool
+                 has exited 0; with --json, {"ok":true,"bytes":N}
+  --append       Add to the end of the current clipboard instead of
+                 replacing it
+  --prepend      Add to the start of the current clipboard
+  --separator <s>
+                 Text between the clipboard and the new content with
+                 --append/--prepend (default: newline)
+  --sep <s>      Join multiple text arguments with s (default: a space)
+  --nul          Join multiple text arguments with NUL bytes
+  --framed       Read a peer frame from stdin and unpack it (used by send
+                 for peers with compress or encrypt set)
+  --             Treat everything after as text, even if it looks like a flag
+
+With policy.scan_secrets set in config, text is checked for credentials
+(AWS keys, private keys, tokens) and copying warns or is blocked.
 
 Examples:
   echo "hello" | pipeboard copy     Copy text from stdin
   pipeboard copy "hello world"      Copy provided text
-  cat image.png | pipeboard copy --image`,
+  pipeboard copy --verify < f.txt   Copy and check the round-trip
+  make 2>&1 | pipeboard copy --tee | grep error
+  pipeboard copy --append "$(pwd)"  Collect snippets in one clipboard
+  pipeboard copy --sep $'\n' a b c  Copy one argument per line
+  pipeboard copy --print-status < f.txt
+  pipeboard copy -- --image         Copy the text "--image"
+  cat image.png | pipeboard copy --image
+  pipeboard copy --image-file shot.png`,
 
-	"paste": `Usage: pipeboard paste [--image]
+	"paste": `Usage: pipeboard paste [--image] [-o <path> [--force]] [--decode <enc>] [--pager|--no-pager]
 
 Paste clipboard contents to stdout.
 
+Text taller than the terminal is shown through a pager (defaults.pager,
+then $PAGER, then "less -R"). Piped output and binary content are never
+paged.
+
 Options:
   --image, -i    Paste clipboard image as PNG
+  --output, -o <path>
+                 Save the content (or PNG with --image) to a new file,
+                 readable only by you, instead of stdout
+  --force        With --output, overwrite an existing file
+  --pager        Page text even if it fits on screen
+  --no-pager     Never use the pager
+  --hash         Print sha256:<hex> of the clipboard (used by send --confirm)
+  --framed [--compress] [--encrypt]
+                 Print the clipboard as a peer frame (used by recv and peek
+                 for peers with compress or encrypt set)
+  --decode <enc> Decode base64, hex, or url (percent-encoded) content
+                 before printing it; the clipboard is left as it is
 
 Examples:
   pipeboard paste                   Print clipboard text
   pipeboard paste | jq .            Pipe to other commands
-  pipeboard paste --image > out.png`,
+  pipeboard paste --decode base64 > token.bin
+  pipeboard paste --image > out.png
+  pipeboard paste --image -o out.png
+  pipeboard paste -o notes.txt --force`,
 
 	"clear": `Usage: pipeboard clear
 
@@ -65,117 +122,491 @@ Clear the clipboard contents (best-effort, may not work on all platforms).`,
 Show the detected clipboard backend for your platform.
 Useful for debugging clipboard issues.`,
 
-	"doctor": `Usage: pipeboard doctor [--json]
+	"doctor": `Usage: pipeboard doctor [--json] [--image] [--fix]
 
 Run environment checks to verify clipboard tools are available.
-Shows detected backend, available commands, and any issues.
+Shows detected backend, available commands, and any issues, plus the
+config file in use and which PIPEBOARD_* variables override it (names
+only, never values).
 
 Options:
-  --json     Output in JSON format`,
-
-	"push": `Usage: pipeboard push <name>
+  --json     Output in JSON format
+  --image    Copy a 1x1 test PNG to the clipboard and paste it back to
+             check image support; the previous clipboard is restored
+  --fix      Install the missing clipboard tools with the system package
+             manager (apt-get, dnf, pacman, ...) after showing the exact
+             command and asking; prints the manual hint when it can't`,
+
+	"push": `Usage: pipeboard push <name> [--from-command <cmd>|--stdin]
+       pipeboard push <name> [text...] --copy
+       pipeboard push <name> -f <file> [-f <file>...] [--tar <dir>...]
+       pipeboard push --auto-name [--from-command <cmd>]
+       pipeboard push <name> --append [--separator <s>] [--from-command <cmd>|--stdin|--copy]
 
 Push current clipboard contents to a remote slot.
 
 Arguments:
   name    Slot name (e.g., "work", "snippet", "tmp")
 
+Options:
+  --auto-name   Name the slot from the current git repo and branch
+                (e.g. "webapp-feature-login"), or the directory name
+                outside a repo; adds -2, -3, ... if the name is taken
+  --from-command <cmd>
+                Run cmd with sh -c and push its stdout instead of the
+                clipboard; a non-zero exit aborts the push
+  --copy        Push text args (or stdin) instead of the clipboard, and
+                also copy it to the clipboard and clipboard history
+  --stdin       Push stdin instead of the clipboard, leaving the
+                clipboard alone (used by send --slot)
+  -f, --file <path>
+                Push files instead of the clipboard, bundled into a tar
+                archive under their base names; repeatable
+  --tar <dir>   Add a directory tree to the archive under its own name;
+                repeatable, and combines with -f. Unpack with pull --extract
+  --append      Add to the end of the slot's current content instead of
+                replacing it; a missing slot is created
+  --separator <s>
+                Text placed between the slot and the new content with
+                --append (default: newline)
+
+Pushing from the clipboard also keeps its richest text flavor (HTML,
+or RTF on macOS) when there is one; see pull --rich.
+
+With policy.scan_secrets set in config, text is checked for credentials
+before pushing; policy.on_secret chooses warn or block.
+
 Examples:
   pipeboard push work               Push to "work" slot
+  pipeboard push --auto-name        Push to "<repo>-<branch>"
+  pipeboard push pods --from-command 'kubectl get pods'
+  pipeboard push note "call back" --copy
+  git log -1 | pipeboard push commit --copy
+  pipeboard push env -f .env -f config.yaml
+  pipeboard push site --tar public/
+  pipeboard push pad --append --from-command 'date'
   pipeboard push kube && ssh server "pipeboard pull kube"`,
 
-	"pull": `Usage: pipeboard pull <name>
+	"pull": `Usage: pipeboard pull <name> [--to-clipboard|--to-stdout] [--decompress]
+                      [--charset <name|auto>] [--lines <N-M>] [--allow-empty] [--rich]
+       pipeboard pull --latest <pattern> [--to-clipboard|--to-stdout] [--decompress]
+                      [--charset <name|auto>] [--lines <N-M>] [--allow-empty] [--rich]
+       pipeboard pull <name> --extract <dir> [--force]
+       pipeboard pull <name> --output <file> [--force] [--decompress]
+                      [--charset <name|auto>] [--lines <N-M>]
+
+Pull a remote slot into the local clipboard. An empty slot is an error
+and leaves the clipboard unchanged unless --allow-empty is given.
 
-Pull a remote slot into the local clipboard.
+--to-stdout writes the slot to stdout instead, like show. With
+defaults.pull_stdout_when_piped: true in the config, that happens
+whenever stdout is not a terminal (redirected to a file or a pipe, or run
+over ssh without -t); --to-clipboard overrides it.
 
 Arguments:
   name    Slot name to pull
 
+Options:
+  --to-clipboard     Write to the clipboard even with pull_stdout_when_piped
+  --to-stdout        Write to stdout instead of the clipboard
+  --decompress, -z   Gunzip slot contents that were pushed already gzipped
+  --charset <name>   Convert text from this charset (utf-16le, latin1,
+                     shift_jis, ...) to UTF-8; "auto" detects it from a
+                     BOM, the charset recorded at push time, or the content
+  --lines <N-M>      Copy only lines N to M (1-based, inclusive) of a text
+                     slot; a range past the end is clamped with a warning
+  --latest           Treat the argument as a glob pattern and pull the
+                     most recently created matching slot
+  --allow-empty      Write the slot even if it is empty (clears the clipboard)
+  --rich             Restore the HTML or RTF flavor pushed with the slot
+                     instead of its plain text; falls back to the text when
+                     the slot has none or this clipboard can't hold it
+  --extract <dir>    Unpack an archive pushed with -f or --tar into dir
+                     instead of the clipboard; entries that would land
+                     outside dir (.., absolute paths, links) are refused
+  --output, -o <file>
+                     Write the slot to a new file (mode 0600) instead of
+                     the clipboard or stdout; byte for byte, for binary slots
+  --force            With --output or --extract, overwrite existing files
+
 Examples:
-  pipeboard pull work               Pull "work" slot to clipboard`,
+  pipeboard pull work               Pull "work" slot to clipboard
+  pipeboard pull logs --decompress  Inflate a gzipped payload
+  pipeboard pull logs --lines 10-20 Copy lines 10 through 20
+  pipeboard pull notes --charset auto  Fix text pushed as UTF-16 on Windows
+  pipeboard pull --latest 'backup-*'  Pull the newest backup slot
+  pipeboard pull env --extract .    Unpack files pushed with -f
+  pipeboard pull kube --to-stdout > kubeconfig  Save a slot to a file
+  pipeboard pull shot -o shot.png   Save a binary slot without redirection`,
+
+	"sync": `Usage: pipeboard sync push [name] [push options...]
+       pipeboard sync pull [name] [pull options...]
+
+Push the clipboard to, or pull it from, the default slot, so a slot you
+use all the time doesn't need naming. With a name, or with push
+--auto-name or pull --latest, this is plain push or pull.
+
+The default slot is defaults.slot in config; aliases apply to it. The
+first argument that isn't a flag or a flag's value is taken as the slot
+name, so push --copy text needs the name spelled out.
 
-	"show": `Usage: pipeboard show <name>
+Examples:
+  pipeboard sync push               Push the clipboard to defaults.slot
+  pipeboard sync pull               Pull defaults.slot to the clipboard
+  pipeboard sync push --from-command 'date'
+  pipeboard sync pull other         Same as pipeboard pull other`,
+
+	"show": `Usage: pipeboard show <name> [--qr [--invert]] [--meta [--json]] [--version <id>]
+                      [--charset <name|auto>] [--lines <N-M>] [--pager|--no-pager]
+       pipeboard show --versions <name>
 
 Print remote slot contents to stdout without modifying local clipboard.
+Text taller than the terminal is shown through a pager (see paste).
 
 Arguments:
   name    Slot name to show
 
+Options:
+  --qr             Render slot contents as a QR code
+  --invert         Invert QR colors (for light terminal backgrounds)
+  --meta           Print slot metadata instead of contents, including
+                   the encryption, compression and encoding it was stored with
+                   and, for slots with a TTL, when it expires
+  --json           With --meta, print the metadata as JSON
+  --versions       List stored versions of the slot (oldest first)
+  --version <id>   Show a specific stored version
+  --charset <name> Convert text to UTF-8 from a charset, or "auto" (see pull)
+  --lines <N-M>    Print only lines N to M (1-based, inclusive) of a text slot
+  --pager          Page text even if it fits on screen
+  --no-pager       Never use the pager
+
+Versions are kept when sync.versions is set in config (local and S3
+backends).
+
 Examples:
   pipeboard show work               Print slot contents
-  pipeboard show work | jq .        Pipe to other commands`,
+  pipeboard show work | jq .        Pipe to other commands
+  pipeboard show wifi --qr          Scan slot contents with a phone
+  pipeboard show --versions kube    Audit changes to a shared slot
+  pipeboard show kube --version 2 --meta
+  pipeboard show work --meta --json | jq .encrypted
+  pipeboard show logs --lines 5     Print line 5 only`,
+
+	"qr": `Usage: pipeboard qr [--invert]
+
+Render clipboard text as a QR code in the terminal.
+
+Useful for moving short secrets, URLs, or codes to a phone.
+Content is limited to 213 bytes of text.
+
+Options:
+  --invert   Invert colors (for light terminal backgrounds)
+
+Examples:
+  pipeboard qr                      Show clipboard as a QR code
+  pipeboard show wifi --qr          Show a slot as a QR code`,
+
+	"diff": `Usage: pipeboard diff <name> [other-name] [--semantic] [--format json|yaml]
+
+Compare a remote slot with the local clipboard, or two slots. Prints
+changed lines as unified hunks without context (like diff -U0).
+
+Options:
+  --semantic          For JSON/YAML content, compare structure: report
+                      added (+), removed (-) and changed (~) keys instead of
+                      line noise from reordering or reformatting. Other
+                      content falls back to the text diff.
+  --format, -f <fmt>  Parse both sides as json or yaml (implies --semantic)
 
-	"slots": `Usage: pipeboard slots [--json]
+Examples:
+  pipeboard diff kube                  Slot vs local clipboard
+  pipeboard diff kube kube-staging     Two slots
+  pipeboard diff config --semantic     Ignore key order and formatting`,
+
+	"slots": `Usage: pipeboard slots [pattern|--filter <glob>] [--sort name|size|age]
+                       [--sort-reverse] [--json|--csv|--tsv] [--wide] [--no-headers]
+
+List remote slots with size and age. A glob pattern (e.g. 'kube-*') lists
+only matching slots; on S3 the text before the first wildcard is sent as
+the listing prefix, so only candidate keys are fetched.
 
-List all remote slots with size and age.
+When any slot has a TTL (sync.ttl_days), an EXPIRES column counts down to
+its expiry ("in 2d", "expired").
 
 Options:
-  --json     Output in JSON format`,
+  --filter <glob>  Same as the pattern argument
+  --sort <key>     Order by name (A-Z), size (largest first), or age
+                   (newest first); ties are ordered by name
+  --sort-reverse   Reverse the --sort order
+  --json           Output in JSON format
+  --csv            Output as CSV (name, size, created_at, age, expires_at)
+  --tsv            Output as tab-separated values
+  --wide           Expand the name column to the terminal width
+  --no-headers     Omit the header row (for awk/cut)
+
+Filtering and sorting apply to every output format, --json included.`,
+
+	"rm": `Usage: pipeboard rm <name> [name...]
+       pipeboard rm <pattern> --keep-last <n> [--yes]
 
-	"rm": `Usage: pipeboard rm <name>
+Delete one or more remote slots.
 
-Delete a remote slot.
+With several names, each slot is deleted in turn and failures are
+reported without stopping; the command fails if any deletion failed.
 
 Arguments:
-  name    Slot name to delete`,
+  name    Slot name to delete
+
+Options:
+  --keep-last <n>   Delete the slots matching a glob pattern except the
+                    newest n, by creation time; lists them and asks first
+  --yes, -y         With --keep-last, delete without asking
+
+Examples:
+  pipeboard rm tmp
+  pipeboard rm tmp scratch old-kube
+  pipeboard rm 'backup-*' --keep-last 3`,
+
+	"verify": `Usage: pipeboard verify <name> [--explain]
+
+Check that a slot decodes cleanly: the stored encoding parses, decryption
+and decompression succeed, and the length matches the payload header.
+
+Options:
+  --explain   Show the pipeline the slot was stored with (compression,
+              encryption, encoding) and where current config differs
+
+Works with the local and S3 backends.
 
-	"send": `Usage: pipeboard send [peer]
+Examples:
+  pipeboard verify kube
+  pipeboard verify kube --explain`,
+
+	"prune": `Usage: pipeboard prune --s3-multipart [--older-than <duration>] [--dry-run]
+
+Clean up storage left behind in the S3 bucket.
+
+Incomplete multipart uploads keep their uploaded parts (and their cost)
+until they are aborted. --s3-multipart lists the incomplete uploads under
+the configured bucket and prefix and aborts those older than --older-than.
+
+Options:
+  --s3-multipart        Abort stale incomplete multipart uploads
+  --older-than <d>      Only abort uploads started more than d ago
+                        (default 24h, so uploads in progress are kept)
+  --dry-run, -n         List what would be aborted
+
+Examples:
+  pipeboard prune --s3-multipart --dry-run
+  pipeboard prune --s3-multipart --older-than 1h`,
+
+	"export": `Usage: pipeboard export [--output <file>] [--force] [--json]
+
+Back up every slot of the sync backend to a tar archive, one <slot>.json
+payload per slot. Slot data is exported exactly as stored: encrypted slots
+stay encrypted, so the backup is as protected as the bucket.
+
+Without --output the archive is written to stdout, which must not be a
+terminal. Export needs the local, s3 or gcs backend; the hosted backend
+doesn't expose stored payloads and isn't supported.
+
+Options:
+  --output, -o <file>   Write the export to file (created with mode 0600)
+  --force               Overwrite an existing output file
+  --json                Write a JSON array of slots instead of a tar
+
+Examples:
+  pipeboard export -o slots.tar
+  pipeboard export --json > slots.json`,
+
+	"import": `Usage: pipeboard import <file|-> [--force]
+
+Restore slots from an export into the sync backend, tar or JSON. Payloads
+are stored as is, without decrypting or re-encrypting them, so slots keep
+the passphrase or age keys they were pushed with. Slots that already exist
+are skipped with a warning unless --force is given. Like export, import
+needs the local, s3 or gcs backend.
+
+Options:
+  --force               Overwrite existing slots
+
+Examples:
+  pipeboard import slots.tar
+  pipeboard --profile r2 import slots.tar`,
+
+	"send": `Usage: pipeboard send [peer] [--slot <name>] [--json] [--dry-run] [--confirm]
 
 Send local clipboard directly to a peer's clipboard via SSH.
 
 Arguments:
   peer    Peer name from config (optional, uses defaults.peer if omitted)
 
+Options:
+  --json         Print {peer, bytes, mime, ok, error} instead of text
+  --dry-run, -n  Print the ssh command and payload size without sending
+  --confirm      Check the peer's clipboard hash after sending and fail if
+                 it doesn't match; peers without paste --hash are skipped
+                 with a warning
+  --slot <name>  Push to this slot on the peer (pipeboard push <name>
+                 there) instead of copying to its clipboard
+
 Examples:
   pipeboard send                    Send to default peer
-  pipeboard send devbox             Send to "devbox" peer`,
+  pipeboard send devbox             Send to "devbox" peer
+  pipeboard send devbox --confirm   Fail unless devbox stored exactly this
+  pipeboard send devbox --dry-run   Check the ssh host and remote_cmd
+  pipeboard send devbox --slot kube Store in devbox's "kube" slot`,
 
-	"recv": `Usage: pipeboard recv [peer]
+	"recv": `Usage: pipeboard recv [peer] [--slot <name>] [--yes] [--json] [--fresh] [--dry-run] [--allow-empty] [--raw]
 
-Receive peer's clipboard into local clipboard via SSH.
+Receive peer's clipboard into local clipboard via SSH. The local
+clipboard is only written once non-empty content has arrived; a failed
+or empty transfer leaves it unchanged.
+
+A clipboard fetched by peek or recv within defaults.peer_cache_ttl
+(default 5s) is reused instead of fetched again.
 
 Arguments:
-  peer    Peer name from config (optional, uses defaults.peer if omitted)`,
+  peer    Peer name from config (optional, uses defaults.peer if omitted)
 
-	"peek": `Usage: pipeboard peek [peer]
+Options:
+  --yes, -y    Skip confirmation when the peer clipboard exceeds
+               defaults.peer_warn_size (default 1 MiB)
+  --json       Print {peer, bytes, mime, ok, error} instead of text
+  --fresh      Fetch from the peer even if a recent copy is cached
+  --dry-run, -n  Print the ssh command without running it
+  --allow-empty  Write an empty peer clipboard (clears the local one)
+  --slot <name>  Fetch this slot on the peer (pipeboard show <name> there)
+                 instead of its clipboard; the cache and size check
+                 don't apply
+  --raw          Print the content to stdout instead of writing the local
+                 clipboard, as peek does; works without a clipboard backend`,
+
+	"peers": `Usage: pipeboard peers [--check [--timeout <duration>]] [--json]
+
+List configured peers. With --check, probe every peer at once over ssh
+(running 'pipeboard version' on it) and report which are reachable and
+what version they run. The default peer is marked with *.
+
+Options:
+  --check            Probe each peer over ssh
+  --timeout <dur>    Give up on a peer after this long (default: 5s)
+  --json             Output as JSON
+
+Probes use ssh with BatchMode, so a peer that would prompt for a password
+is reported unreachable instead of waiting. Fails if any peer is
+unreachable or misconfigured.
+
+Examples:
+  pipeboard peers
+  pipeboard peers --check
+  pipeboard peers --check --json | jq '.[] | select(.reachable | not)'`,
+
+	"peek": `Usage: pipeboard peek [peer] [--yes] [--json] [--fresh] [--dry-run] [--raw]
 
 Print peer's clipboard to stdout without modifying local clipboard.
 
 Arguments:
-  peer    Peer name from config (optional, uses defaults.peer if omitted)`,
+  peer    Peer name from config (optional, uses defaults.peer if omitted)
 
-	"history": `Usage: pipeboard history [--fx] [--slots] [--peer] [--local] [--json]
+Options:
+  --yes, -y    Skip confirmation when the peer clipboard exceeds
+               defaults.peer_warn_size (default 1 MiB)
+  --json       Print {peer, bytes, mime, ok, error, data_b64} with the
+               content base64-encoded
+  --fresh      Fetch from the peer even if a recent copy is cached
+  --dry-run, -n  Print the ssh command without running it
+  --raw        Print the content as is (the default; accepted to match recv)`,
+
+	"history": `Usage: pipeboard history [--fx] [--slots] [--peer] [--local] [--count <n>] [--json|--csv|--tsv] [--wide] [--no-truncate] [--no-headers]
+       pipeboard history --local --stats [--json]
+       pipeboard history --peer <name> [--search <query>] [--count <n>] [--json]
+       pipeboard history clear [--local] [--yes]
 
 Show recent clipboard operations.
 
+history clear deletes all operation history, or with --local all
+clipboard history, after asking; --yes skips the question (required when
+stdin isn't a terminal).
+
 Options:
-  --fx       Filter to fx transforms only
-  --slots    Filter to push/pull/show/rm only
-  --peer     Filter to send/recv/peek only
-  --local    Show local clipboard history (content snapshots)
-  --json     Output in JSON format
+  --fx            Filter to fx transforms only
+  --slots         Filter to push/pull/show/rm only
+  --peer          Filter to send/recv/peek only
+  --peer <name>   Show a peer's clipboard history (runs history --local over ssh)
+  --local         Show local clipboard history (content snapshots)
+  --stats         With --local, summarize entries, sizes and content types
+  --count <n>     Show only the n most recent entries; --local indices
+                  still count from the full history, so recall works on
+                  entries that aren't shown
+  --json          Output in JSON format
+  --csv           Output as CSV (time, command, target, size)
+  --tsv           Output as tab-separated values
+  --wide          Expand columns to the terminal width
+  --no-truncate   Show full previews in --local output
+  --no-headers    Omit the header row and footer hint (for awk/cut)
 
 Examples:
   pipeboard history                 Show all history
   pipeboard history --fx            Show only transforms
   pipeboard history --local         Show clipboard content history
-  pipeboard history --json          Output as JSON`,
+  pipeboard history --local --count 5  Show the last five copies
+  pipeboard history --local --stats Summarize clipboard history
+  pipeboard history --peer dev      Show clipboard history on peer "dev"
+  pipeboard history --json          Output as JSON
+  pipeboard history clear --local   Wipe clipboard history`,
 
-	"fx": `Usage: pipeboard fx <name> [name2...] [--dry-run] [--list]
+	"fx": `Usage: pipeboard fx <name> [name2...] [--dry-run] [--allow-empty] [--timeout <duration>]
+                    [--stdin] [--stdout] [--slot <name> [--to-slot <name>]]
+       pipeboard fx --list [--show-builtin] [--json]
+       pipeboard fx --check [--json]
 
-Run transforms on clipboard contents.
+Run transforms on clipboard contents, or on a stored slot.
 
 Options:
-  --dry-run    Preview output without modifying clipboard
-  --list       List available transforms from config
+  --dry-run          Preview output without modifying clipboard
+  --allow-empty      Run the next step on a step's empty output instead of
+                     stopping the chain there
+  --list             List transforms from config and the builtins
+  --show-builtin     With --list, add a SOURCE column (config or builtin)
+                     and flag config transforms that override a builtin
+  --check            Check that the command each config transform runs
+                     (cmd[0], or the first word of shell) is installed
+  --json             With --list or --check, output as JSON
+  --timeout <dur>    Kill any step that runs longer than this (e.g. 5s),
+                     overriding the transform's 'timeout' in config
+  --slot <name>      Read from a slot instead of the clipboard and write the
+                     result back to it; the clipboard is left alone
+  --to-slot <name>   With --slot, write the result to this slot instead
+  --stdin            Read the input from stdin instead of the clipboard
+  --stdout           Write the result to stdout instead of the clipboard
+                     (or slot), with nothing else printed
+
+Transforms marked 'cache: true' in config reuse their previous output
+when run again on identical input. A transform with 'on_error: passthrough'
+passes its input on unchanged (with a warning) instead of aborting.
+A step that times out leaves the clipboard or slot unchanged, and so does
+one that prints nothing: the chain stops without an error.
+
+Built-in transforms need no config or external tools: upper, lower, trim,
+base64, base64-decode, json-pretty, json-compact, url-encode, url-decode,
+hex, hex-decode.
+A transform in config with the same name overrides the builtin.
 
 Examples:
   pipeboard fx pretty-json              Format JSON in clipboard
+  pipeboard fx trim base64              Builtins, no config needed
   pipeboard fx strip-ansi pretty-json   Chain multiple transforms
   pipeboard fx uppercase --dry-run      Preview without changing clipboard
-  pipeboard fx --list                   Show available transforms`,
+  pipeboard fx pretty-json --slot raw --to-slot pretty
+                                        Transform a slot into another slot
+  cat f.json | pipeboard fx pretty-json --stdin --stdout > out.json
+                                        Use a transform in a pipe
+  pipeboard fx --list                   Show available transforms
+  pipeboard fx --list --show-builtin    Show where each transform comes from
+  pipeboard fx --check                  Find transforms whose tools are missing`,
 
-	"init": `Usage: pipeboard init
+	"init": `Usage: pipeboard init [--example]
 
 Interactive configuration wizard to set up pipeboard.
 
@@ -184,8 +615,34 @@ Creates ~/.config/pipeboard/config.yaml with:
   - Peer connections for SSH clipboard sharing
   - Example transforms for clipboard processing
 
+Options:
+  --example   Skip the wizard and write a reference config documenting
+              every option, all commented out
+
 Run this when first installing pipeboard.`,
 
+	"config": `Usage: pipeboard config show [--format yaml|json|raw]
+       pipeboard config path
+       pipeboard config example
+       pipeboard config validate [--fix]
+
+Show or check the config file.
+
+Options:
+  --format, -f <fmt>   Output format (default: yaml)
+                         yaml   Normalized YAML with secrets redacted
+                         json   JSON with secrets redacted (for tooling)
+                         raw    The file as-is with secret values masked
+  --fix                Restrict a config holding secrets to mode 0600
+                       (validate)
+
+Examples:
+  pipeboard config show                   Show config as YAML
+  pipeboard config show --format json | jq .peers
+  pipeboard config path                   Print the config file location
+  pipeboard config example                Print a commented reference config
+  pipeboard config validate               Check the config and its permissions`,
+
 	"completion": `Usage: pipeboard completion <shell>
 
 Generate shell completion scripts.
@@ -203,9 +660,15 @@ Installation:
   source <(pipeboard completion zsh)
 
   # Fish
-  pipeboard completion fish > ~/.config/fish/completions/pipeboard.fish`,
+  pipeboard completion fish > ~/.config/fish/completions/pipeboard.fish
+
+Transform names for "pipeboard fx <TAB>" are read from your config when
+you press TAB, so new transforms complete without regenerating the script.`,
 
-	"watch": `Usage: pipeboard watch [peer]
+	"watch": `Usage: pipeboard watch [peer] [--replace] [--since-last] [--debounce <duration>] [--max-rate <n>]
+                       [--to-slot-prefix <prefix>]
+       pipeboard watch --clipboard [--interval <duration>] [--max <n>]
+       pipeboard watch --status | --stop
 
 Watch and sync clipboard in real-time with a peer.
 
@@ -213,18 +676,46 @@ Monitors both local and remote clipboards, automatically syncing changes
 bidirectionally. Great for pair programming or keeping clipboards in sync
 across machines.
 
+Only one watch runs at a time; starting a second one fails.
+
+Polling slows down while both clipboards are idle (up to watch.max_interval,
+default 4s) and returns to watch.min_interval (default 500ms) on a change.
+
 Arguments:
   peer    Peer name from config (optional, uses defaults.peer if omitted)
 
+Options:
+  --replace      Stop the running watch and take over
+  --since-last   Resume from the last run: sync changes made while stopped,
+                 skip content that was already seen
+  --debounce <d> Sync a change only after it has been stable for d
+                 (e.g. 1s; overrides watch.debounce)
+  --max-rate <n> Sync at most n changes per minute (overrides watch.max_rate)
+  --to-slot-prefix <prefix>
+                 Relay mode: push each change to the peer's clipboard to a
+                 new slot <prefix>-<YYYYMMDD-HHMMSS> (UTC) instead of the
+                 local clipboard, which is never touched
+  --clipboard    Local mode: no peer; record each new clipboard value in
+                 clipboard history, as a passive clipboard manager
+  --interval <d> How often --clipboard polls (default 1s, at least 100ms)
+  --max <n>      Stop --clipboard after n recorded changes
+  --status       Show whether a watch is running
+  --stop         Stop the running watch
+
 Examples:
   pipeboard watch                    Sync with default peer
   pipeboard watch dev                Sync with "dev" peer
+  pipeboard watch --debounce 2s      Skip intermediate values of bursts
+  pipeboard watch --stop             Stop a watch running elsewhere
+  pipeboard watch dev --to-slot-prefix dev-clip
+                                     Archive dev's clipboard on a headless box
+  pipeboard watch --clipboard        Keep history of everything copied
 
 Press Ctrl+C to stop watching.`,
 
-	"recall": `Usage: pipeboard recall <index>
+	"recall": `Usage: pipeboard recall [--peer <name>] [--stdout] <index>
 
-Restore a previous clipboard entry from local history.
+Restore a previous clipboard entry from local history, or from a peer's.
 
 Use 'pipeboard history --local' to see available entries with their indices.
 Index 1 is the most recent entry.
@@ -232,10 +723,29 @@ Index 1 is the most recent entry.
 Arguments:
   index   Entry number from history (1 = most recent)
 
+Options:
+  --peer <name>   Restore from the peer's clipboard history
+                  (see 'pipeboard history --peer <name>')
+  --stdout        Print the entry instead of copying it
+
 Examples:
   pipeboard history --local          Show clipboard history
   pipeboard recall 1                 Restore most recent entry
-  pipeboard recall 3                 Restore third most recent entry`,
+  pipeboard recall 3                 Restore third most recent entry
+  pipeboard recall --peer dev 1      Copy the newest entry on peer "dev"`,
+
+	"undo": `Usage: pipeboard undo
+
+Restore the clipboard as it was before the last copy or recall replaced
+it. The replaced content is saved in turn, so running undo again redoes.
+
+Only one level is kept. Content larger than history.max_entry_bytes is
+not saved, and the snapshot is encrypted like clipboard history when sync
+encryption is configured.
+
+Examples:
+  pipeboard recall 3                 Restore an older entry
+  pipeboard undo                     Put back what was there before`,
 
 	"login": `Usage: pipeboard login
 
@@ -260,6 +770,41 @@ syncing clipboard between your devices.
 Examples:
   pipeboard signup`,
 
+	"keyring": `Usage: pipeboard keyring set
+
+Store the sync encryption passphrase in the OS keyring, so it doesn't
+have to live in config.yaml. Prompts twice on a terminal; reads the
+first line of stdin when piped.
+
+The passphrase is stored the same way as hosted login tokens:
+  - macOS: Keychain
+  - Linux/Windows: Encrypted file (~/.config/pipeboard/.tokens)
+
+Then use it from config:
+  sync:
+    encryption: aes256
+    passphrase_source: keyring
+
+Examples:
+  pipeboard keyring set`,
+
+	"audit": `Usage: pipeboard audit verify
+
+Check the audit log's HMAC chain. Each record's mac covers the record
+and the mac before it, so an edited, removed or reordered record breaks
+verification. Requires audit.hmac_key; records written before the key
+was set are reported as unsigned.
+
+The audit log is enabled in config and records every push, pull, rm and
+send (time, slot or peer, size, user, outcome) as JSON lines:
+  audit:
+    enabled: true          # ~/.config/pipeboard/audit.log
+    # file: /var/log/pipeboard/audit.log
+    hmac_key: secret
+
+Examples:
+  pipeboard audit verify`,
+
 	"logout": `Usage: pipeboard logout
 
 Clear the stored authentication token for the hosted backend.
@@ -279,6 +824,103 @@ func stdinHasData() bool {
 	return (fi.Mode() & os.ModeCharDevice) == 0
 }
 
+// stdinIsTerminal returns true if stdin is an interactive terminal.
+// It is a variable so tests can simulate a TTY.
+var stdinIsTerminal = func() bool {
+	return term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// stdoutIsTerminal returns true if stdout is an interactive terminal.
+// It is a variable so tests can simulate a TTY.
+var stdoutIsTerminal = func() bool {
+	return term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// terminalHeight returns the height of the terminal on stdout, or 0 when
+// stdout is not a terminal. It is a variable so tests can inject a height.
+var terminalHeight = func() int {
+	_, h, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil {
+		return 0
+	}
+	return h
+}
+
+// terminalWidth returns the width of the terminal on stdout, or 0 when
+// stdout is not a terminal. It is a variable so tests can inject a width.
+var terminalWidth = func() int {
+	w, _, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil {
+		return 0
+	}
+	return w
+}
+
+// tableOptions controls column layout for tabular output (slots, history)
+type tableOptions struct {
+	wide       bool // expand columns to the terminal width
+	noTruncate bool // show full previews
+	noHeaders  bool // omit the header row, for awk/cut
+	delim      rune // with --csv or --tsv, write delimited rows instead
+	limit      int  // show at most this many rows, newest first (0 = all)
+}
+
+// parseDelimitedFlag handles --csv and --tsv, reporting whether arg was one
+func (o *tableOptions) parseDelimitedFlag(arg string) bool {
+	switch arg {
+	case "--csv":
+		o.delim = ','
+	case "--tsv":
+		o.delim = '\t'
+	default:
+		return false
+	}
+	return true
+}
+
+// checkOutputFormat rejects combining --json with --csv/--tsv
+func (o tableOptions) checkOutputFormat(jsonOutput bool) error {
+	if jsonOutput && o.delim != 0 {
+		return errors.New("--json cannot be combined with --csv or --tsv")
+	}
+	return nil
+}
+
+// writeDelimited writes rows as CSV (or TSV) with standard quoting, so
+// fields containing the delimiter, quotes or newlines stay intact
+func (o tableOptions) writeDelimited(header []string, rows [][]string) error {
+	w := csv.NewWriter(os.Stdout)
+	w.Comma = o.delim
+	if !o.noHeaders {
+		if err := w.Write(header); err != nil {
+			return err
+		}
+	}
+	if err := w.WriteAll(rows); err != nil {
+		return err
+	}
+	return w.Error()
+}
+
+// columnWidth returns the width of a flexible column. In wide mode the
+// column grows from its default to fit the longest value, capped so the row
+// fits the terminal after reserved columns. Falls back to the default when
+// wide mode is off or stdout is not a terminal.
+func (o tableOptions) columnWidth(defaultWidth, longest, reserved int) int {
+	if !o.wide {
+		return defaultWidth
+	}
+	w := terminalWidth()
+	if w <= 0 {
+		return defaultWidth
+	}
+	width := max(defaultWidth, longest)
+	if avail := w - reserved; width > avail {
+		width = max(defaultWidth, avail)
+	}
+	return width
+}
+
 // hasHelpFlag checks if args contain -h or --help
 func hasHelpFlag(args []string) bool {
 	for _, arg := range args {
@@ -308,6 +950,9 @@ Usage:
 Global flags:
   --quiet, -q            Suppress informational output
   --debug                Enable debug logging
+  --compact              Print --json output on a single line
+  --profile <name>       Use ~/.config/pipeboard/profiles/<name>.yaml as the
+                         config (takes precedence over PIPEBOARD_CONFIG)
 
 Local clipboard:
   copy [text]          Copy stdin or provided text to clipboard
@@ -315,12 +960,14 @@ Local clipboard:
   paste                Paste clipboard contents to stdout
   paste --image        Paste clipboard image as PNG to stdout
   clear                Clear clipboard (best-effort)
+  qr [--invert]        Render clipboard text as a QR code
   backend              Show detected clipboard backend
   doctor [--json]      Run environment checks
 
 Transforms (programmable clipboard pipelines):
   fx <name> [name2...] Run transform(s) on clipboard (chained, in-place)
   fx <name> --dry-run  Preview output without modifying clipboard
+  fx <name> --slot <s> Transform a slot in place (--to-slot to write elsewhere)
   fx --list            List available transforms
 
   Chaining: pipeboard fx strip-ansi pretty-json
@@ -330,7 +977,9 @@ Direct peer-to-peer (SSH):
   send [peer]          Send local clipboard to peer's clipboard
   recv [peer]          Receive peer's clipboard into local clipboard
   peek [peer]          Print peer's clipboard to stdout (no local change)
+  peers [--check]      List peers; --check probes which are reachable
   watch [peer]         Real-time bidirectional clipboard sync
+  watch --status|--stop  Query or stop the running watch
                        (peer defaults to 'defaults.peer' in config)
 
 Authentication (for hosted backend):
@@ -341,9 +990,19 @@ Authentication (for hosted backend):
 Remote slots (S3, local, or hosted backend):
   push <name>          Push clipboard to remote slot
   pull <name>          Pull remote slot into clipboard
+  pull <name> --decompress  Gunzip externally gzipped slot on pull
+  sync push|pull [name]  Push or pull defaults.slot (or the named slot)
   show <name>          Print remote slot to stdout
-  slots [--json]       List remote slots
-  rm <name>            Delete remote slot
+  show <name> --qr     Render remote slot as a QR code
+  show --versions <name>  List stored versions of a slot
+  slots [pattern]      List remote slots (optionally matching a glob)
+  rm <name> [name...]  Delete remote slot(s)
+  verify <name> [--explain]  Check a slot decodes; show how it was stored
+  diff <name> [name2]  Compare a slot with the clipboard or another slot
+  diff <name> --semantic  Compare JSON/YAML by keys, not lines
+  prune --s3-multipart Abort stale incomplete S3 uploads
+  export -o <file>     Back up all slots (still encrypted) to a tar
+  import <file>        Restore slots from an export
 
 History:
   history [--json]     Show recent operations (most recent first)
@@ -351,10 +1010,17 @@ History:
   history --slots      Filter to push/pull/show/rm only
   history --peer       Filter to send/recv/peek only
   history --local      Show local clipboard history (content snapshots)
+  history --local --stats  Summarize clipboard history by size and type
   recall <index>       Restore entry from clipboard history
+  undo                 Restore the clipboard from before the last copy/recall
 
 Setup:
   init                 Interactive configuration wizard
+  config show          Show config (secrets redacted)
+  config example       Print a commented reference config
+  config validate      Check config settings and file permissions
+  keyring set          Store encryption passphrase in the OS keyring
+  audit verify         Check the audit log's HMAC chain
   completion <shell>   Generate shell completions (bash/zsh/fish)
 
 Other:
@@ -366,6 +1032,12 @@ Config: ~/.config/pipeboard/config.yaml
 
   defaults:
     peer: dev              # default peer for send/recv/peek
+    slot: scratch          # default slot for sync push/pull
+    peer_warn_size: 1048576  # confirm recv/peek above this many bytes
+    hostname: ci-runner    # origin label for pushed slots (or PIPEBOARD_HOSTNAME)
+    pager: less -R         # pager for long show/paste output (default: $PAGER)
+    clipboard_timeout: 5s  # kill a stuck clipboard tool after this long (0 = never)
+    clipboard_order: [wayland, x11, wsl, termux]  # Linux/WSL/Termux clipboard detection order
 
   peers:
     dev:
@@ -379,19 +1051,40 @@ Config: ~/.config/pipeboard/config.yaml
       shell: "sed 's/\\x1b\\[[0-9;]*m//g'"
 
   sync:
-    backend: local         # "local", "s3", or "hosted"
+    backend: local         # "local", "s3", "gcs", or "hosted"
     encryption: aes256     # client-side encryption (optional)
     passphrase: secret     # encryption passphrase
+    # passphrase_source: keyring  # use 'pipeboard keyring set' instead
+    # passphrase_cmd: pass show pipeboard  # or a command / file
+    # passphrase_file: ~/.pb-pass
+    # encryption: age      # or encrypt to age keys, with no passphrase:
+    # recipients: [age1...]
+    # identity_file: /home/me/.config/age/keys.txt
     ttl_days: 30           # auto-expire slots (optional)
+    versions: 5            # keep last N versions per slot (optional)
+    dedup: true            # store identical content once (optional)
+    encoding: base85       # smaller stored payloads than base64 (optional)
     # For S3 backend:
     # s3:
     #   bucket: my-bucket
     #   region: us-west-2
+    # For GCS backend (no versions or dedup yet):
+    # gcs:
+    #   bucket: my-bucket
+    #   credentials_file: ~/key.json  # default: application default credentials
     # For hosted backend:
     # hosted:
     #   url: https://api.pipeboard.dev
     #   email: you@example.com
 
+  policy:
+    scan_secrets: true     # detect credentials on copy/push (optional)
+    on_secret: warn        # "warn" or "block"
+
+  audit:
+    enabled: true          # log push/pull/rm/send as JSON lines (optional)
+    hmac_key: secret       # chain records for tamper evidence (optional)
+
 Examples:
   echo "hello" | pipeboard             # implicit copy
   pipeboard paste | jq .
@@ -421,6 +1114,27 @@ func printInfo(format string, args ...interface{}) {
 	fmt.Printf(format, args...)
 }
 
+// marshalJSONOutput encodes --json output: indented by default, or on a
+// single line with --compact or defaults.json_compact
+func marshalJSONOutput(v interface{}) ([]byte, error) {
+	if useCompactJSON() {
+		return json.Marshal(v)
+	}
+	return json.MarshalIndent(v, "", "  ")
+}
+
+// useCompactJSON reports whether --json output should be single-line
+func useCompactJSON() bool {
+	if compactJSON {
+		return true
+	}
+	cfg, err := loadConfigForAliases()
+	if err != nil {
+		return false
+	}
+	return cfg.Defaults != nil && cfg.Defaults.JSONCompact
+}
+
 // debugLog prints debug information (only in debug mode)
 func debugLog(format string, args ...interface{}) {
 	if !debugMode {
@@ -2,9 +2,12 @@ package main
 
 import (
 	"bytes"
-	"encoding/json"
+	"encoding/base64"
 	"errors"
 	"fmt"
+	"image"
+	"image/color"
+	"image/png"
 	"io"
 	"os"
 	"os/exec"
@@ -13,16 +16,83 @@ import (
 )
 
 func cmdCopy(args []string) error {
-	// Check for --image flag
-	imageMode := false
+	// Check for --image, --image-file, --verify, --tee, --print-status and
+	// --append/--prepend flags
+	imageMode, verify, tee := false, false, false
+	printStatus, jsonOutput, framed := false, false, false
+	var imageFile, encoding string
+	var join string // "append" or "prepend"
+	separator := "\n"
+	argSep := " " // joins text arguments
 	var filteredArgs []string
-	for _, arg := range args {
-		if arg == "--image" || arg == "-i" {
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; arg {
+		case "--":
+			// Everything after is text, even if it looks like a flag
+			filteredArgs = append(filteredArgs, args[i+1:]...)
+			i = len(args)
+		case "--sep":
+			if i+1 >= len(args) {
+				return errors.New("--sep requires a value")
+			}
+			i++
+			argSep = args[i]
+		case "--nul":
+			argSep = "\x00"
+		case "--append", "--prepend":
+			if join != "" && join != arg[2:] {
+				return errors.New("--append and --prepend cannot be combined")
+			}
+			join = arg[2:]
+		case "--separator":
+			if i+1 >= len(args) {
+				return errors.New("--separator requires a value")
+			}
+			i++
+			separator = args[i]
+		case "--image", "-i":
 			imageMode = true
-		} else {
+		case "--image-file":
+			if i+1 >= len(args) {
+				return errors.New("--image-file requires a path")
+			}
+			i++
+			imageFile = args[i]
+			imageMode = true
+		case "--verify":
+			verify = true
+		case "--tee":
+			tee = true
+		case "--encode":
+			if i+1 >= len(args) {
+				return errors.New("--encode requires an encoding (base64, hex, or url)")
+			}
+			i++
+			encoding = args[i]
+			if _, ok := contentCodecs[encoding]; !ok {
+				return fmt.Errorf("unknown encoding %q (use base64, hex, or url)", encoding)
+			}
+		case "--print-status":
+			printStatus = true
+		case "--json":
+			jsonOutput = true
+		case "--framed":
+			// A peer frame from send (see peerframe.go), not for interactive use
+			framed = true
+		default:
 			filteredArgs = append(filteredArgs, arg)
 		}
 	}
+	if jsonOutput && !printStatus {
+		return errors.New("--json requires --print-status")
+	}
+	// The status line would be mixed into the passed-through content
+	if printStatus && tee {
+		return errors.New("--print-status cannot be combined with --tee")
+	}
+	if framed && (imageMode || tee || len(filteredArgs) > 0) {
+		return errors.New("--framed reads a peer frame from stdin and cannot be combined with --image, --tee, or text arguments")
+	}
 
 	b, err := getBackend()
 	if err != nil {
@@ -36,42 +106,342 @@ func cmdCopy(args []string) error {
 		if len(b.ImageCopyCmd) == 0 {
 			return fmt.Errorf("image copy not supported on backend %s", b.Kind)
 		}
-		// For image mode, read from stdin only (no text args)
+		if verify || tee {
+			return errors.New("--verify and --tee cannot be combined with --image")
+		}
+		if encoding != "" {
+			return errors.New("--encode cannot be combined with --image")
+		}
+		if join != "" {
+			return fmt.Errorf("--%s cannot be combined with --image", join)
+		}
+		// For image mode, read from stdin or --image-file only (no text args)
 		if len(filteredArgs) > 0 {
-			return errors.New("--image mode reads PNG data from stdin, does not accept text arguments")
+			return errors.New("--image mode reads PNG data from stdin or --image-file, does not accept text arguments")
+		}
+		var data []byte
+		if imageFile != "" {
+			data, err = readImageFile(imageFile)
+		} else {
+			data, err = io.ReadAll(os.Stdin)
 		}
-		data, err := io.ReadAll(os.Stdin)
 		if err != nil {
 			return err
 		}
-		return runWithInput(b.ImageCopyCmd, data)
+		if err := runClipboardCmd(b.ImageCopyCmd, data, os.Stdout); err != nil {
+			return err
+		}
+		if printStatus {
+			return printCopyStatus(int64(len(data)), jsonOutput)
+		}
+		return nil
 	}
 
-	data, err := readInputOrArgs(filteredArgs)
+	// With --tee the content passes through to stdout, so the clipboard
+	// tool's own output goes to stderr instead
+	var teeOut io.Writer
+	toolOut := io.Writer(os.Stdout)
+	if tee {
+		teeOut, toolOut = os.Stdout, os.Stderr
+	}
+	var in *copyInput
+	if framed {
+		in, err = readFramedCopyInput()
+	} else {
+		in, err = readCopyInput(filteredArgs, argSep, teeOut)
+	}
 	if err != nil {
 		return err
 	}
+	defer in.Close()
+
+	// Encode the new content only, so --append adds an encoded snippet
+	// to what's already there
+	if encoding != "" {
+		if in.spool != nil {
+			return fmt.Errorf("--encode: input is too large to encode in memory (%s)", formatSize(in.size))
+		}
+		encoded, err := applyCodec(encoding, in.data, false)
+		if err != nil {
+			return err
+		}
+		in = &copyInput{data: encoded, size: int64(len(encoded))}
+	}
+
+	if join != "" {
+		if in.spool != nil {
+			return fmt.Errorf("--%s: input is too large to combine in memory (%s)", join, formatSize(in.size))
+		}
+		current, err := readClipboard()
+		if err != nil {
+			return fmt.Errorf("reading clipboard: %w", err)
+		}
+		combined := joinClipboard(current, in.data, separator, join == "prepend")
+		in = &copyInput{data: combined, size: int64(len(combined))}
+	}
+
+	// Spooled content is only scanned up to maxScanBytes, as in memory
+	if err := checkSecretPolicy(in.data, "copy"); err != nil {
+		return err
+	}
+
+	// Keep what's being replaced for undo
+	snapshotClipboard(in.data)
 
 	// Copy to clipboard
-	if err := runWithInput(b.CopyCmd, data); err != nil {
+	r, err := in.reader()
+	if err != nil {
 		return err
 	}
+	if err := runClipboardCmdFrom(b.CopyCmd, r, toolOut); err != nil {
+		return err
+	}
+	if printStatus {
+		if err := printCopyStatus(in.size, jsonOutput); err != nil {
+			return err
+		}
+	}
+
+	if in.spool == nil {
+		if verify {
+			verifyClipboardRoundTrip(in.data)
+		}
+		recordClipboardHistory(in.data)
+		return nil
+	}
+
+	if verify {
+		fmt.Fprintf(os.Stderr, "warning: --verify skipped: %s is too large to read back\n", formatSize(in.size))
+	}
+	// Load spooled content only if history would keep it
+	if in.size <= getHistoryConfig().maxEntryBytes() {
+		if _, err := in.spool.Seek(0, io.SeekStart); err == nil {
+			if data, err := io.ReadAll(in.spool); err == nil {
+				recordClipboardHistory(data)
+			}
+		}
+	} else {
+		debugLog("copy: %s not recorded in clipboard history", formatSize(in.size))
+	}
+	return nil
+}
+
+// readFramedCopyInput reads and unpacks the peer frame send writes to
+// copy --framed
+func readFramedCopyInput() (*copyInput, error) {
+	frame, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return nil, err
+	}
+	data, _, err := decodePeerFrame(frame, peerPassphrase)
+	if err != nil {
+		return nil, err
+	}
+	return &copyInput{data: data, size: int64(len(data))}, nil
+}
+
+// copyStatus is the success line copy --print-status writes
+type copyStatus struct {
+	OK    bool  `json:"ok"`
+	Bytes int64 `json:"bytes"`
+}
 
-	// Record to local history
-	recordClipboardHistory(data)
+// printCopyStatus reports a successful copy on stdout, as "ok <bytes>" or
+// JSON, once the clipboard tool has exited 0
+func printCopyStatus(n int64, jsonOutput bool) error {
+	if !jsonOutput {
+		fmt.Printf("ok %d\n", n)
+		return nil
+	}
+	out, err := marshalJSONOutput(copyStatus{OK: true, Bytes: n})
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(out))
 	return nil
 }
 
+// joinClipboard combines the current clipboard with new content for
+// copy --append/--prepend. The separator is left out when the clipboard
+// is empty, so the first snippet isn't preceded by it.
+func joinClipboard(current, data []byte, separator string, prepend bool) []byte {
+	if len(current) == 0 {
+		return data
+	}
+	first, second := current, data
+	if prepend {
+		first, second = data, current
+	}
+	out := make([]byte, 0, len(first)+len(separator)+len(second))
+	out = append(out, first...)
+	out = append(out, separator...)
+	return append(out, second...)
+}
+
+// readImageFile reads an image for the clipboard, rejecting files that
+// the image copy commands can't take (they all expect PNG)
+func readImageFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading image file: %w", err)
+	}
+	switch mime := detectMIME(data); {
+	case mime == "image/png":
+		return data, nil
+	case strings.HasPrefix(mime, "image/"):
+		return nil, fmt.Errorf("%s is %s; image copy supports PNG only", path, mime)
+	default:
+		return nil, fmt.Errorf("%s is not an image (detected %s)", path, mime)
+	}
+}
+
+// verifyClipboardRoundTrip reads the clipboard back after a copy and warns
+// when the backend changed the content, e.g. by converting line endings
+func verifyClipboardRoundTrip(sent []byte) {
+	got, err := readClipboard()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: could not read clipboard back to verify: %v\n", err)
+		return
+	}
+	if bytes.Equal(sent, got) {
+		debugLog("copy verified: %d bytes round-tripped", len(sent))
+		return
+	}
+	fmt.Fprintf(os.Stderr, "warning: clipboard round-trip changed the content: %s\n", describeRoundTripDiff(sent, got))
+	fmt.Fprintln(os.Stderr, "  the clipboard backend is not byte-exact; for exact bytes, base64-encode the content or use push/pull")
+}
+
+// describeRoundTripDiff names the way a clipboard backend altered content
+func describeRoundTripDiff(sent, got []byte) string {
+	switch {
+	case bytes.Equal(bytes.ReplaceAll(sent, []byte("\n"), []byte("\r\n")), got):
+		return "line endings converted to CRLF"
+	case bytes.Equal(bytes.ReplaceAll(sent, []byte("\r\n"), []byte("\n")), got):
+		return "CRLF line endings converted to LF"
+	case len(got) < len(sent) && bytes.HasPrefix(sent, got):
+		return fmt.Sprintf("trailing %d byte(s) dropped", len(sent)-len(got))
+	case len(got) > len(sent) && bytes.HasPrefix(got, sent):
+		return fmt.Sprintf("%d byte(s) appended", len(got)-len(sent))
+	case bytes.Equal(bytes.TrimRight(sent, " \t\r\n"), bytes.TrimRight(got, " \t\r\n")):
+		return "trailing whitespace changed"
+	default:
+		return fmt.Sprintf("content differs (copied %d bytes, read back %d)", len(sent), len(got))
+	}
+}
+
 func cmdPaste(args []string) error {
-	// Check for --image flag
-	imageMode := false
-	for _, arg := range args {
-		if arg == "--image" || arg == "-i" {
+	// Check for --image, --output, --force, --size, --hash, --decode, and
+	// pager flags
+	imageMode, force := false, false
+	sizeOnly := false
+	hashOnly := false
+	framed := false
+	var frameOpts peerFrameOptions
+	encryptFrame := false
+	var outputPath, decoding string
+	pager := pagerAuto
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if parsePagerFlag(arg, &pager) {
+			continue
+		}
+		switch arg {
+		case "--image", "-i":
 			imageMode = true
-		} else {
+		case "--output", "-o":
+			if i+1 >= len(args) {
+				return fmt.Errorf("%s requires a path", arg)
+			}
+			i++
+			outputPath = args[i]
+		case "--force":
+			force = true
+		case "--size":
+			sizeOnly = true
+		case "--hash":
+			hashOnly = true
+		case "--decode":
+			if i+1 >= len(args) {
+				return errors.New("--decode requires an encoding (base64, hex, or url)")
+			}
+			i++
+			decoding = args[i]
+			if _, ok := contentCodecs[decoding]; !ok {
+				return fmt.Errorf("unknown encoding %q (use base64, hex, or url)", decoding)
+			}
+		case "--framed":
+			// Peers ask for a frame when they have compress or encrypt set
+			framed = true
+		case "--compress":
+			frameOpts.compress = true
+		case "--encrypt":
+			encryptFrame = true
+		default:
 			return fmt.Errorf("unknown argument: %s", arg)
 		}
 	}
+	if force && outputPath == "" {
+		return errors.New("--force requires --output")
+	}
+	if outputPath != "" && (sizeOnly || hashOnly || framed) {
+		return errors.New("--output cannot be combined with --size, --hash, or --framed")
+	}
+	if decoding != "" && (imageMode || sizeOnly || hashOnly) {
+		return errors.New("--decode cannot be combined with --image, --size, or --hash")
+	}
+	if (frameOpts.compress || encryptFrame) && !framed {
+		return errors.New("--compress and --encrypt require --framed")
+	}
+	if framed {
+		if imageMode || sizeOnly || hashOnly || decoding != "" {
+			return errors.New("--framed cannot be combined with --image, --size, --hash, or --decode")
+		}
+		if encryptFrame {
+			pass, err := peerPassphrase()
+			if err != nil {
+				return err
+			}
+			frameOpts.passphrase = pass
+		}
+		data, err := readClipboard()
+		if err != nil {
+			return err
+		}
+		frame, err := encodePeerFrame(data, frameOpts)
+		if err != nil {
+			return err
+		}
+		_, err = os.Stdout.Write(frame)
+		return err
+	}
+
+	// Size-only mode prints the clipboard length in bytes. Peers use this
+	// as a cheap header query before transferring the full contents.
+	if sizeOnly {
+		if imageMode {
+			return errors.New("--size cannot be combined with --image")
+		}
+		data, err := readClipboard()
+		if err != nil {
+			return err
+		}
+		fmt.Println(len(data))
+		return nil
+	}
+
+	// Hash-only mode prints the clipboard's SHA-256, which send --confirm
+	// checks against what it sent
+	if hashOnly {
+		if imageMode || sizeOnly {
+			return errors.New("--hash cannot be combined with --image or --size")
+		}
+		data, err := readClipboard()
+		if err != nil {
+			return err
+		}
+		fmt.Println(clipboardDigest(data))
+		return nil
+	}
 
 	b, err := getBackend()
 	if err != nil {
@@ -85,10 +455,79 @@ func cmdPaste(args []string) error {
 		if len(b.ImagePasteCmd) == 0 {
 			return fmt.Errorf("image paste not supported on backend %s", b.Kind)
 		}
-		return runAndPipeStdout(b.ImagePasteCmd)
+		var out bytes.Buffer
+		if err := runClipboardCmd(b.ImagePasteCmd, nil, &out); err != nil {
+			return err
+		}
+		if outputPath != "" {
+			if out.Len() == 0 {
+				return errors.New("no image on clipboard")
+			}
+			if err := writeOutputFile(outputPath, out.Bytes(), force); err != nil {
+				return fmt.Errorf("writing image: %w", err)
+			}
+			printInfo("saved %s image to %s\n", formatSize(int64(out.Len())), outputPath)
+			return nil
+		}
+		_, err := os.Stdout.Write(out.Bytes())
+		return err
 	}
 
-	return runAndPipeStdout(b.PasteCmd)
+	// Read the whole clipboard first so a slow reader downstream can't
+	// trip the clipboard timeout
+	data, err := readClipboard()
+	if err != nil {
+		return err
+	}
+	if decoding != "" {
+		if data, err = applyCodec(decoding, data, true); err != nil {
+			return fmt.Errorf("--decode: %w", err)
+		}
+	}
+	if outputPath != "" {
+		if err := writeOutputFile(outputPath, data, force); err != nil {
+			return err
+		}
+		printInfo("saved %s to %s\n", formatSize(int64(len(data))), outputPath)
+		return nil
+	}
+	if pager != pagerNever && stdoutIsTerminal() {
+		return writePaged(data, pager)
+	}
+	_, err = os.Stdout.Write(data)
+	return err
+}
+
+// writeOutputFile writes data to path for paste and pull --output, readable
+// only by the user. An existing file is an error unless force is set, so a
+// typo can't clobber something else.
+func writeOutputFile(path string, data []byte, force bool) error {
+	flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if force {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+	f, err := os.OpenFile(path, flags, 0600)
+	if err != nil {
+		if os.IsExist(err) {
+			return fmt.Errorf("%s already exists (use --force to overwrite it)", path)
+		}
+		return fmt.Errorf("creating output file: %w", err)
+	}
+	// An overwritten file keeps its mode otherwise
+	if force {
+		if err := f.Chmod(0600); err != nil {
+			_ = f.Close()
+			return fmt.Errorf("setting output file permissions: %w", err)
+		}
+	}
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	return nil
 }
 
 func cmdClear(args []string) error {
@@ -104,11 +543,11 @@ func cmdClear(args []string) error {
 	}
 
 	if len(b.ClearCmd) > 0 {
-		return runCommand(b.ClearCmd...)
+		return runClipboardCmd(b.ClearCmd, nil, os.Stdout)
 	}
 
 	// Fallback: copy empty string
-	return runWithInput(b.CopyCmd, []byte{})
+	return runClipboardCmd(b.CopyCmd, []byte{}, os.Stdout)
 }
 
 func cmdBackend(args []string) error {
@@ -140,24 +579,42 @@ func cmdBackend(args []string) error {
 }
 
 func cmdDoctor(args []string) error {
-	var jsonOutput bool
+	const usage = "usage: pipeboard doctor [--json] [--image] [--fix]"
+	var jsonOutput, imageCheck, fix bool
 	for _, arg := range args {
 		switch arg {
 		case "--json":
 			jsonOutput = true
+		case "--image":
+			imageCheck = true
+		case "--fix":
+			fix = true
 		default:
-			return fmt.Errorf("unknown flag: %s\nusage: pipeboard doctor [--json]", arg)
+			return fmt.Errorf("unknown flag: %s\n%s", arg, usage)
 		}
 	}
+	if fix && jsonOutput {
+		return fmt.Errorf("--fix can't be combined with --json\n%s", usage)
+	}
 
 	b, err := getBackend()
 	if err != nil {
 		return err
 	}
+	var probe *imageProbe
+	if imageCheck {
+		p := probeImageClipboard(b)
+		probe = &p
+	}
+
+	cfgPath := configPath()
+	_, statErr := os.Stat(cfgPath)
+	cfgExists := cfgPath != "" && statErr == nil
+	envVars := activeConfigEnvVars()
 
 	if jsonOutput {
 		status := "ok"
-		if len(b.Missing) > 0 || b.Kind == BackendUnknown {
+		if len(b.Missing) > 0 || b.Kind == BackendUnknown || (probe != nil && probe.Status != "ok") {
 			status = "warning"
 		}
 		result := struct {
@@ -170,6 +627,12 @@ func cmdDoctor(args []string) error {
 			ClearCmd  []string `json:"clear_cmd,omitempty"`
 			Missing   []string `json:"missing,omitempty"`
 			Notes     string   `json:"notes,omitempty"`
+
+			Image *imageProbe `json:"image,omitempty"`
+
+			ConfigPath   string   `json:"config_path"`
+			ConfigExists bool     `json:"config_exists"`
+			EnvOverrides []string `json:"env_overrides"`
 		}{
 			OS:        runtime.GOOS,
 			Backend:   string(b.Kind),
@@ -180,8 +643,17 @@ func cmdDoctor(args []string) error {
 			ClearCmd:  b.ClearCmd,
 			Missing:   b.Missing,
 			Notes:     b.Notes,
+
+			Image: probe,
+
+			ConfigPath:   cfgPath,
+			ConfigExists: cfgExists,
+			EnvOverrides: envVars,
+		}
+		if result.EnvOverrides == nil {
+			result.EnvOverrides = []string{}
 		}
-		out, err := json.MarshalIndent(result, "", "  ")
+		out, err := marshalJSONOutput(result)
 		if err != nil {
 			return err
 		}
@@ -197,6 +669,18 @@ func cmdDoctor(args []string) error {
 		fmt.Printf("Env:      %s\n", b.EnvSource)
 	}
 
+	switch {
+	case cfgPath == "":
+		fmt.Println("Config:   (could not determine path)")
+	case cfgExists:
+		fmt.Printf("Config:   %s\n", cfgPath)
+	default:
+		fmt.Printf("Config:   %s (not found)\n", cfgPath)
+	}
+	if len(envVars) > 0 {
+		fmt.Printf("Env vars: %s\n", strings.Join(envVars, ", "))
+	}
+
 	if len(b.Missing) == 0 && b.Kind != BackendUnknown {
 		fmt.Println("\nStatus:   OK ✅")
 		fmt.Println("Details:  All required commands for this backend are available.")
@@ -210,15 +694,172 @@ func cmdDoctor(args []string) error {
 		}
 	}
 
+	if probe != nil {
+		switch probe.Status {
+		case "ok":
+			fmt.Printf("Image:    OK ✅ (%s)\n", probe.Detail)
+		case "unsupported":
+			fmt.Printf("Image:    not supported (%s)\n", probe.Detail)
+		default:
+			fmt.Printf("Image:    FAIL ❌ %s\n", probe.Detail)
+		}
+	}
+
 	fmt.Println("\nTips:")
 	fmt.Println("  - On macOS:   pbcopy / pbpaste should be available by default.")
 	fmt.Println("  - On Wayland: install `wl-clipboard` (wl-copy, wl-paste).")
 	fmt.Println("  - On X11:     install `xclip` or `xsel`.")
 	fmt.Println("  - On WSL:     ensure `clip.exe` and `powershell.exe` are in PATH.")
 
+	if fix {
+		return doctorFix(b)
+	}
 	return nil
 }
 
+// confirmInstall asks whether doctor --fix should run the install command
+var confirmInstall = func(prompt string) bool {
+	return promptYesNo(prompt, false)
+}
+
+// runInstallCmd runs a package manager command attached to the terminal,
+// so sudo can ask for a password
+var runInstallCmd = func(argv []string) error {
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// doctorFix installs the package providing the backend's missing tools
+// after the user confirms the exact command. Setups it can't handle get
+// the manual install hint instead of an error.
+func doctorFix(b *Backend) error {
+	if len(b.Missing) == 0 && b.Kind != BackendUnknown {
+		fmt.Println("\nFix:      nothing to install.")
+		return nil
+	}
+	hint := installHint(b.Kind)
+	if b.Kind == BackendUnknown && b.Notes != "" {
+		hint = b.Notes
+	}
+	pkg := fixPackage(b)
+	var argv []string
+	if pkg != "" {
+		argv = packageInstallCmd(pkg, os.Geteuid() == 0)
+	}
+	if argv == nil {
+		fmt.Println("\nFix:      can't install automatically here.")
+		fmt.Printf("Hint:     %s\n", hint)
+		return nil
+	}
+
+	fmt.Printf("\nFix:      %s\n", strings.Join(argv, " "))
+	if !confirmInstall("Run this command?") {
+		fmt.Println("Nothing installed.")
+		return nil
+	}
+	if err := runInstallCmd(argv); err != nil {
+		return fmt.Errorf("installing %s: %w\n       Hint: %s", pkg, err, hint)
+	}
+	fmt.Printf("Installed %s. Run 'pipeboard doctor' again to check.\n", pkg)
+	return nil
+}
+
+// imageProbe is what doctor --image found out about the image clipboard
+type imageProbe struct {
+	Status   string   `json:"status"` // "ok", "fail", or "unsupported"
+	Detail   string   `json:"detail,omitempty"`
+	CopyCmd  []string `json:"copy_cmd,omitempty"`
+	PasteCmd []string `json:"paste_cmd,omitempty"`
+}
+
+// probeColor fills the doctor --image test PNG, so a round trip can be
+// told apart from an image that was already on the clipboard
+var probeColor = color.NRGBA{R: 0x12, G: 0x34, B: 0x56, A: 0xff}
+
+// probeImageClipboard round-trips a 1x1 PNG through the backend's image
+// commands and then puts back what the clipboard held before
+func probeImageClipboard(b *Backend) imageProbe {
+	p := imageProbe{CopyCmd: b.ImageCopyCmd, PasteCmd: b.ImagePasteCmd}
+	switch {
+	case len(b.Missing) > 0:
+		p.Status, p.Detail = "fail", "missing "+strings.Join(b.Missing, ", ")
+		return p
+	case len(b.ImageCopyCmd) == 0 || len(b.ImagePasteCmd) == 0:
+		p.Status, p.Detail = "unsupported", fmt.Sprintf("backend %s has no image copy and paste commands", b.Kind)
+		return p
+	}
+
+	img := image.NewNRGBA(image.Rect(0, 0, 1, 1))
+	img.SetNRGBA(0, 0, probeColor)
+	var sent bytes.Buffer
+	if err := png.Encode(&sent, img); err != nil {
+		p.Status, p.Detail = "fail", fmt.Sprintf("encoding test PNG: %v", err)
+		return p
+	}
+
+	defer saveProbeClipboard(b)()
+	if err := runClipboardCmd(b.ImageCopyCmd, sent.Bytes(), os.Stdout); err != nil {
+		p.Status, p.Detail = "fail", fmt.Sprintf("copying a test PNG: %v", err)
+		return p
+	}
+	var out bytes.Buffer
+	if err := runClipboardCmd(b.ImagePasteCmd, nil, &out); err != nil {
+		p.Status, p.Detail = "fail", fmt.Sprintf("pasting the test PNG back: %v", err)
+		return p
+	}
+	if !isProbeImage(out.Bytes()) {
+		p.Status, p.Detail = "fail", fmt.Sprintf("pasted image doesn't match the test PNG (got %s, %s)", formatSize(int64(out.Len())), detectMIME(out.Bytes()))
+		return p
+	}
+	p.Status, p.Detail = "ok", "round-tripped a 1x1 PNG"
+	return p
+}
+
+// isProbeImage reports whether pasted data is the test PNG. Clipboard
+// tools may re-encode it, and some paste commands print base64, so the
+// pixel is compared rather than the bytes.
+func isProbeImage(data []byte) bool {
+	if detectMIME(data) != "image/png" {
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+		if err != nil {
+			return false
+		}
+		data = decoded
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil || img.Bounds().Dx() != 1 || img.Bounds().Dy() != 1 {
+		return false
+	}
+	return color.NRGBAModel.Convert(img.At(img.Bounds().Min.X, img.Bounds().Min.Y)) == probeColor
+}
+
+// saveProbeClipboard captures the clipboard before doctor --image
+// overwrites it, as an image if it holds one and as text otherwise, and
+// returns a func that restores it
+func saveProbeClipboard(b *Backend) func() {
+	var img bytes.Buffer
+	if err := runClipboardCmd(b.ImagePasteCmd, nil, &img); err == nil && detectMIME(img.Bytes()) == "image/png" {
+		return func() {
+			if err := runClipboardCmd(b.ImageCopyCmd, img.Bytes(), os.Stdout); err != nil {
+				fmt.Fprintf(os.Stderr, "warning: could not restore the clipboard image after the probe: %v\n", err)
+			}
+		}
+	}
+	text, err := readClipboard()
+	if err != nil {
+		debugLog("doctor --image: nothing to restore: %v", err)
+		text = nil
+	}
+	return func() {
+		if err := runClipboardCmd(b.CopyCmd, text, os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: could not restore the clipboard after the probe: %v\n", err)
+		}
+	}
+}
+
 // readClipboard reads the current local clipboard contents
 func readClipboard() ([]byte, error) {
 	b, err := getBackend()
@@ -232,11 +873,8 @@ func readClipboard() ([]byte, error) {
 		return nil, errors.New("no paste command configured")
 	}
 
-	cmd := exec.Command(b.PasteCmd[0], b.PasteCmd[1:]...)
 	var out bytes.Buffer
-	cmd.Stdout = &out
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
+	if err := runClipboardCmd(b.PasteCmd, nil, &out); err != nil {
 		return nil, fmt.Errorf("reading clipboard: %w", err)
 	}
 	return out.Bytes(), nil
@@ -244,6 +882,16 @@ func readClipboard() ([]byte, error) {
 
 // writeClipboard writes data to the local clipboard
 func writeClipboard(data []byte) error {
+	if err := checkClipboardWritable(); err != nil {
+		return err
+	}
+	b, _ := getBackend()
+	return runClipboardCmd(b.CopyCmd, data, os.Stdout)
+}
+
+// checkClipboardWritable returns why the local clipboard can't be
+// written (no backend for the platform, or its tools are missing), or nil
+func checkClipboardWritable() error {
 	b, err := getBackend()
 	if err != nil {
 		return err
@@ -251,5 +899,5 @@ func writeClipboard(data []byte) error {
 	if len(b.Missing) > 0 {
 		return missingToolsError(b)
 	}
-	return runWithInput(b.CopyCmd, data)
+	return nil
 }
@@ -2,9 +2,18 @@ package main
 
 import (
 	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/png"
 	"os"
+	"path/filepath"
+	"runtime"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 // Test readClipboard function
@@ -310,3 +319,1074 @@ func TestCmdCopyImageModeNoTextArgs(t *testing.T) {
 		}
 	}
 }
+
+// useMutatingClipboard installs a file-backed clipboard whose paste
+// command runs pasteFilter over the stored content
+func useMutatingClipboard(t *testing.T, pasteFilter string) {
+	t.Helper()
+	path := useFileClipboard(t, "")
+	cachedBackend.PasteCmd = []string{"sh", "-c", pasteFilter + " < " + path}
+}
+
+// Test copy --verify warns when the backend converts line endings
+func TestCmdCopyVerifyMismatch(t *testing.T) {
+	cleanup := setupSlotsTestConfig(t, "")
+	defer cleanup()
+
+	for _, tc := range []struct {
+		filter, want string
+	}{
+		{`sed 's/$/\r/'`, "converted to CRLF"},
+		{"head -c 4", "trailing 8 byte(s) dropped"},
+	} {
+		useMutatingClipboard(t, tc.filter)
+		var err error
+		stderr := captureStderr(func() {
+			err = cmdCopy([]string{"line1\nline2\n", "--verify"})
+		})
+		if err != nil {
+			t.Fatalf("cmdCopy --verify: %v", err)
+		}
+		if !strings.Contains(stderr, "round-trip changed the content") || !strings.Contains(stderr, tc.want) {
+			t.Errorf("%s: expected mismatch warning mentioning %q, got %q", tc.filter, tc.want, stderr)
+		}
+	}
+}
+
+// Test copy --verify is silent when the content round-trips
+func TestCmdCopyVerifyExact(t *testing.T) {
+	cleanup := setupSlotsTestConfig(t, "")
+	defer cleanup()
+	useFileClipboard(t, "")
+
+	stderr := captureStderr(func() {
+		if err := cmdCopy([]string{"exact\r\ncontent", "--verify"}); err != nil {
+			t.Errorf("cmdCopy --verify: %v", err)
+		}
+	})
+	if stderr != "" {
+		t.Errorf("expected no warning for an exact round-trip, got %q", stderr)
+	}
+
+	// Without --verify a lossy backend goes unnoticed
+	useMutatingClipboard(t, "head -c 2")
+	stderr = captureStderr(func() { _ = cmdCopy([]string{"lossy"}) })
+	if stderr != "" {
+		t.Errorf("expected no verification without --verify, got %q", stderr)
+	}
+}
+
+// Test describeRoundTripDiff names common backend quirks
+func TestDescribeRoundTripDiff(t *testing.T) {
+	for _, tc := range []struct {
+		sent, got, want string
+	}{
+		{"a\nb\n", "a\r\nb\r\n", "converted to CRLF"},
+		{"a\r\nb", "a\nb", "converted to LF"},
+		{"hello\n", "hello", "trailing 1 byte(s) dropped"},
+		{"hello", "hello\n", "1 byte(s) appended"},
+		{"hello \n", "hello\t", "trailing whitespace changed"},
+		{"hello", "world", "content differs"},
+	} {
+		if got := describeRoundTripDiff([]byte(tc.sent), []byte(tc.got)); !strings.Contains(got, tc.want) {
+			t.Errorf("describeRoundTripDiff(%q, %q) = %q, want %q", tc.sent, tc.got, got, tc.want)
+		}
+	}
+}
+
+// useClipboardTimeout sets the clipboard command timeout for one test
+func useClipboardTimeout(t *testing.T, d time.Duration) {
+	t.Helper()
+	cachedClipboardTimeoutOnce = sync.Once{}
+	cachedClipboardTimeoutOnce.Do(func() { cachedClipboardTimeout = d })
+	t.Cleanup(func() { cachedClipboardTimeoutOnce = sync.Once{} })
+}
+
+// Test a hung paste command is killed and reported as a timeout
+func TestReadClipboardTimeout(t *testing.T) {
+	useMutatingClipboard(t, "sleep 3; cat")
+	useClipboardTimeout(t, 100*time.Millisecond)
+
+	start := time.Now()
+	_, err := readClipboard()
+	if err == nil {
+		t.Fatal("expected timeout error")
+	}
+	if !strings.Contains(err.Error(), "clipboard operation timed out after 100ms") {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if !strings.Contains(err.Error(), "defaults.clipboard_timeout") {
+		t.Errorf("error should mention defaults.clipboard_timeout: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("readClipboard took %s, want it killed promptly", elapsed)
+	}
+}
+
+// Test a hung copy command times out through cmdCopy
+func TestCmdCopyTimeout(t *testing.T) {
+	cleanup := setupSlotsTestConfig(t, "")
+	defer cleanup()
+	useFileClipboard(t, "")
+	cachedBackend.CopyCmd = []string{"sleep", "3"}
+	useClipboardTimeout(t, 100*time.Millisecond)
+
+	err := cmdCopy([]string{"hello"})
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("expected timeout error, got %v", err)
+	}
+}
+
+// Test a zero timeout lets slow clipboard commands finish
+func TestClipboardTimeoutDisabled(t *testing.T) {
+	path := useFileClipboard(t, "slow")
+	cachedBackend.PasteCmd = []string{"sh", "-c", "sleep 0.2; cat " + path}
+	useClipboardTimeout(t, 0)
+
+	data, err := readClipboard()
+	if err != nil {
+		t.Fatalf("readClipboard: %v", err)
+	}
+	if string(data) != "slow" {
+		t.Errorf("got %q, want %q", data, "slow")
+	}
+}
+
+// Test defaults.clipboard_timeout is read from config
+func TestGetClipboardTimeoutFromConfig(t *testing.T) {
+	cleanup := setupSlotsTestConfig(t, "defaults:\n  clipboard_timeout: 250ms\n")
+	defer cleanup()
+	cachedClipboardTimeoutOnce = sync.Once{}
+	t.Cleanup(func() { cachedClipboardTimeoutOnce = sync.Once{} })
+
+	if got := getClipboardTimeout(); got != 250*time.Millisecond {
+		t.Errorf("getClipboardTimeout() = %s, want 250ms", got)
+	}
+
+	cfg := &Config{Defaults: &DefaultsConfig{ClipboardTimeout: "soon"}}
+	if _, err := cfg.getClipboardTimeout(); err == nil {
+		t.Error("expected error for invalid clipboard_timeout")
+	}
+	if d, _ := (&Config{}).getClipboardTimeout(); d != defaultClipboardTimeout {
+		t.Errorf("default = %s, want %s", d, defaultClipboardTimeout)
+	}
+}
+
+// Test doctor reports the active config path and overriding env vars
+func TestCmdDoctorConfigAndEnv(t *testing.T) {
+	cfgPath := filepath.Join(t.TempDir(), "custom.yaml")
+	if err := os.WriteFile(cfgPath, []byte("version: 1\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PIPEBOARD_CONFIG", cfgPath)
+	t.Setenv("PIPEBOARD_S3_BUCKET", "my-bucket")
+	t.Setenv("PIPEBOARD_PASSPHRASE", "hunter2")
+
+	out := captureOutput(func() {
+		if err := cmdDoctor(nil); err != nil {
+			t.Errorf("cmdDoctor: %v", err)
+		}
+	})
+	if !strings.Contains(out, "Config:   "+cfgPath+"\n") {
+		t.Errorf("doctor should report config path, got:\n%s", out)
+	}
+	if !strings.Contains(out, "Env vars: PIPEBOARD_CONFIG, PIPEBOARD_PASSPHRASE, PIPEBOARD_S3_BUCKET") {
+		t.Errorf("doctor should list overriding env vars, got:\n%s", out)
+	}
+	if strings.Contains(out, "hunter2") || strings.Contains(out, "my-bucket") {
+		t.Error("doctor must not print env var values")
+	}
+
+	out = captureOutput(func() {
+		if err := cmdDoctor([]string{"--json"}); err != nil {
+			t.Errorf("cmdDoctor --json: %v", err)
+		}
+	})
+	var result struct {
+		ConfigPath   string   `json:"config_path"`
+		ConfigExists bool     `json:"config_exists"`
+		EnvOverrides []string `json:"env_overrides"`
+	}
+	if err := json.Unmarshal([]byte(out), &result); err != nil {
+		t.Fatalf("decoding doctor JSON: %v\n%s", err, out)
+	}
+	if result.ConfigPath != cfgPath || !result.ConfigExists {
+		t.Errorf("config = %q (exists %v), want %q (exists true)", result.ConfigPath, result.ConfigExists, cfgPath)
+	}
+	want := []string{"PIPEBOARD_CONFIG", "PIPEBOARD_PASSPHRASE", "PIPEBOARD_S3_BUCKET"}
+	if strings.Join(result.EnvOverrides, ",") != strings.Join(want, ",") {
+		t.Errorf("env_overrides = %v, want %v", result.EnvOverrides, want)
+	}
+}
+
+// Test doctor flags a config path that doesn't exist
+func TestCmdDoctorMissingConfig(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "nope.yaml")
+	t.Setenv("PIPEBOARD_CONFIG", missing)
+
+	out := captureOutput(func() { _ = cmdDoctor(nil) })
+	if !strings.Contains(out, missing+" (not found)") {
+		t.Errorf("doctor should flag missing config, got:\n%s", out)
+	}
+}
+
+// useImageClipboard gives the file-backed clipboard image commands that
+// store to and read from a separate file, returned as the path
+func useImageClipboard(t *testing.T) string {
+	t.Helper()
+	useFileClipboard(t, "")
+	path := filepath.Join(t.TempDir(), "image")
+	cachedBackend.ImageCopyCmd = []string{"sh", "-c", "cat > " + path}
+	cachedBackend.ImagePasteCmd = []string{"cat", path}
+	return path
+}
+
+// writeTestPNG writes a 1x1 PNG and returns its path and bytes
+func writeTestPNG(t *testing.T) (string, []byte) {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "pixel.png")
+	if err := os.WriteFile(path, buf.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path, buf.Bytes()
+}
+
+// Test copy --image-file hands the file bytes to the image copy command
+func TestCmdCopyImageFile(t *testing.T) {
+	imgPath := useImageClipboard(t)
+	pngPath, pngData := writeTestPNG(t)
+
+	if err := cmdCopy([]string{"--image-file", pngPath}); err != nil {
+		t.Fatalf("cmdCopy --image-file: %v", err)
+	}
+	got, err := os.ReadFile(imgPath)
+	if err != nil {
+		t.Fatalf("reading image clipboard: %v", err)
+	}
+	if !bytes.Equal(got, pngData) {
+		t.Errorf("image copy received %d bytes, want the %d-byte PNG", len(got), len(pngData))
+	}
+}
+
+// Test copy --image-file rejects non-PNG files
+func TestCmdCopyImageFileInvalid(t *testing.T) {
+	imgPath := useImageClipboard(t)
+	dir := t.TempDir()
+	textPath := filepath.Join(dir, "notes.txt")
+	gifPath := filepath.Join(dir, "anim.gif")
+	_ = os.WriteFile(textPath, []byte("just text"), 0600)
+	_ = os.WriteFile(gifPath, []byte("GIF89a\x01\x00\x01\x00\x00\x00\x00;"), 0600)
+
+	for path, want := range map[string]string{
+		textPath:                          "is not an image",
+		gifPath:                           "supports PNG only",
+		filepath.Join(dir, "missing.png"): "reading image file",
+	} {
+		err := cmdCopy([]string{"--image-file", path})
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("cmdCopy --image-file %s: got %v, want error containing %q", filepath.Base(path), err, want)
+		}
+	}
+	if _, err := os.Stat(imgPath); !os.IsNotExist(err) {
+		t.Error("image clipboard should not be written for invalid files")
+	}
+
+	if err := cmdCopy([]string{"--image-file"}); err == nil {
+		t.Error("--image-file without a path should fail")
+	}
+}
+
+// Test paste --image -o saves the clipboard image to a file
+func TestCmdPasteImageOutput(t *testing.T) {
+	imgPath := useImageClipboard(t)
+	_, pngData := writeTestPNG(t)
+	if err := os.WriteFile(imgPath, pngData, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	outPath := filepath.Join(t.TempDir(), "out.png")
+	if err := cmdPaste([]string{"--image", "-o", outPath}); err != nil {
+		t.Fatalf("cmdPaste --image -o: %v", err)
+	}
+	got, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatalf("reading output: %v", err)
+	}
+	if !bytes.Equal(got, pngData) {
+		t.Errorf("saved %d bytes, want %d", len(got), len(pngData))
+	}
+
+	// The file now exists
+	if err := cmdPaste([]string{"--image", "-o", outPath}); err == nil || !strings.Contains(err.Error(), "--force") {
+		t.Errorf("overwriting without --force: got %v", err)
+	}
+	if err := cmdPaste([]string{"--image", "-o", outPath, "--force"}); err != nil {
+		t.Errorf("overwriting with --force: %v", err)
+	}
+}
+
+// Test paste -o writes the text clipboard to a 0600 file, refusing to
+// overwrite without --force
+func TestCmdPasteOutput(t *testing.T) {
+	useFileClipboard(t, "clipboard\x00bytes")
+	outPath := filepath.Join(t.TempDir(), "out.bin")
+
+	captureOutput(func() {
+		if err := cmdPaste([]string{"--output", outPath}); err != nil {
+			t.Errorf("paste --output: %v", err)
+		}
+	})
+	if got, _ := os.ReadFile(outPath); string(got) != "clipboard\x00bytes" {
+		t.Errorf("file = %q, want the clipboard", got)
+	}
+	if info, err := os.Stat(outPath); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("file mode = %v (%v), want 0600", info.Mode().Perm(), err)
+	}
+
+	if err := os.WriteFile(outPath, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := cmdPaste([]string{"-o", outPath}); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("expected an existing file error, got %v", err)
+	}
+	if got, _ := os.ReadFile(outPath); string(got) != "old" {
+		t.Errorf("file = %q, want it untouched", got)
+	}
+	captureOutput(func() {
+		if err := cmdPaste([]string{"-o", outPath, "--force"}); err != nil {
+			t.Errorf("paste -o --force: %v", err)
+		}
+	})
+	if got, _ := os.ReadFile(outPath); string(got) != "clipboard\x00bytes" {
+		t.Errorf("file = %q after --force, want the clipboard", got)
+	}
+	if info, _ := os.Stat(outPath); info.Mode().Perm() != 0600 {
+		t.Errorf("file mode = %v after --force, want 0600", info.Mode().Perm())
+	}
+
+	for _, args := range [][]string{
+		{"--force"},
+		{"-o", outPath, "--size"},
+		{"-o", outPath, "--hash"},
+	} {
+		if err := cmdPaste(args); err == nil {
+			t.Errorf("paste %v should fail", args)
+		}
+	}
+}
+
+// pipeStdin feeds size bytes of a repeating pattern to os.Stdin
+func pipeStdin(t *testing.T, size int) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldStdin := os.Stdin
+	os.Stdin = r
+	t.Cleanup(func() { os.Stdin = oldStdin; _ = r.Close() })
+	go func() {
+		chunk := bytes.Repeat([]byte("0123456789abcdef"), 4096)
+		for written := 0; written < size; written += len(chunk) {
+			_, _ = w.Write(chunk[:min(len(chunk), size-written)])
+		}
+		_ = w.Close()
+	}()
+}
+
+// Test copy spools large stdin to a temp file instead of buffering it
+func TestCmdCopySpoolsLargeInput(t *testing.T) {
+	defer setupSlotsTestConfig(t, "version: 1\nhistory:\n  max_entry_bytes: 1048576\n")()
+	clipPath := useFileClipboard(t, "")
+	oldThreshold := copySpoolThreshold
+	copySpoolThreshold = 256 << 10
+	defer func() { copySpoolThreshold = oldThreshold }()
+
+	const size = 16 << 20
+	pipeStdin(t, size)
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	if err := cmdCopy(nil); err != nil {
+		t.Fatalf("cmdCopy: %v", err)
+	}
+	runtime.ReadMemStats(&after)
+
+	// Well under the input size: the content itself was never buffered
+	if alloc := after.TotalAlloc - before.TotalAlloc; alloc > 8<<20 {
+		t.Errorf("copy allocated %d bytes for a %d byte stream", alloc, size)
+	}
+
+	got, err := os.ReadFile(clipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := bytes.Repeat([]byte("0123456789abcdef"), size/16)
+	if !bytes.Equal(got, want) {
+		t.Errorf("clipboard has %d bytes, want %d matching bytes", len(got), len(want))
+	}
+
+	// Above max_entry_bytes, so kept out of history
+	if _, err := os.Stat(getClipboardHistoryPath()); !os.IsNotExist(err) {
+		t.Errorf("spooled copy above max_entry_bytes was recorded in history (stat err: %v)", err)
+	}
+
+	// No spool files left behind
+	leftover, _ := filepath.Glob(filepath.Join(os.TempDir(), "pipeboard-copy-*"))
+	if len(leftover) > 0 {
+		t.Errorf("spool files not removed: %v", leftover)
+	}
+}
+
+// Test a spooled copy within max_entry_bytes is still recorded in history
+func TestCmdCopySpooledRecordsHistory(t *testing.T) {
+	defer setupSlotsTestConfig(t, "version: 1\n")()
+	useFileClipboard(t, "")
+	oldThreshold := copySpoolThreshold
+	copySpoolThreshold = 1024
+	defer func() { copySpoolThreshold = oldThreshold }()
+
+	pipeStdin(t, 64<<10)
+	if err := cmdCopy(nil); err != nil {
+		t.Fatalf("cmdCopy: %v", err)
+	}
+
+	entries := loadClipboardHistory(t)
+	if len(entries) != 1 || entries[0].Size != 64<<10 {
+		t.Errorf("history = %+v, want one 64KiB entry", entries)
+	}
+}
+
+// Test history skips content above max_entry_bytes
+func TestRecordClipboardHistoryMaxEntryBytes(t *testing.T) {
+	defer setupSlotsTestConfig(t, "version: 1\nhistory:\n  max_entry_bytes: 10\n")()
+
+	recordClipboardHistory([]byte("short"))
+	recordClipboardHistory([]byte("much longer than ten bytes"))
+
+	entries := loadClipboardHistory(t)
+	if len(entries) != 1 || entries[0].Size != 5 {
+		t.Errorf("history = %+v, want only the short entry", entries)
+	}
+}
+
+// loadClipboardHistory reads the clipboard history file
+func loadClipboardHistory(t *testing.T) []ClipboardHistoryEntry {
+	t.Helper()
+	data, err := os.ReadFile(getClipboardHistoryPath())
+	if err != nil {
+		t.Fatalf("reading clipboard history: %v", err)
+	}
+	var entries []ClipboardHistoryEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		t.Fatal(err)
+	}
+	return entries
+}
+
+// Test copy --tee passes stdin through to stdout byte for byte
+func TestCmdCopyTee(t *testing.T) {
+	defer setupSlotsTestConfig(t, "version: 1\n")()
+	clipPath := useFileClipboard(t, "")
+
+	content := []byte("line one\r\nbinary \x00\xff bytes\n\nno trailing newline")
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldStdin := os.Stdin
+	os.Stdin = r
+	defer func() { os.Stdin = oldStdin }()
+	go func() {
+		_, _ = w.Write(content)
+		_ = w.Close()
+	}()
+
+	out := captureOutput(func() {
+		if err := cmdCopy([]string{"--tee"}); err != nil {
+			t.Errorf("cmdCopy --tee: %v", err)
+		}
+	})
+	if out != string(content) {
+		t.Errorf("stdout = %q, want %q", out, content)
+	}
+	if got, _ := os.ReadFile(clipPath); !bytes.Equal(got, content) {
+		t.Errorf("clipboard = %q, want %q", got, content)
+	}
+	if entries := loadClipboardHistory(t); len(entries) != 1 || entries[0].Size != int64(len(content)) {
+		t.Errorf("history = %+v, want the teed content", entries)
+	}
+
+	// Text arguments are echoed too
+	out = captureOutput(func() { _ = cmdCopy([]string{"hello", "--tee"}) })
+	if out != "hello" {
+		t.Errorf("stdout = %q, want hello", out)
+	}
+}
+
+// Test --tee streams spooled input through unchanged
+func TestCmdCopyTeeSpooled(t *testing.T) {
+	defer setupSlotsTestConfig(t, "version: 1\n")()
+	clipPath := useFileClipboard(t, "")
+	oldThreshold := copySpoolThreshold
+	copySpoolThreshold = 4096
+	defer func() { copySpoolThreshold = oldThreshold }()
+
+	const size = 1 << 20
+	pipeStdin(t, size)
+	outFile, err := os.Create(filepath.Join(t.TempDir(), "stdout"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldStdout := os.Stdout
+	os.Stdout = outFile
+	err = cmdCopy([]string{"--tee"})
+	os.Stdout = oldStdout
+	_ = outFile.Close()
+	if err != nil {
+		t.Fatalf("cmdCopy --tee: %v", err)
+	}
+
+	want := bytes.Repeat([]byte("0123456789abcdef"), size/16)
+	if got, _ := os.ReadFile(outFile.Name()); !bytes.Equal(got, want) {
+		t.Errorf("stdout has %d bytes, want %d matching bytes", len(got), len(want))
+	}
+	if got, _ := os.ReadFile(clipPath); !bytes.Equal(got, want) {
+		t.Errorf("clipboard has %d bytes, want %d matching bytes", len(got), len(want))
+	}
+}
+
+// Test copy --append and --prepend join new content onto the clipboard
+func TestCmdCopyAppendPrepend(t *testing.T) {
+	defer setupSlotsTestConfig(t, "version: 1\n")()
+	clipPath := useFileClipboard(t, "first")
+
+	steps := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"second", "--append"}, "first\nsecond"},
+		{[]string{"zero", "--prepend", "--separator", " | "}, "zero | first\nsecond"},
+		{[]string{"--append", "--separator", "", "!"}, "zero | first\nsecond!"},
+	}
+	for _, s := range steps {
+		captureOutput(func() {
+			if err := cmdCopy(s.args); err != nil {
+				t.Fatalf("copy %v: %v", s.args, err)
+			}
+		})
+		if got, _ := os.ReadFile(clipPath); string(got) != s.want {
+			t.Errorf("after copy %v clipboard = %q, want %q", s.args, got, s.want)
+		}
+	}
+
+	// The combined content is what history records
+	entries := loadClipboardHistory(t)
+	if len(entries) == 0 || entries[len(entries)-1].Size != int64(len("zero | first\nsecond!")) {
+		t.Errorf("history = %+v, want the combined content last", entries)
+	}
+}
+
+// Test appending to an empty clipboard adds no leading separator
+func TestCmdCopyAppendEmptyClipboard(t *testing.T) {
+	defer setupSlotsTestConfig(t, "version: 1\n")()
+	clipPath := useFileClipboard(t, "")
+
+	captureOutput(func() {
+		if err := cmdCopy([]string{"only", "--append"}); err != nil {
+			t.Fatalf("copy --append: %v", err)
+		}
+	})
+	if got, _ := os.ReadFile(clipPath); string(got) != "only" {
+		t.Errorf("clipboard = %q, want only", got)
+	}
+}
+
+// Test --append/--prepend reject image mode and each other
+func TestCmdCopyAppendFlagErrors(t *testing.T) {
+	defer setupSlotsTestConfig(t, "version: 1\n")()
+	useFileClipboard(t, "x")
+	cachedBackend.ImageCopyCmd = []string{"true"}
+
+	if err := cmdCopy([]string{"--image", "--append"}); err == nil || !strings.Contains(err.Error(), "--append cannot be combined with --image") {
+		t.Errorf("err = %v, want image conflict", err)
+	}
+	if err := cmdCopy([]string{"a", "--append", "--prepend"}); err == nil || !strings.Contains(err.Error(), "cannot be combined") {
+		t.Errorf("err = %v, want append/prepend conflict", err)
+	}
+	if err := cmdCopy([]string{"a", "--separator"}); err == nil || !strings.Contains(err.Error(), "requires a value") {
+		t.Errorf("err = %v, want missing separator", err)
+	}
+}
+
+// Test --sep and --nul control how text arguments are joined
+func TestCmdCopyArgSeparator(t *testing.T) {
+	defer setupSlotsTestConfig(t, "version: 1\n")()
+	clipPath := useFileClipboard(t, "")
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"a", "b", "c"}, "a b c"},
+		{[]string{"--sep", "\n", "a", "b", "c"}, "a\nb\nc"},
+		{[]string{"a", "b", "--sep", ""}, "ab"},
+		{[]string{"--nul", "a b", "c"}, "a b\x00c"},
+	}
+	for _, tt := range tests {
+		captureOutput(func() {
+			if err := cmdCopy(tt.args); err != nil {
+				t.Fatalf("copy %q: %v", tt.args, err)
+			}
+		})
+		if got, _ := os.ReadFile(clipPath); string(got) != tt.want {
+			t.Errorf("copy %q: clipboard = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+
+	if err := cmdCopy([]string{"a", "--sep"}); err == nil || !strings.Contains(err.Error(), "--sep requires a value") {
+		t.Errorf("err = %v, want missing --sep value", err)
+	}
+}
+
+// Test -- ends flag parsing, so flag-like text is copied literally
+func TestCmdCopyDoubleDash(t *testing.T) {
+	defer setupSlotsTestConfig(t, "version: 1\n")()
+	clipPath := useFileClipboard(t, "")
+	// Image mode would fail: no image copy command is configured
+	cachedBackend.ImageCopyCmd = nil
+
+	captureOutput(func() {
+		if err := cmdCopy([]string{"--sep", ",", "--", "--image", "--", "-i"}); err != nil {
+			t.Fatalf("copy -- --image: %v", err)
+		}
+	})
+	if got, _ := os.ReadFile(clipPath); string(got) != "--image,--,-i" {
+		t.Errorf("clipboard = %q, want the literal arguments", got)
+	}
+}
+
+// Test --print-status reports the byte count only on request and only
+// after the clipboard tool succeeds
+func TestCmdCopyPrintStatus(t *testing.T) {
+	defer setupSlotsTestConfig(t, "version: 1\n")()
+	clipPath := useFileClipboard(t, "")
+
+	out := captureOutput(func() {
+		if err := cmdCopy([]string{"hello world"}); err != nil {
+			t.Fatalf("copy: %v", err)
+		}
+	})
+	if out != "" {
+		t.Errorf("stdout without --print-status = %q, want nothing", out)
+	}
+
+	out = captureOutput(func() {
+		if err := cmdCopy([]string{"hello world", "--print-status"}); err != nil {
+			t.Fatalf("copy --print-status: %v", err)
+		}
+	})
+	if out != "ok 11\n" {
+		t.Errorf("stdout = %q, want %q", out, "ok 11\n")
+	}
+	if got, _ := os.ReadFile(clipPath); string(got) != "hello world" {
+		t.Errorf("clipboard = %q, status line leaked into the content", got)
+	}
+
+	out = captureOutput(func() {
+		if err := cmdCopy([]string{"héllo", "--print-status", "--json"}); err != nil {
+			t.Fatalf("copy --print-status --json: %v", err)
+		}
+	})
+	var status copyStatus
+	if err := json.Unmarshal([]byte(out), &status); err != nil || !status.OK || status.Bytes != 6 {
+		t.Errorf("json status = %q (%v)", out, err)
+	}
+
+	// A failing clipboard tool prints no status
+	cachedBackend.CopyCmd = []string{"false"}
+	out = captureOutput(func() {
+		if err := cmdCopy([]string{"lost", "--print-status"}); err == nil {
+			t.Error("expected the copy to fail")
+		}
+	})
+	if out != "" {
+		t.Errorf("stdout after a failed copy = %q", out)
+	}
+
+	if err := cmdCopy([]string{"x", "--json"}); err == nil || !strings.Contains(err.Error(), "requires --print-status") {
+		t.Errorf("--json alone: err = %v", err)
+	}
+	if err := cmdCopy([]string{"x", "--print-status", "--tee"}); err == nil || !strings.Contains(err.Error(), "--tee") {
+		t.Errorf("--print-status --tee: err = %v", err)
+	}
+}
+
+// useMockWayland puts fake wl-copy/wl-paste on PATH that keep the text
+// and image/png flavors in files under dir, and forces backend detection
+// to pick them up. pasteImage overrides what wl-paste --type image/png prints.
+func useMockWayland(t *testing.T, pasteImage string) (textPath, imagePath string) {
+	t.Helper()
+	if runtime.GOOS != "linux" {
+		t.Skip("wayland backend is detected on linux only")
+	}
+	dir := t.TempDir()
+	textPath = filepath.Join(dir, "text")
+	imagePath = filepath.Join(dir, "image.png")
+	if pasteImage == "" {
+		pasteImage = `cat "` + imagePath + `" 2>/dev/null || { echo "No image/png" >&2; exit 1; }`
+	}
+	scripts := map[string]string{
+		"wl-copy": `case "$*" in
+  "--type image/png") cat > "` + imagePath + `"; rm -f "` + textPath + `" ;;
+  "--clear") rm -f "` + textPath + `" "` + imagePath + `" ;;
+  *) cat > "` + textPath + `"; rm -f "` + imagePath + `" ;;
+esac`,
+		"wl-paste": `case "$*" in
+  "--type image/png") ` + pasteImage + ` ;;
+  *) cat "` + textPath + `" 2>/dev/null || { echo "Nothing is copied" >&2; exit 1; } ;;
+esac`,
+	}
+	for name, body := range scripts {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"+body+"\n"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("WAYLAND_DISPLAY", "wayland-test")
+	t.Setenv("WSL_DISTRO_NAME", "")
+	cachedBackendOnce = sync.Once{}
+	t.Cleanup(func() { cachedBackendOnce = sync.Once{} })
+	return textPath, imagePath
+}
+
+// Test doctor --image round-trips a PNG through the image clipboard and
+// restores the text that was there
+func TestCmdDoctorImageProbe(t *testing.T) {
+	defer setupSlotsTestConfig(t, "version: 1\n")()
+	textPath, _ := useMockWayland(t, "")
+	if err := os.WriteFile(textPath, []byte("keep me"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	out := captureOutput(func() {
+		if err := cmdDoctor([]string{"--image"}); err != nil {
+			t.Errorf("doctor --image: %v", err)
+		}
+	})
+	if !strings.Contains(out, "Image:    OK") {
+		t.Errorf("doctor --image output:\n%s", out)
+	}
+	if got, err := os.ReadFile(textPath); err != nil || string(got) != "keep me" {
+		t.Errorf("clipboard after probe = %q, %v; want the original text", got, err)
+	}
+
+	out = captureOutput(func() {
+		if err := cmdDoctor([]string{"--image", "--json"}); err != nil {
+			t.Errorf("doctor --image --json: %v", err)
+		}
+	})
+	var result struct {
+		Status string      `json:"status"`
+		Image  *imageProbe `json:"image"`
+	}
+	if err := json.Unmarshal([]byte(out), &result); err != nil {
+		t.Fatalf("decoding doctor JSON: %v\n%s", err, out)
+	}
+	if result.Image == nil || result.Image.Status != "ok" || result.Status != "ok" {
+		t.Errorf("doctor --image --json = %s", out)
+	}
+
+	// Without --image the clipboard isn't touched and no probe is reported
+	out = captureOutput(func() { _ = cmdDoctor([]string{"--json"}) })
+	if strings.Contains(out, `"image"`) {
+		t.Errorf("doctor --json without --image reported a probe:\n%s", out)
+	}
+}
+
+// Test an image already on the clipboard is put back after the probe
+func TestCmdDoctorImageProbeRestoresImage(t *testing.T) {
+	defer setupSlotsTestConfig(t, "version: 1\n")()
+	_, imagePath := useMockWayland(t, "")
+	var prior bytes.Buffer
+	if err := png.Encode(&prior, image.NewGray(image.Rect(0, 0, 3, 2))); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(imagePath, prior.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	captureOutput(func() { _ = cmdDoctor([]string{"--image"}) })
+	if got, _ := os.ReadFile(imagePath); !bytes.Equal(got, prior.Bytes()) {
+		t.Error("the prior clipboard image was not restored")
+	}
+}
+
+// Test a paste command that loses the image is reported as a failure
+func TestCmdDoctorImageProbeFailure(t *testing.T) {
+	defer setupSlotsTestConfig(t, "version: 1\n")()
+	useMockWayland(t, "printf 'not a png'")
+
+	out := captureOutput(func() {
+		if err := cmdDoctor([]string{"--image", "--json"}); err != nil {
+			t.Errorf("doctor --image --json: %v", err)
+		}
+	})
+	var result struct {
+		Status string      `json:"status"`
+		Image  *imageProbe `json:"image"`
+	}
+	if err := json.Unmarshal([]byte(out), &result); err != nil {
+		t.Fatalf("decoding doctor JSON: %v\n%s", err, out)
+	}
+	if result.Status != "warning" || result.Image == nil || result.Image.Status != "fail" || !strings.Contains(result.Image.Detail, "doesn't match") {
+		t.Errorf("doctor --image --json = %s", out)
+	}
+
+	// A backend without image commands is unsupported, not a failure
+	useFileClipboard(t, "")
+	out = captureOutput(func() { _ = cmdDoctor([]string{"--image"}) })
+	if !strings.Contains(out, "Image:    not supported") {
+		t.Errorf("doctor --image output:\n%s", out)
+	}
+}
+
+// Test base64 output from image paste (osascript, PowerShell) still matches
+func TestIsProbeImage(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 1, 1))
+	img.SetNRGBA(0, 0, probeColor)
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	if !isProbeImage(buf.Bytes()) {
+		t.Error("raw PNG not recognized")
+	}
+	if !isProbeImage([]byte(base64.StdEncoding.EncodeToString(buf.Bytes()) + "\r\n")) {
+		t.Error("base64 PNG not recognized")
+	}
+	img.SetNRGBA(0, 0, color.NRGBA{A: 0xff})
+	buf.Reset()
+	_ = png.Encode(&buf, img)
+	if isProbeImage(buf.Bytes()) {
+		t.Error("a different pixel matched")
+	}
+}
+
+// Test paste --decode transforms the output without touching the clipboard
+func TestCmdPasteDecode(t *testing.T) {
+	defer setupSlotsTestConfig(t, "version: 1\n")()
+	tests := []struct {
+		encoding, clipboard, want string
+	}{
+		{"base64", "aGVsbG8gd29ybGQ=\n", "hello world"},
+		{"base64", "aGVsbG8", "hello"},
+		{"hex", "68690a", "hi\n"},
+		{"url", "a%20b%26c%3Dd", "a b&c=d"},
+	}
+	for _, tt := range tests {
+		clipPath := useFileClipboard(t, tt.clipboard)
+		out := captureOutput(func() {
+			if err := cmdPaste([]string{"--decode", tt.encoding}); err != nil {
+				t.Errorf("paste --decode %s: %v", tt.encoding, err)
+			}
+		})
+		if out != tt.want {
+			t.Errorf("paste --decode %s of %q = %q, want %q", tt.encoding, tt.clipboard, out, tt.want)
+		}
+		if got, _ := os.ReadFile(clipPath); string(got) != tt.clipboard {
+			t.Errorf("clipboard changed to %q", got)
+		}
+	}
+
+	useFileClipboard(t, "not hex!")
+	err := cmdPaste([]string{"--decode", "hex"})
+	if err == nil || !strings.Contains(err.Error(), "not valid hex") {
+		t.Errorf("invalid hex: err = %v", err)
+	}
+	if err := cmdPaste([]string{"--decode", "rot13"}); err == nil || !strings.Contains(err.Error(), "unknown encoding") {
+		t.Errorf("unknown encoding: err = %v", err)
+	}
+	if err := cmdPaste([]string{"--decode", "base64", "--size"}); err == nil {
+		t.Error("--decode --size should be rejected")
+	}
+}
+
+// Test copy --encode stores the encoded form, which paste --decode reverses
+func TestCmdCopyEncode(t *testing.T) {
+	defer setupSlotsTestConfig(t, "version: 1\n")()
+	clipPath := useFileClipboard(t, "")
+
+	content := "key=a b&c\x00\xff"
+	for _, encoding := range []string{"base64", "hex", "url"} {
+		captureOutput(func() {
+			if err := cmdCopy([]string{"--encode", encoding, "--", content}); err != nil {
+				t.Fatalf("copy --encode %s: %v", encoding, err)
+			}
+		})
+		stored, _ := os.ReadFile(clipPath)
+		if string(stored) == content {
+			t.Errorf("copy --encode %s stored the raw content", encoding)
+		}
+		out := captureOutput(func() {
+			if err := cmdPaste([]string{"--decode", encoding}); err != nil {
+				t.Errorf("paste --decode %s: %v", encoding, err)
+			}
+		})
+		if out != content {
+			t.Errorf("%s round trip = %q, want %q", encoding, out, content)
+		}
+	}
+
+	captureOutput(func() { _ = cmdCopy([]string{"--encode", "hex", "hi"}) })
+	if got, _ := os.ReadFile(clipPath); string(got) != "6869" {
+		t.Errorf("clipboard = %q, want 6869", got)
+	}
+	if err := cmdCopy([]string{"--encode", "hex", "--image"}); err == nil {
+		t.Error("--encode --image should be rejected")
+	}
+	if err := cmdCopy([]string{"x", "--encode"}); err == nil || !strings.Contains(err.Error(), "requires an encoding") {
+		t.Errorf("missing encoding: err = %v", err)
+	}
+}
+
+// useFixPath puts fake package managers (and nothing else) on PATH and
+// makes the next backend detection see an X11 session without xclip
+func useFixPath(t *testing.T, managers ...string) {
+	t.Helper()
+	dir := t.TempDir()
+	for _, name := range managers {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\nexit 0\n"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", dir)
+	t.Setenv("DISPLAY", ":0")
+	t.Setenv("WAYLAND_DISPLAY", "")
+	t.Setenv("WSL_DISTRO_NAME", "")
+	cachedBackendOnce = sync.Once{}
+	t.Cleanup(func() { cachedBackendOnce = sync.Once{} })
+}
+
+// Test the install command uses the first package manager found and only
+// adds sudo when not running as root
+func TestPackageInstallCmd(t *testing.T) {
+	useFixPath(t, "dnf", "brew")
+	if got := strings.Join(packageInstallCmd("xclip", false), " "); got != "sudo dnf install -y xclip" {
+		t.Errorf("as user = %q", got)
+	}
+	if got := strings.Join(packageInstallCmd("xclip", true), " "); got != "dnf install -y xclip" {
+		t.Errorf("as root = %q", got)
+	}
+
+	useFixPath(t, "brew")
+	if got := strings.Join(packageInstallCmd("xclip", false), " "); got != "brew install xclip" {
+		t.Errorf("brew = %q, want no sudo", got)
+	}
+
+	useFixPath(t)
+	if got := packageInstallCmd("xclip", false); got != nil {
+		t.Errorf("no package manager = %q, want nil", got)
+	}
+}
+
+// Test fixPackage maps an unknown Linux backend to its display server
+func TestFixPackage(t *testing.T) {
+	t.Setenv("WAYLAND_DISPLAY", "")
+	t.Setenv("DISPLAY", "")
+	if got := fixPackage(&Backend{Kind: BackendDarwin, Missing: []string{"pbcopy"}}); got != "" {
+		t.Errorf("darwin = %q, want nothing to install", got)
+	}
+	if got := fixPackage(&Backend{Kind: BackendX11}); got != "" {
+		t.Errorf("x11 with nothing missing = %q", got)
+	}
+	if runtime.GOOS != "linux" {
+		return
+	}
+	if got := fixPackage(&Backend{Kind: BackendUnknown}); got != "" {
+		t.Errorf("unknown without a display = %q", got)
+	}
+	t.Setenv("DISPLAY", ":0")
+	if got := fixPackage(&Backend{Kind: BackendUnknown}); got != "xclip" {
+		t.Errorf("unknown under X11 = %q", got)
+	}
+	t.Setenv("WAYLAND_DISPLAY", "wayland-0")
+	if got := fixPackage(&Backend{Kind: BackendUnknown}); got != "wl-clipboard" {
+		t.Errorf("unknown under Wayland = %q", got)
+	}
+}
+
+// Test doctor --fix prints the command and only runs it after a yes
+func TestCmdDoctorFix(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("package installs are only offered on Linux")
+	}
+	defer setupSlotsTestConfig(t, "version: 1\n")()
+	useFixPath(t, "apt-get")
+
+	origConfirm, origRun := confirmInstall, runInstallCmd
+	t.Cleanup(func() { confirmInstall, runInstallCmd = origConfirm, origRun })
+	var ran []string
+	runInstallCmd = func(argv []string) error {
+		ran = argv
+		return nil
+	}
+
+	confirmInstall = func(string) bool { return false }
+	out := captureOutput(func() {
+		if err := cmdDoctor([]string{"--fix"}); err != nil {
+			t.Errorf("doctor --fix: %v", err)
+		}
+	})
+	if !strings.Contains(out, "apt-get install -y xclip") || ran != nil {
+		t.Errorf("declined fix: ran %q, output:\n%s", ran, out)
+	}
+
+	confirmInstall = func(string) bool { return true }
+	out = captureOutput(func() {
+		if err := cmdDoctor([]string{"--fix"}); err != nil {
+			t.Errorf("doctor --fix: %v", err)
+		}
+	})
+	if len(ran) == 0 || ran[len(ran)-1] != "xclip" || !strings.Contains(out, "Installed xclip") {
+		t.Errorf("confirmed fix: ran %q, output:\n%s", ran, out)
+	}
+
+	if err := cmdDoctor([]string{"--fix", "--json"}); err == nil {
+		t.Error("--fix --json should be rejected")
+	}
+}
+
+// Test doctor --fix falls back to the manual hint without a package manager
+func TestCmdDoctorFixUnsupported(t *testing.T) {
+	defer setupSlotsTestConfig(t, "version: 1\n")()
+	useFixPath(t)
+	origConfirm := confirmInstall
+	t.Cleanup(func() { confirmInstall = origConfirm })
+	confirmInstall = func(string) bool {
+		t.Error("should not prompt without an install command")
+		return false
+	}
+
+	b, err := getBackend()
+	if err != nil {
+		t.Skip(err)
+	}
+	out := captureOutput(func() {
+		if err := doctorFix(b); err != nil {
+			t.Errorf("doctorFix: %v", err)
+		}
+	})
+	if len(b.Missing) == 0 && b.Kind != BackendUnknown {
+		if !strings.Contains(out, "nothing to install") {
+			t.Errorf("output:\n%s", out)
+		}
+	} else if !strings.Contains(out, "can't install automatically") || !strings.Contains(out, "Hint:") {
+		t.Errorf("output:\n%s", out)
+	}
+}
@@ -3,6 +3,8 @@ package main
 import (
 	"errors"
 	"fmt"
+	"sort"
+	"strings"
 )
 
 func cmdCompletion(args []string) error {
@@ -24,6 +26,53 @@ func cmdCompletion(args []string) error {
 	return nil
 }
 
+// cmdComplete prints dynamic completion candidates for the shell scripts,
+// one per line: pipeboard __complete <command> [prefix]. It never fails,
+// so a broken config doesn't print errors into the user's shell.
+func cmdComplete(args []string) error {
+	if len(args) == 0 {
+		return nil
+	}
+	prefix := ""
+	if len(args) > 1 {
+		prefix = args[1]
+	}
+
+	var candidates []string
+	switch args[0] {
+	case "fx":
+		candidates = completeFxNames()
+	}
+
+	for _, c := range candidates {
+		if strings.HasPrefix(c, prefix) {
+			fmt.Println(c)
+		}
+	}
+	return nil
+}
+
+// completeFxNames returns the configured and builtin transform names,
+// sorted
+func completeFxNames() []string {
+	cfg, err := loadConfigForFx()
+	if err != nil {
+		debugLog("completion: %v", err)
+		return nil
+	}
+	names := make([]string, 0, len(cfg.Fx)+len(fxBuiltins))
+	for name := range fxBuiltins {
+		names = append(names, name)
+	}
+	for name := range cfg.Fx {
+		if _, ok := fxBuiltins[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
 const bashCompletion = `# pipeboard bash completion
 # Add to ~/.bashrc or /etc/bash_completion.d/pipeboard
 
@@ -33,7 +82,13 @@ _pipeboard() {
     cur="${COMP_WORDS[COMP_CWORD]}"
     prev="${COMP_WORDS[COMP_CWORD-1]}"
 
-    commands="copy paste clear push pull show slots rm send recv peek watch history recall fx backend doctor init completion help version"
+    commands="copy paste clear qr push pull sync show slots rm verify diff prune export import send recv peek peers watch history recall undo fx backend doctor init config keyring audit completion help version"
+
+    # fx takes any number of transform names
+    if [[ ${COMP_CWORD} -ge 2 && "${COMP_WORDS[1]}" == "fx" ]]; then
+        COMPREPLY=( $(compgen -W "--list --show-builtin --check --json --dry-run --allow-empty --timeout --stdin --stdout --slot --to-slot $(pipeboard __complete fx "${cur}" 2>/dev/null)" -- ${cur}) )
+        return 0
+    fi
 
     case "${prev}" in
         pipeboard)
@@ -44,30 +99,104 @@ _pipeboard() {
             COMPREPLY=( $(compgen -W "bash zsh fish" -- ${cur}) )
             return 0
             ;;
-        fx)
-            # Complete with --list, --dry-run, or transform names from config
-            local fx_opts="--list --dry-run"
-            COMPREPLY=( $(compgen -W "${fx_opts}" -- ${cur}) )
+        verify)
+            COMPREPLY=( $(compgen -W "--explain" -- ${cur}) )
+            return 0
+            ;;
+        diff)
+            COMPREPLY=( $(compgen -W "--semantic --format" -- ${cur}) )
+            return 0
+            ;;
+        rm)
+            COMPREPLY=( $(compgen -W "--keep-last --yes" -- ${cur}) )
             return 0
             ;;
-        push|pull|show|rm)
+        push|pull|show)
             # Could complete slot names here if we cached them
             return 0
             ;;
-        send|recv|peek|watch)
-            # Could complete peer names here if we cached them
+        prune)
+            COMPREPLY=( $(compgen -W "--s3-multipart --older-than --dry-run" -- ${cur}) )
+            return 0
+            ;;
+        export)
+            COMPREPLY=( $(compgen -W "--output --force --json" -- ${cur}) )
+            return 0
+            ;;
+        import)
+            COMPREPLY=( $(compgen -W "--force" -- ${cur}) $(compgen -f -- ${cur}) )
+            return 0
+            ;;
+        watch)
+            COMPREPLY=( $(compgen -W "--replace --since-last --status --stop --debounce --max-rate --to-slot-prefix --clipboard --interval --max" -- ${cur}) )
+            return 0
+            ;;
+        send)
+            COMPREPLY=( $(compgen -W "--slot --json --dry-run --confirm" -- ${cur}) )
+            return 0
+            ;;
+        recv)
+            COMPREPLY=( $(compgen -W "--slot --yes --json --fresh --dry-run --allow-empty --raw" -- ${cur}) )
+            return 0
+            ;;
+        peers)
+            COMPREPLY=( $(compgen -W "--check --timeout --json" -- ${cur}) )
+            return 0
+            ;;
+        peek)
+            COMPREPLY=( $(compgen -W "--yes --json --fresh --dry-run --raw" -- ${cur}) )
             return 0
             ;;
         history)
-            COMPREPLY=( $(compgen -W "--fx --slots --peer --local --json" -- ${cur}) )
+            COMPREPLY=( $(compgen -W "clear --fx --slots --peer --local --stats --count --json --csv --tsv --wide --no-truncate --no-headers --yes" -- ${cur}) )
+            return 0
+            ;;
+        recall)
+            COMPREPLY=( $(compgen -W "--peer --stdout" -- ${cur}) )
+            return 0
+            ;;
+        slots)
+            if [[ "$prev" == "--sort" ]]; then
+                COMPREPLY=( $(compgen -W "name size age" -- ${cur}) )
+                return 0
+            fi
+            COMPREPLY=( $(compgen -W "--sort --sort-reverse --filter --json --csv --tsv --wide --no-headers" -- ${cur}) )
+            return 0
+            ;;
+        init)
+            COMPREPLY=( $(compgen -W "--example" -- ${cur}) )
             return 0
             ;;
-        slots|doctor)
-            COMPREPLY=( $(compgen -W "--json" -- ${cur}) )
+        config)
+            COMPREPLY=( $(compgen -W "show path example validate --fix" -- ${cur}) )
             return 0
             ;;
-        copy|paste)
-            COMPREPLY=( $(compgen -W "--image" -- ${cur}) )
+        keyring)
+            COMPREPLY=( $(compgen -W "set" -- ${cur}) )
+            return 0
+            ;;
+        sync)
+            COMPREPLY=( $(compgen -W "push pull" -- ${cur}) )
+            return 0
+            ;;
+        audit)
+            COMPREPLY=( $(compgen -W "verify" -- ${cur}) )
+            return 0
+            ;;
+        --format)
+            COMPREPLY=( $(compgen -W "yaml json raw" -- ${cur}) )
+            return 0
+            ;;
+        doctor)
+            COMPREPLY=( $(compgen -W "--json --image --fix" -- ${cur}) )
+            return 0
+            ;;
+        copy)
+            COMPREPLY=( $(compgen -W "--image --image-file --verify --tee --encode --print-status --json --append --prepend --separator --sep --nul" -- ${cur}) )
+            return 0
+            ;;
+        paste)
+            COMPREPLY=( $(compgen -W "--image --output --force --pager --no-pager --hash --decode" -- ${cur}) )
             return 0
             ;;
         *)
@@ -94,21 +223,33 @@ _pipeboard() {
         'copy:Copy text or image to clipboard'
         'paste:Paste from clipboard to stdout'
         'clear:Clear the clipboard'
+        'qr:Render clipboard as a QR code'
         'push:Push clipboard to a named slot'
         'pull:Pull from a named slot to clipboard'
+        'sync:Push or pull the default slot'
         'show:Show contents of a slot without copying'
         'slots:List all available slots'
         'rm:Delete a slot'
+        'verify:Check a slot decodes and show how it was stored'
+        'diff:Compare a slot with the clipboard or another slot'
+        'prune:Abort stale incomplete S3 uploads'
+        'export:Back up all slots to a tar archive'
+        'import:Restore slots from an export'
         'send:Send clipboard to a peer'
         'recv:Receive clipboard from a peer'
         'peek:View peer clipboard without copying'
+        'peers:List peers and check which are reachable'
         'watch:Real-time bidirectional clipboard sync'
         'history:Show clipboard operation history'
         'recall:Restore entry from clipboard history'
+        'undo:Restore the clipboard from before the last copy or recall'
         'fx:Run transforms on clipboard'
         'backend:Show detected clipboard backend'
         'doctor:Check system clipboard setup'
         'init:Initialize pipeboard configuration'
+        'config:Show configuration'
+        'keyring:Store encryption passphrase in the OS keyring'
+        'audit:Check the audit log'
         'completion:Generate shell completions'
         'help:Show help'
         'version:Show version'
@@ -128,30 +269,206 @@ _pipeboard() {
                     _values 'shell' bash zsh fish
                     ;;
                 fx)
+                    local -a transforms
+                    transforms=(${(f)"$(pipeboard __complete fx 2>/dev/null)"})
                     _arguments \
                         '--list[List available transforms]' \
-                        '--dry-run[Preview without modifying clipboard]'
+                        '--show-builtin[With --list, show whether each transform is builtin or from config]' \
+                        '--check[Check the tools config transforms need are installed]' \
+                        '--json[With --list or --check, output as JSON]' \
+                        '--dry-run[Preview without modifying clipboard]' \
+                        '--allow-empty[Run the next step on empty output]' \
+                        '--stdin[Read input from stdin instead of the clipboard]' \
+                        '--stdout[Write the result to stdout instead of the clipboard]' \
+                        '--timeout[Kill a transform that runs longer]:duration:' \
+                        '--slot[Transform a slot instead of the clipboard]:slot:' \
+                        '--to-slot[Write the result to this slot]:slot:' \
+                        "*:transform:(${transforms[*]})"
                     ;;
                 history)
                     _arguments \
                         '--fx[Show only transform operations]' \
                         '--slots[Show only slot operations]' \
-                        '--peer[Show only peer operations]' \
+                        '--peer[Show only peer operations, or a peer'"'"'s clipboard history]' \
                         '--local[Show local clipboard history]' \
-                        '--json[Output in JSON format]'
+                        '--stats[Summarize local clipboard history]' \
+                        '--count[Show only the most recent entries]:count:' \
+                        '--json[Output in JSON format]' \
+                        '--csv[Output as CSV]' \
+                        '--tsv[Output as tab-separated values]' \
+                        '--wide[Expand columns to terminal width]' \
+                        '--no-truncate[Show full previews]' \
+                        '--no-headers[Omit the header row]' \
+                        '--yes[Clear without asking]' \
+                        '1:action:(clear)'
                     ;;
-                slots|doctor)
+                recall)
                     _arguments \
-                        '--json[Output in JSON format]'
+                        '--peer[Restore from a peer'"'"'s clipboard history]:peer:' \
+                        '--stdout[Print the entry instead of copying it]'
                     ;;
-                copy|paste)
+                slots)
                     _arguments \
-                        '--image[Copy/paste image instead of text]'
+                        '--json[Output in JSON format]' \
+                        '--csv[Output as CSV]' \
+                        '--tsv[Output as tab-separated values]' \
+                        '--wide[Expand columns to terminal width]' \
+                        '--no-headers[Omit the header row]' \
+                        '--sort[Order the slots]:key:(name size age)' \
+                        '--sort-reverse[Reverse the --sort order]' \
+                        '--filter[Only slots matching a glob]:pattern:'
                     ;;
-                push|pull|show|rm)
-                    # Slot name completion would go here
+                doctor)
+                    _arguments \
+                        '--json[Output in JSON format]' \
+                        '--image[Round-trip a test PNG through the clipboard]' \
+                        '--fix[Install missing clipboard tools]'
                     ;;
-                send|recv|peek|watch)
+                sync)
+                    _values 'action' push pull
+                    ;;
+                keyring)
+                    _values 'subcommand' set
+                    ;;
+                audit)
+                    _values 'subcommand' verify
+                    ;;
+                init)
+                    _arguments \
+                        '--example[Write a commented reference config]'
+                    ;;
+                config)
+                    _arguments \
+                        '2:subcommand:(show path example validate)' \
+                        '--format[Output format]:format:(yaml json raw)' \
+                        '--fix[Restrict config permissions to 0600]'
+                    ;;
+                copy)
+                    _arguments \
+                        '--image[Copy image instead of text]' \
+                        '--image-file[Copy a PNG file as an image]:file:_files' \
+                        '--verify[Read back and warn if the content changed]' \
+                        '--encode[Encode the text before copying]:encoding:(base64 hex url)' \
+                        '(--print-status)--tee[Also write the content to stdout]' \
+                        '(--tee)--print-status[Print "ok <bytes>" once the copy succeeds]' \
+                        '--json[Print the --print-status line as JSON]' \
+                        '(--prepend)--append[Add to the end of the current clipboard]' \
+                        '(--append)--prepend[Add to the start of the current clipboard]' \
+                        '--separator[Text between old and new content]:separator:' \
+                        '--sep[Join text arguments with this string]:separator:' \
+                        '--nul[Join text arguments with NUL bytes]'
+                    ;;
+                paste)
+                    _arguments \
+                        '--image[Paste image instead of text]' \
+                        {-o,--output}'[Save to a file instead of stdout]:file:_files' \
+                        '--force[Overwrite an existing --output file]' \
+                        '--pager[Page output]' \
+                        '--no-pager[Never page output]' \
+                        '--hash[Print the SHA-256 of the clipboard]' \
+                        '--decode[Decode the content before printing]:encoding:(base64 hex url)'
+                    ;;
+                show)
+                    _arguments \
+                        '--qr[Render as QR code]' \
+                        '--invert[Invert QR colors]' \
+                        '--meta[Print slot metadata]' \
+                        '--json[Print metadata as JSON]' \
+                        '--versions[List stored versions]' \
+                        '--version[Show a stored version]:id:' \
+                        '--charset[Convert text to UTF-8 from a charset]:charset:(auto utf-16le utf-16be latin1 windows-1252)' \
+                        '--lines[Print only a line range]:range:' \
+                        '--pager[Page output]' \
+                        '--no-pager[Never page output]'
+                    ;;
+                verify)
+                    _arguments \
+                        '--explain[Show the stored pipeline]'
+                    ;;
+                diff)
+                    _arguments \
+                        '--semantic[Compare JSON/YAML structurally]' \
+                        {-f,--format}'[Parse both sides as]:format:(json yaml)'
+                    ;;
+                pull)
+                    _arguments \
+                        '--decompress[Gunzip externally gzipped content]' \
+                        '--charset[Convert text to UTF-8 from a charset]:charset:(auto utf-16le utf-16be latin1 windows-1252)' \
+                        '--lines[Copy only a line range]:range:' \
+                        '--latest[Pull the newest slot matching a pattern]' \
+                        '--allow-empty[Write an empty slot to the clipboard]' \
+                        '--rich[Restore the rich flavor pushed with the slot]' \
+                        '--extract[Unpack an archive slot into a directory]:directory:_directories' \
+                        {-o,--output}'[Write the slot to a file]:file:_files' \
+                        '--force[Overwrite existing --output or --extract files]' \
+                        '(--to-stdout)--to-clipboard[Write to the clipboard even when piped]' \
+                        '(--to-clipboard)--to-stdout[Write to stdout instead of the clipboard]'
+                    ;;
+                push)
+                    _arguments \
+                        '--auto-name[Name the slot from the repo and branch]' \
+                        '--from-command[Push the output of a command]:command:' \
+                        '--copy[Push text or stdin and copy it to the clipboard too]' \
+                        '--stdin[Push stdin instead of the clipboard]' \
+                        '*'{-f,--file}'[Push files as a tar archive]:file:_files' \
+                        '*--tar[Push a directory tree as a tar archive]:directory:_directories' \
+                        '--append[Add to the end of the slot]' \
+                        '--separator[Text between the slot and the new content]:separator:'
+                    ;;
+                rm)
+                    _arguments \
+                        '--keep-last[Delete all but the newest n matching slots]:count:' \
+                        '--yes[Delete without asking]'
+                    ;;
+                prune)
+                    _arguments \
+                        '--s3-multipart[Abort stale incomplete multipart uploads]' \
+                        '--older-than[Minimum upload age]:duration:' \
+                        '--dry-run[List what would be aborted]'
+                    ;;
+                export)
+                    _arguments \
+                        '(-o --output)'{-o,--output}'[Write the export to a file]:file:_files' \
+                        '--force[Overwrite an existing output file]' \
+                        '--json[Write a JSON array instead of a tar]'
+                    ;;
+                import)
+                    _arguments \
+                        '--force[Overwrite existing slots]' \
+                        '1:export:_files'
+                    ;;
+                send)
+                    _arguments \
+                        '--json[Output result as JSON]' \
+                        '--dry-run[Print the ssh command without running it]' \
+                        '--confirm[Check the peer stored what was sent]' \
+                        '--slot[Push to this slot on the peer]:slot:'
+                    ;;
+                recv)
+                    _arguments \
+                        '--yes[Skip the size confirmation]' \
+                        '--json[Output result as JSON]' \
+                        '--fresh[Fetch again instead of using the cache]' \
+                        '--dry-run[Print the ssh command without running it]' \
+                        '--allow-empty[Clear the clipboard if the peer is empty]' \
+                        '--raw[Print to stdout instead of the clipboard]' \
+                        '--slot[Pull this slot from the peer]:slot:'
+                    ;;
+                peek)
+                    _arguments \
+                        '--yes[Skip the size confirmation]' \
+                        '--json[Output result as JSON]' \
+                        '--fresh[Fetch again instead of using the cache]' \
+                        '--dry-run[Print the ssh command without running it]' \
+                        '--raw[Print the content as is (the default)]'
+                    ;;
+                peers)
+                    _arguments \
+                        '--check[Probe each peer over ssh]' \
+                        '--timeout[Give up on a peer after this long]:duration:' \
+                        '--json[Output as JSON]'
+                    ;;
+                watch)
                     # Peer name completion would go here
                     ;;
                 *)
@@ -175,21 +492,33 @@ complete -c pipeboard -f
 complete -c pipeboard -n "__fish_use_subcommand" -a "copy" -d "Copy text or image to clipboard"
 complete -c pipeboard -n "__fish_use_subcommand" -a "paste" -d "Paste from clipboard to stdout"
 complete -c pipeboard -n "__fish_use_subcommand" -a "clear" -d "Clear the clipboard"
+complete -c pipeboard -n "__fish_use_subcommand" -a "qr" -d "Render clipboard as a QR code"
 complete -c pipeboard -n "__fish_use_subcommand" -a "push" -d "Push clipboard to a named slot"
 complete -c pipeboard -n "__fish_use_subcommand" -a "pull" -d "Pull from a named slot to clipboard"
 complete -c pipeboard -n "__fish_use_subcommand" -a "show" -d "Show contents of a slot"
 complete -c pipeboard -n "__fish_use_subcommand" -a "slots" -d "List all available slots"
 complete -c pipeboard -n "__fish_use_subcommand" -a "rm" -d "Delete a slot"
+complete -c pipeboard -n "__fish_use_subcommand" -a "verify" -d "Check a slot decodes"
+complete -c pipeboard -n "__fish_use_subcommand" -a "diff" -d "Compare a slot with the clipboard or another slot"
+complete -c pipeboard -n "__fish_use_subcommand" -a "prune" -d "Abort stale incomplete S3 uploads"
+complete -c pipeboard -n "__fish_use_subcommand" -a "export" -d "Back up all slots to a tar archive"
+complete -c pipeboard -n "__fish_use_subcommand" -a "import" -d "Restore slots from an export"
 complete -c pipeboard -n "__fish_use_subcommand" -a "send" -d "Send clipboard to a peer"
 complete -c pipeboard -n "__fish_use_subcommand" -a "recv" -d "Receive clipboard from a peer"
 complete -c pipeboard -n "__fish_use_subcommand" -a "peek" -d "View peer clipboard"
+complete -c pipeboard -n "__fish_use_subcommand" -a "peers" -d "List peers and check reachability"
 complete -c pipeboard -n "__fish_use_subcommand" -a "watch" -d "Real-time clipboard sync"
 complete -c pipeboard -n "__fish_use_subcommand" -a "history" -d "Show operation history"
 complete -c pipeboard -n "__fish_use_subcommand" -a "recall" -d "Restore from clipboard history"
+complete -c pipeboard -n "__fish_use_subcommand" -a "undo" -d "Restore clipboard from before last copy/recall"
 complete -c pipeboard -n "__fish_use_subcommand" -a "fx" -d "Run transforms on clipboard"
 complete -c pipeboard -n "__fish_use_subcommand" -a "backend" -d "Show clipboard backend"
 complete -c pipeboard -n "__fish_use_subcommand" -a "doctor" -d "Check system setup"
 complete -c pipeboard -n "__fish_use_subcommand" -a "init" -d "Initialize configuration"
+complete -c pipeboard -n "__fish_use_subcommand" -a "config" -d "Show configuration"
+complete -c pipeboard -n "__fish_use_subcommand" -a "sync" -d "Push or pull the default slot"
+complete -c pipeboard -n "__fish_use_subcommand" -a "keyring" -d "Store encryption passphrase in the OS keyring"
+complete -c pipeboard -n "__fish_use_subcommand" -a "audit" -d "Check the audit log"
 complete -c pipeboard -n "__fish_use_subcommand" -a "completion" -d "Generate shell completions"
 complete -c pipeboard -n "__fish_use_subcommand" -a "help" -d "Show help"
 complete -c pipeboard -n "__fish_use_subcommand" -a "version" -d "Show version"
@@ -199,20 +528,160 @@ complete -c pipeboard -n "__fish_seen_subcommand_from completion" -a "bash zsh f
 
 # fx options
 complete -c pipeboard -n "__fish_seen_subcommand_from fx" -l list -d "List available transforms"
+complete -c pipeboard -n "__fish_seen_subcommand_from fx" -l show-builtin -d "With --list, show each transform's source"
+complete -c pipeboard -n "__fish_seen_subcommand_from fx" -l check -d "Check the tools transforms need are installed"
+complete -c pipeboard -n "__fish_seen_subcommand_from fx" -l json -d "With --list or --check, output as JSON"
 complete -c pipeboard -n "__fish_seen_subcommand_from fx" -l dry-run -d "Preview without modifying"
+complete -c pipeboard -n "__fish_seen_subcommand_from fx" -l allow-empty -d "Run the next step on empty output"
+complete -c pipeboard -n "__fish_seen_subcommand_from fx" -l stdin -d "Read input from stdin"
+complete -c pipeboard -n "__fish_seen_subcommand_from fx" -l stdout -d "Write the result to stdout"
+complete -c pipeboard -n "__fish_seen_subcommand_from fx" -l timeout -x -d "Kill a transform that runs longer"
+complete -c pipeboard -n "__fish_seen_subcommand_from fx" -l slot -r -d "Transform a slot instead of the clipboard"
+complete -c pipeboard -n "__fish_seen_subcommand_from fx" -l to-slot -r -d "Write the result to this slot"
+complete -c pipeboard -n "__fish_seen_subcommand_from fx" -a "(pipeboard __complete fx (commandline -ct) 2>/dev/null)" -d "Transform"
+
+# watch options
+complete -c pipeboard -n "__fish_seen_subcommand_from watch" -l replace -d "Take over from running watch"
+complete -c pipeboard -n "__fish_seen_subcommand_from watch" -l since-last -d "Resume from the last run"
+complete -c pipeboard -n "__fish_seen_subcommand_from watch" -l status -d "Show whether watch is running"
+complete -c pipeboard -n "__fish_seen_subcommand_from watch" -l stop -d "Stop the running watch"
+complete -c pipeboard -n "__fish_seen_subcommand_from watch" -l debounce -r -d "Wait for changes to settle"
+complete -c pipeboard -n "__fish_seen_subcommand_from watch" -l max-rate -r -d "Max syncs per minute"
+complete -c pipeboard -n "__fish_seen_subcommand_from watch" -l to-slot-prefix -r -d "Archive peer changes to slots"
+complete -c pipeboard -n "__fish_seen_subcommand_from watch" -l clipboard -d "Record local clipboard changes to history"
+complete -c pipeboard -n "__fish_seen_subcommand_from watch" -l interval -r -d "Poll interval for --clipboard"
+complete -c pipeboard -n "__fish_seen_subcommand_from watch" -l max -r -d "Stop after n captures"
 
 # history options
+complete -c pipeboard -n "__fish_seen_subcommand_from history" -a "clear" -d "Delete history"
+complete -c pipeboard -n "__fish_seen_subcommand_from history" -l yes -s y -d "Clear without asking"
 complete -c pipeboard -n "__fish_seen_subcommand_from history" -l fx -d "Show only transforms"
 complete -c pipeboard -n "__fish_seen_subcommand_from history" -l slots -d "Show only slot ops"
-complete -c pipeboard -n "__fish_seen_subcommand_from history" -l peer -d "Show only peer ops"
+complete -c pipeboard -n "__fish_seen_subcommand_from history" -l peer -d "Show only peer ops, or a peer's clipboard history"
 complete -c pipeboard -n "__fish_seen_subcommand_from history" -l local -d "Show clipboard history"
+complete -c pipeboard -n "__fish_seen_subcommand_from history" -l stats -d "Summarize clipboard history"
+complete -c pipeboard -n "__fish_seen_subcommand_from history" -l count -x -d "Show only the most recent entries"
 complete -c pipeboard -n "__fish_seen_subcommand_from history" -l json -d "Output as JSON"
+complete -c pipeboard -n "__fish_seen_subcommand_from history" -l wide -d "Expand columns to terminal width"
+complete -c pipeboard -n "__fish_seen_subcommand_from history" -l no-truncate -d "Show full previews"
+complete -c pipeboard -n "__fish_seen_subcommand_from history slots" -l csv -d "Output as CSV"
+complete -c pipeboard -n "__fish_seen_subcommand_from history slots" -l tsv -d "Output as tab-separated values"
+complete -c pipeboard -n "__fish_seen_subcommand_from history slots" -l no-headers -d "Omit the header row"
+
+# recall options
+complete -c pipeboard -n "__fish_seen_subcommand_from recall" -l peer -d "Restore from a peer's clipboard history" -r
+complete -c pipeboard -n "__fish_seen_subcommand_from recall" -l stdout -d "Print the entry instead of copying it"
+
+# verify options
+complete -c pipeboard -n "__fish_seen_subcommand_from verify" -l explain -d "Show the stored pipeline"
+
+# diff options
+complete -c pipeboard -n "__fish_seen_subcommand_from diff" -l semantic -d "Compare JSON/YAML structurally"
+complete -c pipeboard -n "__fish_seen_subcommand_from diff" -s f -l format -xa "json yaml" -d "Parse both sides as"
+
+# pull options
+complete -c pipeboard -n "__fish_seen_subcommand_from pull" -l decompress -s z -d "Gunzip gzipped content"
+complete -c pipeboard -n "__fish_seen_subcommand_from pull" -l latest -d "Pull the newest slot matching a pattern"
+complete -c pipeboard -n "__fish_seen_subcommand_from pull" -l rich -d "Restore the rich flavor pushed with the slot"
+complete -c pipeboard -n "__fish_seen_subcommand_from pull" -l to-clipboard -d "Write to the clipboard even when piped"
+complete -c pipeboard -n "__fish_seen_subcommand_from pull" -l to-stdout -d "Write to stdout instead of the clipboard"
+complete -c pipeboard -n "__fish_seen_subcommand_from pull" -l extract -xa "(__fish_complete_directories)" -d "Unpack an archive slot into a directory"
+complete -c pipeboard -n "__fish_seen_subcommand_from pull" -l output -s o -r -F -d "Write the slot to a file"
+complete -c pipeboard -n "__fish_seen_subcommand_from paste" -l force -d "Overwrite an existing --output file"
+complete -c pipeboard -n "__fish_seen_subcommand_from pull" -l force -d "Overwrite existing --output or --extract files"
+complete -c pipeboard -n "__fish_seen_subcommand_from pull show" -l lines -x -d "Only lines N-M of a text slot"
+complete -c pipeboard -n "__fish_seen_subcommand_from pull show" -l charset -xa "auto utf-16le utf-16be latin1 windows-1252" -d "Convert text to UTF-8 from a charset"
+complete -c pipeboard -n "__fish_seen_subcommand_from pull recv" -l allow-empty -d "Write empty content to the clipboard"
+complete -c pipeboard -n "__fish_seen_subcommand_from push" -l auto-name -d "Name the slot from the repo and branch"
+complete -c pipeboard -n "__fish_seen_subcommand_from push" -l from-command -x -d "Push the output of a command"
+complete -c pipeboard -n "__fish_seen_subcommand_from push" -l copy -d "Push text or stdin and copy it too"
+complete -c pipeboard -n "__fish_seen_subcommand_from push" -l stdin -d "Push stdin instead of the clipboard"
+complete -c pipeboard -n "__fish_seen_subcommand_from push" -l file -s f -r -F -d "Push files as a tar archive"
+complete -c pipeboard -n "__fish_seen_subcommand_from push" -l tar -xa "(__fish_complete_directories)" -d "Push a directory tree as a tar archive"
+complete -c pipeboard -n "__fish_seen_subcommand_from push" -l append -d "Add to the end of the slot"
+complete -c pipeboard -n "__fish_seen_subcommand_from push" -l separator -x -d "Text between the slot and the new content"
+
+# rm options
+complete -c pipeboard -n "__fish_seen_subcommand_from rm" -l keep-last -x -d "Delete all but the newest n matching slots"
+complete -c pipeboard -n "__fish_seen_subcommand_from rm" -l yes -s y -d "Delete without asking"
+
+# prune options
+complete -c pipeboard -n "__fish_seen_subcommand_from prune" -l s3-multipart -d "Abort stale multipart uploads"
+complete -c pipeboard -n "__fish_seen_subcommand_from prune" -l older-than -x -d "Minimum upload age"
+complete -c pipeboard -n "__fish_seen_subcommand_from prune" -l dry-run -s n -d "List what would be aborted"
+
+# export options
+complete -c pipeboard -n "__fish_seen_subcommand_from export" -l output -s o -r -d "Write the export to a file"
+complete -c pipeboard -n "__fish_seen_subcommand_from export" -l force -d "Overwrite an existing output file"
+complete -c pipeboard -n "__fish_seen_subcommand_from export" -l json -d "Write a JSON array instead of a tar"
+
+# import options
+complete -c pipeboard -n "__fish_seen_subcommand_from import" -l force -d "Overwrite existing slots"
+
+# peer options
+complete -c pipeboard -n "__fish_seen_subcommand_from recv peek" -l yes -s y -d "Skip the size confirmation"
+complete -c pipeboard -n "__fish_seen_subcommand_from send recv peek" -l json -d "Output result as JSON"
+complete -c pipeboard -n "__fish_seen_subcommand_from recv peek" -l fresh -d "Fetch again instead of using the cache"
+complete -c pipeboard -n "__fish_seen_subcommand_from send recv peek" -l dry-run -s n -d "Print the ssh command without running it"
+complete -c pipeboard -n "__fish_seen_subcommand_from recv peek" -l raw -d "Print to stdout instead of the clipboard"
+complete -c pipeboard -n "__fish_seen_subcommand_from send" -l confirm -d "Check the peer stored what was sent"
+complete -c pipeboard -n "__fish_seen_subcommand_from send recv" -l slot -r -d "Use a slot on the peer instead of its clipboard"
+
+# peers options
+complete -c pipeboard -n "__fish_seen_subcommand_from peers" -l check -d "Probe each peer over ssh"
+complete -c pipeboard -n "__fish_seen_subcommand_from peers" -l timeout -x -d "Give up on a peer after this long"
+complete -c pipeboard -n "__fish_seen_subcommand_from peers" -l json -d "Output as JSON"
+
+# paste/show pager options
+complete -c pipeboard -n "__fish_seen_subcommand_from paste show" -l pager -d "Page output"
+complete -c pipeboard -n "__fish_seen_subcommand_from paste show" -l no-pager -d "Never page output"
+
+# show options
+complete -c pipeboard -n "__fish_seen_subcommand_from show" -l qr -d "Render as QR code"
+complete -c pipeboard -n "__fish_seen_subcommand_from show" -l meta -d "Print slot metadata"
+complete -c pipeboard -n "__fish_seen_subcommand_from show" -l json -d "Print metadata as JSON"
+complete -c pipeboard -n "__fish_seen_subcommand_from show" -l versions -d "List stored versions"
+complete -c pipeboard -n "__fish_seen_subcommand_from show" -l version -x -d "Show a stored version"
+
+# config options
+complete -c pipeboard -n "__fish_seen_subcommand_from config" -a "show path example validate"
+complete -c pipeboard -n "__fish_seen_subcommand_from config" -l format -xa "yaml json raw" -d "Output format"
+complete -c pipeboard -n "__fish_seen_subcommand_from config" -l fix -d "Restrict config permissions to 0600"
+
+# init options
+complete -c pipeboard -n "__fish_seen_subcommand_from init" -l example -d "Write a commented reference config"
+
+# keyring subcommands
+complete -c pipeboard -n "__fish_seen_subcommand_from sync; and not __fish_seen_subcommand_from push pull" -a "push pull"
+complete -c pipeboard -n "__fish_seen_subcommand_from keyring" -a "set"
+
+# audit subcommands
+complete -c pipeboard -n "__fish_seen_subcommand_from audit" -a "verify"
 
 # slots/doctor options
 complete -c pipeboard -n "__fish_seen_subcommand_from slots doctor" -l json -d "Output as JSON"
+complete -c pipeboard -n "__fish_seen_subcommand_from doctor" -l image -d "Round-trip a test PNG through the clipboard"
+complete -c pipeboard -n "__fish_seen_subcommand_from doctor" -l fix -d "Install missing clipboard tools"
+complete -c pipeboard -n "__fish_seen_subcommand_from slots" -l wide -d "Expand columns to terminal width"
+complete -c pipeboard -n "__fish_seen_subcommand_from slots" -l sort -xa "name size age" -d "Order the slots"
+complete -c pipeboard -n "__fish_seen_subcommand_from slots" -l sort-reverse -d "Reverse the --sort order"
+complete -c pipeboard -n "__fish_seen_subcommand_from slots" -l filter -x -d "Only slots matching a glob"
 
 # copy/paste options
 complete -c pipeboard -n "__fish_seen_subcommand_from copy paste" -l image -d "Image mode"
+complete -c pipeboard -n "__fish_seen_subcommand_from copy" -l verify -d "Read back and warn if the content changed"
+complete -c pipeboard -n "__fish_seen_subcommand_from copy" -l tee -d "Also write the content to stdout"
+complete -c pipeboard -n "__fish_seen_subcommand_from copy" -l print-status -d "Print ok <bytes> once the copy succeeds"
+complete -c pipeboard -n "__fish_seen_subcommand_from copy" -l image-file -r -F -d "Copy a PNG file as an image"
+complete -c pipeboard -n "__fish_seen_subcommand_from copy" -l append -d "Add to the end of the current clipboard"
+complete -c pipeboard -n "__fish_seen_subcommand_from copy" -l prepend -d "Add to the start of the current clipboard"
+complete -c pipeboard -n "__fish_seen_subcommand_from copy" -l separator -r -d "Text between old and new content"
+complete -c pipeboard -n "__fish_seen_subcommand_from copy" -l sep -r -d "Join text arguments with this string"
+complete -c pipeboard -n "__fish_seen_subcommand_from copy" -l nul -d "Join text arguments with NUL bytes"
+complete -c pipeboard -n "__fish_seen_subcommand_from paste" -l output -s o -r -F -d "Save to a file instead of stdout"
+complete -c pipeboard -n "__fish_seen_subcommand_from paste" -l hash -d "Print the SHA-256 of the clipboard"
+complete -c pipeboard -n "__fish_seen_subcommand_from paste" -l decode -xa "base64 hex url" -d "Decode the content before printing"
+complete -c pipeboard -n "__fish_seen_subcommand_from copy" -l encode -xa "base64 hex url" -d "Encode the text before copying"
 
 # Global --help
 complete -c pipeboard -l help -d "Show help"
@@ -4,6 +4,11 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -16,6 +21,9 @@ type Config struct {
 	Peers    map[string]PeerConfig `yaml:"peers,omitempty"`
 	Fx       map[string]FxConfig   `yaml:"fx,omitempty"`      // clipboard transforms
 	Aliases  map[string]string     `yaml:"aliases,omitempty"` // slot name shortcuts (e.g., k -> kube-config)
+	Policy   *PolicyConfig         `yaml:"policy,omitempty"`
+	Watch    *WatchConfig          `yaml:"watch,omitempty"`
+	Audit    *AuditConfig          `yaml:"audit,omitempty"`
 
 	// Legacy fields for backwards compatibility
 	Backend string    `yaml:"backend,omitempty"`
@@ -23,13 +31,48 @@ type Config struct {
 }
 
 type DefaultsConfig struct {
-	Peer string `yaml:"peer,omitempty"` // default peer for send/recv/peek
+	Peer             string `yaml:"peer,omitempty"`              // default peer for send/recv/peek
+	Slot             string `yaml:"slot,omitempty"`              // default slot for sync push/pull
+	PeerWarnSize     int64  `yaml:"peer_warn_size,omitempty"`    // confirm recv/peek above N bytes (default: 1MiB, -1 = never)
+	Hostname         string `yaml:"hostname,omitempty"`          // origin label recorded in pushed slots (default: os.Hostname)
+	Pager            string `yaml:"pager,omitempty"`             // pager for long show/paste output (default: $PAGER, then "less -R")
+	PeerCacheTTL     string `yaml:"peer_cache_ttl,omitempty"`    // reuse a peer clipboard fetched this recently (default: 5s, 0 = off)
+	ClipboardTimeout string `yaml:"clipboard_timeout,omitempty"` // kill a stuck clipboard tool after this long (default: 5s, 0 = never)
+	JSONCompact      bool   `yaml:"json_compact,omitempty"`      // print --json output on a single line
+
+	PullStdoutWhenPiped bool `yaml:"pull_stdout_when_piped,omitempty"` // pull to stdout when it isn't a terminal
+
+	ClipboardOrder []string `yaml:"clipboard_order,omitempty"` // Linux/WSL clipboard detection order (default: wayland, x11, wsl, termux)
+}
+
+const (
+	defaultPeerWarnSize = 1 << 20
+	defaultPeerCacheTTL = 5 * time.Second
+
+	defaultClipboardTimeout = 5 * time.Second
+)
+
+// PolicyConfig controls content checks on copy/push
+type PolicyConfig struct {
+	ScanSecrets bool   `yaml:"scan_secrets,omitempty"` // detect credentials in text content
+	OnSecret    string `yaml:"on_secret,omitempty"`    // "warn" (default) or "block"
+}
+
+// WatchConfig smooths bursts of clipboard changes during watch
+type WatchConfig struct {
+	Debounce    string `yaml:"debounce,omitempty"`     // act once a change is stable this long (e.g. "1s")
+	MaxRate     int    `yaml:"max_rate,omitempty"`     // max syncs per minute (0 = unlimited)
+	MinInterval string `yaml:"min_interval,omitempty"` // poll interval right after a change (default: 500ms)
+	MaxInterval string `yaml:"max_interval,omitempty"` // poll interval ceiling when idle (default: 4s)
 }
 
 type HistoryConfig struct {
 	Limit        int  `yaml:"limit,omitempty"`         // max clipboard history entries (default: 20)
 	TTLDays      int  `yaml:"ttl_days,omitempty"`      // auto-delete entries older than N days (0 = never)
 	NoDuplicates bool `yaml:"no_duplicates,omitempty"` // skip entries with same content hash
+
+	MaxEntryBytes int64 `yaml:"max_entry_bytes,omitempty"` // don't record content larger than this (default: 32MiB)
+	BatchWrites   bool  `yaml:"batch_writes,omitempty"`    // queue history records and write them together
 }
 
 // FxConfig defines a clipboard transform
@@ -37,16 +80,34 @@ type FxConfig struct {
 	Cmd         []string `yaml:"cmd,omitempty"`         // command and args
 	Shell       string   `yaml:"shell,omitempty"`       // shorthand: runs via "sh -c"
 	Description string   `yaml:"description,omitempty"` // shown in fx --list
+	Cache       bool     `yaml:"cache,omitempty"`       // reuse output for identical input (deterministic transforms only)
+	OnError     string   `yaml:"on_error,omitempty"`    // "abort" (default) or "passthrough": pass the input on if the transform fails
+	// Env adds variables to the inherited environment; ${VAR} in a value
+	// expands from pipeboard's own environment
+	Env     map[string]string `yaml:"env,omitempty"`
+	Timeout string            `yaml:"timeout,omitempty"` // e.g. "10s": kill the command if it runs longer
+
+	builtin func([]byte) ([]byte, error) // set for builtin transforms, which have no command
 }
 
 type SyncConfig struct {
-	Backend    string        `yaml:"backend"`              // "none", "s3", "local", or "hosted"
-	S3         *S3Config     `yaml:"s3,omitempty"`
-	Local      *LocalConfig  `yaml:"local,omitempty"`
-	Hosted     *HostedConfig `yaml:"hosted,omitempty"`
-	Encryption string        `yaml:"encryption,omitempty"` // "none" or "aes256"
-	Passphrase string        `yaml:"passphrase,omitempty"` // for client-side encryption
-	TTLDays    int           `yaml:"ttl_days,omitempty"`   // auto-expire slots after N days (0 = never)
+	Backend          string        `yaml:"backend"` // "none", "s3", "gcs", "local", or "hosted"
+	S3               *S3Config     `yaml:"s3,omitempty"`
+	GCS              *GCSConfig    `yaml:"gcs,omitempty"`
+	Local            *LocalConfig  `yaml:"local,omitempty"`
+	Hosted           *HostedConfig `yaml:"hosted,omitempty"`
+	Encryption       string        `yaml:"encryption,omitempty"`        // "none", "aes256" or "age"
+	Passphrase       string        `yaml:"passphrase,omitempty"`        // for client-side encryption
+	PassphraseCmd    string        `yaml:"passphrase_cmd,omitempty"`    // command printing the passphrase (e.g. "pass show pipeboard")
+	PassphraseFile   string        `yaml:"passphrase_file,omitempty"`   // file holding the passphrase
+	Recipients       []string      `yaml:"recipients,omitempty"`        // age public keys to encrypt to (encryption: age)
+	IdentityFile     string        `yaml:"identity_file,omitempty"`     // age identity file for decrypting (encryption: age)
+	PassphraseSource string        `yaml:"passphrase_source,omitempty"` // "config" (default) or "keyring"
+	TTLDays          int           `yaml:"ttl_days,omitempty"`          // auto-expire slots after N days (0 = never)
+	Versions         int           `yaml:"versions,omitempty"`          // keep last N versions of each slot (0 = off)
+	Dedup            bool          `yaml:"dedup,omitempty"`             // store identical content once under blobs/
+	Encoding         string        `yaml:"encoding,omitempty"`          // "base64" (default) or "base85" for stored slot data
+	Compression      string        `yaml:"compression,omitempty"`       // "gzip" (default), "zstd" or "none" for slots over 1KB
 }
 
 type S3Config struct {
@@ -55,16 +116,75 @@ type S3Config struct {
 	Prefix  string `yaml:"prefix,omitempty"`
 	Profile string `yaml:"profile,omitempty"`
 	SSE     string `yaml:"sse,omitempty"` // "AES256" or "aws:kms"
+	// Endpoint points the client at an S3-compatible service (MinIO,
+	// Cloudflare R2, Wasabi) instead of AWS. Region defaults to "auto"
+	// when it is set.
+	Endpoint  string `yaml:"endpoint,omitempty"`
+	PathStyle bool   `yaml:"path_style,omitempty"` // bucket in the URL path, as MinIO needs
 }
 
 type PeerConfig struct {
 	SSH       string `yaml:"ssh"`                  // SSH host/alias
 	RemoteCmd string `yaml:"remote_cmd,omitempty"` // default: "pipeboard"
+	Compress  bool   `yaml:"compress,omitempty"`   // gzip transfers (framed; the peer needs a pipeboard that supports it)
+	Encrypt   bool   `yaml:"encrypt,omitempty"`    // encrypt transfers with the shared sync passphrase (framed)
+}
+
+// configEnvVars lists the environment variables that override config
+// settings, in the order doctor reports them
+var configEnvVars = []string{
+	"PIPEBOARD_CONFIG",
+	"PIPEBOARD_BACKEND",
+	"PIPEBOARD_PASSPHRASE",
+	"PIPEBOARD_HOSTNAME",
+	"PIPEBOARD_HISTORY_LIMIT",
+	"PIPEBOARD_S3_BUCKET",
+	"PIPEBOARD_S3_REGION",
+	"PIPEBOARD_S3_PREFIX",
+	"PIPEBOARD_S3_PROFILE",
+	"PIPEBOARD_S3_SSE",
+	"PIPEBOARD_S3_ENDPOINT",
+	"PIPEBOARD_GCS_BUCKET",
+	"PIPEBOARD_GCS_PREFIX",
+	"PIPEBOARD_GCS_CREDENTIALS_FILE",
+	"PIPEBOARD_GCS_ENDPOINT",
 }
 
+// activeConfigEnvVars returns the names of configEnvVars that are set.
+// Only names are returned so secrets never reach the output.
+func activeConfigEnvVars() []string {
+	var set []string
+	for _, name := range configEnvVars {
+		if os.Getenv(name) != "" {
+			set = append(set, name)
+		}
+	}
+	return set
+}
+
+// checkConfigProfile rejects a --profile without a name, or with one
+// that isn't a plain file name. args are what parseGlobalFlags left.
+func checkConfigProfile(args []string) error {
+	if len(args) > 0 && args[len(args)-1] == "--profile" {
+		return fmt.Errorf("--profile requires a name")
+	}
+	if configProfile == "" {
+		return nil
+	}
+	if configProfile == "." || configProfile == ".." || strings.ContainsAny(configProfile, `/\`) {
+		return fmt.Errorf("invalid profile name %q", configProfile)
+	}
+	return nil
+}
+
+// configPath returns the config file: profiles/<name>.yaml with --profile,
+// else $PIPEBOARD_CONFIG, else config.yaml, all under the pipeboard config
+// directory. An explicit --profile wins over the environment.
 func configPath() string {
-	if p := os.Getenv("PIPEBOARD_CONFIG"); p != "" {
-		return p
+	if configProfile == "" {
+		if p := os.Getenv("PIPEBOARD_CONFIG"); p != "" {
+			return p
+		}
 	}
 
 	// XDG_CONFIG_HOME or ~/.config
@@ -77,6 +197,9 @@ func configPath() string {
 		configDir = filepath.Join(home, ".config")
 	}
 
+	if configProfile != "" {
+		return filepath.Join(configDir, "pipeboard", "profiles", configProfile+".yaml")
+	}
 	return filepath.Join(configDir, "pipeboard", "config.yaml")
 }
 
@@ -138,6 +261,9 @@ func loadConfig() (*Config, error) {
 	if err := validateSyncConfig(&cfg); err != nil {
 		return nil, err
 	}
+	if err := resolvePassphrase(cfg.Sync); err != nil {
+		return nil, err
+	}
 
 	debugLog("config loaded: sync backend=%s", cfg.Sync.Backend)
 	return &cfg, nil
@@ -165,6 +291,8 @@ func applyEnvOverrides(cfg *Config) {
 	applyLegacyConfig(cfg)
 	applyBackendEnv(cfg)
 	applyS3Env(cfg)
+	applyGCSEnv(cfg)
+	applyPassphraseEnv(cfg)
 }
 
 func applyLegacyConfig(cfg *Config) {
@@ -185,6 +313,17 @@ func applyBackendEnv(cfg *Config) {
 	}
 }
 
+// applyPassphraseEnv lets PIPEBOARD_PASSPHRASE replace sync.passphrase, so
+// CI can supply it without writing it to the file. An explicit
+// passphrase_source (keyring) still wins, since resolvePassphrase runs later,
+// as do passphrase_cmd and passphrase_file.
+func applyPassphraseEnv(cfg *Config) {
+	if v := os.Getenv("PIPEBOARD_PASSPHRASE"); v != "" && cfg.Sync != nil &&
+		cfg.Sync.PassphraseCmd == "" && cfg.Sync.PassphraseFile == "" {
+		cfg.Sync.Passphrase = v
+	}
+}
+
 func ensureSyncS3(cfg *Config) {
 	if cfg.Sync == nil {
 		cfg.Sync = &SyncConfig{S3: &S3Config{}}
@@ -215,6 +354,45 @@ func applyS3Env(cfg *Config) {
 		{"PIPEBOARD_S3_PREFIX", &cfg.Sync.S3.Prefix},
 		{"PIPEBOARD_S3_PROFILE", &cfg.Sync.S3.Profile},
 		{"PIPEBOARD_S3_SSE", &cfg.Sync.S3.SSE},
+		{"PIPEBOARD_S3_ENDPOINT", &cfg.Sync.S3.Endpoint},
+	}
+
+	for _, m := range envMappings {
+		if v := os.Getenv(m.env); v != "" {
+			*m.dest = v
+		}
+	}
+}
+
+func ensureSyncGCS(cfg *Config) {
+	if cfg.Sync == nil {
+		cfg.Sync = &SyncConfig{GCS: &GCSConfig{}}
+	}
+	if cfg.Sync.GCS == nil {
+		cfg.Sync.GCS = &GCSConfig{}
+	}
+}
+
+func applyGCSEnv(cfg *Config) {
+	if v := os.Getenv("PIPEBOARD_GCS_BUCKET"); v != "" {
+		ensureSyncGCS(cfg)
+		cfg.Sync.GCS.Bucket = v
+		if cfg.Sync.Backend == "" {
+			cfg.Sync.Backend = "gcs"
+		}
+	}
+
+	if cfg.Sync == nil || cfg.Sync.GCS == nil {
+		return
+	}
+
+	envMappings := []struct {
+		env  string
+		dest *string
+	}{
+		{"PIPEBOARD_GCS_PREFIX", &cfg.Sync.GCS.Prefix},
+		{"PIPEBOARD_GCS_CREDENTIALS_FILE", &cfg.Sync.GCS.CredentialsFile},
+		{"PIPEBOARD_GCS_ENDPOINT", &cfg.Sync.GCS.Endpoint},
 	}
 
 	for _, m := range envMappings {
@@ -233,37 +411,26 @@ func validateSyncConfig(cfg *Config) error {
 		return fmt.Errorf("sync backend not configured (backend: none)")
 	}
 
-	switch cfg.Sync.Backend {
-	case "s3":
-		if cfg.Sync.S3 == nil {
-			return fmt.Errorf("s3 backend selected but s3 config missing")
-		}
-		if cfg.Sync.S3.Bucket == "" {
-			return fmt.Errorf("s3.bucket is required")
-		}
-		if cfg.Sync.S3.Region == "" {
-			return fmt.Errorf("s3.region is required")
-		}
-	case "local":
-		// Local backend requires no mandatory config (uses defaults)
-		if cfg.Sync.Local == nil {
-			cfg.Sync.Local = &LocalConfig{}
-		}
-	case "hosted":
-		// Hosted backend requires URL and email
-		if cfg.Sync.Hosted == nil {
-			return fmt.Errorf("hosted backend selected but hosted config missing")
-		}
-		if cfg.Sync.Hosted.URL == "" {
-			return fmt.Errorf("hosted.url is required")
-		}
-		if cfg.Sync.Hosted.Email == "" {
-			return fmt.Errorf("hosted.email is required")
-		}
-	default:
-		return fmt.Errorf("unsupported backend: %s", cfg.Sync.Backend)
+	if err := validatePayloadEncoding(cfg.Sync.Encoding); err != nil {
+		return err
+	}
+	if err := validateCompression(cfg.Sync.Compression); err != nil {
+		return err
+	}
+	if err := validateAgeConfig(cfg.Sync); err != nil {
+		return err
+	}
+	if err := validatePassphraseSources(cfg.Sync); err != nil {
+		return err
 	}
 
+	d, err := lookupBackend(cfg.Sync.Backend)
+	if err != nil {
+		return err
+	}
+	if d.validate != nil {
+		return d.validate(cfg.Sync)
+	}
 	return nil
 }
 
@@ -293,6 +460,80 @@ func (cfg *Config) getDefaultPeer() (string, error) {
 	return cfg.Defaults.Peer, nil
 }
 
+// getDefaultSlot returns the default slot name from config, or error if not set.
+func (cfg *Config) getDefaultSlot() (string, error) {
+	if cfg.Defaults == nil || cfg.Defaults.Slot == "" {
+		return "", fmt.Errorf("no default slot configured; set 'defaults.slot' in config or specify a slot name")
+	}
+	return cfg.Defaults.Slot, nil
+}
+
+// getPeerWarnSize returns the size above which recv/peek ask for confirmation.
+// Returns 0 when the guard is disabled.
+func (cfg *Config) getPeerWarnSize() int64 {
+	if cfg.Defaults == nil || cfg.Defaults.PeerWarnSize == 0 {
+		return defaultPeerWarnSize
+	}
+	if cfg.Defaults.PeerWarnSize < 0 {
+		return 0
+	}
+	return cfg.Defaults.PeerWarnSize
+}
+
+// getPeerCacheTTL returns how long recv/peek reuse a fetched peer
+// clipboard. Returns 0 when caching is disabled.
+func (cfg *Config) getPeerCacheTTL() (time.Duration, error) {
+	if cfg.Defaults == nil || cfg.Defaults.PeerCacheTTL == "" {
+		return defaultPeerCacheTTL, nil
+	}
+	ttl, err := time.ParseDuration(cfg.Defaults.PeerCacheTTL)
+	if err != nil || ttl < 0 {
+		return 0, fmt.Errorf("invalid defaults.peer_cache_ttl: %q (use a duration like 5s, or 0 to disable)", cfg.Defaults.PeerCacheTTL)
+	}
+	return ttl, nil
+}
+
+// getClipboardTimeout returns how long a clipboard tool may run before
+// it is killed. Returns 0 when the timeout is disabled.
+func (cfg *Config) getClipboardTimeout() (time.Duration, error) {
+	if cfg.Defaults == nil || cfg.Defaults.ClipboardTimeout == "" {
+		return defaultClipboardTimeout, nil
+	}
+	d, err := time.ParseDuration(cfg.Defaults.ClipboardTimeout)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid defaults.clipboard_timeout: %q (use a duration like 5s, or 0 to disable)", cfg.Defaults.ClipboardTimeout)
+	}
+	return d, nil
+}
+
+// getClipboardOrder returns defaults.clipboard_order, or the default
+// Linux detection order when unset
+func (cfg *Config) getClipboardOrder() ([]string, error) {
+	if cfg.Defaults == nil || len(cfg.Defaults.ClipboardOrder) == 0 {
+		return defaultLinuxBackendOrder, nil
+	}
+	for _, name := range cfg.Defaults.ClipboardOrder {
+		if _, ok := linuxBackendDetectors[name]; !ok {
+			return nil, fmt.Errorf("invalid defaults.clipboard_order entry: %q (use wayland, x11, wsl or termux)", name)
+		}
+	}
+	return cfg.Defaults.ClipboardOrder, nil
+}
+
+// slotHostname returns the origin label recorded in pushed slots.
+// PIPEBOARD_HOSTNAME takes precedence over defaults.hostname, which takes
+// precedence over os.Hostname(). cfg may be nil.
+func slotHostname(cfg *Config) string {
+	if v := os.Getenv("PIPEBOARD_HOSTNAME"); v != "" {
+		return v
+	}
+	if cfg != nil && cfg.Defaults != nil && cfg.Defaults.Hostname != "" {
+		return cfg.Defaults.Hostname
+	}
+	host, _ := os.Hostname()
+	return host
+}
+
 // loadConfigForFx loads config for fx commands.
 // Returns empty config if file doesn't exist (no fx defined is valid).
 func loadConfigForFx() (*Config, error) {
@@ -321,18 +562,37 @@ func loadConfigForFx() (*Config, error) {
 	return &cfg, nil
 }
 
-// getFx looks up an fx transform by name.
+// getFx looks up an fx transform by name, falling back to the builtins
+// when config doesn't define it.
 func (cfg *Config) getFx(name string) (FxConfig, error) {
-	if len(cfg.Fx) == 0 {
-		return FxConfig{}, fmt.Errorf("no transforms defined; add 'fx' section to config")
-	}
 	fx, ok := cfg.Fx[name]
 	if !ok {
+		if b, ok := fxBuiltins[name]; ok {
+			return FxConfig{Description: b.description, builtin: b.run}, nil
+		}
+		if len(cfg.Fx) == 0 {
+			return FxConfig{}, fmt.Errorf("unknown transform %q; no transforms defined in config (see 'pipeboard fx --list' for builtins)", name)
+		}
 		return FxConfig{}, fmt.Errorf("unknown transform %q; define it under 'fx' in config", name)
 	}
 	if len(fx.Cmd) == 0 && fx.Shell == "" {
 		return FxConfig{}, fmt.Errorf("transform %q has no 'cmd' or 'shell' defined", name)
 	}
+	switch fx.OnError {
+	case "", "abort", "passthrough":
+	default:
+		return FxConfig{}, fmt.Errorf("transform %q has invalid on_error %q (use abort or passthrough)", name, fx.OnError)
+	}
+	if fx.Timeout != "" {
+		if d, err := time.ParseDuration(fx.Timeout); err != nil || d <= 0 {
+			return FxConfig{}, fmt.Errorf("transform %q has invalid timeout %q (use a duration like 10s)", name, fx.Timeout)
+		}
+	}
+	for key := range fx.Env {
+		if key == "" || strings.ContainsAny(key, "= \t") {
+			return FxConfig{}, fmt.Errorf("transform %q has invalid env variable name %q", name, key)
+		}
+	}
 	return fx, nil
 }
 
@@ -344,6 +604,33 @@ func (fx *FxConfig) getCommand() []string {
 	return fx.Cmd
 }
 
+// getTimeout returns how long the command may run (0 = no limit)
+func (fx *FxConfig) getTimeout() time.Duration {
+	d, _ := time.ParseDuration(fx.Timeout)
+	return d
+}
+
+// envVarRef matches ${VAR} references in fx env values
+var envVarRef = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// getEnv returns the transform's extra environment as sorted KEY=value
+// pairs, with ${VAR} references expanded. Only the braced form expands, so
+// a literal $ in a value is kept; unset variables expand to "".
+func (fx *FxConfig) getEnv() []string {
+	if len(fx.Env) == 0 {
+		return nil
+	}
+	env := make([]string, 0, len(fx.Env))
+	for key, value := range fx.Env {
+		value = envVarRef.ReplaceAllStringFunc(value, func(ref string) string {
+			return os.Getenv(ref[2 : len(ref)-1])
+		})
+		env = append(env, key+"="+value)
+	}
+	slices.Sort(env)
+	return env
+}
+
 // resolveAlias returns the full slot name for an alias, or the original name if no alias exists.
 func (cfg *Config) resolveAlias(name string) string {
 	if cfg.Aliases == nil {
@@ -379,3 +666,222 @@ func loadConfigForAliases() (*Config, error) {
 
 	return &cfg, nil
 }
+
+// secretConfigKeys lists config keys whose values are never printed
+var secretConfigKeys = map[string]bool{
+	"passphrase": true,
+	"hmac_key":   true,
+}
+
+const redactedValue = "[REDACTED]"
+
+// rawSecretLine matches "key: value" lines for secret keys in the raw file
+var rawSecretLine = func() *regexp.Regexp {
+	keys := make([]string, 0, len(secretConfigKeys))
+	for k := range secretConfigKeys {
+		keys = append(keys, regexp.QuoteMeta(k))
+	}
+	slices.Sort(keys)
+	return regexp.MustCompile(`^(\s*(?:- )?(?:` + strings.Join(keys, "|") + `)\s*:\s*)(\S.*)$`)
+}()
+
+// cmdConfig handles config subcommands
+func cmdConfig(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: pipeboard config show [--format yaml|json|raw]")
+	}
+	switch args[0] {
+	case "show":
+		return cmdConfigShow(args[1:])
+	case "path":
+		fmt.Println(configPath())
+		return nil
+	case "example":
+		fmt.Print(exampleConfigYAML())
+		return nil
+	case "validate":
+		return cmdConfigValidate(args[1:])
+	default:
+		return fmt.Errorf("unknown config subcommand: %s\nusage: pipeboard config show [--format yaml|json|raw]", args[0])
+	}
+}
+
+// cmdConfigShow prints the config file with secrets redacted
+func cmdConfigShow(args []string) error {
+	format := "yaml"
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--format" || args[i] == "-f":
+			if i+1 >= len(args) {
+				return fmt.Errorf("--format requires a value (yaml, json, or raw)")
+			}
+			i++
+			format = args[i]
+		case strings.HasPrefix(args[i], "--format="):
+			format = strings.TrimPrefix(args[i], "--format=")
+		default:
+			return fmt.Errorf("unknown argument: %s\nusage: pipeboard config show [--format yaml|json|raw]", args[i])
+		}
+	}
+	if format != "yaml" && format != "json" && format != "raw" {
+		return fmt.Errorf("unknown format %q (expected yaml, json, or raw)", format)
+	}
+
+	path := configPath()
+	if path == "" {
+		return fmt.Errorf("could not determine config path")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("config file not found: %s\nRun 'pipeboard init' to create one", path)
+		}
+		return fmt.Errorf("reading config: %w", err)
+	}
+
+	if format == "raw" {
+		fmt.Fprintln(os.Stderr, "warning: raw output shows the config file as-is; secret values are masked but review before sharing")
+		fmt.Print(maskRawSecrets(string(data)))
+		return nil
+	}
+
+	// Validate against the schema so show reports the same errors as other commands
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return fmt.Errorf("parsing config: %w", err)
+	}
+	var doc map[string]interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("parsing config: %w", err)
+	}
+	if doc == nil {
+		doc = map[string]interface{}{}
+	}
+	redactSecrets(doc)
+
+	if format == "json" {
+		out, err := marshalJSONOutput(doc)
+		if err != nil {
+			return fmt.Errorf("encoding config: %w", err)
+		}
+		fmt.Println(string(out))
+		return nil
+	}
+
+	out, err := yaml.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("encoding config: %w", err)
+	}
+	fmt.Print(string(out))
+	return nil
+}
+
+// cmdConfigValidate checks the config file against the schema and warns
+// about inline secrets in a file other users can read
+func cmdConfigValidate(args []string) error {
+	fix := false
+	for _, arg := range args {
+		switch arg {
+		case "--fix":
+			fix = true
+		default:
+			return fmt.Errorf("unknown argument: %s\nusage: pipeboard config validate [--fix]", arg)
+		}
+	}
+
+	path := configPath()
+	if path == "" {
+		return fmt.Errorf("could not determine config path")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("config file not found: %s\nRun 'pipeboard init' to create one", path)
+		}
+		return fmt.Errorf("reading config: %w", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("reading config: %w", err)
+	}
+
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return fmt.Errorf("parsing config: %w", err)
+	}
+	if cfg.Sync != nil {
+		if err := validateSyncConfig(&cfg); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	secrets := inlineConfigSecrets(&cfg)
+	mode := info.Mode().Perm()
+	if len(secrets) == 0 || mode&0o077 == 0 || runtime.GOOS == "windows" {
+		fmt.Printf("config ok: %s\n", path)
+		return nil
+	}
+
+	if fix {
+		if err := os.Chmod(path, 0o600); err != nil {
+			return fmt.Errorf("fixing config permissions: %w", err)
+		}
+		fmt.Printf("fixed: %s is now mode 0600 (was %04o)\n", path, mode)
+		return nil
+	}
+
+	fmt.Fprintf(os.Stderr, "warning: %s is readable by other users (mode %04o) and contains %s in plaintext\n", path, mode, strings.Join(secrets, ", "))
+	fmt.Fprintf(os.Stderr, "  restrict it with 'pipeboard config validate --fix' (chmod 600)\n")
+	if slices.Contains(secrets, "sync.passphrase") {
+		fmt.Fprintf(os.Stderr, "  or move the passphrase out of the file: 'pipeboard keyring set' with passphrase_source: keyring, passphrase_cmd, passphrase_file, or PIPEBOARD_PASSPHRASE\n")
+	}
+	return nil
+}
+
+// inlineConfigSecrets returns the secret keys set to literal values in the
+// config file. A bare ${VAR} placeholder is not a secret.
+func inlineConfigSecrets(cfg *Config) []string {
+	var keys []string
+	isInline := func(v string) bool {
+		return v != "" && !(strings.HasPrefix(v, "${") && strings.HasSuffix(v, "}"))
+	}
+	if cfg.Sync != nil && isInline(cfg.Sync.Passphrase) {
+		keys = append(keys, "sync.passphrase")
+	}
+	if cfg.Audit != nil && isInline(cfg.Audit.HMACKey) {
+		keys = append(keys, "audit.hmac_key")
+	}
+	return keys
+}
+
+// redactSecrets replaces secret values in a decoded config document
+func redactSecrets(v interface{}) {
+	switch node := v.(type) {
+	case map[string]interface{}:
+		for k, child := range node {
+			if secretConfigKeys[k] {
+				if child != nil && child != "" {
+					node[k] = redactedValue
+				}
+				continue
+			}
+			redactSecrets(child)
+		}
+	case []interface{}:
+		for _, child := range node {
+			redactSecrets(child)
+		}
+	}
+}
+
+// maskRawSecrets masks secret values in the raw config text, keeping layout
+// and comments intact so the output still diffs cleanly
+func maskRawSecrets(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if m := rawSecretLine.FindStringSubmatch(line); m != nil {
+			lines[i] = m[1] + `"********"`
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -1,9 +1,14 @@
 package main
 
 import (
+	"encoding/json"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
+
+	"gopkg.in/yaml.v3"
 )
 
 func TestConfigPath(t *testing.T) {
@@ -51,6 +56,81 @@ func TestConfigPath(t *testing.T) {
 	})
 }
 
+func TestConfigPathProfile(t *testing.T) {
+	t.Cleanup(func() { configProfile = "" })
+	t.Setenv("XDG_CONFIG_HOME", "/xdg/config")
+	// --profile wins over PIPEBOARD_CONFIG
+	t.Setenv("PIPEBOARD_CONFIG", "/custom/path/config.yaml")
+
+	remaining := parseGlobalFlags([]string{"--profile", "work", "slots", "--json"})
+	if len(remaining) != 2 || remaining[0] != "slots" {
+		t.Errorf("remaining args should be [slots --json], got %v", remaining)
+	}
+	if p := configPath(); p != "/xdg/config/pipeboard/profiles/work.yaml" {
+		t.Errorf("configPath() = %s", p)
+	}
+
+	parseGlobalFlags([]string{"--profile=personal", "pull"})
+	if p := configPath(); p != "/xdg/config/pipeboard/profiles/personal.yaml" {
+		t.Errorf("configPath() = %s", p)
+	}
+}
+
+func TestCheckConfigProfile(t *testing.T) {
+	t.Cleanup(func() { configProfile = "" })
+	tests := []struct {
+		args    []string
+		wantErr string
+	}{
+		{[]string{"--profile", "work", "slots"}, ""},
+		{[]string{"slots"}, ""},
+		{[]string{"slots", "--profile"}, "requires a name"},
+		{[]string{"--profile", "../evil", "slots"}, "invalid profile"},
+		{[]string{"--profile=..", "slots"}, "invalid profile"},
+	}
+	for _, tt := range tests {
+		configProfile = ""
+		err := checkConfigProfile(parseGlobalFlags(tt.args))
+		if tt.wantErr == "" && err != nil {
+			t.Errorf("%v: unexpected error %v", tt.args, err)
+		}
+		if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("%v: err = %v, want %q", tt.args, err, tt.wantErr)
+		}
+	}
+}
+
+// Test push/pull use the profile's sync backend
+func TestRunWithProfile(t *testing.T) {
+	t.Cleanup(func() { configProfile = "" })
+	xdg := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+	t.Setenv("PIPEBOARD_CONFIG", "")
+	workDir, defaultDir := t.TempDir(), t.TempDir()
+	if err := os.MkdirAll(filepath.Join(xdg, "pipeboard", "profiles"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(xdg, "pipeboard", "config.yaml"), []byte(slotsConfigAt(defaultDir, "")), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(xdg, "pipeboard", "profiles", "work.yaml"), []byte(slotsConfigAt(workDir, "")), 0600); err != nil {
+		t.Fatal(err)
+	}
+	useFileClipboard(t, "")
+
+	captureOutput(func() {
+		if code := run([]string{"--profile", "work", "push", "note", "hi", "--copy"}, func() bool { return false }); code != 0 {
+			t.Fatalf("push exited %d", code)
+		}
+	})
+	if _, err := os.Stat(filepath.Join(workDir, "note.pb")); err != nil {
+		t.Errorf("slot not pushed to the work profile's backend: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(defaultDir, "note.pb")); !os.IsNotExist(err) {
+		t.Error("slot pushed to the default config's backend")
+	}
+}
+
 func TestApplyDefaults(t *testing.T) {
 	cfg := &Config{}
 	applyDefaults(cfg)
@@ -113,7 +193,7 @@ func TestValidateSyncConfig(t *testing.T) {
 		{
 			name: "unsupported backend",
 			cfg: Config{
-				Sync: &SyncConfig{Backend: "gcs"},
+				Sync: &SyncConfig{Backend: "azure"},
 			},
 			wantErr: true,
 		},
@@ -157,6 +237,26 @@ func TestValidateSyncConfig(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "s3 endpoint without region",
+			cfg: Config{
+				Sync: &SyncConfig{
+					Backend: "s3",
+					S3:      &S3Config{Bucket: "my-bucket", Endpoint: "https://acct.r2.cloudflarestorage.com"},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "s3 endpoint without scheme",
+			cfg: Config{
+				Sync: &SyncConfig{
+					Backend: "s3",
+					S3:      &S3Config{Bucket: "my-bucket", Region: "us-east-1", Endpoint: "localhost:9000"},
+				},
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -770,6 +870,86 @@ func TestApplyBackendEnv(t *testing.T) {
 	})
 }
 
+func TestApplyPassphraseEnv(t *testing.T) {
+	t.Run("env wins over config passphrase", func(t *testing.T) {
+		t.Setenv("PIPEBOARD_PASSPHRASE", "from-env")
+		cfg := &Config{Sync: &SyncConfig{Passphrase: "from-file"}}
+		applyPassphraseEnv(cfg)
+		if cfg.Sync.Passphrase != "from-env" {
+			t.Errorf("expected env passphrase, got %q", cfg.Sync.Passphrase)
+		}
+	})
+
+	t.Run("env fills a missing passphrase", func(t *testing.T) {
+		t.Setenv("PIPEBOARD_PASSPHRASE", "from-env")
+		cfg := &Config{Sync: &SyncConfig{Encryption: "aes256"}}
+		applyPassphraseEnv(cfg)
+		if cfg.Sync.Passphrase != "from-env" {
+			t.Errorf("expected env passphrase, got %q", cfg.Sync.Passphrase)
+		}
+	})
+
+	t.Run("config kept without env", func(t *testing.T) {
+		t.Setenv("PIPEBOARD_PASSPHRASE", "")
+		cfg := &Config{Sync: &SyncConfig{Passphrase: "from-file"}}
+		applyPassphraseEnv(cfg)
+		if cfg.Sync.Passphrase != "from-file" {
+			t.Errorf("expected file passphrase, got %q", cfg.Sync.Passphrase)
+		}
+	})
+
+	t.Run("does not create sync section", func(t *testing.T) {
+		t.Setenv("PIPEBOARD_PASSPHRASE", "from-env")
+		cfg := &Config{}
+		applyPassphraseEnv(cfg)
+		if cfg.Sync != nil {
+			t.Error("Sync should remain nil")
+		}
+	})
+}
+
+// Test PIPEBOARD_PASSPHRASE reaches sync and history encryption, and an
+// explicit keyring source still takes precedence
+func TestPassphraseEnvPrecedence(t *testing.T) {
+	const base = `version: 1
+sync:
+  backend: local
+  encryption: aes256
+`
+	t.Setenv("PIPEBOARD_PASSPHRASE", "env-secret")
+
+	cleanup := setupSlotsTestConfig(t, base+"  passphrase: file-secret\n")
+	cfg, err := loadConfig()
+	cleanup()
+	if err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+	if cfg.Sync.Passphrase != "env-secret" {
+		t.Errorf("env should override the file passphrase, got %q", cfg.Sync.Passphrase)
+	}
+
+	cleanup = setupSlotsTestConfig(t, base)
+	defer cleanup()
+	if _, err := newRemoteBackendFromConfig(); err != nil {
+		t.Errorf("env passphrase should satisfy encryption without one in the file: %v", err)
+	}
+	if enabled, passphrase := getHistoryEncryptionConfig(); !enabled || passphrase != "env-secret" {
+		t.Errorf("history encryption should use the env passphrase, got %v %q", enabled, passphrase)
+	}
+
+	store := useMockKeyring(t)
+	store[passphraseAccount] = "keyring-secret"
+	cleanup2 := setupSlotsTestConfig(t, base+"  passphrase_source: keyring\n")
+	defer cleanup2()
+	cfg, err = loadConfig()
+	if err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+	if cfg.Sync.Passphrase != "keyring-secret" {
+		t.Errorf("explicit keyring source should beat the env, got %q", cfg.Sync.Passphrase)
+	}
+}
+
 func TestApplyS3EnvAllVars(t *testing.T) {
 	// Save all original env vars
 	envVars := []string{
@@ -778,6 +958,7 @@ func TestApplyS3EnvAllVars(t *testing.T) {
 		"PIPEBOARD_S3_PREFIX",
 		"PIPEBOARD_S3_PROFILE",
 		"PIPEBOARD_S3_SSE",
+		"PIPEBOARD_S3_ENDPOINT",
 	}
 	origVals := make(map[string]string)
 	for _, v := range envVars {
@@ -795,6 +976,7 @@ func TestApplyS3EnvAllVars(t *testing.T) {
 	_ = os.Setenv("PIPEBOARD_S3_PREFIX", "prefix/")
 	_ = os.Setenv("PIPEBOARD_S3_PROFILE", "test-profile")
 	_ = os.Setenv("PIPEBOARD_S3_SSE", "AES256")
+	_ = os.Setenv("PIPEBOARD_S3_ENDPOINT", "http://localhost:9000")
 
 	cfg := &Config{}
 	applyS3Env(cfg)
@@ -817,6 +999,9 @@ func TestApplyS3EnvAllVars(t *testing.T) {
 	if cfg.Sync.S3.SSE != "AES256" {
 		t.Errorf("expected SSE 'AES256', got %s", cfg.Sync.S3.SSE)
 	}
+	if cfg.Sync.S3.Endpoint != "http://localhost:9000" {
+		t.Errorf("expected endpoint 'http://localhost:9000', got %s", cfg.Sync.S3.Endpoint)
+	}
 	if cfg.Sync.Backend != "s3" {
 		t.Errorf("expected backend 's3', got %s", cfg.Sync.Backend)
 	}
@@ -1130,3 +1315,225 @@ aliases:
 		t.Errorf("expected alias p=prod-secrets, got %s", cfg.Aliases["p"])
 	}
 }
+
+const configShowTestYAML = `version: 1
+# shared sync settings
+sync:
+  backend: local
+  encryption: aes256
+  passphrase: "hunter2-secret"
+peers:
+  dev:
+    ssh: devbox
+audit:
+  hmac_key: audit-key-secret
+`
+
+// setupConfigShowTest writes a config file and points PIPEBOARD_CONFIG at it
+func setupConfigShowTest(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("writing config: %v", err)
+	}
+	t.Setenv("PIPEBOARD_CONFIG", path)
+	return path
+}
+
+func TestCmdConfigShowYAML(t *testing.T) {
+	setupConfigShowTest(t, configShowTestYAML)
+
+	var err error
+	out := captureOutput(func() {
+		err = cmdConfig([]string{"show"})
+	})
+	if err != nil {
+		t.Fatalf("config show failed: %v", err)
+	}
+	if strings.Contains(out, "hunter2-secret") {
+		t.Error("passphrase should be redacted in yaml output")
+	}
+
+	var cfg Config
+	if err := yaml.Unmarshal([]byte(out), &cfg); err != nil {
+		t.Fatalf("yaml output is not valid: %v\n%s", err, out)
+	}
+	if cfg.Sync == nil || cfg.Sync.Passphrase != redactedValue {
+		t.Errorf("expected redacted passphrase, got %+v", cfg.Sync)
+	}
+	if cfg.Peers["dev"].SSH != "devbox" {
+		t.Errorf("expected peer dev to be preserved, got %+v", cfg.Peers)
+	}
+}
+
+func TestCmdConfigShowJSON(t *testing.T) {
+	setupConfigShowTest(t, configShowTestYAML)
+
+	var err error
+	out := captureOutput(func() {
+		err = cmdConfig([]string{"show", "--format", "json"})
+	})
+	if err != nil {
+		t.Fatalf("config show --format json failed: %v", err)
+	}
+	if strings.Contains(out, "hunter2-secret") {
+		t.Error("passphrase should be redacted in json output")
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatalf("json output is not valid: %v\n%s", err, out)
+	}
+	sync, ok := doc["sync"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected sync object, got %T", doc["sync"])
+	}
+	if sync["passphrase"] != redactedValue {
+		t.Errorf("expected redacted passphrase, got %v", sync["passphrase"])
+	}
+	if sync["backend"] != "local" {
+		t.Errorf("expected backend local, got %v", sync["backend"])
+	}
+}
+
+func TestCmdConfigShowRaw(t *testing.T) {
+	setupConfigShowTest(t, configShowTestYAML)
+
+	var err error
+	stderr := captureStderr(func() {
+		out := captureOutput(func() {
+			err = cmdConfig([]string{"show", "--format=raw"})
+		})
+		if strings.Contains(out, "hunter2-secret") {
+			t.Error("passphrase should be masked in raw output")
+		}
+		if strings.Contains(out, "audit-key-secret") || !strings.Contains(out, `  hmac_key: "********"`) {
+			t.Errorf("hmac_key should be masked in raw output, got:\n%s", out)
+		}
+		if !strings.Contains(out, "# shared sync settings") {
+			t.Error("raw output should keep comments")
+		}
+		if !strings.Contains(out, `  passphrase: "********"`) {
+			t.Errorf("expected masked passphrase line, got:\n%s", out)
+		}
+		var cfg Config
+		if err := yaml.Unmarshal([]byte(out), &cfg); err != nil {
+			t.Errorf("raw output is not valid yaml: %v", err)
+		}
+	})
+	if err != nil {
+		t.Fatalf("config show --format raw failed: %v", err)
+	}
+	if !strings.Contains(stderr, "warning") {
+		t.Errorf("expected warning on stderr, got %q", stderr)
+	}
+}
+
+func TestCmdConfigShowErrors(t *testing.T) {
+	setupConfigShowTest(t, configShowTestYAML)
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"no subcommand", []string{}, "usage"},
+		{"unknown subcommand", []string{"edit"}, "unknown config subcommand"},
+		{"bad format", []string{"show", "--format", "toml"}, "unknown format"},
+		{"missing format value", []string{"show", "--format"}, "requires a value"},
+		{"unknown flag", []string{"show", "--bogus"}, "unknown argument"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := cmdConfig(tt.args)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+
+	t.Setenv("PIPEBOARD_CONFIG", filepath.Join(t.TempDir(), "missing.yaml"))
+	if err := cmdConfig([]string{"show"}); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("expected not found error, got %v", err)
+	}
+}
+
+// Test validate warns about a plaintext passphrase in a world-readable file
+// and --fix restricts it to the owner
+func TestCmdConfigValidatePermissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file modes are not enforced on Windows")
+	}
+	path := setupConfigShowTest(t, configShowTestYAML)
+	if err := os.Chmod(path, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var err error
+	stderr := captureStderr(func() {
+		captureOutput(func() { err = cmdConfig([]string{"validate"}) })
+	})
+	if err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	for _, want := range []string{"mode 0644", "sync.passphrase", "keyring", "--fix"} {
+		if !strings.Contains(stderr, want) {
+			t.Errorf("warning should mention %q, got:\n%s", want, stderr)
+		}
+	}
+
+	out := captureOutput(func() { err = cmdConfig([]string{"validate", "--fix"}) })
+	if err != nil || !strings.Contains(out, "0600") {
+		t.Fatalf("validate --fix: %v, %q", err, out)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Errorf("mode after --fix = %04o, want 0600", info.Mode().Perm())
+	}
+
+	stderr = captureStderr(func() {
+		out = captureOutput(func() { err = cmdConfig([]string{"validate"}) })
+	})
+	if err != nil || stderr != "" || !strings.Contains(out, "config ok") {
+		t.Errorf("0600 config should pass clean, got err=%v stdout=%q stderr=%q", err, out, stderr)
+	}
+}
+
+// Test validate ignores readable files without secrets and reports schema
+// errors
+func TestCmdConfigValidate(t *testing.T) {
+	path := setupConfigShowTest(t, "version: 1\nsync:\n  backend: local\n  passphrase: ${PIPEBOARD_PASSPHRASE}\n")
+	_ = os.Chmod(path, 0644)
+	var err error
+	stderr := captureStderr(func() {
+		captureOutput(func() { err = cmdConfig([]string{"validate"}) })
+	})
+	if err != nil || stderr != "" {
+		t.Errorf("placeholder passphrase should not warn, got err=%v stderr=%q", err, stderr)
+	}
+
+	setupConfigShowTest(t, "version: 1\nsync:\n  backend: s3\n")
+	if err := cmdConfig([]string{"validate"}); err == nil || !strings.Contains(err.Error(), "s3") {
+		t.Errorf("expected sync validation error, got %v", err)
+	}
+	if err := cmdConfig([]string{"validate", "--bogus"}); err == nil || !strings.Contains(err.Error(), "unknown argument") {
+		t.Errorf("expected unknown argument error, got %v", err)
+	}
+}
+
+func TestRedactSecretsNested(t *testing.T) {
+	doc := map[string]interface{}{
+		"passphrase": "top",
+		"list":       []interface{}{map[string]interface{}{"passphrase": "nested"}},
+		"empty":      map[string]interface{}{"passphrase": ""},
+	}
+	redactSecrets(doc)
+	if doc["passphrase"] != redactedValue {
+		t.Errorf("top-level passphrase not redacted: %v", doc["passphrase"])
+	}
+	if doc["list"].([]interface{})[0].(map[string]interface{})["passphrase"] != redactedValue {
+		t.Error("nested passphrase not redacted")
+	}
+	if doc["empty"].(map[string]interface{})["passphrase"] != "" {
+		t.Error("empty passphrase should stay empty")
+	}
+}
@@ -0,0 +1,363 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// maxDiffCells bounds the line-diff table (lines × lines after trimming
+// the common prefix and suffix) so huge inputs don't exhaust memory
+const maxDiffCells = 4 << 20
+
+func cmdDiff(args []string) error {
+	const usage = "usage: pipeboard diff <slot> [other-slot] [--semantic] [--format json|yaml]"
+	var semantic bool
+	var format string
+	var positional []string
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; {
+		case arg == "--semantic":
+			semantic = true
+		case arg == "--format", arg == "-f":
+			if i+1 >= len(args) {
+				return fmt.Errorf("--format requires json or yaml\n%s", usage)
+			}
+			i++
+			format = args[i]
+		case strings.HasPrefix(arg, "-"):
+			return fmt.Errorf("unknown flag: %s\n%s", arg, usage)
+		default:
+			positional = append(positional, arg)
+		}
+	}
+	if len(positional) < 1 || len(positional) > 2 {
+		return errors.New(usage)
+	}
+	if format != "" && format != "json" && format != "yaml" {
+		return fmt.Errorf("invalid --format %q (want json or yaml)", format)
+	}
+	if format != "" {
+		semantic = true
+	}
+
+	backend, err := newRemoteBackendFromConfig()
+	if err != nil {
+		return err
+	}
+
+	// A slot against the local clipboard, or two slots
+	nameA := resolveSlotName(positional[0])
+	a, _, err := backend.Pull(nameA)
+	if err != nil {
+		return err
+	}
+	nameB := "clipboard"
+	var b []byte
+	if len(positional) == 2 {
+		nameB = resolveSlotName(positional[1])
+		b, _, err = backend.Pull(nameB)
+	} else {
+		b, err = readClipboard()
+	}
+	if err != nil {
+		return err
+	}
+
+	if semantic {
+		handled, err := writeSemanticDiff(os.Stdout, nameA, nameB, a, b, format)
+		if handled || err != nil {
+			return err
+		}
+		debugLog("diff: content is not JSON/YAML, falling back to a text diff")
+	}
+	writeTextDiff(os.Stdout, nameA, nameB, a, b)
+	return nil
+}
+
+// structuredFormat reports whether data is a JSON or YAML document
+// ("json", "yaml"), or "" for anything else. Scalar YAML is not
+// counted, since any line of plain text parses as one.
+func structuredFormat(data []byte) string {
+	if !strings.HasPrefix(detectMIME(data), "text/") {
+		return ""
+	}
+	if json.Valid(data) {
+		return "json"
+	}
+	var v interface{}
+	if yaml.Unmarshal(data, &v) != nil {
+		return ""
+	}
+	switch v.(type) {
+	case map[string]interface{}, map[interface{}]interface{}, []interface{}:
+		return "yaml"
+	}
+	return ""
+}
+
+// parseStructured decodes data as format into plain maps, slices and
+// float64 numbers, so JSON and YAML documents compare alike
+func parseStructured(data []byte, format string) (interface{}, error) {
+	var v interface{}
+	var err error
+	if format == "json" {
+		err = json.Unmarshal(data, &v)
+	} else {
+		err = yaml.Unmarshal(data, &v)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return normalizeStructured(v), nil
+}
+
+func normalizeStructured(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, e := range t {
+			t[k] = normalizeStructured(e)
+		}
+		return t
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, e := range t {
+			m[fmt.Sprint(k)] = normalizeStructured(e)
+		}
+		return m
+	case []interface{}:
+		for i, e := range t {
+			t[i] = normalizeStructured(e)
+		}
+		return t
+	case int:
+		return float64(t)
+	case int64:
+		return float64(t)
+	case uint64:
+		return float64(t)
+	}
+	return v
+}
+
+// semanticChange is one added, removed or changed value in a
+// structured diff
+type semanticChange struct {
+	Kind     byte // '+', '-' or '~'
+	Path     string
+	Old, New interface{}
+}
+
+// diffStructured compares a and b, recording changes under path
+func diffStructured(path string, a, b interface{}, changes *[]semanticChange) {
+	switch ta := a.(type) {
+	case map[string]interface{}:
+		tb, ok := b.(map[string]interface{})
+		if !ok {
+			break
+		}
+		keys := make(map[string]bool, len(ta)+len(tb))
+		for k := range ta {
+			keys[k] = true
+		}
+		for k := range tb {
+			keys[k] = true
+		}
+		sorted := make([]string, 0, len(keys))
+		for k := range keys {
+			sorted = append(sorted, k)
+		}
+		sort.Strings(sorted)
+		for _, k := range sorted {
+			va, inA := ta[k]
+			vb, inB := tb[k]
+			child := path + "." + k
+			switch {
+			case !inB:
+				*changes = append(*changes, semanticChange{Kind: '-', Path: child, Old: va})
+			case !inA:
+				*changes = append(*changes, semanticChange{Kind: '+', Path: child, New: vb})
+			default:
+				diffStructured(child, va, vb, changes)
+			}
+		}
+		return
+	case []interface{}:
+		tb, ok := b.([]interface{})
+		if !ok {
+			break
+		}
+		for i := 0; i < len(ta) || i < len(tb); i++ {
+			child := fmt.Sprintf("%s[%d]", path, i)
+			switch {
+			case i >= len(tb):
+				*changes = append(*changes, semanticChange{Kind: '-', Path: child, Old: ta[i]})
+			case i >= len(ta):
+				*changes = append(*changes, semanticChange{Kind: '+', Path: child, New: tb[i]})
+			default:
+				diffStructured(child, ta[i], tb[i], changes)
+			}
+		}
+		return
+	default:
+		if a == b {
+			return
+		}
+	}
+	*changes = append(*changes, semanticChange{Kind: '~', Path: path, Old: a, New: b})
+}
+
+// writeSemanticDiff reports key-level differences between two JSON/YAML
+// documents. It returns false without writing anything when either side
+// isn't structured and no format was forced, so the caller can fall
+// back to a text diff.
+func writeSemanticDiff(w io.Writer, nameA, nameB string, a, b []byte, format string) (bool, error) {
+	formatA, formatB := format, format
+	if format == "" {
+		formatA, formatB = structuredFormat(a), structuredFormat(b)
+		if formatA == "" || formatB == "" {
+			return false, nil
+		}
+	}
+	va, err := parseStructured(a, formatA)
+	if err != nil {
+		return true, fmt.Errorf("parsing %s as %s: %w", nameA, formatA, err)
+	}
+	vb, err := parseStructured(b, formatB)
+	if err != nil {
+		return true, fmt.Errorf("parsing %s as %s: %w", nameB, formatB, err)
+	}
+
+	var changes []semanticChange
+	diffStructured("", va, vb, &changes)
+	if len(changes) == 0 {
+		if bytes.Equal(a, b) {
+			fmt.Fprintf(w, "no differences\n")
+		} else {
+			fmt.Fprintf(w, "no semantic differences (%s and %s differ only in formatting or key order)\n", nameA, nameB)
+		}
+		return true, nil
+	}
+
+	fmt.Fprintf(w, "--- %s\n+++ %s\n", nameA, nameB)
+	for _, c := range changes {
+		path := c.Path
+		if path == "" {
+			path = "."
+		}
+		switch c.Kind {
+		case '+':
+			fmt.Fprintf(w, "+ %s: %s\n", path, compactValue(c.New))
+		case '-':
+			fmt.Fprintf(w, "- %s: %s\n", path, compactValue(c.Old))
+		default:
+			fmt.Fprintf(w, "~ %s: %s -> %s\n", path, compactValue(c.Old), compactValue(c.New))
+		}
+	}
+	return true, nil
+}
+
+// compactValue renders a structured value on one line
+func compactValue(v interface{}) string {
+	out, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(out)
+}
+
+// writeTextDiff prints a line diff of a and b as unified hunks without
+// context lines (like diff -U0)
+func writeTextDiff(w io.Writer, nameA, nameB string, a, b []byte) {
+	if bytes.Equal(a, b) {
+		fmt.Fprintf(w, "no differences\n")
+		return
+	}
+	linesA, linesB := splitDiffLines(a), splitDiffLines(b)
+
+	// Only the middle between the common prefix and suffix needs diffing
+	pre := 0
+	for pre < len(linesA) && pre < len(linesB) && linesA[pre] == linesB[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(linesA)-pre && suf < len(linesB)-pre && linesA[len(linesA)-1-suf] == linesB[len(linesB)-1-suf] {
+		suf++
+	}
+	midA, midB := linesA[pre:len(linesA)-suf], linesB[pre:len(linesB)-suf]
+
+	fmt.Fprintf(w, "--- %s\n+++ %s\n", nameA, nameB)
+	if len(midA)*len(midB) > maxDiffCells {
+		fmt.Fprintf(w, "@@ -%d,%d +%d,%d @@\n", pre+1, len(midA), pre+1, len(midB))
+		fmt.Fprintf(w, "(%d lines replaced by %d; too large to diff line by line)\n", len(midA), len(midB))
+		return
+	}
+
+	// Longest common subsequence table over the middle section
+	n, m := len(midA), len(midB)
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if midA[i] == midB[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < n || j < m {
+		if i < n && j < m && midA[i] == midB[j] {
+			i++
+			j++
+			continue
+		}
+		// Collect one hunk of removals and additions
+		startA, startB := i, j
+		for i < n || j < m {
+			if i < n && j < m && midA[i] == midB[j] {
+				break
+			}
+			if j >= m || (i < n && lcs[i+1][j] >= lcs[i][j+1]) {
+				i++
+			} else {
+				j++
+			}
+		}
+		fmt.Fprintf(w, "@@ -%s +%s @@\n", hunkRange(pre+startA, i-startA), hunkRange(pre+startB, j-startB))
+		for _, line := range midA[startA:i] {
+			fmt.Fprintf(w, "-%s\n", line)
+		}
+		for _, line := range midB[startB:j] {
+			fmt.Fprintf(w, "+%s\n", line)
+		}
+	}
+}
+
+// hunkRange formats a unified diff range; start is the 0-based index of
+// the first line, and an empty range names the line before it
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+// splitDiffLines splits content into lines, ignoring a final newline
+func splitDiffLines(data []byte) []string {
+	if len(data) == 0 {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+}
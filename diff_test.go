@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// Test reordered JSON keys are equal semantically but differ as text
+func TestDiffReorderedJSON(t *testing.T) {
+	a := []byte(`{"name": "api", "replicas": 2, "labels": {"env": "prod", "tier": "web"}}`)
+	b := []byte("{\n  \"labels\": {\"tier\": \"web\", \"env\": \"prod\"},\n  \"replicas\": 2,\n  \"name\": \"api\"\n}\n")
+
+	var sem bytes.Buffer
+	handled, err := writeSemanticDiff(&sem, "a", "b", a, b, "")
+	if err != nil || !handled {
+		t.Fatalf("semantic diff: handled=%v err=%v", handled, err)
+	}
+	if !strings.Contains(sem.String(), "no semantic differences") {
+		t.Errorf("semantic output = %q, want no semantic differences", sem.String())
+	}
+
+	var text bytes.Buffer
+	writeTextDiff(&text, "a", "b", a, b)
+	if !strings.Contains(text.String(), "-{\"name\"") || !strings.Contains(text.String(), "+  \"replicas\": 2,") {
+		t.Errorf("text diff should report changed lines, got %q", text.String())
+	}
+}
+
+// Test added, removed and changed keys are reported by path
+func TestDiffSemanticChanges(t *testing.T) {
+	a := []byte(`{"spec": {"replicas": 2, "ports": [80, 443]}, "debug": true}`)
+	b := []byte("spec:\n  replicas: 3\n  ports: [80]\nmetadata:\n  env: prod\n")
+
+	var out bytes.Buffer
+	if _, err := writeSemanticDiff(&out, "a", "b", a, b, ""); err != nil {
+		t.Fatal(err)
+	}
+	want := "--- a\n+++ b\n" +
+		"- .debug: true\n" +
+		"+ .metadata: {\"env\":\"prod\"}\n" +
+		"- .spec.ports[1]: 443\n" +
+		"~ .spec.replicas: 2 -> 3\n"
+	if out.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
+
+// Test non-structured content is left to the text diff
+func TestDiffSemanticFallsBack(t *testing.T) {
+	var out bytes.Buffer
+	handled, err := writeSemanticDiff(&out, "a", "b", []byte("plain words\n"), []byte(`{"k": 1}`), "")
+	if handled || err != nil || out.Len() != 0 {
+		t.Errorf("handled=%v err=%v out=%q, want fallback", handled, err, out.String())
+	}
+
+	// A forced format that doesn't parse is an error instead
+	if _, err := writeSemanticDiff(&out, "a", "b", []byte("{not json"), []byte(`{}`), "json"); err == nil || !strings.Contains(err.Error(), "parsing a as json") {
+		t.Errorf("err = %v, want parse error", err)
+	}
+}
+
+func TestWriteTextDiffHunks(t *testing.T) {
+	a := []byte("one\ntwo\nthree\nfour\n")
+	b := []byte("one\n2\nthree\nfour\nfive\n")
+
+	var out bytes.Buffer
+	writeTextDiff(&out, "old", "new", a, b)
+	want := "--- old\n+++ new\n@@ -2,1 +2,1 @@\n-two\n+2\n@@ -4,0 +5,1 @@\n+five\n"
+	if out.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", out.String(), want)
+	}
+
+	out.Reset()
+	writeTextDiff(&out, "old", "new", a, a)
+	if out.String() != "no differences\n" {
+		t.Errorf("identical: %q", out.String())
+	}
+}
+
+func TestStructuredFormat(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{`{"a": 1}`, "json"},
+		{`[1, 2]`, "json"},
+		{"a: 1\nb: [x]\n", "yaml"},
+		{"just a sentence", ""},
+		{"\x00\x01\x02binary", ""},
+	}
+	for _, tt := range tests {
+		if got := structuredFormat([]byte(tt.in)); got != tt.want {
+			t.Errorf("structuredFormat(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+// Test diff compares a slot with the clipboard and two slots
+func TestCmdDiff(t *testing.T) {
+	defer setupSlotsTestConfig(t, slotsConfigAt(t.TempDir(), ""))()
+	backend, err := newRemoteBackendFromConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = backend.Push("a", []byte(`{"x": 1, "y": 2}`), nil)
+	_ = backend.Push("b", []byte(`{"y": 2, "x": 1}`), nil)
+	useFileClipboard(t, `{"x": 5, "y": 2}`)
+
+	out := captureOutput(func() {
+		if err := cmdDiff([]string{"a", "b", "--semantic"}); err != nil {
+			t.Errorf("diff: %v", err)
+		}
+	})
+	if !strings.Contains(out, "no semantic differences (a and b") {
+		t.Errorf("two slots: %q", out)
+	}
+
+	out = captureOutput(func() {
+		if err := cmdDiff([]string{"a", "--semantic"}); err != nil {
+			t.Errorf("diff: %v", err)
+		}
+	})
+	if !strings.Contains(out, "+++ clipboard") || !strings.Contains(out, "~ .x: 1 -> 5") {
+		t.Errorf("slot vs clipboard: %q", out)
+	}
+
+	if err := cmdDiff([]string{"a", "--format", "toml"}); err == nil {
+		t.Error("invalid --format should fail")
+	}
+	if err := cmdDiff(nil); err == nil || !strings.Contains(err.Error(), "usage") {
+		t.Errorf("err = %v, want usage", err)
+	}
+}
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+	"time"
+)
+
+// exportedSlot is one slot in a JSON export
+type exportedSlot struct {
+	Slot    string      `json:"slot"`
+	Payload SlotPayload `json:"payload"`
+}
+
+// exportSlotExt names the slot payloads inside an export tar
+const exportSlotExt = ".json"
+
+func cmdExport(args []string) error {
+	const usage = "usage: pipeboard export [--output <file>] [--force] [--json]"
+	var outputPath string
+	var force, asJSON bool
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; arg {
+		case "--output", "-o":
+			if i+1 >= len(args) {
+				return fmt.Errorf("%s requires a file path\n%s", arg, usage)
+			}
+			i++
+			outputPath = args[i]
+		case "--force":
+			force = true
+		case "--json":
+			asJSON = true
+		default:
+			return fmt.Errorf("unknown argument: %s\n%s", arg, usage)
+		}
+	}
+	if force && outputPath == "" {
+		return fmt.Errorf("--force requires --output\n%s", usage)
+	}
+	if outputPath == "" && stdoutIsTerminal() {
+		return fmt.Errorf("refusing to write an export to the terminal; use --output <file> or redirect stdout\n%s", usage)
+	}
+
+	backend, err := newRemoteBackendFromConfig()
+	if err != nil {
+		return err
+	}
+	slots, err := exportSlots(backend)
+	if err != nil {
+		return err
+	}
+
+	var data []byte
+	if asJSON {
+		data, err = json.MarshalIndent(slots, "", "  ")
+		if err == nil {
+			data = append(data, '\n')
+		}
+	} else {
+		data, err = buildExportArchive(slots)
+	}
+	if err != nil {
+		return fmt.Errorf("encoding export: %w", err)
+	}
+
+	if outputPath == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	if err := writeOutputFile(outputPath, data, force); err != nil {
+		return err
+	}
+	printInfo("exported %d slot(s) to %s\n", len(slots), outputPath)
+	return nil
+}
+
+// exportSlots reads the stored payload of every slot. Data stays encoded
+// exactly as stored, so encrypted slots are exported still encrypted.
+func exportSlots(backend RemoteBackend) ([]exportedSlot, error) {
+	inspector, ok := backend.(InspectableBackend)
+	if !ok {
+		return nil, errors.New("export needs the local, s3 or gcs sync backend; the hosted backend only returns decrypted slot data")
+	}
+	list, err := backend.List()
+	if err != nil {
+		return nil, err
+	}
+	slots := make([]exportedSlot, 0, len(list))
+	for _, s := range list {
+		payload, _, err := inspector.Inspect(s.Name)
+		if err != nil {
+			return nil, fmt.Errorf("reading slot %q: %w", s.Name, err)
+		}
+		// Inspect fills in dedup data; the export stands on its own
+		payload.Blob = ""
+		slots = append(slots, exportedSlot{Slot: s.Name, Payload: payload})
+	}
+	return slots, nil
+}
+
+// buildExportArchive writes each slot's payload to a tar entry named
+// after the slot
+func buildExportArchive(slots []exportedSlot) ([]byte, error) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	now := time.Now()
+	for _, s := range slots {
+		body, err := marshalSlotPayload(s.Payload, "  ")
+		if err != nil {
+			return nil, err
+		}
+		hdr := &tar.Header{
+			Name:    s.Slot + exportSlotExt,
+			Mode:    0600,
+			Size:    int64(len(body)),
+			ModTime: now,
+			Format:  tar.FormatPAX,
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return nil, err
+		}
+		if _, err := tw.Write(body); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func cmdImport(args []string) error {
+	const usage = "usage: pipeboard import <file|-> [--force]"
+	var path string
+	var force bool
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; {
+		case arg == "--force":
+			force = true
+		case arg == "-":
+			path = arg
+		case strings.HasPrefix(arg, "-"):
+			return fmt.Errorf("unknown flag: %s\n%s", arg, usage)
+		case path != "":
+			return fmt.Errorf("unexpected argument: %s\n%s", arg, usage)
+		default:
+			path = arg
+		}
+	}
+	if path == "" {
+		return errors.New(usage)
+	}
+
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return fmt.Errorf("reading export: %w", err)
+	}
+	slots, err := parseExport(data)
+	if err != nil {
+		return err
+	}
+
+	backend, err := newRemoteBackendFromConfig()
+	if err != nil {
+		return err
+	}
+	imported, skipped, err := importSlots(backend, slots, force)
+	if err != nil {
+		return err
+	}
+	if skipped > 0 {
+		printInfo("imported %d slot(s), skipped %d existing (use --force to overwrite)\n", imported, skipped)
+	} else {
+		printInfo("imported %d slot(s)\n", imported)
+	}
+	return nil
+}
+
+// parseExport reads an export written by export, either a tar of slot
+// payloads or a JSON array
+func parseExport(data []byte) ([]exportedSlot, error) {
+	if !isTarArchive(data) {
+		var slots []exportedSlot
+		if err := json.Unmarshal(data, &slots); err != nil {
+			return nil, fmt.Errorf("not a pipeboard export (expected a tar archive or JSON array): %w", err)
+		}
+		for _, s := range slots {
+			if err := checkExportedSlotName(s.Slot); err != nil {
+				return nil, err
+			}
+		}
+		return slots, nil
+	}
+
+	var slots []exportedSlot
+	tr := tar.NewReader(bytes.NewReader(data))
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading export archive: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		name, ok := strings.CutSuffix(hdr.Name, exportSlotExt)
+		if !ok {
+			return nil, fmt.Errorf("unexpected file %q in export archive", hdr.Name)
+		}
+		if err := checkExportedSlotName(name); err != nil {
+			return nil, err
+		}
+		body, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", hdr.Name, err)
+		}
+		var payload SlotPayload
+		if err := json.Unmarshal(body, &payload); err != nil {
+			return nil, fmt.Errorf("decoding %s: %w", hdr.Name, err)
+		}
+		slots = append(slots, exportedSlot{Slot: name, Payload: payload})
+	}
+	return slots, nil
+}
+
+// checkExportedSlotName rejects slot names that would escape the slots
+// directory of the local backend
+func checkExportedSlotName(name string) error {
+	if name == "" || !fs.ValidPath(name) {
+		return fmt.Errorf("invalid slot name %q in export", name)
+	}
+	return nil
+}
+
+// importSlots stores exported payloads as is, skipping slots that already
+// exist unless force is set. It returns how many were imported and skipped.
+func importSlots(backend RemoteBackend, slots []exportedSlot, force bool) (int, int, error) {
+	importer, ok := backend.(PayloadImporter)
+	if !ok {
+		return 0, 0, errors.New("import needs the local, s3 or gcs sync backend; the hosted backend can't store exported payloads as is")
+	}
+	existing := map[string]bool{}
+	if !force {
+		list, err := backend.List()
+		if err != nil {
+			return 0, 0, err
+		}
+		for _, s := range list {
+			existing[s.Name] = true
+		}
+	}
+
+	imported, skipped := 0, 0
+	for _, s := range slots {
+		if err := checkPayloadVersion(s.Slot, s.Payload); err != nil {
+			return imported, skipped, err
+		}
+		if existing[s.Slot] {
+			fmt.Fprintf(os.Stderr, "warning: slot %q already exists; skipped\n", s.Slot)
+			skipped++
+			continue
+		}
+		err := importer.ImportPayload(s.Slot, s.Payload)
+		recordAudit(AuditRecord{Op: "import", Slot: s.Slot, Size: int64(s.Payload.Len)}, err)
+		if err != nil {
+			return imported, skipped, fmt.Errorf("importing slot %q: %w", s.Slot, err)
+		}
+		imported++
+	}
+	return imported, skipped, nil
+}
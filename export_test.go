@@ -0,0 +1,169 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Test an export of encrypted slots imports into another backend without
+// ever being decrypted
+func TestCmdExportImport(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	large := strings.Repeat("apiVersion: v1\n", 200)
+
+	cleanup := setupSlotsTestConfig(t, slotsConfigAt(src, "  encryption: aes256\n  passphrase: secret\n  dedup: true\n"))
+	backend, err := newRemoteBackendFromConfig()
+	if err != nil {
+		t.Fatalf("backend: %v", err)
+	}
+	for slot, data := range map[string]string{"kube": large, "token": "s3cr3t-token"} {
+		if err := backend.Push(slot, []byte(data), nil); err != nil {
+			t.Fatalf("push %s: %v", slot, err)
+		}
+	}
+	archive := filepath.Join(t.TempDir(), "slots.tar")
+	if err := cmdExport([]string{"-o", archive}); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	if err := cmdExport([]string{"-o", archive}); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("expected an error for an existing output file, got %v", err)
+	}
+	cleanup()
+
+	exported, err := os.ReadFile(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(exported, []byte("s3cr3t")) {
+		t.Error("export contains plaintext slot data")
+	}
+
+	// Importing needs no passphrase; the payloads are stored as is
+	cleanup = setupSlotsTestConfig(t, slotsConfigAt(dst, ""))
+	if err := cmdImport([]string{archive}); err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	cleanup()
+	var payload SlotPayload
+	jsonData, err := os.ReadFile(filepath.Join(dst, "token.pb"))
+	if err != nil {
+		t.Fatalf("reading imported slot: %v", err)
+	}
+	if err := json.Unmarshal(jsonData, &payload); err != nil {
+		t.Fatal(err)
+	}
+	if !payload.Encrypted || payload.Blob != "" || payload.DataB64 == "" {
+		t.Errorf("imported payload = %+v, want encrypted data stored inline", payload)
+	}
+
+	cleanup = setupSlotsTestConfig(t, slotsConfigAt(dst, "  encryption: aes256\n  passphrase: secret\n"))
+	defer cleanup()
+	backend, err = newRemoteBackendFromConfig()
+	if err != nil {
+		t.Fatalf("backend: %v", err)
+	}
+	for slot, want := range map[string]string{"kube": large, "token": "s3cr3t-token"} {
+		data, _, err := backend.Pull(slot)
+		if err != nil || string(data) != want {
+			t.Errorf("pull %s: err = %v, got %d bytes, want %d", slot, err, len(data), len(want))
+		}
+	}
+}
+
+// Test import skips existing slots unless --force, and reads JSON exports
+func TestCmdImportExisting(t *testing.T) {
+	dir := t.TempDir()
+	cleanup := setupSlotsTestConfig(t, slotsConfigAt(dir, ""))
+	defer cleanup()
+	backend, err := newRemoteBackendFromConfig()
+	if err != nil {
+		t.Fatalf("backend: %v", err)
+	}
+	if err := backend.Push("notes", []byte("exported"), nil); err != nil {
+		t.Fatal(err)
+	}
+	export := filepath.Join(t.TempDir(), "slots.json")
+	if err := cmdExport([]string{"--json", "-o", export}); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	if err := backend.Push("notes", []byte("changed"), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var out string
+	stderr := captureStderr(func() {
+		out = captureOutput(func() {
+			if err := cmdImport([]string{export}); err != nil {
+				t.Errorf("import: %v", err)
+			}
+		})
+	})
+	if !strings.Contains(stderr, `slot "notes" already exists`) || !strings.Contains(out, "skipped 1 existing") {
+		t.Errorf("stdout = %q, stderr = %q", out, stderr)
+	}
+	if data, _, _ := backend.Pull("notes"); string(data) != "changed" {
+		t.Errorf("existing slot overwritten without --force: %q", data)
+	}
+
+	if err := cmdImport([]string{export, "--force"}); err != nil {
+		t.Fatalf("import --force: %v", err)
+	}
+	if data, _, _ := backend.Pull("notes"); string(data) != "exported" {
+		t.Errorf("pull after import --force = %q", data)
+	}
+}
+
+func TestCmdExportImportErrors(t *testing.T) {
+	dir := t.TempDir()
+	cleanup := setupSlotsTestConfig(t, slotsConfigAt(dir, ""))
+	defer cleanup()
+
+	useTerminalStdout(t)
+	if err := cmdExport(nil); err == nil || !strings.Contains(err.Error(), "--output") {
+		t.Errorf("expected an error exporting to a terminal, got %v", err)
+	}
+	if err := cmdExport([]string{"--force"}); err == nil || !strings.Contains(err.Error(), "--force requires --output") {
+		t.Errorf("expected --force to require --output, got %v", err)
+	}
+	if err := cmdImport(nil); err == nil || !strings.Contains(err.Error(), "usage") {
+		t.Errorf("expected usage error, got %v", err)
+	}
+
+	notExport := filepath.Join(t.TempDir(), "notes.txt")
+	_ = os.WriteFile(notExport, []byte("hello"), 0600)
+	if err := cmdImport([]string{notExport}); err == nil || !strings.Contains(err.Error(), "not a pipeboard export") {
+		t.Errorf("expected an error for a non-export file, got %v", err)
+	}
+
+	// Entries can't escape the slots directory
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	_ = tw.WriteHeader(&tar.Header{Name: "../evil.json", Mode: 0600, Size: 2})
+	_, _ = tw.Write([]byte("{}"))
+	_ = tw.Close()
+	evil := filepath.Join(t.TempDir(), "evil.tar")
+	_ = os.WriteFile(evil, buf.Bytes(), 0600)
+	if err := cmdImport([]string{evil}); err == nil || !strings.Contains(err.Error(), "invalid slot name") {
+		t.Errorf("expected an invalid slot name error, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(dir), "evil.pb")); err == nil {
+		t.Error("import wrote outside the slots directory")
+	}
+}
+
+// Test the hosted backend, which can't read or store raw payloads, is
+// rejected with a pointer to the backends that can
+func TestExportImportHosted(t *testing.T) {
+	hosted := &HostedBackend{}
+	if _, err := exportSlots(hosted); err == nil || !strings.Contains(err.Error(), "local, s3 or gcs") {
+		t.Errorf("expected export to reject the hosted backend, got %v", err)
+	}
+	if _, _, err := importSlots(hosted, []exportedSlot{{Slot: "notes"}}, true); err == nil || !strings.Contains(err.Error(), "local, s3 or gcs") {
+		t.Errorf("expected import to reject the hosted backend, got %v", err)
+	}
+}
@@ -2,26 +2,81 @@ package main
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"net/url"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 )
 
+// maxFxCacheBytes bounds the on-disk size of cached transform output
+const maxFxCacheBytes = 16 << 20 // 16 MiB
+
+// errFxEmptyOutput marks a transform that succeeded but printed nothing.
+// Unless fx --allow-empty is given the chain stops there rather than
+// running the next steps on nothing or clearing the clipboard.
+var errFxEmptyOutput = errors.New("produced empty output")
+
+// errFxTimeout marks a transform killed for running past its timeout
+var errFxTimeout = errors.New("timed out")
+
 // cmdFx runs a user-defined clipboard transform (supports chaining)
 func cmdFx(args []string) error {
+	const usage = "usage: pipeboard fx <name> [name2...] [--dry-run] [--allow-empty] [--timeout <duration>] [--stdin] [--stdout] [--slot <name> [--to-slot <name>]]\n       pipeboard fx --list [--show-builtin] [--json]\n       pipeboard fx --check [--json]"
+
 	// Parse flags and collect transform names
-	var dryRun bool
-	var listMode bool
+	var dryRun, allowEmpty, fromStdin, toStdout bool
+	var listMode, checkMode, showBuiltin, jsonOutput bool
+	var fromSlot, toSlot, timeout string
 	var fxNames []string
 
-	for _, arg := range args {
-		switch arg {
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; arg {
 		case "--list", "-l":
 			listMode = true
+		case "--check":
+			checkMode = true
+		case "--show-builtin":
+			showBuiltin = true
+		case "--json":
+			jsonOutput = true
 		case "--dry-run", "-n":
 			dryRun = true
+		case "--allow-empty":
+			allowEmpty = true
+		case "--stdin":
+			fromStdin = true
+		case "--stdout":
+			toStdout = true
+		case "--slot", "--to-slot":
+			if i+1 >= len(args) {
+				return fmt.Errorf("%s requires a slot name", arg)
+			}
+			i++
+			if arg == "--slot" {
+				fromSlot = args[i]
+			} else {
+				toSlot = args[i]
+			}
+		case "--timeout":
+			if i+1 >= len(args) {
+				return fmt.Errorf("--timeout requires a duration (e.g. 5s)")
+			}
+			i++
+			timeout = args[i]
+			if d, err := time.ParseDuration(timeout); err != nil || d <= 0 {
+				return fmt.Errorf("invalid --timeout: %s (use a duration like 5s)", timeout)
+			}
 		default:
 			if strings.HasPrefix(arg, "-") {
 				return fmt.Errorf("unknown flag: %s", arg)
@@ -30,6 +85,16 @@ func cmdFx(args []string) error {
 		}
 	}
 
+	if listMode && checkMode {
+		return fmt.Errorf("--list and --check can't be combined\n%s", usage)
+	}
+	if showBuiltin && !listMode {
+		return fmt.Errorf("--show-builtin requires --list\n%s", usage)
+	}
+	if jsonOutput && !listMode && !checkMode {
+		return fmt.Errorf("--json requires --list or --check\n%s", usage)
+	}
+
 	cfg, err := loadConfigForFx()
 	if err != nil {
 		return err
@@ -37,12 +102,30 @@ func cmdFx(args []string) error {
 
 	// List mode
 	if listMode {
+		if jsonOutput {
+			return fxListJSON(cfg)
+		}
+		if showBuiltin {
+			return fxListSources(cfg)
+		}
 		return fxList(cfg)
 	}
+	if checkMode {
+		return fxCheck(cfg, jsonOutput)
+	}
 
 	// Require at least one transform name
 	if len(fxNames) == 0 {
-		return fmt.Errorf("usage: pipeboard fx <name> [name2...] [--dry-run]\n       pipeboard fx --list")
+		return errors.New(usage)
+	}
+	if toSlot != "" && fromSlot == "" {
+		return fmt.Errorf("--to-slot requires --slot\n%s", usage)
+	}
+	if fromStdin && fromSlot != "" {
+		return fmt.Errorf("--stdin and --slot can't be combined\n%s", usage)
+	}
+	if toStdout && toSlot != "" {
+		return fmt.Errorf("--stdout and --to-slot can't be combined\n%s", usage)
 	}
 
 	// Validate all transforms exist before reading clipboard
@@ -52,63 +135,152 @@ func cmdFx(args []string) error {
 		if err != nil {
 			return err
 		}
+		// --timeout replaces each step's configured timeout
+		if timeout != "" {
+			fx.Timeout = timeout
+		}
 		transforms = append(transforms, fx)
 	}
 
-	// Read clipboard
-	data, err := readClipboard()
-	if err != nil {
-		return fmt.Errorf("reading clipboard: %w", err)
+	// Slot mode reads from and writes to the remote backend, leaving the
+	// clipboard alone. The result goes back to the source slot unless
+	// --to-slot names another.
+	// --stdin and --stdout swap the clipboard for the pipe on either side.
+	var backend RemoteBackend
+	var data []byte
+	target := "clipboard"
+	if toStdout {
+		target = "stdout"
+	}
+	if fromSlot != "" {
+		fromSlot = resolveSlotName(fromSlot)
+		if !toStdout {
+			if toSlot == "" {
+				toSlot = fromSlot
+			}
+			toSlot = resolveSlotName(toSlot)
+			target = fmt.Sprintf("slot %q", toSlot)
+		}
+
+		backend, err = newRemoteBackendFromConfig()
+		if err != nil {
+			return err
+		}
+		data, _, err = backend.Pull(fromSlot)
+		if err != nil {
+			return err
+		}
+	} else if fromStdin {
+		data, err = io.ReadAll(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("reading stdin: %w", err)
+		}
+	} else {
+		data, err = readClipboard()
+		if err != nil {
+			return fmt.Errorf("reading clipboard: %w", err)
+		}
 	}
 	originalSize := len(data)
 
 	// Run transforms in order, feeding output → input
-	// If any step fails, abort without modifying clipboard
+	// If any step fails, abort without modifying the target, unless the
+	// transform is on_error: passthrough, which hands its input on instead.
+	// A step with empty output ends the chain without writing anything.
 	result := data
 	for i, fx := range transforms {
-		cmdArgs := fx.getCommand()
-		result, err = runTransform(cmdArgs, result)
-		if err != nil {
-			return fmt.Errorf("transform %q (step %d) failed: %w; clipboard unchanged", fxNames[i], i+1, err)
+		out, err := runFxTransform(fxNames[i], fx, result)
+		if err == nil && len(out) == 0 && !allowEmpty {
+			err = errFxEmptyOutput
 		}
-		// Check for empty output
-		if len(result) == 0 {
-			return fmt.Errorf("transform %q (step %d) produced empty output; clipboard unchanged", fxNames[i], i+1)
+		if err != nil {
+			if fx.OnError == "passthrough" {
+				fmt.Fprintf(os.Stderr, "warning: transform %q (step %d) failed: %v; passing its input through\n", fxNames[i], i+1, err)
+				continue
+			}
+			if err == errFxEmptyOutput {
+				fmt.Fprintf(os.Stderr, "transform chain produced empty output at %q (step %d); %s unchanged\n", fxNames[i], i+1, target)
+				return nil
+			}
+			if errors.Is(err, errFxTimeout) {
+				return fmt.Errorf("transform %q (step %d) %v; %s unchanged", fxNames[i], i+1, err, target)
+			}
+			return fmt.Errorf("transform %q (step %d) failed: %w; %s unchanged", fxNames[i], i+1, err, target)
 		}
+		result = out
 	}
 
-	// Dry run mode - print result to stdout, never touch clipboard
+	// Dry run mode - print result to stdout, never touch clipboard or slot
 	if dryRun {
 		_, err = os.Stdout.Write(result)
 		return err
 	}
 
+	chainDesc := strings.Join(fxNames, " → ")
+	if toStdout {
+		if _, err := os.Stdout.Write(result); err != nil {
+			return err
+		}
+		recordHistory("fx:"+chainDesc, fromSlot, int64(len(result)))
+		return nil
+	}
+	if backend != nil {
+		if err := checkSecretPolicy(result, "push"); err != nil {
+			return err
+		}
+		cfg, err := loadConfigForAliases()
+		if err != nil {
+			return err
+		}
+		meta := map[string]string{"hostname": slotHostname(cfg)}
+		if err := backend.Push(toSlot, result, meta); err != nil {
+			return err
+		}
+		fmt.Printf("fx %s: slot %q → %s: %s → %s\n", chainDesc, fromSlot, target, formatSize(int64(originalSize)), formatSize(int64(len(result))))
+		recordHistory("fx:"+chainDesc, toSlot, int64(len(result)))
+		return nil
+	}
+
 	// Write result back to clipboard
 	if err := writeClipboard(result); err != nil {
 		return fmt.Errorf("writing clipboard: %w", err)
 	}
 
 	// Report what happened
-	chainDesc := strings.Join(fxNames, " → ")
 	fmt.Printf("fx %s: %s → %s\n", chainDesc, formatSize(int64(originalSize)), formatSize(int64(len(result))))
 	recordHistory("fx:"+chainDesc, "", int64(len(result)))
 	return nil
 }
 
-// fxList prints available transforms
-func fxList(cfg *Config) error {
-	if len(cfg.Fx) == 0 {
-		fmt.Println("No transforms defined.")
-		fmt.Println("\nAdd transforms to your config:")
-		fmt.Println("  fx:")
-		fmt.Println("    pretty-json:")
-		fmt.Println("      cmd: [\"jq\", \".\"]")
-		fmt.Println("      description: \"Format JSON\"")
-		return nil
+// fxListEntry describes one transform for fx --list --show-builtin and
+// --json. OverridesBuiltin marks a config transform that shadows the
+// builtin of the same name.
+type fxListEntry struct {
+	Name             string `json:"name"`
+	Description      string `json:"description"`
+	Source           string `json:"source"` // "config" or "builtin"
+	OverridesBuiltin bool   `json:"overrides_builtin,omitempty"`
+}
+
+// fxListEntries returns the transforms fx can run: those in config, then
+// the builtins they don't override, each sorted by name
+func fxListEntries(cfg *Config) []fxListEntry {
+	configured := make([]string, 0, len(cfg.Fx))
+	for name := range cfg.Fx {
+		configured = append(configured, name)
+	}
+	sort.Strings(configured)
+	builtins := make([]string, 0, len(fxBuiltins))
+	for name := range fxBuiltins {
+		if _, ok := cfg.Fx[name]; !ok {
+			builtins = append(builtins, name)
+		}
 	}
+	sort.Strings(builtins)
 
-	fmt.Printf("%-20s  %s\n", "NAME", "DESCRIPTION")
-	for name, fx := range cfg.Fx {
+	entries := make([]fxListEntry, 0, len(configured)+len(builtins))
+	for _, name := range configured {
+		fx := cfg.Fx[name]
 		desc := fx.Description
 		if desc == "" {
 			if fx.Shell != "" {
@@ -121,25 +293,186 @@ func fxList(cfg *Config) error {
 				desc = desc[:47] + "..."
 			}
 		}
-		fmt.Printf("%-20s  %s\n", name, desc)
+		_, overrides := fxBuiltins[name]
+		entries = append(entries, fxListEntry{Name: name, Description: desc, Source: "config", OverridesBuiltin: overrides})
+	}
+	for _, name := range builtins {
+		entries = append(entries, fxListEntry{Name: name, Description: fxBuiltins[name].description, Source: "builtin"})
+	}
+	return entries
+}
+
+// fxList prints available transforms: those in config, then the
+// builtins they don't override
+func fxList(cfg *Config) error {
+	fmt.Printf("%-20s  %s\n", "NAME", "DESCRIPTION")
+	for _, e := range fxListEntries(cfg) {
+		if e.Source == "builtin" {
+			fmt.Printf("%-20s  %s (builtin)\n", e.Name, e.Description)
+		} else {
+			fmt.Printf("%-20s  %s\n", e.Name, e.Description)
+		}
+	}
+	printFxConfigHint(cfg)
+	return nil
+}
+
+// fxListSources is fx --list --show-builtin: the same transforms with a
+// SOURCE column, so a config transform shadowing a builtin stands out
+func fxListSources(cfg *Config) error {
+	fmt.Printf("%-20s  %-26s  %s\n", "NAME", "SOURCE", "DESCRIPTION")
+	for _, e := range fxListEntries(cfg) {
+		source := e.Source
+		if e.OverridesBuiltin {
+			source += " (overrides builtin)"
+		}
+		fmt.Printf("%-20s  %-26s  %s\n", e.Name, source, e.Description)
+	}
+	printFxConfigHint(cfg)
+	return nil
+}
+
+// fxListJSON is fx --list --json
+func fxListJSON(cfg *Config) error {
+	out, err := marshalJSONOutput(fxListEntries(cfg))
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(out))
+	return nil
+}
+
+// printFxConfigHint shows how to define a transform when config has none
+func printFxConfigHint(cfg *Config) {
+	if len(cfg.Fx) == 0 {
+		fmt.Println("\nNo transforms defined in config. Add your own:")
+		fmt.Println("  fx:")
+		fmt.Println("    pretty-json:")
+		fmt.Println("      cmd: [\"jq\", \".\"]")
+		fmt.Println("      description: \"Format JSON\"")
+	}
+}
+
+// fxCheckResult is one row of fx --check
+type fxCheckResult struct {
+	Name  string `json:"name"`
+	Tool  string `json:"tool"`
+	Found bool   `json:"found"`
+}
+
+// fxCheck is fx --check: it reports whether the command each config
+// transform starts with is installed, and fails if any is missing.
+// Builtins need no tools and aren't listed.
+func fxCheck(cfg *Config, jsonOutput bool) error {
+	names := make([]string, 0, len(cfg.Fx))
+	for name := range cfg.Fx {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	results := make([]fxCheckResult, 0, len(names))
+	missing := 0
+	for _, name := range names {
+		tool := fxTool(cfg.Fx[name])
+		if tool == "" {
+			continue
+		}
+		r := fxCheckResult{Name: name, Tool: tool, Found: hasCmd(tool)}
+		if !r.Found {
+			missing++
+		}
+		results = append(results, r)
+	}
+
+	if jsonOutput {
+		out, err := marshalJSONOutput(results)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+	} else if len(results) == 0 {
+		fmt.Println("No command transforms defined in config.")
+	} else {
+		fmt.Printf("%-20s  %-16s  %s\n", "NAME", "TOOL", "STATUS")
+		for _, r := range results {
+			status := "ok"
+			if !r.Found {
+				status = "missing"
+			}
+			fmt.Printf("%-20s  %-16s  %s\n", r.Name, r.Tool, status)
+		}
+	}
+	if missing > 0 {
+		return fmt.Errorf("%d of %d transforms need a command that isn't installed", missing, len(results))
 	}
 	return nil
 }
 
+// fxShellSkip are shell keywords and builtins that can start a shell
+// transform without naming a tool to look for
+var fxShellSkip = map[string]bool{
+	"if": true, "for": true, "while": true, "until": true, "case": true,
+	"{": true, "(": true, "!": true, "cd": true, "export": true, "set": true,
+	"exec": true, "eval": true, ".": true, "read": true,
+}
+
+// fxTool returns the command a transform runs first: cmd[0], or the
+// first word of shell (past any VAR=value assignments), or "" when
+// there's nothing to check
+func fxTool(fx FxConfig) string {
+	if len(fx.Cmd) > 0 {
+		return fx.Cmd[0]
+	}
+	for _, word := range strings.Fields(fx.Shell) {
+		if strings.Contains(word, "=") && !strings.HasPrefix(word, "=") {
+			continue
+		}
+		if fxShellSkip[word] || strings.ContainsAny(word, "$`|;&<>()") {
+			return ""
+		}
+		return strings.Trim(word, `"'`)
+	}
+	return ""
+}
+
 // runTransform executes a transform command with input data
 func runTransform(cmdArgs []string, input []byte) ([]byte, error) {
+	return runTransformEnv(cmdArgs, nil, 0, input)
+}
+
+// runTransformEnv runs a transform with KEY=value pairs added to the
+// inherited environment. With a timeout, the command and its children are
+// killed once it passes, and any partial output is discarded.
+func runTransformEnv(cmdArgs, env []string, timeout time.Duration, input []byte) ([]byte, error) {
 	if len(cmdArgs) == 0 {
 		return nil, errors.New("no command specified")
 	}
 
-	cmd := exec.Command(cmdArgs[0], cmdArgs[1:]...)
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	cmd := exec.CommandContext(ctx, cmdArgs[0], cmdArgs[1:]...)
 	cmd.Stdin = bytes.NewReader(input)
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	if timeout > 0 {
+		killProcessGroupOnCancel(cmd)
+		// Don't wait on pipes held open by a killed command's children
+		cmd.WaitDelay = time.Second
+	}
 
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 
 	err := cmd.Run()
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil, fmt.Errorf("%w after %s", errFxTimeout, timeout)
+	}
 	if err != nil {
 		// Include stderr in error message for debugging
 		errMsg := stderr.String()
@@ -151,3 +484,192 @@ func runTransform(cmdArgs []string, input []byte) ([]byte, error) {
 
 	return stdout.Bytes(), nil
 }
+
+// getFxCacheDir returns the directory for cached transform output
+func getFxCacheDir() string {
+	configDir := os.Getenv("XDG_CONFIG_HOME")
+	if configDir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		configDir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(configDir, "pipeboard", "fx-cache")
+}
+
+// fxCacheKey identifies a transform run by name, command, environment, and
+// input hash. Including the command and env means editing a transform
+// invalidates its cache.
+func fxCacheKey(name string, cmdArgs, env []string, input []byte) string {
+	h := sha256.New()
+	h.Write([]byte(name))
+	h.Write([]byte{0})
+	for _, arg := range cmdArgs {
+		h.Write([]byte(arg))
+		h.Write([]byte{0})
+	}
+	h.Write([]byte{0})
+	for _, kv := range env {
+		h.Write([]byte(kv))
+		h.Write([]byte{0})
+	}
+	inputHash := sha256.Sum256(input)
+	h.Write(inputHash[:])
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// runFxTransform runs a configured transform, serving output from the
+// on-disk cache when the transform has cache enabled
+func runFxTransform(name string, fx FxConfig, input []byte) ([]byte, error) {
+	if fx.builtin != nil {
+		return fx.builtin(input)
+	}
+	cmdArgs := fx.getCommand()
+	env := fx.getEnv()
+	timeout := fx.getTimeout()
+	dir := getFxCacheDir()
+	if !fx.Cache || dir == "" {
+		return runTransformEnv(cmdArgs, env, timeout, input)
+	}
+
+	path := filepath.Join(dir, fxCacheKey(name, cmdArgs, env, input))
+	if out, err := os.ReadFile(path); err == nil {
+		debugLog("fx %q: cache hit", name)
+		// Touch the entry so pruning evicts least recently used first
+		now := time.Now()
+		_ = os.Chtimes(path, now, now)
+		return out, nil
+	}
+
+	out, err := runTransformEnv(cmdArgs, env, timeout, input)
+	if err != nil {
+		return nil, err
+	}
+
+	// Caching is best-effort; failures never affect the transform result
+	if len(out) <= maxFxCacheBytes {
+		if err := os.MkdirAll(dir, 0700); err == nil {
+			if err := os.WriteFile(path, out, 0600); err == nil {
+				pruneFxCache(dir, maxFxCacheBytes)
+			}
+		}
+	}
+	return out, nil
+}
+
+// pruneFxCache removes the least recently used entries until the cache
+// directory is within limit bytes
+func pruneFxCache(dir string, limit int64) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+
+	var files []os.FileInfo
+	var total int64
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || info.IsDir() {
+			continue
+		}
+		files = append(files, info)
+		total += info.Size()
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].ModTime().Before(files[j].ModTime())
+	})
+	for _, f := range files {
+		if total <= limit {
+			break
+		}
+		if err := os.Remove(filepath.Join(dir, f.Name())); err == nil {
+			total -= f.Size()
+			debugLog("fx cache: evicted %s", f.Name())
+		}
+	}
+}
+
+// fxBuiltin is a transform that runs in-process, so common operations
+// work without jq, sed or base64 installed
+type fxBuiltin struct {
+	description string
+	run         func(input []byte) ([]byte, error)
+}
+
+// fxBuiltins are available to fx without any config. A transform of the
+// same name under 'fx' in config takes precedence.
+var fxBuiltins = map[string]fxBuiltin{
+	"upper": {"Convert to upper case", func(in []byte) ([]byte, error) {
+		return bytes.ToUpper(in), nil
+	}},
+	"lower": {"Convert to lower case", func(in []byte) ([]byte, error) {
+		return bytes.ToLower(in), nil
+	}},
+	"trim": {"Strip leading and trailing whitespace", func(in []byte) ([]byte, error) {
+		return bytes.TrimSpace(in), nil
+	}},
+	"base64": {"Base64-encode", func(in []byte) ([]byte, error) {
+		return []byte(base64.StdEncoding.EncodeToString(in)), nil
+	}},
+	"base64-decode": {"Base64-decode (padded or unpadded)", func(in []byte) ([]byte, error) {
+		s := string(bytes.TrimSpace(in))
+		if out, err := base64.StdEncoding.DecodeString(s); err == nil {
+			return out, nil
+		}
+		return base64.RawStdEncoding.DecodeString(s)
+	}},
+	"json-pretty": {"Indent JSON", func(in []byte) ([]byte, error) {
+		var out bytes.Buffer
+		if err := json.Indent(&out, in, "", "  "); err != nil {
+			return nil, err
+		}
+		out.WriteByte('\n')
+		return out.Bytes(), nil
+	}},
+	"json-compact": {"Remove insignificant JSON whitespace", func(in []byte) ([]byte, error) {
+		var out bytes.Buffer
+		if err := json.Compact(&out, in); err != nil {
+			return nil, err
+		}
+		return out.Bytes(), nil
+	}},
+	"url-encode": {"Percent-encode for a URL query", func(in []byte) ([]byte, error) {
+		return []byte(url.QueryEscape(string(in))), nil
+	}},
+	"url-decode": {"Decode a percent-encoded string", func(in []byte) ([]byte, error) {
+		out, err := url.QueryUnescape(string(bytes.TrimSpace(in)))
+		return []byte(out), err
+	}},
+	"hex": {"Hex-encode", func(in []byte) ([]byte, error) {
+		return []byte(hex.EncodeToString(in)), nil
+	}},
+	"hex-decode": {"Decode hex digits", func(in []byte) ([]byte, error) {
+		return hex.DecodeString(string(bytes.TrimSpace(in)))
+	}},
+}
+
+// contentCodecs maps the names copy --encode and paste --decode take to
+// the fx builtins that do the work
+var contentCodecs = map[string]struct{ encode, decode string }{
+	"base64": {"base64", "base64-decode"},
+	"hex":    {"hex", "hex-decode"},
+	"url":    {"url-encode", "url-decode"},
+}
+
+// applyCodec encodes or decodes data with one of contentCodecs
+func applyCodec(name string, data []byte, decode bool) ([]byte, error) {
+	codec, ok := contentCodecs[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown encoding %q (use base64, hex, or url)", name)
+	}
+	if !decode {
+		return fxBuiltins[codec.encode].run(data)
+	}
+	out, err := fxBuiltins[codec.decode].run(data)
+	if err != nil {
+		return nil, fmt.Errorf("content is not valid %s: %w", name, err)
+	}
+	return out, nil
+}
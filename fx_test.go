@@ -0,0 +1,716 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// counterFx returns a transform that appends a line to counterFile on
+// every run, so tests can tell whether the command actually executed
+func counterFx(counterFile string, cache bool) FxConfig {
+	return FxConfig{
+		Shell: "echo run >> " + counterFile + "; tr '[:lower:]' '[:upper:]'",
+		Cache: cache,
+	}
+}
+
+func countRuns(t *testing.T, counterFile string) int {
+	t.Helper()
+	data, err := os.ReadFile(counterFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0
+		}
+		t.Fatalf("reading counter: %v", err)
+	}
+	return strings.Count(string(data), "run")
+}
+
+// Test a cached transform only runs once for identical input
+func TestRunFxTransformCacheHit(t *testing.T) {
+	cleanup := setupSlotsTestConfig(t, "")
+	defer cleanup()
+
+	counter := filepath.Join(t.TempDir(), "counter")
+	fx := counterFx(counter, true)
+
+	for i := 0; i < 2; i++ {
+		out, err := runFxTransform("upper", fx, []byte("hello"))
+		if err != nil {
+			t.Fatalf("run %d: %v", i+1, err)
+		}
+		if string(out) != "HELLO" {
+			t.Errorf("run %d: expected HELLO, got %q", i+1, out)
+		}
+	}
+	if n := countRuns(t, counter); n != 1 {
+		t.Errorf("expected transform to run once, ran %d times", n)
+	}
+
+	// Different input misses the cache
+	if _, err := runFxTransform("upper", fx, []byte("world")); err != nil {
+		t.Fatalf("run with new input: %v", err)
+	}
+	if n := countRuns(t, counter); n != 2 {
+		t.Errorf("expected new input to run the transform, ran %d times", n)
+	}
+}
+
+// Test transforms without cache always run
+func TestRunFxTransformNoCache(t *testing.T) {
+	cleanup := setupSlotsTestConfig(t, "")
+	defer cleanup()
+
+	counter := filepath.Join(t.TempDir(), "counter")
+	fx := counterFx(counter, false)
+
+	for i := 0; i < 2; i++ {
+		if _, err := runFxTransform("upper", fx, []byte("hello")); err != nil {
+			t.Fatalf("run %d: %v", i+1, err)
+		}
+	}
+	if n := countRuns(t, counter); n != 2 {
+		t.Errorf("expected uncached transform to run twice, ran %d times", n)
+	}
+	if _, err := os.Stat(getFxCacheDir()); !os.IsNotExist(err) {
+		t.Error("uncached transform should not create a cache directory")
+	}
+}
+
+// Test failed transforms are not cached
+func TestRunFxTransformFailureNotCached(t *testing.T) {
+	cleanup := setupSlotsTestConfig(t, "")
+	defer cleanup()
+
+	fx := FxConfig{Shell: "exit 1", Cache: true}
+	if _, err := runFxTransform("fail", fx, []byte("x")); err == nil {
+		t.Fatal("expected error from failing transform")
+	}
+	entries, _ := os.ReadDir(getFxCacheDir())
+	if len(entries) != 0 {
+		t.Errorf("failed transform should not be cached, found %d entries", len(entries))
+	}
+}
+
+// Test the cache key depends on name, command, env, and input
+func TestFxCacheKey(t *testing.T) {
+	base := fxCacheKey("a", []string{"jq", "."}, nil, []byte("{}"))
+	if base != fxCacheKey("a", []string{"jq", "."}, nil, []byte("{}")) {
+		t.Error("cache key should be deterministic")
+	}
+	variants := []string{
+		fxCacheKey("b", []string{"jq", "."}, nil, []byte("{}")),
+		fxCacheKey("a", []string{"jq", "-c", "."}, nil, []byte("{}")),
+		fxCacheKey("a", []string{"jq", "."}, nil, []byte("[]")),
+		fxCacheKey("a", []string{"jq", "."}, []string{"LANG=C"}, []byte("{}")),
+		fxCacheKey("a", []string{"jq", ".", "LANG=C"}, nil, []byte("{}")),
+	}
+	for i, v := range variants {
+		if v == base {
+			t.Errorf("variant %d should produce a different key", i)
+		}
+	}
+}
+
+// Test pruning evicts least recently used entries first
+func TestPruneFxCache(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	for i, name := range []string{"oldest", "middle", "newest"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, make([]byte, 100), 0600); err != nil {
+			t.Fatal(err)
+		}
+		mtime := now.Add(time.Duration(i-3) * time.Minute)
+		_ = os.Chtimes(path, mtime, mtime)
+	}
+
+	pruneFxCache(dir, 200)
+
+	if _, err := os.Stat(filepath.Join(dir, "oldest")); !os.IsNotExist(err) {
+		t.Error("oldest entry should be evicted")
+	}
+	for _, name := range []string{"middle", "newest"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("%s should remain: %v", name, err)
+		}
+	}
+}
+
+const fxSlotTestConfig = `version: 1
+sync:
+  backend: local
+fx:
+  pretty-json:
+    cmd: ["jq", "."]
+  upper:
+    shell: "tr '[:lower:]' '[:upper:]'"
+`
+
+// Test fx --slot --to-slot transforms a slot without touching the clipboard
+func TestCmdFxSlotToSlot(t *testing.T) {
+	if _, err := exec.LookPath("jq"); err != nil {
+		t.Skip("jq not installed")
+	}
+	cleanup := setupSlotsTestConfig(t, fxSlotTestConfig)
+	defer cleanup()
+	clipPath := useFileClipboard(t, "clipboard contents")
+
+	backend, err := newRemoteBackendFromConfig()
+	if err != nil {
+		t.Fatalf("backend: %v", err)
+	}
+	if err := backend.Push("x", []byte(`{"a":1,"b":[2,3]}`), nil); err != nil {
+		t.Fatalf("push: %v", err)
+	}
+
+	var fxErr error
+	out := captureOutput(func() {
+		fxErr = cmdFx([]string{"pretty-json", "--slot", "x", "--to-slot", "y"})
+	})
+	if fxErr != nil {
+		t.Fatalf("cmdFx: %v", fxErr)
+	}
+	if !strings.Contains(out, `slot "x" → slot "y"`) {
+		t.Errorf("output should name both slots: %q", out)
+	}
+
+	got, _, err := backend.Pull("y")
+	if err != nil {
+		t.Fatalf("pull y: %v", err)
+	}
+	want := "{\n  \"a\": 1,\n  \"b\": [\n    2,\n    3\n  ]\n}\n"
+	if string(got) != want {
+		t.Errorf("slot y = %q, want %q", got, want)
+	}
+
+	// Source slot and clipboard are left alone
+	if src, _, _ := backend.Pull("x"); string(src) != `{"a":1,"b":[2,3]}` {
+		t.Errorf("slot x changed to %q", src)
+	}
+	if clip, _ := os.ReadFile(clipPath); string(clip) != "clipboard contents" {
+		t.Errorf("clipboard changed to %q", clip)
+	}
+}
+
+// Test fx --slot without --to-slot writes the result back to the same slot
+func TestCmdFxSlotInPlace(t *testing.T) {
+	cleanup := setupSlotsTestConfig(t, fxSlotTestConfig)
+	defer cleanup()
+	useFileClipboard(t, "")
+
+	backend, err := newRemoteBackendFromConfig()
+	if err != nil {
+		t.Fatalf("backend: %v", err)
+	}
+	if err := backend.Push("notes", []byte("hello"), nil); err != nil {
+		t.Fatalf("push: %v", err)
+	}
+
+	captureOutput(func() { err = cmdFx([]string{"upper", "--slot", "notes"}) })
+	if err != nil {
+		t.Fatalf("cmdFx: %v", err)
+	}
+	if got, _, _ := backend.Pull("notes"); string(got) != "HELLO" {
+		t.Errorf("slot notes = %q, want %q", got, "HELLO")
+	}
+}
+
+// Test fx slot flag validation
+func TestCmdFxSlotFlagErrors(t *testing.T) {
+	cleanup := setupSlotsTestConfig(t, fxSlotTestConfig)
+	defer cleanup()
+
+	for _, args := range [][]string{
+		{"upper", "--to-slot", "y"},
+		{"upper", "--slot"},
+	} {
+		if err := cmdFx(args); err == nil {
+			t.Errorf("cmdFx(%v) should fail", args)
+		}
+	}
+
+	err := cmdFx([]string{"upper", "--slot", "missing"})
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("expected not found error, got %v", err)
+	}
+}
+
+const fxOnErrorTestConfig = `version: 1
+fx:
+  upper:
+    cmd: ["tr", "a-z", "A-Z"]
+  strict-broken:
+    shell: "echo broken >&2; exit 1"
+  lenient-broken:
+    shell: "echo broken >&2; exit 1"
+    on_error: passthrough
+  lenient-empty:
+    shell: "cat > /dev/null"
+    on_error: passthrough
+  bad-mode:
+    shell: "cat"
+    on_error: ignore
+`
+
+// Test a failing transform aborts by default and leaves the clipboard alone
+func TestCmdFxOnErrorAbort(t *testing.T) {
+	cleanup := setupSlotsTestConfig(t, fxOnErrorTestConfig)
+	defer cleanup()
+	clipPath := useFileClipboard(t, "hello")
+
+	err := cmdFx([]string{"strict-broken", "upper"})
+	if err == nil || !strings.Contains(err.Error(), "clipboard unchanged") {
+		t.Fatalf("expected abort, got %v", err)
+	}
+	if clip, _ := os.ReadFile(clipPath); string(clip) != "hello" {
+		t.Errorf("clipboard = %q, want it untouched", clip)
+	}
+}
+
+// Test on_error: passthrough hands the input to the next step with a warning
+func TestCmdFxOnErrorPassthrough(t *testing.T) {
+	cleanup := setupSlotsTestConfig(t, fxOnErrorTestConfig)
+	defer cleanup()
+	clipPath := useFileClipboard(t, "hello")
+
+	var err error
+	stderr := captureStderr(func() {
+		captureOutput(func() { err = cmdFx([]string{"lenient-broken", "upper", "lenient-empty"}) })
+	})
+	if err != nil {
+		t.Fatalf("cmdFx: %v", err)
+	}
+	if clip, _ := os.ReadFile(clipPath); string(clip) != "HELLO" {
+		t.Errorf("clipboard = %q, want %q", clip, "HELLO")
+	}
+	if !strings.Contains(stderr, `transform "lenient-broken" (step 1) failed`) ||
+		!strings.Contains(stderr, `transform "lenient-empty" (step 3) failed`) {
+		t.Errorf("expected a warning per passed-through step, got %q", stderr)
+	}
+
+	// Passthrough alone writes the original content back
+	captureStderr(func() {
+		captureOutput(func() { err = cmdFx([]string{"lenient-broken"}) })
+	})
+	if err != nil {
+		t.Fatalf("cmdFx: %v", err)
+	}
+	if clip, _ := os.ReadFile(clipPath); string(clip) != "HELLO" {
+		t.Errorf("clipboard = %q, want it unchanged", clip)
+	}
+}
+
+// Test a step with empty output stops the chain without an error, and
+// --allow-empty runs the rest of the chain on it
+func TestCmdFxEmptyOutput(t *testing.T) {
+	cleanup := setupSlotsTestConfig(t, `version: 1
+fx:
+  drop:
+    shell: "cat > /dev/null"
+  mark:
+    shell: "cat; printf done"
+`)
+	defer cleanup()
+	clipPath := useFileClipboard(t, "hello")
+
+	var err error
+	var out string
+	stderr := captureStderr(func() {
+		out = captureOutput(func() { err = cmdFx([]string{"drop", "mark"}) })
+	})
+	if err != nil {
+		t.Fatalf("cmdFx: %v", err)
+	}
+	if !strings.Contains(stderr, `transform chain produced empty output at "drop" (step 1); clipboard unchanged`) {
+		t.Errorf("stderr = %q", stderr)
+	}
+	if out != "" {
+		t.Errorf("stdout = %q, want nothing", out)
+	}
+	if clip, _ := os.ReadFile(clipPath); string(clip) != "hello" {
+		t.Errorf("clipboard = %q, want it untouched", clip)
+	}
+
+	captureOutput(func() { err = cmdFx([]string{"drop", "mark", "--allow-empty"}) })
+	if err != nil {
+		t.Fatalf("cmdFx --allow-empty: %v", err)
+	}
+	if clip, _ := os.ReadFile(clipPath); string(clip) != "done" {
+		t.Errorf("clipboard = %q, want %q", clip, "done")
+	}
+}
+
+// Test --stdin and --stdout run transforms in a pipe without the clipboard
+func TestCmdFxStdinStdout(t *testing.T) {
+	dir := t.TempDir()
+	cleanup := setupSlotsTestConfig(t, slotsConfigAt(dir, ""))
+	defer cleanup()
+	clipPath := useFileClipboard(t, "clipboard")
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldStdin := os.Stdin
+	os.Stdin = r
+	defer func() { os.Stdin = oldStdin }()
+	go func() {
+		_, _ = w.Write([]byte("piped text"))
+		_ = w.Close()
+	}()
+
+	out := captureOutput(func() {
+		if err := cmdFx([]string{"upper", "--stdin", "--stdout"}); err != nil {
+			t.Errorf("cmdFx: %v", err)
+		}
+	})
+	if out != "PIPED TEXT" {
+		t.Errorf("stdout = %q, want only the result", out)
+	}
+	if clip, _ := os.ReadFile(clipPath); string(clip) != "clipboard" {
+		t.Errorf("clipboard = %q, want it untouched", clip)
+	}
+
+	// --stdout with --slot prints the result and leaves the slot alone
+	backend, err := newRemoteBackendFromConfig()
+	if err != nil {
+		t.Fatalf("backend: %v", err)
+	}
+	if err := backend.Push("notes", []byte("stored"), nil); err != nil {
+		t.Fatal(err)
+	}
+	out = captureOutput(func() {
+		if err := cmdFx([]string{"upper", "--slot", "notes", "--stdout"}); err != nil {
+			t.Errorf("cmdFx --slot --stdout: %v", err)
+		}
+	})
+	if out != "STORED" {
+		t.Errorf("stdout = %q, want %q", out, "STORED")
+	}
+	if data, _, _ := backend.Pull("notes"); string(data) != "stored" {
+		t.Errorf("slot = %q, want it untouched", data)
+	}
+
+	if err := cmdFx([]string{"upper", "--stdin", "--slot", "notes"}); err == nil || !strings.Contains(err.Error(), "can't be combined") {
+		t.Errorf("expected --stdin/--slot conflict, got %v", err)
+	}
+	if err := cmdFx([]string{"upper", "--stdout", "--slot", "notes", "--to-slot", "x"}); err == nil || !strings.Contains(err.Error(), "can't be combined") {
+		t.Errorf("expected --stdout/--to-slot conflict, got %v", err)
+	}
+}
+
+// Test an unknown on_error value is rejected before running anything
+func TestCmdFxOnErrorInvalid(t *testing.T) {
+	cleanup := setupSlotsTestConfig(t, fxOnErrorTestConfig)
+	defer cleanup()
+	useFileClipboard(t, "hello")
+
+	err := cmdFx([]string{"bad-mode"})
+	if err == nil || !strings.Contains(err.Error(), "on_error") {
+		t.Errorf("expected on_error validation error, got %v", err)
+	}
+}
+
+// Test each builtin runs in-process, with no commands on PATH
+func TestFxBuiltins(t *testing.T) {
+	t.Setenv("PATH", "")
+	cfg := &Config{}
+
+	tests := []struct {
+		name, in, want string
+	}{
+		{"upper", "Hello, World", "HELLO, WORLD"},
+		{"lower", "Hello, World", "hello, world"},
+		{"trim", "\n\t  padded  \n", "padded"},
+		{"base64", "hi there", "aGkgdGhlcmU="},
+		{"base64-decode", "aGkgdGhlcmU=\n", "hi there"},
+		{"base64-decode", "aGkgdGhlcmU", "hi there"},
+		{"json-pretty", `{"a":[1,2],"b":{}}`, "{\n  \"a\": [\n    1,\n    2\n  ],\n  \"b\": {}\n}\n"},
+		{"json-compact", "{\n  \"a\": [1, 2]\n}\n", `{"a":[1,2]}`},
+		{"url-encode", "a b&c=d/é", "a+b%26c%3Dd%2F%C3%A9"},
+		{"url-decode", "a+b%26c%3Dd%2F%C3%A9\n", "a b&c=d/é"},
+	}
+	for _, tt := range tests {
+		fx, err := cfg.getFx(tt.name)
+		if err != nil {
+			t.Fatalf("getFx(%q): %v", tt.name, err)
+		}
+		out, err := runFxTransform(tt.name, fx, []byte(tt.in))
+		if err != nil {
+			t.Errorf("%s(%q): %v", tt.name, tt.in, err)
+			continue
+		}
+		if string(out) != tt.want {
+			t.Errorf("%s(%q) = %q, want %q", tt.name, tt.in, out, tt.want)
+		}
+	}
+
+	for _, name := range []string{"json-pretty", "base64-decode", "url-decode"} {
+		fx, _ := cfg.getFx(name)
+		if _, err := runFxTransform(name, fx, []byte("%%not {valid")); err == nil {
+			t.Errorf("%s should fail on invalid input", name)
+		}
+	}
+}
+
+// Test a config transform overrides the builtin of the same name
+func TestFxConfigOverridesBuiltin(t *testing.T) {
+	cfg := &Config{Fx: map[string]FxConfig{"upper": {Shell: "echo overridden"}}}
+	fx, err := cfg.getFx("upper")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := runFxTransform("upper", fx, []byte("x"))
+	if err != nil || string(out) != "overridden\n" {
+		t.Errorf("upper = %q, %v; want the config transform", out, err)
+	}
+
+	out2 := captureOutput(func() { _ = fxList(cfg) })
+	if strings.Count(out2, "upper") != 1 || !strings.Contains(out2, "lower") || !strings.Contains(out2, "(builtin)") {
+		t.Errorf("fx --list should show the override once and the other builtins:\n%s", out2)
+	}
+}
+
+// Test fx --list --show-builtin and --json report each transform's source
+// and flag a config transform that shadows a builtin
+func TestFxListShowBuiltin(t *testing.T) {
+	cfg := &Config{Fx: map[string]FxConfig{
+		"upper":  {Shell: "echo overridden"},
+		"redact": {Cmd: []string{"sed", "s/x/y/"}},
+	}}
+
+	out := captureOutput(func() { _ = fxListSources(cfg) })
+	lines := map[string]string{}
+	for _, line := range strings.Split(out, "\n") {
+		if fields := strings.Fields(line); len(fields) > 0 {
+			lines[fields[0]] = line
+		}
+	}
+	if !strings.Contains(lines["NAME"], "SOURCE") {
+		t.Errorf("missing SOURCE header:\n%s", out)
+	}
+	if !strings.Contains(lines["upper"], "config (overrides builtin)") {
+		t.Errorf("upper should be reported as overriding the builtin: %q", lines["upper"])
+	}
+	if l := lines["redact"]; !strings.Contains(l, "config") || strings.Contains(l, "overrides") {
+		t.Errorf("redact = %q, want a plain config transform", l)
+	}
+	if !strings.Contains(lines["lower"], "builtin") {
+		t.Errorf("lower = %q, want builtin", lines["lower"])
+	}
+
+	out = captureOutput(func() {
+		if err := fxListJSON(cfg); err != nil {
+			t.Fatal(err)
+		}
+	})
+	var entries []fxListEntry
+	if err := json.Unmarshal([]byte(out), &entries); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out)
+	}
+	got := map[string]fxListEntry{}
+	for _, e := range entries {
+		if _, dup := got[e.Name]; dup {
+			t.Errorf("%s listed twice", e.Name)
+		}
+		got[e.Name] = e
+	}
+	if e := got["upper"]; e.Source != "config" || !e.OverridesBuiltin {
+		t.Errorf("upper = %+v, want a config override", e)
+	}
+	if e := got["redact"]; e.Source != "config" || e.OverridesBuiltin {
+		t.Errorf("redact = %+v", e)
+	}
+	if e := got["lower"]; e.Source != "builtin" || e.OverridesBuiltin || e.Description == "" {
+		t.Errorf("lower = %+v", e)
+	}
+	if len(got) != len(fxBuiltins)+1 {
+		t.Errorf("got %d transforms, want %d", len(got), len(fxBuiltins)+1)
+	}
+}
+
+// Test --show-builtin and --json are only accepted with --list
+func TestCmdFxListFlagsRequireList(t *testing.T) {
+	for _, flag := range []string{"--show-builtin", "--json"} {
+		if err := cmdFx([]string{"upper", flag}); err == nil || !strings.Contains(err.Error(), "requires --list") {
+			t.Errorf("fx upper %s: err = %v", flag, err)
+		}
+	}
+}
+
+// Test builtins chain with each other through cmdFx without any config
+func TestCmdFxBuiltinChain(t *testing.T) {
+	defer setupSlotsTestConfig(t, "")()
+	clipPath := useFileClipboard(t, "  {\"name\": \"api\"}  \n")
+
+	captureOutput(func() {
+		if err := cmdFx([]string{"trim", "json-compact", "base64"}); err != nil {
+			t.Fatalf("fx: %v", err)
+		}
+	})
+	if got, _ := os.ReadFile(clipPath); string(got) != "eyJuYW1lIjoiYXBpIn0=" {
+		t.Errorf("clipboard = %q", got)
+	}
+}
+
+// Test env values reach the transform, expand ${VAR} from the parent
+// environment, and keep a bare $ literal
+func TestFxEnv(t *testing.T) {
+	t.Setenv("PIPEBOARD_TEST_FX_KEY", "s3cret")
+	t.Setenv("PIPEBOARD_TEST_FX_INHERITED", "inherited")
+	fx := FxConfig{
+		Shell: `printf '%s|%s|%s|%s' "$API_KEY" "$LC_ALL" "$PRICE" "$PIPEBOARD_TEST_FX_INHERITED"`,
+		Env: map[string]string{
+			"API_KEY": "key-${PIPEBOARD_TEST_FX_KEY}",
+			"LC_ALL":  "C",
+			"PRICE":   "$5 ${PIPEBOARD_TEST_FX_UNSET}",
+		},
+	}
+	if got := fx.getEnv(); strings.Join(got, ",") != "API_KEY=key-s3cret,LC_ALL=C,PRICE=$5 " {
+		t.Errorf("getEnv = %q", got)
+	}
+
+	out, err := runFxTransform("env", fx, nil)
+	if err != nil {
+		t.Fatalf("runFxTransform: %v", err)
+	}
+	if string(out) != "key-s3cret|C|$5 |inherited" {
+		t.Errorf("output = %q", out)
+	}
+
+	if (&FxConfig{Cmd: []string{"cat"}}).getEnv() != nil {
+		t.Error("a transform without env should inherit the environment unchanged")
+	}
+
+	cfg := &Config{Fx: map[string]FxConfig{"bad": {Cmd: []string{"cat"}, Env: map[string]string{"A=B": "x"}}}}
+	if _, err := cfg.getFx("bad"); err == nil || !strings.Contains(err.Error(), "invalid env variable name") {
+		t.Errorf("expected invalid env name error, got %v", err)
+	}
+}
+
+// Test a hung transform is killed at its timeout, children included, and
+// the clipboard keeps its content rather than partial output
+func TestCmdFxTimeout(t *testing.T) {
+	defer setupSlotsTestConfig(t, `version: 1
+fx:
+  hang:
+    shell: "printf partial; sleep 30"
+    timeout: 200ms
+  quick:
+    cmd: ["cat"]
+    timeout: 5s
+`)()
+	clipPath := useFileClipboard(t, "original")
+
+	start := time.Now()
+	err := cmdFx([]string{"hang"})
+	if err == nil || !strings.Contains(err.Error(), `transform "hang" (step 1) timed out after 200ms; clipboard unchanged`) {
+		t.Fatalf("err = %v, want timeout error", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("transform ran for %s; the sleep child should have been killed", elapsed)
+	}
+	if got, _ := os.ReadFile(clipPath); string(got) != "original" {
+		t.Errorf("clipboard = %q, want it unchanged", got)
+	}
+
+	// --timeout overrides the configured value
+	if err := cmdFx([]string{"hang", "--timeout", "100ms"}); err == nil || !strings.Contains(err.Error(), "timed out after 100ms") {
+		t.Errorf("err = %v, want the --timeout value", err)
+	}
+
+	captureOutput(func() {
+		if err := cmdFx([]string{"quick"}); err != nil {
+			t.Errorf("fast transform under its timeout: %v", err)
+		}
+	})
+
+	for _, args := range [][]string{{"quick", "--timeout"}, {"quick", "--timeout", "soon"}, {"quick", "--timeout", "0s"}} {
+		if err := cmdFx(args); err == nil || !strings.Contains(err.Error(), "timeout") {
+			t.Errorf("fx %v: err = %v, want timeout flag error", args, err)
+		}
+	}
+
+	cfg := &Config{Fx: map[string]FxConfig{"bad": {Cmd: []string{"cat"}, Timeout: "forever"}}}
+	if _, err := cfg.getFx("bad"); err == nil || !strings.Contains(err.Error(), "invalid timeout") {
+		t.Errorf("expected invalid timeout error, got %v", err)
+	}
+}
+
+// Test fxTool finds the command a transform starts with
+func TestFxTool(t *testing.T) {
+	tests := []struct {
+		fx   FxConfig
+		want string
+	}{
+		{FxConfig{Cmd: []string{"jq", "."}}, "jq"},
+		{FxConfig{Shell: "sed 's/a/b/' | tr a-z A-Z"}, "sed"},
+		{FxConfig{Shell: "LC_ALL=C sort -u"}, "sort"},
+		{FxConfig{Shell: "'/opt/bin/tool' --x"}, "/opt/bin/tool"},
+		{FxConfig{Shell: "if true; then cat; fi"}, ""},
+		{FxConfig{Shell: "$HOME/bin/tool"}, ""},
+		{FxConfig{}, ""},
+	}
+	for _, tt := range tests {
+		if got := fxTool(tt.fx); got != tt.want {
+			t.Errorf("fxTool(%+v) = %q, want %q", tt.fx, got, tt.want)
+		}
+	}
+}
+
+// Test fx --check flags a transform whose command isn't installed
+func TestCmdFxCheck(t *testing.T) {
+	defer setupSlotsTestConfig(t, `version: 1
+fx:
+  upper:
+    shell: "tr a-z A-Z"
+  broken:
+    cmd: ["pipeboard-no-such-tool", "--pretty"]
+  broken-shell:
+    shell: "pipeboard-no-such-tool | cat"
+`)()
+
+	var err error
+	out := captureOutput(func() { err = cmdFx([]string{"--check"}) })
+	if err == nil || !strings.Contains(err.Error(), "2 of 3 transforms") {
+		t.Errorf("err = %v, want 2 of 3 missing", err)
+	}
+	lines := map[string]string{}
+	for _, line := range strings.Split(out, "\n") {
+		if fields := strings.Fields(line); len(fields) > 0 {
+			lines[fields[0]] = line
+		}
+	}
+	for _, name := range []string{"broken", "broken-shell"} {
+		if !strings.Contains(lines[name], "pipeboard-no-such-tool") || !strings.HasSuffix(lines[name], "missing") {
+			t.Errorf("%s = %q, want its missing tool reported", name, lines[name])
+		}
+	}
+	if !strings.HasSuffix(lines["upper"], "ok") {
+		t.Errorf("upper = %q, want ok", lines["upper"])
+	}
+
+	out = captureOutput(func() { err = cmdFx([]string{"--check", "--json"}) })
+	var results []fxCheckResult
+	if jsonErr := json.Unmarshal([]byte(out), &results); jsonErr != nil {
+		t.Fatalf("invalid JSON: %v\n%s", jsonErr, out)
+	}
+	if err == nil || len(results) != 3 {
+		t.Fatalf("results = %+v, err = %v", results, err)
+	}
+	for _, r := range results {
+		if r.Found != (r.Name == "upper") {
+			t.Errorf("%+v", r)
+		}
+	}
+
+	if err := cmdFx([]string{"--check", "--list"}); err == nil {
+		t.Error("--check with --list should be rejected")
+	}
+}
@@ -0,0 +1,7 @@
+package main
+
+import "os/exec"
+
+// killProcessGroupOnCancel is a no-op on Windows, where exec kills the
+// transform process itself when its context ends
+func killProcessGroupOnCancel(cmd *exec.Cmd) {}
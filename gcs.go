@@ -0,0 +1,391 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+)
+
+// GCSConfig configures the Google Cloud Storage sync backend
+type GCSConfig struct {
+	Bucket string `yaml:"bucket"`
+	Prefix string `yaml:"prefix,omitempty"`
+	// CredentialsFile is a service account key or gcloud user credentials
+	// JSON file (default: application default credentials)
+	CredentialsFile string `yaml:"credentials_file,omitempty"`
+	// Endpoint overrides the API URL, e.g. for an emulator. Without
+	// credentials, requests to a custom endpoint are unauthenticated.
+	Endpoint string `yaml:"endpoint,omitempty"`
+}
+
+const (
+	// gcsTimeout bounds each GCS operation, including the client's retries
+	gcsTimeout = 2 * time.Minute
+	// gcsMaxAttempts matches the attempts retryWithBackoff gives S3
+	gcsMaxAttempts = 3
+)
+
+// GCSBackend stores slots as objects in a Google Cloud Storage bucket,
+// using the same SlotPayload envelope as the S3 and local backends so
+// slots are interchangeable. The storage client handles credentials,
+// retries and resumable uploads.
+type GCSBackend struct {
+	client     *storage.Client
+	bucket     *storage.BucketHandle
+	prefix     string
+	encryption string   // "none" or "aes256" for client-side encryption
+	passphrase string   // passphrase for client-side encryption
+	age        *ageKeys // recipients and identities for encryption: age
+	ttlDays    int      // TTL in days (0 = never expires)
+	encoding   string   // "base85" stores data as Ascii85 (default base64)
+	compress   string   // sync.compression: "gzip" (default), "zstd" or "none"
+}
+
+func init() {
+	registerBackend("gcs", backendDriver{
+		validate: func(cfg *SyncConfig) error {
+			if cfg.GCS == nil {
+				return fmt.Errorf("gcs backend selected but gcs config missing")
+			}
+			if cfg.GCS.Bucket == "" {
+				return fmt.Errorf("gcs.bucket is required")
+			}
+			if cfg.Versions > 0 || cfg.Dedup {
+				return fmt.Errorf("sync.versions and sync.dedup are not supported by the gcs backend")
+			}
+			return nil
+		},
+		open: func(cfg *SyncConfig) (RemoteBackend, error) {
+			b, err := newGCSBackend(cfg.GCS, cfg.Encryption, cfg.Passphrase, cfg.TTLDays)
+			if err != nil {
+				return nil, err
+			}
+			b.encoding = cfg.Encoding
+			b.compress = cfg.Compression
+			if b.age, err = loadAgeKeys(cfg); err != nil {
+				return nil, err
+			}
+			return b, nil
+		},
+	})
+}
+
+func newGCSBackend(cfg *GCSConfig, encryption, passphrase string, ttlDays int) (*GCSBackend, error) {
+	// Validate encryption config
+	if encryption == "aes256" && passphrase == "" {
+		return nil, fmt.Errorf("passphrase required when encryption is set to aes256")
+	}
+
+	// Reads use the JSON API like everything else, so an emulator only
+	// needs to serve that
+	opts := []option.ClientOption{storage.WithJSONReads()}
+	switch {
+	case cfg.CredentialsFile != "":
+		opts = append(opts, option.WithCredentialsFile(cfg.CredentialsFile))
+	case cfg.Endpoint != "" && os.Getenv("GOOGLE_APPLICATION_CREDENTIALS") == "":
+		debugLog("gcs: no credentials, using %s unauthenticated", cfg.Endpoint)
+		opts = append(opts, option.WithoutAuthentication())
+	}
+	if cfg.Endpoint != "" {
+		opts = append(opts, option.WithEndpoint(strings.TrimSuffix(cfg.Endpoint, "/")+"/storage/v1/"))
+	}
+
+	client, err := storage.NewClient(context.Background(), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("creating GCS client: %w", err)
+	}
+	client.SetRetry(storage.WithMaxAttempts(gcsMaxAttempts))
+
+	return &GCSBackend{
+		client:     client,
+		bucket:     client.Bucket(cfg.Bucket),
+		prefix:     cfg.Prefix,
+		encryption: encryption,
+		passphrase: passphrase,
+		ttlDays:    ttlDays,
+	}, nil
+}
+
+func (b *GCSBackend) key(slot string) string {
+	return path.Join(b.prefix, slot+".pb")
+}
+
+// putObject uploads an object. With rev set the upload is conditional on
+// the object's generation ("" for an object that must not exist yet) and
+// fails with errSlotChanged when it has moved on. An unconditional upload
+// writes the same bytes again when repeated, so it is retried as well.
+func (b *GCSBackend) putObject(key string, body []byte, rev *string) error {
+	obj := b.bucket.Object(key)
+	switch {
+	case rev == nil:
+		obj = obj.Retryer(storage.WithPolicy(storage.RetryAlways))
+	case *rev == "":
+		obj = obj.If(storage.Conditions{DoesNotExist: true})
+	default:
+		generation, err := strconv.ParseInt(*rev, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid GCS generation %q", *rev)
+		}
+		obj = obj.If(storage.Conditions{GenerationMatch: generation})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), gcsTimeout)
+	defer cancel()
+	w := obj.NewWriter(ctx)
+	w.ContentType = "application/json"
+	// Slots smaller than a chunk go up in a single request; larger ones
+	// as a resumable upload
+	if len(body) < googleapi.DefaultUploadChunkSize {
+		w.ChunkSize = 0
+	}
+	_, err := w.Write(body)
+	if cerr := w.Close(); err == nil {
+		err = cerr
+	}
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) && apiErr.Code == http.StatusPreconditionFailed {
+		return fmt.Errorf("uploading to GCS: %w", errSlotChanged)
+	}
+	if err != nil {
+		return fmt.Errorf("uploading to GCS: %w", err)
+	}
+	return nil
+}
+
+// getSlot fetches a slot's payload JSON and generation, reporting a
+// missing object by slot name
+func (b *GCSBackend) getSlot(slot string) ([]byte, string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), gcsTimeout)
+	defer cancel()
+	r, err := b.bucket.Object(b.key(slot)).NewReader(ctx)
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return nil, "", fmt.Errorf("slot %q not found", slot)
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("fetching from GCS: %w", err)
+	}
+	defer func() { _ = r.Close() }()
+	jsonData, err := io.ReadAll(r)
+	if err != nil {
+		return nil, "", fmt.Errorf("reading GCS object: %w", err)
+	}
+	return jsonData, strconv.FormatInt(r.Attrs.Generation, 10), nil
+}
+
+func (b *GCSBackend) Push(slot string, data []byte, meta map[string]string) error {
+	return b.push(slot, data, meta, nil)
+}
+
+// PushIfRevision implements ConditionalPusher; rev is the generation of
+// the slot read by PullRevision
+func (b *GCSBackend) PushIfRevision(slot string, data []byte, meta map[string]string, rev string) error {
+	return b.push(slot, data, meta, &rev)
+}
+
+// push stores a slot, conditional on its generation when rev is set (see
+// putObject)
+func (b *GCSBackend) push(slot string, data []byte, meta map[string]string, rev *string) error {
+	hostname := meta["hostname"]
+	if hostname == "" {
+		hostname = slotHostname(nil)
+	}
+
+	// Detect MIME type before any transformations
+	mimeType := detectMIME(data)
+
+	// Compress data > 1KB (saves bandwidth/storage)
+	storeData, compressed, algo := compressSlotData(data, b.compress)
+
+	// Apply client-side encryption if configured (after compression)
+	storeData, encrypted, encryption, err := encryptSlotData(storeData, b.encryption, b.passphrase, b.age)
+	if err != nil {
+		return err
+	}
+
+	payload := SlotPayload{
+		Version:         slotPayloadVersion,
+		CreatedAt:       time.Now().UTC().Format(time.RFC3339),
+		Hostname:        hostname,
+		OS:              runtime.GOOS,
+		Len:             len(data), // Original length before compression/encryption
+		MIME:            mimeType,
+		Encrypted:       encrypted,
+		Encryption:      encryption,
+		Compressed:      compressed,
+		CompressionAlgo: algo,
+	}
+	payload.DataB64, payload.Encoding = encodePayloadData(storeData, b.encoding)
+	if payload.Flavor, err = sealSlotFlavor(meta, b.encryption, b.passphrase, b.age); err != nil {
+		return err
+	}
+
+	// Set expiry time if TTL configured
+	if b.ttlDays > 0 {
+		payload.ExpiresAt = time.Now().UTC().AddDate(0, 0, b.ttlDays).Format(time.RFC3339)
+	}
+
+	jsonData, err := marshalSlotPayload(payload, "")
+	if err != nil {
+		return fmt.Errorf("encoding payload: %w", err)
+	}
+	return b.putObject(b.key(slot), jsonData, rev)
+}
+
+// ImportPayload implements PayloadImporter
+func (b *GCSBackend) ImportPayload(slot string, payload SlotPayload) error {
+	jsonData, err := marshalSlotPayload(payload, "")
+	if err != nil {
+		return fmt.Errorf("encoding payload: %w", err)
+	}
+	return b.putObject(b.key(slot), jsonData, nil)
+}
+
+func (b *GCSBackend) Pull(slot string) ([]byte, map[string]string, error) {
+	data, meta, _, err := b.PullRevision(slot)
+	return data, meta, err
+}
+
+// PullRevision implements ConditionalPusher; the revision is the slot
+// object's generation
+func (b *GCSBackend) PullRevision(slot string) ([]byte, map[string]string, string, error) {
+	jsonData, generation, err := b.getSlot(slot)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	var payload SlotPayload
+	if err := json.Unmarshal(jsonData, &payload); err != nil {
+		return nil, nil, "", fmt.Errorf("decoding payload: %w", err)
+	}
+	if err := checkPayloadVersion(slot, payload); err != nil {
+		return nil, nil, "", err
+	}
+
+	// Check if slot has expired
+	if payload.ExpiresAt != "" {
+		expiresAt, err := time.Parse(time.RFC3339, payload.ExpiresAt)
+		if err == nil && time.Now().UTC().After(expiresAt) {
+			// Auto-delete expired slot
+			_ = b.Delete(slot)
+			return nil, nil, "", fmt.Errorf("slot %q has expired", slot)
+		}
+	}
+	if payload.Blob != "" {
+		return nil, nil, "", fmt.Errorf("slot %q is stored with sync.dedup, which the gcs backend does not support", slot)
+	}
+
+	data, err := decodePayloadData(payload, b.passphrase, b.age)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	meta := map[string]string{
+		"hostname":   payload.Hostname,
+		"os":         payload.OS,
+		"created_at": payload.CreatedAt,
+		"mime":       payload.MIME,
+	}
+	if err := addFlavorMeta(meta, payload.Flavor, b.passphrase, b.age); err != nil {
+		return nil, nil, "", err
+	}
+
+	return data, meta, generation, nil
+}
+
+// Inspect implements InspectableBackend
+func (b *GCSBackend) Inspect(slot string) (SlotPayload, int64, error) {
+	jsonData, _, err := b.getSlot(slot)
+	if err != nil {
+		return SlotPayload{}, 0, err
+	}
+	var payload SlotPayload
+	if err := json.Unmarshal(jsonData, &payload); err != nil {
+		return SlotPayload{}, 0, fmt.Errorf("decoding payload: %w", err)
+	}
+	return payload, int64(len(jsonData)), nil
+}
+
+func (b *GCSBackend) List() ([]RemoteSlot, error) {
+	return b.listSlots(b.prefix)
+}
+
+// ListPrefix implements PrefixListableBackend, listing only the objects
+// under the slot name prefix
+func (b *GCSBackend) ListPrefix(prefix string) ([]RemoteSlot, error) {
+	if prefix == "" {
+		return b.List()
+	}
+	keyPrefix := prefix
+	if b.prefix != "" {
+		keyPrefix = strings.TrimSuffix(b.prefix, "/") + "/" + prefix
+	}
+	return b.listSlots(keyPrefix)
+}
+
+func (b *GCSBackend) listSlots(keyPrefix string) ([]RemoteSlot, error) {
+	// As with S3, expiry is estimated from the update time and the
+	// configured TTL rather than fetching every object
+	ctx, cancel := context.WithTimeout(context.Background(), gcsTimeout)
+	defer cancel()
+	query := &storage.Query{Prefix: keyPrefix}
+	if err := query.SetAttrSelection([]string{"Name", "Size", "Updated"}); err != nil {
+		return nil, err
+	}
+
+	var slots []RemoteSlot
+	it := b.bucket.Objects(ctx, query)
+	for {
+		obj, err := it.Next()
+		if err == iterator.Done {
+			return slots, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("listing GCS objects: %w", err)
+		}
+		if !strings.HasSuffix(obj.Name, ".pb") {
+			continue
+		}
+		name := strings.TrimPrefix(obj.Name, b.prefix)
+		name = strings.TrimPrefix(name, "/")
+		name = strings.TrimSuffix(name, ".pb")
+
+		// Skip stored versions and blobs
+		if strings.HasPrefix(name, versionsDir+"/") || strings.HasPrefix(name, blobsDir+"/") {
+			continue
+		}
+
+		slot := RemoteSlot{Name: name, Size: obj.Size, CreatedAt: obj.Updated}
+		if b.ttlDays > 0 {
+			slot.ExpiresAt = slot.CreatedAt.AddDate(0, 0, b.ttlDays)
+			slot.ExpiryEstimated = true
+		}
+		slots = append(slots, slot)
+	}
+}
+
+func (b *GCSBackend) Delete(slot string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), gcsTimeout)
+	defer cancel()
+	err := b.bucket.Object(b.key(slot)).Delete(ctx)
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return fmt.Errorf("slot %q not found", slot)
+	}
+	if err != nil {
+		return fmt.Errorf("deleting from GCS: %w", err)
+	}
+	return nil
+}
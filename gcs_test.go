@@ -0,0 +1,357 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// mockGCS is an in-memory fake of the GCS JSON API object endpoints
+type mockGCS struct {
+	mu          sync.Mutex
+	objects     map[string][]byte
+	generations map[string]int64 // bumped by each upload; honoured by ifGenerationMatch
+	requireAuth bool             // reject requests without a bearer token
+	pageSize    int
+	requests    int
+}
+
+// mockGCSObject is the part of the JSON API object resource the client reads
+type mockGCSObject struct {
+	Bucket     string    `json:"bucket"`
+	Name       string    `json:"name"`
+	Size       string    `json:"size"`       // int64 encoded as a string
+	Generation string    `json:"generation"` // int64 encoded as a string
+	Updated    time.Time `json:"updated"`
+}
+
+func (m *mockGCS) object(name string) mockGCSObject {
+	return mockGCSObject{
+		Bucket:     "bucket",
+		Name:       name,
+		Size:       strconv.Itoa(len(m.objects[name])),
+		Generation: strconv.FormatInt(m.generations[name], 10),
+		Updated:    time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func (m *mockGCS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests++
+	if m.requireAuth && !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ") {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	const objects = "/storage/v1/b/bucket/o"
+	q := r.URL.Query()
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/upload"+objects && q.Get("uploadType") == "multipart":
+		name := q.Get("name")
+		if m.generations == nil {
+			m.generations = map[string]int64{}
+		}
+		if want := q.Get("ifGenerationMatch"); want != "" && want != strconv.FormatInt(m.generations[name], 10) {
+			http.Error(w, "conditionNotMet", http.StatusPreconditionFailed)
+			return
+		}
+		// The body is the object's metadata followed by its content
+		_, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		mr := multipart.NewReader(r.Body, params["boundary"])
+		var body []byte
+		for i := 0; i < 2; i++ {
+			part, err := mr.NextPart()
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			body, _ = io.ReadAll(part)
+		}
+		m.objects[name] = body
+		m.generations[name]++
+		_ = json.NewEncoder(w).Encode(m.object(name))
+	case r.Method == http.MethodGet && r.URL.Path == objects:
+		var names []string
+		for name := range m.objects {
+			if strings.HasPrefix(name, q.Get("prefix")) && name > q.Get("pageToken") {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		var page struct {
+			Items         []mockGCSObject `json:"items"`
+			NextPageToken string          `json:"nextPageToken,omitempty"`
+		}
+		for i, name := range names {
+			if m.pageSize > 0 && i == m.pageSize {
+				page.NextPageToken = names[i-1]
+				break
+			}
+			page.Items = append(page.Items, m.object(name))
+		}
+		_ = json.NewEncoder(w).Encode(page)
+	case strings.HasPrefix(r.URL.Path, objects+"/"):
+		name := strings.TrimPrefix(r.URL.Path, objects+"/")
+		data, ok := m.objects[name]
+		if !ok {
+			http.Error(w, "No such object", http.StatusNotFound)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			w.Header().Set("X-Goog-Generation", strconv.FormatInt(m.generations[name], 10))
+			_, _ = w.Write(data)
+		case http.MethodDelete:
+			delete(m.objects, name)
+			delete(m.generations, name)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	default:
+		http.Error(w, "unexpected request", http.StatusBadRequest)
+	}
+}
+
+// newMockGCSBackend returns a GCSBackend talking to a fake server without
+// credentials
+func newMockGCSBackend(t *testing.T, mock *mockGCS, prefix string) *GCSBackend {
+	t.Helper()
+	srv := httptest.NewServer(mock)
+	t.Cleanup(srv.Close)
+	b, err := newGCSBackend(&GCSConfig{Bucket: "bucket", Prefix: prefix, Endpoint: srv.URL}, "none", "", 0)
+	if err != nil {
+		t.Fatalf("newGCSBackend: %v", err)
+	}
+	return b
+}
+
+// Test a compressed, encrypted push round-trips through the fake server
+func TestGCSPushPullRoundTrip(t *testing.T) {
+	mock := &mockGCS{objects: make(map[string][]byte)}
+	b := newMockGCSBackend(t, mock, "clips")
+	b.encryption, b.passphrase = "aes256", "secret"
+
+	data := []byte(strings.Repeat("gcs payload ", 200))
+	if err := b.Push("notes", data, map[string]string{"hostname": "laptop"}); err != nil {
+		t.Fatalf("Push: %v", err)
+	}
+
+	stored, ok := mock.objects["clips/notes.pb"]
+	if !ok {
+		t.Fatalf("expected object clips/notes.pb, have %v", mock.objects)
+	}
+	var payload SlotPayload
+	if err := json.Unmarshal(stored, &payload); err != nil {
+		t.Fatalf("stored object is not a slot payload: %v", err)
+	}
+	if !payload.Encrypted || !payload.Compressed || payload.Hostname != "laptop" {
+		t.Errorf("payload = %+v, want encrypted, compressed, from laptop", payload)
+	}
+
+	got, meta, err := b.Pull("notes")
+	if err != nil {
+		t.Fatalf("Pull: %v", err)
+	}
+	if string(got) != string(data) {
+		t.Error("pulled data does not match pushed data")
+	}
+	if meta["hostname"] != "laptop" {
+		t.Errorf("meta hostname = %q", meta["hostname"])
+	}
+
+	if _, size, err := b.Inspect("notes"); err != nil || size != int64(len(stored)) {
+		t.Errorf("Inspect size = %d, err = %v", size, err)
+	}
+}
+
+// Test missing slots are reported by name without retrying
+func TestGCSNotFound(t *testing.T) {
+	mock := &mockGCS{objects: make(map[string][]byte)}
+	b := newMockGCSBackend(t, mock, "")
+
+	if _, _, err := b.Pull("missing"); err == nil || err.Error() != `slot "missing" not found` {
+		t.Errorf("Pull err = %v", err)
+	}
+	if err := b.Delete("missing"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("Delete err = %v", err)
+	}
+	if mock.requests != 2 {
+		t.Errorf("expected 2 requests (no retries), got %d", mock.requests)
+	}
+}
+
+// Test listing follows pagination, strips the prefix and skips other objects
+func TestGCSListAndDelete(t *testing.T) {
+	mock := &mockGCS{objects: make(map[string][]byte), pageSize: 2}
+	b := newMockGCSBackend(t, mock, "clips")
+	b.ttlDays = 7
+	for _, name := range []string{"a", "b", "build-1", "build-2"} {
+		if err := b.Push(name, []byte(name), nil); err != nil {
+			t.Fatalf("Push %s: %v", name, err)
+		}
+	}
+	mock.objects["clips/readme.txt"] = []byte("not a slot")
+	mock.objects["other/c.pb"] = []byte("{}")
+
+	slots, err := b.List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	var names []string
+	for _, s := range slots {
+		names = append(names, s.Name)
+	}
+	if strings.Join(names, ",") != "a,b,build-1,build-2" {
+		t.Errorf("List = %v", names)
+	}
+	if want := time.Date(2026, 1, 8, 0, 0, 0, 0, time.UTC); !slots[0].ExpiresAt.Equal(want) || !slots[0].ExpiryEstimated {
+		t.Errorf("ExpiresAt = %v (estimated %v), want estimated %v", slots[0].ExpiresAt, slots[0].ExpiryEstimated, want)
+	}
+
+	builds, err := b.ListPrefix("build-")
+	if err != nil || len(builds) != 2 {
+		t.Errorf("ListPrefix = %v, %v", builds, err)
+	}
+
+	if err := b.Delete("a"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, ok := mock.objects["clips/a.pb"]; ok {
+		t.Error("slot a should be deleted")
+	}
+}
+
+// Test credentials_file is used to authenticate requests
+func TestGCSServiceAccountAuth(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, _ := x509.MarshalPKCS8PrivateKey(key)
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+
+	tokenSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"tok-123","token_type":"Bearer","expires_in":3600}`))
+	}))
+	defer tokenSrv.Close()
+
+	creds, _ := json.Marshal(map[string]string{
+		"type":           "service_account",
+		"project_id":     "pipeboard-test",
+		"private_key_id": "key-1",
+		"private_key":    string(keyPEM),
+		"client_email":   "pipeboard@pipeboard-test.iam.gserviceaccount.com",
+		"token_uri":      tokenSrv.URL,
+	})
+	credsFile := filepath.Join(t.TempDir(), "key.json")
+	if err := os.WriteFile(credsFile, creds, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	mock := &mockGCS{objects: make(map[string][]byte), requireAuth: true}
+	srv := httptest.NewServer(mock)
+	defer srv.Close()
+	b, err := newGCSBackend(&GCSConfig{Bucket: "bucket", CredentialsFile: credsFile, Endpoint: srv.URL}, "none", "", 0)
+	if err != nil {
+		t.Fatalf("newGCSBackend: %v", err)
+	}
+
+	if err := b.Push("s", []byte("hi"), nil); err != nil {
+		t.Fatalf("Push: %v", err)
+	}
+	if got, _, err := b.Pull("s"); err != nil || string(got) != "hi" {
+		t.Fatalf("Pull = %q, %v", got, err)
+	}
+
+	// Without credentials, requests to the endpoint aren't authenticated
+	b = newMockGCSBackend(t, mock, "")
+	if err := b.Push("s", []byte("hi"), nil); err == nil {
+		t.Error("unauthenticated push should be rejected")
+	}
+}
+
+// Test config validation and an unreadable credentials file
+func TestGCSConfigValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		sync SyncConfig
+		want string
+	}{
+		{"missing config", SyncConfig{Backend: "gcs"}, "gcs config missing"},
+		{"missing bucket", SyncConfig{Backend: "gcs", GCS: &GCSConfig{}}, "gcs.bucket is required"},
+		{"versions", SyncConfig{Backend: "gcs", GCS: &GCSConfig{Bucket: "b"}, Versions: 3}, "not supported"},
+		{"dedup", SyncConfig{Backend: "gcs", GCS: &GCSConfig{Bucket: "b"}, Dedup: true}, "not supported"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateSyncConfig(&Config{Sync: &tt.sync})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+
+	missing := filepath.Join(t.TempDir(), "creds.json")
+	if _, err := newGCSBackend(&GCSConfig{Bucket: "b", CredentialsFile: missing}, "none", "", 0); err == nil || !strings.Contains(err.Error(), "creating GCS client") {
+		t.Errorf("expected a credentials error, got %v", err)
+	}
+}
+
+func TestApplyGCSEnv(t *testing.T) {
+	envVars := []string{
+		"PIPEBOARD_GCS_BUCKET",
+		"PIPEBOARD_GCS_PREFIX",
+		"PIPEBOARD_GCS_CREDENTIALS_FILE",
+		"PIPEBOARD_GCS_ENDPOINT",
+	}
+	for _, v := range envVars {
+		orig := os.Getenv(v)
+		defer restoreEnv(v, orig)
+	}
+
+	_ = os.Setenv("PIPEBOARD_GCS_BUCKET", "env-bucket")
+	_ = os.Setenv("PIPEBOARD_GCS_PREFIX", "clips/")
+	_ = os.Setenv("PIPEBOARD_GCS_CREDENTIALS_FILE", "/tmp/key.json")
+	_ = os.Setenv("PIPEBOARD_GCS_ENDPOINT", "http://localhost:4443")
+
+	cfg := &Config{}
+	applyGCSEnv(cfg)
+
+	if cfg.Sync == nil || cfg.Sync.GCS == nil {
+		t.Fatal("Sync and GCS should be created")
+	}
+	want := GCSConfig{Bucket: "env-bucket", Prefix: "clips/", CredentialsFile: "/tmp/key.json", Endpoint: "http://localhost:4443"}
+	if *cfg.Sync.GCS != want {
+		t.Errorf("GCS = %+v, want %+v", *cfg.Sync.GCS, want)
+	}
+	if cfg.Sync.Backend != "gcs" {
+		t.Errorf("expected backend 'gcs', got %s", cfg.Sync.Backend)
+	}
+
+	// An explicit backend is kept
+	cfg = &Config{Sync: &SyncConfig{Backend: "s3"}}
+	applyGCSEnv(cfg)
+	if cfg.Sync.Backend != "s3" {
+		t.Errorf("env bucket should not override an explicit backend, got %s", cfg.Sync.Backend)
+	}
+}
@@ -1,15 +1,22 @@
 package main
 
 import (
+	"bytes"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
+	"unicode/utf8"
 )
 
 type HistoryEntry struct {
@@ -22,10 +29,10 @@ type HistoryEntry struct {
 // ClipboardHistoryEntry stores clipboard content snapshots
 type ClipboardHistoryEntry struct {
 	Timestamp time.Time `json:"timestamp"`
-	Hash      string    `json:"hash"`              // SHA256 hash for deduplication
-	Preview   string    `json:"preview"`           // First 100 chars (may be encrypted preview if encryption enabled)
+	Hash      string    `json:"hash"`    // SHA256 hash for deduplication
+	Preview   string    `json:"preview"` // First 100 chars (may be encrypted preview if encryption enabled)
 	Size      int64     `json:"size"`
-	Content   []byte    `json:"content"`           // Full content (may be encrypted)
+	Content   []byte    `json:"content"`             // Full content (may be encrypted)
 	Encrypted bool      `json:"encrypted,omitempty"` // true if content is encrypted
 }
 
@@ -33,9 +40,28 @@ const maxHistoryEntries = 50
 const defaultClipboardHistoryLimit = 20
 const previewLength = 100
 
-// getClipboardHistoryLimit returns the configured history limit or default
+// defaultMaxHistoryEntryBytes keeps huge copies out of the history file
+const defaultMaxHistoryEntryBytes = 32 << 20
+
+// maxEntryBytes returns history.max_entry_bytes, or the default
+func (h *HistoryConfig) maxEntryBytes() int64 {
+	if h.MaxEntryBytes > 0 {
+		return h.MaxEntryBytes
+	}
+	return defaultMaxHistoryEntryBytes
+}
+
+// getClipboardHistoryLimit returns the history limit: PIPEBOARD_HISTORY_LIMIT
+// for this invocation, else history.limit, else the default. Values that
+// aren't positive integers are ignored.
 func getClipboardHistoryLimit() int {
-	cfg, err := loadConfig()
+	if v := os.Getenv("PIPEBOARD_HISTORY_LIMIT"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			return n
+		}
+		debugLog("ignoring PIPEBOARD_HISTORY_LIMIT=%q: not a positive integer", v)
+	}
+	cfg, err := loadConfigForAliases()
 	if err != nil || cfg.History == nil || cfg.History.Limit <= 0 {
 		return defaultClipboardHistoryLimit
 	}
@@ -44,7 +70,7 @@ func getClipboardHistoryLimit() int {
 
 // getHistoryConfig returns the full history configuration
 func getHistoryConfig() *HistoryConfig {
-	cfg, err := loadConfig()
+	cfg, err := loadConfigForAliases()
 	if err != nil || cfg.History == nil {
 		return &HistoryConfig{Limit: defaultClipboardHistoryLimit}
 	}
@@ -100,9 +126,125 @@ func getClipboardHistoryPath() string {
 	return filepath.Join(configDir, "pipeboard", "clipboard_history.json")
 }
 
+// historyFlushDelay is how long batched history records wait for more
+// records before they're written together
+const historyFlushDelay = 500 * time.Millisecond
+
+// historyFileMu serializes reads and writes of the history files, which
+// batched writes make from a timer goroutine
+var historyFileMu sync.Mutex
+
+// historyFileWrites counts history file writes (for tests)
+var historyFileWrites atomic.Int64
+
+// historyBatch holds records queued by history.batch_writes
+type historyBatch struct {
+	ops   []HistoryEntry
+	clips []pendingClip
+}
+
+// historyWriter coalesces history records into one write per file. It
+// only queues while started: run starts it and flushes it on the way out,
+// so a record made anywhere else is written synchronously.
+type historyWriter struct {
+	mu      sync.Mutex
+	started bool
+	pending historyBatch
+	timer   *time.Timer
+}
+
+var historyQueue historyWriter
+
+// start makes records queue when history.batch_writes is set; the
+// returned func flushes what's queued and goes back to synchronous writes
+func (w *historyWriter) start() (stop func()) {
+	w.mu.Lock()
+	w.started = true
+	w.mu.Unlock()
+	return func() {
+		w.mu.Lock()
+		w.started = false
+		w.mu.Unlock()
+		w.flush()
+	}
+}
+
+func (w *historyWriter) active() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.started
+}
+
+// add queues a record and arms the flush timer
+func (w *historyWriter) add(queue func(*historyBatch)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	queue(&w.pending)
+	if w.timer == nil {
+		w.timer = time.AfterFunc(historyFlushDelay, w.flush)
+	}
+}
+
+// flush writes every queued record
+func (w *historyWriter) flush() {
+	w.mu.Lock()
+	batch := w.pending
+	w.pending = historyBatch{}
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+	w.mu.Unlock()
+	if len(batch.ops) > 0 || len(batch.clips) > 0 {
+		debugLog("writing %d batched history records", len(batch.ops)+len(batch.clips))
+	}
+	appendHistory(batch.ops)
+	appendClipboardHistory(batch.clips)
+}
+
+// historyBatching reports whether records should be queued rather than
+// written now
+func historyBatching() bool {
+	return historyQueue.active() && getHistoryConfig().BatchWrites
+}
+
+// writeHistoryFile replaces a history file through a temp file and rename,
+// so a concurrent reader never sees it half-written
+func writeHistoryFile(path string, data []byte) error {
+	historyFileWrites.Add(1)
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".history-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
 func recordHistory(command, target string, size int64) {
+	entry := HistoryEntry{
+		Timestamp: time.Now(),
+		Command:   command,
+		Target:    target,
+		Size:      size,
+	}
+	if historyBatching() {
+		historyQueue.add(func(b *historyBatch) { b.ops = append(b.ops, entry) })
+		return
+	}
+	appendHistory([]HistoryEntry{entry})
+}
+
+// appendHistory adds entries to the command history file in one write
+func appendHistory(entries []HistoryEntry) {
 	path := getHistoryPath()
-	if path == "" {
+	if path == "" || len(entries) == 0 {
 		return
 	}
 
@@ -112,19 +254,16 @@ func recordHistory(command, target string, size int64) {
 		return
 	}
 
+	historyFileMu.Lock()
+	defer historyFileMu.Unlock()
+
 	// Load existing history
 	var history []HistoryEntry
 	if data, err := os.ReadFile(path); err == nil {
 		_ = json.Unmarshal(data, &history)
 	}
 
-	// Add new entry
-	history = append(history, HistoryEntry{
-		Timestamp: time.Now(),
-		Command:   command,
-		Target:    target,
-		Size:      size,
-	})
+	history = append(history, entries...)
 
 	// Trim to max entries
 	if len(history) > maxHistoryEntries {
@@ -136,7 +275,7 @@ func recordHistory(command, target string, size int64) {
 	if err != nil {
 		return
 	}
-	_ = os.WriteFile(path, data, 0600)
+	_ = writeHistoryFile(path, data)
 }
 
 // getHistoryEncryptionConfig returns encryption settings for clipboard history
@@ -146,7 +285,13 @@ func getHistoryEncryptionConfig() (enabled bool, passphrase string) {
 		return false, ""
 	}
 	// Use the same encryption settings as sync for consistency
-	if cfg.Sync.Encryption == "aes256" && cfg.Sync.Passphrase != "" {
+	if cfg.Sync.Encryption == "aes256" && cfg.Sync.hasPassphrase() {
+		// Enabled without a passphrase, so callers don't fall back to
+		// writing plaintext when the command or file fails
+		if err := loadPassphraseSource(cfg.Sync); err != nil {
+			debugLog("clipboard history: %v", err)
+			return true, ""
+		}
 		return true, cfg.Sync.Passphrase
 	}
 	return false, ""
@@ -154,8 +299,33 @@ func getHistoryEncryptionConfig() (enabled bool, passphrase string) {
 
 // recordClipboardHistory saves clipboard content to local history
 func recordClipboardHistory(content []byte) {
+	histCfg := getHistoryConfig()
+	if int64(len(content)) > histCfg.maxEntryBytes() {
+		debugLog("not recording %d bytes in clipboard history (max_entry_bytes %d)", len(content), histCfg.maxEntryBytes())
+		return
+	}
+	clip := pendingClip{at: time.Now(), content: content}
+	if histCfg.BatchWrites && historyQueue.active() {
+		// The caller may reuse its buffer before the batch is written
+		clip.content = bytes.Clone(content)
+		historyQueue.add(func(b *historyBatch) { b.clips = append(b.clips, clip) })
+		return
+	}
+	appendClipboardHistory([]pendingClip{clip})
+}
+
+// pendingClip is clipboard content waiting to be added to history
+type pendingClip struct {
+	at      time.Time
+	content []byte
+}
+
+// appendClipboardHistory adds clips to clipboard history in one write,
+// applying the TTL, dedup, encryption, and limit as if each were recorded
+// on its own
+func appendClipboardHistory(clips []pendingClip) {
 	path := getClipboardHistoryPath()
-	if path == "" {
+	if path == "" || len(clips) == 0 {
 		return
 	}
 
@@ -165,6 +335,16 @@ func recordClipboardHistory(content []byte) {
 		return
 	}
 
+	// Check if encryption is enabled
+	encEnabled, passphrase := getHistoryEncryptionConfig()
+	if encEnabled && passphrase == "" {
+		debugLog("not recording clipboard history: encryption passphrase unavailable")
+		return
+	}
+
+	historyFileMu.Lock()
+	defer historyFileMu.Unlock()
+
 	// Get history configuration
 	histCfg := getHistoryConfig()
 
@@ -179,65 +359,69 @@ func recordClipboardHistory(content []byte) {
 		history = applyHistoryTTL(history, histCfg.TTLDays)
 	}
 
-	// Compute hash (always on plaintext for deduplication)
-	hashBytes := sha256.Sum256(content)
-	hash := hex.EncodeToString(hashBytes[:])
+	added := 0
+	for _, clip := range clips {
+		content := clip.content
+
+		// Compute hash (always on plaintext for deduplication)
+		hashBytes := sha256.Sum256(content)
+		hash := hex.EncodeToString(hashBytes[:])
 
-	// Check for duplicates
-	if histCfg.NoDuplicates {
-		// Full duplicate detection: check all entries
-		if isDuplicateInHistory(history, hash) {
-			return
+		// Check for duplicates
+		if histCfg.NoDuplicates {
+			// Full duplicate detection: check all entries
+			if isDuplicateInHistory(history, hash) {
+				continue
+			}
+		} else {
+			// Default: only check most recent entry
+			if len(history) > 0 && history[len(history)-1].Hash == hash {
+				continue
+			}
 		}
-	} else {
-		// Default: only check most recent entry
-		if len(history) > 0 && history[len(history)-1].Hash == hash {
-			return
+
+		// Generate preview
+		preview := string(content)
+		if len(preview) > previewLength {
+			preview = preview[:previewLength] + "..."
 		}
-	}
+		// Clean up preview (remove newlines for display)
+		preview = strings.ReplaceAll(preview, "\n", "\\n")
+		preview = strings.ReplaceAll(preview, "\r", "")
 
-	// Generate preview
-	preview := string(content)
-	if len(preview) > previewLength {
-		preview = preview[:previewLength] + "..."
-	}
-	// Clean up preview (remove newlines for display)
-	preview = strings.ReplaceAll(preview, "\n", "\\n")
-	preview = strings.ReplaceAll(preview, "\r", "")
+		storeContent := content
+		encrypted := false
 
-	// Check if encryption is enabled
-	encEnabled, passphrase := getHistoryEncryptionConfig()
-	storeContent := content
-	encrypted := false
-
-	if encEnabled && passphrase != "" {
-		encData, err := encrypt(content, passphrase)
-		if err == nil {
-			storeContent = encData
-			encrypted = true
-			// Also encrypt the preview for privacy
-			encPreview, err := encrypt([]byte(preview), passphrase)
+		if encEnabled {
+			encData, err := encrypt(content, passphrase)
 			if err == nil {
-				preview = hex.EncodeToString(encPreview)
+				storeContent = encData
+				encrypted = true
+				// Also encrypt the preview for privacy
+				encPreview, err := encrypt([]byte(preview), passphrase)
+				if err == nil {
+					preview = hex.EncodeToString(encPreview)
+				}
 			}
 		}
-	}
 
-	// Add new entry
-	history = append(history, ClipboardHistoryEntry{
-		Timestamp: time.Now(),
-		Hash:      hash,
-		Preview:   preview,
-		Size:      int64(len(content)),
-		Content:   storeContent,
-		Encrypted: encrypted,
-	})
+		// Add new entry
+		history = append(history, ClipboardHistoryEntry{
+			Timestamp: clip.at,
+			Hash:      hash,
+			Preview:   preview,
+			Size:      int64(len(content)),
+			Content:   storeContent,
+			Encrypted: encrypted,
+		})
+		added++
+	}
+	if added == 0 {
+		return
+	}
 
 	// Trim to max entries
-	limit := histCfg.Limit
-	if limit <= 0 {
-		limit = defaultClipboardHistoryLimit
-	}
+	limit := getClipboardHistoryLimit()
 	if len(history) > limit {
 		history = history[len(history)-limit:]
 	}
@@ -247,13 +431,18 @@ func recordClipboardHistory(content []byte) {
 	if err != nil {
 		return
 	}
-	_ = os.WriteFile(path, data, 0600)
+	_ = writeHistoryFile(path, data)
 }
 
 func cmdHistory(args []string) error {
+	if len(args) > 0 && args[0] == "clear" {
+		return clearHistory(args[1:])
+	}
+
 	// Parse filter flags
-	var filterFx, filterSlots, filterPeer, filterLocal, jsonOutput bool
-	var searchQuery string
+	var filterFx, filterSlots, filterPeer, filterLocal, jsonOutput, statsMode bool
+	var searchQuery, peerName string
+	var opts tableOptions
 	for i := 0; i < len(args); i++ {
 		arg := args[i]
 		switch {
@@ -262,11 +451,42 @@ func cmdHistory(args []string) error {
 		case arg == "--slots":
 			filterSlots = true
 		case arg == "--peer":
-			filterPeer = true
+			// "--peer <name>" browses that peer's clipboard history; a bare
+			// --peer filters operation history to peer commands
+			if i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
+				i++
+				peerName = args[i]
+			} else {
+				filterPeer = true
+			}
+		case strings.HasPrefix(arg, "--peer="):
+			peerName = strings.TrimPrefix(arg, "--peer=")
 		case arg == "--local":
 			filterLocal = true
 		case arg == "--json":
 			jsonOutput = true
+		case arg == "--stats":
+			statsMode = true
+		case arg == "--wide":
+			opts.wide = true
+		case arg == "--no-truncate":
+			opts.noTruncate = true
+		case arg == "--no-headers":
+			opts.noHeaders = true
+		case arg == "--count" || strings.HasPrefix(arg, "--count="):
+			value, ok := strings.CutPrefix(arg, "--count=")
+			if !ok {
+				if i+1 >= len(args) {
+					return fmt.Errorf("--count requires a number")
+				}
+				i++
+				value = args[i]
+			}
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid --count: %s (must be a positive number)", value)
+			}
+			opts.limit = n
 		case arg == "--search" || arg == "-s":
 			if i+1 >= len(args) {
 				return fmt.Errorf("--search requires a query argument")
@@ -277,14 +497,39 @@ func cmdHistory(args []string) error {
 			searchQuery = strings.TrimPrefix(arg, "--search=")
 		case strings.HasPrefix(arg, "-s="):
 			searchQuery = strings.TrimPrefix(arg, "-s=")
+		case opts.parseDelimitedFlag(arg):
 		default:
-			return fmt.Errorf("unknown flag: %s\nusage: pipeboard history [--fx] [--slots] [--peer] [--local] [--search <query>] [--json]", arg)
+			return fmt.Errorf("unknown flag: %s\nusage: pipeboard history [--fx] [--slots] [--peer] [--local] [--search <query>] [--count <n>] [--json|--csv|--tsv] [--wide] [--no-truncate] [--no-headers]\n       pipeboard history --local --stats [--json]\n       pipeboard history --peer <name> [--search <query>] [--count <n>] [--json]\n       pipeboard history clear [--local] [--yes]", arg)
 		}
 	}
+	if err := opts.checkOutputFormat(jsonOutput); err != nil {
+		return err
+	}
+	if opts.delim != 0 && (filterLocal || statsMode) {
+		return errors.New("--csv and --tsv are not supported with --local")
+	}
+
+	// A peer's clipboard history; --local is implied
+	if peerName != "" {
+		if statsMode || opts.delim != 0 || filterFx || filterSlots || filterPeer {
+			return errors.New("--peer <name> only supports --search, --count, --json, --wide, --no-truncate and --no-headers")
+		}
+		return showPeerClipboardHistory(peerName, jsonOutput, searchQuery, opts)
+	}
+
+	if statsMode {
+		if !filterLocal {
+			return errors.New("--stats requires --local")
+		}
+		if searchQuery != "" || opts.limit > 0 {
+			return errors.New("--stats cannot be combined with --search or --count")
+		}
+		return showClipboardHistoryStats(jsonOutput)
+	}
 
 	// Local clipboard history mode
 	if filterLocal {
-		return showClipboardHistory(jsonOutput, searchQuery)
+		return showClipboardHistory(jsonOutput, searchQuery, opts)
 	}
 
 	path := getHistoryPath()
@@ -295,6 +540,9 @@ func cmdHistory(args []string) error {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		if os.IsNotExist(err) {
+			if opts.delim != 0 {
+				return writeHistoryDelimited(nil, opts)
+			}
 			if jsonOutput {
 				fmt.Println("[]")
 				return nil
@@ -311,6 +559,9 @@ func cmdHistory(args []string) error {
 	}
 
 	if len(history) == 0 {
+		if opts.delim != 0 {
+			return writeHistoryDelimited(nil, opts)
+		}
 		if jsonOutput {
 			fmt.Println("[]")
 			return nil
@@ -335,6 +586,9 @@ func cmdHistory(args []string) error {
 	}
 
 	if len(filtered) == 0 {
+		if opts.delim != 0 {
+			return writeHistoryDelimited(nil, opts)
+		}
 		if jsonOutput {
 			fmt.Println("[]")
 			return nil
@@ -348,9 +602,14 @@ func cmdHistory(args []string) error {
 	for i := 0; i < len(filtered); i++ {
 		reversed[i] = filtered[len(filtered)-1-i]
 	}
+	reversed = limitRows(reversed, opts.limit)
+
+	if opts.delim != 0 {
+		return writeHistoryDelimited(reversed, opts)
+	}
 
 	if jsonOutput {
-		out, err := json.MarshalIndent(reversed, "", "  ")
+		out, err := marshalJSONOutput(reversed)
 		if err != nil {
 			return err
 		}
@@ -358,24 +617,141 @@ func cmdHistory(args []string) error {
 		return nil
 	}
 
+	// Command and target columns grow in --wide mode
+	longestCmd, longestTarget := 0, 0
+	for _, h := range reversed {
+		longestCmd = max(longestCmd, utf8.RuneCountInString(h.Command))
+		longestTarget = max(longestTarget, utf8.RuneCountInString(h.Target))
+	}
+	cmdWidth := opts.columnWidth(12, longestCmd, 20+2+2+15+2+10)
+	targetWidth := opts.columnWidth(15, longestTarget, 20+2+cmdWidth+2+2+10)
+
 	// Show most recent first (reverse order)
-	fmt.Printf("%-20s  %-12s  %-15s  %s\n", "TIME", "COMMAND", "TARGET", "SIZE")
+	if !opts.noHeaders {
+		fmt.Printf("%-20s  %-*s  %-*s  %s\n", "TIME", cmdWidth, "COMMAND", targetWidth, "TARGET", "SIZE")
+	}
 	for _, h := range reversed {
 		sizeStr := ""
 		if h.Size > 0 {
 			sizeStr = formatSize(h.Size)
 		}
-		fmt.Printf("%-20s  %-12s  %-15s  %s\n",
+		fmt.Printf("%-20s  %-*s  %-*s  %s\n",
 			h.Timestamp.Format("2006-01-02 15:04:05"),
-			h.Command,
-			h.Target,
+			cmdWidth, h.Command,
+			targetWidth, h.Target,
 			sizeStr,
 		)
 	}
 	return nil
 }
 
-func showClipboardHistory(jsonOutput bool, searchQuery string) error {
+// writeHistoryDelimited prints operation history as CSV/TSV rows with
+// RFC 3339 times and exact byte sizes
+// confirmClearHistory asks whether history clear should go ahead
+var confirmClearHistory = func(prompt string) bool {
+	return promptYesNo(prompt, false)
+}
+
+// clearHistory empties operation history, or clipboard history with
+// --local, after confirmation. Nothing is recorded about the clear itself.
+func clearHistory(args []string) error {
+	const usage = "usage: pipeboard history clear [--local] [--yes]"
+	var local, assumeYes bool
+	for _, arg := range args {
+		switch arg {
+		case "--local":
+			local = true
+		case "--yes", "-y":
+			assumeYes = true
+		default:
+			return fmt.Errorf("unknown argument: %s\n%s", arg, usage)
+		}
+	}
+
+	path, what := getHistoryPath(), "operation history"
+	if local {
+		path, what = getClipboardHistoryPath(), "clipboard history"
+	}
+	if path == "" {
+		return errors.New("could not determine history path")
+	}
+
+	historyFileMu.Lock()
+	defer historyFileMu.Unlock()
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		printInfo("%s is already empty\n", what)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	// Only for the prompt; an unreadable file can still be cleared
+	var entries []json.RawMessage
+	_ = json.Unmarshal(data, &entries)
+	if len(entries) == 0 && json.Valid(data) {
+		printInfo("%s is already empty\n", what)
+		return nil
+	}
+
+	if !assumeYes {
+		if quietMode || !stdinIsTerminal() {
+			return fmt.Errorf("history clear would delete %d %s entries; use --yes to confirm", len(entries), what)
+		}
+		if !confirmClearHistory(fmt.Sprintf("Delete all %d %s entries?", len(entries), what)) {
+			return errors.New("history clear cancelled")
+		}
+	}
+	if err := writeHistoryFile(path, []byte("[]")); err != nil {
+		return fmt.Errorf("clearing %s: %w", what, err)
+	}
+	printInfo("cleared %d %s entries\n", len(entries), what)
+	return nil
+}
+
+func writeHistoryDelimited(entries []HistoryEntry, opts tableOptions) error {
+	rows := make([][]string, len(entries))
+	for i, h := range entries {
+		rows[i] = []string{
+			h.Timestamp.Format(time.RFC3339),
+			h.Command,
+			h.Target,
+			strconv.FormatInt(h.Size, 10),
+		}
+	}
+	return opts.writeDelimited([]string{"time", "command", "target", "size"}, rows)
+}
+
+// decryptClipboardHistory returns a copy of history with encrypted
+// content and previews decrypted and Encrypted cleared. Entries that fail
+// to decrypt (or all encrypted entries, when passphrase is empty) are left
+// as stored.
+func decryptClipboardHistory(history []ClipboardHistoryEntry, passphrase string) []ClipboardHistoryEntry {
+	decryptedHistory := make([]ClipboardHistoryEntry, len(history))
+	for i, h := range history {
+		decryptedHistory[i] = h
+		if h.Encrypted && passphrase != "" {
+			// Decrypt content
+			decContent, err := decrypt(h.Content, passphrase)
+			if err == nil {
+				decryptedHistory[i].Content = decContent
+				decryptedHistory[i].Encrypted = false
+			}
+			// Decrypt preview (stored as hex)
+			encPreviewBytes, err := hex.DecodeString(h.Preview)
+			if err == nil {
+				decPreview, err := decrypt(encPreviewBytes, passphrase)
+				if err == nil {
+					decryptedHistory[i].Preview = string(decPreview)
+				}
+			}
+		}
+	}
+	return decryptedHistory
+}
+
+func showClipboardHistory(jsonOutput bool, searchQuery string, opts tableOptions) error {
 	path := getClipboardHistoryPath()
 	if path == "" {
 		return errors.New("could not determine clipboard history path")
@@ -416,111 +792,320 @@ func showClipboardHistory(jsonOutput bool, searchQuery string) error {
 
 	// Get encryption config for decryption
 	_, passphrase := getHistoryEncryptionConfig()
+	history = decryptClipboardHistory(history, passphrase)
+
+	// Listings carry previews only; content is too large. Indices count
+	// from the most recent entry of the full history, matching recall,
+	// however the listing is filtered or capped.
+	searchLower := strings.ToLower(searchQuery)
+	var entries []clipboardHistoryListEntry
+	for i := len(history) - 1; i >= 0; i-- {
+		h := history[i]
+		// Search in both preview and full content
+		if searchQuery != "" &&
+			!strings.Contains(strings.ToLower(h.Preview), searchLower) &&
+			!strings.Contains(strings.ToLower(string(h.Content)), searchLower) {
+			continue
+		}
+		entries = append(entries, clipboardHistoryListEntry{
+			Index:     len(history) - i,
+			Timestamp: h.Timestamp,
+			Preview:   h.Preview,
+			Size:      h.Size,
+		})
+	}
+	if len(entries) == 0 {
+		if jsonOutput {
+			fmt.Println("[]")
+			return nil
+		}
+		fmt.Printf("No clipboard history entries matching %q.\n", searchQuery)
+		return nil
+	}
+	entries = limitRows(entries, opts.limit)
 
-	// Decrypt entries for display/search if needed
-	decryptedHistory := make([]ClipboardHistoryEntry, len(history))
-	for i, h := range history {
-		decryptedHistory[i] = h
-		if h.Encrypted && passphrase != "" {
-			// Decrypt content
-			decContent, err := decrypt(h.Content, passphrase)
-			if err == nil {
-				decryptedHistory[i].Content = decContent
-			}
-			// Decrypt preview (stored as hex)
-			encPreviewBytes, err := hex.DecodeString(h.Preview)
-			if err == nil {
-				decPreview, err := decrypt(encPreviewBytes, passphrase)
-				if err == nil {
-					decryptedHistory[i].Preview = string(decPreview)
-				}
-			}
+	if jsonOutput {
+		out, err := marshalJSONOutput(entries)
+		if err != nil {
+			return err
 		}
+		fmt.Println(string(out))
+		return nil
 	}
-	history = decryptedHistory
 
-	// Filter by search query if provided
-	if searchQuery != "" {
-		searchLower := strings.ToLower(searchQuery)
-		var filtered []ClipboardHistoryEntry
-		for _, h := range history {
-			// Search in both preview and full content
-			if strings.Contains(strings.ToLower(h.Preview), searchLower) ||
-				strings.Contains(strings.ToLower(string(h.Content)), searchLower) {
-				filtered = append(filtered, h)
-			}
+	printClipboardHistoryTable(entries, opts, "pipeboard recall <index>")
+	return nil
+}
+
+// limitRows keeps the first n rows of a newest-first listing (all if n is 0)
+func limitRows[T any](rows []T, n int) []T {
+	if n > 0 && len(rows) > n {
+		return rows[:n]
+	}
+	return rows
+}
+
+// clipboardHistoryListEntry is one row of `history --local --json`, which
+// is also what history --peer reads from the remote side
+type clipboardHistoryListEntry struct {
+	Index     int       `json:"index"`
+	Timestamp time.Time `json:"timestamp"`
+	Preview   string    `json:"preview"`
+	Size      int64     `json:"size"`
+}
+
+// printClipboardHistoryTable renders a clipboard history listing, ending
+// with a hint showing the recall command for these indices
+func printClipboardHistoryTable(entries []clipboardHistoryListEntry, opts tableOptions, recallCmd string) {
+	longestPreview := 0
+	for _, h := range entries {
+		longestPreview = max(longestPreview, len(h.Preview))
+	}
+	previewWidth := opts.columnWidth(50, longestPreview, 5+2+20+2+10+2)
+
+	if !opts.noHeaders {
+		fmt.Printf("%-5s  %-20s  %-10s  %s\n", "INDEX", "TIME", "SIZE", "PREVIEW")
+	}
+	for _, h := range entries {
+		preview := h.Preview
+		if !opts.noTruncate {
+			preview = truncateString(preview, previewWidth)
 		}
-		history = filtered
-		if len(history) == 0 {
-			if jsonOutput {
-				fmt.Println("[]")
-				return nil
-			}
-			fmt.Printf("No clipboard history entries matching %q.\n", searchQuery)
-			return nil
+		fmt.Printf("%-5d  %-20s  %-10s  %s\n",
+			h.Index,
+			h.Timestamp.Format("2006-01-02 15:04:05"),
+			formatSize(h.Size),
+			preview,
+		)
+	}
+	// The hint is decoration too; keep piped output to data rows
+	if !opts.noHeaders {
+		fmt.Println()
+		fmt.Printf("Use '%s' to restore an entry to clipboard.\n", recallCmd)
+	}
+}
+
+// runPeerHistoryCommand runs "pipeboard <op> [args]" on a peer and returns
+// its stdout; peerCommand quotes args such as the --search query. Failures
+// carry the remote's stderr and a hint that the peer may run a pipeboard
+// without clipboard history support.
+func runPeerHistoryCommand(peerName string, peer PeerConfig, op string, args ...string) ([]byte, error) {
+	argv := peerCommand(peer, op, args...)
+	var out, errOut bytes.Buffer
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Stdin = nil
+	cmd.Stdout = &out
+	cmd.Stderr = &errOut
+
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(errOut.String())
+		if msg == "" {
+			msg = err.Error()
 		}
+		return nil, fmt.Errorf("could not read clipboard history from peer %q (%s): %s\n(the peer needs a pipeboard with clipboard history support)", peerName, peer.SSH, msg)
 	}
+	return out.Bytes(), nil
+}
 
-	// Reverse for most recent first
-	reversed := make([]ClipboardHistoryEntry, len(history))
-	for i := 0; i < len(history); i++ {
-		reversed[i] = history[len(history)-1-i]
+// showPeerClipboardHistory lists a peer's clipboard history by running
+// `history --local --json` on it over ssh
+func showPeerClipboardHistory(peerName string, jsonOutput bool, searchQuery string, opts tableOptions) error {
+	cfg, err := loadConfigForPeers()
+	if err != nil {
+		return err
+	}
+	peer, err := cfg.getPeer(peerName)
+	if err != nil {
+		return err
 	}
 
+	remoteArgs := []string{"--local", "--json"}
+	if searchQuery != "" {
+		remoteArgs = append(remoteArgs, "--search", searchQuery)
+	}
+	out, err := runPeerHistoryCommand(peerName, peer, "history", remoteArgs...)
+	if err != nil {
+		return err
+	}
+
+	var entries []clipboardHistoryListEntry
+	if trimmed := bytes.TrimSpace(out); len(trimmed) > 0 {
+		if err := json.Unmarshal(trimmed, &entries); err != nil {
+			return fmt.Errorf("unexpected clipboard history output from peer %q: %w", peerName, err)
+		}
+	}
+	entries = limitRows(entries, opts.limit)
+
 	if jsonOutput {
-		// Output without content for JSON (too large)
-		type jsonEntry struct {
-			Index     int       `json:"index"`
-			Timestamp time.Time `json:"timestamp"`
-			Preview   string    `json:"preview"`
-			Size      int64     `json:"size"`
-		}
-		entries := make([]jsonEntry, len(reversed))
-		for i, h := range reversed {
-			entries[i] = jsonEntry{
-				Index:     i + 1,
-				Timestamp: h.Timestamp,
-				Preview:   h.Preview,
-				Size:      h.Size,
-			}
+		if entries == nil {
+			entries = []clipboardHistoryListEntry{}
 		}
-		out, err := json.MarshalIndent(entries, "", "  ")
+		out, err := marshalJSONOutput(entries)
 		if err != nil {
 			return err
 		}
 		fmt.Println(string(out))
 		return nil
 	}
+	if len(entries) == 0 {
+		fmt.Printf("No clipboard history on peer %q.\n", peerName)
+		return nil
+	}
 
-	fmt.Printf("%-5s  %-20s  %-10s  %s\n", "INDEX", "TIME", "SIZE", "PREVIEW")
-	for i, h := range reversed {
-		fmt.Printf("%-5d  %-20s  %-10s  %s\n",
-			i+1,
-			h.Timestamp.Format("2006-01-02 15:04:05"),
-			formatSize(h.Size),
-			truncateString(h.Preview, 50),
-		)
+	printClipboardHistoryTable(entries, opts, "pipeboard recall --peer "+peerName+" <index>")
+	return nil
+}
+
+// clipboardHistoryStats summarizes local clipboard history
+type clipboardHistoryStats struct {
+	Entries      int            `json:"entries"`
+	TotalBytes   int64          `json:"total_bytes"`
+	AverageBytes int64          `json:"average_bytes"`
+	Newest       *time.Time     `json:"newest,omitempty"`
+	Oldest       *time.Time     `json:"oldest,omitempty"`
+	ByMIME       map[string]int `json:"by_mime"`
+}
+
+// computeClipboardHistoryStats builds stats from (decrypted) history.
+// Entries that are still encrypted count under "encrypted", since their
+// type can't be detected without the passphrase.
+func computeClipboardHistoryStats(history []ClipboardHistoryEntry) clipboardHistoryStats {
+	stats := clipboardHistoryStats{ByMIME: make(map[string]int)}
+	for _, h := range history {
+		stats.Entries++
+		stats.TotalBytes += h.Size
+		if stats.Newest == nil || h.Timestamp.After(*stats.Newest) {
+			ts := h.Timestamp
+			stats.Newest = &ts
+		}
+		if stats.Oldest == nil || h.Timestamp.Before(*stats.Oldest) {
+			ts := h.Timestamp
+			stats.Oldest = &ts
+		}
+
+		mimeType := "encrypted"
+		if !h.Encrypted {
+			// Group by media type, dropping parameters like charset
+			mimeType, _, _ = strings.Cut(detectMIME(h.Content), ";")
+		}
+		stats.ByMIME[mimeType]++
+	}
+	if stats.Entries > 0 {
+		stats.AverageBytes = stats.TotalBytes / int64(stats.Entries)
+	}
+	return stats
+}
+
+// showClipboardHistoryStats prints a summary of local clipboard history
+func showClipboardHistoryStats(jsonOutput bool) error {
+	path := getClipboardHistoryPath()
+	if path == "" {
+		return errors.New("could not determine clipboard history path")
+	}
+
+	var history []ClipboardHistoryEntry
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &history); err != nil {
+			return err
+		}
+	}
+
+	// Apply TTL cleanup on read, as history --local does
+	histCfg := getHistoryConfig()
+	if histCfg.TTLDays > 0 {
+		history = applyHistoryTTL(history, histCfg.TTLDays)
+	}
+
+	_, passphrase := getHistoryEncryptionConfig()
+	stats := computeClipboardHistoryStats(decryptClipboardHistory(history, passphrase))
+
+	if jsonOutput {
+		out, err := marshalJSONOutput(stats)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+		return nil
+	}
+
+	if stats.Entries == 0 {
+		fmt.Println("No clipboard history yet. Use 'pipeboard copy' to record history.")
+		return nil
+	}
+
+	fmt.Printf("Entries:  %d\n", stats.Entries)
+	fmt.Printf("Total:    %s (avg %s)\n", formatSize(stats.TotalBytes), formatSize(stats.AverageBytes))
+	fmt.Printf("Newest:   %s (%s)\n", stats.Newest.Format("2006-01-02 15:04:05"), formatAge(*stats.Newest))
+	fmt.Printf("Oldest:   %s (%s)\n", stats.Oldest.Format("2006-01-02 15:04:05"), formatAge(*stats.Oldest))
+
+	// Most common types first
+	types := make([]string, 0, len(stats.ByMIME))
+	for t := range stats.ByMIME {
+		types = append(types, t)
+	}
+	sort.Slice(types, func(i, j int) bool {
+		if stats.ByMIME[types[i]] != stats.ByMIME[types[j]] {
+			return stats.ByMIME[types[i]] > stats.ByMIME[types[j]]
+		}
+		return types[i] < types[j]
+	})
+	width := 0
+	for _, t := range types {
+		width = max(width, len(t))
+	}
+	fmt.Println("\nBy type:")
+	for _, t := range types {
+		fmt.Printf("  %-*s  %d\n", width, t, stats.ByMIME[t])
 	}
-	fmt.Println()
-	fmt.Println("Use 'pipeboard recall <index>' to restore an entry to clipboard.")
 	return nil
 }
 
 func cmdRecall(args []string) error {
-	if len(args) != 1 {
-		return fmt.Errorf("usage: pipeboard recall <index>")
+	const usage = "usage: pipeboard recall [--peer <name>] [--stdout] <index>"
+	var positional []string
+	var peerName string
+	var toStdout bool
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--peer":
+			if i+1 >= len(args) {
+				return errors.New("--peer requires a peer name")
+			}
+			i++
+			peerName = args[i]
+		case strings.HasPrefix(arg, "--peer="):
+			peerName = strings.TrimPrefix(arg, "--peer=")
+		case arg == "--stdout":
+			toStdout = true
+		case strings.HasPrefix(arg, "--"):
+			return fmt.Errorf("unknown flag: %s\n%s", arg, usage)
+		default:
+			positional = append(positional, arg)
+		}
+	}
+	if len(positional) != 1 {
+		return errors.New(usage)
 	}
 
 	// Parse index
 	var index int
-	if _, err := fmt.Sscanf(args[0], "%d", &index); err != nil {
-		return fmt.Errorf("invalid index: %s", args[0])
+	if _, err := fmt.Sscanf(positional[0], "%d", &index); err != nil {
+		return fmt.Errorf("invalid index: %s", positional[0])
 	}
 
 	if index < 1 {
 		return fmt.Errorf("index must be >= 1")
 	}
 
+	if peerName != "" {
+		return recallFromPeer(peerName, index, toStdout)
+	}
+
 	path := getClipboardHistoryPath()
 	if path == "" {
 		return errors.New("could not determine clipboard history path")
@@ -565,6 +1150,12 @@ func cmdRecall(args []string) error {
 		content = decContent
 	}
 
+	if toStdout {
+		_, err := os.Stdout.Write(content)
+		return err
+	}
+
+	snapshotClipboard(content)
 	if err := writeClipboard(content); err != nil {
 		return err
 	}
@@ -573,6 +1164,37 @@ func cmdRecall(args []string) error {
 	return nil
 }
 
+// recallFromPeer fetches entry index of a peer's clipboard history with
+// `recall --stdout` on the peer, then copies it locally (or prints it)
+func recallFromPeer(peerName string, index int, toStdout bool) error {
+	cfg, err := loadConfigForPeers()
+	if err != nil {
+		return err
+	}
+	peer, err := cfg.getPeer(peerName)
+	if err != nil {
+		return err
+	}
+
+	content, err := runPeerHistoryCommand(peerName, peer, "recall", strconv.Itoa(index), "--stdout")
+	if err != nil {
+		return err
+	}
+
+	if toStdout {
+		_, err := os.Stdout.Write(content)
+		return err
+	}
+
+	snapshotClipboard(content)
+	if err := writeClipboard(content); err != nil {
+		return err
+	}
+
+	fmt.Printf("restored entry %d from peer %q (%s) to clipboard\n", index, peerName, formatSize(int64(len(content))))
+	return nil
+}
+
 func truncateString(s string, maxLen int) string {
 	if len(s) <= maxLen {
 		return s
@@ -1,7 +1,9 @@
 package main
 
 import (
+	"encoding/csv"
 	"encoding/json"
+	"fmt"
 	"os"
 	"strings"
 	"testing"
@@ -235,7 +237,7 @@ func TestShowClipboardHistoryEmpty(t *testing.T) {
 	_ = os.MkdirAll(tmpDir+"/pipeboard", 0755)
 	_ = os.WriteFile(historyPath, []byte("[]"), 0600)
 
-	err := showClipboardHistory(false, "")
+	err := showClipboardHistory(false, "", tableOptions{})
 	if err != nil {
 		t.Errorf("showClipboardHistory should not error on empty history: %v", err)
 	}
@@ -257,7 +259,7 @@ func TestShowClipboardHistoryJSON(t *testing.T) {
 	// Record some content
 	recordClipboardHistory([]byte("test content"))
 
-	err := showClipboardHistory(true, "")
+	err := showClipboardHistory(true, "", tableOptions{})
 	if err != nil {
 		t.Errorf("showClipboardHistory with JSON should not error: %v", err)
 	}
@@ -282,7 +284,7 @@ func TestShowClipboardHistorySearch(t *testing.T) {
 	recordClipboardHistory([]byte("hello again"))
 
 	// Search for "hello"
-	err := showClipboardHistory(false, "hello")
+	err := showClipboardHistory(false, "hello", tableOptions{})
 	if err != nil {
 		t.Errorf("showClipboardHistory with search should not error: %v", err)
 	}
@@ -305,7 +307,7 @@ func TestShowClipboardHistorySearchNoMatch(t *testing.T) {
 	recordClipboardHistory([]byte("hello world"))
 
 	// Search for something not present
-	err := showClipboardHistory(false, "xyz123notfound")
+	err := showClipboardHistory(false, "xyz123notfound", tableOptions{})
 	if err != nil {
 		t.Errorf("showClipboardHistory with no match should not error: %v", err)
 	}
@@ -433,6 +435,53 @@ func TestCmdHistoryJSONOutput(t *testing.T) {
 	}
 }
 
+// Test cmdHistory --csv writes valid, escaped CSV with a header row
+func TestCmdHistoryCSVOutput(t *testing.T) {
+	tmpDir := t.TempDir()
+	origXDG := os.Getenv("XDG_CONFIG_HOME")
+	defer func() {
+		if origXDG != "" {
+			_ = os.Setenv("XDG_CONFIG_HOME", origXDG)
+		} else {
+			_ = os.Unsetenv("XDG_CONFIG_HOME")
+		}
+	}()
+	_ = os.Setenv("XDG_CONFIG_HOME", tmpDir)
+
+	recordHistory("push", `a,"b"`, 100)
+	recordHistory("pull", "plain", 0)
+
+	out := captureOutput(func() {
+		if err := cmdHistory([]string{"--csv"}); err != nil {
+			t.Errorf("cmdHistory --csv error: %v", err)
+		}
+	})
+	records, err := csv.NewReader(strings.NewReader(out)).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV: %v\n%s", err, out)
+	}
+	if len(records) != 3 {
+		t.Fatalf("expected header and two rows, got %d records", len(records))
+	}
+	if strings.Join(records[0], ",") != "time,command,target,size" {
+		t.Errorf("unexpected header: %v", records[0])
+	}
+	// Most recent first, matching the table output
+	if records[1][1] != "pull" || records[2][1] != "push" {
+		t.Errorf("unexpected order: %v", records[1:])
+	}
+	if records[2][2] != `a,"b"` || records[2][3] != "100" {
+		t.Errorf("unexpected row: %v", records[2])
+	}
+
+	if err := cmdHistory([]string{"--tsv", "--local"}); err == nil {
+		t.Error("expected error for --tsv with --local")
+	}
+	if err := cmdHistory([]string{"--csv", "--json"}); err == nil {
+		t.Error("expected error combining --csv and --json")
+	}
+}
+
 // Test cmdHistory --local with JSON and search
 func TestCmdHistoryLocalJSONWithSearch(t *testing.T) {
 	tmpDir := t.TempDir()
@@ -468,7 +517,7 @@ func TestShowClipboardHistoryNoFile(t *testing.T) {
 	_ = os.Setenv("XDG_CONFIG_HOME", tmpDir)
 
 	// Don't create the file
-	err := showClipboardHistory(false, "")
+	err := showClipboardHistory(false, "", tableOptions{})
 	if err != nil {
 		t.Errorf("showClipboardHistory should not error when file doesn't exist: %v", err)
 	}
@@ -488,7 +537,7 @@ func TestShowClipboardHistoryNoFileJSON(t *testing.T) {
 	_ = os.Setenv("XDG_CONFIG_HOME", tmpDir)
 
 	// Don't create the file
-	err := showClipboardHistory(true, "")
+	err := showClipboardHistory(true, "", tableOptions{})
 	if err != nil {
 		t.Errorf("showClipboardHistory JSON should not error when file doesn't exist: %v", err)
 	}
@@ -991,7 +1040,7 @@ func TestShowClipboardHistoryJSONSearch(t *testing.T) {
 	recordClipboardHistory([]byte("foo bar"))
 
 	// Search with JSON output
-	err := showClipboardHistory(true, "hello")
+	err := showClipboardHistory(true, "hello", tableOptions{})
 	if err != nil {
 		t.Errorf("showClipboardHistory JSON with search should not error: %v", err)
 	}
@@ -1013,7 +1062,7 @@ func TestShowClipboardHistoryJSONSearchNoMatch(t *testing.T) {
 	recordClipboardHistory([]byte("hello world"))
 
 	// Search for non-existent content with JSON
-	err := showClipboardHistory(true, "notfound")
+	err := showClipboardHistory(true, "notfound", tableOptions{})
 	if err != nil {
 		t.Errorf("showClipboardHistory JSON with no match should not error: %v", err)
 	}
@@ -1380,7 +1429,7 @@ sync:
 	recordClipboardHistory([]byte("encrypted test data"))
 
 	// Show history (should decrypt)
-	err := showClipboardHistory(false, "")
+	err := showClipboardHistory(false, "", tableOptions{})
 	if err != nil {
 		t.Errorf("showClipboardHistory should not error with encryption: %v", err)
 	}
@@ -1414,7 +1463,7 @@ sync:
 	recordClipboardHistory([]byte("searchable encrypted data"))
 
 	// Search in encrypted history (should decrypt and search)
-	err := showClipboardHistory(false, "searchable")
+	err := showClipboardHistory(false, "searchable", tableOptions{})
 	if err != nil {
 		t.Errorf("search on encrypted history should not error: %v", err)
 	}
@@ -1613,7 +1662,7 @@ func TestShowClipboardHistoryInvalidJSONStructure(t *testing.T) {
 	_ = os.MkdirAll(tmpDir+"/pipeboard", 0755)
 	_ = os.WriteFile(historyPath, []byte(`{"wrong": "structure"}`), 0600)
 
-	err := showClipboardHistory(false, "")
+	err := showClipboardHistory(false, "", tableOptions{})
 	if err == nil {
 		t.Error("showClipboardHistory should error on wrong JSON structure")
 	}
@@ -1841,3 +1890,693 @@ history:
 		t.Error("old entry should have been removed by TTL")
 	}
 }
+
+// Test --no-truncate shows full previews in local history
+func TestShowClipboardHistoryNoTruncate(t *testing.T) {
+	cleanup := setupSlotsTestConfig(t, "")
+	defer cleanup()
+
+	long := strings.Repeat("abcdefghij", 8) // 80 chars, over the default 50
+	recordClipboardHistory([]byte(long))
+
+	out := captureOutput(func() {
+		if err := showClipboardHistory(false, "", tableOptions{}); err != nil {
+			t.Errorf("showClipboardHistory error: %v", err)
+		}
+	})
+	if strings.Contains(out, long) {
+		t.Error("default output should truncate long previews")
+	}
+
+	out = captureOutput(func() {
+		if err := showClipboardHistory(false, "", tableOptions{noTruncate: true}); err != nil {
+			t.Errorf("showClipboardHistory error: %v", err)
+		}
+	})
+	if !strings.Contains(out, long) {
+		t.Errorf("--no-truncate should show the full preview, got:\n%s", out)
+	}
+}
+
+// Test --wide preview width adapts to the terminal width
+func TestShowClipboardHistoryWide(t *testing.T) {
+	cleanup := setupSlotsTestConfig(t, "")
+	defer cleanup()
+
+	origWidth := terminalWidth
+	defer func() { terminalWidth = origWidth }()
+
+	long := strings.Repeat("x", previewLength)
+	recordClipboardHistory([]byte(long))
+
+	previewLen := func(opts tableOptions) int {
+		out := captureOutput(func() {
+			if err := showClipboardHistory(false, "", opts); err != nil {
+				t.Errorf("showClipboardHistory error: %v", err)
+			}
+		})
+		for _, line := range strings.Split(out, "\n") {
+			if strings.HasPrefix(line, "1 ") {
+				fields := strings.Fields(line)
+				return len(fields[len(fields)-1])
+			}
+		}
+		t.Fatalf("no entry line in output:\n%s", out)
+		return 0
+	}
+
+	terminalWidth = func() int { return 121 }
+	if got := previewLen(tableOptions{wide: true}); got != 121-41 {
+		t.Errorf("expected preview width %d at 121 columns, got %d", 121-41, got)
+	}
+
+	// Not a TTY: fall back to the default width
+	terminalWidth = func() int { return 0 }
+	if got := previewLen(tableOptions{wide: true}); got != 50 {
+		t.Errorf("expected default preview width 50 without a TTY, got %d", got)
+	}
+}
+
+// Test --wide expands the command and target columns of operation history
+func TestCmdHistoryWide(t *testing.T) {
+	cleanup := setupSlotsTestConfig(t, "")
+	defer cleanup()
+
+	origWidth := terminalWidth
+	defer func() { terminalWidth = origWidth }()
+	terminalWidth = func() int { return 200 }
+
+	longTarget := "a-very-long-target-name-for-alignment"
+	recordHistory("push", longTarget, 10)
+	recordHistory("pull", "short", 10)
+
+	out := captureOutput(func() {
+		if err := cmdHistory([]string{"--wide"}); err != nil {
+			t.Errorf("cmdHistory --wide error: %v", err)
+		}
+	})
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected header and 2 rows, got:\n%s", out)
+	}
+	// SIZE column starts at the same offset on every row
+	col := strings.Index(lines[0], "SIZE")
+	for _, line := range lines[1:] {
+		if strings.Index(line, "10 B") != col {
+			t.Errorf("misaligned SIZE column in %q (want offset %d)", line, col)
+		}
+	}
+}
+
+// Test --no-headers drops the header row of both history tables
+func TestCmdHistoryNoHeaders(t *testing.T) {
+	cleanup := setupSlotsTestConfig(t, "")
+	defer cleanup()
+
+	recordHistory("push", "kube-config", 10)
+	recordClipboardHistory([]byte("snapshot"))
+
+	for _, tc := range []struct {
+		args   []string
+		header string
+	}{
+		{nil, "COMMAND"},
+		{[]string{"--local"}, "PREVIEW"},
+	} {
+		out := captureOutput(func() {
+			if err := cmdHistory(tc.args); err != nil {
+				t.Errorf("cmdHistory %v error: %v", tc.args, err)
+			}
+		})
+		if !strings.Contains(out, tc.header) {
+			t.Errorf("%v: expected header by default, got:\n%s", tc.args, out)
+		}
+
+		out = captureOutput(func() {
+			if err := cmdHistory(append(tc.args, "--no-headers", "--wide")); err != nil {
+				t.Errorf("cmdHistory %v --no-headers error: %v", tc.args, err)
+			}
+		})
+		lines := strings.Split(strings.TrimSpace(out), "\n")
+		if strings.Contains(out, tc.header) || len(lines) != 1 {
+			t.Errorf("%v: expected only the data row with --no-headers, got:\n%s", tc.args, out)
+		}
+	}
+}
+
+// Test columnWidth behavior
+func TestTableOptionsColumnWidth(t *testing.T) {
+	origWidth := terminalWidth
+	defer func() { terminalWidth = origWidth }()
+
+	terminalWidth = func() int { return 100 }
+	if got := (tableOptions{}).columnWidth(20, 60, 30); got != 20 {
+		t.Errorf("narrow mode should use default width, got %d", got)
+	}
+	if got := (tableOptions{wide: true}).columnWidth(20, 40, 30); got != 40 {
+		t.Errorf("wide mode should fit longest value, got %d", got)
+	}
+	if got := (tableOptions{wide: true}).columnWidth(20, 90, 30); got != 70 {
+		t.Errorf("wide mode should cap at available width, got %d", got)
+	}
+	if got := (tableOptions{wide: true}).columnWidth(20, 90, 95); got != 20 {
+		t.Errorf("wide mode should never shrink below default, got %d", got)
+	}
+}
+
+// seedClipboardHistoryForStats writes four entries: plain text, a PNG,
+// text encrypted with the configured passphrase, and text encrypted with
+// another passphrase
+func seedClipboardHistoryForStats(t *testing.T, base time.Time) {
+	t.Helper()
+	mine, err := encrypt([]byte("secret text"), "statspass")
+	if err != nil {
+		t.Fatal(err)
+	}
+	theirs, err := encrypt([]byte("someone else"), "otherpass")
+	if err != nil {
+		t.Fatal(err)
+	}
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	history := []ClipboardHistoryEntry{
+		{Timestamp: base.Add(-3 * time.Hour), Content: []byte("hello"), Size: 5},
+		{Timestamp: base.Add(-2 * time.Hour), Content: png, Size: int64(len(png))},
+		{Timestamp: base.Add(-1 * time.Hour), Content: mine, Size: 11, Encrypted: true},
+		{Timestamp: base, Content: theirs, Size: 12, Encrypted: true},
+	}
+	data, err := json.Marshal(history)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(getClipboardHistoryPath(), data, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Test history --local --stats counts entries, sizes and MIME types
+func TestClipboardHistoryStats(t *testing.T) {
+	cleanup := setupSlotsTestConfig(t, `version: 1
+sync:
+  backend: local
+  encryption: aes256
+  passphrase: statspass
+`)
+	defer cleanup()
+	base := time.Now().Add(-time.Minute).Truncate(time.Second)
+	seedClipboardHistoryForStats(t, base)
+
+	out := captureOutput(func() {
+		if err := cmdHistory([]string{"--local", "--stats", "--json"}); err != nil {
+			t.Errorf("history --local --stats --json: %v", err)
+		}
+	})
+	var stats clipboardHistoryStats
+	if err := json.Unmarshal([]byte(out), &stats); err != nil {
+		t.Fatalf("decoding stats: %v\n%s", err, out)
+	}
+	if stats.Entries != 4 {
+		t.Errorf("entries = %d, want 4", stats.Entries)
+	}
+	if stats.TotalBytes != 5+16+11+12 || stats.AverageBytes != 44/4 {
+		t.Errorf("total/avg = %d/%d, want 44/11", stats.TotalBytes, stats.AverageBytes)
+	}
+	if stats.Newest == nil || !stats.Newest.Equal(base) {
+		t.Errorf("newest = %v, want %v", stats.Newest, base)
+	}
+	if stats.Oldest == nil || !stats.Oldest.Equal(base.Add(-3*time.Hour)) {
+		t.Errorf("oldest = %v, want %v", stats.Oldest, base.Add(-3*time.Hour))
+	}
+	want := map[string]int{"text/plain": 2, "image/png": 1, "encrypted": 1}
+	if len(stats.ByMIME) != len(want) {
+		t.Errorf("by_mime = %v, want %v", stats.ByMIME, want)
+	}
+	for k, v := range want {
+		if stats.ByMIME[k] != v {
+			t.Errorf("by_mime[%s] = %d, want %d", k, stats.ByMIME[k], v)
+		}
+	}
+
+	out = captureOutput(func() {
+		if err := cmdHistory([]string{"--local", "--stats"}); err != nil {
+			t.Errorf("history --local --stats: %v", err)
+		}
+	})
+	for _, s := range []string{"Entries:  4", "text/plain  2", "image/png   1", "encrypted   1"} {
+		if !strings.Contains(out, s) {
+			t.Errorf("output missing %q:\n%s", s, out)
+		}
+	}
+	// Most common type is listed first
+	if strings.Index(out, "text/plain") > strings.Index(out, "image/png") {
+		t.Errorf("types should be sorted by count:\n%s", out)
+	}
+}
+
+// Test history --stats flag validation and the empty case
+func TestClipboardHistoryStatsFlags(t *testing.T) {
+	cleanup := setupSlotsTestConfig(t, "")
+	defer cleanup()
+
+	if err := cmdHistory([]string{"--stats"}); err == nil || !strings.Contains(err.Error(), "requires --local") {
+		t.Errorf("--stats without --local: got %v", err)
+	}
+	if err := cmdHistory([]string{"--local", "--stats", "--search", "x"}); err == nil {
+		t.Error("--stats with --search should fail")
+	}
+
+	out := captureOutput(func() { _ = cmdHistory([]string{"--local", "--stats", "--json"}) })
+	var stats clipboardHistoryStats
+	if err := json.Unmarshal([]byte(out), &stats); err != nil {
+		t.Fatalf("decoding stats: %v\n%s", err, out)
+	}
+	if stats.Entries != 0 || stats.Newest != nil {
+		t.Errorf("empty history stats = %+v", stats)
+	}
+}
+
+// mockPeerHistoryScript is a mock ssh serving a peer's clipboard history
+const mockPeerHistoryScript = `case "$*" in
+  *"history --local --json"*)
+    echo '[{"index":1,"timestamp":"2026-01-02T15:04:05Z","preview":"newest entry","size":12},{"index":2,"timestamp":"2026-01-01T15:04:05Z","preview":"older, \\"quoted\\" entry","size":21}]' ;;
+  *"recall 2 --stdout"*)
+    printf 'older, "quoted" entry' ;;
+  *)
+    echo "unexpected: $*" >&2; exit 1 ;;
+esac`
+
+// Test history --peer --search sends the query as one quoted word
+func TestCmdHistoryPeerSearchQuoting(t *testing.T) {
+	argsPath, marker := setupShellPeer(t, "[]")
+
+	query := "two words; touch " + marker
+	captureOutput(func() {
+		if err := cmdHistory([]string{"--peer", "dev", "--search", query}); err != nil {
+			t.Errorf("history --peer --search: %v", err)
+		}
+	})
+	args, _ := os.ReadFile(argsPath)
+	if want := "pipeboard\nhistory\n--local\n--json\n--search\n" + query + "\n"; string(args) != want {
+		t.Errorf("remote args = %q, want %q", args, want)
+	}
+	if _, err := os.Stat(marker); err == nil {
+		t.Error("search query ran as a command on the peer")
+	}
+}
+
+// Test history --peer <name> renders the peer's clipboard history
+func TestCmdHistoryPeerListing(t *testing.T) {
+	setupScriptPeer(t, mockPeerHistoryScript)
+
+	out := captureOutput(func() {
+		if err := cmdHistory([]string{"--peer", "dev"}); err != nil {
+			t.Errorf("history --peer dev error: %v", err)
+		}
+	})
+	lines := strings.Split(out, "\n")
+	if !strings.HasPrefix(lines[0], "INDEX") {
+		t.Errorf("expected header, got:\n%s", out)
+	}
+	if !strings.HasPrefix(lines[1], "1 ") || !strings.Contains(lines[1], "newest entry") {
+		t.Errorf("unexpected first row %q", lines[1])
+	}
+	if !strings.HasPrefix(lines[2], "2 ") || !strings.Contains(lines[2], `older, "quoted" entry`) {
+		t.Errorf("unexpected second row %q", lines[2])
+	}
+	if !strings.Contains(out, "pipeboard recall --peer dev <index>") {
+		t.Errorf("expected peer recall hint, got:\n%s", out)
+	}
+
+	out = captureOutput(func() {
+		if err := cmdHistory([]string{"--peer", "dev", "--json"}); err != nil {
+			t.Errorf("history --peer dev --json error: %v", err)
+		}
+	})
+	var entries []clipboardHistoryListEntry
+	if err := json.Unmarshal([]byte(out), &entries); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out)
+	}
+	if len(entries) != 2 || entries[1].Size != 21 || entries[1].Index != 2 {
+		t.Errorf("unexpected entries: %+v", entries)
+	}
+}
+
+// Test a bare --peer still filters operation history
+func TestCmdHistoryPeerFilterFlag(t *testing.T) {
+	setupScriptPeer(t, "echo 'ssh should not run' >&2; exit 1")
+	recordHistory("send", "dev", 10)
+	recordHistory("push", "slot", 10)
+
+	out := captureOutput(func() {
+		if err := cmdHistory([]string{"--peer", "--no-headers"}); err != nil {
+			t.Errorf("history --peer error: %v", err)
+		}
+	})
+	if !strings.Contains(out, "send") || strings.Contains(out, "push") {
+		t.Errorf("expected only peer operations, got:\n%s", out)
+	}
+}
+
+// Test history --peer degrades gracefully when the peer has no history
+// support or no entries
+func TestCmdHistoryPeerUnavailable(t *testing.T) {
+	setupScriptPeer(t, "echo 'unknown flag: --local' >&2; exit 1")
+	err := cmdHistory([]string{"--peer", "dev"})
+	if err == nil {
+		t.Fatal("expected error from peer without history support")
+	}
+	if !strings.Contains(err.Error(), "unknown flag: --local") || !strings.Contains(err.Error(), "clipboard history support") {
+		t.Errorf("error should carry the remote message and a hint: %v", err)
+	}
+
+	setupScriptPeer(t, "echo '[]'")
+	out := captureOutput(func() {
+		if err := cmdHistory([]string{"--peer", "dev"}); err != nil {
+			t.Errorf("history --peer with empty remote history: %v", err)
+		}
+	})
+	if !strings.Contains(out, "No clipboard history on peer") {
+		t.Errorf("expected empty-history message, got %q", out)
+	}
+
+	setupScriptPeer(t, "echo 'not json'")
+	if err := cmdHistory([]string{"--peer", "dev"}); err == nil {
+		t.Error("expected error for unparseable remote output")
+	}
+}
+
+// Test recall --peer copies the remote entry into the local clipboard
+func TestCmdRecallPeer(t *testing.T) {
+	setupScriptPeer(t, mockPeerHistoryScript)
+	clipPath := useFileClipboard(t, "before")
+
+	out := captureOutput(func() {
+		if err := cmdRecall([]string{"--peer", "dev", "2"}); err != nil {
+			t.Errorf("recall --peer dev 2 error: %v", err)
+		}
+	})
+	if got, _ := os.ReadFile(clipPath); string(got) != `older, "quoted" entry` {
+		t.Errorf("clipboard = %q", got)
+	}
+	if !strings.Contains(out, `restored entry 2 from peer "dev"`) {
+		t.Errorf("unexpected output %q", out)
+	}
+
+	// A failing recall leaves the clipboard alone
+	var err error
+	captureStderr(func() { err = cmdRecall([]string{"--peer", "dev", "9"}) })
+	if err == nil {
+		t.Error("expected error for out-of-range remote index")
+	}
+	if got, _ := os.ReadFile(clipPath); string(got) != `older, "quoted" entry` {
+		t.Errorf("clipboard changed after failed recall: %q", got)
+	}
+}
+
+// Test recall --stdout prints the entry instead of copying it
+func TestCmdRecallStdout(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", tmpDir)
+	clipPath := useFileClipboard(t, "untouched")
+	recordClipboardHistory([]byte("first"))
+	recordClipboardHistory([]byte("second"))
+
+	out := captureOutput(func() {
+		if err := cmdRecall([]string{"--stdout", "2"}); err != nil {
+			t.Errorf("recall --stdout error: %v", err)
+		}
+	})
+	if out != "first" {
+		t.Errorf("stdout = %q, want %q", out, "first")
+	}
+	if got, _ := os.ReadFile(clipPath); string(got) != "untouched" {
+		t.Errorf("clipboard = %q, want it untouched", got)
+	}
+}
+
+// Test --count caps the listing while indices still match recall
+func TestCmdHistoryCount(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	for i := 1; i <= 15; i++ {
+		recordClipboardHistory([]byte(fmt.Sprintf("entry-%02d", i)))
+		recordHistory("push", fmt.Sprintf("slot-%02d", i), int64(i))
+	}
+
+	out := captureOutput(func() {
+		if err := cmdHistory([]string{"--local", "--count", "5", "--no-headers"}); err != nil {
+			t.Fatalf("history --local --count: %v", err)
+		}
+	})
+	rows := strings.Split(strings.TrimSpace(out), "\n")
+	if len(rows) != 5 || !strings.HasPrefix(rows[0], "1 ") || !strings.Contains(rows[4], "entry-11") {
+		t.Errorf("expected the 5 newest rows, got:\n%s", out)
+	}
+
+	// Entries past the display cap are still reachable by their index
+	out = captureOutput(func() {
+		if err := cmdRecall([]string{"--stdout", "10"}); err != nil {
+			t.Fatalf("recall 10: %v", err)
+		}
+	})
+	if out != "entry-06" {
+		t.Errorf("recall 10 = %q, want entry-06", out)
+	}
+
+	// Search results keep their full-history indices
+	out = captureOutput(func() {
+		if err := cmdHistory([]string{"--local", "--json", "--search", "entry-06"}); err != nil {
+			t.Fatalf("history --search: %v", err)
+		}
+	})
+	var entries []clipboardHistoryListEntry
+	if err := json.Unmarshal([]byte(out), &entries); err != nil || len(entries) != 1 || entries[0].Index != 10 {
+		t.Errorf("search result = %+v (%v), want index 10", entries, err)
+	}
+
+	out = captureOutput(func() {
+		if err := cmdHistory([]string{"--count=3", "--json"}); err != nil {
+			t.Fatalf("history --count: %v", err)
+		}
+	})
+	var ops []HistoryEntry
+	if err := json.Unmarshal([]byte(out), &ops); err != nil || len(ops) != 3 || ops[0].Target != "slot-15" {
+		t.Errorf("operation history = %+v (%v), want the 3 newest", ops, err)
+	}
+
+	for _, args := range [][]string{{"--count"}, {"--count", "0"}, {"--count=x"}, {"--local", "--stats", "--count", "2"}} {
+		if err := cmdHistory(args); err == nil {
+			t.Errorf("history %v should fail", args)
+		}
+	}
+}
+
+// Test PIPEBOARD_HISTORY_LIMIT overrides history.limit, and values that
+// aren't positive integers are ignored
+func TestGetClipboardHistoryLimitEnv(t *testing.T) {
+	defer setupSlotsTestConfig(t, "version: 1\nhistory:\n  limit: 50\n")()
+
+	tests := []struct {
+		env  string
+		want int
+	}{
+		{"", 50},
+		{"500", 500},
+		{"1", 1},
+		{"0", 50},
+		{"-3", 50},
+		{"lots", 50},
+		{"2.5", 50},
+	}
+	for _, tt := range tests {
+		t.Setenv("PIPEBOARD_HISTORY_LIMIT", tt.env)
+		if got := getClipboardHistoryLimit(); got != tt.want {
+			t.Errorf("PIPEBOARD_HISTORY_LIMIT=%q: limit = %d, want %d", tt.env, got, tt.want)
+		}
+	}
+
+	// Without a config limit, an invalid value falls back to the default
+	defer setupSlotsTestConfig(t, "version: 1\n")()
+	t.Setenv("PIPEBOARD_HISTORY_LIMIT", "nope")
+	if got := getClipboardHistoryLimit(); got != defaultClipboardHistoryLimit {
+		t.Errorf("limit = %d, want default %d", got, defaultClipboardHistoryLimit)
+	}
+}
+
+// Test recording history trims to the env override
+func TestRecordClipboardHistoryEnvLimit(t *testing.T) {
+	defer setupSlotsTestConfig(t, "version: 1\nhistory:\n  limit: 3\n")()
+	t.Setenv("PIPEBOARD_HISTORY_LIMIT", "30")
+
+	for i := 0; i < 25; i++ {
+		recordClipboardHistory([]byte(fmt.Sprintf("entry %d", i)))
+	}
+	if entries := loadClipboardHistory(t); len(entries) != 25 {
+		t.Errorf("history has %d entries, want 25 (env limit 30 over config 3)", len(entries))
+	}
+}
+
+// Test history.batch_writes coalesces rapid records into one write per
+// file, persisting every entry once flushed
+func TestHistoryBatchWrites(t *testing.T) {
+	defer setupSlotsTestConfig(t, "version: 1\nhistory:\n  limit: 50\n  batch_writes: true\n")()
+
+	// Not started (as outside run): each record is written at once
+	before := historyFileWrites.Load()
+	for i := 0; i < 20; i++ {
+		recordClipboardHistory([]byte(fmt.Sprintf("sync %d", i)))
+	}
+	syncWrites := historyFileWrites.Load() - before
+	if syncWrites != 20 {
+		t.Errorf("synchronous records made %d writes, want 20", syncWrites)
+	}
+
+	stop := historyQueue.start()
+	before = historyFileWrites.Load()
+	buf := []byte("batched 00")
+	for i := 0; i < 20; i++ {
+		// Reusing the buffer must not change queued entries
+		copy(buf[len(buf)-2:], fmt.Sprintf("%02d", i))
+		recordClipboardHistory(buf)
+		recordHistory("push", fmt.Sprintf("slot-%d", i), 1)
+	}
+	if got := len(loadClipboardHistory(t)); got != 20 {
+		t.Errorf("history has %d entries before the flush, want the 20 synchronous ones", got)
+	}
+	stop()
+
+	batchWrites := historyFileWrites.Load() - before
+	if batchWrites != 2 {
+		t.Errorf("batched records made %d writes, want 2 (one per file)", batchWrites)
+	}
+	entries := loadClipboardHistory(t)
+	if len(entries) != 40 {
+		t.Fatalf("history has %d entries after the flush, want 40", len(entries))
+	}
+	for i, e := range entries[20:] {
+		if want := fmt.Sprintf("batched %02d", i); string(e.Content) != want {
+			t.Errorf("entry %d = %q, want %q", 20+i, e.Content, want)
+		}
+	}
+	data, err := os.ReadFile(getHistoryPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ops []HistoryEntry
+	if err := json.Unmarshal(data, &ops); err != nil {
+		t.Fatal(err)
+	}
+	if len(ops) != 20 || ops[19].Target != "slot-19" {
+		t.Errorf("command history has %d entries, want 20 ending with slot-19", len(ops))
+	}
+
+	// Stopped again: back to synchronous writes
+	recordClipboardHistory([]byte("after"))
+	if got := len(loadClipboardHistory(t)); got != 41 {
+		t.Errorf("history has %d entries, want 41", got)
+	}
+}
+
+// Test batched records are written by the timer without waiting for exit
+func TestHistoryBatchTimerFlush(t *testing.T) {
+	defer setupSlotsTestConfig(t, "version: 1\nhistory:\n  batch_writes: true\n")()
+	stop := historyQueue.start()
+	defer stop()
+
+	recordClipboardHistory([]byte("soon"))
+	deadline := time.Now().Add(5 * historyFlushDelay)
+	for time.Now().Before(deadline) {
+		historyFileMu.Lock()
+		_, err := os.Stat(getClipboardHistoryPath())
+		historyFileMu.Unlock()
+		if err == nil {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Error("batched record wasn't written by the flush timer")
+}
+
+// Test run flushes batched history before returning, and that without
+// batch_writes a started writer still writes synchronously
+func TestRunFlushesBatchedHistory(t *testing.T) {
+	for _, batch := range []bool{true, false} {
+		t.Run(fmt.Sprintf("batch=%t", batch), func(t *testing.T) {
+			defer setupSlotsTestConfig(t, fmt.Sprintf("version: 1\nhistory:\n  batch_writes: %t\n", batch))()
+			useFileClipboard(t, "")
+			if code := run([]string{"copy", "via run"}, func() bool { return false }); code != 0 {
+				t.Fatalf("run copy exited %d", code)
+			}
+			entries := loadClipboardHistory(t)
+			if len(entries) != 1 || string(entries[0].Content) != "via run" {
+				t.Errorf("history after run = %+v", entries)
+			}
+		})
+	}
+}
+
+// Test history clear empties operation or clipboard history after
+// confirmation, and is a no-op without a history file
+func TestCmdHistoryClear(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	origTTY, origConfirm := stdinIsTerminal, confirmClearHistory
+	defer func() { stdinIsTerminal, confirmClearHistory = origTTY, origConfirm }()
+
+	captureOutput(func() {
+		if err := cmdHistory([]string{"clear", "--yes"}); err != nil {
+			t.Errorf("clear without a history file: %v", err)
+		}
+	})
+	if _, err := os.Stat(getHistoryPath()); !os.IsNotExist(err) {
+		t.Error("clear should not create the history file")
+	}
+
+	recordHistory("push", "a", 1)
+	recordHistory("pull", "a", 1)
+	recordClipboardHistory([]byte("secret"))
+
+	// Non-interactive runs need --yes
+	stdinIsTerminal = func() bool { return false }
+	if err := cmdHistory([]string{"clear"}); err == nil || !strings.Contains(err.Error(), "--yes") {
+		t.Errorf("expected --yes error, got %v", err)
+	}
+
+	stdinIsTerminal = func() bool { return true }
+	var prompt string
+	confirmClearHistory = func(p string) bool { prompt = p; return false }
+	if err := cmdHistory([]string{"clear"}); err == nil || !strings.Contains(err.Error(), "cancelled") {
+		t.Errorf("expected cancel error, got %v", err)
+	}
+	if !strings.Contains(prompt, "2 operation history entries") {
+		t.Errorf("prompt = %q", prompt)
+	}
+
+	confirmClearHistory = func(string) bool { return true }
+	captureOutput(func() {
+		if err := cmdHistory([]string{"clear"}); err != nil {
+			t.Errorf("history clear: %v", err)
+		}
+	})
+	if data, _ := os.ReadFile(getHistoryPath()); string(data) != "[]" {
+		t.Errorf("history file = %q, want an empty list", data)
+	}
+	// Clipboard history is separate
+	if data, _ := os.ReadFile(getClipboardHistoryPath()); !strings.Contains(string(data), "secret") {
+		t.Error("clipboard history should be kept without --local")
+	}
+
+	captureOutput(func() {
+		if err := cmdHistory([]string{"clear", "--local", "--yes"}); err != nil {
+			t.Errorf("history clear --local: %v", err)
+		}
+	})
+	if data, _ := os.ReadFile(getClipboardHistoryPath()); string(data) != "[]" {
+		t.Errorf("clipboard history file = %q, want an empty list", data)
+	}
+	// The clear itself isn't recorded
+	if data, _ := os.ReadFile(getHistoryPath()); string(data) != "[]" {
+		t.Errorf("history file = %q after clear --local, want it still empty", data)
+	}
+
+	if err := cmdHistory([]string{"clear", "--fx"}); err == nil {
+		t.Error("expected an unknown argument error")
+	}
+}
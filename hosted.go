@@ -7,6 +7,8 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -15,6 +17,9 @@ import (
 type HostedConfig struct {
 	URL   string `yaml:"url"`   // Base URL of the mobile backend (e.g., https://pipeboard.example.com)
 	Email string `yaml:"email"` // User email for authentication
+	// Prefix namespaces slot names so several projects can share one
+	// account (e.g. "work-" stores slot "notes" as "work-notes")
+	Prefix string `yaml:"prefix,omitempty"`
 	// Token is stored securely in keychain/encrypted file, not in config file
 }
 
@@ -24,10 +29,12 @@ type HostedConfig struct {
 type HostedBackend struct {
 	baseURL    string       // Base URL of the backend API
 	email      string       // User's email address
+	prefix     string       // Prepended to slot names on the server
 	token      string       // JWT authentication token
 	httpClient *http.Client // HTTP client with 30s timeout
-	encryption string       // Encryption mode: "none" or "aes256"
+	encryption string       // Encryption mode: "none", "aes256" or "age"
 	passphrase string       // Encryption passphrase (empty if encryption is "none")
+	age        *ageKeys     // Recipients and identities for encryption: age
 	ttlDays    int          // TTL for slots (0 = never expires)
 }
 
@@ -69,6 +76,34 @@ type slotDataResponse struct {
 	UpdatedAt     string `json:"updated_at"`     // ISO 8601 timestamp
 }
 
+func init() {
+	registerBackend("hosted", backendDriver{
+		validate: func(cfg *SyncConfig) error {
+			// Hosted backend requires URL and email
+			if cfg.Hosted == nil {
+				return fmt.Errorf("hosted backend selected but hosted config missing")
+			}
+			if cfg.Hosted.URL == "" {
+				return fmt.Errorf("hosted.url is required")
+			}
+			if cfg.Hosted.Email == "" {
+				return fmt.Errorf("hosted.email is required")
+			}
+			return nil
+		},
+		open: func(cfg *SyncConfig) (RemoteBackend, error) {
+			b, err := newHostedBackend(cfg.Hosted, cfg.Encryption, cfg.Passphrase, cfg.TTLDays)
+			if err != nil {
+				return nil, err
+			}
+			if b.age, err = loadAgeKeys(cfg); err != nil {
+				return nil, err
+			}
+			return b, nil
+		},
+	})
+}
+
 // newHostedBackend creates a new HostedBackend instance.
 // It retrieves the JWT token from secure storage (keychain or encrypted file).
 // Returns an error if the user is not logged in or if encryption config is invalid.
@@ -91,6 +126,7 @@ func newHostedBackend(cfg *HostedConfig, encryption, passphrase string, ttlDays
 	return &HostedBackend{
 		baseURL:    cfg.URL,
 		email:      cfg.Email,
+		prefix:     cfg.Prefix,
 		token:      token,
 		httpClient: &http.Client{Timeout: 30 * time.Second},
 		encryption: encryption,
@@ -99,16 +135,17 @@ func newHostedBackend(cfg *HostedConfig, encryption, passphrase string, ttlDays
 	}, nil
 }
 
+// slotURL returns the API URL for a slot, with the namespace prefix applied
+func (h *HostedBackend) slotURL(slot string) string {
+	return fmt.Sprintf("%s/api/v1/slots/%s", h.baseURL, h.prefix+slot)
+}
+
 // Push uploads encrypted data to a slot
 func (h *HostedBackend) Push(slot string, data []byte, meta map[string]string) error {
 	// Encrypt data if configured
-	payload := data
-	var err error
-	if h.encryption == "aes256" {
-		payload, err = encrypt(data, h.passphrase)
-		if err != nil {
-			return fmt.Errorf("encryption failed: %w", err)
-		}
+	payload, _, _, err := encryptSlotData(data, h.encryption, h.passphrase, h.age)
+	if err != nil {
+		return err
 	}
 
 	// Determine content type from metadata or detect
@@ -118,8 +155,7 @@ func (h *HostedBackend) Push(slot string, data []byte, meta map[string]string) e
 	}
 
 	// Create HTTP request
-	url := fmt.Sprintf("%s/api/v1/slots/%s", h.baseURL, slot)
-	req, err := http.NewRequest(http.MethodPut, url, bytes.NewReader(payload))
+	req, err := http.NewRequest(http.MethodPut, h.slotURL(slot), bytes.NewReader(payload))
 	if err != nil {
 		return err
 	}
@@ -150,8 +186,7 @@ func (h *HostedBackend) Push(slot string, data []byte, meta map[string]string) e
 // Pull downloads and decrypts data from a slot
 func (h *HostedBackend) Pull(slot string) ([]byte, map[string]string, error) {
 	// Create HTTP request
-	url := fmt.Sprintf("%s/api/v1/slots/%s", h.baseURL, slot)
-	req, err := http.NewRequest(http.MethodGet, url, nil)
+	req, err := http.NewRequest(http.MethodGet, h.slotURL(slot), nil)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -190,12 +225,19 @@ func (h *HostedBackend) Pull(slot string) ([]byte, map[string]string, error) {
 		return nil, nil, fmt.Errorf("failed to decode base64: %w", err)
 	}
 
-	// Decrypt data if configured
+	// Decrypt data if configured. The server stores no envelope, so age
+	// data is recognized by its header.
 	data := encryptedData
-	if h.encryption == "aes256" {
-		data, err = decrypt(encryptedData, h.passphrase)
-		if err != nil {
-			return nil, nil, fmt.Errorf("decryption failed: %w", err)
+	var scheme string
+	switch {
+	case isAgeEncrypted(encryptedData):
+		scheme = "age"
+	case h.encryption == "aes256":
+		scheme = "aes256"
+	}
+	if scheme != "" {
+		if data, err = decryptSlotData(encryptedData, scheme, h.passphrase, h.age); err != nil {
+			return nil, nil, err
 		}
 	}
 
@@ -203,6 +245,9 @@ func (h *HostedBackend) Pull(slot string) ([]byte, map[string]string, error) {
 	meta := map[string]string{
 		"mime":       slotData.ContentType,
 		"updated_at": slotData.UpdatedAt,
+		"stored":     strconv.Itoa(slotData.SizeBytes),
+		"encrypted":  strconv.FormatBool(scheme != ""),
+		"encryption": scheme,
 	}
 
 	return data, meta, nil
@@ -242,15 +287,19 @@ func (h *HostedBackend) List() ([]RemoteSlot, error) {
 		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
 
-	// Convert to RemoteSlot
-	slots := make([]RemoteSlot, len(metadata))
-	for i, m := range metadata {
+	// Convert to RemoteSlot, keeping only slots in our namespace
+	slots := make([]RemoteSlot, 0, len(metadata))
+	for _, m := range metadata {
+		name, ok := strings.CutPrefix(m.Name, h.prefix)
+		if !ok || name == "" {
+			continue
+		}
 		updatedAt, _ := time.Parse(time.RFC3339, m.UpdatedAt)
-		slots[i] = RemoteSlot{
-			Name:      m.Name,
+		slots = append(slots, RemoteSlot{
+			Name:      name,
 			Size:      int64(m.SizeBytes),
 			CreatedAt: updatedAt, // Use updated_at as created_at since backend doesn't return it
-		}
+		})
 	}
 
 	return slots, nil
@@ -259,8 +308,7 @@ func (h *HostedBackend) List() ([]RemoteSlot, error) {
 // Delete removes a slot from the backend
 func (h *HostedBackend) Delete(slot string) error {
 	// Create HTTP request
-	url := fmt.Sprintf("%s/api/v1/slots/%s", h.baseURL, slot)
-	req, err := http.NewRequest(http.MethodDelete, url, nil)
+	req, err := http.NewRequest(http.MethodDelete, h.slotURL(slot), nil)
 	if err != nil {
 		return err
 	}
@@ -622,3 +622,111 @@ func TestHostedBackendWithEncryption(t *testing.T) {
 		t.Errorf("decrypted data mismatch: expected %s, got %s", string(originalData), string(pulledData))
 	}
 }
+
+// TestHostedBackendPrefix tests that a configured prefix namespaces slots
+func TestHostedBackendPrefix(t *testing.T) {
+	email := "test-hosted-prefix@example.com"
+	token := "test-jwt-token"
+	if err := storeToken(email, token); err != nil {
+		t.Fatalf("failed to store token: %v", err)
+	}
+	defer func() { _ = clearToken(email) }()
+
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.Method+" "+r.URL.Path)
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v1/slots":
+			_ = json.NewEncoder(w).Encode([]slotMetadataResponse{
+				{Name: "work-notes", SizeBytes: 10, UpdatedAt: "2025-12-06T00:00:00Z"},
+				{Name: "home-notes", SizeBytes: 20, UpdatedAt: "2025-12-06T00:00:00Z"},
+				{Name: "work-todo", SizeBytes: 30, UpdatedAt: "2025-12-05T00:00:00Z"},
+			})
+		case r.Method == http.MethodGet:
+			_ = json.NewEncoder(w).Encode(slotDataResponse{
+				Name:          "work-notes",
+				EncryptedData: base64.StdEncoding.EncodeToString([]byte("hello")),
+				ContentType:   "text/plain",
+			})
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer server.Close()
+
+	cfg := &HostedConfig{URL: server.URL, Email: email, Prefix: "work-"}
+	backend, err := newHostedBackend(cfg, "none", "", 0)
+	if err != nil {
+		t.Fatalf("newHostedBackend failed: %v", err)
+	}
+
+	if err := backend.Push("notes", []byte("hello"), map[string]string{}); err != nil {
+		t.Fatalf("Push failed: %v", err)
+	}
+	if _, _, err := backend.Pull("notes"); err != nil {
+		t.Fatalf("Pull failed: %v", err)
+	}
+	if err := backend.Delete("notes"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	slots, err := backend.List()
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+
+	want := []string{
+		"PUT /api/v1/slots/work-notes",
+		"GET /api/v1/slots/work-notes",
+		"DELETE /api/v1/slots/work-notes",
+		"GET /api/v1/slots",
+	}
+	if len(paths) != len(want) {
+		t.Fatalf("requests = %v, want %v", paths, want)
+	}
+	for i := range want {
+		if paths[i] != want[i] {
+			t.Errorf("request %d = %q, want %q", i, paths[i], want[i])
+		}
+	}
+
+	// Listed names are stripped and other namespaces are hidden
+	if len(slots) != 2 || slots[0].Name != "notes" || slots[1].Name != "todo" {
+		t.Errorf("List = %+v, want notes and todo", slots)
+	}
+}
+
+// Test show --meta --json surfaces the hosted slot's content type and
+// update time
+func TestCmdShowMetaHosted(t *testing.T) {
+	email := "test-hosted-meta@example.com"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(slotDataResponse{
+			Name:          "notes",
+			EncryptedData: "dGVzdCBkYXRh", // base64("test data")
+			ContentType:   "text/plain; charset=utf-8",
+			SizeBytes:     9,
+			UpdatedAt:     "2026-01-02T03:04:05Z",
+		})
+	}))
+	defer server.Close()
+
+	defer setupSlotsTestConfig(t, "version: 1\nsync:\n  backend: hosted\n  hosted:\n    url: "+server.URL+"\n    email: "+email+"\n")()
+	if err := storeToken(email, "test-jwt-token"); err != nil {
+		t.Fatalf("failed to store token: %v", err)
+	}
+	defer func() { _ = clearToken(email) }()
+
+	out := captureOutput(func() {
+		if err := cmdShow([]string{"notes", "--meta", "--json"}); err != nil {
+			t.Errorf("show --meta --json: %v", err)
+		}
+	})
+	var got slotMeta
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("bad JSON %q: %v", out, err)
+	}
+	want := slotMeta{Slot: "notes", Size: 9, Stored: 9, UpdatedAt: "2026-01-02T03:04:05Z", MIME: "text/plain; charset=utf-8"}
+	if got != want {
+		t.Errorf("meta = %+v, want %+v", got, want)
+	}
+}
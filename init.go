@@ -9,6 +9,16 @@ import (
 )
 
 func cmdInit(args []string) error {
+	example := false
+	for _, arg := range args {
+		switch arg {
+		case "--example":
+			example = true
+		default:
+			return fmt.Errorf("unknown argument: %s\nusage: pipeboard init [--example]", arg)
+		}
+	}
+
 	// Check if config already exists
 	cfgPath := configPath()
 	if _, err := os.Stat(cfgPath); err == nil {
@@ -19,6 +29,18 @@ func cmdInit(args []string) error {
 		}
 	}
 
+	if example {
+		if err := os.MkdirAll(filepath.Dir(cfgPath), 0755); err != nil {
+			return fmt.Errorf("creating config directory: %w", err)
+		}
+		if err := os.WriteFile(cfgPath, []byte(exampleConfigYAML()), 0600); err != nil {
+			return fmt.Errorf("writing config: %w", err)
+		}
+		fmt.Printf("✓ Example configuration saved to %s\n", cfgPath)
+		fmt.Println("Every option is commented out; remove the leading # from the ones you need.")
+		return nil
+	}
+
 	fmt.Println("pipeboard init - Configuration Wizard")
 	fmt.Println("======================================")
 	fmt.Println()
@@ -316,8 +338,158 @@ func generateConfigYAML(cfg *Config) string {
 			if fx.Description != "" {
 				sb.WriteString(fmt.Sprintf("    description: %q\n", fx.Description))
 			}
+			if fx.Cache {
+				sb.WriteString("    cache: true\n")
+			}
 		}
 	}
 
 	return sb.String()
 }
+
+// exampleConfig builds a commented reference config. Settings are written
+// as "#" directly followed by the YAML line, so deleting that one character
+// enables them; explanations use "## " and stay comments.
+type exampleConfig struct {
+	sb strings.Builder
+}
+
+// section starts a top-level block with a heading comment
+func (e *exampleConfig) section(heading string) {
+	e.sb.WriteString("\n## " + heading + "\n")
+}
+
+// note writes explanatory comment lines
+func (e *exampleConfig) note(lines ...string) {
+	for _, l := range lines {
+		e.sb.WriteString(strings.TrimRight("## "+l, " ") + "\n")
+	}
+}
+
+// opt writes a commented-out setting, with an optional trailing comment
+func (e *exampleConfig) opt(line, comment string) {
+	if comment != "" {
+		line = fmt.Sprintf("%-32s # %s", line, comment)
+	}
+	e.sb.WriteString("#" + line + "\n")
+}
+
+// exampleConfigYAML returns a reference config documenting every option,
+// all commented out, for 'init --example' and 'config example'
+func exampleConfigYAML() string {
+	e := &exampleConfig{}
+	e.note(
+		"pipeboard configuration",
+		"Generated by 'pipeboard init --example'",
+		"",
+		"Every option below is commented out. Remove the single leading # from",
+		"a setting (and its section line) to enable it; lines starting with ##",
+		"are explanations. Durations use Go syntax (500ms, 2s, 1m).",
+	)
+	e.sb.WriteString("\nversion: 1\n")
+
+	e.section("Defaults")
+	e.opt("defaults:", "")
+	e.opt("  peer: dev", "default peer for send/recv/peek/watch")
+	e.opt("  slot: scratch", "default slot for sync push/pull")
+	e.opt(fmt.Sprintf("  peer_warn_size: %d", defaultPeerWarnSize), "confirm recv/peek above N bytes (-1 = never)")
+	e.opt("  hostname: my-laptop", "origin label for pushed slots (default: system hostname)")
+	e.opt("  pager: less -R", "pager for long show/paste output (default: $PAGER)")
+	e.opt(fmt.Sprintf("  peer_cache_ttl: %s", defaultPeerCacheTTL), "reuse a fetched peer clipboard (0 = off)")
+	e.opt(fmt.Sprintf("  clipboard_timeout: %s", defaultClipboardTimeout), "kill a stuck clipboard tool after this long (0 = never)")
+	e.opt("  json_compact: true", "single-line --json output (default: indented)")
+	e.opt("  clipboard_order: [wayland, x11, wsl, termux]", "Linux/WSL/Termux clipboard detection order")
+
+	e.section("Sync: remote slots for push/pull/show/slots/rm")
+	e.note("backend is \"local\" (a directory), \"s3\" (an AWS bucket), \"gcs\" (a Google Cloud Storage bucket) or \"hosted\".")
+	e.opt("sync:", "")
+	e.opt("  backend: local", "local, s3, gcs or hosted")
+	e.opt("  local:", "")
+	e.opt("    path: /path/to/slots", "default: ~/.config/pipeboard/slots")
+	e.opt("    extension: .json", "slot file extension (default: .pb)")
+	e.opt("  s3:", "")
+	e.opt("    bucket: my-pipeboard", "required for s3")
+	e.opt("    region: us-west-2", "required for s3")
+	e.opt("    prefix: clips/", "key prefix")
+	e.opt("    sse: AES256", "server-side encryption: AES256 or aws:kms")
+	e.opt("    profile: default", "AWS profile")
+	e.opt("    endpoint: https://<account>.r2.cloudflarestorage.com", "S3-compatible service (MinIO, R2, Wasabi); region defaults to auto")
+	e.opt("    path_style: true", "bucket in the URL path (MinIO)")
+	e.opt("  gcs:", "")
+	e.opt("    bucket: my-pipeboard", "required for gcs")
+	e.opt("    prefix: clips/", "object name prefix")
+	e.opt("    credentials_file: ~/key.json", "default: $GOOGLE_APPLICATION_CREDENTIALS")
+	e.opt("  hosted:", "")
+	e.opt("    url: https://pipeboard.example.com", "required for hosted")
+	e.opt("    email: me@example.com", "required for hosted")
+	e.opt("    prefix: work-", "namespace slot names on a shared account")
+	e.note("Encryption: slots are encrypted client-side before upload.")
+	e.opt("  encryption: aes256", "none, aes256, or age")
+	e.opt("  passphrase: <passphrase>", "read as written; or set PIPEBOARD_PASSPHRASE or passphrase_cmd")
+	e.opt("  passphrase_source: config", "config, or keyring (see 'pipeboard keyring set')")
+	e.opt("  passphrase_cmd: pass show pipeboard", "or print it with a command (instead of passphrase)")
+	e.opt("  passphrase_file: ~/.pb-pass", "or read it from a file (instead of passphrase)")
+	e.opt("  recipients: [age1...]", "age: public keys to encrypt to")
+	e.opt("  identity_file: /home/me/.config/age/keys.txt", "age: key file for pulling (from age-keygen)")
+	e.note("Retention and storage.")
+	e.opt("  ttl_days: 30", "auto-expire slots after N days (0 = never)")
+	e.opt("  versions: 5", "keep the last N versions of each slot (0 = off)")
+	e.opt("  dedup: true", "store identical content once")
+	e.opt("  encoding: base64", "base64, or base85 for smaller stored payloads")
+	e.opt("  compression: gzip", "gzip, zstd (smaller), or none for slots over 1KB")
+
+	e.section("Peers: SSH hosts for send/recv/peek/watch")
+	e.opt("peers:", "")
+	e.opt("  dev:", "")
+	e.opt("    ssh: devbox", "host from ~/.ssh/config or user@host")
+	e.opt("    remote_cmd: pipeboard", "pipeboard binary on the peer (default shown)")
+	e.opt("    compress: false", "gzip transfers (the peer needs a framing pipeboard)")
+	e.opt("    encrypt: false", "encrypt transfers with the shared sync passphrase")
+
+	e.section("Transforms for 'pipeboard fx'")
+	e.note("Use cmd (argv, no shell) or shell (run with sh -c).")
+	e.opt("fx:", "")
+	e.opt("  pretty-json:", "")
+	e.opt(`    cmd: ["jq", "."]`, "")
+	e.opt(`    description: "Format JSON"`, "shown in fx --list")
+	e.opt("    cache: true", "reuse output for identical input")
+	e.opt("    on_error: abort", "or passthrough: keep the input if the transform fails")
+	e.opt("    timeout: 10s", "kill the command if it runs longer")
+	e.opt("    env:", "extra environment for the command")
+	e.opt("      API_KEY: ${MY_API_KEY}", "${VAR} expands from your environment")
+	e.opt("  strip-ansi:", "")
+	e.opt(`    shell: "sed 's/\\x1b\\[[0-9;]*m//g'"`, "")
+	e.opt(`    description: "Remove ANSI codes"`, "")
+
+	e.section("Slot aliases: short names for slot commands")
+	e.opt("aliases:", "")
+	e.opt("  k: kube-config", "")
+
+	e.section("Clipboard history")
+	e.opt("history:", "")
+	e.opt(fmt.Sprintf("  limit: %d", defaultClipboardHistoryLimit), "max entries")
+	e.opt("  ttl_days: 30", "delete entries older than N days (0 = never)")
+	e.opt("  no_duplicates: true", "skip content already in history")
+	e.opt("  max_entry_bytes: 1048576", "don't record larger content (default: 32MiB)")
+	e.opt("  batch_writes: true", "write rapid history records together")
+
+	e.section("Secret scanning on copy/push")
+	e.opt("policy:", "")
+	e.opt("  scan_secrets: true", "")
+	e.opt("  on_secret: warn", "warn or block")
+
+	e.section("Audit log of push/pull/rm/send (never trimmed)")
+	e.opt("audit:", "")
+	e.opt("  enabled: true", "log to ~/.config/pipeboard/audit.log")
+	e.opt("  file: /var/log/pipeboard/audit.log", "or log here instead")
+	e.opt("  hmac_key: secret", "chain records so edits are detectable")
+
+	e.section("Clipboard watch")
+	e.opt("watch:", "")
+	e.opt("  debounce: 1s", "sync a change once it is stable this long")
+	e.opt("  max_rate: 30", "max syncs per minute (0 = unlimited)")
+	e.opt(fmt.Sprintf("  min_interval: %s", defaultWatchInterval), "poll interval after a change")
+	e.opt(fmt.Sprintf("  max_interval: %s", defaultMaxWatchInterval), "poll interval ceiling while idle")
+
+	return e.sb.String()
+}
@@ -2,8 +2,11 @@ package main
 
 import (
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+
+	"gopkg.in/yaml.v3"
 )
 
 // Test generateConfigYAML with minimal config
@@ -506,3 +509,91 @@ func TestCmdInitNoneBackend(t *testing.T) {
 // Note: Multi-prompt cmdInit tests are skipped because the init.go prompt functions
 // each create their own bufio.Reader, which causes buffering issues with piped input.
 // The individual prompt functions are tested above with single-input tests.
+
+// Test the example config documents every section, all commented out
+func TestExampleConfigYAMLCommented(t *testing.T) {
+	example := exampleConfigYAML()
+
+	for _, section := range []string{"defaults", "sync", "peers", "fx", "aliases", "history", "policy", "watch"} {
+		if !strings.Contains(example, "\n#"+section+":\n") {
+			t.Errorf("expected commented %s section", section)
+		}
+	}
+	for _, opt := range []string{"encryption: aes256", "passphrase_source:", "bucket:", "remote_cmd:", "no_duplicates:", "max_interval:"} {
+		if !strings.Contains(example, opt) {
+			t.Errorf("expected example to document %q", opt)
+		}
+	}
+
+	// As written, only the version is set
+	var cfg Config
+	if err := yaml.Unmarshal([]byte(example), &cfg); err != nil {
+		t.Fatalf("example should parse: %v", err)
+	}
+	if cfg.Version != 1 || cfg.Sync != nil || cfg.Peers != nil {
+		t.Errorf("expected only version set, got %+v", cfg)
+	}
+}
+
+// Test the example config parses once every setting is uncommented
+func TestExampleConfigYAMLUncommented(t *testing.T) {
+	var lines []string
+	for _, line := range strings.Split(exampleConfigYAML(), "\n") {
+		if strings.HasPrefix(line, "#") && !strings.HasPrefix(line, "##") {
+			line = line[1:]
+		}
+		lines = append(lines, line)
+	}
+
+	var cfg Config
+	if err := yaml.Unmarshal([]byte(strings.Join(lines, "\n")), &cfg); err != nil {
+		t.Fatalf("uncommented example should parse: %v", err)
+	}
+	if cfg.Sync == nil || cfg.Sync.Backend != "local" || cfg.Sync.S3 == nil || cfg.Sync.Encryption != "aes256" {
+		t.Errorf("unexpected sync section: %+v", cfg.Sync)
+	}
+	if cfg.Peers["dev"].SSH != "devbox" {
+		t.Errorf("unexpected peers: %+v", cfg.Peers)
+	}
+	if len(cfg.Fx["pretty-json"].Cmd) != 2 || cfg.Fx["strip-ansi"].Shell == "" {
+		t.Errorf("unexpected fx: %+v", cfg.Fx)
+	}
+	if cfg.Aliases["k"] != "kube-config" {
+		t.Errorf("unexpected aliases: %+v", cfg.Aliases)
+	}
+	if cfg.History == nil || cfg.History.Limit != defaultClipboardHistoryLimit {
+		t.Errorf("unexpected history: %+v", cfg.History)
+	}
+	if cfg.Defaults == nil || cfg.Defaults.Peer != "dev" || cfg.Policy == nil || cfg.Watch == nil {
+		t.Errorf("expected defaults, policy and watch sections")
+	}
+	if _, err := newWatchBackoff(cfg.Watch); err != nil {
+		t.Errorf("example watch settings should be valid: %v", err)
+	}
+}
+
+// Test init --example writes the reference config without prompting
+func TestCmdInitExample(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pipeboard", "config.yaml")
+	t.Setenv("PIPEBOARD_CONFIG", path)
+
+	output := captureOutput(func() {
+		if err := cmdInit([]string{"--example"}); err != nil {
+			t.Fatalf("cmdInit --example: %v", err)
+		}
+	})
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading config: %v", err)
+	}
+	if string(data) != exampleConfigYAML() {
+		t.Error("expected the example config to be written")
+	}
+	if !strings.Contains(output, path) {
+		t.Errorf("expected output to name the file, got %q", output)
+	}
+
+	if err := cmdInit([]string{"--bogus"}); err == nil {
+		t.Error("expected error for unknown argument")
+	}
+}
@@ -1,17 +1,25 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strings"
+	"syscall"
+
+	"golang.org/x/term"
 )
 
 // Token storage: secure token storage across platforms
@@ -268,3 +276,171 @@ func clearTokenFile(email string) error {
 	delete(store.Tokens, email)
 	return saveTokenStore(store)
 }
+
+// Encryption passphrase storage (sync.passphrase_source: keyring)
+// uses the same stores as tokens, under a fixed account name
+
+const passphraseAccount = "sync-passphrase"
+
+// setKeyringPassphrase stores the sync encryption passphrase
+var setKeyringPassphrase = func(passphrase string) error {
+	if runtime.GOOS == "darwin" {
+		// -U updates an existing entry
+		return runCommand("security", "add-generic-password", "-U",
+			"-s", serviceName, "-a", passphraseAccount, "-w", passphrase)
+	}
+	return storeTokenFile(passphraseAccount, passphrase)
+}
+
+// getKeyringPassphrase retrieves the stored sync encryption passphrase
+var getKeyringPassphrase = func() (string, error) {
+	if runtime.GOOS == "darwin" {
+		output, err := runCommandOutput("security", "find-generic-password",
+			"-s", serviceName, "-a", passphraseAccount, "-w")
+		if err != nil {
+			return "", fmt.Errorf("passphrase not found in keychain")
+		}
+		return string(output), nil
+	}
+	return getTokenFile(passphraseAccount)
+}
+
+// validatePassphraseSources rejects configs that say where to find the
+// passphrase more than once, rather than silently preferring one. The
+// keyring already overrides sync.passphrase, so that pair is allowed.
+func validatePassphraseSources(cfg *SyncConfig) error {
+	var sources []string
+	if cfg.Passphrase != "" && cfg.PassphraseSource != "keyring" {
+		sources = append(sources, "sync.passphrase")
+	}
+	if cfg.PassphraseCmd != "" {
+		sources = append(sources, "sync.passphrase_cmd")
+	}
+	if cfg.PassphraseFile != "" {
+		sources = append(sources, "sync.passphrase_file")
+	}
+	if cfg.PassphraseSource == "keyring" {
+		sources = append(sources, "sync.passphrase_source: keyring")
+	}
+	if len(sources) > 1 {
+		return fmt.Errorf("%s are all ways to set the passphrase; keep only one", strings.Join(sources, " and "))
+	}
+	return nil
+}
+
+// loadPassphraseSource fills in sync.passphrase from sync.passphrase_cmd
+// or sync.passphrase_file. It runs only when a backend or history needs
+// the passphrase, so other commands never invoke a password manager.
+func loadPassphraseSource(cfg *SyncConfig) error {
+	switch {
+	case cfg.PassphraseCmd != "":
+		debugLog("running passphrase_cmd: sh -c %q", cfg.PassphraseCmd)
+		var stdout bytes.Buffer
+		cmd := exec.Command("sh", "-c", cfg.PassphraseCmd)
+		cmd.Stdout = &stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("sync.passphrase_cmd failed: %w", err)
+		}
+		cfg.Passphrase = strings.TrimSpace(stdout.String())
+		if cfg.Passphrase == "" {
+			return errors.New("sync.passphrase_cmd printed an empty passphrase")
+		}
+	case cfg.PassphraseFile != "":
+		path := cfg.PassphraseFile
+		if rest, ok := strings.CutPrefix(path, "~/"); ok {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return fmt.Errorf("could not determine home directory: %w", err)
+			}
+			path = filepath.Join(home, rest)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("reading sync.passphrase_file: %w", err)
+		}
+		cfg.Passphrase = strings.TrimSpace(string(data))
+		if cfg.Passphrase == "" {
+			return fmt.Errorf("sync.passphrase_file %s is empty", path)
+		}
+	}
+	return nil
+}
+
+// hasPassphrase reports whether a passphrase is configured, including
+// sources not yet loaded
+func (cfg *SyncConfig) hasPassphrase() bool {
+	return cfg.Passphrase != "" || cfg.PassphraseCmd != "" || cfg.PassphraseFile != ""
+}
+
+// resolvePassphrase fills in sync.passphrase from the keyring when
+// sync.passphrase_source is "keyring"
+func resolvePassphrase(cfg *SyncConfig) error {
+	switch cfg.PassphraseSource {
+	case "", "config":
+		return nil
+	case "keyring":
+		passphrase, err := getKeyringPassphrase()
+		if err != nil || passphrase == "" {
+			return fmt.Errorf("encryption passphrase not found in keyring; run 'pipeboard keyring set'")
+		}
+		cfg.Passphrase = passphrase
+		return nil
+	default:
+		return fmt.Errorf("unsupported passphrase_source: %s (use \"config\" or \"keyring\")", cfg.PassphraseSource)
+	}
+}
+
+// cmdKeyring manages secrets in the OS keyring
+func cmdKeyring(args []string) error {
+	if len(args) != 1 || args[0] != "set" {
+		return fmt.Errorf("usage: pipeboard keyring set")
+	}
+
+	passphrase, err := readNewPassphrase()
+	if err != nil {
+		return err
+	}
+	if err := setKeyringPassphrase(passphrase); err != nil {
+		return fmt.Errorf("storing passphrase: %w", err)
+	}
+	printInfo("encryption passphrase stored in keyring\n")
+	printInfo("set sync.passphrase_source: keyring in config to use it\n")
+	return nil
+}
+
+// readNewPassphrase prompts for a passphrase twice on a terminal, or
+// reads it from stdin (first line) when piped
+func readNewPassphrase() (string, error) {
+	if !stdinIsTerminal() {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && err != io.EOF {
+			return "", err
+		}
+		passphrase := strings.TrimRight(line, "\r\n")
+		if passphrase == "" {
+			return "", fmt.Errorf("empty passphrase")
+		}
+		return passphrase, nil
+	}
+
+	fmt.Fprint(os.Stderr, "Passphrase: ")
+	first, err := term.ReadPassword(int(syscall.Stdin))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprint(os.Stderr, "Confirm passphrase: ")
+	confirm, err := term.ReadPassword(int(syscall.Stdin))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	if string(first) != string(confirm) {
+		return "", fmt.Errorf("passphrases do not match")
+	}
+	if len(first) == 0 {
+		return "", fmt.Errorf("empty passphrase")
+	}
+	return string(first), nil
+}
@@ -1,7 +1,10 @@
 package main
 
 import (
+	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -321,3 +324,189 @@ func TestTokenStoreLoadSave(t *testing.T) {
 		}
 	})
 }
+
+// useMockKeyring replaces the passphrase keyring with an in-memory store
+func useMockKeyring(t *testing.T) map[string]string {
+	t.Helper()
+	store := make(map[string]string)
+	origSet, origGet := setKeyringPassphrase, getKeyringPassphrase
+	setKeyringPassphrase = func(p string) error {
+		store[passphraseAccount] = p
+		return nil
+	}
+	getKeyringPassphrase = func() (string, error) {
+		p, ok := store[passphraseAccount]
+		if !ok {
+			return "", fmt.Errorf("not found")
+		}
+		return p, nil
+	}
+	t.Cleanup(func() { setKeyringPassphrase, getKeyringPassphrase = origSet, origGet })
+	return store
+}
+
+// Test keyring set stores a piped passphrase
+func TestCmdKeyringSet(t *testing.T) {
+	store := useMockKeyring(t)
+
+	origTTY := stdinIsTerminal
+	defer func() { stdinIsTerminal = origTTY }()
+	stdinIsTerminal = func() bool { return false }
+
+	oldStdin := os.Stdin
+	defer func() { os.Stdin = oldStdin }()
+	r, w, _ := os.Pipe()
+	os.Stdin = r
+	go func() {
+		_, _ = w.Write([]byte("correct horse battery\n"))
+		_ = w.Close()
+	}()
+
+	var err error
+	captureOutput(func() {
+		err = cmdKeyring([]string{"set"})
+	})
+	if err != nil {
+		t.Fatalf("keyring set: %v", err)
+	}
+	if got := store[passphraseAccount]; got != "correct horse battery" {
+		t.Errorf("stored passphrase = %q", got)
+	}
+
+	if err := cmdKeyring([]string{"get"}); err == nil {
+		t.Error("expected usage error for unknown subcommand")
+	}
+}
+
+// Test passphrase_source: keyring feeds the keyring passphrase to the backend
+func TestPassphraseSourceKeyring(t *testing.T) {
+	store := useMockKeyring(t)
+
+	tmpDir := t.TempDir()
+	configPath := tmpDir + "/config.yaml"
+	config := "version: 1\nsync:\n  backend: local\n  encryption: aes256\n  passphrase_source: keyring\n  local:\n    path: " + tmpDir + "/slots\n"
+	if err := os.WriteFile(configPath, []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PIPEBOARD_CONFIG", configPath)
+
+	// Nothing stored yet
+	if _, err := newRemoteBackendFromConfig(); err == nil || !strings.Contains(err.Error(), "keyring set") {
+		t.Fatalf("expected missing keyring passphrase error, got %v", err)
+	}
+
+	store[passphraseAccount] = "from-keyring"
+	backend, err := newRemoteBackendFromConfig()
+	if err != nil {
+		t.Fatalf("newRemoteBackendFromConfig: %v", err)
+	}
+	local, ok := backend.(*LocalBackend)
+	if !ok {
+		t.Fatalf("expected local backend, got %T", backend)
+	}
+	if local.passphrase != "from-keyring" {
+		t.Errorf("backend passphrase = %q, want keyring value", local.passphrase)
+	}
+
+	// Data pushed with the keyring passphrase decrypts with it
+	if err := backend.Push("secret", []byte("payload"), nil); err != nil {
+		t.Fatalf("Push: %v", err)
+	}
+	other, err := newLocalBackend(&LocalConfig{Path: tmpDir + "/slots"}, "aes256", "from-keyring", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _, err := other.Pull("secret"); err != nil || string(data) != "payload" {
+		t.Errorf("Pull with keyring passphrase: %q, %v", data, err)
+	}
+}
+
+func TestResolvePassphraseUnknownSource(t *testing.T) {
+	err := resolvePassphrase(&SyncConfig{PassphraseSource: "vault"})
+	if err == nil || !strings.Contains(err.Error(), "passphrase_source") {
+		t.Errorf("expected unsupported source error, got %v", err)
+	}
+	cfg := &SyncConfig{Passphrase: "inline"}
+	if err := resolvePassphrase(cfg); err != nil || cfg.Passphrase != "inline" {
+		t.Errorf("config source should keep the inline passphrase, got %q, %v", cfg.Passphrase, err)
+	}
+}
+
+// Test passphrase_cmd and passphrase_file feed the trimmed passphrase to
+// the backend and to clipboard history, and win over PIPEBOARD_PASSPHRASE
+func TestPassphraseCmdAndFile(t *testing.T) {
+	dir := t.TempDir()
+	passFile := filepath.Join(dir, "pass")
+	if err := os.WriteFile(passFile, []byte("  from-file\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PIPEBOARD_PASSPHRASE", "from-env")
+
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{"cmd", "  passphrase_cmd: \"printf 'from-cmd\\\\n'\"\n", "from-cmd"},
+		{"file", "  passphrase_file: " + passFile + "\n", "from-file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer setupSlotsTestConfig(t, slotsConfigAt(filepath.Join(dir, "slots"), "  encryption: aes256\n"+tt.source))()
+			backend, err := newRemoteBackendFromConfig()
+			if err != nil {
+				t.Fatalf("newRemoteBackendFromConfig: %v", err)
+			}
+			if got := backend.(*LocalBackend).passphrase; got != tt.want {
+				t.Errorf("backend passphrase = %q, want %q", got, tt.want)
+			}
+			if enabled, got := getHistoryEncryptionConfig(); !enabled || got != tt.want {
+				t.Errorf("history passphrase = %v %q, want %q", enabled, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPassphraseSourceErrors(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		source  string
+		wantErr string
+	}{
+		{"passphrase and cmd", "  passphrase: inline\n  passphrase_cmd: echo x\n", "sync.passphrase and sync.passphrase_cmd"},
+		{"cmd and file", "  passphrase_cmd: echo x\n  passphrase_file: /p\n", "keep only one"},
+		{"keyring and file", "  passphrase_source: keyring\n  passphrase_file: /p\n", "keep only one"},
+		{"failing cmd", "  passphrase_cmd: exit 3\n", "passphrase_cmd failed"},
+		{"empty cmd output", "  passphrase_cmd: \"true\"\n", "empty passphrase"},
+		{"missing file", "  passphrase_file: " + filepath.Join(dir, "nope") + "\n", "reading sync.passphrase_file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer setupSlotsTestConfig(t, slotsConfigAt(filepath.Join(dir, "slots"), "  encryption: aes256\n"+tt.source))()
+			if _, err := newRemoteBackendFromConfig(); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	// A failing command leaves history unrecorded rather than plaintext
+	defer setupSlotsTestConfig(t, slotsConfigAt(filepath.Join(dir, "slots"), "  encryption: aes256\n  passphrase_cmd: exit 1\n"))()
+	recordClipboardHistory([]byte("sensitive"))
+	if _, err := os.Stat(getClipboardHistoryPath()); !os.IsNotExist(err) {
+		t.Errorf("history written without the passphrase: %v", err)
+	}
+}
+
+// Test a ~/ passphrase_file is read from the home directory
+func TestPassphraseFileHome(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	if err := os.WriteFile(filepath.Join(home, ".pb-pass"), []byte("home-secret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &SyncConfig{PassphraseFile: "~/.pb-pass"}
+	if err := loadPassphraseSource(cfg); err != nil || cfg.Passphrase != "home-secret" {
+		t.Errorf("passphrase = %q, %v", cfg.Passphrase, err)
+	}
+}
@@ -1,27 +1,67 @@
 package main
 
 import (
-	"encoding/base64"
+	"crypto/rand"
 	"encoding/json"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
 	"time"
 )
 
 // LocalConfig holds configuration for local file backend
 type LocalConfig struct {
-	Path string `yaml:"path,omitempty"` // directory for slot storage
+	Path      string `yaml:"path,omitempty"`      // directory for slot storage
+	Extension string `yaml:"extension,omitempty"` // slot file extension (default: .pb)
 }
 
+// defaultSlotExt is the slot file extension, and the one legacy slots
+// keep after local.extension is changed
+const defaultSlotExt = ".pb"
+
 // LocalBackend implements RemoteBackend using local filesystem
 type LocalBackend struct {
 	path       string
+	ext        string // slot file extension, with the dot
 	encryption string
 	passphrase string
+	age        *ageKeys // recipients and identities for encryption: age
 	ttlDays    int
+	versions   int    // versions to keep per slot (0 = off)
+	dedup      bool   // store payloads once under blobs/ (content-addressed)
+	encoding   string // "base85" stores data as Ascii85 (default base64)
+	compress   string // sync.compression: "gzip" (default), "zstd" or "none"
+}
+
+func init() {
+	registerBackend("local", backendDriver{
+		validate: func(cfg *SyncConfig) error {
+			// Local backend requires no mandatory config (uses defaults)
+			if cfg.Local == nil {
+				cfg.Local = &LocalConfig{}
+			}
+			_, err := normalizeSlotExt(cfg.Local.Extension)
+			return err
+		},
+		open: func(cfg *SyncConfig) (RemoteBackend, error) {
+			b, err := newLocalBackend(cfg.Local, cfg.Encryption, cfg.Passphrase, cfg.TTLDays)
+			if err != nil {
+				return nil, err
+			}
+			b.versions = cfg.Versions
+			b.dedup = cfg.Dedup
+			b.encoding = cfg.Encoding
+			b.compress = cfg.Compression
+			if b.age, err = loadAgeKeys(cfg); err != nil {
+				return nil, err
+			}
+			return b, nil
+		},
+	})
 }
 
 func newLocalBackend(cfg *LocalConfig, encryption, passphrase string, ttlDays int) (*LocalBackend, error) {
@@ -30,6 +70,11 @@ func newLocalBackend(cfg *LocalConfig, encryption, passphrase string, ttlDays in
 		return nil, fmt.Errorf("passphrase required when encryption is set to aes256")
 	}
 
+	ext, err := normalizeSlotExt(cfg.Extension)
+	if err != nil {
+		return nil, err
+	}
+
 	path := cfg.Path
 	if path == "" {
 		// Default to ~/.config/pipeboard/slots
@@ -51,60 +96,89 @@ func newLocalBackend(cfg *LocalConfig, encryption, passphrase string, ttlDays in
 
 	return &LocalBackend{
 		path:       path,
+		ext:        ext,
 		encryption: encryption,
 		passphrase: passphrase,
 		ttlDays:    ttlDays,
 	}, nil
 }
 
+// normalizeSlotExt returns local.extension with a leading dot, or the
+// default when unset
+func normalizeSlotExt(ext string) (string, error) {
+	if ext == "" {
+		return defaultSlotExt, nil
+	}
+	if !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	if ext == "." || strings.ContainsAny(ext, `/\`) {
+		return "", fmt.Errorf("invalid local.extension %q", ext)
+	}
+	return ext, nil
+}
+
+// slotExt returns the slot file extension
+func (b *LocalBackend) slotExt() string {
+	if b.ext == "" {
+		return defaultSlotExt
+	}
+	return b.ext
+}
+
 func (b *LocalBackend) slotPath(slot string) string {
-	return filepath.Join(b.path, slot+".pb")
+	return filepath.Join(b.path, slot+b.slotExt())
+}
+
+// existingSlotPath returns the slot's file for reading, falling back to
+// a legacy .pb file written before local.extension was changed
+func (b *LocalBackend) existingSlotPath(slot string) string {
+	path := b.slotPath(slot)
+	if b.slotExt() == defaultSlotExt {
+		return path
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		legacy := filepath.Join(b.path, slot+defaultSlotExt)
+		if _, err := os.Stat(legacy); err == nil {
+			return legacy
+		}
+	}
+	return path
 }
 
 func (b *LocalBackend) Push(slot string, data []byte, meta map[string]string) error {
 	hostname := meta["hostname"]
 	if hostname == "" {
-		hostname, _ = os.Hostname()
+		hostname = slotHostname(nil)
 	}
 
 	// Detect MIME type before any transformations
 	mimeType := detectMIME(data)
 
-	// Store original data for processing
-	storeData := data
-	compressed := false
-	encrypted := false
-
-	// Apply gzip compression for data > 1KB (saves storage)
-	if len(data) > 1024 {
-		compressedData, err := compressData(data)
-		if err == nil && len(compressedData) < len(data) {
-			// Only use compression if it actually reduces size
-			storeData = compressedData
-			compressed = true
-		}
-	}
+	// Compress data > 1KB (saves storage)
+	storeData, compressed, algo := compressSlotData(data, b.compress)
 
 	// Apply client-side encryption if configured (after compression)
-	if b.encryption == "aes256" && b.passphrase != "" {
-		encData, err := encrypt(storeData, b.passphrase)
-		if err != nil {
-			return fmt.Errorf("encrypting data: %w", err)
-		}
-		storeData = encData
-		encrypted = true
+	storeData, encrypted, encryption, err := encryptSlotData(storeData, b.encryption, b.passphrase, b.age)
+	if err != nil {
+		return err
 	}
 
 	payload := SlotPayload{
-		Version:    1,
-		CreatedAt:  time.Now().UTC().Format(time.RFC3339),
-		Hostname:   hostname,
-		OS:         runtime.GOOS,
-		Len:        len(data), // Original length before compression/encryption
-		MIME:       mimeType,
-		Encrypted:  encrypted,
-		Compressed: compressed,
-		DataB64:    base64.StdEncoding.EncodeToString(storeData),
+		Version:         slotPayloadVersion,
+		CreatedAt:       time.Now().UTC().Format(time.RFC3339),
+		Hostname:        hostname,
+		OS:              runtime.GOOS,
+		Len:             len(data), // Original length before compression/encryption
+		MIME:            mimeType,
+		Encrypted:       encrypted,
+		Encryption:      encryption,
+		Compressed:      compressed,
+		CompressionAlgo: algo,
+	}
+	payload.DataB64, payload.Encoding = encodePayloadData(storeData, b.encoding)
+	if payload.Flavor, err = sealSlotFlavor(meta, b.encryption, b.passphrase, b.age); err != nil {
+		return err
 	}
 
 	// Set expiry time if TTL configured
@@ -112,7 +186,31 @@ func (b *LocalBackend) Push(slot string, data []byte, meta map[string]string) er
 		payload.ExpiresAt = time.Now().UTC().AddDate(0, 0, b.ttlDays).Format(time.RFC3339)
 	}
 
-	jsonData, err := json.MarshalIndent(payload, "", "  ")
+	// Store the data once under blobs/ and point the slot at it
+	if b.dedup {
+		hash, err := blobHash(data, b.encryption, b.passphrase)
+		if err != nil {
+			return err
+		}
+		pointer, blob := splitBlobPayload(payload, hash)
+		if err := b.saveBlob(hash, blob); err != nil {
+			return err
+		}
+		payload = pointer
+	}
+	return b.writeSlot(slot, payload)
+}
+
+// ImportPayload implements PayloadImporter. The payload is stored inline,
+// since its dedup hash can't be recomputed without the passphrase.
+func (b *LocalBackend) ImportPayload(slot string, payload SlotPayload) error {
+	payload.Blob = ""
+	return b.writeSlot(slot, payload)
+}
+
+// writeSlot stores a slot's payload and keeps a version of it
+func (b *LocalBackend) writeSlot(slot string, payload SlotPayload) error {
+	jsonData, err := marshalSlotPayload(payload, "  ")
 	if err != nil {
 		return fmt.Errorf("encoding payload: %w", err)
 	}
@@ -120,12 +218,78 @@ func (b *LocalBackend) Push(slot string, data []byte, meta map[string]string) er
 	if err := os.WriteFile(b.slotPath(slot), jsonData, 0600); err != nil {
 		return fmt.Errorf("writing slot file: %w", err)
 	}
+	// The slot now lives under the configured extension
+	if b.slotExt() != defaultSlotExt {
+		_ = os.Remove(filepath.Join(b.path, slot+defaultSlotExt))
+	}
 
+	if b.versions > 0 {
+		if err := b.saveVersion(slot, jsonData); err != nil {
+			return fmt.Errorf("saving slot version: %w", err)
+		}
+	}
+
+	// The old payload or a trimmed version may have held the last pointer
+	// to a blob
+	b.pruneBlobs()
 	return nil
 }
 
+// pruneBlobs deletes the dedup blobs that no slot or stored version points
+// at any more. Blobs hold slot data, possibly secrets, so they go with
+// their last pointer rather than staying behind after rm or expiry.
+func (b *LocalBackend) pruneBlobs() {
+	blobs, err := os.ReadDir(filepath.Join(b.path, blobsDir))
+	if err != nil || len(blobs) == 0 {
+		return
+	}
+
+	referenced := map[string]bool{}
+	err = filepath.WalkDir(b.path, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if name := d.Name(); path != b.path && (name == blobsDir || name == locksDir) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, defaultSlotExt) && !strings.HasSuffix(path, b.slotExt()) {
+			return nil
+		}
+		jsonData, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var payload SlotPayload
+		if json.Unmarshal(jsonData, &payload) == nil && payload.Blob != "" {
+			referenced[payload.Blob] = true
+		}
+		return nil
+	})
+	if err != nil {
+		// Without the full picture, keeping every blob is the safe choice
+		debugLog("not pruning blobs: %v", err)
+		return
+	}
+
+	cutoff := time.Now().Add(-blobGracePeriod)
+	for _, entry := range blobs {
+		hash, ok := strings.CutSuffix(entry.Name(), ".pb")
+		if !ok || referenced[hash] {
+			continue
+		}
+		if info, err := entry.Info(); err != nil || info.ModTime().After(cutoff) {
+			continue
+		}
+		debugLog("removing unreferenced blob %s", hash)
+		_ = os.Remove(b.blobPath(hash))
+	}
+}
+
 func (b *LocalBackend) Pull(slot string) ([]byte, map[string]string, error) {
-	jsonData, err := os.ReadFile(b.slotPath(slot))
+	jsonData, err := os.ReadFile(b.existingSlotPath(slot))
 	if err != nil {
 		if os.IsNotExist(err) {
 			return nil, nil, fmt.Errorf("slot %q not found", slot)
@@ -137,6 +301,9 @@ func (b *LocalBackend) Pull(slot string) ([]byte, map[string]string, error) {
 	if err := json.Unmarshal(jsonData, &payload); err != nil {
 		return nil, nil, fmt.Errorf("decoding payload: %w", err)
 	}
+	if err := checkPayloadVersion(slot, payload); err != nil {
+		return nil, nil, err
+	}
 
 	// Check if slot has expired
 	if payload.ExpiresAt != "" {
@@ -148,30 +315,14 @@ func (b *LocalBackend) Pull(slot string) ([]byte, map[string]string, error) {
 		}
 	}
 
-	data, err := base64.StdEncoding.DecodeString(payload.DataB64)
+	payload, err = b.resolveBlob(payload)
 	if err != nil {
-		return nil, nil, fmt.Errorf("decoding base64 data: %w", err)
-	}
-
-	// Decrypt if the payload was encrypted (before decompression)
-	if payload.Encrypted {
-		if b.passphrase == "" {
-			return nil, nil, fmt.Errorf("slot is encrypted but no passphrase configured")
-		}
-		decData, err := decrypt(data, b.passphrase)
-		if err != nil {
-			return nil, nil, fmt.Errorf("decrypting data: %w", err)
-		}
-		data = decData
+		return nil, nil, err
 	}
 
-	// Decompress if the payload was compressed (after decryption)
-	if payload.Compressed {
-		decompressedData, err := decompressData(data)
-		if err != nil {
-			return nil, nil, fmt.Errorf("decompressing data: %w", err)
-		}
-		data = decompressedData
+	data, err := decodePayloadData(payload, b.passphrase, b.age)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	meta := map[string]string{
@@ -180,10 +331,77 @@ func (b *LocalBackend) Pull(slot string) ([]byte, map[string]string, error) {
 		"created_at": payload.CreatedAt,
 		"mime":       payload.MIME,
 	}
+	if err := addFlavorMeta(meta, payload.Flavor, b.passphrase, b.age); err != nil {
+		return nil, nil, err
+	}
 
 	return data, meta, nil
 }
 
+func (b *LocalBackend) blobPath(hash string) string {
+	return filepath.Join(b.path, blobsDir, hash+".pb")
+}
+
+// saveBlob writes a blob unless one with the same hash already exists.
+// A reused blob is touched so pruneBlobs leaves it alone until the new
+// pointer to it is written.
+func (b *LocalBackend) saveBlob(hash string, blob SlotPayload) error {
+	blobPath := b.blobPath(hash)
+	if _, err := os.Stat(blobPath); err == nil {
+		debugLog("blob %s already stored, skipping write", hash)
+		now := time.Now()
+		_ = os.Chtimes(blobPath, now, now)
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(blobPath), 0700); err != nil {
+		return fmt.Errorf("creating blobs directory: %w", err)
+	}
+	jsonData, err := marshalSlotPayload(blob, "  ")
+	if err != nil {
+		return fmt.Errorf("encoding blob: %w", err)
+	}
+	if err := os.WriteFile(blobPath, jsonData, 0600); err != nil {
+		return fmt.Errorf("writing blob file: %w", err)
+	}
+	return nil
+}
+
+// Inspect implements InspectableBackend
+func (b *LocalBackend) Inspect(slot string) (SlotPayload, int64, error) {
+	jsonData, err := os.ReadFile(b.existingSlotPath(slot))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return SlotPayload{}, 0, fmt.Errorf("slot %q not found", slot)
+		}
+		return SlotPayload{}, 0, fmt.Errorf("reading slot file: %w", err)
+	}
+	var payload SlotPayload
+	if err := json.Unmarshal(jsonData, &payload); err != nil {
+		return SlotPayload{}, 0, fmt.Errorf("decoding payload: %w", err)
+	}
+	payload, err = b.resolveBlob(payload)
+	if err != nil {
+		return SlotPayload{}, 0, err
+	}
+	return payload, int64(len(jsonData)), nil
+}
+
+// resolveBlob fills in the data of a pointer payload from its blob
+func (b *LocalBackend) resolveBlob(payload SlotPayload) (SlotPayload, error) {
+	if payload.Blob == "" {
+		return payload, nil
+	}
+	jsonData, err := os.ReadFile(b.blobPath(payload.Blob))
+	if err != nil {
+		return SlotPayload{}, fmt.Errorf("reading blob %s: %w", payload.Blob, err)
+	}
+	var blob SlotPayload
+	if err := json.Unmarshal(jsonData, &blob); err != nil {
+		return SlotPayload{}, fmt.Errorf("decoding blob: %w", err)
+	}
+	return withBlobData(payload, blob), nil
+}
+
 func (b *LocalBackend) List() ([]RemoteSlot, error) {
 	entries, err := os.ReadDir(b.path)
 	if err != nil {
@@ -195,30 +413,49 @@ func (b *LocalBackend) List() ([]RemoteSlot, error) {
 
 	var slots []RemoteSlot
 	var expiredSlots []string
+	seen := make(map[string]bool)
 
 	for _, entry := range entries {
 		if entry.IsDir() {
 			continue
 		}
 
+		// Slots in the configured extension, plus legacy .pb slots
 		name := entry.Name()
-		if !strings.HasSuffix(name, ".pb") {
+		var slotName string
+		switch {
+		case strings.HasSuffix(name, b.slotExt()):
+			slotName = strings.TrimSuffix(name, b.slotExt())
+		case strings.HasSuffix(name, defaultSlotExt):
+			slotName = strings.TrimSuffix(name, defaultSlotExt)
+		default:
 			continue
 		}
-
-		slotName := strings.TrimSuffix(name, ".pb")
+		if seen[slotName] {
+			continue
+		}
+		seen[slotName] = true
 
 		info, err := entry.Info()
 		if err != nil {
 			continue
 		}
 
-		// Read slot file to check expiry
+		// Read slot file to check expiry. The payload's creation time is
+		// preferred over the file mtime, which copies and restores reset.
 		var expiresAt time.Time
-		slotPath := b.slotPath(slotName)
+		var stored *SlotPipeline
+		createdAt := info.ModTime()
+		slotPath := b.existingSlotPath(slotName)
 		if jsonData, err := os.ReadFile(slotPath); err == nil {
 			var payload SlotPayload
 			if err := json.Unmarshal(jsonData, &payload); err == nil {
+				// Dedup pointers carry the blob's flags, so no blob read
+				p := payloadPipeline(payload)
+				stored = &p
+				if t, err := time.Parse(time.RFC3339, payload.CreatedAt); err == nil {
+					createdAt = t
+				}
 				if payload.ExpiresAt != "" {
 					if t, err := time.Parse(time.RFC3339, payload.ExpiresAt); err == nil {
 						expiresAt = t
@@ -235,8 +472,9 @@ func (b *LocalBackend) List() ([]RemoteSlot, error) {
 		slots = append(slots, RemoteSlot{
 			Name:      slotName,
 			Size:      info.Size(),
-			CreatedAt: info.ModTime(),
+			CreatedAt: createdAt,
 			ExpiresAt: expiresAt,
+			Stored:    stored,
 		})
 	}
 
@@ -249,12 +487,211 @@ func (b *LocalBackend) List() ([]RemoteSlot, error) {
 }
 
 func (b *LocalBackend) Delete(slot string) error {
-	err := os.Remove(b.slotPath(slot))
+	if err := b.deleteSlot(slot); err != nil {
+		return err
+	}
+	b.pruneBlobs()
+	return nil
+}
+
+// DeleteBatch implements BatchDeleter, pruning blobs once for the batch
+// since that walks every slot file
+func (b *LocalBackend) DeleteBatch(slots []string, done func(slot string, err error)) {
+	for _, slot := range slots {
+		done(slot, b.deleteSlot(slot))
+	}
+	b.pruneBlobs()
+}
+
+// deleteSlot removes a slot file and its stored versions, leaving any
+// blobs they pointed at to pruneBlobs
+func (b *LocalBackend) deleteSlot(slot string) error {
+	err := os.Remove(b.existingSlotPath(slot))
 	if err != nil {
 		if os.IsNotExist(err) {
 			return fmt.Errorf("slot %q not found", slot)
 		}
 		return fmt.Errorf("deleting slot file: %w", err)
 	}
+	_ = os.RemoveAll(b.versionDir(slot))
 	return nil
 }
+
+// locksDir holds the lock files of slots being appended to
+const locksDir = ".locks"
+
+// How long LockSlot waits for another writer, and the age after which a
+// lock left behind by a crashed process is taken over
+const (
+	slotLockTimeout  = 10 * time.Second
+	staleSlotLockAge = 30 * time.Second
+)
+
+// LockSlot takes the slot's lock file, waiting while another process holds
+// it. The lock file holds a token unique to its holder, written before the
+// file appears, so a stale lock is only removed while it still holds the
+// token that was judged stale. The returned function releases the lock.
+func (b *LocalBackend) LockSlot(slot string) (func(), error) {
+	path := filepath.Join(b.path, locksDir, slot+".lock")
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("creating locks directory: %w", err)
+	}
+	nonce := make([]byte, 8)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("creating slot lock: %w", err)
+	}
+	id := fmt.Sprintf("%d-%x", os.Getpid(), nonce)
+	token := id + "\n"
+
+	// The token is linked into place, so the lock never exists without it
+	tmp := path + "." + id + ".tmp"
+	if err := os.WriteFile(tmp, []byte(token), 0600); err != nil {
+		return nil, fmt.Errorf("creating slot lock: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp) }()
+
+	deadline := time.Now().Add(slotLockTimeout)
+	for {
+		err := os.Link(tmp, path)
+		if err == nil {
+			debugLog("locked slot %q: %s", slot, path)
+			return func() { removeLockIf(path, token, id) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("creating slot lock: %w", err)
+		}
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > staleSlotLockAge {
+			if stale, err := os.ReadFile(path); err == nil {
+				debugLog("taking over stale lock %s", path)
+				removeLockIf(path, string(stale), id)
+			}
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("slot %q is locked by another push (%s); try again", slot, path)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// removeLockIf removes the lock file at path if it holds token. The file
+// is first renamed to a name of this process's own (id), so the contents
+// compared are those of the file removed. Two waiters taking over the same
+// stale lock can't remove each other's new lock this way: a lock found to
+// hold another token is linked back into place.
+func removeLockIf(path, token, id string) {
+	moved := path + "." + id + ".old"
+	if err := os.Rename(path, moved); err != nil {
+		return
+	}
+	if held, err := os.ReadFile(moved); err == nil && string(held) != token {
+		debugLog("lock %s changed hands; leaving it", path)
+		if err := os.Link(moved, path); err != nil {
+			debugLog("restoring lock %s: %v", path, err)
+		}
+	}
+	_ = os.Remove(moved)
+}
+
+func (b *LocalBackend) versionDir(slot string) string {
+	return filepath.Join(b.path, versionsDir, slot)
+}
+
+func (b *LocalBackend) versionPath(slot string, id int) string {
+	return filepath.Join(b.versionDir(slot), fmt.Sprintf("%d.pb", id))
+}
+
+// versionIDs returns the stored version IDs for a slot in ascending order
+func (b *LocalBackend) versionIDs(slot string) ([]int, error) {
+	entries, err := os.ReadDir(b.versionDir(slot))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading versions directory: %w", err)
+	}
+	var ids []int
+	for _, entry := range entries {
+		if id, ok := parseVersionID(entry.Name()); ok && !entry.IsDir() {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+	return ids, nil
+}
+
+// saveVersion stores a copy of the pushed payload and prunes old versions
+func (b *LocalBackend) saveVersion(slot string, jsonData []byte) error {
+	if err := os.MkdirAll(b.versionDir(slot), 0700); err != nil {
+		return fmt.Errorf("creating versions directory: %w", err)
+	}
+	ids, err := b.versionIDs(slot)
+	if err != nil {
+		return err
+	}
+	next := 1
+	if len(ids) > 0 {
+		next = ids[len(ids)-1] + 1
+	}
+	if err := os.WriteFile(b.versionPath(slot, next), jsonData, 0600); err != nil {
+		return fmt.Errorf("writing version file: %w", err)
+	}
+
+	ids = append(ids, next)
+	for len(ids) > b.versions {
+		_ = os.Remove(b.versionPath(slot, ids[0]))
+		ids = ids[1:]
+	}
+	return nil
+}
+
+// readVersion reads a stored version's payload
+func (b *LocalBackend) readVersion(slot string, id int) (SlotPayload, int64, error) {
+	jsonData, err := os.ReadFile(b.versionPath(slot, id))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return SlotPayload{}, 0, fmt.Errorf("version %d of slot %q not found", id, slot)
+		}
+		return SlotPayload{}, 0, fmt.Errorf("reading version file: %w", err)
+	}
+	var payload SlotPayload
+	if err := json.Unmarshal(jsonData, &payload); err != nil {
+		return SlotPayload{}, 0, fmt.Errorf("decoding payload: %w", err)
+	}
+	return payload, int64(len(jsonData)), nil
+}
+
+func (b *LocalBackend) ListVersions(slot string) ([]SlotVersion, error) {
+	ids, err := b.versionIDs(slot)
+	if err != nil {
+		return nil, err
+	}
+	versions := make([]SlotVersion, 0, len(ids))
+	for _, id := range ids {
+		payload, size, err := b.readVersion(slot, id)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, newSlotVersion(id, size, payload))
+	}
+	return versions, nil
+}
+
+func (b *LocalBackend) PullVersion(slot string, id int) ([]byte, SlotVersion, error) {
+	payload, size, err := b.readVersion(slot, id)
+	if err != nil {
+		return nil, SlotVersion{}, err
+	}
+	if err := checkPayloadVersion(slot, payload); err != nil {
+		return nil, SlotVersion{}, err
+	}
+	payload, err = b.resolveBlob(payload)
+	if err != nil {
+		return nil, SlotVersion{}, err
+	}
+	data, err := decodePayloadData(payload, b.passphrase, b.age)
+	if err != nil {
+		return nil, SlotVersion{}, err
+	}
+	return data, newSlotVersion(id, size, payload), nil
+}
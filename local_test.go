@@ -1,10 +1,17 @@
 package main
 
 import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"testing"
+	"time"
+
+	"filippo.io/age"
 )
 
 func TestLocalBackendPushPull(t *testing.T) {
@@ -874,3 +881,560 @@ func TestLocalBackendListNoExpiresAt(t *testing.T) {
 		t.Error("ExpiresAt should be zero for slot without TTL")
 	}
 }
+
+func TestLocalBackendDedupStoresOneBlob(t *testing.T) {
+	tmpDir := t.TempDir()
+	backend, err := newLocalBackend(&LocalConfig{Path: tmpDir}, "", "", 0)
+	if err != nil {
+		t.Fatalf("failed to create local backend: %v", err)
+	}
+	backend.dedup = true
+
+	artifact := []byte(strings.Repeat("build artifact ", 200))
+	for _, slot := range []string{"release", "release-copy"} {
+		if err := backend.Push(slot, artifact, nil); err != nil {
+			t.Fatalf("Push %s failed: %v", slot, err)
+		}
+	}
+
+	blobs, err := os.ReadDir(filepath.Join(tmpDir, blobsDir))
+	if err != nil {
+		t.Fatalf("reading blobs dir: %v", err)
+	}
+	if len(blobs) != 1 {
+		t.Fatalf("expected 1 blob, got %d", len(blobs))
+	}
+	if want := contentHash(artifact, "") + ".pb"; blobs[0].Name() != want {
+		t.Errorf("blob name = %q, want %q", blobs[0].Name(), want)
+	}
+
+	// Slot files are pointers without the data
+	pointer, err := os.ReadFile(filepath.Join(tmpDir, "release.pb"))
+	if err != nil {
+		t.Fatalf("reading pointer: %v", err)
+	}
+	if !strings.Contains(string(pointer), `"blob"`) || strings.Contains(string(pointer), "YnVpbGQg") {
+		t.Errorf("slot file should reference the blob without embedding data: %s", pointer)
+	}
+
+	for _, slot := range []string{"release", "release-copy"} {
+		pulled, _, err := backend.Pull(slot)
+		if err != nil {
+			t.Fatalf("Pull %s failed: %v", slot, err)
+		}
+		if string(pulled) != string(artifact) {
+			t.Errorf("Pull %s returned wrong content", slot)
+		}
+	}
+
+	// Blobs aren't listed as slots
+	slots, err := backend.List()
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(slots) != 2 {
+		t.Errorf("expected 2 slots, got %+v", slots)
+	}
+
+	// Deleting one slot leaves the blob for the other
+	if err := backend.Delete("release"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, _, err := backend.Pull("release-copy"); err != nil {
+		t.Errorf("Pull after deleting other slot failed: %v", err)
+	}
+}
+
+func TestLocalBackendDedupWithEncryption(t *testing.T) {
+	tmpDir := t.TempDir()
+	backend, err := newLocalBackend(&LocalConfig{Path: tmpDir}, "aes256", "test-passphrase", 0)
+	if err != nil {
+		t.Fatalf("failed to create local backend: %v", err)
+	}
+	backend.dedup = true
+
+	secret := []byte("shared secret data")
+	for _, slot := range []string{"a", "b"} {
+		if err := backend.Push(slot, secret, nil); err != nil {
+			t.Fatalf("Push failed: %v", err)
+		}
+	}
+
+	blobs, err := os.ReadDir(filepath.Join(tmpDir, blobsDir))
+	if err != nil {
+		t.Fatalf("reading blobs dir: %v", err)
+	}
+	if len(blobs) != 1 {
+		t.Fatalf("expected 1 blob, got %d", len(blobs))
+	}
+	// Keyed hash: the blob name is not the plain digest of the content
+	if blobs[0].Name() == contentHash(secret, "")+".pb" {
+		t.Error("encrypted blob should not be named by the plain content digest")
+	}
+
+	pulled, _, err := backend.Pull("b")
+	if err != nil {
+		t.Fatalf("Pull failed: %v", err)
+	}
+	if string(pulled) != string(secret) {
+		t.Errorf("decrypted data mismatch: got %q", pulled)
+	}
+}
+
+// Test dedup is refused with age, which has no secret to key blob names
+// with, even when the backend isn't built from a validated config
+func TestLocalBackendDedupRefusesAge(t *testing.T) {
+	tmpDir := t.TempDir()
+	backend, err := newLocalBackend(&LocalConfig{Path: tmpDir}, "age", "", 0)
+	if err != nil {
+		t.Fatalf("failed to create local backend: %v", err)
+	}
+	backend.dedup = true
+	backend.age = &ageKeys{recipients: []age.Recipient{newTestAgeIdentity(t).Recipient()}}
+
+	if err := backend.Push("a", []byte("shared secret data"), nil); err == nil || !strings.Contains(err.Error(), "sync.dedup") {
+		t.Errorf("expected dedup with age to be refused, got %v", err)
+	}
+	if entries, _ := os.ReadDir(filepath.Join(tmpDir, blobsDir)); len(entries) != 0 {
+		t.Errorf("refused push wrote %d blob(s)", len(entries))
+	}
+}
+
+// Test rm and expiry remove the blobs only the removed slot pointed at
+func TestLocalBackendDedupPrunesBlobs(t *testing.T) {
+	tmpDir := t.TempDir()
+	backend, err := newLocalBackend(&LocalConfig{Path: tmpDir}, "", "", 0)
+	if err != nil {
+		t.Fatalf("failed to create local backend: %v", err)
+	}
+	backend.dedup = true
+	blobPath := func(data string) string {
+		return backend.blobPath(contentHash([]byte(data), ""))
+	}
+	// Blobs written within the grace period are never pruned
+	age := func(data string) {
+		old := time.Now().Add(-2 * blobGracePeriod)
+		if err := os.Chtimes(blobPath(data), old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for slot, data := range map[string]string{"a": "shared", "b": "shared", "c": "secret"} {
+		if err := backend.Push(slot, []byte(data), nil); err != nil {
+			t.Fatalf("Push %s failed: %v", slot, err)
+		}
+	}
+	age("shared")
+	age("secret")
+
+	if err := backend.Delete("c"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := os.Stat(blobPath("secret")); !os.IsNotExist(err) {
+		t.Error("blob of a deleted slot was kept")
+	}
+	if err := backend.Delete("a"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := os.Stat(blobPath("shared")); err != nil {
+		t.Fatal("blob still pointed at by another slot was deleted")
+	}
+
+	// An expired slot takes its blob with it when pulled
+	pointer := filepath.Join(tmpDir, "b.pb")
+	jsonData, _ := os.ReadFile(pointer)
+	var payload SlotPayload
+	_ = json.Unmarshal(jsonData, &payload)
+	payload.ExpiresAt = time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	jsonData, _ = json.Marshal(payload)
+	_ = os.WriteFile(pointer, jsonData, 0600)
+	if _, _, err := backend.Pull("b"); err == nil {
+		t.Fatal("expected an expired error")
+	}
+	if _, err := os.Stat(blobPath("shared")); !os.IsNotExist(err) {
+		t.Error("blob of an expired slot was kept")
+	}
+}
+
+func TestLocalBackendDedupMissingBlob(t *testing.T) {
+	tmpDir := t.TempDir()
+	backend, err := newLocalBackend(&LocalConfig{Path: tmpDir}, "", "", 0)
+	if err != nil {
+		t.Fatalf("failed to create local backend: %v", err)
+	}
+	backend.dedup = true
+
+	if err := backend.Push("orphan", []byte("data"), nil); err != nil {
+		t.Fatalf("Push failed: %v", err)
+	}
+	if err := os.RemoveAll(filepath.Join(tmpDir, blobsDir)); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := backend.Pull("orphan"); err == nil || !strings.Contains(err.Error(), "blob") {
+		t.Errorf("expected blob error, got %v", err)
+	}
+}
+
+// Test base85 round-trips binary data and stores less than base64
+func TestLocalBackendBase85Encoding(t *testing.T) {
+	// Random bytes don't compress, so the encoding is the only overhead
+	data := make([]byte, 64*1024)
+	if _, err := rand.Read(data); err != nil {
+		t.Fatalf("generating data: %v", err)
+	}
+
+	sizes := map[string]int64{}
+	for _, encoding := range []string{"", "base85"} {
+		tmpDir := t.TempDir()
+		backend, err := newLocalBackend(&LocalConfig{Path: tmpDir}, "", "", 0)
+		if err != nil {
+			t.Fatalf("failed to create local backend: %v", err)
+		}
+		backend.encoding = encoding
+
+		if err := backend.Push("bin", data, nil); err != nil {
+			t.Fatalf("Push (%q) failed: %v", encoding, err)
+		}
+		pulled, _, err := backend.Pull("bin")
+		if err != nil {
+			t.Fatalf("Pull (%q) failed: %v", encoding, err)
+		}
+		if !bytes.Equal(pulled, data) {
+			t.Fatalf("round trip (%q) changed the data", encoding)
+		}
+
+		raw, err := os.ReadFile(filepath.Join(tmpDir, "bin.pb"))
+		if err != nil {
+			t.Fatalf("reading slot file: %v", err)
+		}
+		var payload SlotPayload
+		if err := json.Unmarshal(raw, &payload); err != nil {
+			t.Fatalf("stored payload is not valid JSON: %v", err)
+		}
+		if payload.Encoding != encoding {
+			t.Errorf("Encoding = %q, want %q", payload.Encoding, encoding)
+		}
+		sizes[encoding] = int64(len(raw))
+	}
+
+	if sizes["base85"] >= sizes[""] {
+		t.Errorf("base85 payload (%d bytes) should be smaller than base64 (%d bytes)", sizes["base85"], sizes[""])
+	}
+}
+
+// Test base85 works with compression, encryption and dedup blobs
+func TestLocalBackendBase85WithPipeline(t *testing.T) {
+	tmpDir := t.TempDir()
+	backend, err := newLocalBackend(&LocalConfig{Path: tmpDir}, "aes256", "secret", 0)
+	if err != nil {
+		t.Fatalf("failed to create local backend: %v", err)
+	}
+	backend.encoding = "base85"
+	backend.dedup = true
+
+	data := []byte(strings.Repeat("compressible <&> text ", 200))
+	if err := backend.Push("doc", data, nil); err != nil {
+		t.Fatalf("Push failed: %v", err)
+	}
+	pulled, _, err := backend.Pull("doc")
+	if err != nil {
+		t.Fatalf("Pull failed: %v", err)
+	}
+	if !bytes.Equal(pulled, data) {
+		t.Error("round trip changed the data")
+	}
+}
+
+// Test sync.encoding is validated
+func TestValidateSyncConfigEncoding(t *testing.T) {
+	for _, encoding := range []string{"", "base64", "base85"} {
+		cfg := &Config{Sync: &SyncConfig{Backend: "local", Encoding: encoding}}
+		if err := validateSyncConfig(cfg); err != nil {
+			t.Errorf("encoding %q should be valid: %v", encoding, err)
+		}
+	}
+	cfg := &Config{Sync: &SyncConfig{Backend: "local", Encoding: "base91"}}
+	if err := validateSyncConfig(cfg); err == nil || !strings.Contains(err.Error(), "sync.encoding") {
+		t.Errorf("expected sync.encoding error, got %v", err)
+	}
+}
+
+// Test sync.compression picks the algorithm recorded in each payload, and
+// slots pushed with one setting still pull under another
+func TestLocalBackendCompression(t *testing.T) {
+	tmpDir := t.TempDir()
+	data := []byte(strings.Repeat("kubectl get pods -A --watch\n", 400))
+
+	tests := []struct {
+		compress       string
+		wantCompressed bool
+		wantAlgo       string
+	}{
+		{"", true, ""},
+		{"gzip", true, ""},
+		{"zstd", true, "zstd"},
+		{"none", false, ""},
+	}
+	for _, tt := range tests {
+		backend, err := newLocalBackend(&LocalConfig{Path: tmpDir}, "aes256", "secret", 0)
+		if err != nil {
+			t.Fatalf("failed to create local backend: %v", err)
+		}
+		backend.compress = tt.compress
+		slot := "doc-" + tt.compress
+		if err := backend.Push(slot, data, nil); err != nil {
+			t.Fatalf("Push (%q) failed: %v", tt.compress, err)
+		}
+
+		raw, err := os.ReadFile(filepath.Join(tmpDir, slot+".pb"))
+		if err != nil {
+			t.Fatalf("reading slot file: %v", err)
+		}
+		var payload SlotPayload
+		if err := json.Unmarshal(raw, &payload); err != nil {
+			t.Fatalf("stored payload is not valid JSON: %v", err)
+		}
+		if payload.Compressed != tt.wantCompressed || payload.CompressionAlgo != tt.wantAlgo {
+			t.Errorf("compress %q stored compressed=%t algo=%q, want %t %q", tt.compress, payload.Compressed, payload.CompressionAlgo, tt.wantCompressed, tt.wantAlgo)
+		}
+
+		// Pull dispatches on the payload, not the current setting
+		backend.compress = "none"
+		pulled, _, err := backend.Pull(slot)
+		if err != nil {
+			t.Fatalf("Pull (%q) failed: %v", tt.compress, err)
+		}
+		if !bytes.Equal(pulled, data) {
+			t.Errorf("round trip (%q) changed the data", tt.compress)
+		}
+	}
+}
+
+// Test an unknown compression field fails the pull instead of returning
+// compressed bytes
+func TestDecodePayloadUnknownCompression(t *testing.T) {
+	stored, _ := compressData([]byte(strings.Repeat("x", 2000)))
+	payload := SlotPayload{Compressed: true, CompressionAlgo: "brotli"}
+	payload.DataB64, payload.Encoding = encodePayloadData(stored, "")
+	if _, err := decodePayloadData(payload, "", nil); err == nil || !strings.Contains(err.Error(), "brotli") {
+		t.Errorf("expected unsupported compression error, got %v", err)
+	}
+}
+
+// Test sync.compression is validated
+func TestValidateSyncConfigCompression(t *testing.T) {
+	for _, compression := range []string{"", "gzip", "zstd", "none"} {
+		cfg := &Config{Sync: &SyncConfig{Backend: "local", Compression: compression}}
+		if err := validateSyncConfig(cfg); err != nil {
+			t.Errorf("compression %q should be valid: %v", compression, err)
+		}
+	}
+	cfg := &Config{Sync: &SyncConfig{Backend: "local", Compression: "lz4"}}
+	if err := validateSyncConfig(cfg); err == nil || !strings.Contains(err.Error(), "sync.compression") {
+		t.Errorf("expected sync.compression error, got %v", err)
+	}
+}
+
+// Test local.extension names slot files and still finds legacy .pb slots
+func TestLocalBackendCustomExtension(t *testing.T) {
+	tmpDir := t.TempDir()
+	legacy, err := newLocalBackend(&LocalConfig{Path: tmpDir}, "", "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := legacy.Push("old", []byte("legacy content"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := legacy.Push("moved", []byte("before"), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	backend, err := newLocalBackend(&LocalConfig{Path: tmpDir, Extension: "json"}, "", "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := backend.Push("new", []byte("new content"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "new.json")); err != nil {
+		t.Errorf("expected new.json: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "new.pb")); !os.IsNotExist(err) {
+		t.Errorf("new slot should not use .pb (stat err: %v)", err)
+	}
+
+	// Pushing over a legacy slot moves it to the new extension
+	if err := backend.Push("moved", []byte("after"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "moved.pb")); !os.IsNotExist(err) {
+		t.Errorf("legacy moved.pb should be removed (stat err: %v)", err)
+	}
+
+	slots, err := backend.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, s := range slots {
+		names = append(names, s.Name)
+	}
+	sort.Strings(names)
+	if strings.Join(names, ",") != "moved,new,old" {
+		t.Errorf("List = %v, want moved, new, old", names)
+	}
+
+	data, _, err := backend.Pull("old")
+	if err != nil || string(data) != "legacy content" {
+		t.Errorf("Pull legacy slot = %q, %v", data, err)
+	}
+	if data, _, err := backend.Pull("moved"); err != nil || string(data) != "after" {
+		t.Errorf("Pull moved slot = %q, %v", data, err)
+	}
+	if err := backend.Delete("old"); err != nil {
+		t.Errorf("Delete legacy slot: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "old.pb")); !os.IsNotExist(err) {
+		t.Errorf("old.pb should be deleted (stat err: %v)", err)
+	}
+}
+
+func TestNormalizeSlotExt(t *testing.T) {
+	tests := []struct {
+		in, want string
+		wantErr  bool
+	}{
+		{"", ".pb", false},
+		{"json", ".json", false},
+		{".json", ".json", false},
+		{".", "", true},
+		{"../x", "", true},
+	}
+	for _, tt := range tests {
+		got, err := normalizeSlotExt(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("normalizeSlotExt(%q) = %q, %v", tt.in, got, err)
+		}
+	}
+}
+
+// Test slots written by a newer pipeboard: versions that declare a
+// min_version this build meets pull (ignoring unknown fields) with a
+// warning, and the rest fail asking for an upgrade
+func TestLocalBackendPayloadVersions(t *testing.T) {
+	tmpDir := t.TempDir()
+	backend, err := newLocalBackend(&LocalConfig{Path: tmpDir}, "", "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := backend.Push("current", []byte("v1 data"), nil); err != nil {
+		t.Fatalf("push: %v", err)
+	}
+	stored, err := os.ReadFile(filepath.Join(tmpDir, "current.pb"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var current map[string]any
+	if err := json.Unmarshal(stored, &current); err != nil {
+		t.Fatal(err)
+	}
+	if current["version"] != float64(slotPayloadVersion) {
+		t.Errorf("pushed version = %v, want %d", current["version"], slotPayloadVersion)
+	}
+
+	// writeFuture stores the current payload with a different version
+	// header and a field this build doesn't know
+	writeFuture := func(slot string, version, minVersion int) {
+		payload := make(map[string]any, len(current)+2)
+		for k, v := range current {
+			payload[k] = v
+		}
+		payload["version"] = version
+		if minVersion > 0 {
+			payload["min_version"] = minVersion
+		}
+		payload["shiny_new_field"] = map[string]any{"x": 1}
+		data, err := json.Marshal(payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(tmpDir, slot+".pb"), data, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name        string
+		version     int
+		minVersion  int
+		wantErr     bool
+		wantWarning bool
+	}{
+		{"current", slotPayloadVersion, 0, false, false},
+		{"older header", 0, 0, false, false},
+		{"compatible", slotPayloadVersion + 1, slotPayloadVersion, false, true},
+		{"no min_version", slotPayloadVersion + 1, 0, true, false},
+		{"incompatible", slotPayloadVersion + 2, slotPayloadVersion + 1, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeFuture("future", tt.version, tt.minVersion)
+			var data []byte
+			var err error
+			stderr := captureStderr(func() {
+				data, _, err = backend.Pull("future")
+			})
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "newer pipeboard") || !strings.Contains(err.Error(), "upgrade") {
+					t.Errorf("err = %v, want an upgrade error", err)
+				}
+				return
+			}
+			if err != nil || string(data) != "v1 data" {
+				t.Fatalf("pull = %q, %v", data, err)
+			}
+			if got := strings.Contains(stderr, "newer pipeboard"); got != tt.wantWarning {
+				t.Errorf("warning = %v, want %v (stderr %q)", got, tt.wantWarning, stderr)
+			}
+		})
+	}
+}
+
+// Test a stale lock is taken over, and a second waiter that judged the same
+// lock stale can't remove the new holder's lock
+func TestLocalBackendLockSlotStaleTakeover(t *testing.T) {
+	tmpDir := t.TempDir()
+	backend, err := newLocalBackend(&LocalConfig{Path: tmpDir}, "", "", 0)
+	if err != nil {
+		t.Fatalf("failed to create local backend: %v", err)
+	}
+	path := filepath.Join(tmpDir, locksDir, "pad.lock")
+	_ = os.MkdirAll(filepath.Dir(path), 0700)
+	const stale = "12345-crashed\n"
+	if err := os.WriteFile(path, []byte(stale), 0600); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-2 * staleSlotLockAge)
+	_ = os.Chtimes(path, old, old)
+
+	unlock, err := backend.LockSlot("pad")
+	if err != nil {
+		t.Fatalf("stale lock should be taken over: %v", err)
+	}
+	held, _ := os.ReadFile(path)
+	if string(held) == stale {
+		t.Fatal("lock file still holds the stale token")
+	}
+
+	// A waiter that read the stale lock before the takeover removes it late
+	removeLockIf(path, stale, "late-waiter")
+	if now, err := os.ReadFile(path); err != nil || string(now) != string(held) {
+		t.Errorf("new holder's lock was removed or changed: %q, %v", now, err)
+	}
+
+	unlock()
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 0 {
+		t.Errorf("lock files left behind: %v", entries)
+	}
+}
@@ -8,6 +8,7 @@ package main
 import (
 	"fmt"
 	"os"
+	"strings"
 )
 
 // version is set at build time via ldflags
@@ -15,8 +16,10 @@ var version = "dev"
 
 // Global flags
 var (
-	quietMode = false // Suppress non-essential output
-	debugMode = false // Enable debug logging
+	quietMode     = false // Suppress non-essential output
+	debugMode     = false // Enable debug logging
+	compactJSON   = false // Single-line --json output
+	configProfile = ""    // --profile: use profiles/<name>.yaml instead of config.yaml
 )
 
 // commands maps command names to their handler functions
@@ -28,33 +31,66 @@ var commands = map[string]func([]string) error{
 	"doctor":     cmdDoctor,
 	"push":       cmdPush,
 	"pull":       cmdPull,
+	"sync":       cmdSync,
 	"show":       cmdShow,
+	"qr":         cmdQR,
 	"slots":      cmdSlots,
 	"rm":         cmdRm,
+	"verify":     cmdVerify,
+	"diff":       cmdDiff,
+	"prune":      cmdPrune,
+	"export":     cmdExport,
+	"import":     cmdImport,
 	"send":       cmdSend,
 	"recv":       cmdRecv,
 	"receive":    cmdRecv,
 	"peek":       cmdPeek,
+	"peers":      cmdPeers,
 	"history":    cmdHistory,
 	"fx":         cmdFx,
 	"init":       cmdInit,
+	"config":     cmdConfig,
 	"completion": cmdCompletion,
+	"__complete": cmdComplete,
 	"watch":      cmdWatch,
 	"recall":     cmdRecall,
+	"undo":       cmdUndo,
+	"audit":      cmdAudit,
 	"login":      cmdLogin,
 	"signup":     cmdSignup,
 	"logout":     cmdLogout,
+	"keyring":    cmdKeyring,
 }
 
-// parseGlobalFlags extracts global flags and returns remaining args
+// parseGlobalFlags extracts global flags and returns remaining args.
+// Parsing stops at "--", which is kept for the command along with the
+// literal arguments after it.
 func parseGlobalFlags(args []string) []string {
 	var remaining []string
-	for _, arg := range args {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			return append(remaining, args[i:]...)
+		}
+		if name, ok := strings.CutPrefix(arg, "--profile="); ok {
+			configProfile = name
+			continue
+		}
 		switch arg {
+		case "--profile":
+			// Without a name it is left for run to reject
+			if i+1 >= len(args) {
+				remaining = append(remaining, arg)
+				continue
+			}
+			i++
+			configProfile = args[i]
 		case "-q", "--quiet":
 			quietMode = true
 		case "--debug":
 			debugMode = true
+		case "--compact":
+			compactJSON = true
 		default:
 			remaining = append(remaining, arg)
 		}
@@ -66,6 +102,13 @@ func parseGlobalFlags(args []string) []string {
 func run(args []string, checkStdin func() bool) int {
 	// Parse global flags first
 	args = parseGlobalFlags(args)
+	if err := checkConfigProfile(args); err != nil {
+		printError(err)
+		return 1
+	}
+
+	// Batched history records are written before the command returns
+	defer historyQueue.start()()
 
 	if len(args) == 0 {
 		// Check if stdin has data (piped input) - default to copy
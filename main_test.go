@@ -2,8 +2,10 @@ package main
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
 	"runtime"
 	"strings"
 	"testing"
@@ -254,13 +256,6 @@ func TestRunCommandEmptyCommand(t *testing.T) {
 	}
 }
 
-func TestRunAndPipeStdoutEmptyCommand(t *testing.T) {
-	err := runAndPipeStdout([]string{})
-	if err == nil {
-		t.Error("runAndPipeStdout with empty command should return error")
-	}
-}
-
 func TestCmdPasteWithArgs(t *testing.T) {
 	err := cmdPaste([]string{"unexpected", "args"})
 	if err == nil {
@@ -305,6 +300,21 @@ func captureOutput(f func()) string {
 	return buf.String()
 }
 
+func captureStderr(f func()) string {
+	old := os.Stderr
+	r, w, _ := os.Pipe()
+	os.Stderr = w
+
+	f()
+
+	_ = w.Close()
+	os.Stderr = old
+
+	var buf bytes.Buffer
+	_, _ = buf.ReadFrom(r)
+	return buf.String()
+}
+
 func TestPrintHelp(t *testing.T) {
 	output := captureOutput(printHelp)
 
@@ -393,12 +403,6 @@ func TestCmdRmNoArgs(t *testing.T) {
 	}
 }
 
-func TestCmdRmTooManyArgs(t *testing.T) {
-	err := cmdRm([]string{"slot1", "slot2"})
-	if err == nil {
-		t.Error("cmdRm with too many args should return error")
-	}
-}
 
 func TestCmdSendNoArgs(t *testing.T) {
 	err := cmdSend([]string{})
@@ -909,23 +913,6 @@ func TestRunWithInput(t *testing.T) {
 	})
 }
 
-// Test runAndPipeStdout
-func TestRunAndPipeStdout(t *testing.T) {
-	t.Run("empty command", func(t *testing.T) {
-		err := runAndPipeStdout([]string{})
-		if err == nil {
-			t.Error("expected error for empty command")
-		}
-	})
-
-	t.Run("successful command", func(t *testing.T) {
-		err := runAndPipeStdout([]string{"true"})
-		if err != nil {
-			t.Errorf("unexpected error: %v", err)
-		}
-	})
-}
-
 // Test cmdClear error paths
 func TestCmdClearNoPanic(t *testing.T) {
 	// cmdClear will fail in test environment without proper clipboard
@@ -1230,6 +1217,7 @@ func TestCmdPeekWithMockSSH(t *testing.T) {
 
 	_ = os.Setenv("PATH", mockDir+":"+origPath)
 	_ = os.Setenv("PIPEBOARD_CONFIG", configFile)
+	t.Setenv("XDG_CONFIG_HOME", tmpDir) // keep the peer cache out of $HOME
 
 	// cmdPeek should succeed with mock SSH
 	err := cmdPeek([]string{"testpeer"})
@@ -1263,6 +1251,7 @@ func TestCmdRecvWithMockSSH(t *testing.T) {
 
 	_ = os.Setenv("PATH", mockDir+":"+origPath)
 	_ = os.Setenv("PIPEBOARD_CONFIG", configFile)
+	t.Setenv("XDG_CONFIG_HOME", tmpDir) // keep the peer cache out of $HOME
 
 	// cmdRecv will try to write to clipboard which may fail
 	// but the SSH part should work
@@ -1906,6 +1895,7 @@ func TestInstallHint(t *testing.T) {
 		{BackendDarwin, "pbcopy"},
 		{BackendWSL, "clip.exe"},
 		{BackendWindows, "clip.exe"},
+		{BackendTermux, "termux-api"},
 		{BackendUnknown, "doctor"},
 	}
 
@@ -2366,6 +2356,68 @@ func TestCmdCompletionOutput(t *testing.T) {
 	}
 }
 
+// Test __complete fx lists configured transforms matching a prefix
+func TestCmdCompleteFx(t *testing.T) {
+	cleanup := setupSlotsTestConfig(t, `version: 1
+fx:
+  pretty-json:
+    cmd: ["jq", "."]
+  prune:
+    shell: "grep -v '^#'"
+  strip-ansi:
+    shell: "sed 's/x//'"
+`)
+	defer cleanup()
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"fx"}, "base64\nbase64-decode\nhex\nhex-decode\njson-compact\njson-pretty\nlower\npretty-json\nprune\nstrip-ansi\ntrim\nupper\nurl-decode\nurl-encode\n"},
+		{[]string{"fx", "pr"}, "pretty-json\nprune\n"},
+		{[]string{"fx", "strip"}, "strip-ansi\n"},
+		{[]string{"fx", "url"}, "url-decode\nurl-encode\n"},
+		{[]string{"fx", "zzz"}, ""},
+		{[]string{"unknown"}, ""},
+		{[]string{}, ""},
+	}
+	for _, tc := range tests {
+		var err error
+		output := captureOutput(func() {
+			err = cmdComplete(tc.args)
+		})
+		if err != nil {
+			t.Errorf("cmdComplete(%v) error: %v", tc.args, err)
+		}
+		if output != tc.want {
+			t.Errorf("cmdComplete(%v) = %q, want %q", tc.args, output, tc.want)
+		}
+	}
+}
+
+// Test __complete stays silent on a broken config
+func TestCmdCompleteBadConfig(t *testing.T) {
+	cleanup := setupSlotsTestConfig(t, "fx: [not: valid")
+	defer cleanup()
+
+	var err error
+	output := captureOutput(func() {
+		err = cmdComplete([]string{"fx"})
+	})
+	if err != nil || output != "" {
+		t.Errorf("expected no output and no error, got %q, %v", output, err)
+	}
+}
+
+// Test the generated scripts call __complete for fx names
+func TestCompletionScriptsUseDynamicFx(t *testing.T) {
+	for _, script := range []string{bashCompletion, zshCompletion, fishCompletion} {
+		if !strings.Contains(script, "pipeboard __complete fx") {
+			t.Errorf("completion script should call __complete fx:\n%.80s", script)
+		}
+	}
+}
+
 // Test cmdInit fails gracefully when called (requires stdin interaction)
 func TestCmdInitBasic(t *testing.T) {
 	// cmdInit requires interactive input, so we can't fully test it
@@ -2727,6 +2779,40 @@ func TestParseGlobalFlagsMultiple(t *testing.T) {
 	}
 }
 
+// Test global flags after "--" are left as literal arguments
+func TestParseGlobalFlagsStopsAtDoubleDash(t *testing.T) {
+	origQuiet := quietMode
+	origDebug := debugMode
+	defer func() {
+		quietMode = origQuiet
+		debugMode = origDebug
+	}()
+
+	quietMode = false
+	debugMode = false
+	remaining := parseGlobalFlags([]string{"--debug", "copy", "--", "--quiet", "-q"})
+
+	if !debugMode {
+		t.Error("parseGlobalFlags should set debugMode before --")
+	}
+	if quietMode {
+		t.Error("--quiet after -- should not set quietMode")
+	}
+	if want := "copy -- --quiet -q"; strings.Join(remaining, " ") != want {
+		t.Errorf("remaining args should be [%s], got %v", want, remaining)
+	}
+
+	// The literal reaches the clipboard
+	defer setupPeerTestConfig(t, "")()
+	path := useFileClipboard(t, "")
+	if code := run([]string{"copy", "--", "--quiet"}, func() bool { return false }); code != 0 {
+		t.Fatalf("run copy exited %d", code)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "--quiet" {
+		t.Errorf("clipboard = %q, want --quiet", data)
+	}
+}
+
 // Test parseGlobalFlags preserves non-flag args
 func TestParseGlobalFlagsPreservesArgs(t *testing.T) {
 	origQuiet := quietMode
@@ -2803,3 +2889,178 @@ func TestRunUnknownCommandError(t *testing.T) {
 		t.Errorf("run(nonexistent-cmd) = %d, want 1", code)
 	}
 }
+
+// Test --compact makes doctor --json print a single line
+func TestCompactJSONDoctor(t *testing.T) {
+	cleanup := setupSlotsTestConfig(t, "")
+	defer cleanup()
+	origCompact := compactJSON
+	defer func() { compactJSON = origCompact }()
+
+	compactJSON = false
+	indented := captureOutput(func() { _ = run([]string{"doctor", "--json"}, func() bool { return false }) })
+	if strings.Count(strings.TrimSpace(indented), "\n") == 0 {
+		t.Errorf("default doctor --json should be indented, got %q", indented)
+	}
+
+	compacted := captureOutput(func() { _ = run([]string{"--compact", "doctor", "--json"}, func() bool { return false }) })
+	if !compactJSON {
+		t.Error("parseGlobalFlags should set compactJSON for --compact")
+	}
+	line := strings.TrimSpace(compacted)
+	if strings.Contains(line, "\n") || !strings.HasPrefix(line, "{") {
+		t.Errorf("doctor --json --compact should be one line, got %q", compacted)
+	}
+	var v map[string]interface{}
+	if err := json.Unmarshal([]byte(line), &v); err != nil {
+		t.Errorf("compact output is not valid JSON: %v", err)
+	}
+}
+
+// Test defaults.json_compact turns on compact output without the flag
+func TestCompactJSONConfig(t *testing.T) {
+	cleanup := setupSlotsTestConfig(t, "defaults:\n  json_compact: true\n")
+	defer cleanup()
+	origCompact := compactJSON
+	defer func() { compactJSON = origCompact }()
+	compactJSON = false
+
+	out, err := marshalJSONOutput(map[string]int{"a": 1, "b": 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != `{"a":1,"b":2}` {
+		t.Errorf("marshalJSONOutput = %q, want compact", out)
+	}
+}
+
+// fakeWSLg simulates WSL2 with WSLg: a WSL release string, a Wayland
+// display, and only the named tools on PATH
+func fakeWSLg(t *testing.T, tools ...string) {
+	t.Helper()
+	dir := t.TempDir()
+	for _, tool := range tools {
+		if err := os.WriteFile(filepath.Join(dir, tool), []byte("#!/bin/sh\n"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	release := filepath.Join(dir, "osrelease")
+	if err := os.WriteFile(release, []byte("5.15.153.1-microsoft-standard-WSL2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	origRelease := wslReleasePath
+	wslReleasePath = release
+	t.Cleanup(func() { wslReleasePath = origRelease })
+	t.Setenv("PATH", dir)
+	t.Setenv("WSL_DISTRO_NAME", "")
+	t.Setenv("WAYLAND_DISPLAY", "wayland-0")
+	t.Setenv("DISPLAY", ":0")
+}
+
+// Test WSLg prefers the native clipboard over clip.exe
+func TestDetectLinuxWSLgPrefersNative(t *testing.T) {
+	fakeWSLg(t, "wl-copy", "wl-paste", "clip.exe", "powershell.exe")
+	if !isWSLg() {
+		t.Fatal("expected WSLg to be detected")
+	}
+
+	b := detectLinux(defaultLinuxBackendOrder)
+	if b.Kind != BackendWayland {
+		t.Errorf("expected %s under WSLg, got %s", BackendWayland, b.Kind)
+	}
+	if !strings.Contains(b.Notes, "WSLg detected") {
+		t.Errorf("expected a WSLg note, got %q", b.Notes)
+	}
+}
+
+// Test WSLg falls back to clip.exe without native tools
+func TestDetectLinuxWSLgFallback(t *testing.T) {
+	fakeWSLg(t, "clip.exe", "powershell.exe")
+
+	b := detectLinux(defaultLinuxBackendOrder)
+	if b.Kind != BackendWSL {
+		t.Errorf("expected fallback to %s, got %s", BackendWSL, b.Kind)
+	}
+	if !strings.Contains(b.Notes, "wl-clipboard") {
+		t.Errorf("expected an install hint, got %q", b.Notes)
+	}
+}
+
+// Test defaults.clipboard_order overrides the detection order
+func TestDetectLinuxClipboardOrder(t *testing.T) {
+	fakeWSLg(t, "wl-copy", "wl-paste", "clip.exe", "powershell.exe")
+	cleanup := setupSlotsTestConfig(t, "version: 1\ndefaults:\n  clipboard_order: [wsl, wayland]\n")
+	defer cleanup()
+
+	cfg, err := loadConfigForAliases()
+	if err != nil {
+		t.Fatal(err)
+	}
+	order, err := cfg.getClipboardOrder()
+	if err != nil {
+		t.Fatalf("getClipboardOrder: %v", err)
+	}
+	if b := detectLinux(order); b.Kind != BackendWSL {
+		t.Errorf("expected %s first with clipboard_order, got %s", BackendWSL, b.Kind)
+	}
+
+	cfg.Defaults.ClipboardOrder = []string{"wayland", "pbcopy"}
+	if _, err := cfg.getClipboardOrder(); err == nil || !strings.Contains(err.Error(), "pbcopy") {
+		t.Errorf("expected error for unknown entry, got %v", err)
+	}
+}
+
+// Test Termux is detected by PREFIX and uses the termux-api commands
+func TestDetectLinuxTermux(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("PATH", dir)
+	t.Setenv("WSL_DISTRO_NAME", "")
+	t.Setenv("WAYLAND_DISPLAY", "")
+	t.Setenv("DISPLAY", "")
+	t.Setenv("PREFIX", "/data/data/com.termux/files/usr")
+
+	// Without termux-api installed the unknown backend says what to install
+	b := detectLinux(defaultLinuxBackendOrder)
+	if b.Kind != BackendUnknown || !strings.Contains(b.Notes, "pkg install termux-api") {
+		t.Errorf("expected an unknown backend with a termux-api hint, got %s: %q", b.Kind, b.Notes)
+	}
+
+	for _, tool := range []string{"termux-clipboard-get", "termux-clipboard-set"} {
+		if err := os.WriteFile(filepath.Join(dir, tool), []byte("#!/bin/sh\n"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	b = detectLinux(defaultLinuxBackendOrder)
+	if b.Kind != BackendTermux {
+		t.Fatalf("expected %s, got %s", BackendTermux, b.Kind)
+	}
+	if b.CopyCmd[0] != "termux-clipboard-set" || b.PasteCmd[0] != "termux-clipboard-get" {
+		t.Errorf("CopyCmd = %v, PasteCmd = %v", b.CopyCmd, b.PasteCmd)
+	}
+
+	// Elsewhere the tools alone don't make it Termux
+	t.Setenv("PREFIX", "")
+	if b := detectTermux(); b != nil {
+		t.Errorf("expected no Termux backend outside Termux, got %s", b.Kind)
+	}
+}
+
+// Test WSL detection needs the release string or WSL_DISTRO_NAME
+func TestIsWSL(t *testing.T) {
+	dir := t.TempDir()
+	release := filepath.Join(dir, "osrelease")
+	_ = os.WriteFile(release, []byte("6.8.0-generic\n"), 0644)
+	origRelease := wslReleasePath
+	wslReleasePath = release
+	defer func() { wslReleasePath = origRelease }()
+	t.Setenv("WSL_DISTRO_NAME", "")
+	t.Setenv("DISPLAY", ":0")
+
+	if isWSL() || isWSLg() {
+		t.Error("plain Linux should not be detected as WSL")
+	}
+	t.Setenv("WSL_DISTRO_NAME", "Ubuntu")
+	if !isWSL() || !isWSLg() {
+		t.Error("WSL_DISTRO_NAME with DISPLAY should be detected as WSLg")
+	}
+}
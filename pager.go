@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"strings"
+	"unicode/utf8"
+)
+
+// pagerMode selects whether stdout output goes through the pager
+type pagerMode int
+
+const (
+	pagerAuto   pagerMode = iota // page text taller than the terminal
+	pagerAlways                  // --pager: page any text
+	pagerNever                   // --no-pager
+)
+
+const (
+	defaultPager         = "less -R"
+	defaultTerminalLines = 24 // used when the terminal height is unknown
+)
+
+// parsePagerFlag updates mode for --pager/--no-pager and reports whether
+// arg was a pager flag
+func parsePagerFlag(arg string, mode *pagerMode) bool {
+	switch arg {
+	case "--pager":
+		*mode = pagerAlways
+	case "--no-pager":
+		*mode = pagerNever
+	default:
+		return false
+	}
+	return true
+}
+
+// pagerCommand returns the pager to run: defaults.pager, then $PAGER,
+// then "less -R"
+func pagerCommand() []string {
+	if cfg, err := loadConfigForAliases(); err == nil && cfg.Defaults != nil && cfg.Defaults.Pager != "" {
+		return strings.Fields(cfg.Defaults.Pager)
+	}
+	if p := os.Getenv("PAGER"); p != "" {
+		return strings.Fields(p)
+	}
+	return strings.Fields(defaultPager)
+}
+
+// shouldPage reports whether data should be shown through the pager.
+// Only text is paged, and only when stdout is a terminal.
+func shouldPage(data []byte, mode pagerMode) bool {
+	if mode == pagerNever || !stdoutIsTerminal() {
+		return false
+	}
+	if !utf8.Valid(data) || bytes.IndexByte(data, 0) >= 0 {
+		return false
+	}
+	if mode == pagerAlways {
+		return true
+	}
+	rows := terminalHeight()
+	if rows <= 0 {
+		rows = defaultTerminalLines
+	}
+	return bytes.Count(data, []byte("\n")) >= rows
+}
+
+// writePaged writes data to stdout, through the pager when appropriate.
+// If the pager cannot be started, output is written directly.
+func writePaged(data []byte, mode pagerMode) error {
+	if shouldPage(data, mode) {
+		cmd := pagerCommand()
+		if len(cmd) > 0 {
+			if _, err := exec.LookPath(cmd[0]); err == nil {
+				return runWithInput(cmd, data)
+			}
+			debugLog("pager %q not found, writing directly", cmd[0])
+		}
+	}
+	_, err := os.Stdout.Write(data)
+	return err
+}
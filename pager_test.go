@@ -0,0 +1,188 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// setupMockPager installs a PAGER script that records its input, and
+// simulates a terminal of the given height on stdout
+func setupMockPager(t *testing.T, tty bool, rows int) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("mock pager uses sh")
+	}
+	dir := t.TempDir()
+	out := filepath.Join(dir, "paged")
+	script := filepath.Join(dir, "pager")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\ncat > "+out+"\n"), 0755); err != nil {
+		t.Fatalf("writing mock pager: %v", err)
+	}
+	t.Setenv("PAGER", script)
+	t.Setenv("PIPEBOARD_CONFIG", filepath.Join(dir, "missing.yaml"))
+
+	origTTY, origHeight := stdoutIsTerminal, terminalHeight
+	stdoutIsTerminal = func() bool { return tty }
+	terminalHeight = func() int { return rows }
+	t.Cleanup(func() {
+		stdoutIsTerminal, terminalHeight = origTTY, origHeight
+	})
+	return out
+}
+
+func TestWritePagedLargeText(t *testing.T) {
+	paged := setupMockPager(t, true, 10)
+	data := []byte(strings.Repeat("line\n", 50))
+
+	var err error
+	stdout := captureOutput(func() {
+		err = writePaged(data, pagerAuto)
+	})
+	if err != nil {
+		t.Fatalf("writePaged: %v", err)
+	}
+	got, readErr := os.ReadFile(paged)
+	if readErr != nil {
+		t.Fatal("pager should have been invoked for large text")
+	}
+	if string(got) != string(data) {
+		t.Errorf("pager received %d bytes, want %d", len(got), len(data))
+	}
+	if stdout != "" {
+		t.Errorf("content should not also be written to stdout, got %d bytes", len(stdout))
+	}
+}
+
+func TestWritePagedBypass(t *testing.T) {
+	tests := []struct {
+		name string
+		tty  bool
+		mode pagerMode
+		data []byte
+	}{
+		{"short text", true, pagerAuto, []byte("one line\n")},
+		{"binary", true, pagerAlways, append([]byte{0x89, 'P', 'N', 'G', 0}, []byte(strings.Repeat("\n", 50))...)},
+		{"piped", false, pagerAlways, []byte(strings.Repeat("line\n", 50))},
+		{"no-pager", true, pagerNever, []byte(strings.Repeat("line\n", 50))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			paged := setupMockPager(t, tt.tty, 10)
+			stdout := captureOutput(func() {
+				if err := writePaged(tt.data, tt.mode); err != nil {
+					t.Errorf("writePaged: %v", err)
+				}
+			})
+			if _, err := os.Stat(paged); err == nil {
+				t.Error("pager should not have been invoked")
+			}
+			if stdout != string(tt.data) {
+				t.Errorf("expected content on stdout, got %d bytes", len(stdout))
+			}
+		})
+	}
+}
+
+func TestWritePagedForced(t *testing.T) {
+	paged := setupMockPager(t, true, 50)
+	captureOutput(func() {
+		if err := writePaged([]byte("short\n"), pagerAlways); err != nil {
+			t.Errorf("writePaged: %v", err)
+		}
+	})
+	if got, _ := os.ReadFile(paged); string(got) != "short\n" {
+		t.Errorf("--pager should page short text, pager got %q", got)
+	}
+}
+
+func TestWritePagedMissingPager(t *testing.T) {
+	setupMockPager(t, true, 10)
+	t.Setenv("PAGER", "/nonexistent/pager")
+	data := []byte(strings.Repeat("line\n", 50))
+	stdout := captureOutput(func() {
+		if err := writePaged(data, pagerAuto); err != nil {
+			t.Errorf("missing pager should fall back to stdout: %v", err)
+		}
+	})
+	if stdout != string(data) {
+		t.Errorf("expected fallback output on stdout, got %d bytes", len(stdout))
+	}
+}
+
+func TestPagerCommand(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("PIPEBOARD_CONFIG", filepath.Join(dir, "missing.yaml"))
+	t.Setenv("PAGER", "")
+	if got := strings.Join(pagerCommand(), " "); got != "less -R" {
+		t.Errorf("expected default pager, got %q", got)
+	}
+	t.Setenv("PAGER", "more -d")
+	if got := strings.Join(pagerCommand(), " "); got != "more -d" {
+		t.Errorf("expected $PAGER, got %q", got)
+	}
+
+	cfgPath := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(cfgPath, []byte("version: 1\ndefaults:\n  pager: most\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PIPEBOARD_CONFIG", cfgPath)
+	if got := strings.Join(pagerCommand(), " "); got != "most" {
+		t.Errorf("config pager should win over $PAGER, got %q", got)
+	}
+}
+
+func TestParsePagerFlag(t *testing.T) {
+	mode := pagerAuto
+	if !parsePagerFlag("--pager", &mode) || mode != pagerAlways {
+		t.Error("--pager should set pagerAlways")
+	}
+	if !parsePagerFlag("--no-pager", &mode) || mode != pagerNever {
+		t.Error("--no-pager should set pagerNever")
+	}
+	if parsePagerFlag("--qr", &mode) {
+		t.Error("--qr is not a pager flag")
+	}
+}
+
+// Test show pages a large text slot through the pager
+func TestCmdShowPager(t *testing.T) {
+	cleanup := setupSlotsTestConfig(t, `version: 1
+sync:
+  backend: local
+`)
+	defer cleanup()
+
+	paged := setupMockPager(t, true, 10)
+	t.Setenv("PIPEBOARD_CONFIG", "")
+
+	backend, err := newRemoteBackendFromConfig()
+	if err != nil {
+		t.Fatalf("backend: %v", err)
+	}
+	data := strings.Repeat("log entry\n", 40)
+	if err := backend.Push("log", []byte(data), map[string]string{}); err != nil {
+		t.Fatalf("push: %v", err)
+	}
+
+	captureOutput(func() {
+		if err := cmdShow([]string{"log"}); err != nil {
+			t.Errorf("show: %v", err)
+		}
+	})
+	if got, _ := os.ReadFile(paged); string(got) != data {
+		t.Error("show should page large text")
+	}
+
+	_ = os.Remove(paged)
+	out := captureOutput(func() {
+		if err := cmdShow([]string{"log", "--no-pager"}); err != nil {
+			t.Errorf("show --no-pager: %v", err)
+		}
+	})
+	if out != data {
+		t.Error("show --no-pager should write to stdout")
+	}
+}
@@ -1,137 +1,876 @@
 package main
 
 import (
+	"bufio"
 	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 )
 
 func cmdSend(args []string) error {
+	args, flags, err := parsePeerFlags(args)
+	var res peerResult
+	if err == nil {
+		res, err = sendToPeer(args, flags)
+	}
+	if flags.json {
+		return writePeerResult(res, err)
+	}
+	return err
+}
+
+func sendToPeer(args []string, flags peerFlags) (res peerResult, err error) {
 	cfg, err := loadConfigForPeers()
 	if err != nil {
-		return err
+		return res, err
 	}
 
 	var peerName string
 	if len(args) == 0 {
 		peerName, err = cfg.getDefaultPeer()
 		if err != nil {
-			return fmt.Errorf("usage: pipeboard send [peer]\n%w", err)
+			return res, fmt.Errorf("usage: pipeboard send [peer] [--slot <name>] [--json] [--dry-run] [--confirm]\n%w", err)
 		}
 	} else if len(args) == 1 {
 		peerName = args[0]
 	} else {
-		return fmt.Errorf("usage: pipeboard send [peer]")
+		return res, fmt.Errorf("usage: pipeboard send [peer] [--slot <name>] [--json] [--dry-run] [--confirm]")
+	}
+	if flags.slot != "" && flags.confirm {
+		return res, errors.New("--confirm checks the peer clipboard and can't be combined with --slot")
 	}
+	res.Peer, res.Slot = peerName, flags.slot
+	defer func() {
+		if !res.DryRun {
+			recordAudit(AuditRecord{Op: "send", Peer: peerName, Slot: flags.slot, Size: int64(res.Bytes)}, err)
+		}
+	}()
 
 	peer, err := cfg.getPeer(peerName)
 	if err != nil {
-		return err
+		return res, err
 	}
 
 	data, err := readClipboard()
 	if err != nil {
-		return err
+		return res, err
 	}
+	res.Bytes = len(data)
+	res.MIME = detectMIME(data)
 
-	sshTarget := peer.SSH
-	remoteCmd := peer.RemoteCmd
+	// Framed transfers carry their own packing, so the peer unpacks them
+	// whatever its own settings. With --slot the peer pushes what it
+	// reads to its own sync backend instead of copying it.
+	payload := data
+	framed := peer.framed() && flags.slot == ""
+	argv := peerCommand(peer, "copy")
+	if flags.slot != "" {
+		argv = peerCommand(peer, "push", flags.slot, "--stdin")
+	} else if framed {
+		argv = peerCommand(peer, "copy", "--framed")
+	}
+	if flags.dryRun {
+		res.DryRun, res.Command = true, argv
+		if !flags.json {
+			fmt.Printf("would run: %s (%s from clipboard on stdin)\n", strings.Join(argv, " "), formatSize(int64(len(data))))
+		}
+		return res, nil
+	}
+
+	if framed {
+		opts, err := peer.frameOptions()
+		if err != nil {
+			return res, err
+		}
+		if payload, err = encodePeerFrame(data, opts); err != nil {
+			return res, err
+		}
+		debugLog("framed %d bytes as %d for peer %q", len(data), len(payload), peerName)
+	}
 
-	cmd := exec.Command("ssh", sshTarget, remoteCmd, "copy")
-	cmd.Stdin = bytes.NewReader(data)
+	sshTarget := peer.SSH
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Stdin = bytes.NewReader(payload)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
+	if flags.json {
+		cmd.Stdout = nil // keep stdout valid JSON
+	}
 
 	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to send to peer %q (%s): %w", peerName, sshTarget, err)
+		return res, fmt.Errorf("failed to send to peer %q (%s): %w", peerName, sshTarget, err)
+	}
+	if flags.slot != "" {
+		if !flags.json {
+			printInfo("sent %s to slot %q on peer %q (%s)\n", formatSize(int64(len(data))), flags.slot, peerName, sshTarget)
+		}
+		recordHistory("send", peerName, int64(len(data)))
+		return res, nil
+	}
+	// The peer's clipboard now holds what we sent
+	removePeerCache(peer)
+
+	if flags.confirm {
+		confirmed, err := confirmPeerReceived(peerName, peer, data)
+		if err != nil {
+			return res, err
+		}
+		res.Confirmed = confirmed
 	}
 
-	printInfo("sent %s to peer %q (%s)\n", formatSize(int64(len(data))), peerName, sshTarget)
+	if !flags.json {
+		if res.Confirmed {
+			printInfo("sent %s to peer %q (%s), confirmed\n", formatSize(int64(len(data))), peerName, sshTarget)
+		} else {
+			printInfo("sent %s to peer %q (%s)\n", formatSize(int64(len(data))), peerName, sshTarget)
+		}
+	}
 	recordHistory("send", peerName, int64(len(data)))
-	return nil
+	return res, nil
 }
 
 func cmdRecv(args []string) error {
+	args, flags, err := parsePeerFlags(args)
+	var res peerResult
+	if err == nil {
+		res, err = recvFromPeer(args, flags)
+	}
+	if flags.json {
+		return writePeerResult(res, err)
+	}
+	return err
+}
+
+func recvFromPeer(args []string, flags peerFlags) (peerResult, error) {
+	var res peerResult
 	cfg, err := loadConfigForPeers()
 	if err != nil {
-		return err
+		return res, err
 	}
 
 	var peerName string
 	if len(args) == 0 {
 		peerName, err = cfg.getDefaultPeer()
 		if err != nil {
-			return fmt.Errorf("usage: pipeboard recv [peer]\n%w", err)
+			return res, fmt.Errorf("usage: pipeboard recv [peer] [--slot <name>] [--yes] [--json] [--fresh] [--dry-run] [--allow-empty] [--raw]\n%w", err)
 		}
 	} else if len(args) == 1 {
 		peerName = args[0]
 	} else {
-		return fmt.Errorf("usage: pipeboard recv [peer]")
+		return res, fmt.Errorf("usage: pipeboard recv [peer] [--slot <name>] [--yes] [--json] [--fresh] [--dry-run] [--allow-empty] [--raw]")
+	}
+	res.Peer, res.Slot = peerName, flags.slot
+	if flags.raw && flags.json {
+		return res, errors.New("--raw prints the content to stdout and can't be combined with --json")
 	}
 
 	peer, err := cfg.getPeer(peerName)
 	if err != nil {
-		return err
+		return res, err
 	}
 
-	sshTarget := peer.SSH
-	remoteCmd := peer.RemoteCmd
+	if flags.dryRun {
+		return peerDryRun(res, peer, flags), nil
+	}
 
-	var out bytes.Buffer
-	cmd := exec.Command("ssh", sshTarget, remoteCmd, "paste")
-	cmd.Stdin = nil
-	cmd.Stdout = &out
-	cmd.Stderr = os.Stderr
+	what := "clipboard"
+	if flags.slot != "" {
+		what = fmt.Sprintf("slot %q", flags.slot)
+	}
+	// Fail before the transfer when there's no clipboard to write to
+	if !flags.raw {
+		if err := checkClipboardWritable(); err != nil {
+			return res, fmt.Errorf("%w\n       Use --raw to print the peer's %s to stdout instead", err, what)
+		}
+	}
 
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to receive from peer %q (%s): %w", peerName, sshTarget, err)
+	var data []byte
+	if flags.slot != "" {
+		data, err = fetchPeerSlot(peerName, peer, flags.slot)
+	} else {
+		data, err = fetchPeerClipboard(cfg, peerName, peer, flags, "receive from")
 	}
+	if err != nil {
+		return res, err
+	}
+	res.Bytes = len(data)
+	res.MIME = detectMIME(data)
 
-	if err := writeClipboard(out.Bytes()); err != nil {
-		return err
+	if flags.raw {
+		if _, err := os.Stdout.Write(data); err != nil {
+			return res, err
+		}
+		recordHistory("recv", peerName, int64(len(data)))
+		return res, nil
 	}
 
-	printInfo("received %s from peer %q (%s)\n", formatSize(int64(out.Len())), peerName, sshTarget)
-	recordHistory("recv", peerName, int64(out.Len()))
-	return nil
+	// An empty transfer would silently clear the local clipboard
+	if len(data) == 0 && !flags.allowEmpty {
+		return res, fmt.Errorf("peer %q %s is empty; local clipboard unchanged (use --allow-empty to clear it)", peerName, what)
+	}
+
+	if err := writeClipboard(data); err != nil {
+		return res, err
+	}
+
+	if !flags.json {
+		if flags.slot != "" {
+			printInfo("received %s from slot %q on peer %q (%s)\n", formatSize(int64(len(data))), flags.slot, peerName, peer.SSH)
+		} else {
+			printInfo("received %s from peer %q (%s)\n", formatSize(int64(len(data))), peerName, peer.SSH)
+		}
+	}
+	recordHistory("recv", peerName, int64(len(data)))
+	return res, nil
 }
 
 func cmdPeek(args []string) error {
+	args, flags, err := parsePeerFlags(args)
+	var res peerResult
+	if err == nil {
+		res, err = peekAtPeer(args, flags)
+	}
+	if flags.json {
+		return writePeerResult(res, err)
+	}
+	return err
+}
+
+func peekAtPeer(args []string, flags peerFlags) (peerResult, error) {
+	var res peerResult
 	cfg, err := loadConfigForPeers()
 	if err != nil {
-		return err
+		return res, err
 	}
 
 	var peerName string
 	if len(args) == 0 {
 		peerName, err = cfg.getDefaultPeer()
 		if err != nil {
-			return fmt.Errorf("usage: pipeboard peek [peer]\n%w", err)
+			return res, fmt.Errorf("usage: pipeboard peek [peer] [--yes] [--json] [--fresh] [--dry-run] [--raw]\n%w", err)
 		}
 	} else if len(args) == 1 {
 		peerName = args[0]
 	} else {
-		return fmt.Errorf("usage: pipeboard peek [peer]")
+		return res, fmt.Errorf("usage: pipeboard peek [peer] [--yes] [--json] [--fresh] [--dry-run] [--raw]")
+	}
+	res.Peer = peerName
+	if flags.slot != "" {
+		return res, errors.New("--slot is only supported by send and recv")
+	}
+	if flags.raw && flags.json {
+		return res, errors.New("--raw prints the content to stdout and can't be combined with --json")
 	}
 
 	peer, err := cfg.getPeer(peerName)
 	if err != nil {
-		return err
+		return res, err
 	}
 
-	sshTarget := peer.SSH
-	remoteCmd := peer.RemoteCmd
+	if flags.dryRun {
+		return peerDryRun(res, peer, flags), nil
+	}
+
+	data, err := fetchPeerClipboard(cfg, peerName, peer, flags, "peek from")
+	if err != nil {
+		return res, err
+	}
+
+	// With --json the content goes into the result instead of stdout
+	if flags.json {
+		res.Bytes = len(data)
+		res.MIME = detectMIME(data)
+		res.DataB64 = base64.StdEncoding.EncodeToString(data)
+	} else if _, err := os.Stdout.Write(data); err != nil {
+		return res, err
+	}
+
+	recordHistory("peek", peerName, 0)
+	return res, nil
+}
+
+// peerCommand returns the argv that runs "pipeboard <op> [args]" on peer
+// over ssh. ssh joins its arguments into one line for the remote shell, so
+// op and args are quoted; remote_cmd is config and is passed as written.
+func peerCommand(peer PeerConfig, op string, args ...string) []string {
+	remote := []string{peer.RemoteCmd, shellQuote(op)}
+	for _, arg := range args {
+		remote = append(remote, shellQuote(arg))
+	}
+	return append([]string{"ssh", peer.SSH}, remote...)
+}
 
-	cmd := exec.Command("ssh", sshTarget, remoteCmd, "paste")
+// shellQuote quotes s as a single word for a POSIX shell. Words made only
+// of characters the shell doesn't interpret are left as they are.
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_-./:=@%+,") == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// peerPasteCommand returns the argv that fetches the peer's clipboard,
+// framed when the peer has compress or encrypt set
+func peerPasteCommand(peer PeerConfig) []string {
+	if peer.framed() {
+		return peerCommand(peer, "paste", peer.frameFlags()...)
+	}
+	return peerCommand(peer, "paste")
+}
+
+// fetchPeerSlot runs "pipeboard show <slot>" on the peer for recv --slot.
+// show has written the raw slot to stdout in every release, so the
+// transfer doesn't depend on how the peer's pull treats a pipe. The peer's
+// version is checked first, so a remote_cmd that isn't pipeboard fails
+// before anything is written. The peer cache and size check are for
+// clipboards and don't apply.
+func fetchPeerSlot(peerName string, peer PeerConfig, slot string) ([]byte, error) {
+	if _, err := peerVersion(peerName, peer); err != nil {
+		return nil, err
+	}
+	argv := peerCommand(peer, "show", slot)
+	var out bytes.Buffer
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Stdout = &out
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("failed to receive slot %q from peer %q (%s): %w", slot, peerName, peer.SSH, err)
+	}
+	return out.Bytes(), nil
+}
+
+// peerVersion runs "pipeboard version" on the peer and returns the version
+// it reports, e.g. "0.8.0" or "dev". Unlike probePeer, ssh may prompt.
+func peerVersion(peerName string, peer PeerConfig) (string, error) {
+	argv := peerCommand(peer, "version")
+	var out bytes.Buffer
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Stdout = &out
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("failed to get the pipeboard version of peer %q (%s): %w", peerName, peer.SSH, err)
+	}
+	version, ok := strings.CutPrefix(strings.TrimSpace(out.String()), "pipeboard ")
+	if !ok || version == "" {
+		return "", fmt.Errorf("peer %q (%s) didn't report a pipeboard version; check its remote_cmd", peerName, peer.SSH)
+	}
+	return version, nil
+}
+
+// peerDryRun fills in res for recv/peek --dry-run and prints the ssh
+// command that would fetch the peer clipboard (or slot, with --slot).
+// Nothing is run, including the size query.
+func peerDryRun(res peerResult, peer PeerConfig, flags peerFlags) peerResult {
+	res.DryRun, res.Command = true, peerPasteCommand(peer)
+	if flags.slot != "" {
+		res.Command = peerCommand(peer, "show", flags.slot)
+	}
+	if !flags.json {
+		fmt.Printf("would run: %s\n", strings.Join(res.Command, " "))
+	}
+	return res
+}
+
+// peerFlags holds the flags shared by send, recv and peek
+type peerFlags struct {
+	yes    bool // --yes: skip the large transfer confirmation
+	json   bool // --json: print a peerResult instead of human output
+	fresh  bool // --fresh: ignore the peer cache (recv/peek)
+	dryRun bool // --dry-run: print the ssh command instead of running it
+
+	allowEmpty bool   // --allow-empty: let recv clear the clipboard with empty content
+	raw        bool   // --raw: recv prints to stdout instead of the clipboard, like peek
+	confirm    bool   // --confirm: check the peer stored what send sent
+	slot       string // --slot: push to / pull from this slot on the peer (send/recv)
+}
+
+// parsePeerFlags separates the shared flags from positional arguments
+func parsePeerFlags(args []string) ([]string, peerFlags, error) {
+	var positional []string
+	var flags peerFlags
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; arg {
+		case "--yes", "-y":
+			flags.yes = true
+		case "--json":
+			flags.json = true
+		case "--fresh":
+			flags.fresh = true
+		case "--dry-run", "-n":
+			flags.dryRun = true
+		case "--allow-empty":
+			flags.allowEmpty = true
+		case "--raw":
+			flags.raw = true
+		case "--confirm":
+			flags.confirm = true
+		case "--slot":
+			if i+1 >= len(args) || args[i+1] == "" {
+				return nil, flags, errors.New("--slot requires a slot name")
+			}
+			i++
+			flags.slot = args[i]
+		default:
+			positional = append(positional, arg)
+		}
+	}
+	return positional, flags, nil
+}
+
+// peerResult is the --json output of send, recv and peek
+type peerResult struct {
+	Peer    string   `json:"peer"`
+	Bytes   int      `json:"bytes"`
+	MIME    string   `json:"mime,omitempty"`
+	OK      bool     `json:"ok"`
+	Error   string   `json:"error,omitempty"`
+	DataB64 string   `json:"data_b64,omitempty"` // peek only
+	DryRun  bool     `json:"dry_run,omitempty"`
+	Command []string `json:"command,omitempty"` // ssh argv, with --dry-run
+
+	Confirmed bool   `json:"confirmed,omitempty"` // send --confirm
+	Slot      string `json:"slot,omitempty"`      // send/recv --slot
+}
+
+// writePeerResult prints res as JSON with ok/error set from err. err is
+// returned unchanged so the exit status still reports the failure.
+func writePeerResult(res peerResult, err error) error {
+	res.OK = err == nil
+	if err != nil {
+		res.Error = err.Error()
+	}
+	out, jsonErr := marshalJSONOutput(res)
+	if jsonErr != nil {
+		return jsonErr
+	}
+	fmt.Println(string(out))
+	return err
+}
+
+// fetchPeerClipboard returns the peer's clipboard for recv/peek. A copy
+// fetched within defaults.peer_cache_ttl is reused, so a recv right after
+// a peek doesn't transfer the same content twice. verb names the
+// operation in errors ("receive from", "peek from").
+func fetchPeerClipboard(cfg *Config, peerName string, peer PeerConfig, flags peerFlags, verb string) ([]byte, error) {
+	ttl, err := cfg.getPeerCacheTTL()
+	if err != nil {
+		return nil, err
+	}
+	if ttl > 0 && !flags.fresh {
+		if data, ok := readPeerCache(peer, ttl); ok {
+			debugLog("peer %q: using clipboard fetched within %s", peerName, ttl)
+			return data, nil
+		}
+	}
+
+	if err := checkPeerSize(cfg, peerName, peer, flags.yes); err != nil {
+		return nil, err
+	}
+
+	argv := peerPasteCommand(peer)
+	var out bytes.Buffer
+	cmd := exec.Command(argv[0], argv[1:]...)
 	cmd.Stdin = nil
-	cmd.Stdout = os.Stdout
+	cmd.Stdout = &out
 	cmd.Stderr = os.Stderr
 
 	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to peek from peer %q (%s): %w", peerName, sshTarget, err)
+		return nil, fmt.Errorf("failed to %s peer %q (%s): %w", verb, peerName, peer.SSH, err)
+	}
+	data := out.Bytes()
+	if peer.framed() {
+		var hdr peerFrameHeader
+		var err error
+		if data, hdr, err = decodePeerFrame(data, peerPassphrase); err != nil {
+			return nil, fmt.Errorf("failed to %s peer %q: %w", verb, peerName, err)
+		}
+		debugLog("peer %q sent %d bytes framed (compressed=%t, encrypted=%t)", peerName, hdr.Len, hdr.Compressed, hdr.Encrypted)
 	}
+	if ttl > 0 {
+		writePeerCache(peer, data, ttl)
+	}
+	return data, nil
+}
 
-	recordHistory("peek", peerName, 0)
+// getPeerCacheDir returns the directory for recently fetched peer
+// clipboards. It is under the user cache directory rather than the
+// config directory, which tends to be synced and backed up.
+func getPeerCacheDir() string {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(cacheDir, "pipeboard", "peer-cache")
+}
+
+// legacyPeerCacheDir is where earlier releases kept the peer cache
+func legacyPeerCacheDir() string {
+	configDir := os.Getenv("XDG_CONFIG_HOME")
+	if configDir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		configDir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(configDir, "pipeboard", "peer-cache")
+}
+
+// peerCachePath returns the cache file for a peer. The key covers the
+// host and remote command, so editing a peer's config bypasses old entries.
+func peerCachePath(peer PeerConfig) string {
+	dir := getPeerCacheDir()
+	if dir == "" {
+		return ""
+	}
+	h := sha256.Sum256([]byte(peer.SSH + "\x00" + peer.RemoteCmd))
+	return filepath.Join(dir, hex.EncodeToString(h[:]))
+}
+
+// readPeerCache returns the cached clipboard for peer if it is younger
+// than ttl. Expired entries are removed.
+func readPeerCache(peer PeerConfig, ttl time.Duration) ([]byte, bool) {
+	path := peerCachePath(peer)
+	if path == "" {
+		return nil, false
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, false
+	}
+	if time.Since(info.ModTime()) > ttl {
+		_ = os.Remove(path)
+		return nil, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// writePeerCache stores a fetched clipboard and removes entries older
+// than ttl, so clipboards from peers that aren't fetched again don't
+// linger on disk. Caching is best-effort; failures never affect the
+// command.
+func writePeerCache(peer PeerConfig, data []byte, ttl time.Duration) {
+	path := peerCachePath(peer)
+	if path == "" {
+		return
+	}
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		debugLog("failed to write peer cache: %v", err)
+	}
+	prunePeerCache(dir, ttl)
+}
+
+// prunePeerCache removes cache entries older than ttl, along with any
+// cache left in the legacy location
+func prunePeerCache(dir string, ttl time.Duration) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || time.Since(info.ModTime()) <= ttl {
+			continue
+		}
+		_ = os.Remove(filepath.Join(dir, entry.Name()))
+	}
+	if legacy := legacyPeerCacheDir(); legacy != "" && legacy != dir {
+		_ = os.RemoveAll(legacy)
+	}
+}
+
+// removePeerCache drops the cached clipboard for peer
+func removePeerCache(peer PeerConfig) {
+	if path := peerCachePath(peer); path != "" {
+		_ = os.Remove(path)
+	}
+}
+
+// readRemoteClipboardSize asks a peer for its clipboard size without
+// transferring the contents
+func readRemoteClipboardSize(peer PeerConfig) (int64, error) {
+	var out bytes.Buffer
+	argv := peerCommand(peer, "paste", "--size")
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Stdin = nil
+	cmd.Stdout = &out
+	cmd.Stderr = nil
+
+	if err := cmd.Run(); err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(strings.TrimSpace(out.String()), 10, 64)
+}
+
+// clipboardDigest identifies clipboard content in the send --confirm
+// handshake, as printed by paste --hash
+func clipboardDigest(data []byte) string {
+	return "sha256:" + contentHash(data, "")
+}
+
+// confirmPeerReceived asks the peer for the hash of its clipboard after a
+// send and fails when it isn't the hash of what was sent. Peers whose
+// pipeboard predates paste --hash are skipped with a warning; it returns
+// whether the transfer was confirmed.
+func confirmPeerReceived(peerName string, peer PeerConfig, data []byte) (bool, error) {
+	var out, stderr bytes.Buffer
+	argv := peerCommand(peer, "paste", "--hash")
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if strings.Contains(stderr.String(), "unknown argument") {
+			fmt.Fprintf(os.Stderr, "warning: peer %q can't confirm the transfer (its pipeboard has no paste --hash); skipped\n", peerName)
+			return false, nil
+		}
+		return false, fmt.Errorf("sent to peer %q but could not confirm it: %w", peerName, err)
+	}
+
+	want := clipboardDigest(data)
+	got := strings.TrimSpace(out.String())
+	if got != want {
+		return false, fmt.Errorf("peer %q stored different content than was sent (sent %s, peer has %s); send again", peerName, want, got)
+	}
+	debugLog("send to %q confirmed: %s", peerName, want)
+	return true, nil
+}
+
+// confirmTransfer asks the user whether to continue a large transfer.
+// Prompts on stderr so peek output on stdout stays clean.
+var confirmTransfer = func(prompt string) bool {
+	fmt.Fprintf(os.Stderr, "%s [y/N]: ", prompt)
+	reader := bufio.NewReader(os.Stdin)
+	input, _ := reader.ReadString('\n')
+	input = strings.TrimSpace(strings.ToLower(input))
+	return input == "y" || input == "yes"
+}
+
+// checkPeerSize queries the peer's clipboard size and requires confirmation
+// before transferring anything larger than defaults.peer_warn_size.
+// Without a TTY (or in quiet mode) large transfers are declined unless --yes.
+func checkPeerSize(cfg *Config, peerName string, peer PeerConfig, assumeYes bool) error {
+	threshold := cfg.getPeerWarnSize()
+	if threshold == 0 || assumeYes {
+		return nil
+	}
+
+	size, err := readRemoteClipboardSize(peer)
+	if err != nil {
+		// Older peers don't support the size query; skip the guard
+		debugLog("peer size query failed, skipping size check: %v", err)
+		return nil
+	}
+	debugLog("peer %q clipboard size: %d bytes (threshold %d)", peerName, size, threshold)
+	if size <= threshold {
+		return nil
+	}
+
+	if quietMode || !stdinIsTerminal() {
+		return fmt.Errorf("peer %q clipboard is %s (over %s limit); use --yes to transfer anyway",
+			peerName, formatSize(size), formatSize(threshold))
+	}
+	if !confirmTransfer(fmt.Sprintf("Peer %q clipboard is %s. Transfer anyway?", peerName, formatSize(size))) {
+		return errors.New("transfer cancelled")
+	}
+	return nil
+}
+
+// defaultPeerCheckTimeout bounds each peers --check probe
+const defaultPeerCheckTimeout = 5 * time.Second
+
+// peerStatus is one row of the peers listing
+type peerStatus struct {
+	Name      string `json:"name"`
+	SSH       string `json:"ssh"`
+	Default   bool   `json:"default,omitempty"`
+	Checked   bool   `json:"checked,omitempty"`
+	Reachable bool   `json:"reachable,omitempty"`
+	Version   string `json:"version,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+func cmdPeers(args []string) error {
+	const usage = "usage: pipeboard peers [--check [--timeout <duration>]] [--json]"
+	var check, jsonOutput bool
+	timeout := defaultPeerCheckTimeout
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; arg {
+		case "--check":
+			check = true
+		case "--json":
+			jsonOutput = true
+		case "--timeout":
+			if i+1 >= len(args) {
+				return fmt.Errorf("--timeout requires a duration\n%s", usage)
+			}
+			i++
+			d, err := time.ParseDuration(args[i])
+			if err != nil || d <= 0 {
+				return fmt.Errorf("invalid --timeout %q (e.g. 3s)", args[i])
+			}
+			timeout = d
+		default:
+			return errors.New(usage)
+		}
+	}
+
+	cfg, err := loadConfigForPeers()
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(cfg.Peers))
+	for name := range cfg.Peers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	defaultPeer := ""
+	if cfg.Defaults != nil {
+		defaultPeer = cfg.Defaults.Peer
+	}
+	statuses := make([]peerStatus, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		st := &statuses[i]
+		st.Name, st.Default = name, name == defaultPeer
+		peer, err := cfg.getPeer(name)
+		if err != nil {
+			st.SSH, st.Error = cfg.Peers[name].SSH, err.Error()
+			continue
+		}
+		st.SSH = peer.SSH
+		if !check {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			version, err := probePeer(peer, timeout)
+			st.Checked = true
+			if err != nil {
+				st.Error = err.Error()
+				return
+			}
+			st.Reachable, st.Version = true, version
+		}()
+	}
+	wg.Wait()
+
+	if jsonOutput {
+		out, err := marshalJSONOutput(statuses)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+	} else {
+		printPeersTable(statuses, check)
+	}
+
+	failed := 0
+	for _, st := range statuses {
+		if st.Error != "" {
+			failed++
+		}
+	}
+	if failed > 0 {
+		if check {
+			return fmt.Errorf("%d of %d peers unreachable", failed, len(statuses))
+		}
+		return fmt.Errorf("%d of %d peers misconfigured", failed, len(statuses))
+	}
 	return nil
 }
+
+// probePeer runs the peer's pipeboard version over ssh and returns the
+// reported version. BatchMode keeps a password prompt from stalling the
+// check.
+func probePeer(peer PeerConfig, timeout time.Duration) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "ssh", "-o", "BatchMode=yes", peer.SSH, peer.RemoteCmd, "version")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	cmd.WaitDelay = time.Second
+	err := cmd.Run()
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return "", fmt.Errorf("timed out after %s", timeout)
+	}
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%v: %s", err, lastLine(msg))
+		}
+		return "", err
+	}
+	return strings.TrimPrefix(strings.TrimSpace(stdout.String()), "pipeboard "), nil
+}
+
+// lastLine returns the final line of s, where ssh puts its error
+func lastLine(s string) string {
+	if i := strings.LastIndexByte(s, '\n'); i >= 0 {
+		return s[i+1:]
+	}
+	return s
+}
+
+func printPeersTable(statuses []peerStatus, check bool) {
+	if len(statuses) == 0 {
+		printInfo("no peers configured\n")
+		return
+	}
+	nameWidth, sshWidth := len("PEER"), len("SSH")
+	for _, st := range statuses {
+		nameWidth = max(nameWidth, len(st.Name)+2) // room for " *"
+		sshWidth = max(sshWidth, len(st.SSH))
+	}
+
+	if check {
+		fmt.Printf("%-*s  %-*s  %-12s  %s\n", nameWidth, "PEER", sshWidth, "SSH", "STATUS", "VERSION")
+	} else {
+		fmt.Printf("%-*s  %-*s  %s\n", nameWidth, "PEER", sshWidth, "SSH", "STATUS")
+	}
+	hasDefault := false
+	for _, st := range statuses {
+		name := st.Name
+		if st.Default {
+			name += " *"
+			hasDefault = true
+		}
+		status, detail := "ok", ""
+		switch {
+		case st.Reachable:
+			status, detail = "reachable", st.Version
+		case st.Checked:
+			status, detail = "unreachable", st.Error
+		case st.Error != "":
+			status, detail = "invalid", st.Error
+		}
+		line := fmt.Sprintf("%-*s  %-*s  %-12s  %s", nameWidth, name, sshWidth, st.SSH, status, detail)
+		fmt.Println(strings.TrimRight(line, " "))
+	}
+	if hasDefault {
+		printInfo("\n* default peer\n")
+	}
+}
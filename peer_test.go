@@ -1,9 +1,15 @@
 package main
 
 import (
+	"encoding/base64"
+	"encoding/json"
 	"os"
+	"path/filepath"
+	"runtime"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 // Helper to set up test config environment
@@ -11,8 +17,10 @@ func setupPeerTestConfig(t *testing.T, configContent string) func() {
 	t.Helper()
 	tmpDir := t.TempDir()
 	origXDG := os.Getenv("XDG_CONFIG_HOME")
+	origCache := os.Getenv("XDG_CACHE_HOME")
 
 	_ = os.Setenv("XDG_CONFIG_HOME", tmpDir)
+	_ = os.Setenv("XDG_CACHE_HOME", filepath.Join(tmpDir, "cache"))
 
 	if configContent != "" {
 		configDir := tmpDir + "/pipeboard"
@@ -26,6 +34,11 @@ func setupPeerTestConfig(t *testing.T, configContent string) func() {
 		} else {
 			_ = os.Unsetenv("XDG_CONFIG_HOME")
 		}
+		if origCache != "" {
+			_ = os.Setenv("XDG_CACHE_HOME", origCache)
+		} else {
+			_ = os.Unsetenv("XDG_CACHE_HOME")
+		}
 	}
 }
 
@@ -332,3 +345,975 @@ func TestCmdPeekNoConfigFile(t *testing.T) {
 		t.Error("cmdPeek should error when no config file exists")
 	}
 }
+
+// createMockSSHWithSize creates a mock ssh that answers the size query
+// with the given byte count and otherwise prints content
+func createMockSSHWithSize(t *testing.T, size, content string) string {
+	t.Helper()
+	tmpDir := t.TempDir()
+	script := "#!/bin/sh\n" +
+		"for arg in \"$@\"; do\n" +
+		"  if [ \"$arg\" = \"--size\" ]; then echo '" + size + "'; exit 0; fi\n" +
+		"done\n" +
+		"echo '" + content + "'\n"
+	if err := os.WriteFile(tmpDir+"/ssh", []byte(script), 0755); err != nil {
+		t.Fatalf("failed to create mock ssh: %v", err)
+	}
+	return tmpDir
+}
+
+// setupPeerSizeTest installs a mock ssh and a config with a single peer
+func setupPeerSizeTest(t *testing.T, size, extraDefaults string) func() {
+	t.Helper()
+	mockDir := createMockSSHWithSize(t, size, "peer data")
+	cleanup := setupPeerTestConfig(t, `version: 1
+defaults:
+  peer: dev
+`+extraDefaults+`
+peers:
+  dev:
+    ssh: user@host
+`)
+	origPath := os.Getenv("PATH")
+	_ = os.Setenv("PATH", mockDir+":"+origPath)
+	return func() {
+		_ = os.Setenv("PATH", origPath)
+		cleanup()
+	}
+}
+
+// Test cmdPeek declines a large clipboard without a TTY
+func TestCmdPeekLargeSizeDeclinedWithoutTTY(t *testing.T) {
+	cleanup := setupPeerSizeTest(t, "5000000", "")
+	defer cleanup()
+
+	origTTY := stdinIsTerminal
+	defer func() { stdinIsTerminal = origTTY }()
+	stdinIsTerminal = func() bool { return false }
+
+	err := cmdPeek([]string{})
+	if err == nil {
+		t.Fatal("cmdPeek should refuse a large clipboard without confirmation")
+	}
+	if !strings.Contains(err.Error(), "--yes") {
+		t.Errorf("error should mention --yes: %v", err)
+	}
+}
+
+// Test cmdPeek --yes bypasses the size guard
+func TestCmdPeekLargeSizeWithYes(t *testing.T) {
+	cleanup := setupPeerSizeTest(t, "5000000", "")
+	defer cleanup()
+
+	var err error
+	captureOutput(func() {
+		err = cmdPeek([]string{"--yes"})
+	})
+	if err != nil {
+		t.Errorf("cmdPeek --yes should skip the size guard: %v", err)
+	}
+}
+
+// Test cmdRecv declines a large clipboard in quiet mode even on a TTY
+func TestCmdRecvLargeSizeDeclinedInQuietMode(t *testing.T) {
+	cleanup := setupPeerSizeTest(t, "5000000", "")
+	defer cleanup()
+
+	origTTY := stdinIsTerminal
+	origQuiet := quietMode
+	defer func() {
+		stdinIsTerminal = origTTY
+		quietMode = origQuiet
+	}()
+	stdinIsTerminal = func() bool { return true }
+	quietMode = true
+
+	err := cmdRecv([]string{"dev"})
+	if err == nil || !strings.Contains(err.Error(), "--yes") {
+		t.Errorf("cmdRecv should refuse a large clipboard in quiet mode: %v", err)
+	}
+}
+
+// Test the confirmation prompt gates the transfer on a TTY
+func TestCmdPeekLargeSizeConfirmation(t *testing.T) {
+	cleanup := setupPeerSizeTest(t, "5000000", "")
+	defer cleanup()
+
+	origTTY := stdinIsTerminal
+	origConfirm := confirmTransfer
+	defer func() {
+		stdinIsTerminal = origTTY
+		confirmTransfer = origConfirm
+	}()
+	stdinIsTerminal = func() bool { return true }
+
+	var prompted string
+	confirmTransfer = func(prompt string) bool {
+		prompted = prompt
+		return false
+	}
+	err := cmdPeek([]string{})
+	if err == nil || !strings.Contains(err.Error(), "cancelled") {
+		t.Errorf("declined confirmation should cancel: %v", err)
+	}
+	if !strings.Contains(prompted, "4.8 MiB") {
+		t.Errorf("prompt should report the peer size, got %q", prompted)
+	}
+
+	confirmTransfer = func(string) bool { return true }
+	captureOutput(func() {
+		err = cmdPeek([]string{})
+	})
+	if err != nil {
+		t.Errorf("accepted confirmation should transfer: %v", err)
+	}
+}
+
+// Test small clipboards and custom thresholds skip the prompt
+func TestCmdPeekSizeUnderThreshold(t *testing.T) {
+	cleanup := setupPeerSizeTest(t, "5000000", "  peer_warn_size: 10000000\n")
+	defer cleanup()
+
+	origConfirm := confirmTransfer
+	defer func() { confirmTransfer = origConfirm }()
+	confirmTransfer = func(string) bool {
+		t.Error("should not prompt below threshold")
+		return false
+	}
+
+	var err error
+	captureOutput(func() {
+		err = cmdPeek([]string{})
+	})
+	if err != nil {
+		t.Errorf("cmdPeek under threshold should succeed: %v", err)
+	}
+}
+
+// Test getPeerWarnSize defaults and disabling
+func TestGetPeerWarnSize(t *testing.T) {
+	tests := []struct {
+		name     string
+		defaults *DefaultsConfig
+		want     int64
+	}{
+		{"nil defaults", nil, defaultPeerWarnSize},
+		{"unset", &DefaultsConfig{}, defaultPeerWarnSize},
+		{"custom", &DefaultsConfig{PeerWarnSize: 4096}, 4096},
+		{"disabled", &DefaultsConfig{PeerWarnSize: -1}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{Defaults: tt.defaults}
+			if got := cfg.getPeerWarnSize(); got != tt.want {
+				t.Errorf("getPeerWarnSize() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+// Test parsePeerFlags separates --yes and --json from the peer name
+func TestParsePeerFlags(t *testing.T) {
+	args, flags, _ := parsePeerFlags([]string{"dev", "-y"})
+	if !flags.yes || flags.json {
+		t.Errorf("expected -y to set only yes, got %+v", flags)
+	}
+	if len(args) != 1 || args[0] != "dev" {
+		t.Errorf("expected [dev], got %v", args)
+	}
+
+	args, flags, _ = parsePeerFlags([]string{"--json", "dev"})
+	if !flags.json || flags.yes {
+		t.Errorf("expected --json to set only json, got %+v", flags)
+	}
+	if len(args) != 1 || args[0] != "dev" {
+		t.Errorf("expected [dev], got %v", args)
+	}
+}
+
+// Test parsePeerFlags takes a value for --slot
+func TestParsePeerFlagsSlot(t *testing.T) {
+	args, flags, err := parsePeerFlags([]string{"dev", "--slot", "notes", "--json"})
+	if err != nil || flags.slot != "notes" || !flags.json || len(args) != 1 || args[0] != "dev" {
+		t.Errorf("got %v, %+v, %v", args, flags, err)
+	}
+	if _, _, err := parsePeerFlags([]string{"dev", "--slot"}); err == nil {
+		t.Error("--slot without a name should fail")
+	}
+}
+
+// decodePeerResult parses --json output from send/recv/peek
+func decodePeerResult(t *testing.T, output string) peerResult {
+	t.Helper()
+	var res peerResult
+	if err := json.Unmarshal([]byte(output), &res); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, output)
+	}
+	return res
+}
+
+// Test peek --json includes the content base64-encoded
+func TestCmdPeekJSON(t *testing.T) {
+	cleanup := setupPeerSizeTest(t, "10", "")
+	defer cleanup()
+
+	var err error
+	output := captureOutput(func() {
+		err = cmdPeek([]string{"--json"})
+	})
+	if err != nil {
+		t.Fatalf("cmdPeek --json: %v", err)
+	}
+	res := decodePeerResult(t, output)
+	if !res.OK || res.Error != "" {
+		t.Errorf("expected ok result, got %+v", res)
+	}
+	if res.Peer != "dev" || res.Bytes != len("peer data\n") {
+		t.Errorf("unexpected peer/bytes: %+v", res)
+	}
+	if !strings.HasPrefix(res.MIME, "text/plain") {
+		t.Errorf("expected text MIME, got %q", res.MIME)
+	}
+	data, err := base64.StdEncoding.DecodeString(res.DataB64)
+	if err != nil || string(data) != "peer data\n" {
+		t.Errorf("data_b64 should hold the clipboard, got %q (%v)", data, err)
+	}
+}
+
+// Test send and recv --json report the transfer
+func TestCmdSendRecvJSON(t *testing.T) {
+	cleanup := setupPeerSizeTest(t, "10", "")
+	defer cleanup()
+	useFileClipboard(t, "local clip")
+
+	var err error
+	output := captureOutput(func() {
+		err = cmdSend([]string{"--json"})
+	})
+	if err != nil {
+		t.Fatalf("cmdSend --json: %v", err)
+	}
+	res := decodePeerResult(t, output)
+	if !res.OK || res.Peer != "dev" || res.Bytes != len("local clip") || res.DataB64 != "" {
+		t.Errorf("unexpected send result: %+v", res)
+	}
+
+	output = captureOutput(func() {
+		err = cmdRecv([]string{"dev", "--json"})
+	})
+	if err != nil {
+		t.Fatalf("cmdRecv --json: %v", err)
+	}
+	res = decodePeerResult(t, output)
+	if !res.OK || res.Bytes != len("peer data\n") || res.DataB64 != "" {
+		t.Errorf("unexpected recv result: %+v", res)
+	}
+}
+
+// Test failures are reported in the JSON as well as the returned error
+func TestCmdPeerJSONErrors(t *testing.T) {
+	cleanup := setupPeerTestConfig(t, `version: 1
+peers:
+  dev:
+    ssh: user@host
+`)
+	defer cleanup()
+
+	mockDir := createMockSSH(t, "", true)
+	origPath := os.Getenv("PATH")
+	_ = os.Setenv("PATH", mockDir+":"+origPath)
+	defer func() { _ = os.Setenv("PATH", origPath) }()
+
+	for name, fn := range map[string]func([]string) error{"send": cmdSend, "recv": cmdRecv, "peek": cmdPeek} {
+		if name == "send" {
+			useFileClipboard(t, "data")
+		}
+		var err error
+		output := captureOutput(func() {
+			err = fn([]string{"dev", "--json", "--yes"})
+		})
+		if err == nil {
+			t.Errorf("%s: expected error from failing ssh", name)
+		}
+		res := decodePeerResult(t, output)
+		if res.OK || !strings.Contains(res.Error, "peer \"dev\"") || res.Peer != "dev" {
+			t.Errorf("%s: error should be in the JSON result, got %+v", name, res)
+		}
+	}
+
+	// Errors before a peer is known are still JSON
+	var err error
+	output := captureOutput(func() {
+		err = cmdPeek([]string{"missing", "--json"})
+	})
+	if err == nil {
+		t.Error("expected error for unknown peer")
+	}
+	if res := decodePeerResult(t, output); res.OK || res.Error == "" {
+		t.Errorf("unknown peer should be reported in JSON, got %+v", res)
+	}
+}
+
+// setupCountingPeer installs a mock ssh that logs each invocation and a
+// config with peer "dev". It returns the log path.
+func setupCountingPeer(t *testing.T, content, extraDefaults string) string {
+	t.Helper()
+	mockDir := t.TempDir()
+	logPath := mockDir + "/calls"
+	script := "#!/bin/sh\n" +
+		"echo \"$@\" >> '" + logPath + "'\n" +
+		"for arg in \"$@\"; do\n" +
+		"  if [ \"$arg\" = \"copy\" ]; then cat > /dev/null; exit 0; fi\n" +
+		"done\n" +
+		"echo '" + content + "'\n"
+	if err := os.WriteFile(mockDir+"/ssh", []byte(script), 0755); err != nil {
+		t.Fatalf("failed to create mock ssh: %v", err)
+	}
+	cleanup := setupPeerTestConfig(t, `version: 1
+defaults:
+  peer: dev
+`+extraDefaults+`
+peers:
+  dev:
+    ssh: user@host
+`)
+	t.Cleanup(cleanup)
+	t.Setenv("PATH", mockDir+":"+os.Getenv("PATH"))
+	return logPath
+}
+
+// countPastes returns how many clipboard fetches the mock ssh served
+func countPastes(t *testing.T, logPath string) int {
+	t.Helper()
+	data, err := os.ReadFile(logPath)
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatalf("reading ssh log: %v", err)
+	}
+	n := 0
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.HasSuffix(line, " paste") {
+			n++
+		}
+	}
+	return n
+}
+
+// Test recv right after peek reuses the fetched clipboard
+func TestPeekThenRecvUsesCache(t *testing.T) {
+	logPath := setupCountingPeer(t, "peer data", "")
+	clipPath := useFileClipboard(t, "")
+
+	output := captureOutput(func() {
+		if err := cmdPeek([]string{"--yes"}); err != nil {
+			t.Fatalf("cmdPeek: %v", err)
+		}
+	})
+	if !strings.Contains(output, "peer data") {
+		t.Errorf("peek should print the peer clipboard, got %q", output)
+	}
+	if err := cmdRecv([]string{"--yes"}); err != nil {
+		t.Fatalf("cmdRecv: %v", err)
+	}
+
+	if n := countPastes(t, logPath); n != 1 {
+		t.Errorf("expected one ssh fetch for peek+recv, got %d", n)
+	}
+	data, _ := os.ReadFile(clipPath)
+	if !strings.Contains(string(data), "peer data") {
+		t.Errorf("recv should write the cached content, got %q", data)
+	}
+}
+
+// Test --fresh, send and a zero TTL bypass the cache
+func TestPeerCacheBypass(t *testing.T) {
+	logPath := setupCountingPeer(t, "peer data", "")
+	useFileClipboard(t, "local data")
+
+	captureOutput(func() {
+		_ = cmdPeek([]string{"--yes"})
+		_ = cmdPeek([]string{"--yes", "--fresh"})
+	})
+	if n := countPastes(t, logPath); n != 2 {
+		t.Errorf("--fresh should fetch again, got %d fetches", n)
+	}
+
+	// Sending replaces the peer clipboard, so the cached copy is stale
+	if err := cmdSend([]string{}); err != nil {
+		t.Fatalf("cmdSend: %v", err)
+	}
+	captureOutput(func() { _ = cmdPeek([]string{"--yes"}) })
+	if n := countPastes(t, logPath); n != 3 {
+		t.Errorf("peek after send should fetch again, got %d fetches", n)
+	}
+}
+
+// Test peer_cache_ttl: 0 disables caching and bad values are rejected
+func TestPeerCacheTTLConfig(t *testing.T) {
+	logPath := setupCountingPeer(t, "peer data", "  peer_cache_ttl: 0s\n")
+	captureOutput(func() {
+		_ = cmdPeek([]string{"--yes"})
+		_ = cmdPeek([]string{"--yes"})
+	})
+	if n := countPastes(t, logPath); n != 2 {
+		t.Errorf("ttl 0 should disable the cache, got %d fetches", n)
+	}
+	if entries, _ := os.ReadDir(getPeerCacheDir()); len(entries) != 0 {
+		t.Errorf("ttl 0 should not write cache files, found %d", len(entries))
+	}
+
+	cfg := &Config{Defaults: &DefaultsConfig{PeerCacheTTL: "soon"}}
+	if _, err := cfg.getPeerCacheTTL(); err == nil {
+		t.Error("expected error for invalid peer_cache_ttl")
+	}
+	if ttl, _ := (&Config{}).getPeerCacheTTL(); ttl != defaultPeerCacheTTL {
+		t.Errorf("expected default ttl, got %v", ttl)
+	}
+}
+
+// Test expired cache entries are ignored and removed
+func TestReadPeerCacheExpired(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	peer := PeerConfig{SSH: "user@host", RemoteCmd: "pipeboard"}
+	writePeerCache(peer, []byte("old"), time.Minute)
+
+	if data, ok := readPeerCache(peer, time.Minute); !ok || string(data) != "old" {
+		t.Fatalf("expected fresh entry, got %q %v", data, ok)
+	}
+	old := time.Now().Add(-time.Hour)
+	_ = os.Chtimes(peerCachePath(peer), old, old)
+	if _, ok := readPeerCache(peer, time.Minute); ok {
+		t.Error("expired entry should not be used")
+	}
+	if _, err := os.Stat(peerCachePath(peer)); !os.IsNotExist(err) {
+		t.Error("expired entry should be removed")
+	}
+}
+
+// Test the cache lives outside the config directory with mode 0700, and
+// writing an entry removes expired ones and the legacy cache
+func TestWritePeerCachePrunes(t *testing.T) {
+	configDir, cacheDir := t.TempDir(), t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configDir)
+	t.Setenv("XDG_CACHE_HOME", cacheDir)
+	if runtime.GOOS != "darwin" && runtime.GOOS != "windows" && !strings.HasPrefix(getPeerCacheDir(), cacheDir) {
+		t.Errorf("peer cache dir = %s, want it under %s", getPeerCacheDir(), cacheDir)
+	}
+	legacy := filepath.Join(configDir, "pipeboard", "peer-cache")
+	_ = os.MkdirAll(legacy, 0700)
+	_ = os.WriteFile(filepath.Join(legacy, "stale"), []byte("secret"), 0600)
+
+	gone := PeerConfig{SSH: "user@gone"}
+	writePeerCache(gone, []byte("old"), time.Minute)
+	old := time.Now().Add(-time.Hour)
+	_ = os.Chtimes(peerCachePath(gone), old, old)
+
+	peer := PeerConfig{SSH: "user@host"}
+	writePeerCache(peer, []byte("new"), time.Minute)
+	if _, err := os.Stat(peerCachePath(gone)); !os.IsNotExist(err) {
+		t.Error("expired entry should be removed on write")
+	}
+	if data, ok := readPeerCache(peer, time.Minute); !ok || string(data) != "new" {
+		t.Errorf("expected the new entry, got %q %v", data, ok)
+	}
+	if _, err := os.Stat(legacy); !os.IsNotExist(err) {
+		t.Error("legacy cache under the config directory should be removed")
+	}
+	if info, err := os.Stat(getPeerCacheDir()); err != nil || info.Mode().Perm() != 0700 {
+		t.Errorf("peer cache dir mode = %v, %v; want 0700", info.Mode().Perm(), err)
+	}
+}
+
+// Test --dry-run prints the ssh command without running ssh
+func TestPeerDryRun(t *testing.T) {
+	logPath := setupCountingPeer(t, "peer data", "")
+	useFileClipboard(t, "hello")
+
+	for name, fn := range map[string]func([]string) error{"send": cmdSend, "recv": cmdRecv, "peek": cmdPeek} {
+		var err error
+		output := captureOutput(func() {
+			err = fn([]string{"--dry-run"})
+		})
+		if err != nil {
+			t.Fatalf("%s --dry-run: %v", name, err)
+		}
+		op := "paste"
+		if name == "send" {
+			op = "copy"
+		}
+		if !strings.Contains(output, "would run: ssh user@host pipeboard "+op) {
+			t.Errorf("%s: expected ssh command in output, got %q", name, output)
+		}
+		if name == "send" && !strings.Contains(output, "5 B") {
+			t.Errorf("send: expected payload size in output, got %q", output)
+		}
+	}
+
+	if _, err := os.Stat(logPath); !os.IsNotExist(err) {
+		t.Error("--dry-run should not run ssh")
+	}
+}
+
+// Test --dry-run with --json reports the command
+func TestPeerDryRunJSON(t *testing.T) {
+	logPath := setupCountingPeer(t, "peer data", "")
+
+	output := captureOutput(func() {
+		if err := cmdRecv([]string{"-n", "--json"}); err != nil {
+			t.Fatalf("cmdRecv: %v", err)
+		}
+	})
+	res := decodePeerResult(t, output)
+	want := []string{"ssh", "user@host", "pipeboard", "paste"}
+	if !res.OK || !res.DryRun || strings.Join(res.Command, " ") != strings.Join(want, " ") {
+		t.Errorf("unexpected dry-run result: %+v", res)
+	}
+	if _, err := os.Stat(logPath); !os.IsNotExist(err) {
+		t.Error("--dry-run should not run ssh")
+	}
+}
+
+// setupScriptPeer installs a mock ssh running body and a config with a
+// single default peer "dev"
+func setupScriptPeer(t *testing.T, body string) {
+	t.Helper()
+	mockDir := t.TempDir()
+	if err := os.WriteFile(mockDir+"/ssh", []byte("#!/bin/sh\n"+body+"\n"), 0755); err != nil {
+		t.Fatalf("failed to create mock ssh: %v", err)
+	}
+	cleanup := setupPeerTestConfig(t, `version: 1
+defaults:
+  peer: dev
+  peer_cache_ttl: 0
+peers:
+  dev:
+    ssh: user@host
+`)
+	t.Cleanup(cleanup)
+	t.Setenv("PATH", mockDir+":"+os.Getenv("PATH"))
+}
+
+// setupShellPeer installs a peer whose mock ssh hands its arguments to a
+// shell the way ssh does, recording the words the remote command gets in
+// the returned file. A marker path is returned for injection tests: it
+// only exists if something the peer was sent ran as a command.
+func setupShellPeer(t *testing.T, output string) (argsPath, marker string) {
+	t.Helper()
+	dir := t.TempDir()
+	argsPath, marker = filepath.Join(dir, "args"), filepath.Join(dir, "pwned")
+	setupScriptPeer(t, `shift
+eval "set -- $*"
+printf '%s\n' "$@" > `+argsPath+`
+cat > /dev/null
+printf '%s' '`+output+`'`)
+	return argsPath, marker
+}
+
+// Test remote arguments reach the peer as single words, never as shell
+// syntax
+func TestPeerCommandQuoting(t *testing.T) {
+	argsPath, marker := setupShellPeer(t, "")
+	useFileClipboard(t, "hello")
+
+	slot := "my notes; touch " + marker + " $(touch " + marker + ")"
+	captureOutput(func() {
+		if err := cmdSend([]string{"--slot", slot}); err != nil {
+			t.Errorf("send --slot: %v", err)
+		}
+	})
+	args, _ := os.ReadFile(argsPath)
+	if want := "pipeboard\npush\n" + slot + "\n--stdin\n"; string(args) != want {
+		t.Errorf("remote args = %q, want %q", args, want)
+	}
+	if _, err := os.Stat(marker); err == nil {
+		t.Error("slot name ran as a command on the peer")
+	}
+
+	if got := shellQuote("it's"); got != `'it'\''s'` {
+		t.Errorf("shellQuote = %s", got)
+	}
+	if got := shellQuote(""); got != "''" {
+		t.Errorf("shellQuote of empty = %s", got)
+	}
+}
+
+// Test a failed recv leaves the local clipboard untouched
+func TestCmdRecvErrorKeepsClipboard(t *testing.T) {
+	setupScriptPeer(t, "echo 'connection refused' >&2; exit 255")
+	clipPath := useFileClipboard(t, "keep me")
+
+	var err error
+	captureStderr(func() { err = cmdRecv([]string{"--yes"}) })
+	if err == nil {
+		t.Fatal("expected recv to fail")
+	}
+	if got, _ := os.ReadFile(clipPath); string(got) != "keep me" {
+		t.Errorf("clipboard = %q, want it untouched", got)
+	}
+}
+
+// Test recv refuses to write an empty peer clipboard without --allow-empty
+func TestCmdRecvEmptyKeepsClipboard(t *testing.T) {
+	setupScriptPeer(t, "printf ''")
+	clipPath := useFileClipboard(t, "keep me")
+
+	err := cmdRecv([]string{"--yes"})
+	if err == nil || !strings.Contains(err.Error(), "--allow-empty") {
+		t.Fatalf("expected empty clipboard error, got %v", err)
+	}
+	if got, _ := os.ReadFile(clipPath); string(got) != "keep me" {
+		t.Errorf("clipboard = %q, want it untouched", got)
+	}
+
+	if err := cmdRecv([]string{"--yes", "--allow-empty"}); err != nil {
+		t.Fatalf("recv --allow-empty: %v", err)
+	}
+	if got, _ := os.ReadFile(clipPath); len(got) != 0 {
+		t.Errorf("clipboard = %q, want empty with --allow-empty", got)
+	}
+}
+
+// Test recv --raw prints the peer clipboard without a local clipboard, and
+// recv without it points at --raw before transferring anything
+func TestCmdRecvRaw(t *testing.T) {
+	dir := t.TempDir()
+	setupScriptPeer(t, "echo x >> "+dir+"/calls; printf 'remote data'")
+	cachedBackendOnce = sync.Once{}
+	cachedBackendOnce.Do(func() {
+		cachedBackend = &Backend{Kind: BackendX11, Missing: []string{"xclip"}}
+		cachedBackendErr = nil
+	})
+	t.Cleanup(func() { cachedBackendOnce = sync.Once{} })
+
+	err := cmdRecv([]string{"--yes"})
+	if err == nil || !strings.Contains(err.Error(), "--raw") {
+		t.Fatalf("expected missing clipboard error suggesting --raw, got %v", err)
+	}
+	if _, err := os.Stat(dir + "/calls"); !os.IsNotExist(err) {
+		t.Error("recv should fail before contacting the peer")
+	}
+
+	out := captureOutput(func() {
+		if err := cmdRecv([]string{"--yes", "--raw"}); err != nil {
+			t.Errorf("recv --raw: %v", err)
+		}
+	})
+	if out != "remote data" {
+		t.Errorf("recv --raw printed %q, want the peer clipboard only", out)
+	}
+
+	captureOutput(func() { err = cmdRecv([]string{"--raw", "--json"}) })
+	if err == nil {
+		t.Error("expected --raw with --json to fail")
+	}
+}
+
+// Test clipboard data survives a send and recv through the peer command
+// unchanged: the transport has to carry piped stdin and stdout
+func TestPeerSendRecvDataFlow(t *testing.T) {
+	// The mock ssh runs the remote command against a file standing in for
+	// the peer's clipboard
+	peerClip := filepath.Join(t.TempDir(), "peer-clipboard")
+	setupScriptPeer(t, `shift
+eval "set -- $*"
+case "$2 $3" in
+"paste --size") wc -c < `+peerClip+` ;;
+"copy "*) cat > `+peerClip+` ;;
+"paste "*) cat `+peerClip+` ;;
+*) exit 1 ;;
+esac`)
+
+	want := "line one\n\ttabbed 'quoted' $HOME\n\x00\xff binary tail"
+	clip := useFileClipboard(t, want)
+	captureOutput(func() {
+		if err := cmdSend(nil); err != nil {
+			t.Errorf("cmdSend: %v", err)
+		}
+	})
+	if got, _ := os.ReadFile(peerClip); string(got) != want {
+		t.Fatalf("peer received %q, want %q", got, want)
+	}
+
+	if err := os.WriteFile(clip, []byte("replaced"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	captureOutput(func() {
+		if err := cmdRecv(nil); err != nil {
+			t.Errorf("cmdRecv: %v", err)
+		}
+	})
+	if got, _ := os.ReadFile(clip); string(got) != want {
+		t.Errorf("received %q, want %q", got, want)
+	}
+}
+
+// setupPeersCheck installs a mock ssh that answers version for hosts
+// named ok-*, fails for down-*, and hangs for slow-*
+func setupPeersCheck(t *testing.T) {
+	t.Helper()
+	mockDir := t.TempDir()
+	script := `#!/bin/sh
+for a; do
+	case "$a" in
+	ok-*) echo "pipeboard 1.2.3"; exit 0 ;;
+	down-*) echo "ssh: connect to host $a port 22: Connection refused" >&2; exit 255 ;;
+	slow-*) exec sleep 10 ;;
+	esac
+done
+exit 1
+`
+	if err := os.WriteFile(mockDir+"/ssh", []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", mockDir+":"+os.Getenv("PATH"))
+	t.Cleanup(setupPeerTestConfig(t, `version: 1
+defaults:
+  peer: alpha
+peers:
+  alpha:
+    ssh: ok-alpha
+  beta:
+    ssh: down-beta
+  gamma:
+    ssh: slow-gamma
+  delta:
+    ssh: ok-delta
+`))
+}
+
+// Test peers --check probes all peers and aggregates their status
+func TestCmdPeersCheck(t *testing.T) {
+	setupPeersCheck(t)
+
+	var err error
+	start := time.Now()
+	out := captureOutput(func() { err = cmdPeers([]string{"--check", "--timeout", "300ms"}) })
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("probes took %s; expected them to run concurrently under the timeout", elapsed)
+	}
+	if err == nil || !strings.Contains(err.Error(), "2 of 4 peers unreachable") {
+		t.Errorf("err = %v, want 2 of 4 unreachable", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if !strings.HasPrefix(lines[0], "PEER") || !strings.Contains(lines[0], "VERSION") {
+		t.Errorf("header = %q", lines[0])
+	}
+	for _, want := range []struct{ peer, status, detail string }{
+		{"alpha *", "reachable", "1.2.3"},
+		{"beta", "unreachable", "Connection refused"},
+		{"delta", "reachable", "1.2.3"},
+		{"gamma", "unreachable", "timed out after 300ms"},
+	} {
+		found := false
+		for _, line := range lines {
+			if strings.HasPrefix(line, want.peer+" ") && strings.Contains(line, want.status) && strings.Contains(line, want.detail) {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("no row for %s %s %s in:\n%s", want.peer, want.status, want.detail, out)
+		}
+	}
+}
+
+// Test peers --check --json reports per-peer results
+func TestCmdPeersCheckJSON(t *testing.T) {
+	setupPeersCheck(t)
+
+	out := captureOutput(func() { _ = cmdPeers([]string{"--check", "--json", "--timeout", "300ms"}) })
+	var statuses []peerStatus
+	if err := json.Unmarshal([]byte(out), &statuses); err != nil {
+		t.Fatalf("invalid JSON %q: %v", out, err)
+	}
+	if len(statuses) != 4 {
+		t.Fatalf("got %d peers, want 4", len(statuses))
+	}
+	byName := map[string]peerStatus{}
+	for _, st := range statuses {
+		byName[st.Name] = st
+	}
+	if st := byName["alpha"]; !st.Reachable || st.Version != "1.2.3" || !st.Default {
+		t.Errorf("alpha = %+v", st)
+	}
+	if st := byName["delta"]; !st.Reachable || st.SSH != "ok-delta" {
+		t.Errorf("delta = %+v", st)
+	}
+	if st := byName["beta"]; st.Reachable || !st.Checked || !strings.Contains(st.Error, "Connection refused") {
+		t.Errorf("beta = %+v", st)
+	}
+}
+
+// Test peers without --check lists config without running ssh
+func TestCmdPeersList(t *testing.T) {
+	t.Setenv("PATH", t.TempDir()) // no ssh at all
+	defer setupPeerTestConfig(t, "version: 1\npeers:\n  dev:\n    ssh: devbox\n  broken:\n    remote_cmd: pb\n")()
+
+	var err error
+	out := captureOutput(func() { err = cmdPeers(nil) })
+	if err == nil || !strings.Contains(err.Error(), "1 of 2 peers misconfigured") {
+		t.Errorf("err = %v", err)
+	}
+	if !strings.Contains(out, "STATUS") || strings.Contains(out, "TRANSPORT") || !strings.Contains(out, "devbox") || !strings.Contains(out, "missing 'ssh' field") {
+		t.Errorf("output = %q", out)
+	}
+	if strings.Contains(out, "default peer") {
+		t.Errorf("no default configured, got %q", out)
+	}
+
+	if err := cmdPeers([]string{"--timeout", "soon"}); err == nil {
+		t.Error("invalid --timeout should fail")
+	}
+}
+
+// hashScriptPeer returns a mock ssh body that answers paste --hash with
+// hashReply and swallows the copy payload otherwise
+func hashScriptPeer(hashReply string) string {
+	return `for arg in "$@"; do
+  if [ "$arg" = "--hash" ]; then ` + hashReply + `; fi
+done
+cat >/dev/null`
+}
+
+// Test send --confirm succeeds when the peer echoes the hash that was sent
+func TestCmdSendConfirmMatch(t *testing.T) {
+	useFileClipboard(t, "hello peer")
+	setupScriptPeer(t, hashScriptPeer("echo '"+clipboardDigest([]byte("hello peer"))+"'; exit 0"))
+
+	var res peerResult
+	out := captureOutput(func() {
+		if err := cmdSend([]string{"--confirm", "--json"}); err != nil {
+			t.Errorf("send --confirm with a matching hash: %v", err)
+		}
+	})
+	if err := json.Unmarshal([]byte(out), &res); err != nil {
+		t.Fatalf("bad JSON %q: %v", out, err)
+	}
+	if !res.OK || !res.Confirmed {
+		t.Errorf("result = %+v, want ok and confirmed", res)
+	}
+}
+
+// Test send --confirm fails when the peer stored something else
+func TestCmdSendConfirmMismatch(t *testing.T) {
+	useFileClipboard(t, "hello peer")
+	setupScriptPeer(t, hashScriptPeer("echo '"+clipboardDigest([]byte("hello pe"))+"'; exit 0"))
+
+	err := cmdSend([]string{"--confirm"})
+	if err == nil || !strings.Contains(err.Error(), "stored different content") {
+		t.Errorf("err = %v, want a hash mismatch", err)
+	}
+}
+
+// Test send --confirm warns and succeeds against a peer without paste --hash
+func TestCmdSendConfirmOldPeer(t *testing.T) {
+	useFileClipboard(t, "hello peer")
+	setupScriptPeer(t, hashScriptPeer("echo 'unknown argument: --hash' >&2; exit 1"))
+
+	var err error
+	stderr := captureStderr(func() { err = cmdSend([]string{"--confirm"}) })
+	if err != nil {
+		t.Errorf("send to an old peer should still succeed: %v", err)
+	}
+	if !strings.Contains(stderr, "can't confirm") {
+		t.Errorf("stderr = %q, want a skipped handshake warning", stderr)
+	}
+}
+
+// Test paste --hash prints the digest send --confirm compares against
+func TestCmdPasteHash(t *testing.T) {
+	useFileClipboard(t, "abc")
+	out := captureOutput(func() {
+		if err := cmdPaste([]string{"--hash"}); err != nil {
+			t.Errorf("paste --hash: %v", err)
+		}
+	})
+	want := "sha256:ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad\n"
+	if out != want {
+		t.Errorf("paste --hash = %q, want %q", out, want)
+	}
+}
+
+// Test send --slot pushes the clipboard to a slot on the peer instead of
+// copying it, and recv --slot pulls a peer slot into the local clipboard
+func TestCmdSendRecvSlot(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "ssh.log")
+	setupScriptPeer(t, `echo "$@" >> '`+logPath+`'
+case "$*" in
+  *" push notes --stdin") cat > '`+logPath+`.stdin' ;;
+  *" version") echo 'pipeboard 0.8.0' ;;
+  *" show notes") printf 'slot content' ;;
+  *) echo "unexpected: $*" >&2; exit 1 ;;
+esac`)
+	clipPath := useFileClipboard(t, "local clip")
+
+	var res peerResult
+	out := captureOutput(func() {
+		if err := cmdSend([]string{"--slot", "notes", "--json"}); err != nil {
+			t.Errorf("send --slot: %v", err)
+		}
+	})
+	if err := json.Unmarshal([]byte(out), &res); err != nil {
+		t.Fatalf("bad JSON %q: %v", out, err)
+	}
+	if !res.OK || res.Slot != "notes" || res.Bytes != len("local clip") {
+		t.Errorf("send result = %+v", res)
+	}
+	if got, _ := os.ReadFile(logPath + ".stdin"); string(got) != "local clip" {
+		t.Errorf("peer push read %q, want the local clipboard", got)
+	}
+
+	captureOutput(func() {
+		if err := cmdRecv([]string{"dev", "--slot", "notes"}); err != nil {
+			t.Errorf("recv --slot: %v", err)
+		}
+	})
+	if got, _ := os.ReadFile(clipPath); string(got) != "slot content" {
+		t.Errorf("clipboard = %q, want the peer slot", got)
+	}
+
+	// Only the version check and slot commands ran: no copy, paste or size query
+	log, _ := os.ReadFile(logPath)
+	if want := "user@host pipeboard push notes --stdin\nuser@host pipeboard version\nuser@host pipeboard show notes\n"; string(log) != want {
+		t.Errorf("ssh calls:\n%s\nwant:\n%s", log, want)
+	}
+
+	out = captureOutput(func() {
+		if err := cmdRecv([]string{"--slot", "notes", "--dry-run"}); err != nil {
+			t.Errorf("recv --slot --dry-run: %v", err)
+		}
+	})
+	if !strings.Contains(out, "pipeboard show notes") {
+		t.Errorf("dry run = %q", out)
+	}
+
+	if err := cmdSend([]string{"--slot", "notes", "--confirm"}); err == nil {
+		t.Error("send --slot --confirm should be rejected")
+	}
+	if err := cmdPeek([]string{"--slot", "notes"}); err == nil {
+		t.Error("peek --slot should be rejected")
+	}
+}
+
+// Test recv --slot stops before the transfer when the peer doesn't answer
+// with a pipeboard version
+func TestCmdRecvSlotChecksPeerVersion(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "ssh.log")
+	setupScriptPeer(t, `echo "$@" >> '`+logPath+`'
+case "$*" in
+  *" version") echo 'usage: something-else' ;;
+  *) printf 'slot content' ;;
+esac`)
+	clipPath := useFileClipboard(t, "local clip")
+
+	err := cmdRecv([]string{"--slot", "notes"})
+	if err == nil || !strings.Contains(err.Error(), "didn't report a pipeboard version") {
+		t.Errorf("recv --slot error = %v", err)
+	}
+	if got, _ := os.ReadFile(clipPath); string(got) != "local clip" {
+		t.Errorf("clipboard = %q, want it untouched", got)
+	}
+	if log, _ := os.ReadFile(logPath); string(log) != "user@host pipeboard version\n" {
+		t.Errorf("ssh calls = %q, want only the version check", log)
+	}
+}
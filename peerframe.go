@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Peer frames wrap clipboard content sent between pipeboards over ssh
+// when a peer has compress or encrypt set. A frame is one header line,
+//
+//	pipeboard-frame/<version> {"len":12,"compressed":true,...}
+//
+// followed by exactly len bytes of payload. The header says how the
+// payload was packed, so the receiving side unpacks it whatever its own
+// peer settings are. Plain transfers (no compress/encrypt) stay unframed
+// so older peers keep working.
+const (
+	peerFrameMagic   = "pipeboard-frame/"
+	peerFrameVersion = 1
+)
+
+// peerFrameHeader describes a frame's payload
+type peerFrameHeader struct {
+	// MinVersion is the oldest frame version able to read this frame; a
+	// newer sender sets it when its additions are safe to ignore
+	MinVersion int    `json:"min_version,omitempty"`
+	Len        int    `json:"len"` // payload bytes after the header
+	Size       int    `json:"size"`
+	MIME       string `json:"mime,omitempty"`
+	Compressed bool   `json:"compressed,omitempty"`
+	Encrypted  bool   `json:"encrypted,omitempty"`
+}
+
+// peerFrameOptions chooses how encodePeerFrame packs content
+type peerFrameOptions struct {
+	compress   bool
+	passphrase string // encrypt with this when set
+}
+
+// encodePeerFrame packs data into a frame. Compression is skipped when it
+// wouldn't make the payload smaller.
+func encodePeerFrame(data []byte, opts peerFrameOptions) ([]byte, error) {
+	hdr := peerFrameHeader{Size: len(data), MIME: detectMIME(data)}
+	payload := data
+	if opts.compress && len(data) > 0 {
+		if c, err := compressData(data); err == nil && len(c) < len(data) {
+			payload, hdr.Compressed = c, true
+		}
+	}
+	if opts.passphrase != "" {
+		enc, err := encrypt(payload, opts.passphrase)
+		if err != nil {
+			return nil, fmt.Errorf("encrypting peer transfer: %w", err)
+		}
+		payload, hdr.Encrypted = enc, true
+	}
+	hdr.Len = len(payload)
+	meta, err := json.Marshal(hdr)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s%d %s\n", peerFrameMagic, peerFrameVersion, meta)
+	buf.Write(payload)
+	return buf.Bytes(), nil
+}
+
+// decodePeerFrame unpacks a frame. passphrase is only needed, and only
+// looked up, when the frame is encrypted.
+func decodePeerFrame(frame []byte, passphrase func() (string, error)) ([]byte, peerFrameHeader, error) {
+	var hdr peerFrameHeader
+	line, payload, ok := bytes.Cut(frame, []byte("\n"))
+	if !ok || !bytes.HasPrefix(line, []byte(peerFrameMagic)) {
+		return nil, hdr, errors.New("peer didn't send a pipeboard frame; is pipeboard on the peer up to date?")
+	}
+	versionStr, meta, _ := strings.Cut(string(line[len(peerFrameMagic):]), " ")
+	version, err := strconv.Atoi(versionStr)
+	if err != nil {
+		return nil, hdr, fmt.Errorf("malformed peer frame header %q", line)
+	}
+	if err := json.Unmarshal([]byte(meta), &hdr); err != nil {
+		return nil, hdr, fmt.Errorf("malformed peer frame header: %w", err)
+	}
+	if version > peerFrameVersion && (hdr.MinVersion == 0 || hdr.MinVersion > peerFrameVersion) {
+		return nil, hdr, fmt.Errorf("peer sent frame version %d, please upgrade pipeboard to read it", version)
+	}
+	if len(payload) != hdr.Len {
+		return nil, hdr, fmt.Errorf("peer frame truncated: got %d of %d bytes", len(payload), hdr.Len)
+	}
+
+	data := payload
+	if hdr.Encrypted {
+		pass, err := passphrase()
+		if err != nil {
+			return nil, hdr, err
+		}
+		if data, err = decrypt(data, pass); err != nil {
+			return nil, hdr, fmt.Errorf("decrypting peer transfer (do both machines share the sync passphrase?): %w", err)
+		}
+	}
+	if hdr.Compressed {
+		if data, err = decompressData(data); err != nil {
+			return nil, hdr, fmt.Errorf("decompressing peer transfer: %w", err)
+		}
+	}
+	if len(data) != hdr.Size {
+		return nil, hdr, fmt.Errorf("peer transfer is %d bytes, header says %d", len(data), hdr.Size)
+	}
+	return data, hdr, nil
+}
+
+// peerPassphrase returns the sync passphrase that encrypts peer frames.
+// Both machines need the same one.
+func peerPassphrase() (string, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return "", fmt.Errorf("peer encryption needs the sync passphrase: %w", err)
+	}
+	if cfg.Sync == nil || !cfg.Sync.hasPassphrase() {
+		return "", errors.New("peer encryption needs a sync passphrase (sync.passphrase, passphrase_cmd, passphrase_file, or the keyring)")
+	}
+	if err := loadPassphraseSource(cfg.Sync); err != nil {
+		return "", err
+	}
+	return cfg.Sync.Passphrase, nil
+}
+
+// framed reports whether transfers with the peer use frames
+func (p PeerConfig) framed() bool {
+	return p.Compress || p.Encrypt
+}
+
+// frameFlags are the paste flags asking the peer for a frame packed the
+// way this peer is configured
+func (p PeerConfig) frameFlags() []string {
+	flags := []string{"--framed"}
+	if p.Compress {
+		flags = append(flags, "--compress")
+	}
+	if p.Encrypt {
+		flags = append(flags, "--encrypt")
+	}
+	return flags
+}
+
+// frameOptions packs a frame for send the way this peer is configured
+func (p PeerConfig) frameOptions() (peerFrameOptions, error) {
+	opts := peerFrameOptions{compress: p.Compress}
+	if p.Encrypt {
+		pass, err := peerPassphrase()
+		if err != nil {
+			return opts, err
+		}
+		opts.passphrase = pass
+	}
+	return opts, nil
+}
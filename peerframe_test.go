@@ -0,0 +1,246 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func fixedPassphrase(pass string) func() (string, error) {
+	return func() (string, error) { return pass, nil }
+}
+
+func TestPeerFrameRoundTrip(t *testing.T) {
+	text := []byte(strings.Repeat("kubectl get pods -A\n", 100))
+	tests := []struct {
+		name           string
+		data           []byte
+		opts           peerFrameOptions
+		wantCompressed bool
+	}{
+		{"plain", text, peerFrameOptions{}, false},
+		{"compressed", text, peerFrameOptions{compress: true}, true},
+		{"encrypted", text, peerFrameOptions{passphrase: "secret"}, false},
+		{"compressed and encrypted", text, peerFrameOptions{compress: true, passphrase: "secret"}, true},
+		// Compression is skipped when it doesn't help
+		{"incompressible", []byte("x"), peerFrameOptions{compress: true}, false},
+		{"empty", nil, peerFrameOptions{compress: true, passphrase: "secret"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			frame, err := encodePeerFrame(tt.data, tt.opts)
+			if err != nil {
+				t.Fatalf("encode: %v", err)
+			}
+			if tt.opts.passphrase != "" && bytes.Contains(frame, []byte("kubectl")) {
+				t.Error("encrypted frame contains plaintext")
+			}
+			got, hdr, err := decodePeerFrame(frame, fixedPassphrase("secret"))
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if !bytes.Equal(got, tt.data) {
+				t.Errorf("round trip changed content: got %d bytes, want %d", len(got), len(tt.data))
+			}
+			if hdr.Compressed != tt.wantCompressed {
+				t.Errorf("compressed = %t, want %t", hdr.Compressed, tt.wantCompressed)
+			}
+			if hdr.Encrypted != (tt.opts.passphrase != "") {
+				t.Errorf("encrypted = %t", hdr.Encrypted)
+			}
+		})
+	}
+}
+
+func TestDecodePeerFrameErrors(t *testing.T) {
+	frame, err := encodePeerFrame([]byte("hello"), peerFrameOptions{passphrase: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name  string
+		frame []byte
+		pass  func() (string, error)
+		want  string
+	}{
+		{"not a frame", []byte("hello"), fixedPassphrase("secret"), "didn't send a pipeboard frame"},
+		{"truncated", frame[:len(frame)-3], fixedPassphrase("secret"), "truncated"},
+		{"wrong passphrase", frame, fixedPassphrase("other"), "sync passphrase"},
+		{"no passphrase", frame, func() (string, error) { return "", errors.New("no passphrase set") }, "no passphrase set"},
+		{"newer version", []byte("pipeboard-frame/2 {\"len\":0}\n"), fixedPassphrase("secret"), "upgrade"},
+		{"bad header", []byte("pipeboard-frame/1 {len\n"), fixedPassphrase("secret"), "malformed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := decodePeerFrame(tt.frame, tt.pass); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+// Test a newer frame that older readers can still handle is accepted
+func TestDecodePeerFrameForwardCompatible(t *testing.T) {
+	frame := []byte("pipeboard-frame/2 {\"min_version\":1,\"len\":5,\"size\":5,\"checksum\":\"x\"}\nhello")
+	got, _, err := decodePeerFrame(frame, fixedPassphrase(""))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if string(got) != "hello" {
+		t.Errorf("got %q", got)
+	}
+}
+
+// setupFramedPeer is setupScriptPeer for a peer with compress and encrypt
+// set, sharing the sync passphrase "secret"
+func setupFramedPeer(t *testing.T, body string) {
+	t.Helper()
+	mockDir := t.TempDir()
+	if err := os.WriteFile(mockDir+"/ssh", []byte("#!/bin/sh\n"+body+"\n"), 0755); err != nil {
+		t.Fatalf("failed to create mock ssh: %v", err)
+	}
+	t.Cleanup(setupPeerTestConfig(t, `version: 1
+sync:
+  backend: local
+  local:
+    path: `+t.TempDir()+`
+  encryption: aes256
+  passphrase: secret
+defaults:
+  peer: dev
+  peer_cache_ttl: 0
+peers:
+  dev:
+    ssh: user@host
+    compress: true
+    encrypt: true
+`))
+	t.Setenv("PATH", mockDir+":"+os.Getenv("PATH"))
+}
+
+func TestCmdRecvFramed(t *testing.T) {
+	content := strings.Repeat("secret config line\n", 50)
+	frame, err := encodePeerFrame([]byte(content), peerFrameOptions{compress: true, passphrase: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	framePath := filepath.Join(dir, "frame")
+	argsPath := filepath.Join(dir, "args")
+	if err := os.WriteFile(framePath, frame, 0600); err != nil {
+		t.Fatal(err)
+	}
+	setupFramedPeer(t, `echo "$@" > `+argsPath+`; cat `+framePath)
+	clipPath := useFileClipboard(t, "")
+
+	captureOutput(func() {
+		if err := cmdRecv([]string{"--yes"}); err != nil {
+			t.Fatalf("recv: %v", err)
+		}
+	})
+	if got := readFileString(t, clipPath); got != content {
+		t.Errorf("clipboard = %d bytes, want the %d sent", len(got), len(content))
+	}
+	if args := readFileString(t, argsPath); !strings.Contains(args, "paste --framed --compress --encrypt") {
+		t.Errorf("peer ran %q, want a framed paste", args)
+	}
+}
+
+// Test framing is per transfer: a framed receiver reads a frame packed
+// without compression or encryption
+func TestCmdPeekFramedPlainSender(t *testing.T) {
+	frame, err := encodePeerFrame([]byte("plain text"), peerFrameOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	framePath := filepath.Join(t.TempDir(), "frame")
+	if err := os.WriteFile(framePath, frame, 0600); err != nil {
+		t.Fatal(err)
+	}
+	setupFramedPeer(t, "cat "+framePath)
+
+	out := captureOutput(func() {
+		if err := cmdPeek(nil); err != nil {
+			t.Fatalf("peek: %v", err)
+		}
+	})
+	if !strings.Contains(out, "plain text") {
+		t.Errorf("peek output = %q", out)
+	}
+}
+
+// Test peers without compress or encrypt keep the unframed protocol
+func TestCmdRecvUnframedPeer(t *testing.T) {
+	argsPath := filepath.Join(t.TempDir(), "args")
+	setupScriptPeer(t, `echo "$@" > `+argsPath+`; printf 'raw content'`)
+	clipPath := useFileClipboard(t, "")
+
+	captureOutput(func() {
+		if err := cmdRecv([]string{"--yes"}); err != nil {
+			t.Fatalf("recv: %v", err)
+		}
+	})
+	if got := readFileString(t, clipPath); got != "raw content" {
+		t.Errorf("clipboard = %q", got)
+	}
+	if args := readFileString(t, argsPath); strings.Contains(args, "--framed") {
+		t.Errorf("peer ran %q, want a plain paste", args)
+	}
+}
+
+func TestCmdSendFramed(t *testing.T) {
+	content := strings.Repeat("token=abc123\n", 40)
+	sentPath := filepath.Join(t.TempDir(), "sent")
+	setupFramedPeer(t, "cat > "+sentPath)
+	useFileClipboard(t, content)
+
+	captureOutput(func() {
+		if err := cmdSend(nil); err != nil {
+			t.Fatalf("send: %v", err)
+		}
+	})
+	sent, err := os.ReadFile(sentPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(sent, []byte("token=abc123")) {
+		t.Error("peer received plaintext")
+	}
+	got, hdr, err := decodePeerFrame(sent, fixedPassphrase("secret"))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if string(got) != content || !hdr.Compressed || !hdr.Encrypted {
+		t.Errorf("decoded %d bytes (compressed=%t, encrypted=%t)", len(got), hdr.Compressed, hdr.Encrypted)
+	}
+}
+
+// Test the receiving end of send: copy --framed unpacks onto the clipboard
+func TestCmdCopyFramed(t *testing.T) {
+	defer setupSlotsTestConfig(t, slotsConfigAt(t.TempDir(), "  encryption: aes256\n  passphrase: secret\n"))()
+	clipPath := useFileClipboard(t, "")
+	content := strings.Repeat("line\n", 100)
+	frame, err := encodePeerFrame([]byte(content), peerFrameOptions{compress: true, passphrase: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	restore := mockStdin(t, string(frame))
+	err = cmdCopy([]string{"--framed"})
+	restore()
+	if err != nil {
+		t.Fatalf("copy --framed: %v", err)
+	}
+	if got := readFileString(t, clipPath); got != content {
+		t.Errorf("clipboard = %d bytes, want %d", len(got), len(content))
+	}
+
+	if err := cmdCopy([]string{"--framed", "text"}); err == nil || !strings.Contains(err.Error(), "--framed") {
+		t.Errorf("--framed with text: err = %v", err)
+	}
+	if err := cmdPaste([]string{"--compress"}); err == nil || !strings.Contains(err.Error(), "--framed") {
+		t.Errorf("--compress without --framed: err = %v", err)
+	}
+}
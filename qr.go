@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/skip2/go-qrcode"
+)
+
+// QR codes are encoded with github.com/skip2/go-qrcode at error
+// correction level M and drawn with half blocks for the terminal.
+
+// maxQRBytes caps QR content at what fits version 10 at level M in byte
+// mode. Larger symbols are too wide to scan from an 80-column terminal.
+const maxQRBytes = 213
+
+// qrQuietZone is the light border (in modules) required around the symbol
+const qrQuietZone = 4
+
+// qrEncode encodes data as a QR code and returns its module matrix
+// (true = dark), without the quiet zone
+func qrEncode(data []byte) ([][]bool, error) {
+	if len(data) > maxQRBytes {
+		return nil, fmt.Errorf("content too large for QR code (%d bytes, max %d)", len(data), maxQRBytes)
+	}
+	code, err := qrcode.New(string(data), qrcode.Medium)
+	if err != nil {
+		return nil, fmt.Errorf("encoding QR code: %w", err)
+	}
+	code.DisableBorder = true
+	debugLog("qr: version %d, %d bytes", code.VersionNumber, len(data))
+	return code.Bitmap(), nil
+}
+
+// renderQR draws a QR matrix with Unicode half blocks, two module rows
+// per line. Light modules are drawn as blocks, which suits dark terminal
+// backgrounds; invert draws dark modules instead for light backgrounds.
+func renderQR(modules [][]bool, invert bool) string {
+	size := len(modules) + 2*qrQuietZone
+	drawn := func(r, c int) bool {
+		if r >= size {
+			return false
+		}
+		r, c = r-qrQuietZone, c-qrQuietZone
+		dark := r >= 0 && r < len(modules) && c >= 0 && c < len(modules) && modules[r][c]
+		return dark == invert
+	}
+
+	var sb strings.Builder
+	for r := 0; r < size; r += 2 {
+		for c := 0; c < size; c++ {
+			top, bottom := drawn(r, c), drawn(r+1, c)
+			switch {
+			case top && bottom:
+				sb.WriteString("█")
+			case top:
+				sb.WriteString("▀")
+			case bottom:
+				sb.WriteString("▄")
+			default:
+				sb.WriteString(" ")
+			}
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// printQR validates content and prints it as a terminal QR code
+func printQR(data []byte, invert bool) error {
+	data = bytes.TrimRight(data, "\r\n")
+	if len(data) == 0 {
+		return errors.New("nothing to encode: content is empty")
+	}
+	if !utf8.Valid(data) {
+		return errors.New("QR output only supports text content")
+	}
+	if len(data) > maxQRBytes {
+		return fmt.Errorf("content too large for QR code (%d bytes, max %d)", len(data), maxQRBytes)
+	}
+
+	modules, err := qrEncode(data)
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.WriteString(renderQR(modules, invert))
+	return err
+}
+
+// cmdQR renders the current clipboard as a QR code in the terminal
+func cmdQR(args []string) error {
+	invert := false
+	for _, arg := range args {
+		switch arg {
+		case "--invert":
+			invert = true
+		default:
+			return fmt.Errorf("unknown argument: %s\nusage: pipeboard qr [--invert]", arg)
+		}
+	}
+
+	data, err := readClipboard()
+	if err != nil {
+		return err
+	}
+	return printQR(data, invert)
+}
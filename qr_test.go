@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// Test content picks the smallest symbol that holds it, up to version 10
+func TestQREncodeSizes(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		maxVersion int
+	}{
+		{"short secret", "hunter2", 1},
+		{"url", "https://example.com/reset?token=abc123", 3},
+		{"max capacity", strings.Repeat("z", maxQRBytes), 10},
+		{"unicode", "pässwörd ✓", 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			modules, err := qrEncode([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("qrEncode failed: %v", err)
+			}
+			size := len(modules)
+			if size < 21 || (size-17)%4 != 0 {
+				t.Fatalf("invalid matrix size %d", size)
+			}
+			if limit := tt.maxVersion*4 + 17; size > limit {
+				t.Errorf("expected at most version %d (size %d), got size %d", tt.maxVersion, limit, size)
+			}
+			for i, row := range modules {
+				if len(row) != size {
+					t.Fatalf("row %d has %d modules, want %d", i, len(row), size)
+				}
+			}
+		})
+	}
+}
+
+// Test finder patterns are in all three corners
+func TestQREncodeFinderPatterns(t *testing.T) {
+	modules, err := qrEncode([]byte("hello"))
+	if err != nil {
+		t.Fatalf("qrEncode failed: %v", err)
+	}
+	size := len(modules)
+	for _, origin := range [][2]int{{0, 0}, {0, size - 7}, {size - 7, 0}} {
+		r, c := origin[0], origin[1]
+		if !modules[r][c] || !modules[r+3][c+3] || modules[r+1][c+1] {
+			t.Errorf("finder pattern missing at %v", origin)
+		}
+	}
+}
+
+// Test qrEncode rejects content over capacity
+func TestQREncodeTooLarge(t *testing.T) {
+	_, err := qrEncode(bytes.Repeat([]byte("a"), maxQRBytes+1))
+	if err == nil {
+		t.Fatal("expected error for content over capacity")
+	}
+	if !strings.Contains(err.Error(), "too large") {
+		t.Errorf("error should mention too large: %v", err)
+	}
+}
+
+// Test renderQR output dimensions and block characters
+func TestRenderQR(t *testing.T) {
+	modules, err := qrEncode([]byte("hello"))
+	if err != nil {
+		t.Fatalf("qrEncode failed: %v", err)
+	}
+	out := renderQR(modules, false)
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	width := len(modules) + 2*qrQuietZone
+	if len(lines) != (width+1)/2 {
+		t.Errorf("expected %d lines, got %d", (width+1)/2, len(lines))
+	}
+	for i, line := range lines {
+		if n := len([]rune(line)); n != width {
+			t.Errorf("line %d: expected width %d, got %d", i, width, n)
+		}
+	}
+	// The quiet zone is light, so the first line is solid blocks
+	if strings.Trim(lines[0], "█") != "" {
+		t.Errorf("first line should be quiet zone blocks, got %q", lines[0])
+	}
+	if renderQR(modules, true) == out {
+		t.Error("inverted output should differ")
+	}
+}
+
+// Test printQR rejects binary and oversized content
+func TestPrintQRValidation(t *testing.T) {
+	if err := printQR([]byte{0xff, 0xfe, 0x00}, false); err == nil || !strings.Contains(err.Error(), "text") {
+		t.Errorf("expected text-only error, got %v", err)
+	}
+	if err := printQR(bytes.Repeat([]byte("a"), 500), false); err == nil || !strings.Contains(err.Error(), "too large") {
+		t.Errorf("expected too large error, got %v", err)
+	}
+	if err := printQR([]byte("\n"), false); err == nil || !strings.Contains(err.Error(), "empty") {
+		t.Errorf("expected empty error, got %v", err)
+	}
+}
+
+// Test printQR writes a QR code for short text
+func TestPrintQRShortText(t *testing.T) {
+	var err error
+	out := captureOutput(func() {
+		err = printQR([]byte("secret\n"), false)
+	})
+	if err != nil {
+		t.Fatalf("printQR failed: %v", err)
+	}
+	if !strings.Contains(out, "█") {
+		t.Error("expected block characters in output")
+	}
+}
+
+// Test cmdQR with unknown flag
+func TestCmdQRUnknownFlag(t *testing.T) {
+	err := cmdQR([]string{"--bogus"})
+	if err == nil || !strings.Contains(err.Error(), "unknown argument") {
+		t.Errorf("expected unknown argument error, got %v", err)
+	}
+}
+
+// Test cmdShow --qr renders a slot
+func TestCmdShowQR(t *testing.T) {
+	cleanup := setupSlotsTestConfig(t, `version: 1
+sync:
+  backend: local
+`)
+	defer cleanup()
+
+	backend, err := newRemoteBackendFromConfig()
+	if err != nil {
+		t.Fatalf("backend: %v", err)
+	}
+	if err := backend.Push("wifi", []byte("WIFI:S:home;P:pass;;"), map[string]string{}); err != nil {
+		t.Fatalf("push: %v", err)
+	}
+
+	out := captureOutput(func() {
+		err = cmdShow([]string{"wifi", "--qr"})
+	})
+	if err != nil {
+		t.Fatalf("cmdShow --qr failed: %v", err)
+	}
+	if !strings.Contains(out, "▀") && !strings.Contains(out, "▄") {
+		t.Error("expected half-block characters in QR output")
+	}
+}
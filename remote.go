@@ -4,15 +4,23 @@ import (
 	"bytes"
 	"compress/gzip"
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/ascii85"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"math/rand"
 	"net/http"
+	"net/url"
 	"os"
 	"path"
 	"runtime"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -25,16 +33,42 @@ import (
 
 // SlotPayload is the JSON envelope stored in remote slots
 type SlotPayload struct {
-	Version    int    `json:"version"`
-	CreatedAt  string `json:"created_at"`
-	ExpiresAt  string `json:"expires_at,omitempty"` // RFC3339 timestamp for TTL
-	Hostname   string `json:"hostname"`
-	OS         string `json:"os"`
-	Len        int    `json:"len"`
-	MIME       string `json:"mime"`
-	Encrypted  bool   `json:"encrypted,omitempty"`  // true if data is client-side encrypted
-	Compressed bool   `json:"compressed,omitempty"` // true if data is gzip compressed
-	DataB64    string `json:"data_b64"`
+	Version         int         `json:"version"`
+	MinVersion      int         `json:"min_version,omitempty"` // oldest payload version able to read this one (set by newer writers)
+	CreatedAt       string      `json:"created_at"`
+	ExpiresAt       string      `json:"expires_at,omitempty"` // RFC3339 timestamp for TTL
+	Hostname        string      `json:"hostname"`
+	OS              string      `json:"os"`
+	Len             int         `json:"len"`
+	MIME            string      `json:"mime"`
+	Encrypted       bool        `json:"encrypted,omitempty"`   // true if data is client-side encrypted
+	Encryption      string      `json:"encryption,omitempty"`  // "age" if Encrypted with age; empty means aes256
+	Compressed      bool        `json:"compressed,omitempty"`  // true if data is compressed
+	CompressionAlgo string      `json:"compression,omitempty"` // "zstd" if Compressed with zstd; empty means gzip
+	Encoding        string      `json:"encoding,omitempty"`    // "base85" if DataB64 is Ascii85; empty means base64
+	DataB64         string      `json:"data_b64"`
+	Blob            string      `json:"blob,omitempty"`   // content hash of the blob holding the data (dedup)
+	Flavor          *SlotFlavor `json:"flavor,omitempty"` // rich clipboard flavor pushed with the text
+}
+
+// slotPayloadVersion is the payload format this pipeboard writes and
+// fully understands. A newer writer that only adds fields older readers
+// can ignore sets min_version to the oldest version that can still read
+// the slot; without it, a newer version is taken as incompatible.
+const slotPayloadVersion = 1
+
+// checkPayloadVersion decides whether this pipeboard can read a payload:
+// known versions decode fully, newer ones that declare themselves
+// readable decode the known fields with a warning, and the rest fail
+func checkPayloadVersion(slot string, payload SlotPayload) error {
+	if payload.Version <= slotPayloadVersion {
+		return nil
+	}
+	if payload.MinVersion == 0 || payload.MinVersion > slotPayloadVersion {
+		return fmt.Errorf("slot %q written by a newer pipeboard (payload version %d), please upgrade to read it", slot, payload.Version)
+	}
+	fmt.Fprintf(os.Stderr, "warning: slot %q was written by a newer pipeboard (payload version %d); reading the fields this version knows\n", slot, payload.Version)
+	return nil
 }
 
 // compressData compresses data using gzip
@@ -60,11 +94,67 @@ func decompressData(data []byte) ([]byte, error) {
 	return io.ReadAll(r)
 }
 
+// Compression settings for sync.compression. gzip is the default and is
+// stored without a compression field, so older clients can still read
+// those slots.
+const (
+	compressionGzip = "gzip"
+	compressionZstd = "zstd"
+	compressionNone = "none"
+)
+
+// validateCompression checks a sync.compression value
+func validateCompression(algo string) error {
+	switch algo {
+	case "", compressionGzip, compressionZstd, compressionNone:
+		return nil
+	default:
+		return fmt.Errorf("unsupported sync.compression: %s (use \"gzip\", \"zstd\" or \"none\")", algo)
+	}
+}
+
+// compressSlotData compresses pushed data over 1KB with algo, keeping the
+// result only if it's smaller. It returns the data to store and the
+// payload's Compressed and CompressionAlgo fields.
+func compressSlotData(data []byte, algo string) (stored []byte, compressed bool, field string) {
+	if len(data) <= 1024 || algo == compressionNone {
+		return data, false, ""
+	}
+	var c []byte
+	var err error
+	if algo == compressionZstd {
+		c, err = zstdCompress(data)
+		field = compressionZstd
+	} else {
+		c, err = compressData(data)
+	}
+	if err != nil || len(c) >= len(data) {
+		return data, false, ""
+	}
+	return c, true, field
+}
+
+// decompressSlotData reverses compressSlotData for a payload's
+// CompressionAlgo field
+func decompressSlotData(data []byte, algo string) ([]byte, error) {
+	switch algo {
+	case "", compressionGzip:
+		return decompressData(data)
+	case compressionZstd:
+		return zstdDecompress(data)
+	default:
+		return nil, fmt.Errorf("unsupported compression %q", algo)
+	}
+}
+
 // detectMIME detects the MIME type of data
 func detectMIME(data []byte) string {
 	if len(data) == 0 {
 		return "text/plain; charset=utf-8"
 	}
+	if isTarArchive(data) {
+		return tarMIME
+	}
 	// Use http.DetectContentType for accurate detection
 	mimeType := http.DetectContentType(data)
 	return mimeType
@@ -77,6 +167,9 @@ func retryWithBackoff(maxRetries int, operation func() error) error {
 		if err := operation(); err != nil {
 			lastErr = err
 			// Don't retry on non-transient errors
+			if errors.Is(err, errSlotChanged) {
+				return err
+			}
 			if strings.Contains(err.Error(), "NoSuchKey") ||
 				strings.Contains(err.Error(), "AccessDenied") ||
 				strings.Contains(err.Error(), "InvalidAccessKeyId") {
@@ -100,6 +193,133 @@ type RemoteSlot struct {
 	CreatedAt time.Time
 	ExpiresAt time.Time // Zero value means no expiry
 	Hostname  string
+	Stored    *SlotPipeline // nil when the backend lists without reading payloads
+
+	// ExpiryEstimated is set when ExpiresAt was derived from the upload
+	// time and the current ttl_days rather than read from the slot
+	ExpiryEstimated bool
+}
+
+// SlotPipeline describes how a slot's data was stored, as recorded in its
+// payload header (not the current config)
+type SlotPipeline struct {
+	Encoding    string `json:"encoding"` // "base64" or "base85"
+	Compressed  bool   `json:"compressed"`
+	Compression string `json:"compression,omitempty"` // "gzip" or "zstd" when Compressed
+	Encrypted   bool   `json:"encrypted"`
+	Encryption  string `json:"encryption,omitempty"` // "age"; empty means aes256 when Encrypted
+}
+
+// payloadPipeline reads the stored pipeline from a payload header
+func payloadPipeline(payload SlotPayload) SlotPipeline {
+	encoding := payload.Encoding
+	if encoding == "" {
+		encoding = payloadEncodingBase64
+	}
+	p := SlotPipeline{Encoding: encoding, Compressed: payload.Compressed, Encrypted: payload.Encrypted, Encryption: payload.Encryption}
+	if p.Compressed {
+		p.Compression = payload.CompressionAlgo
+		if p.Compression == "" {
+			p.Compression = compressionGzip
+		}
+	}
+	return p
+}
+
+// Steps lists the pipeline stages in the order they were applied on push
+func (p SlotPipeline) Steps() []string {
+	var steps []string
+	if p.Compressed {
+		compression := p.Compression
+		if compression == "" {
+			compression = compressionGzip
+		}
+		steps = append(steps, compression)
+	}
+	if p.Encrypted {
+		steps = append(steps, p.Scheme())
+	}
+	return append(steps, p.Encoding)
+}
+
+// Scheme names the encryption the data was stored with, or "none"
+func (p SlotPipeline) Scheme() string {
+	switch {
+	case !p.Encrypted:
+		return "none"
+	case p.Encryption != "":
+		return p.Encryption
+	default:
+		return "aes256"
+	}
+}
+
+// encryptionName is Scheme, or "" for unencrypted data
+func (p SlotPipeline) encryptionName() string {
+	if !p.Encrypted {
+		return ""
+	}
+	return p.Scheme()
+}
+
+// InspectableBackend is implemented by backends that store SlotPayload
+// envelopes and can return one without decoding (or decrypting) its data
+type InspectableBackend interface {
+	// Inspect returns the slot's payload, with dedup blob data filled in,
+	// and the size of the stored slot object
+	Inspect(slot string) (SlotPayload, int64, error)
+}
+
+// PrefixListableBackend is implemented by backends that can list only
+// the slots whose names start with a prefix, filtering on the server
+// instead of fetching every slot
+type PrefixListableBackend interface {
+	ListPrefix(prefix string) ([]RemoteSlot, error)
+}
+
+// SlotLocker is implemented by backends that can hold an exclusive lock on
+// a slot, so push --append's pull and push can't interleave with another
+// writer's
+type SlotLocker interface {
+	LockSlot(slot string) (unlock func(), err error)
+}
+
+// BatchDeleter is implemented by backends whose cleanup after a delete
+// is costly enough to run once for several slots. done is called with
+// each slot's result.
+type BatchDeleter interface {
+	DeleteBatch(slots []string, done func(slot string, err error))
+}
+
+// ConditionalPusher is implemented by backends that can make a push
+// conditional on the slot being unchanged since it was read, so
+// push --append can retry instead of overwriting another writer's push
+// where the backend has no lock
+type ConditionalPusher interface {
+	// PullRevision is Pull, also returning the revision of the slot read
+	PullRevision(slot string) (data []byte, meta map[string]string, rev string, err error)
+	// PushIfRevision pushes only while the slot is at rev, or still doesn't
+	// exist when rev is "", and returns errSlotChanged otherwise
+	PushIfRevision(slot string, data []byte, meta map[string]string, rev string) error
+}
+
+// errSlotChanged is returned by a conditional push when another writer
+// changed the slot first
+var errSlotChanged = errors.New("slot changed since it was read")
+
+// PayloadImporter is implemented by backends that can store a SlotPayload
+// envelope as is, so import restores exported slots without decrypting or
+// re-encrypting them
+type PayloadImporter interface {
+	ImportPayload(slot string, payload SlotPayload) error
+}
+
+// isSlotNotFound reports whether a Pull error means the slot doesn't exist
+// (or has just expired), which backends word differently
+func isSlotNotFound(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "not found") || strings.Contains(msg, "NoSuchKey") ||
+		strings.Contains(msg, "has expired")
 }
 
 // RemoteBackend defines the interface for remote clipboard sync
@@ -110,15 +330,312 @@ type RemoteBackend interface {
 	Delete(slot string) error
 }
 
+// versionsDir is the directory (local) or key prefix (S3) holding slot versions
+const versionsDir = ".versions"
+
+// SlotVersion describes one stored version of a slot
+type SlotVersion struct {
+	ID          int
+	Size        int64 // stored payload size
+	Len         int   // original content length
+	CreatedAt   time.Time
+	Hostname    string
+	OS          string
+	MIME        string
+	Encrypted   bool
+	Encryption  string // "age"; empty means aes256 when Encrypted
+	Compressed  bool
+	Compression string // "gzip" or "zstd" when Compressed
+	Encoding    string // "base64" or "base85"
+}
+
+// VersionedBackend is implemented by backends that keep previous versions
+// of slots when sync.versions is set
+type VersionedBackend interface {
+	ListVersions(slot string) ([]SlotVersion, error)
+	PullVersion(slot string, id int) ([]byte, SlotVersion, error)
+}
+
+// newSlotVersion builds a SlotVersion from a stored payload
+func newSlotVersion(id int, size int64, payload SlotPayload) SlotVersion {
+	created, _ := time.Parse(time.RFC3339, payload.CreatedAt)
+	return SlotVersion{
+		ID:          id,
+		Size:        size,
+		Len:         payload.Len,
+		CreatedAt:   created,
+		Hostname:    payload.Hostname,
+		OS:          payload.OS,
+		MIME:        payload.MIME,
+		Encrypted:   payload.Encrypted,
+		Encryption:  payload.Encryption,
+		Compressed:  payload.Compressed,
+		Compression: payloadPipeline(payload).Compression,
+		Encoding:    payloadPipeline(payload).Encoding,
+	}
+}
+
+// parseVersionID parses a version file/key name like "3.pb"
+func parseVersionID(name string) (int, bool) {
+	if !strings.HasSuffix(name, ".pb") {
+		return 0, false
+	}
+	id, err := strconv.Atoi(strings.TrimSuffix(name, ".pb"))
+	if err != nil || id <= 0 {
+		return 0, false
+	}
+	return id, true
+}
+
+// Payload encodings for sync.encoding. base64 is the default and is stored
+// without an encoding field, so older clients can still read those slots.
+const (
+	payloadEncodingBase64 = "base64"
+	payloadEncodingBase85 = "base85"
+)
+
+// validatePayloadEncoding checks a sync.encoding value
+func validatePayloadEncoding(encoding string) error {
+	switch encoding {
+	case "", payloadEncodingBase64, payloadEncodingBase85:
+		return nil
+	default:
+		return fmt.Errorf("unsupported sync.encoding: %s (use \"base64\" or \"base85\")", encoding)
+	}
+}
+
+// encodePayloadData encodes stored bytes for a payload's DataB64 field and
+// returns the value for its Encoding field
+func encodePayloadData(data []byte, encoding string) (encoded, field string) {
+	if encoding == payloadEncodingBase85 {
+		buf := make([]byte, ascii85.MaxEncodedLen(len(data)))
+		n := ascii85.Encode(buf, data)
+		return string(buf[:n]), payloadEncodingBase85
+	}
+	return base64.StdEncoding.EncodeToString(data), ""
+}
+
+// decodePayloadEncoding reverses encodePayloadData
+func decodePayloadEncoding(payload SlotPayload) ([]byte, error) {
+	switch payload.Encoding {
+	case "", payloadEncodingBase64:
+		data, err := base64.StdEncoding.DecodeString(payload.DataB64)
+		if err != nil {
+			return nil, fmt.Errorf("decoding base64 data: %w", err)
+		}
+		return data, nil
+	case payloadEncodingBase85:
+		// A "z" group expands to four bytes, so 4x is the upper bound
+		buf := make([]byte, 4*len(payload.DataB64))
+		n, _, err := ascii85.Decode(buf, []byte(payload.DataB64), true)
+		if err != nil {
+			return nil, fmt.Errorf("decoding base85 data: %w", err)
+		}
+		return buf[:n], nil
+	default:
+		return nil, fmt.Errorf("unsupported payload encoding %q", payload.Encoding)
+	}
+}
+
+// marshalSlotPayload encodes a payload (or blob) as JSON without HTML
+// escaping: Ascii85 uses '<', '>' and '&', which would otherwise each grow
+// to six bytes
+func marshalSlotPayload(payload SlotPayload, indent string) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", indent)
+	if err := enc.Encode(payload); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// encryptSlotData applies the configured client-side encryption and
+// returns the values for a payload's Encrypted and Encryption fields. aes256
+// is recorded without an encryption field, as before age was supported.
+func encryptSlotData(data []byte, encryption, passphrase string, age *ageKeys) (stored []byte, encrypted bool, field string, err error) {
+	switch {
+	case encryption == "age" && age != nil:
+		stored, err = ageEncrypt(data, age.recipients)
+		field = "age"
+	case encryption == "aes256" && passphrase != "":
+		stored, err = encrypt(data, passphrase)
+	default:
+		return data, false, "", nil
+	}
+	if err != nil {
+		return nil, false, "", fmt.Errorf("encrypting data: %w", err)
+	}
+	return stored, true, field, nil
+}
+
+// decryptSlotData reverses encryptSlotData for the scheme a payload
+// records, whatever the current config's encryption setting
+func decryptSlotData(data []byte, scheme, passphrase string, age *ageKeys) ([]byte, error) {
+	var decData []byte
+	var err error
+	switch scheme {
+	case "age":
+		if age == nil || len(age.identities) == 0 {
+			return nil, fmt.Errorf("slot is encrypted with age but no sync.identity_file is configured")
+		}
+		decData, err = ageDecrypt(data, age.identities)
+	case "", "aes256":
+		if passphrase == "" {
+			return nil, fmt.Errorf("slot is encrypted but no passphrase configured")
+		}
+		decData, err = decrypt(data, passphrase)
+	default:
+		return nil, fmt.Errorf("slot is encrypted with unsupported scheme %q", scheme)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("decrypting data: %w", err)
+	}
+	return decData, nil
+}
+
+// decodePayloadData returns the original content of a payload, reversing
+// encryption and compression
+func decodePayloadData(payload SlotPayload, passphrase string, age *ageKeys) ([]byte, error) {
+	data, err := decodePayloadEncoding(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	// Decrypt if the payload was encrypted (before decompression)
+	if payload.Encrypted {
+		decData, err := decryptSlotData(data, payload.Encryption, passphrase, age)
+		if err != nil {
+			return nil, err
+		}
+		data = decData
+	}
+
+	// Decompress if the payload was compressed (after decryption)
+	if payload.Compressed {
+		decompressedData, err := decompressSlotData(data, payload.CompressionAlgo)
+		if err != nil {
+			return nil, fmt.Errorf("decompressing data: %w", err)
+		}
+		data = decompressedData
+	}
+
+	return data, nil
+}
+
+// blobsDir is the directory (local) or key prefix (S3) holding
+// content-addressed payloads when sync.dedup is set
+const blobsDir = "blobs"
+
+// blobGracePeriod keeps blobs written or reused this recently from being
+// pruned, so a push between storing a blob and its pointer doesn't lose it
+const blobGracePeriod = time.Minute
+
+// blobHash names the dedup blob for data pushed with the given
+// encryption. Encrypted blob names must be keyed by the passphrase:
+// encryption: age has no shared secret, and a plain digest would let
+// anyone who can list the bucket confirm guessed content, so it's refused.
+func blobHash(data []byte, encryption, passphrase string) (string, error) {
+	if encryption == "age" {
+		return "", errors.New("sync.dedup can't be combined with encryption: age")
+	}
+	return contentHash(data, passphrase), nil
+}
+
+// contentHash returns the blob name for data. With a passphrase the hash
+// is keyed, so blob names don't reveal the digest of encrypted content.
+func contentHash(data []byte, passphrase string) string {
+	if passphrase != "" {
+		mac := hmac.New(sha256.New, []byte(passphrase))
+		mac.Write(data)
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// splitBlobPayload splits a payload into a pointer, which keeps the slot
+// metadata, and a blob, which holds the encoded data
+func splitBlobPayload(payload SlotPayload, hash string) (pointer, blob SlotPayload) {
+	blob = SlotPayload{
+		Version:         payload.Version,
+		CreatedAt:       payload.CreatedAt,
+		Len:             payload.Len,
+		MIME:            payload.MIME,
+		Encrypted:       payload.Encrypted,
+		Encryption:      payload.Encryption,
+		Compressed:      payload.Compressed,
+		Encoding:        payload.Encoding,
+		DataB64:         payload.DataB64,
+		CompressionAlgo: payload.CompressionAlgo,
+	}
+	pointer = payload
+	pointer.DataB64 = ""
+	pointer.Blob = hash
+	return pointer, blob
+}
+
+// withBlobData returns pointer with the encoded data of its blob filled in
+func withBlobData(pointer, blob SlotPayload) SlotPayload {
+	pointer.DataB64 = blob.DataB64
+	pointer.Encrypted = blob.Encrypted
+	pointer.Encryption = blob.Encryption
+	pointer.Compressed = blob.Compressed
+	pointer.CompressionAlgo = blob.CompressionAlgo
+	pointer.Encoding = blob.Encoding
+	return pointer
+}
+
 // S3Backend implements RemoteBackend using AWS S3
 type S3Backend struct {
 	client     *s3.Client
 	bucket     string
 	prefix     string
 	sse        string
-	encryption string // "none" or "aes256" for client-side encryption
-	passphrase string // passphrase for client-side encryption
-	ttlDays    int    // TTL in days (0 = never expires)
+	encryption string   // "none" or "aes256" for client-side encryption
+	passphrase string   // passphrase for client-side encryption
+	age        *ageKeys // recipients and identities for encryption: age
+	ttlDays    int      // TTL in days (0 = never expires)
+	versions   int      // versions to keep per slot (0 = off)
+	dedup      bool     // store payloads once under blobs/ (content-addressed)
+	encoding   string   // "base85" stores data as Ascii85 (default base64)
+	compress   string   // sync.compression: "gzip" (default), "zstd" or "none"
+}
+
+// backendDriver builds one kind of sync backend. Each backend registers
+// its driver under the sync.backend name from an init function, so adding
+// a backend doesn't touch the config loader or the dispatcher.
+type backendDriver struct {
+	// validate checks the backend's settings and fills in defaults. Optional.
+	validate func(cfg *SyncConfig) error
+	// open builds the backend from a validated config
+	open func(cfg *SyncConfig) (RemoteBackend, error)
+}
+
+var backendDrivers = map[string]backendDriver{}
+
+// registerBackend makes a backend available as sync.backend: name.
+// Registering the same name twice is a programming error.
+func registerBackend(name string, d backendDriver) {
+	if _, dup := backendDrivers[name]; dup {
+		panic("pipeboard: backend registered twice: " + name)
+	}
+	backendDrivers[name] = d
+}
+
+// lookupBackend returns the driver for a sync.backend name
+func lookupBackend(name string) (backendDriver, error) {
+	d, ok := backendDrivers[name]
+	if !ok {
+		names := make([]string, 0, len(backendDrivers))
+		for n := range backendDrivers {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return backendDriver{}, fmt.Errorf("unsupported backend: %s (available: %s)", name, strings.Join(names, ", "))
+	}
+	return d, nil
 }
 
 func newRemoteBackendFromConfig() (RemoteBackend, error) {
@@ -127,16 +644,64 @@ func newRemoteBackendFromConfig() (RemoteBackend, error) {
 		return nil, err
 	}
 
-	switch cfg.Sync.Backend {
-	case "s3":
-		return newS3Backend(cfg.Sync.S3, cfg.Sync.Encryption, cfg.Sync.Passphrase, cfg.Sync.TTLDays)
-	case "local":
-		return newLocalBackend(cfg.Sync.Local, cfg.Sync.Encryption, cfg.Sync.Passphrase, cfg.Sync.TTLDays)
-	case "hosted":
-		return newHostedBackend(cfg.Sync.Hosted, cfg.Sync.Encryption, cfg.Sync.Passphrase, cfg.Sync.TTLDays)
-	default:
-		return nil, fmt.Errorf("unsupported backend: %s", cfg.Sync.Backend)
+	d, err := lookupBackend(cfg.Sync.Backend)
+	if err != nil {
+		return nil, err
 	}
+	if err := loadPassphraseSource(cfg.Sync); err != nil {
+		return nil, err
+	}
+	return d.open(cfg.Sync)
+}
+
+func init() {
+	registerBackend("s3", backendDriver{
+		validate: func(cfg *SyncConfig) error {
+			if cfg.S3 == nil {
+				return fmt.Errorf("s3 backend selected but s3 config missing")
+			}
+			if cfg.S3.Bucket == "" {
+				return fmt.Errorf("s3.bucket is required")
+			}
+			if cfg.S3.Endpoint != "" {
+				if err := validateS3Endpoint(cfg.S3.Endpoint); err != nil {
+					return err
+				}
+				// R2 and most S3-compatible services ignore the region,
+				// but requests still have to be signed with one
+				if cfg.S3.Region == "" {
+					cfg.S3.Region = "auto"
+				}
+			}
+			if cfg.S3.Region == "" {
+				return fmt.Errorf("s3.region is required")
+			}
+			return nil
+		},
+		open: func(cfg *SyncConfig) (RemoteBackend, error) {
+			b, err := newS3Backend(cfg.S3, cfg.Encryption, cfg.Passphrase, cfg.TTLDays)
+			if err != nil {
+				return nil, err
+			}
+			b.versions = cfg.Versions
+			b.dedup = cfg.Dedup
+			b.encoding = cfg.Encoding
+			b.compress = cfg.Compression
+			if b.age, err = loadAgeKeys(cfg); err != nil {
+				return nil, err
+			}
+			return b, nil
+		},
+	})
+}
+
+// validateS3Endpoint checks s3.endpoint is an http(s) URL with a host
+func validateS3Endpoint(endpoint string) error {
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid s3.endpoint: %q (use a URL like https://<account>.r2.cloudflarestorage.com)", endpoint)
+	}
+	return nil
 }
 
 func newS3Backend(cfg *S3Config, encryption, passphrase string, ttlDays int) (*S3Backend, error) {
@@ -174,7 +739,12 @@ func newS3Backend(cfg *S3Config, encryption, passphrase string, ttlDays int) (*S
 		return nil, fmt.Errorf("loading AWS config: %w", err)
 	}
 
-	client := s3.NewFromConfig(awsCfg)
+	client := s3.NewFromConfig(awsCfg, func(o *s3.Options) {
+		if cfg.Endpoint != "" {
+			o.BaseEndpoint = aws.String(strings.TrimSuffix(cfg.Endpoint, "/"))
+		}
+		o.UsePathStyle = cfg.PathStyle
+	})
 
 	return &S3Backend{
 		client:     client,
@@ -192,49 +762,50 @@ func (b *S3Backend) key(slot string) string {
 }
 
 func (b *S3Backend) Push(slot string, data []byte, meta map[string]string) error {
+	return b.push(slot, data, meta, nil)
+}
+
+// PushIfRevision implements ConditionalPusher; rev is the ETag of the slot
+// read by PullRevision
+func (b *S3Backend) PushIfRevision(slot string, data []byte, meta map[string]string, rev string) error {
+	return b.push(slot, data, meta, &rev)
+}
+
+// push stores a slot, conditional on its ETag when rev is set (see
+// putObject)
+func (b *S3Backend) push(slot string, data []byte, meta map[string]string, rev *string) error {
 	hostname := meta["hostname"]
 	if hostname == "" {
-		hostname, _ = os.Hostname()
+		hostname = slotHostname(nil)
 	}
 
 	// Detect MIME type before any transformations
 	mimeType := detectMIME(data)
 
-	// Store original data for processing
-	storeData := data
-	compressed := false
-	encrypted := false
-
-	// Apply gzip compression for data > 1KB (saves bandwidth/storage)
-	if len(data) > 1024 {
-		compressedData, err := compressData(data)
-		if err == nil && len(compressedData) < len(data) {
-			// Only use compression if it actually reduces size
-			storeData = compressedData
-			compressed = true
-		}
-	}
+	// Compress data > 1KB (saves bandwidth/storage)
+	storeData, compressed, algo := compressSlotData(data, b.compress)
 
 	// Apply client-side encryption if configured (after compression)
-	if b.encryption == "aes256" && b.passphrase != "" {
-		encData, err := encrypt(storeData, b.passphrase)
-		if err != nil {
-			return fmt.Errorf("encrypting data: %w", err)
-		}
-		storeData = encData
-		encrypted = true
+	storeData, encrypted, encryption, err := encryptSlotData(storeData, b.encryption, b.passphrase, b.age)
+	if err != nil {
+		return err
 	}
 
 	payload := SlotPayload{
-		Version:    1,
-		CreatedAt:  time.Now().UTC().Format(time.RFC3339),
-		Hostname:   hostname,
-		OS:         runtime.GOOS,
-		Len:        len(data), // Original length before compression/encryption
-		MIME:       mimeType,
-		Encrypted:  encrypted,
-		Compressed: compressed,
-		DataB64:    base64.StdEncoding.EncodeToString(storeData),
+		Version:         slotPayloadVersion,
+		CreatedAt:       time.Now().UTC().Format(time.RFC3339),
+		Hostname:        hostname,
+		OS:              runtime.GOOS,
+		Len:             len(data), // Original length before compression/encryption
+		MIME:            mimeType,
+		Encrypted:       encrypted,
+		Encryption:      encryption,
+		Compressed:      compressed,
+		CompressionAlgo: algo,
+	}
+	payload.DataB64, payload.Encoding = encodePayloadData(storeData, b.encoding)
+	if payload.Flavor, err = sealSlotFlavor(meta, b.encryption, b.passphrase, b.age); err != nil {
+		return err
 	}
 
 	// Set expiry time if TTL configured
@@ -242,17 +813,110 @@ func (b *S3Backend) Push(slot string, data []byte, meta map[string]string) error
 		payload.ExpiresAt = time.Now().UTC().AddDate(0, 0, b.ttlDays).Format(time.RFC3339)
 	}
 
-	jsonData, err := json.Marshal(payload)
+	// Store the data once under blobs/ and point the slot at it
+	if b.dedup {
+		hash, err := blobHash(data, b.encryption, b.passphrase)
+		if err != nil {
+			return err
+		}
+		pointer, blob := splitBlobPayload(payload, hash)
+		if err := b.saveBlob(hash, blob); err != nil {
+			return err
+		}
+		payload = pointer
+	}
+	return b.writeSlot(slot, payload, rev)
+}
+
+// ImportPayload implements PayloadImporter. The payload is stored inline,
+// since its dedup hash can't be recomputed without the passphrase.
+func (b *S3Backend) ImportPayload(slot string, payload SlotPayload) error {
+	payload.Blob = ""
+	return b.writeSlot(slot, payload, nil)
+}
+
+// writeSlot uploads a slot's payload and keeps a version of it
+func (b *S3Backend) writeSlot(slot string, payload SlotPayload, rev *string) error {
+	jsonData, err := marshalSlotPayload(payload, "")
 	if err != nil {
 		return fmt.Errorf("encoding payload: %w", err)
 	}
 
+	// Replacing a pointer, or trimming the version holding it, can drop
+	// the last reference to a blob
+	dropped := false
+	if b.dedup && b.versions == 0 {
+		if old, err := b.getObject(b.key(slot)); err == nil {
+			var oldPayload SlotPayload
+			if json.Unmarshal(old, &oldPayload) == nil && oldPayload.Blob != "" && oldPayload.Blob != payload.Blob {
+				dropped = true
+			}
+		}
+	}
+
+	if err := b.putSlotObject(b.key(slot), jsonData, rev); err != nil {
+		return err
+	}
+
+	if b.versions > 0 {
+		trimmed, err := b.saveVersion(slot, jsonData)
+		if err != nil {
+			return fmt.Errorf("saving slot version: %w", err)
+		}
+		dropped = b.dedup && trimmed
+	}
+	if dropped {
+		b.pruneBlobs()
+	}
+	return nil
+}
+
+// s3TTLTagKey tags slot objects with sync.ttl_days, so a bucket lifecycle
+// rule filtered on the tag can expire them server-side
+const s3TTLTagKey = "pipeboard-ttl-days"
+
+// ttlTagging returns the object tagging for slots pushed with a TTL
+func (b *S3Backend) ttlTagging() string {
+	if b.ttlDays <= 0 {
+		return ""
+	}
+	return url.Values{s3TTLTagKey: {strconv.Itoa(b.ttlDays)}}.Encode()
+}
+
+// putSlotObject uploads a slot or slot version, tagged with its TTL.
+// Tagging needs s3:PutObjectTagging; credentials without it still push,
+// untagged, with a warning.
+func (b *S3Backend) putSlotObject(key string, body []byte, rev *string) error {
+	tagging := b.ttlTagging()
+	err := b.putObject(key, body, tagging, rev)
+	if err != nil && tagging != "" && strings.Contains(err.Error(), "AccessDenied") {
+		debugLog("tagged upload of %s denied: %v", key, err)
+		if err = b.putObject(key, body, "", rev); err == nil {
+			fmt.Fprintf(os.Stderr, "warning: couldn't tag %s for lifecycle expiry (needs s3:PutObjectTagging); pushed untagged\n", key)
+		}
+	}
+	return err
+}
+
+// putObject uploads an object, applying server-side encryption, tagging
+// (URL-encoded, may be empty), and retries. With rev set the upload is
+// conditional: it only replaces the object with that ETag, or creates one
+// that doesn't exist when rev is "", and fails with errSlotChanged.
+func (b *S3Backend) putObject(key string, body []byte, tagging string, rev *string) error {
 	input := &s3.PutObjectInput{
 		Bucket:      aws.String(b.bucket),
-		Key:         aws.String(b.key(slot)),
-		Body:        bytes.NewReader(jsonData),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(body),
 		ContentType: aws.String("application/json"),
 	}
+	if tagging != "" {
+		input.Tagging = aws.String(tagging)
+	}
+	if rev != nil && *rev == "" {
+		input.IfNoneMatch = aws.String("*")
+	} else if rev != nil {
+		input.IfMatch = aws.String(*rev)
+	}
 
 	// Apply server-side encryption
 	switch b.sse {
@@ -267,40 +931,68 @@ func (b *S3Backend) Push(slot string, data []byte, meta map[string]string) error
 		ctx := context.Background()
 		_, err := b.client.PutObject(ctx, input)
 		if err != nil {
+			// S3 answers 409 when a concurrent conditional write is in flight
+			if strings.Contains(err.Error(), "PreconditionFailed") || strings.Contains(err.Error(), "ConditionalRequestConflict") {
+				return fmt.Errorf("uploading to S3: %w", errSlotChanged)
+			}
 			return fmt.Errorf("uploading to S3: %w", err)
 		}
 		return nil
 	})
 }
 
-func (b *S3Backend) Pull(slot string) ([]byte, map[string]string, error) {
-	var jsonData []byte
+// getObject downloads an object with retries
+func (b *S3Backend) getObject(key string) ([]byte, error) {
+	body, _, err := b.getObjectRevision(key)
+	return body, err
+}
+
+// getObjectRevision downloads an object with retries, returning its ETag
+func (b *S3Backend) getObjectRevision(key string) ([]byte, string, error) {
+	var body []byte
+	var etag string
 
 	// Use retry with exponential backoff for network resilience
 	err := retryWithBackoff(3, func() error {
 		ctx := context.Background()
 		result, err := b.client.GetObject(ctx, &s3.GetObjectInput{
 			Bucket: aws.String(b.bucket),
-			Key:    aws.String(b.key(slot)),
+			Key:    aws.String(key),
 		})
 		if err != nil {
 			return fmt.Errorf("fetching from S3: %w", err)
 		}
 		defer func() { _ = result.Body.Close() }()
 
-		jsonData, err = io.ReadAll(result.Body)
+		body, err = io.ReadAll(result.Body)
 		if err != nil {
 			return fmt.Errorf("reading S3 object: %w", err)
 		}
+		etag = aws.ToString(result.ETag)
 		return nil
 	})
+	return body, etag, err
+}
+
+func (b *S3Backend) Pull(slot string) ([]byte, map[string]string, error) {
+	data, meta, _, err := b.PullRevision(slot)
+	return data, meta, err
+}
+
+// PullRevision implements ConditionalPusher; the revision is the slot
+// object's ETag
+func (b *S3Backend) PullRevision(slot string) ([]byte, map[string]string, string, error) {
+	jsonData, etag, err := b.getObjectRevision(b.key(slot))
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, "", err
 	}
 
 	var payload SlotPayload
 	if err := json.Unmarshal(jsonData, &payload); err != nil {
-		return nil, nil, fmt.Errorf("decoding payload: %w", err)
+		return nil, nil, "", fmt.Errorf("decoding payload: %w", err)
+	}
+	if err := checkPayloadVersion(slot, payload); err != nil {
+		return nil, nil, "", err
 	}
 
 	// Check if slot has expired
@@ -309,57 +1001,220 @@ func (b *S3Backend) Pull(slot string) ([]byte, map[string]string, error) {
 		if err == nil && time.Now().UTC().After(expiresAt) {
 			// Auto-delete expired slot
 			_ = b.Delete(slot)
-			return nil, nil, fmt.Errorf("slot %q has expired", slot)
+			return nil, nil, "", fmt.Errorf("slot %q has expired", slot)
 		}
 	}
 
-	data, err := base64.StdEncoding.DecodeString(payload.DataB64)
+	payload, err = b.resolveBlob(payload)
 	if err != nil {
-		return nil, nil, fmt.Errorf("decoding base64 data: %w", err)
+		return nil, nil, "", err
 	}
 
-	// Decrypt if the payload was encrypted (before decompression)
-	if payload.Encrypted {
-		if b.passphrase == "" {
-			return nil, nil, fmt.Errorf("slot is encrypted but no passphrase configured")
+	data, err := decodePayloadData(payload, b.passphrase, b.age)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	meta := map[string]string{
+		"hostname":   payload.Hostname,
+		"os":         payload.OS,
+		"created_at": payload.CreatedAt,
+		"mime":       payload.MIME,
+	}
+	if err := addFlavorMeta(meta, payload.Flavor, b.passphrase, b.age); err != nil {
+		return nil, nil, "", err
+	}
+
+	return data, meta, etag, nil
+}
+
+func (b *S3Backend) blobKey(hash string) string {
+	return path.Join(b.prefix, blobsDir, hash+".pb")
+}
+
+// saveBlob uploads a blob unless one with the same hash already exists.
+// Blobs are tagged with the TTL like slots; a reused blob is copied onto
+// itself, which restarts its lifecycle age and keeps pruneBlobs off it
+// until the new pointer is written.
+func (b *S3Backend) saveBlob(hash string, blob SlotPayload) error {
+	ctx := context.Background()
+	key := b.blobKey(hash)
+	_, err := b.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(b.bucket),
+		Key:    aws.String(key),
+	})
+	if err == nil {
+		debugLog("blob %s already stored, skipping upload", hash)
+		if err := b.refreshBlob(key); err != nil {
+			debugLog("refreshing blob %s: %v", hash, err)
 		}
-		decData, err := decrypt(data, b.passphrase)
+		return nil
+	}
+	if !strings.Contains(err.Error(), "NotFound") && !strings.Contains(err.Error(), "NoSuchKey") {
+		return fmt.Errorf("checking blob in S3: %w", err)
+	}
+
+	jsonData, err := marshalSlotPayload(blob, "")
+	if err != nil {
+		return fmt.Errorf("encoding blob: %w", err)
+	}
+	return b.putSlotObject(key, jsonData, nil)
+}
+
+// refreshBlob copies a blob onto itself with the current TTL tagging
+func (b *S3Backend) refreshBlob(key string) error {
+	input := &s3.CopyObjectInput{
+		Bucket:            aws.String(b.bucket),
+		Key:               aws.String(key),
+		CopySource:        aws.String(b.bucket + "/" + key),
+		ContentType:       aws.String("application/json"),
+		MetadataDirective: types.MetadataDirectiveReplace,
+		TaggingDirective:  types.TaggingDirectiveReplace,
+	}
+	if tagging := b.ttlTagging(); tagging != "" {
+		input.Tagging = aws.String(tagging)
+	}
+	switch b.sse {
+	case "AES256":
+		input.ServerSideEncryption = types.ServerSideEncryptionAes256
+	case "aws:kms":
+		input.ServerSideEncryption = types.ServerSideEncryptionAwsKms
+	}
+	_, err := b.client.CopyObject(context.Background(), input)
+	return err
+}
+
+// pruneBlobs deletes the dedup blobs that no slot or stored version points
+// at any more, so slot data doesn't stay behind in the bucket after rm or
+// expiry. Blobs written or reused within blobGracePeriod are kept.
+func (b *S3Backend) pruneBlobs() {
+	ctx := context.Background()
+	listPrefix := b.prefix
+	if listPrefix != "" {
+		listPrefix = strings.TrimSuffix(listPrefix, "/") + "/"
+	}
+	blobPrefix := path.Join(b.prefix, blobsDir) + "/"
+	paginator := s3.NewListObjectsV2Paginator(b.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(b.bucket),
+		Prefix: aws.String(listPrefix),
+	})
+
+	blobs := map[string]time.Time{}
+	var pointers []string
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
 		if err != nil {
-			return nil, nil, fmt.Errorf("decrypting data: %w", err)
+			debugLog("not pruning blobs: listing S3 objects: %v", err)
+			return
 		}
-		data = decData
+		for _, obj := range page.Contents {
+			key := aws.ToString(obj.Key)
+			if !strings.HasSuffix(key, ".pb") {
+				continue
+			}
+			if hash, ok := strings.CutPrefix(key, blobPrefix); ok {
+				blobs[strings.TrimSuffix(hash, ".pb")] = aws.ToTime(obj.LastModified)
+			} else {
+				pointers = append(pointers, key)
+			}
+		}
+	}
+	if len(blobs) == 0 {
+		return
 	}
 
-	// Decompress if the payload was compressed (after decryption)
-	if payload.Compressed {
-		decompressedData, err := decompressData(data)
+	referenced := map[string]bool{}
+	for _, key := range pointers {
+		jsonData, err := b.getObject(key)
 		if err != nil {
-			return nil, nil, fmt.Errorf("decompressing data: %w", err)
+			// Without the full picture, keeping every blob is the safe choice
+			debugLog("not pruning blobs: %v", err)
+			return
+		}
+		var payload SlotPayload
+		if json.Unmarshal(jsonData, &payload) == nil && payload.Blob != "" {
+			referenced[payload.Blob] = true
 		}
-		data = decompressedData
 	}
 
-	meta := map[string]string{
-		"hostname":   payload.Hostname,
-		"os":         payload.OS,
-		"created_at": payload.CreatedAt,
-		"mime":       payload.MIME,
+	cutoff := time.Now().Add(-blobGracePeriod)
+	for hash, modified := range blobs {
+		if referenced[hash] || modified.After(cutoff) {
+			continue
+		}
+		debugLog("removing unreferenced blob %s", hash)
+		_, _ = b.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+			Bucket: aws.String(b.bucket),
+			Key:    aws.String(b.blobKey(hash)),
+		})
+	}
+}
+
+// Inspect implements InspectableBackend
+func (b *S3Backend) Inspect(slot string) (SlotPayload, int64, error) {
+	jsonData, err := b.getObject(b.key(slot))
+	if err != nil {
+		return SlotPayload{}, 0, err
+	}
+	var payload SlotPayload
+	if err := json.Unmarshal(jsonData, &payload); err != nil {
+		return SlotPayload{}, 0, fmt.Errorf("decoding payload: %w", err)
 	}
+	payload, err = b.resolveBlob(payload)
+	if err != nil {
+		return SlotPayload{}, 0, err
+	}
+	return payload, int64(len(jsonData)), nil
+}
 
-	return data, meta, nil
+// resolveBlob fills in the data of a pointer payload from its blob
+func (b *S3Backend) resolveBlob(payload SlotPayload) (SlotPayload, error) {
+	if payload.Blob == "" {
+		return payload, nil
+	}
+	jsonData, err := b.getObject(b.blobKey(payload.Blob))
+	if err != nil {
+		return SlotPayload{}, fmt.Errorf("reading blob %s: %w", payload.Blob, err)
+	}
+	var blob SlotPayload
+	if err := json.Unmarshal(jsonData, &blob); err != nil {
+		return SlotPayload{}, fmt.Errorf("decoding blob: %w", err)
+	}
+	return withBlobData(payload, blob), nil
 }
 
 func (b *S3Backend) List() ([]RemoteSlot, error) {
+	return b.listSlots(b.prefix)
+}
+
+// ListPrefix implements PrefixListableBackend. S3 returns only the keys
+// under the slot name prefix, so large buckets aren't listed in full.
+func (b *S3Backend) ListPrefix(prefix string) ([]RemoteSlot, error) {
+	if prefix == "" {
+		return b.List()
+	}
+	keyPrefix := prefix
+	if b.prefix != "" {
+		keyPrefix = strings.TrimSuffix(b.prefix, "/") + "/" + prefix
+	}
+	return b.listSlots(keyPrefix)
+}
+
+// listSlots lists the slots whose keys start with keyPrefix
+func (b *S3Backend) listSlots(keyPrefix string) ([]RemoteSlot, error) {
 	ctx := context.Background()
 
 	// Note: Unlike LocalBackend, we don't check expiry here because it would
 	// require fetching each object's content (expensive S3 GET requests).
-	// Expired slots are cleaned up lazily on Pull() instead.
+	// Expired slots are cleaned up lazily on Pull() instead. The expiry
+	// shown is estimated from the object's last-modified time and the
+	// current TTL, and marked as such: Pull enforces the expiry recorded
+	// in the payload, which differs once ttl_days changes.
 
 	// Use paginator to handle more than 1000 objects
 	paginator := s3.NewListObjectsV2Paginator(b.client, &s3.ListObjectsV2Input{
 		Bucket: aws.String(b.bucket),
-		Prefix: aws.String(b.prefix),
+		Prefix: aws.String(keyPrefix),
 	})
 
 	var slots []RemoteSlot
@@ -382,11 +1237,20 @@ func (b *S3Backend) List() ([]RemoteSlot, error) {
 			name = strings.TrimPrefix(name, "/")
 			name = strings.TrimSuffix(name, ".pb")
 
+			// Skip stored versions and blobs
+			if strings.HasPrefix(name, versionsDir+"/") || strings.HasPrefix(name, blobsDir+"/") {
+				continue
+			}
+
 			slot := RemoteSlot{
 				Name:      name,
 				Size:      aws.ToInt64(obj.Size),
 				CreatedAt: aws.ToTime(obj.LastModified),
 			}
+			if b.ttlDays > 0 {
+				slot.ExpiresAt = slot.CreatedAt.AddDate(0, 0, b.ttlDays)
+				slot.ExpiryEstimated = true
+			}
 
 			// Try to get hostname from object metadata (optional, may require HEAD request)
 			// For now, we'll get it when showing details
@@ -398,6 +1262,29 @@ func (b *S3Backend) List() ([]RemoteSlot, error) {
 }
 
 func (b *S3Backend) Delete(slot string) error {
+	if err := b.deleteSlot(slot); err != nil {
+		return err
+	}
+	if b.dedup {
+		b.pruneBlobs()
+	}
+	return nil
+}
+
+// DeleteBatch implements BatchDeleter. Finding unreferenced blobs lists
+// the bucket and reads every pointer, so it runs once for the batch.
+func (b *S3Backend) DeleteBatch(slots []string, done func(slot string, err error)) {
+	for _, slot := range slots {
+		done(slot, b.deleteSlot(slot))
+	}
+	if b.dedup {
+		b.pruneBlobs()
+	}
+}
+
+// deleteSlot removes a slot and its stored versions, leaving any blobs
+// they pointed at to pruneBlobs
+func (b *S3Backend) deleteSlot(slot string) error {
 	ctx := context.Background()
 
 	_, err := b.client.DeleteObject(ctx, &s3.DeleteObjectInput{
@@ -408,6 +1295,190 @@ func (b *S3Backend) Delete(slot string) error {
 		return fmt.Errorf("deleting from S3: %w", err)
 	}
 
+	// Remove stored versions along with the slot (best-effort)
+	if ids, err := b.versionIDs(slot); err == nil {
+		for _, id := range ids {
+			_, _ = b.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+				Bucket: aws.String(b.bucket),
+				Key:    aws.String(b.versionKey(slot, id)),
+			})
+		}
+	}
+	return nil
+}
+
+func (b *S3Backend) versionPrefix(slot string) string {
+	return path.Join(b.prefix, versionsDir, slot) + "/"
+}
+
+func (b *S3Backend) versionKey(slot string, id int) string {
+	return b.versionPrefix(slot) + strconv.Itoa(id) + ".pb"
+}
+
+// versionIDs returns the stored version IDs for a slot in ascending order
+func (b *S3Backend) versionIDs(slot string) ([]int, error) {
+	ctx := context.Background()
+	prefix := b.versionPrefix(slot)
+	paginator := s3.NewListObjectsV2Paginator(b.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(b.bucket),
+		Prefix: aws.String(prefix),
+	})
+
+	var ids []int
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("listing S3 objects: %w", err)
+		}
+		for _, obj := range page.Contents {
+			if id, ok := parseVersionID(strings.TrimPrefix(aws.ToString(obj.Key), prefix)); ok {
+				ids = append(ids, id)
+			}
+		}
+	}
+	sort.Ints(ids)
+	return ids, nil
+}
+
+// saveVersion stores a copy of the pushed payload and prunes old versions,
+// reporting whether any were removed
+func (b *S3Backend) saveVersion(slot string, jsonData []byte) (bool, error) {
+	ids, err := b.versionIDs(slot)
+	if err != nil {
+		return false, err
+	}
+	next := 1
+	if len(ids) > 0 {
+		next = ids[len(ids)-1] + 1
+	}
+
+	if err := b.putSlotObject(b.versionKey(slot, next), jsonData, nil); err != nil {
+		return false, err
+	}
+
+	ctx := context.Background()
+	ids = append(ids, next)
+	trimmed := len(ids) > b.versions
+	for len(ids) > b.versions {
+		_, _ = b.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+			Bucket: aws.String(b.bucket),
+			Key:    aws.String(b.versionKey(slot, ids[0])),
+		})
+		ids = ids[1:]
+	}
+	return trimmed, nil
+}
+
+// readVersion fetches a stored version's payload
+func (b *S3Backend) readVersion(slot string, id int) (SlotPayload, int64, error) {
+	ctx := context.Background()
+	result, err := b.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(b.bucket),
+		Key:    aws.String(b.versionKey(slot, id)),
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "NoSuchKey") {
+			return SlotPayload{}, 0, fmt.Errorf("version %d of slot %q not found", id, slot)
+		}
+		return SlotPayload{}, 0, fmt.Errorf("fetching from S3: %w", err)
+	}
+	defer func() { _ = result.Body.Close() }()
+
+	jsonData, err := io.ReadAll(result.Body)
+	if err != nil {
+		return SlotPayload{}, 0, fmt.Errorf("reading S3 object: %w", err)
+	}
+	var payload SlotPayload
+	if err := json.Unmarshal(jsonData, &payload); err != nil {
+		return SlotPayload{}, 0, fmt.Errorf("decoding payload: %w", err)
+	}
+	return payload, int64(len(jsonData)), nil
+}
+
+func (b *S3Backend) ListVersions(slot string) ([]SlotVersion, error) {
+	ids, err := b.versionIDs(slot)
+	if err != nil {
+		return nil, err
+	}
+	versions := make([]SlotVersion, 0, len(ids))
+	for _, id := range ids {
+		payload, size, err := b.readVersion(slot, id)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, newSlotVersion(id, size, payload))
+	}
+	return versions, nil
+}
+
+func (b *S3Backend) PullVersion(slot string, id int) ([]byte, SlotVersion, error) {
+	payload, size, err := b.readVersion(slot, id)
+	if err != nil {
+		return nil, SlotVersion{}, err
+	}
+	if err := checkPayloadVersion(slot, payload); err != nil {
+		return nil, SlotVersion{}, err
+	}
+	payload, err = b.resolveBlob(payload)
+	if err != nil {
+		return nil, SlotVersion{}, err
+	}
+	data, err := decodePayloadData(payload, b.passphrase, b.age)
+	if err != nil {
+		return nil, SlotVersion{}, err
+	}
+	return data, newSlotVersion(id, size, payload), nil
+}
+
+// multipartUpload is an incomplete S3 multipart upload
+type multipartUpload struct {
+	Key       string
+	UploadID  string
+	Initiated time.Time
+}
+
+// listMultipartUploads returns the incomplete multipart uploads under the
+// backend's prefix. Their parts are billed until the upload is aborted.
+func (b *S3Backend) listMultipartUploads() ([]multipartUpload, error) {
+	ctx := context.Background()
+	input := &s3.ListMultipartUploadsInput{
+		Bucket: aws.String(b.bucket),
+		Prefix: aws.String(b.prefix),
+	}
+
+	var uploads []multipartUpload
+	for {
+		page, err := b.client.ListMultipartUploads(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("listing S3 multipart uploads: %w", err)
+		}
+		for _, u := range page.Uploads {
+			uploads = append(uploads, multipartUpload{
+				Key:       aws.ToString(u.Key),
+				UploadID:  aws.ToString(u.UploadId),
+				Initiated: aws.ToTime(u.Initiated),
+			})
+		}
+		if !aws.ToBool(page.IsTruncated) {
+			break
+		}
+		input.KeyMarker = page.NextKeyMarker
+		input.UploadIdMarker = page.NextUploadIdMarker
+	}
+	return uploads, nil
+}
+
+// abortMultipartUpload aborts an upload and frees its stored parts
+func (b *S3Backend) abortMultipartUpload(u multipartUpload) error {
+	ctx := context.Background()
+	_, err := b.client.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(b.bucket),
+		Key:      aws.String(u.Key),
+		UploadId: aws.String(u.UploadID),
+	})
+	if err != nil {
+		return fmt.Errorf("aborting multipart upload of %s: %w", u.Key, err)
+	}
 	return nil
 }
 
@@ -459,3 +1530,12 @@ func formatTimeUntil(t time.Time) string {
 	}
 	return fmt.Sprintf("%dd", int(d.Hours()/24))
 }
+
+// formatExpiry returns a countdown for display ("in 2d"), or "expired"
+// once t has passed
+func formatExpiry(t time.Time) string {
+	if time.Until(t) < 0 {
+		return "expired"
+	}
+	return "in " + formatTimeUntil(t)
+}
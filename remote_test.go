@@ -1,13 +1,28 @@
 package main
 
 import (
+	"context"
+	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
+
+	"filippo.io/age"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
 )
 
 func TestSlotPayloadEncoding(t *testing.T) {
@@ -203,7 +218,7 @@ func TestNewRemoteBackendFromConfigUnsupportedBackend(t *testing.T) {
 	// Config with unsupported backend
 	configContent := `version: 1
 sync:
-  backend: gcs
+  backend: azure
 `
 	if err := os.WriteFile(configFile, []byte(configContent), 0644); err != nil {
 		t.Fatalf("failed to write config: %v", err)
@@ -483,6 +498,44 @@ func TestNewS3BackendEncryptionNoPassphrase(t *testing.T) {
 	}
 }
 
+// Test s3.endpoint and s3.path_style reach the client, and an endpoint
+// lets the region default to auto
+func TestNewS3BackendEndpoint(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "minio")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "minio123")
+	sync := &SyncConfig{
+		Backend: "s3",
+		S3:      &S3Config{Bucket: "clips", Endpoint: "http://localhost:9000/", PathStyle: true},
+	}
+	if err := validateSyncConfig(&Config{Sync: sync}); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	if sync.S3.Region != "auto" {
+		t.Errorf("region = %q, want auto", sync.S3.Region)
+	}
+
+	b, err := newS3Backend(sync.S3, "", "", 0)
+	if err != nil {
+		t.Fatalf("newS3Backend: %v", err)
+	}
+	opts := b.client.Options()
+	if opts.BaseEndpoint == nil || *opts.BaseEndpoint != "http://localhost:9000" {
+		t.Errorf("BaseEndpoint = %v, want http://localhost:9000", opts.BaseEndpoint)
+	}
+	if !opts.UsePathStyle {
+		t.Error("UsePathStyle should be set")
+	}
+
+	// Without an endpoint the client talks to AWS
+	b, err = newS3Backend(&S3Config{Bucket: "clips", Region: "us-east-1"}, "", "", 0)
+	if err != nil {
+		t.Fatalf("newS3Backend: %v", err)
+	}
+	if opts := b.client.Options(); opts.BaseEndpoint != nil || opts.UsePathStyle {
+		t.Errorf("default client has BaseEndpoint %v, UsePathStyle %v", opts.BaseEndpoint, opts.UsePathStyle)
+	}
+}
+
 func TestRetryWithBackoffNoSuchKeyError(t *testing.T) {
 	// NoSuchKey errors should not retry
 	attempts := 0
@@ -724,3 +777,832 @@ func TestSlotPayloadAllFieldsSet(t *testing.T) {
 		}
 	}
 }
+
+// mockMultipartS3 is a minimal S3 server that tracks multipart uploads
+type mockMultipartS3 struct {
+	mu      sync.Mutex
+	nextID  int
+	uploads map[string]*mockUpload // by upload ID
+}
+
+type mockUpload struct {
+	key       string
+	initiated time.Time
+	parts     int
+}
+
+func (m *mockMultipartS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Path-style: /<bucket>/<key>
+	key := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 2)
+	q := r.URL.Query()
+	switch {
+	case r.Method == http.MethodPost && q.Has("uploads"):
+		m.nextID++
+		id := fmt.Sprintf("upload-%d", m.nextID)
+		m.uploads[id] = &mockUpload{key: key[1], initiated: time.Now()}
+		fmt.Fprintf(w, `<InitiateMultipartUploadResult><Bucket>%s</Bucket><Key>%s</Key><UploadId>%s</UploadId></InitiateMultipartUploadResult>`, key[0], key[1], id)
+	case r.Method == http.MethodPut && q.Has("uploadId"):
+		u, ok := m.uploads[q.Get("uploadId")]
+		if !ok {
+			http.Error(w, `<Error><Code>NoSuchUpload</Code></Error>`, http.StatusNotFound)
+			return
+		}
+		u.parts++
+		w.Header().Set("ETag", `"etag"`)
+	case r.Method == http.MethodGet && q.Has("uploads"):
+		var b strings.Builder
+		b.WriteString(`<ListMultipartUploadsResult><IsTruncated>false</IsTruncated>`)
+		for id, u := range m.uploads {
+			if strings.HasPrefix(u.key, q.Get("prefix")) {
+				fmt.Fprintf(&b, `<Upload><Key>%s</Key><UploadId>%s</UploadId><Initiated>%s</Initiated></Upload>`,
+					u.key, id, u.initiated.UTC().Format(time.RFC3339))
+			}
+		}
+		b.WriteString(`</ListMultipartUploadsResult>`)
+		_, _ = w.Write([]byte(b.String()))
+	case r.Method == http.MethodDelete && q.Has("uploadId"):
+		delete(m.uploads, q.Get("uploadId"))
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "unexpected request", http.StatusBadRequest)
+	}
+}
+
+// newMockS3Backend returns an S3Backend talking to a mock server
+func newMockS3Backend(t *testing.T, handler http.Handler, prefix string) *S3Backend {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	client := s3.New(s3.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(srv.URL),
+		UsePathStyle: true,
+		Credentials:  credentials.NewStaticCredentialsProvider("AKIDTEST", "secret", ""),
+	})
+	return &S3Backend{client: client, bucket: "bucket", prefix: prefix}
+}
+
+// Test prune aborts a multipart upload left behind by a failed push
+func TestPruneMultipartUploadsAbortsStale(t *testing.T) {
+	mock := &mockMultipartS3{uploads: make(map[string]*mockUpload)}
+	b := newMockS3Backend(t, mock, "clips/")
+	ctx := context.Background()
+
+	// Simulate a push that failed after uploading its first part
+	created, err := b.client.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
+		Bucket: aws.String("bucket"),
+		Key:    aws.String("clips/big.pb"),
+	})
+	if err != nil {
+		t.Fatalf("CreateMultipartUpload: %v", err)
+	}
+	if _, err := b.client.UploadPart(ctx, &s3.UploadPartInput{
+		Bucket:     aws.String("bucket"),
+		Key:        aws.String("clips/big.pb"),
+		UploadId:   created.UploadId,
+		PartNumber: aws.Int32(1),
+		Body:       strings.NewReader("part one"),
+	}); err != nil {
+		t.Fatalf("UploadPart: %v", err)
+	}
+
+	// An upload outside the prefix is not ours
+	mock.uploads["other"] = &mockUpload{key: "elsewhere/x", initiated: time.Now().Add(-72 * time.Hour)}
+
+	// Recent uploads are left alone
+	var n int
+	captureOutput(func() {
+		n, err = pruneMultipartUploads(b, time.Hour, false)
+	})
+	if err != nil || n != 0 {
+		t.Fatalf("recent upload should be skipped, got n=%d err=%v", n, err)
+	}
+
+	mock.uploads[aws.ToString(created.UploadId)].initiated = time.Now().Add(-48 * time.Hour)
+
+	// Dry run reports without aborting
+	output := captureOutput(func() {
+		n, err = pruneMultipartUploads(b, time.Hour, true)
+	})
+	if err != nil || n != 1 || !strings.Contains(output, "would abort clips/big.pb") {
+		t.Fatalf("dry run: n=%d err=%v output=%q", n, err, output)
+	}
+	if len(mock.uploads) != 2 {
+		t.Fatal("dry run should not abort uploads")
+	}
+
+	captureOutput(func() {
+		n, err = pruneMultipartUploads(b, time.Hour, false)
+	})
+	if err != nil || n != 1 {
+		t.Fatalf("prune: n=%d err=%v", n, err)
+	}
+	if _, ok := mock.uploads[aws.ToString(created.UploadId)]; ok {
+		t.Error("stale upload should have been aborted")
+	}
+	if _, ok := mock.uploads["other"]; !ok {
+		t.Error("upload outside the prefix should be left alone")
+	}
+}
+
+// Test prune argument handling and backend requirement
+func TestCmdPrune(t *testing.T) {
+	if err := cmdPrune(nil); err == nil || !strings.Contains(err.Error(), "usage") {
+		t.Errorf("expected usage error, got %v", err)
+	}
+	if err := cmdPrune([]string{"--s3-multipart", "--older-than", "soon"}); err == nil {
+		t.Error("expected invalid duration error")
+	}
+
+	tmpDir := t.TempDir()
+	configPath := tmpDir + "/config.yaml"
+	if err := os.WriteFile(configPath, []byte("version: 1\nsync:\n  backend: local\n  local:\n    path: "+tmpDir+"/slots\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PIPEBOARD_CONFIG", configPath)
+	if err := cmdPrune([]string{"--s3-multipart"}); err == nil || !strings.Contains(err.Error(), "s3") {
+		t.Errorf("expected s3 backend error, got %v", err)
+	}
+}
+
+// memBackend is an in-memory RemoteBackend registered as "memory" by
+// tests, standing in for a third-party backend
+type memBackend struct {
+	mu    sync.Mutex
+	slots map[string]memSlot
+}
+
+type memSlot struct {
+	data    []byte
+	meta    map[string]string
+	created time.Time
+}
+
+func (m *memBackend) Push(slot string, data []byte, meta map[string]string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stored := map[string]string{"hostname": meta["hostname"]}
+	m.slots[slot] = memSlot{data: append([]byte(nil), data...), meta: stored, created: time.Now()}
+	return nil
+}
+
+func (m *memBackend) Pull(slot string) ([]byte, map[string]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.slots[slot]
+	if !ok {
+		return nil, nil, fmt.Errorf("slot %q not found", slot)
+	}
+	return s.data, s.meta, nil
+}
+
+func (m *memBackend) List() ([]RemoteSlot, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []RemoteSlot
+	for name, s := range m.slots {
+		out = append(out, RemoteSlot{Name: name, Size: int64(len(s.data)), CreatedAt: s.created, Hostname: s.meta["hostname"]})
+	}
+	return out, nil
+}
+
+func (m *memBackend) Delete(slot string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.slots[slot]; !ok {
+		return fmt.Errorf("slot %q not found", slot)
+	}
+	delete(m.slots, slot)
+	return nil
+}
+
+// registerMemBackend registers memBackend for the duration of the test
+func registerMemBackend(t *testing.T) {
+	t.Helper()
+	registerBackend("memory", backendDriver{
+		open: func(cfg *SyncConfig) (RemoteBackend, error) {
+			return &memBackend{slots: make(map[string]memSlot)}, nil
+		},
+	})
+	t.Cleanup(func() { delete(backendDrivers, "memory") })
+}
+
+// testBackendConformance checks the Push/Pull/List/Delete semantics every
+// backend must share
+func testBackendConformance(t *testing.T, b RemoteBackend) {
+	t.Helper()
+
+	slots, err := b.List()
+	if err != nil || len(slots) != 0 {
+		t.Fatalf("new backend should list no slots, got %v, %v", slots, err)
+	}
+	if _, _, err := b.Pull("missing"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("Pull of a missing slot should report not found, got %v", err)
+	}
+
+	if err := b.Push("alpha", []byte("first"), map[string]string{"hostname": "box1"}); err != nil {
+		t.Fatalf("Push: %v", err)
+	}
+	if err := b.Push("alpha", []byte("second"), map[string]string{"hostname": "box2"}); err != nil {
+		t.Fatalf("Push overwrite: %v", err)
+	}
+	if err := b.Push("beta", []byte("other"), map[string]string{}); err != nil {
+		t.Fatalf("Push: %v", err)
+	}
+
+	data, meta, err := b.Pull("alpha")
+	if err != nil {
+		t.Fatalf("Pull: %v", err)
+	}
+	if string(data) != "second" || meta["hostname"] != "box2" {
+		t.Errorf("Pull should return the latest push, got %q %v", data, meta)
+	}
+
+	slots, err = b.List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	names := make([]string, 0, len(slots))
+	for _, s := range slots {
+		names = append(names, s.Name)
+		if s.CreatedAt.IsZero() {
+			t.Errorf("slot %q has no creation time", s.Name)
+		}
+	}
+	sort.Strings(names)
+	if strings.Join(names, ",") != "alpha,beta" {
+		t.Errorf("List should return each slot once, got %v", names)
+	}
+
+	if err := b.Delete("alpha"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, _, err := b.Pull("alpha"); err == nil {
+		t.Error("Pull after Delete should fail")
+	}
+	if err := b.Delete("alpha"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("Delete of a missing slot should report not found, got %v", err)
+	}
+	if slots, _ := b.List(); len(slots) != 1 || slots[0].Name != "beta" {
+		t.Errorf("List after Delete should only have beta, got %v", slots)
+	}
+}
+
+// Test the local and a registered third-party backend behave alike,
+// both opened through the registry from config
+func TestBackendConformance(t *testing.T) {
+	registerMemBackend(t)
+
+	for name, cfg := range map[string]string{
+		"local":  "version: 1\nsync:\n  backend: local\n",
+		"memory": "version: 1\nsync:\n  backend: memory\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			cleanup := setupSlotsTestConfig(t, cfg)
+			defer cleanup()
+
+			b, err := newRemoteBackendFromConfig()
+			if err != nil {
+				t.Fatalf("newRemoteBackendFromConfig: %v", err)
+			}
+			testBackendConformance(t, b)
+		})
+	}
+}
+
+// Test the registry lists available backends and rejects duplicates
+func TestBackendRegistry(t *testing.T) {
+	for _, name := range []string{"s3", "gcs", "local", "hosted"} {
+		if _, err := lookupBackend(name); err != nil {
+			t.Errorf("built-in backend %q should be registered: %v", name, err)
+		}
+	}
+
+	_, err := lookupBackend("azure")
+	if err == nil || !strings.Contains(err.Error(), "available: gcs, hosted, local, s3") {
+		t.Errorf("expected unsupported backend error listing backends, got %v", err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("registering a backend twice should panic")
+		}
+	}()
+	registerBackend("local", backendDriver{})
+}
+
+// mockListS3 serves ListObjectsV2 over a fixed key set, two keys per page,
+// and records each request's prefix and the keys it returned
+type mockListS3 struct {
+	mu       sync.Mutex
+	keys     []string
+	prefixes []string
+	served   []string
+}
+
+func (m *mockListS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	q := r.URL.Query()
+	if r.Method != http.MethodGet || q.Get("list-type") != "2" {
+		http.Error(w, "unexpected request", http.StatusBadRequest)
+		return
+	}
+	prefix := q.Get("prefix")
+	m.prefixes = append(m.prefixes, prefix)
+
+	var matching []string
+	for _, k := range m.keys {
+		if strings.HasPrefix(k, prefix) {
+			matching = append(matching, k)
+		}
+	}
+	start := 0
+	if tok := q.Get("continuation-token"); tok != "" {
+		start, _ = strconv.Atoi(tok)
+	}
+	end := min(start+2, len(matching))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<ListBucketResult><Name>bucket</Name><Prefix>%s</Prefix><KeyCount>%d</KeyCount>`, prefix, end-start)
+	if end < len(matching) {
+		fmt.Fprintf(&b, `<IsTruncated>true</IsTruncated><NextContinuationToken>%d</NextContinuationToken>`, end)
+	} else {
+		b.WriteString(`<IsTruncated>false</IsTruncated>`)
+	}
+	for _, k := range matching[start:end] {
+		m.served = append(m.served, k)
+		fmt.Fprintf(&b, `<Contents><Key>%s</Key><Size>10</Size><LastModified>2026-01-01T00:00:00.000Z</LastModified></Contents>`, k)
+	}
+	b.WriteString(`</ListBucketResult>`)
+	_, _ = w.Write([]byte(b.String()))
+}
+
+// Test slot patterns are listed with an S3 prefix, across pages
+func TestS3ListPrefixFiltersServerSide(t *testing.T) {
+	mock := &mockListS3{keys: []string{
+		"clips/kube-dev.pb", "clips/kube-prod.pb", "clips/kube-stage.pb",
+		"clips/notes.pb", "clips/scratch.pb", "clips/.versions/kube-dev/1.pb",
+	}}
+	b := newMockS3Backend(t, mock, "clips/")
+
+	slots, err := listSlotsMatching(b, "kube-*")
+	if err != nil {
+		t.Fatalf("listSlotsMatching: %v", err)
+	}
+	var names []string
+	for _, s := range slots {
+		names = append(names, s.Name)
+	}
+	if strings.Join(names, ",") != "kube-dev,kube-prod,kube-stage" {
+		t.Errorf("slots = %v", names)
+	}
+
+	// Two pages, both requested with the pattern's literal prefix
+	if len(mock.prefixes) != 2 || mock.prefixes[0] != "clips/kube-" || mock.prefixes[1] != "clips/kube-" {
+		t.Errorf("list prefixes = %q, want clips/kube- for each page", mock.prefixes)
+	}
+	for _, k := range mock.served {
+		if !strings.HasPrefix(k, "clips/kube-") {
+			t.Errorf("unrelated key %q was listed", k)
+		}
+	}
+
+	// A pattern starting with a wildcard has no prefix to narrow by
+	mock.prefixes, mock.served = nil, nil
+	if slots, err = listSlotsMatching(b, "*s*"); err != nil {
+		t.Fatal(err)
+	}
+	if len(slots) != 3 || mock.prefixes[0] != "clips/" {
+		t.Errorf("slots = %+v, prefixes = %q", slots, mock.prefixes)
+	}
+}
+
+// Test a bucket prefix without a trailing slash still scopes the listing
+func TestS3ListPrefixBarePrefix(t *testing.T) {
+	mock := &mockListS3{keys: []string{"team/a1.pb", "team/b1.pb"}}
+	b := newMockS3Backend(t, mock, "team")
+	slots, err := b.ListPrefix("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(slots) != 1 || slots[0].Name != "a1" || mock.prefixes[0] != "team/a" {
+		t.Errorf("slots = %+v, prefixes = %q", slots, mock.prefixes)
+	}
+}
+
+func TestGlobLiteralPrefix(t *testing.T) {
+	for pattern, want := range map[string]string{
+		"kube-*":   "kube-",
+		"kube":     "kube",
+		"*":        "",
+		"a?c":      "a",
+		"pr[io]d":  "pr",
+		`esc\*ape`: "esc",
+	} {
+		if got := globLiteralPrefix(pattern); got != want {
+			t.Errorf("globLiteralPrefix(%q) = %q, want %q", pattern, got, want)
+		}
+	}
+}
+
+// Test S3 listings estimate expiry from last-modified time and ttl_days
+func TestS3ListEstimatesExpiry(t *testing.T) {
+	mock := &mockListS3{keys: []string{"clips/notes.pb"}}
+	b := newMockS3Backend(t, mock, "clips/")
+	b.ttlDays = 3
+
+	slots, err := b.List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	want := time.Date(2026, 1, 4, 0, 0, 0, 0, time.UTC)
+	if len(slots) != 1 || !slots[0].ExpiresAt.Equal(want) || !slots[0].ExpiryEstimated {
+		t.Errorf("slots = %+v, want estimated expiry %s", slots, want)
+	}
+
+	b.ttlDays = 0
+	if slots, _ := b.List(); len(slots) != 1 || !slots[0].ExpiresAt.IsZero() {
+		t.Errorf("without ttl_days slots should have no expiry: %+v", slots)
+	}
+}
+
+// Test slots labels S3 expiry as an estimate in the table and --json, and
+// the local backend, which reads each payload, doesn't
+func TestCmdSlotsEstimatedExpiry(t *testing.T) {
+	srv := httptest.NewServer(&mockListS3{keys: []string{"clips/notes.pb"}})
+	defer srv.Close()
+	t.Setenv("AWS_ACCESS_KEY_ID", "minio")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "minio123")
+	defer setupSlotsTestConfig(t, `version: 1
+sync:
+  backend: s3
+  ttl_days: 3
+  s3:
+    bucket: clips
+    prefix: clips/
+    endpoint: `+srv.URL+`
+    path_style: true
+`)()
+
+	out := captureOutput(func() {
+		if err := cmdSlots(nil); err != nil {
+			t.Errorf("slots: %v", err)
+		}
+	})
+	if !strings.Contains(out, "EST. EXPIRES") {
+		t.Errorf("table header not labelled as an estimate:\n%s", out)
+	}
+	out = captureOutput(func() {
+		if err := cmdSlots([]string{"--json"}); err != nil {
+			t.Errorf("slots --json: %v", err)
+		}
+	})
+	if !strings.Contains(out, `"expires_estimated": true`) {
+		t.Errorf("--json missing expires_estimated:\n%s", out)
+	}
+
+	dir := t.TempDir()
+	defer setupSlotsTestConfig(t, slotsConfigAt(dir, "  ttl_days: 3\n"))()
+	backend, err := newRemoteBackendFromConfig()
+	if err != nil {
+		t.Fatalf("backend: %v", err)
+	}
+	if err := backend.Push("notes", []byte("text"), map[string]string{}); err != nil {
+		t.Fatalf("push: %v", err)
+	}
+	out = captureOutput(func() {
+		if err := cmdSlots(nil); err != nil {
+			t.Errorf("slots: %v", err)
+		}
+	})
+	if !strings.Contains(out, "EXPIRES") || strings.Contains(out, "EST.") {
+		t.Errorf("local expiry should be exact:\n%s", out)
+	}
+}
+
+// mockObjectS3 stores objects in memory and records the tagging sent with
+// each upload or copy. Uploads honour If-Match and If-None-Match against
+// the ETag served with each object. With denyTagging set, tagged uploads
+// fail as they do for credentials without s3:PutObjectTagging.
+type mockObjectS3 struct {
+	mu          sync.Mutex
+	objects     map[string][]byte
+	tags        map[string]string
+	modified    map[string]time.Time
+	denyTagging bool
+	deleted     []string
+	lists       int // ListObjectsV2 requests
+}
+
+func (m *mockObjectS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Path-style: /<bucket>/<key>
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 2)
+	switch {
+	case r.Method == http.MethodGet && r.URL.Query().Get("list-type") == "2":
+		m.lists++
+		prefix := r.URL.Query().Get("prefix")
+		keys := slices.Sorted(maps.Keys(m.objects))
+		var b strings.Builder
+		b.WriteString(`<ListBucketResult><Name>bucket</Name><IsTruncated>false</IsTruncated>`)
+		for _, k := range keys {
+			if strings.HasPrefix(k, prefix) {
+				fmt.Fprintf(&b, `<Contents><Key>%s</Key><Size>%d</Size><LastModified>%s</LastModified></Contents>`,
+					k, len(m.objects[k]), m.modified[k].UTC().Format("2006-01-02T15:04:05.000Z"))
+			}
+		}
+		b.WriteString(`</ListBucketResult>`)
+		_, _ = w.Write([]byte(b.String()))
+	case len(parts) < 2:
+		http.Error(w, "unexpected request", http.StatusBadRequest)
+	case r.Method == http.MethodPut && r.Header.Get("X-Amz-Copy-Source") != "":
+		source := strings.SplitN(strings.TrimPrefix(r.Header.Get("X-Amz-Copy-Source"), "/"), "/", 2)
+		body, ok := m.objects[source[1]]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`<Error><Code>NoSuchKey</Code></Error>`))
+			return
+		}
+		m.objects[parts[1]] = body
+		m.tags[parts[1]] = r.Header.Get("X-Amz-Tagging")
+		m.modified[parts[1]] = time.Now()
+		_, _ = w.Write([]byte(`<CopyObjectResult><ETag>"etag"</ETag></CopyObjectResult>`))
+	case r.Method == http.MethodPut:
+		tagging := r.Header.Get("X-Amz-Tagging")
+		if tagging != "" && m.denyTagging {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`<Error><Code>AccessDenied</Code><Message>Access Denied</Message></Error>`))
+			return
+		}
+		current, exists := m.objects[parts[1]]
+		ifMatch, ifNoneMatch := r.Header.Get("If-Match"), r.Header.Get("If-None-Match")
+		if (ifMatch != "" && (!exists || ifMatch != mockETag(current))) || (ifNoneMatch == "*" && exists) {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			_, _ = w.Write([]byte(`<Error><Code>PreconditionFailed</Code><Message>At least one of the pre-conditions you specified did not hold</Message></Error>`))
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		m.objects[parts[1]] = body
+		m.tags[parts[1]] = tagging
+		m.modified[parts[1]] = time.Now()
+		w.Header().Set("ETag", mockETag(body))
+	case r.Method == http.MethodHead:
+		if _, ok := m.objects[parts[1]]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	case r.Method == http.MethodGet:
+		body, ok := m.objects[parts[1]]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`<Error><Code>NoSuchKey</Code></Error>`))
+			return
+		}
+		w.Header().Set("ETag", mockETag(body))
+		_, _ = w.Write(body)
+	case r.Method == http.MethodDelete:
+		delete(m.objects, parts[1])
+		m.deleted = append(m.deleted, parts[1])
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "unexpected request", http.StatusBadRequest)
+	}
+}
+
+func mockETag(body []byte) string {
+	return fmt.Sprintf(`"%x"`, sha256.Sum256(body))
+}
+
+func newMockObjectS3() *mockObjectS3 {
+	return &mockObjectS3{objects: map[string][]byte{}, tags: map[string]string{}, modified: map[string]time.Time{}}
+}
+
+// Test pushing with ttl_days tags the slot, its versions and its blob
+func TestS3PushTagsTTL(t *testing.T) {
+	mock := newMockObjectS3()
+	b := newMockS3Backend(t, mock, "clips/")
+	b.ttlDays = 7
+	b.versions = 2
+	if err := b.Push("notes", []byte("hello"), map[string]string{}); err != nil {
+		t.Fatalf("Push: %v", err)
+	}
+	if got := mock.tags["clips/notes.pb"]; got != "pipeboard-ttl-days=7" {
+		t.Errorf("slot tagging = %q", got)
+	}
+	if got := mock.tags["clips/.versions/notes/1.pb"]; got != "pipeboard-ttl-days=7" {
+		t.Errorf("version tagging = %q (objects: %v)", got, mock.tags)
+	}
+
+	// A blob is tagged when uploaded, and retagged when a push reuses it
+	b.versions, b.dedup = 0, true
+	blobKey := "clips/blobs/" + contentHash([]byte("shared"), "") + ".pb"
+	if err := b.Push("first", []byte("shared"), map[string]string{}); err != nil {
+		t.Fatalf("Push: %v", err)
+	}
+	if got := mock.tags[blobKey]; got != "pipeboard-ttl-days=7" {
+		t.Errorf("blob tagging = %q (objects: %v)", got, mock.tags)
+	}
+	b.ttlDays = 30
+	if err := b.Push("second", []byte("shared"), map[string]string{}); err != nil {
+		t.Fatalf("Push: %v", err)
+	}
+	if got := mock.tags[blobKey]; got != "pipeboard-ttl-days=30" {
+		t.Errorf("reused blob tagging = %q", got)
+	}
+
+	b.ttlDays = 0
+	if err := b.Push("kept", []byte("forever"), map[string]string{}); err != nil {
+		t.Fatalf("Push: %v", err)
+	}
+	for _, key := range []string{"clips/kept.pb", "clips/blobs/" + contentHash([]byte("forever"), "") + ".pb"} {
+		if tagging := mock.tags[key]; tagging != "" {
+			t.Errorf("%s tagged %q without ttl_days", key, tagging)
+		}
+	}
+}
+
+// Test credentials without s3:PutObjectTagging still push, untagged
+func TestS3PushTagDenied(t *testing.T) {
+	mock := newMockObjectS3()
+	mock.denyTagging = true
+	b := newMockS3Backend(t, mock, "")
+	b.ttlDays = 1
+
+	stderr := captureStderr(func() {
+		if err := b.Push("notes", []byte("hello"), map[string]string{}); err != nil {
+			t.Errorf("Push: %v", err)
+		}
+	})
+	if _, ok := mock.objects["notes.pb"]; !ok {
+		t.Error("slot wasn't uploaded after the tagged upload was denied")
+	}
+	if !strings.Contains(stderr, "s3:PutObjectTagging") {
+		t.Errorf("stderr = %q", stderr)
+	}
+}
+
+// Test pulling an expired S3 slot deletes it and reports it expired
+func TestS3PullExpired(t *testing.T) {
+	mock := newMockObjectS3()
+	b := newMockS3Backend(t, mock, "clips/")
+	expired, _ := json.Marshal(SlotPayload{
+		Version:   1,
+		CreatedAt: "2025-01-01T00:00:00Z",
+		ExpiresAt: time.Now().Add(-time.Hour).UTC().Format(time.RFC3339),
+		DataB64:   base64.StdEncoding.EncodeToString([]byte("stale")),
+	})
+	mock.objects["clips/old.pb"] = expired
+
+	if _, _, err := b.Pull("old"); err == nil || !strings.Contains(err.Error(), "has expired") {
+		t.Fatalf("Pull = %v, want an expired error", err)
+	}
+	if _, ok := mock.objects["clips/old.pb"]; ok {
+		t.Errorf("expired slot wasn't deleted (deleted: %v)", mock.deleted)
+	}
+
+	// A slot that hasn't expired yet is pulled as usual
+	b.ttlDays = 1
+	if err := b.Push("fresh", []byte("new"), map[string]string{}); err != nil {
+		t.Fatalf("Push: %v", err)
+	}
+	if data, _, err := b.Pull("fresh"); err != nil || string(data) != "new" {
+		t.Errorf("Pull fresh = %q, %v", data, err)
+	}
+}
+
+// Test dedup blob names never equal sha256 of the plaintext for encrypted
+// slots: aes256 keys them with the passphrase, and age is refused
+func TestS3DedupBlobNamesKeyed(t *testing.T) {
+	secret := []byte("hunter2 is my password")
+	plain := "clips/blobs/" + contentHash(secret, "") + ".pb"
+
+	mock := newMockObjectS3()
+	b := newMockS3Backend(t, mock, "clips/")
+	b.dedup, b.encryption, b.passphrase = true, "aes256", "test-passphrase"
+	if err := b.Push("a", secret, map[string]string{}); err != nil {
+		t.Fatalf("Push: %v", err)
+	}
+	var blobs int
+	for key := range mock.objects {
+		if strings.HasPrefix(key, "clips/blobs/") {
+			blobs++
+			if key == plain {
+				t.Errorf("blob %s is named by the plaintext digest", key)
+			}
+		}
+	}
+	if blobs != 1 {
+		t.Errorf("expected 1 blob, got %d (objects: %v)", blobs, mock.objects)
+	}
+
+	mock = newMockObjectS3()
+	b = newMockS3Backend(t, mock, "clips/")
+	b.dedup, b.encryption = true, "age"
+	b.age = &ageKeys{recipients: []age.Recipient{newTestAgeIdentity(t).Recipient()}}
+	if err := b.Push("a", secret, map[string]string{}); err == nil || !strings.Contains(err.Error(), "sync.dedup") {
+		t.Errorf("expected dedup with age to be refused, got %v", err)
+	}
+	if _, ok := mock.objects[plain]; ok || len(mock.objects) != 0 {
+		t.Errorf("refused push wrote objects: %v", mock.objects)
+	}
+}
+
+// Test deleting without dedup doesn't list the bucket for blobs, and a
+// batch delete with dedup prunes once
+func TestS3DeletePrunesOnlyWithDedup(t *testing.T) {
+	mock := newMockObjectS3()
+	b := newMockS3Backend(t, mock, "clips/")
+	for _, slot := range []string{"a", "b", "c"} {
+		if err := b.Push(slot, []byte(slot), map[string]string{}); err != nil {
+			t.Fatalf("Push %s: %v", slot, err)
+		}
+	}
+	mock.lists = 0
+	if err := b.Delete("a"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	// Only the listing of the slot's stored versions
+	if mock.lists != 1 {
+		t.Errorf("Delete without dedup listed the bucket %d times, want 1", mock.lists)
+	}
+
+	b.dedup = true
+	mock.lists = 0
+	var deleted []string
+	b.DeleteBatch([]string{"b", "c"}, func(slot string, err error) {
+		if err != nil {
+			t.Errorf("delete %s: %v", slot, err)
+		}
+		deleted = append(deleted, slot)
+	})
+	if strings.Join(deleted, ",") != "b,c" {
+		t.Errorf("deleted = %v", deleted)
+	}
+	// One listing for the versions of each slot, one for the prune
+	if mock.lists != 3 {
+		t.Errorf("batch delete listed the bucket %d times, want 3", mock.lists)
+	}
+	if len(mock.objects) != 0 {
+		t.Errorf("objects left: %v", slices.Sorted(maps.Keys(mock.objects)))
+	}
+}
+
+// Test removing or overwriting the last pointer to an S3 blob deletes the
+// blob, while blobs other slots still point at are kept
+func TestS3PruneBlobs(t *testing.T) {
+	mock := newMockObjectS3()
+	b := newMockS3Backend(t, mock, "clips/")
+	b.dedup = true
+	blobKey := func(data string) string {
+		return "clips/blobs/" + contentHash([]byte(data), "") + ".pb"
+	}
+	age := func() {
+		for k := range mock.modified {
+			mock.modified[k] = time.Now().Add(-2 * blobGracePeriod)
+		}
+	}
+
+	for slot, data := range map[string]string{"a": "shared", "b": "shared", "c": "secret"} {
+		if err := b.Push(slot, []byte(data), map[string]string{}); err != nil {
+			t.Fatalf("Push %s: %v", slot, err)
+		}
+	}
+	age()
+	if err := b.Delete("c"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, ok := mock.objects[blobKey("secret")]; ok {
+		t.Error("blob of a deleted slot was kept")
+	}
+	if err := b.Delete("a"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, ok := mock.objects[blobKey("shared")]; !ok {
+		t.Fatal("blob still pointed at by another slot was deleted")
+	}
+
+	// Overwriting the last pointer drops the old blob
+	age()
+	if err := b.Push("b", []byte("changed"), map[string]string{}); err != nil {
+		t.Fatalf("Push: %v", err)
+	}
+	if _, ok := mock.objects[blobKey("shared")]; ok {
+		t.Error("blob of an overwritten slot was kept")
+	}
+	if _, ok := mock.objects[blobKey("changed")]; !ok {
+		t.Error("blob of the new content is missing")
+	}
+
+	// Blobs inside the grace period are left for a push in flight
+	if err := b.Delete("b"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, ok := mock.objects[blobKey("changed")]; !ok {
+		t.Error("blob written within the grace period was deleted")
+	}
+}
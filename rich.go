@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// richFlavors are the clipboard formats push keeps alongside the text,
+// richest first
+var richFlavors = []string{"text/html", "text/rtf"}
+
+// Meta keys carrying a rich flavor between cmdPush/cmdPull and the backends
+const (
+	metaFlavor     = "flavor"
+	metaFlavorData = "flavor_data"
+)
+
+// SlotFlavor is a rich copy of the clipboard stored next to the plain text.
+// It's sealed on its own so older clients that only read data_b64 still
+// get the text, and dedup'd blobs never have to carry it.
+type SlotFlavor struct {
+	MIME       string `json:"mime"`
+	Encrypted  bool   `json:"encrypted,omitempty"`
+	Encryption string `json:"encryption,omitempty"`
+	Compressed bool   `json:"compressed,omitempty"`
+	DataB64    string `json:"data_b64"`
+}
+
+// sealSlotFlavor compresses and encrypts the flavor in meta the same way
+// as the text; it returns nil when push captured no flavor
+func sealSlotFlavor(meta map[string]string, encryption, passphrase string, age *ageKeys) (*SlotFlavor, error) {
+	mime := meta[metaFlavor]
+	if mime == "" {
+		return nil, nil
+	}
+	data := []byte(meta[metaFlavorData])
+	compressed := false
+	if len(data) > 1024 {
+		if c, err := compressData(data); err == nil && len(c) < len(data) {
+			data, compressed = c, true
+		}
+	}
+	data, encrypted, field, err := encryptSlotData(data, encryption, passphrase, age)
+	if err != nil {
+		return nil, err
+	}
+	encoded, _ := encodePayloadData(data, "")
+	return &SlotFlavor{
+		MIME:       mime,
+		Encrypted:  encrypted,
+		Encryption: field,
+		Compressed: compressed,
+		DataB64:    encoded,
+	}, nil
+}
+
+// addFlavorMeta decodes a stored flavor into pull metadata
+func addFlavorMeta(meta map[string]string, flavor *SlotFlavor, passphrase string, age *ageKeys) error {
+	if flavor == nil {
+		return nil
+	}
+	data, err := decodePayloadData(SlotPayload{
+		Encrypted:  flavor.Encrypted,
+		Encryption: flavor.Encryption,
+		Compressed: flavor.Compressed,
+		DataB64:    flavor.DataB64,
+	}, passphrase, age)
+	if err != nil {
+		return fmt.Errorf("decoding %s flavor: %w", flavor.MIME, err)
+	}
+	meta[metaFlavor] = flavor.MIME
+	meta[metaFlavorData] = string(data)
+	return nil
+}
+
+// readRichFlavor returns the richest flavor on the clipboard besides
+// plain text, or "" when there's none or the backend can't read flavors
+func readRichFlavor() (string, []byte) {
+	b, err := getBackend()
+	if err != nil || len(b.Missing) > 0 || len(b.FlavorPasteCmds) == 0 {
+		return "", nil
+	}
+	offered := offeredFlavors(b)
+	for _, mime := range richFlavors {
+		cmd, ok := b.FlavorPasteCmds[mime]
+		if !ok || (offered != nil && !offered[mime]) {
+			continue
+		}
+		var out bytes.Buffer
+		if err := runClipboardCmd(cmd, nil, &out); err != nil {
+			debugLog("reading %s flavor: %v", mime, err)
+			continue
+		}
+		if looksLikeFlavor(mime, out.Bytes()) {
+			return mime, out.Bytes()
+		}
+	}
+	return "", nil
+}
+
+// offeredFlavors lists the MIME types on the clipboard, or nil when the
+// backend can't enumerate them and each flavor has to be tried
+func offeredFlavors(b *Backend) map[string]bool {
+	if len(b.FlavorsCmd) == 0 {
+		return nil
+	}
+	var out bytes.Buffer
+	if err := runClipboardCmd(b.FlavorsCmd, nil, &out); err != nil {
+		debugLog("listing clipboard flavors: %v", err)
+		return map[string]bool{}
+	}
+	offered := map[string]bool{}
+	for _, line := range strings.Split(out.String(), "\n") {
+		// Drop parameters such as "text/html;charset=utf-8"
+		mime, _, _ := strings.Cut(line, ";")
+		if mime = strings.TrimSpace(mime); mime != "" {
+			offered[mime] = true
+		}
+	}
+	return offered
+}
+
+// looksLikeFlavor guards against paste commands that quietly fall back to
+// plain text when the flavor isn't there (pbpaste -Prefer does)
+func looksLikeFlavor(mime string, data []byte) bool {
+	switch mime {
+	case "text/rtf":
+		return bytes.HasPrefix(data, []byte(`{\rtf`))
+	case "text/html":
+		return bytes.Contains(data, []byte("<"))
+	default:
+		return len(data) > 0
+	}
+}
+
+// writeRichFlavor puts data on the clipboard as mime; it returns false
+// without writing when this backend can't hold that flavor
+func writeRichFlavor(mime string, data []byte) (bool, error) {
+	b, err := getBackend()
+	if err != nil {
+		return false, err
+	}
+	if len(b.Missing) > 0 {
+		return false, missingToolsError(b)
+	}
+	cmd, ok := b.FlavorCopyCmds[mime]
+	if !ok {
+		return false, nil
+	}
+	return true, runClipboardCmd(cmd, data, os.Stdout)
+}
+
+// flavorCmds builds a backend's per-flavor commands from a template
+func flavorCmds(cmd func(mime string) []string) map[string][]string {
+	cmds := make(map[string][]string, len(richFlavors))
+	for _, mime := range richFlavors {
+		cmds[mime] = cmd(mime)
+	}
+	return cmds
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// useRichClipboard replaces the detected backend with a file-backed one
+// offering plain text plus text/html, like wl-paste --list-types reports.
+// Copying HTML replaces the text as a single-target clipboard tool does.
+func useRichClipboard(t *testing.T, text, html string) (textPath, htmlPath string) {
+	t.Helper()
+	dir := t.TempDir()
+	textPath = filepath.Join(dir, "text")
+	htmlPath = filepath.Join(dir, "html")
+	if err := os.WriteFile(textPath, []byte(text), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(htmlPath, []byte(html), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cachedBackendOnce = sync.Once{}
+	cachedBackendOnce.Do(func() {
+		cachedBackend = &Backend{
+			Kind:       BackendUnknown,
+			CopyCmd:    []string{"sh", "-c", "cat > " + textPath + " && : > " + htmlPath},
+			PasteCmd:   []string{"cat", textPath},
+			FlavorsCmd: []string{"sh", "-c", "echo text/plain; [ -s " + htmlPath + " ] && echo 'text/html;charset=utf-8'; true"},
+			FlavorPasteCmds: map[string][]string{
+				"text/html": {"cat", htmlPath},
+			},
+			FlavorCopyCmds: map[string][]string{
+				"text/html": {"sh", "-c", "cat > " + htmlPath + " && : > " + textPath},
+			},
+		}
+		cachedBackendErr = nil
+	})
+	t.Cleanup(func() { cachedBackendOnce = sync.Once{} })
+	return textPath, htmlPath
+}
+
+func readFileString(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestPushPullRichFlavor(t *testing.T) {
+	useTerminalStdout(t)
+	tests := []struct {
+		name  string
+		extra string
+	}{
+		{"plain", ""},
+		{"encrypted", "  encryption: aes256\n  passphrase: secret\n"},
+		{"dedup", "  dedup: true\n"},
+	}
+	const text, html = "quarterly numbers", "<p><b>quarterly</b> numbers</p>"
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			defer setupSlotsTestConfig(t, slotsConfigAt(dir, tt.extra))()
+			textPath, htmlPath := useRichClipboard(t, text, html)
+
+			out := captureOutput(func() {
+				if err := cmdPush([]string{"report"}); err != nil {
+					t.Fatalf("push: %v", err)
+				}
+			})
+			if !strings.Contains(out, "(with text/html)") {
+				t.Errorf("push output = %q", out)
+			}
+
+			stored := readFileString(t, filepath.Join(dir, "report.pb"))
+			var payload SlotPayload
+			if err := json.Unmarshal([]byte(stored), &payload); err != nil {
+				t.Fatal(err)
+			}
+			if payload.Flavor == nil || payload.Flavor.MIME != "text/html" {
+				t.Fatalf("payload has no html flavor: %s", stored)
+			}
+			if payload.Flavor.Encrypted != payload.Encrypted {
+				t.Errorf("flavor encrypted = %t, text encrypted = %t", payload.Flavor.Encrypted, payload.Encrypted)
+			}
+
+			// Both flavors come back: the HTML with --rich, the text without
+			_ = os.WriteFile(textPath, nil, 0600)
+			_ = os.WriteFile(htmlPath, nil, 0600)
+			out = captureOutput(func() {
+				if err := cmdPull([]string{"report", "--rich"}); err != nil {
+					t.Fatalf("pull --rich: %v", err)
+				}
+			})
+			if got := readFileString(t, htmlPath); got != html {
+				t.Errorf("html after pull --rich = %q", got)
+			}
+			if !strings.Contains(out, "as text/html") {
+				t.Errorf("pull --rich output = %q", out)
+			}
+
+			captureOutput(func() {
+				if err := cmdPull([]string{"report"}); err != nil {
+					t.Fatalf("pull: %v", err)
+				}
+			})
+			if got := readFileString(t, textPath); got != text {
+				t.Errorf("text after pull = %q", got)
+			}
+		})
+	}
+}
+
+func TestPushWithoutRichFlavor(t *testing.T) {
+	dir := t.TempDir()
+	defer setupSlotsTestConfig(t, slotsConfigAt(dir, ""))()
+	useRichClipboard(t, "just text", "")
+
+	captureOutput(func() {
+		if err := cmdPush([]string{"note"}); err != nil {
+			t.Fatalf("push: %v", err)
+		}
+	})
+	var payload SlotPayload
+	if err := json.Unmarshal([]byte(readFileString(t, filepath.Join(dir, "note.pb"))), &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.Flavor != nil {
+		t.Errorf("flavor = %+v, want none", payload.Flavor)
+	}
+
+	// --from-command output has no flavors even if the clipboard does
+	useRichClipboard(t, "clip", "<i>clip</i>")
+	captureOutput(func() {
+		if err := cmdPush([]string{"cmd", "--from-command", "echo out"}); err != nil {
+			t.Fatalf("push --from-command: %v", err)
+		}
+	})
+	if strings.Contains(readFileString(t, filepath.Join(dir, "cmd.pb")), "flavor") {
+		t.Error("--from-command push captured a clipboard flavor")
+	}
+}
+
+func TestPullRichFallsBackToText(t *testing.T) {
+	useTerminalStdout(t)
+	dir := t.TempDir()
+	defer setupSlotsTestConfig(t, slotsConfigAt(dir, ""))()
+	useRichClipboard(t, "hello", "<b>hello</b>")
+	captureOutput(func() {
+		if err := cmdPush([]string{"greeting"}); err != nil {
+			t.Fatalf("push: %v", err)
+		}
+	})
+
+	// This machine's clipboard only holds text
+	path := useFileClipboard(t, "")
+	var stderr string
+	captureOutput(func() {
+		stderr = captureStderr(func() {
+			if err := cmdPull([]string{"greeting", "--rich"}); err != nil {
+				t.Fatalf("pull --rich: %v", err)
+			}
+		})
+	})
+	if got := readFileString(t, path); got != "hello" {
+		t.Errorf("clipboard = %q, want the text", got)
+	}
+	if !strings.Contains(stderr, "can't hold text/html") {
+		t.Errorf("stderr = %q", stderr)
+	}
+
+	out := captureOutput(func() {
+		if err := cmdShow([]string{"greeting", "--meta"}); err != nil {
+			t.Errorf("show --meta: %v", err)
+		}
+	})
+	if !strings.Contains(out, "flavor:     text/html") {
+		t.Errorf("show --meta:\n%s", out)
+	}
+
+	if err := cmdPull([]string{"greeting", "--rich", "--lines", "1"}); err == nil || !strings.Contains(err.Error(), "--rich") {
+		t.Errorf("--rich --lines: err = %v", err)
+	}
+}
+
+func TestLooksLikeFlavor(t *testing.T) {
+	if looksLikeFlavor("text/rtf", []byte("plain fallback")) {
+		t.Error("plain text accepted as RTF")
+	}
+	if !looksLikeFlavor("text/rtf", []byte(`{\rtf1\ansi hi}`)) {
+		t.Error("RTF rejected")
+	}
+	if looksLikeFlavor("text/html", []byte("no markup")) {
+		t.Error("plain text accepted as HTML")
+	}
+}
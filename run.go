@@ -2,13 +2,20 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"errors"
+	"fmt"
 	"io"
 	"os"
 	"os/exec"
 	"strings"
+	"sync"
+	"time"
 )
 
+// runCommand and runCommandOutput run the macOS security tool for the
+// keychain. They have no timeout, unlike runClipboardCmd: the tool may
+// be waiting on the user to answer a keychain access prompt.
 func runCommand(cmdParts ...string) error {
 	if len(cmdParts) == 0 {
 		return errors.New("no command configured")
@@ -36,6 +43,9 @@ func runCommandOutput(cmdParts ...string) ([]byte, error) {
 	return output, nil
 }
 
+// runWithInput runs the pager with data on stdin. It has no timeout,
+// unlike runClipboardCmd, since the pager runs for as long as the user
+// is reading.
 func runWithInput(cmdParts []string, data []byte) error {
 	if len(cmdParts) == 0 {
 		return errors.New("no command configured")
@@ -47,15 +57,149 @@ func runWithInput(cmdParts []string, data []byte) error {
 	return cmd.Run()
 }
 
-func runAndPipeStdout(cmdParts []string) error {
+// Cached clipboard timeout - read from config once per process
+var (
+	cachedClipboardTimeout     time.Duration
+	cachedClipboardTimeoutOnce sync.Once
+)
+
+// getClipboardTimeout returns defaults.clipboard_timeout, falling back to
+// the default when the config is missing or unreadable.
+func getClipboardTimeout() time.Duration {
+	cachedClipboardTimeoutOnce.Do(func() {
+		cachedClipboardTimeout = defaultClipboardTimeout
+		cfg, err := loadConfigForAliases()
+		if err != nil {
+			debugLog("clipboard timeout: %v", err)
+			return
+		}
+		d, err := cfg.getClipboardTimeout()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+			return
+		}
+		cachedClipboardTimeout = d
+	})
+	return cachedClipboardTimeout
+}
+
+// runClipboardCmd runs a clipboard tool with input on stdin (nil for
+// none) and its output written to stdout. The tool is killed if it runs
+// past defaults.clipboard_timeout, which keeps a stuck clipboard owner
+// from hanging copy, paste and watch.
+func runClipboardCmd(cmdParts []string, input []byte, stdout io.Writer) error {
+	var r io.Reader
+	if input != nil {
+		r = bytes.NewReader(input)
+	}
+	return runClipboardCmdFrom(cmdParts, r, stdout)
+}
+
+// runClipboardCmdFrom is runClipboardCmd with the tool's stdin read from
+// input (nil for none), so spooled content never has to be in memory
+func runClipboardCmdFrom(cmdParts []string, input io.Reader, stdout io.Writer) error {
 	if len(cmdParts) == 0 {
 		return errors.New("no command configured")
 	}
-	cmd := exec.Command(cmdParts[0], cmdParts[1:]...)
-	cmd.Stdin = nil
-	cmd.Stdout = os.Stdout
+	ctx := context.Background()
+	timeout := getClipboardTimeout()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	cmd := exec.CommandContext(ctx, cmdParts[0], cmdParts[1:]...)
+	cmd.Stdin = input
+	cmd.Stdout = stdout
 	cmd.Stderr = os.Stderr
-	return cmd.Run()
+	// Don't wait on pipes held open by a killed tool's children
+	cmd.WaitDelay = time.Second
+	err := cmd.Run()
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("clipboard operation timed out after %s (%s)\n\nThe clipboard owner may be unresponsive. Retry, or raise defaults.clipboard_timeout", timeout, cmdParts[0])
+	}
+	return err
+}
+
+// copySpoolThreshold is how much stdin copy holds in memory; longer
+// input is spooled to a temp file and fed to the clipboard tool from there
+var copySpoolThreshold int64 = 32 << 20
+
+// copyInput is the content for copy: in memory, or spooled to a temp file
+type copyInput struct {
+	data  []byte   // the whole content, or its first maxScanBytes when spooled
+	spool *os.File // set when the content was spooled
+	size  int64
+}
+
+// readCopyInput reads copy's content from args, joined with sep, or
+// stdin, spooling stdin beyond copySpoolThreshold to a temp file. Content
+// is also written to tee as it is read, if set. Close removes the spool.
+func readCopyInput(args []string, sep string, tee io.Writer) (*copyInput, error) {
+	if len(args) > 0 {
+		data := []byte(strings.Join(args, sep))
+		var err error
+		if tee != nil {
+			_, err = tee.Write(data)
+		}
+		return &copyInput{data: data, size: int64(len(data))}, err
+	}
+
+	var stdin io.Reader = os.Stdin
+	if tee != nil {
+		stdin = io.TeeReader(os.Stdin, tee)
+	}
+	var buf bytes.Buffer
+	n, err := io.CopyN(&buf, stdin, copySpoolThreshold+1)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if n <= copySpoolThreshold {
+		return &copyInput{data: buf.Bytes(), size: n}, nil
+	}
+
+	spool, err := os.CreateTemp("", "pipeboard-copy-*")
+	if err != nil {
+		return nil, fmt.Errorf("creating spool file: %w", err)
+	}
+	in := &copyInput{spool: spool}
+	head := buf.Bytes()
+	if len(head) > maxScanBytes {
+		head = head[:maxScanBytes]
+	}
+	in.data = bytes.Clone(head)
+	if _, err := spool.Write(buf.Bytes()); err != nil {
+		in.Close()
+		return nil, fmt.Errorf("spooling input: %w", err)
+	}
+	buf = bytes.Buffer{}
+	rest, err := io.Copy(spool, stdin)
+	if err != nil {
+		in.Close()
+		return nil, fmt.Errorf("spooling input: %w", err)
+	}
+	in.size = n + rest
+	debugLog("copy: spooled %d bytes to %s", in.size, spool.Name())
+	return in, nil
+}
+
+// reader returns the content from the start
+func (in *copyInput) reader() (io.Reader, error) {
+	if in.spool == nil {
+		return bytes.NewReader(in.data), nil
+	}
+	if _, err := in.spool.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return in.spool, nil
+}
+
+// Close removes the spool file, if any
+func (in *copyInput) Close() {
+	if in.spool != nil {
+		_ = in.spool.Close()
+		_ = os.Remove(in.spool.Name())
+	}
 }
 
 func readInputOrArgs(args []string) ([]byte, error) {
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Secret scanning is a lightweight, best-effort check for credentials in
+// text content before it leaves the machine (copy) or is shared (push).
+// It is opt-in via policy.scan_secrets.
+
+// secretPattern is a named regular expression for a known credential format
+type secretPattern struct {
+	kind string
+	re   *regexp.Regexp
+}
+
+var secretPatterns = []secretPattern{
+	{"AWS access key", regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"private key", regexp.MustCompile(`-----BEGIN (?:[A-Z]+ )*PRIVATE KEY(?: BLOCK)?-----`)},
+	{"GitHub token", regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`)},
+	{"Slack token", regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}\b`)},
+}
+
+// highEntropyToken matches long runs of base64/url-safe characters
+var highEntropyToken = regexp.MustCompile(`[A-Za-z0-9+/_=-]{32,}`)
+
+const (
+	minSecretEntropy = 4.3 // bits per character
+	maxScanBytes     = 1 << 20
+)
+
+// secretFinding describes a likely secret found in content
+type secretFinding struct {
+	Kind string
+	Line int
+}
+
+// scanSecrets returns likely secrets in text content. Binary content and
+// content beyond the first 1MiB are not scanned.
+func scanSecrets(data []byte) []secretFinding {
+	if len(data) > maxScanBytes {
+		data = data[:maxScanBytes]
+	}
+	if !utf8.Valid(data) {
+		return nil
+	}
+
+	var findings []secretFinding
+	for i, line := range bytes.Split(data, []byte("\n")) {
+		matched := false
+		for _, p := range secretPatterns {
+			if p.re.Match(line) {
+				findings = append(findings, secretFinding{Kind: p.kind, Line: i + 1})
+				matched = true
+			}
+		}
+		if matched {
+			continue
+		}
+		for _, tok := range highEntropyToken.FindAll(line, -1) {
+			if looksRandom(string(tok)) {
+				findings = append(findings, secretFinding{Kind: "high-entropy string", Line: i + 1})
+				break
+			}
+		}
+	}
+	return findings
+}
+
+// looksRandom reports whether a token has high entropy and mixes character
+// classes, which filters out long words, paths, and repeated characters
+func looksRandom(tok string) bool {
+	var upper, lower, digit bool
+	for _, r := range tok {
+		switch {
+		case r >= 'A' && r <= 'Z':
+			upper = true
+		case r >= 'a' && r <= 'z':
+			lower = true
+		case r >= '0' && r <= '9':
+			digit = true
+		}
+	}
+	if !digit || !(upper || lower) {
+		return false
+	}
+	return shannonEntropy(tok) >= minSecretEntropy
+}
+
+// shannonEntropy returns the entropy of s in bits per character
+func shannonEntropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+	counts := make(map[rune]int)
+	n := 0
+	for _, r := range s {
+		counts[r]++
+		n++
+	}
+	var h float64
+	for _, c := range counts {
+		p := float64(c) / float64(n)
+		h -= p * math.Log2(p)
+	}
+	return h
+}
+
+// describeFindings summarizes findings for a message, e.g.
+// "AWS access key (line 1), private key (line 3)"
+func describeFindings(findings []secretFinding) string {
+	parts := make([]string, 0, len(findings))
+	for _, f := range findings {
+		parts = append(parts, fmt.Sprintf("%s (line %d)", f.Kind, f.Line))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// checkSecretPolicy scans content when policy.scan_secrets is enabled.
+// With on_secret: block it returns an error; otherwise it prints a warning.
+func checkSecretPolicy(data []byte, action string) error {
+	cfg, err := loadConfigForAliases()
+	if err != nil {
+		debugLog("failed to load config for secret policy: %v", err)
+		return nil
+	}
+	if cfg.Policy == nil || !cfg.Policy.ScanSecrets {
+		return nil
+	}
+	mode := cfg.Policy.OnSecret
+	if mode == "" {
+		mode = "warn"
+	}
+	if mode != "warn" && mode != "block" {
+		return fmt.Errorf("invalid policy.on_secret %q (expected warn or block)", mode)
+	}
+
+	findings := scanSecrets(data)
+	if len(findings) == 0 {
+		return nil
+	}
+	if mode == "block" {
+		return fmt.Errorf("refusing to %s: content looks like it contains a secret: %s\n(set policy.on_secret: warn to allow)", action, describeFindings(findings))
+	}
+	fmt.Fprintf(os.Stderr, "warning: content looks like it contains a secret: %s\n", describeFindings(findings))
+	return nil
+}
@@ -1,9 +1,18 @@
 package main
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
 )
 
 // resolveSlotName resolves slot aliases to full slot names.
@@ -17,15 +26,115 @@ func resolveSlotName(name string) string {
 	return cfg.resolveAlias(name)
 }
 
-func cmdPush(args []string) error {
-	if len(args) != 1 {
-		return fmt.Errorf("usage: pipeboard push <name>")
+func cmdPush(args []string) (err error) {
+	const usage = "usage: pipeboard push <name> [--from-command <cmd>|--stdin]\n       pipeboard push <name> [text...] --copy\n       pipeboard push <name> -f <file> [-f <file>...] [--tar <dir>...]\n       pipeboard push --auto-name [--from-command <cmd>]\n       pipeboard push <name> --append [--separator <s>] [--from-command <cmd>|--stdin|--copy]"
+	var autoName, alsoCopy, fromStdin, appendMode bool
+	var fromCommand string
+	separator := "\n"
+	var files, dirs, positional []string
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; arg {
+		case "--auto-name":
+			autoName = true
+		case "--from-command":
+			if i+1 >= len(args) || args[i+1] == "" {
+				return fmt.Errorf("--from-command requires a command\n%s", usage)
+			}
+			i++
+			fromCommand = args[i]
+		case "--copy":
+			alsoCopy = true
+		case "--stdin":
+			fromStdin = true
+		case "--append":
+			appendMode = true
+		case "--separator":
+			if i+1 >= len(args) {
+				return fmt.Errorf("--separator requires a value\n%s", usage)
+			}
+			i++
+			separator = args[i]
+		case "--file", "-f":
+			if i+1 >= len(args) || args[i+1] == "" {
+				return fmt.Errorf("%s requires a path\n%s", arg, usage)
+			}
+			i++
+			files = append(files, args[i])
+		case "--tar":
+			if i+1 >= len(args) || args[i+1] == "" {
+				return fmt.Errorf("--tar requires a directory\n%s", usage)
+			}
+			i++
+			dirs = append(dirs, args[i])
+		default:
+			positional = append(positional, arg)
+		}
+	}
+	archive := len(files) > 0 || len(dirs) > 0
+	if archive && (alsoCopy || fromCommand != "") {
+		return errors.New("-f and --tar can't be combined with --copy or --from-command")
+	}
+	if fromStdin && (archive || alsoCopy || fromCommand != "") {
+		return errors.New("--stdin can't be combined with --copy, --from-command, -f or --tar")
+	}
+	if appendMode && (archive || autoName) {
+		return errors.New("--append can't be combined with -f, --tar or --auto-name")
+	}
+	// A name (or --auto-name), then text only with --copy
+	var slot string
+	var text []string
+	switch {
+	case autoName:
+		text = positional
+	case len(positional) == 0:
+		return errors.New(usage)
+	default:
+		slot = resolveSlotName(positional[0])
+		text = positional[1:]
+	}
+	if len(text) > 0 && (!alsoCopy || fromCommand != "") {
+		return errors.New(usage)
 	}
-	slot := resolveSlotName(args[0])
 
-	// Read from local clipboard
-	data, err := readClipboard()
-	if err != nil {
+	var data, flavorData []byte
+	var flavor string
+	var fileCount int
+	defer func() {
+		recordAudit(AuditRecord{Op: "push", Slot: slot, Size: int64(len(data))}, err)
+	}()
+
+	// Read from files (-f, --tar), the command's stdout, text args or
+	// stdin (--copy, --stdin), or the local clipboard
+	if archive {
+		data, fileCount, err = buildSlotArchive(files, dirs)
+		if err != nil {
+			return fmt.Errorf("building archive: %w; nothing pushed", err)
+		}
+		debugLog("archived %d files into %d bytes", fileCount, len(data))
+	} else if fromStdin || (alsoCopy && fromCommand == "") {
+		data, err = readInputOrArgs(text)
+		if err != nil {
+			return err
+		}
+	} else if fromCommand != "" {
+		debugLog("running: sh -c %q", fromCommand)
+		data, err = runTransform([]string{"sh", "-c", fromCommand}, nil)
+		if err != nil {
+			return fmt.Errorf("--from-command failed: %w; nothing pushed", err)
+		}
+	} else {
+		data, err = readClipboard()
+		if err != nil {
+			return err
+		}
+		// Keep the richest flavor (HTML, RTF) so pull --rich can restore it;
+		// it would only describe the new part of an appended slot
+		if !appendMode {
+			flavor, flavorData = readRichFlavor()
+		}
+	}
+
+	if err := checkSecretPolicy(data, "push"); err != nil {
 		return err
 	}
 
@@ -35,90 +144,1079 @@ func cmdPush(args []string) error {
 		return err
 	}
 
-	host, _ := os.Hostname()
-	meta := map[string]string{"hostname": host}
+	if autoName {
+		slot, err = autoSlotName(backend)
+		if err != nil {
+			return err
+		}
+	}
+
+	cfg, err := loadConfigForAliases()
+	if err != nil {
+		return err
+	}
+	meta := map[string]string{"hostname": slotHostname(cfg)}
+	if flavor != "" {
+		debugLog("captured %s flavor (%d bytes)", flavor, len(flavorData))
+		meta[metaFlavor] = flavor
+		meta[metaFlavorData] = string(flavorData)
+	}
 
 	// Push to remote
-	if err := backend.Push(slot, data, meta); err != nil {
+	added := len(data)
+	if appendMode {
+		combined, err := appendToSlot(backend, slot, data, separator, meta)
+		if err != nil {
+			return err
+		}
+		data = combined
+	} else if err := backend.Push(slot, data, meta); err != nil {
 		return err
 	}
 
-	printInfo("pushed %s to slot %q\n", formatSize(int64(len(data))), slot)
+	// Only after the push succeeds, so a failed push leaves the clipboard alone
+	if alsoCopy {
+		if err := writeClipboard(data); err != nil {
+			return fmt.Errorf("pushed to slot %q but updating the clipboard failed: %w", slot, err)
+		}
+		recordClipboardHistory(data)
+	}
+
+	if appendMode {
+		printInfo("appended %s to slot %q (now %s)\n", formatSize(int64(added)), slot, formatSize(int64(len(data))))
+	} else if alsoCopy {
+		printInfo("pushed %s to slot %q and copied it to the clipboard\n", formatSize(int64(len(data))), slot)
+	} else if archive {
+		printInfo("pushed %d file(s) as a %s archive to slot %q\n", fileCount, formatSize(int64(len(data))), slot)
+	} else if fromCommand != "" {
+		printInfo("pushed %s of command output to slot %q\n", formatSize(int64(len(data))), slot)
+	} else if flavor != "" {
+		printInfo("pushed %s to slot %q (with %s)\n", formatSize(int64(len(data))), slot, flavor)
+	} else {
+		printInfo("pushed %s to slot %q\n", formatSize(int64(len(data))), slot)
+	}
 	recordHistory("push", slot, int64(len(data)))
 	return nil
 }
 
-func cmdPull(args []string) error {
-	if len(args) != 1 {
-		return fmt.Errorf("usage: pipeboard pull <name>")
+// appendConflictRetries bounds how often push --append starts over after
+// another writer changed the slot between its pull and conditional push
+const appendConflictRetries = 10
+
+// appendToSlot pushes the slot's current content followed by data, holding
+// the slot's lock across the pull and push when the backend has one, or
+// pushing only if the slot is unchanged since the pull when it can (and
+// starting over if it changed). A missing slot is pushed with data alone.
+// It returns the content pushed.
+func appendToSlot(backend RemoteBackend, slot string, data []byte, separator string, meta map[string]string) ([]byte, error) {
+	if locker, ok := backend.(SlotLocker); ok {
+		unlock, err := locker.LockSlot(slot)
+		if err != nil {
+			return nil, err
+		}
+		defer unlock()
+	} else if conditional, ok := backend.(ConditionalPusher); ok {
+		for attempt := 1; ; attempt++ {
+			current, _, rev, err := conditional.PullRevision(slot)
+			if err != nil {
+				if !isSlotNotFound(err) {
+					return nil, fmt.Errorf("reading slot %q to append to: %w", slot, err)
+				}
+				current, rev = nil, ""
+			}
+			combined := joinClipboard(current, data, separator, false)
+			err = conditional.PushIfRevision(slot, combined, meta, rev)
+			if err == nil {
+				return combined, nil
+			}
+			if !errors.Is(err, errSlotChanged) {
+				return nil, err
+			}
+			if attempt == appendConflictRetries {
+				return nil, fmt.Errorf("slot %q kept changing during the append; try again", slot)
+			}
+			debugLog("slot %q changed during the append; starting over", slot)
+		}
+	} else {
+		fmt.Fprintf(os.Stderr, "warning: this backend can't lock slots; an append racing another push to %q may be lost\n", slot)
+	}
+
+	current, _, err := backend.Pull(slot)
+	if err != nil {
+		if !isSlotNotFound(err) {
+			return nil, fmt.Errorf("reading slot %q to append to: %w", slot, err)
+		}
+		debugLog("slot %q not found; pushing without appending", slot)
+		current = nil
+	}
+	combined := joinClipboard(current, data, separator, false)
+	if err := backend.Push(slot, combined, meta); err != nil {
+		return nil, err
+	}
+	return combined, nil
+}
+
+// Flags of push and pull that take a value, so sync can tell a slot name
+// from a flag's argument
+var (
+	pushValueFlags = map[string]bool{"--from-command": true, "--file": true, "-f": true, "--tar": true, "--separator": true}
+	pullValueFlags = map[string]bool{"--charset": true, "--lines": true, "--extract": true, "--output": true, "-o": true}
+)
+
+// cmdSync runs push or pull against defaults.slot when no slot is named
+func cmdSync(args []string) error {
+	const usage = "usage: pipeboard sync push [name] [push flags...]\n       pipeboard sync pull [name] [pull flags...]"
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+	var run func([]string) error
+	var valueFlags map[string]bool
+	switch args[0] {
+	case "push":
+		run, valueFlags = cmdPush, pushValueFlags
+	case "pull":
+		run, valueFlags = cmdPull, pullValueFlags
+	default:
+		return fmt.Errorf("unknown sync action %q\n%s", args[0], usage)
+	}
+	rest := args[1:]
+
+	// --auto-name and --latest pick the slot themselves
+	if hasPositionalArg(rest, valueFlags) || slices.Contains(rest, "--auto-name") || slices.Contains(rest, "--latest") {
+		return run(rest)
+	}
+	cfg, err := loadConfigForAliases()
+	if err != nil {
+		return err
+	}
+	slot, err := cfg.getDefaultSlot()
+	if err != nil {
+		return fmt.Errorf("%s\n%w", usage, err)
+	}
+	debugLog("sync %s: using default slot %q", args[0], slot)
+	return run(append([]string{slot}, rest...))
+}
+
+// hasPositionalArg reports whether args contain anything besides flags
+// and the values of valueFlags
+func hasPositionalArg(args []string, valueFlags map[string]bool) bool {
+	for i := 0; i < len(args); i++ {
+		switch {
+		case valueFlags[args[i]]:
+			i++
+		case !strings.HasPrefix(args[i], "-"):
+			return true
+		}
+	}
+	return false
+}
+
+// autoSlotName derives a slot name from the working directory and git
+// branch, appending a counter if a slot with that name already exists
+func autoSlotName(backend RemoteBackend) (string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("determining working directory: %w", err)
+	}
+	base := contextSlotName(dir)
+	if base == "" {
+		return "", fmt.Errorf("cannot derive a slot name from %s; pass a name instead", dir)
+	}
+
+	slots, err := backend.List()
+	if err != nil {
+		return "", err
+	}
+	existing := make(map[string]bool, len(slots))
+	for _, s := range slots {
+		existing[s.Name] = true
+	}
+
+	name := base
+	for n := 2; existing[name]; n++ {
+		name = fmt.Sprintf("%s-%d", base, n)
+	}
+	debugLog("auto-named slot %q", name)
+	return name, nil
+}
+
+// contextSlotName returns "<repo>-<branch>" inside a git work tree, or
+// the directory name elsewhere
+func contextSlotName(dir string) string {
+	name := filepath.Base(dir)
+	if top, err := gitOutput(dir, "rev-parse", "--show-toplevel"); err == nil && top != "" {
+		name = filepath.Base(top)
+		branch, err := gitOutput(dir, "symbolic-ref", "--short", "-q", "HEAD")
+		if err != nil || branch == "" {
+			// Detached HEAD: use the abbreviated commit
+			branch, _ = gitOutput(dir, "rev-parse", "--short", "HEAD")
+		}
+		if branch != "" {
+			name += "-" + branch
+		}
+	}
+	return sanitizeSlotName(name)
+}
+
+// gitOutput runs git in dir and returns its trimmed stdout
+func gitOutput(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	out, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// sanitizeSlotName replaces characters that don't belong in a slot name
+// (path separators, spaces) with dashes
+func sanitizeSlotName(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			b.WriteRune(r)
+		default:
+			b.WriteRune('-')
+		}
+	}
+	return strings.Trim(b.String(), "-.")
+}
+
+// pullStdoutWhenPiped reports whether defaults.pull_stdout_when_piped is
+// set, making pull write to a redirected stdout instead of the clipboard
+func pullStdoutWhenPiped() bool {
+	cfg, err := loadConfigForAliases()
+	return err == nil && cfg.Defaults != nil && cfg.Defaults.PullStdoutWhenPiped
+}
+
+func cmdPull(args []string) (err error) {
+	const usage = "usage: pipeboard pull <name> [--to-clipboard|--to-stdout] [--decompress] [--charset <name|auto>] [--lines <N-M>] [--allow-empty] [--rich]\n       pipeboard pull --latest <pattern> [--to-clipboard|--to-stdout] [--decompress] [--charset <name|auto>] [--lines <N-M>] [--allow-empty] [--rich]\n       pipeboard pull <name> --extract <dir> [--force]\n       pipeboard pull <name> --output <file> [--force] [--decompress] [--charset <name|auto>] [--lines <N-M>]"
+	var decompress, latest, allowEmpty, rich, toClipboard, toStdout, force bool
+	var charset, extractDir, outputPath string
+	var lines *lineRange
+	var positional []string
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; arg {
+		case "--decompress", "-z":
+			decompress = true
+		case "--latest":
+			latest = true
+		case "--allow-empty":
+			allowEmpty = true
+		case "--rich":
+			rich = true
+		case "--to-clipboard":
+			toClipboard = true
+		case "--to-stdout":
+			toStdout = true
+		case "--extract":
+			if i+1 >= len(args) || args[i+1] == "" {
+				return fmt.Errorf("--extract requires a directory\n%s", usage)
+			}
+			i++
+			extractDir = args[i]
+		case "--output", "-o":
+			if i+1 >= len(args) || args[i+1] == "" {
+				return fmt.Errorf("%s requires a path\n%s", arg, usage)
+			}
+			i++
+			outputPath = args[i]
+		case "--force":
+			force = true
+		case "--charset":
+			if i+1 >= len(args) {
+				return fmt.Errorf("--charset requires a charset name or auto\n%s", usage)
+			}
+			i++
+			charset = args[i]
+		case "--lines":
+			if i+1 >= len(args) {
+				return fmt.Errorf("--lines requires a range\n%s", usage)
+			}
+			i++
+			r, err := parseLineRange(args[i])
+			if err != nil {
+				return err
+			}
+			lines = &r
+		default:
+			positional = append(positional, arg)
+		}
+	}
+	if len(positional) != 1 {
+		return errors.New(usage)
+	}
+	// The rich flavor is restored as stored, so it can't honor text edits
+	if rich && (decompress || charset != "" || lines != nil) {
+		return errors.New("--rich can't be combined with --decompress, --charset, or --lines")
 	}
-	slot := resolveSlotName(args[0])
+	// Archives are unpacked as stored and never touch the clipboard
+	if extractDir != "" && (rich || decompress || charset != "" || lines != nil || allowEmpty || toClipboard || toStdout) {
+		return errors.New("--extract can't be combined with --rich, --decompress, --charset, --lines, --allow-empty, --to-clipboard, or --to-stdout")
+	}
+	if toClipboard && toStdout {
+		return errors.New("--to-clipboard and --to-stdout can't be combined")
+	}
+	if outputPath != "" && (rich || extractDir != "" || toClipboard || toStdout) {
+		return errors.New("--output can't be combined with --rich, --extract, --to-clipboard, or --to-stdout")
+	}
+	if force && outputPath == "" && extractDir == "" {
+		return errors.New("--force requires --output or --extract")
+	}
+	if rich && toStdout {
+		return errors.New("--rich restores a clipboard flavor; it can't be combined with --to-stdout")
+	}
+	// With defaults.pull_stdout_when_piped, write to stdout when it's
+	// redirected, like paste, unless told otherwise; --rich only makes
+	// sense on the clipboard
+	if !toClipboard && !toStdout && !rich && extractDir == "" && outputPath == "" && !stdoutIsTerminal() && pullStdoutWhenPiped() {
+		debugLog("stdout is not a terminal; pulling to stdout (use --to-clipboard for the clipboard)")
+		toStdout = true
+	}
+
+	var slot string
+	var data []byte
+	defer func() {
+		recordAudit(AuditRecord{Op: "pull", Slot: slot, Size: int64(len(data))}, err)
+	}()
 
 	backend, err := newRemoteBackendFromConfig()
 	if err != nil {
 		return err
 	}
 
+	if latest {
+		if slot, err = latestMatchingSlot(backend, positional[0]); err != nil {
+			return err
+		}
+		debugLog("--latest %q selected slot %q", positional[0], slot)
+	} else {
+		slot = resolveSlotName(positional[0])
+	}
+
 	data, meta, err := backend.Pull(slot)
 	if err != nil {
 		return err
 	}
 
-	if err := writeClipboard(data); err != nil {
-		return err
+	if extractDir != "" {
+		if !isTarArchive(data) {
+			return fmt.Errorf("slot %q is not a tar archive (%s); push files with -f or --tar", slot, meta["mime"])
+		}
+		n, err := extractSlotArchive(data, extractDir, force)
+		if err != nil {
+			return fmt.Errorf("extracting slot %q: %w", slot, err)
+		}
+		printInfo("extracted %d file(s) from slot %q to %s\n", n, slot, extractDir)
+		recordHistory("pull", slot, int64(len(data)))
+		return nil
+	}
+
+	// Inflate externally gzipped content (independent of pipeboard's own
+	// transparent compression, which Pull has already reversed)
+	mimeType := meta["mime"]
+	if decompress {
+		if isGzipContent(data, mimeType) {
+			inflated, err := decompressData(data)
+			if err != nil {
+				return fmt.Errorf("decompressing slot %q: %w", slot, err)
+			}
+			debugLog("decompressed %d bytes to %d bytes", len(data), len(inflated))
+			data = inflated
+			mimeType = detectMIME(data)
+		} else {
+			debugLog("slot %q is not gzip content (%s), leaving as-is", slot, mimeType)
+		}
+	}
+
+	if charset != "" {
+		if data, mimeType, err = applyCharset(data, charset, mimeType, slot); err != nil {
+			return err
+		}
+	}
+
+	if lines != nil {
+		if data, err = applyLineRange(data, mimeType, *lines, slot); err != nil {
+			return err
+		}
+	}
+
+	if toStdout {
+		if _, err := os.Stdout.Write(data); err != nil {
+			return err
+		}
+		recordHistory("pull", slot, int64(len(data)))
+		return nil
+	}
+	if outputPath != "" {
+		if err := writeOutputFile(outputPath, data, force); err != nil {
+			return err
+		}
+		printInfo("pulled %s from slot %q to %s\n", formatSize(int64(len(data))), slot, outputPath)
+		recordHistory("pull", slot, int64(len(data)))
+		return nil
+	}
+
+	// An empty slot would silently clear the local clipboard
+	if len(data) == 0 && !allowEmpty {
+		return fmt.Errorf("slot %q is empty; clipboard unchanged (use --allow-empty to clear it)", slot)
+	}
+
+	// Fall back to the text when the slot has no flavor or this machine's
+	// clipboard can't hold it
+	restored := false
+	if flavor := meta[metaFlavor]; rich && flavor != "" {
+		if restored, err = writeRichFlavor(flavor, []byte(meta[metaFlavorData])); err != nil {
+			return err
+		}
+		if !restored {
+			fmt.Fprintf(os.Stderr, "warning: this clipboard can't hold %s; pulled slot %q as plain text\n", flavor, slot)
+		}
+	}
+	if !restored {
+		if err := writeClipboard(data); err != nil {
+			return err
+		}
 	}
 
-	host := meta["hostname"]
-	if host != "" {
-		printInfo("pulled %s from slot %q (source: %s)\n", formatSize(int64(len(data))), slot, host)
+	what := fmt.Sprintf("pulled %s from slot %q", formatSize(int64(len(data))), slot)
+	if restored {
+		what += " as " + meta[metaFlavor]
+	}
+	if host := meta["hostname"]; host != "" {
+		printInfo("%s (source: %s)\n", what, host)
 	} else {
-		printInfo("pulled %s from slot %q\n", formatSize(int64(len(data))), slot)
+		printInfo("%s\n", what)
 	}
 	recordHistory("pull", slot, int64(len(data)))
 	return nil
 }
 
+// sortSlots orders slots for slots --sort: names A-Z, largest first, or
+// newest first, with ties broken by name. reverse flips the whole order.
+func sortSlots(slots []RemoteSlot, by string, reverse bool) {
+	sort.SliceStable(slots, func(i, j int) bool {
+		a, b := slots[i], slots[j]
+		if reverse {
+			a, b = b, a
+		}
+		switch {
+		case by == "size" && a.Size != b.Size:
+			return a.Size > b.Size
+		case by == "age" && !a.CreatedAt.Equal(b.CreatedAt):
+			return a.CreatedAt.After(b.CreatedAt)
+		}
+		return a.Name < b.Name
+	})
+}
+
+// latestMatchingSlot returns the most recently created slot whose name
+// matches a glob pattern (e.g. "backup-*"). Creation time decides, not
+// the name, so non-padded timestamps in names sort correctly.
+func latestMatchingSlot(backend RemoteBackend, pattern string) (string, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return "", fmt.Errorf("invalid slot pattern %q: %w", pattern, err)
+	}
+	slots, err := listSlotsMatching(backend, pattern)
+	if err != nil {
+		return "", err
+	}
+	var newest *RemoteSlot
+	for i, s := range slots {
+		if newest == nil || s.CreatedAt.After(newest.CreatedAt) {
+			newest = &slots[i]
+		}
+	}
+	if newest == nil {
+		return "", fmt.Errorf("no slots match %q", pattern)
+	}
+	return newest.Name, nil
+}
+
+// listSlotsMatching lists the slots whose names match the glob pattern.
+// The pattern's literal prefix is passed to backends that can filter on
+// the server, so only candidate slots are fetched.
+func listSlotsMatching(backend RemoteBackend, pattern string) ([]RemoteSlot, error) {
+	var slots []RemoteSlot
+	var err error
+	if pl, ok := backend.(PrefixListableBackend); ok {
+		prefix := globLiteralPrefix(pattern)
+		debugLog("listing slots with prefix %q", prefix)
+		slots, err = pl.ListPrefix(prefix)
+	} else {
+		slots, err = backend.List()
+	}
+	if err != nil {
+		return nil, err
+	}
+	matched := slots[:0]
+	for _, s := range slots {
+		if ok, _ := path.Match(pattern, s.Name); ok {
+			matched = append(matched, s)
+		}
+	}
+	return matched, nil
+}
+
+// globLiteralPrefix returns the part of a glob pattern before its first
+// special character
+func globLiteralPrefix(pattern string) string {
+	if i := strings.IndexAny(pattern, `*?[\`); i >= 0 {
+		return pattern[:i]
+	}
+	return pattern
+}
+
+// isGzipContent reports whether data is gzip, by declared MIME type or
+// by the gzip magic bytes
+func isGzipContent(data []byte, mimeType string) bool {
+	switch strings.TrimSpace(strings.SplitN(mimeType, ";", 2)[0]) {
+	case "application/gzip", "application/x-gzip":
+		return true
+	}
+	return len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b
+}
+
+// lineRange is a 1-based, inclusive range of lines (--lines N-M)
+type lineRange struct {
+	start, end int
+}
+
+// parseLineRange parses "N-M" or a single line "N"
+func parseLineRange(s string) (lineRange, error) {
+	from, to, isRange := strings.Cut(s, "-")
+	start, err := strconv.Atoi(from)
+	if err != nil || start < 1 {
+		return lineRange{}, fmt.Errorf("invalid line range: %s (use N-M, counting from 1)", s)
+	}
+	end := start
+	if isRange {
+		end, err = strconv.Atoi(to)
+		if err != nil || end < start {
+			return lineRange{}, fmt.Errorf("invalid line range: %s (use N-M, counting from 1)", s)
+		}
+	}
+	return lineRange{start: start, end: end}, nil
+}
+
+// selectLines returns the lines of data in r, keeping their line endings.
+// The range is clamped to the content; total is the number of lines.
+func selectLines(data []byte, r lineRange) (selected []byte, total int) {
+	lines := strings.SplitAfter(string(data), "\n")
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1] // trailing newline doesn't start a line
+	}
+	total = len(lines)
+	if r.start > total {
+		return []byte{}, total
+	}
+	end := min(r.end, total)
+	return []byte(strings.Join(lines[r.start-1:end], "")), total
+}
+
+// applyLineRange narrows text content to the lines in r, warning when the
+// range runs past the end. Binary content is rejected.
+func applyLineRange(data []byte, mimeType string, r lineRange, slot string) ([]byte, error) {
+	if !strings.HasPrefix(mimeType, "text/") {
+		return nil, fmt.Errorf("--lines only works with text content (slot %q is %s)", slot, mimeType)
+	}
+	selected, total := selectLines(data, r)
+	if r.end > total {
+		fmt.Fprintf(os.Stderr, "warning: slot %q has %d lines; requested %d-%d\n", slot, total, r.start, r.end)
+	}
+	return selected, nil
+}
+
 func cmdShow(args []string) error {
-	if len(args) != 1 {
-		return fmt.Errorf("usage: pipeboard show <name>")
+	const usage = "usage: pipeboard show <name> [--qr [--invert]] [--meta [--json]] [--version <id>] [--charset <name|auto>] [--lines <N-M>] [--pager|--no-pager]\n       pipeboard show --versions <name>"
+	var qrMode, invert, meta, jsonOutput, listVersions bool
+	var charset string
+	var lines *lineRange
+	var versionID int
+	pager := pagerAuto
+	var positional []string
+	for i := 0; i < len(args); i++ {
+		if parsePagerFlag(args[i], &pager) {
+			continue
+		}
+		switch arg := args[i]; arg {
+		case "--qr":
+			qrMode = true
+		case "--invert":
+			invert = true
+		case "--meta":
+			meta = true
+		case "--json":
+			jsonOutput = true
+		case "--versions":
+			listVersions = true
+		case "--version":
+			if i+1 >= len(args) {
+				return fmt.Errorf("--version requires a version id\n%s", usage)
+			}
+			i++
+			id, err := strconv.Atoi(args[i])
+			if err != nil || id <= 0 {
+				return fmt.Errorf("invalid version id: %s", args[i])
+			}
+			versionID = id
+		case "--charset":
+			if i+1 >= len(args) {
+				return fmt.Errorf("--charset requires a charset name or auto\n%s", usage)
+			}
+			i++
+			charset = args[i]
+		case "--lines":
+			if i+1 >= len(args) {
+				return fmt.Errorf("--lines requires a range\n%s", usage)
+			}
+			i++
+			r, err := parseLineRange(args[i])
+			if err != nil {
+				return err
+			}
+			lines = &r
+		default:
+			positional = append(positional, arg)
+		}
 	}
-	slot := resolveSlotName(args[0])
+	if len(positional) != 1 {
+		return errors.New(usage)
+	}
+	if jsonOutput && !meta {
+		return errors.New("--json requires --meta")
+	}
+	slot := resolveSlotName(positional[0])
 
 	backend, err := newRemoteBackendFromConfig()
 	if err != nil {
 		return err
 	}
 
-	data, _, err := backend.Pull(slot)
+	if listVersions || versionID > 0 {
+		vb, ok := backend.(VersionedBackend)
+		if !ok {
+			return fmt.Errorf("this sync backend does not support slot versions")
+		}
+		if listVersions {
+			return printSlotVersions(vb, slot)
+		}
+		if meta {
+			// Read metadata from the listing so it works without the passphrase
+			versions, err := vb.ListVersions(slot)
+			if err != nil {
+				return err
+			}
+			for _, v := range versions {
+				if v.ID == versionID {
+					return writeSlotMeta(slotVersionMeta(slot, v), jsonOutput)
+				}
+			}
+			return fmt.Errorf("version %d of slot %q not found", versionID, slot)
+		}
+		data, v, err := vb.PullVersion(slot, versionID)
+		if err != nil {
+			return err
+		}
+		mimeType := v.MIME
+		if charset != "" {
+			if data, mimeType, err = applyCharset(data, charset, mimeType, slot); err != nil {
+				return err
+			}
+		}
+		if lines != nil {
+			if data, err = applyLineRange(data, mimeType, *lines, slot); err != nil {
+				return err
+			}
+		}
+		return writeShowOutput(data, qrMode, invert, pager)
+	}
+
+	// Read the header without decoding, so this works without the passphrase
+	if ib, ok := backend.(InspectableBackend); ok && meta {
+		payload, size, err := ib.Inspect(slot)
+		if err != nil {
+			return err
+		}
+		return writeSlotMeta(slotPayloadMeta(slot, payload, size), jsonOutput)
+	}
+
+	data, slotMeta, err := backend.Pull(slot)
 	if err != nil {
 		return err
 	}
 
+	if meta {
+		return writeSlotMeta(slotPulledMeta(slot, data, slotMeta), jsonOutput)
+	}
+
+	mimeType := slotMeta["mime"]
+	if charset != "" {
+		if data, mimeType, err = applyCharset(data, charset, mimeType, slot); err != nil {
+			return err
+		}
+	}
+
+	if lines != nil {
+		if data, err = applyLineRange(data, mimeType, *lines, slot); err != nil {
+			return err
+		}
+	}
+
+	return writeShowOutput(data, qrMode, invert, pager)
+}
+
+// writeShowOutput prints slot content to stdout, or as a QR code
+func writeShowOutput(data []byte, qrMode, invert bool, pager pagerMode) error {
+	if qrMode {
+		return printQR(data, invert)
+	}
+
 	// Write to stdout instead of clipboard
-	_, err = os.Stdout.Write(data)
-	return err
+	return writePaged(data, pager)
 }
 
-func cmdSlots(args []string) error {
-	var jsonOutput bool
+// printSlotVersions lists the stored versions of a slot, oldest first
+func printSlotVersions(vb VersionedBackend, slot string) error {
+	versions, err := vb.ListVersions(slot)
+	if err != nil {
+		return err
+	}
+	if len(versions) == 0 {
+		fmt.Printf("No versions stored for %q (set sync.versions in config to enable).\n", slot)
+		return nil
+	}
+
+	fmt.Printf("%-8s %-10s %-20s %-12s %s\n", "VERSION", "SIZE", "CREATED", "AGE", "HOST")
+	for _, v := range versions {
+		fmt.Printf("%-8d %-10s %-20s %-12s %s\n",
+			v.ID,
+			formatSize(int64(v.Len)),
+			v.CreatedAt.Local().Format("2006-01-02 15:04:05"),
+			formatAge(v.CreatedAt),
+			v.Hostname)
+	}
+	return nil
+}
+
+// slotMeta is the envelope metadata show --meta prints, as text or with
+// --json. Fields the backend doesn't record are left empty and omitted.
+type slotMeta struct {
+	Slot        string `json:"slot"`
+	Version     int    `json:"version,omitempty"`
+	Size        int64  `json:"size"`             // content length in bytes
+	Stored      int64  `json:"stored,omitempty"` // bytes held by the backend
+	CreatedAt   string `json:"created_at,omitempty"`
+	ExpiresAt   string `json:"expires_at,omitempty"`
+	UpdatedAt   string `json:"updated_at,omitempty"` // hosted backend
+	Hostname    string `json:"hostname,omitempty"`
+	OS          string `json:"os,omitempty"`
+	MIME        string `json:"mime,omitempty"`
+	Encrypted   bool   `json:"encrypted"`
+	Encryption  string `json:"encryption,omitempty"` // "aes256" or "age" when encrypted
+	Compressed  bool   `json:"compressed"`
+	Compression string `json:"compression,omitempty"` // "gzip" or "zstd" when compressed
+	Encoding    string `json:"encoding,omitempty"`
+	Flavor      string `json:"flavor,omitempty"` // rich flavor pushed with the text
+}
+
+// slotVersionMeta describes one stored version from the version listing
+func slotVersionMeta(slot string, v SlotVersion) slotMeta {
+	return slotMeta{
+		Slot:        slot,
+		Version:     v.ID,
+		Size:        int64(v.Len),
+		Stored:      v.Size,
+		CreatedAt:   v.CreatedAt.UTC().Format(time.RFC3339),
+		Hostname:    v.Hostname,
+		OS:          v.OS,
+		MIME:        v.MIME,
+		Encrypted:   v.Encrypted,
+		Encryption:  SlotPipeline{Encrypted: v.Encrypted, Encryption: v.Encryption}.encryptionName(),
+		Compressed:  v.Compressed,
+		Compression: v.Compression,
+		Encoding:    v.Encoding,
+	}
+}
+
+// slotPayloadMeta describes a slot from its payload header, so the flags
+// shown are the ones the data was stored with
+func slotPayloadMeta(slot string, payload SlotPayload, size int64) slotMeta {
+	p := payloadPipeline(payload)
+	return slotMeta{
+		Slot:        slot,
+		Size:        int64(payload.Len),
+		Stored:      size,
+		CreatedAt:   payload.CreatedAt,
+		ExpiresAt:   payload.ExpiresAt,
+		Hostname:    payload.Hostname,
+		OS:          payload.OS,
+		MIME:        payload.MIME,
+		Encrypted:   p.Encrypted,
+		Encryption:  p.encryptionName(),
+		Compressed:  p.Compressed,
+		Compression: p.Compression,
+		Encoding:    p.Encoding,
+		Flavor:      payloadFlavorMIME(payload),
+	}
+}
+
+// payloadFlavorMIME is the MIME type of the rich flavor stored with a
+// payload, or "" when push captured only text
+func payloadFlavorMIME(payload SlotPayload) string {
+	if payload.Flavor == nil {
+		return ""
+	}
+	return payload.Flavor.MIME
+}
+
+// slotPulledMeta describes a slot from the metadata a backend's Pull
+// returned, for backends that can't be inspected without pulling
+func slotPulledMeta(slot string, data []byte, meta map[string]string) slotMeta {
+	stored, _ := strconv.ParseInt(meta["stored"], 10, 64)
+	return slotMeta{
+		Slot:       slot,
+		Size:       int64(len(data)),
+		Stored:     stored,
+		CreatedAt:  meta["created_at"],
+		UpdatedAt:  meta["updated_at"],
+		Hostname:   meta["hostname"],
+		OS:         meta["os"],
+		MIME:       meta["mime"],
+		Encrypted:  meta["encrypted"] == "true",
+		Encryption: meta["encryption"],
+		Flavor:     meta[metaFlavor],
+	}
+}
+
+// writeSlotMeta prints slot metadata as aligned fields, or as JSON
+func writeSlotMeta(m slotMeta, jsonOutput bool) error {
+	if jsonOutput {
+		out, err := marshalJSONOutput(m)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+		return nil
+	}
+
+	fmt.Printf("slot:       %s\n", m.Slot)
+	if m.Version > 0 {
+		fmt.Printf("version:    %d\n", m.Version)
+	}
+	fmt.Printf("size:       %s\n", formatSize(m.Size))
+	if m.Stored > 0 {
+		fmt.Printf("stored:     %s\n", formatSize(m.Stored))
+	}
+	if m.CreatedAt != "" {
+		fmt.Printf("created_at: %s\n", m.CreatedAt)
+	}
+	if expiresAt, err := time.Parse(time.RFC3339, m.ExpiresAt); err == nil {
+		fmt.Printf("expires_at: %s (%s)\n", m.ExpiresAt, formatExpiry(expiresAt))
+	}
+	if m.UpdatedAt != "" {
+		fmt.Printf("updated_at: %s\n", m.UpdatedAt)
+	}
+	if m.Hostname != "" {
+		fmt.Printf("hostname:   %s\n", m.Hostname)
+	}
+	if m.OS != "" {
+		fmt.Printf("os:         %s\n", m.OS)
+	}
+	fmt.Printf("mime:       %s\n", m.MIME)
+	if m.Encryption != "" {
+		fmt.Printf("encrypted:  %t (%s)\n", m.Encrypted, m.Encryption)
+	} else {
+		fmt.Printf("encrypted:  %t\n", m.Encrypted)
+	}
+	if m.Compression != "" {
+		fmt.Printf("compressed: %t (%s)\n", m.Compressed, m.Compression)
+	} else {
+		fmt.Printf("compressed: %t\n", m.Compressed)
+	}
+	if m.Encoding != "" {
+		fmt.Printf("encoding:   %s\n", m.Encoding)
+	}
+	if m.Flavor != "" {
+		fmt.Printf("flavor:     %s\n", m.Flavor)
+	}
+	return nil
+}
+
+// cmdVerify checks that a slot decodes back to what its header records:
+// the encoding parses, decryption and decompression succeed, and the
+// content length matches
+func cmdVerify(args []string) error {
+	const usage = "usage: pipeboard verify <name> [--explain]"
+	var explain bool
+	var positional []string
 	for _, arg := range args {
-		switch arg {
+		switch {
+		case arg == "--explain":
+			explain = true
+		case strings.HasPrefix(arg, "-"):
+			return fmt.Errorf("unknown flag: %s\n%s", arg, usage)
+		default:
+			positional = append(positional, arg)
+		}
+	}
+	if len(positional) != 1 {
+		return errors.New(usage)
+	}
+	slot := resolveSlotName(positional[0])
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	backend, err := newRemoteBackendFromConfig()
+	if err != nil {
+		return err
+	}
+	ib, ok := backend.(InspectableBackend)
+	if !ok {
+		return fmt.Errorf("the %s backend does not store slot payloads; verify supports the local and s3 backends", cfg.Sync.Backend)
+	}
+
+	payload, size, err := ib.Inspect(slot)
+	if err != nil {
+		return err
+	}
+	if explain {
+		printSlotPipeline(slot, payload, size, cfg.Sync)
+	}
+
+	data, _, err := backend.Pull(slot)
+	if err != nil {
+		return fmt.Errorf("slot %q failed verification: %w", slot, err)
+	}
+	if len(data) != payload.Len {
+		return fmt.Errorf("slot %q failed verification: decoded %d bytes, header records %d", slot, len(data), payload.Len)
+	}
+
+	if explain {
+		fmt.Println("verified:   ok")
+		return nil
+	}
+	fmt.Printf("slot %q ok (%s)\n", slot, formatSize(int64(len(data))))
+	return nil
+}
+
+// printSlotPipeline describes the stages a slot went through on push, and
+// how the current config differs, for verify --explain
+func printSlotPipeline(slot string, payload SlotPayload, size int64, sync *SyncConfig) {
+	stored := payloadPipeline(payload)
+	fmt.Printf("slot:       %s\n", slot)
+	fmt.Printf("content:    %s (%s)\n", formatSize(int64(payload.Len)), payload.MIME)
+	fmt.Printf("pipeline:   %s\n", strings.Join(stored.Steps(), " -> "))
+	fmt.Printf("stored:     %s\n", formatSize(size))
+
+	// Compression is skipped per push for small or incompressible data,
+	// so only encryption and encoding are compared with config
+	configured := SlotPipeline{Encoding: sync.Encoding}
+	switch {
+	case sync.Encryption == "aes256" && sync.hasPassphrase():
+		configured.Encrypted = true
+	case sync.Encryption == "age":
+		configured.Encrypted, configured.Encryption = true, "age"
+	}
+	if configured.Encoding == "" {
+		configured.Encoding = payloadEncodingBase64
+	}
+	var differs []string
+	if configured.Scheme() != stored.Scheme() {
+		differs = append(differs, "encryption")
+	}
+	if configured.Encoding != stored.Encoding {
+		differs = append(differs, "encoding")
+	}
+	line := fmt.Sprintf("encryption %s, encoding %s", configured.Scheme(), configured.Encoding)
+	if len(differs) > 0 {
+		line += " (differs from stored: " + strings.Join(differs, ", ") + ")"
+	}
+	fmt.Printf("config:     %s\n", line)
+}
+
+func cmdSlots(args []string) error {
+	const usage = "usage: pipeboard slots [pattern|--filter <glob>] [--sort name|size|age] [--sort-reverse] [--json|--csv|--tsv] [--wide] [--no-headers]"
+	var jsonOutput, reverse bool
+	var opts tableOptions
+	var pattern, sortBy string
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; arg {
+		case "--sort":
+			if i+1 >= len(args) {
+				return fmt.Errorf("--sort requires name, size, or age\n%s", usage)
+			}
+			i++
+			sortBy = args[i]
+			if sortBy != "name" && sortBy != "size" && sortBy != "age" {
+				return fmt.Errorf("invalid --sort %q (use name, size, or age)", sortBy)
+			}
+		case "--sort-reverse":
+			reverse = true
+		case "--filter":
+			if i+1 >= len(args) || args[i+1] == "" {
+				return fmt.Errorf("--filter requires a glob pattern\n%s", usage)
+			}
+			if pattern != "" {
+				return errors.New("give the slot pattern once, either as an argument or with --filter")
+			}
+			i++
+			pattern = args[i]
 		case "--json":
 			jsonOutput = true
+		case "--wide":
+			opts.wide = true
+		case "--no-headers":
+			opts.noHeaders = true
 		default:
-			return fmt.Errorf("unknown flag: %s\nusage: pipeboard slots [--json]", arg)
+			if opts.parseDelimitedFlag(arg) {
+				continue
+			}
+			if strings.HasPrefix(arg, "-") {
+				return fmt.Errorf("unknown flag: %s\n%s", arg, usage)
+			}
+			if pattern != "" {
+				return errors.New(usage)
+			}
+			pattern = arg
+		}
+	}
+	if reverse && sortBy == "" {
+		return errors.New("--sort-reverse requires --sort")
+	}
+	if pattern != "" {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid slot pattern %q: %w", pattern, err)
 		}
 	}
+	if err := opts.checkOutputFormat(jsonOutput); err != nil {
+		return err
+	}
 
 	backend, err := newRemoteBackendFromConfig()
 	if err != nil {
 		return err
 	}
 
-	slots, err := backend.List()
+	var slots []RemoteSlot
+	if pattern != "" {
+		slots, err = listSlotsMatching(backend, pattern)
+	} else {
+		slots, err = backend.List()
+	}
 	if err != nil {
 		return err
 	}
+	if sortBy != "" {
+		sortSlots(slots, sortBy, reverse)
+	}
+
+	if opts.delim != 0 {
+		return writeSlotsDelimited(slots, opts)
+	}
 
 	if len(slots) == 0 {
 		if jsonOutput {
@@ -138,6 +1236,11 @@ func cmdSlots(args []string) error {
 			Age       string `json:"age"`
 			ExpiresAt string `json:"expires_at,omitempty"`
 			ExpiresIn string `json:"expires_in,omitempty"`
+			// Seconds until expiry, 0 once expired
+			ExpiresInSeconds *int64 `json:"expires_in_seconds,omitempty"`
+			// Expiry derived from the upload time and current ttl_days
+			ExpiresEstimated bool          `json:"expires_estimated,omitempty"`
+			Stored           *SlotPipeline `json:"stored,omitempty"`
 		}
 		jsonSlots := make([]jsonSlot, len(slots))
 		for i, s := range slots {
@@ -147,14 +1250,18 @@ func cmdSlots(args []string) error {
 				SizeHuman: formatSize(s.Size),
 				CreatedAt: s.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
 				Age:       formatAge(s.CreatedAt),
+				Stored:    s.Stored,
 			}
 			if !s.ExpiresAt.IsZero() {
 				js.ExpiresAt = s.ExpiresAt.Format("2006-01-02T15:04:05Z07:00")
 				js.ExpiresIn = formatTimeUntil(s.ExpiresAt)
+				secs := max(int64(time.Until(s.ExpiresAt).Seconds()), 0)
+				js.ExpiresInSeconds = &secs
+				js.ExpiresEstimated = s.ExpiryEstimated
 			}
 			jsonSlots[i] = js
 		}
-		out, err := json.MarshalIndent(jsonSlots, "", "  ")
+		out, err := marshalJSONOutput(jsonSlots)
 		if err != nil {
 			return err
 		}
@@ -162,37 +1269,51 @@ func cmdSlots(args []string) error {
 		return nil
 	}
 
-	// Check if any slots have expiry
+	// Check if any slots have expiry, and whether it is estimated
 	hasExpiry := false
+	expiresHeader := "EXPIRES"
+	longestName := 0
 	for _, s := range slots {
 		if !s.ExpiresAt.IsZero() {
 			hasExpiry = true
-			break
 		}
+		if s.ExpiryEstimated {
+			expiresHeader = "EST. EXPIRES"
+		}
+		longestName = max(longestName, utf8.RuneCountInString(s.Name))
 	}
 
-	// Print header
+	// Name column grows in --wide mode; the rest are fixed
+	reserved := 2 + 10 + 2 + 12
 	if hasExpiry {
-		fmt.Printf("%-20s  %-10s  %-12s  %-12s\n", "NAME", "SIZE", "AGE", "EXPIRES")
-	} else {
-		fmt.Printf("%-20s  %-10s  %-12s\n", "NAME", "SIZE", "AGE")
+		reserved += 2 + 12
+	}
+	nameWidth := opts.columnWidth(20, longestName, reserved)
+
+	// Print header
+	if !opts.noHeaders {
+		if hasExpiry {
+			fmt.Printf("%-*s  %-10s  %-12s  %-12s\n", nameWidth, "NAME", "SIZE", "AGE", expiresHeader)
+		} else {
+			fmt.Printf("%-*s  %-10s  %-12s\n", nameWidth, "NAME", "SIZE", "AGE")
+		}
 	}
 
 	for _, s := range slots {
 		if hasExpiry {
 			expires := "-"
 			if !s.ExpiresAt.IsZero() {
-				expires = formatTimeUntil(s.ExpiresAt)
+				expires = formatExpiry(s.ExpiresAt)
 			}
-			fmt.Printf("%-20s  %-10s  %-12s  %-12s\n",
-				s.Name,
+			fmt.Printf("%-*s  %-10s  %-12s  %-12s\n",
+				nameWidth, s.Name,
 				formatSize(s.Size),
 				formatAge(s.CreatedAt),
 				expires,
 			)
 		} else {
-			fmt.Printf("%-20s  %-10s  %-12s\n",
-				s.Name,
+			fmt.Printf("%-*s  %-10s  %-12s\n",
+				nameWidth, s.Name,
 				formatSize(s.Size),
 				formatAge(s.CreatedAt),
 			)
@@ -202,21 +1323,236 @@ func cmdSlots(args []string) error {
 	return nil
 }
 
+// writeSlotsDelimited prints slots as CSV/TSV rows: exact byte sizes and
+// RFC 3339 times, plus the human-readable age
+func writeSlotsDelimited(slots []RemoteSlot, opts tableOptions) error {
+	rows := make([][]string, len(slots))
+	for i, s := range slots {
+		expires, estimated := "", ""
+		if !s.ExpiresAt.IsZero() {
+			expires = s.ExpiresAt.Format(time.RFC3339)
+			estimated = strconv.FormatBool(s.ExpiryEstimated)
+		}
+		rows[i] = []string{
+			s.Name,
+			strconv.FormatInt(s.Size, 10),
+			s.CreatedAt.Format(time.RFC3339),
+			formatAge(s.CreatedAt),
+			expires,
+			estimated,
+		}
+	}
+	return opts.writeDelimited([]string{"name", "size", "created_at", "age", "expires_at", "expires_estimated"}, rows)
+}
+
 func cmdRm(args []string) error {
-	if len(args) != 1 {
-		return fmt.Errorf("usage: pipeboard rm <name>")
+	const usage = "usage: pipeboard rm <name> [name...]\n       pipeboard rm <pattern> --keep-last <n> [--yes]"
+	var names []string
+	keepLast := -1
+	var assumeYes bool
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; arg {
+		case "--keep-last":
+			if i+1 >= len(args) {
+				return fmt.Errorf("--keep-last requires a count\n%s", usage)
+			}
+			i++
+			n, err := strconv.Atoi(args[i])
+			if err != nil || n < 0 {
+				return fmt.Errorf("invalid --keep-last: %s (use a count of 0 or more)", args[i])
+			}
+			keepLast = n
+		case "--yes", "-y":
+			assumeYes = true
+		default:
+			if strings.HasPrefix(arg, "-") {
+				return fmt.Errorf("unknown flag: %s\n%s", arg, usage)
+			}
+			names = append(names, arg)
+		}
+	}
+	if len(names) == 0 {
+		return errors.New(usage)
+	}
+	if keepLast < 0 && assumeYes {
+		return fmt.Errorf("--yes requires --keep-last\n%s", usage)
+	}
+	if keepLast >= 0 {
+		if len(names) != 1 {
+			return fmt.Errorf("--keep-last takes a single slot pattern\n%s", usage)
+		}
+		if _, err := path.Match(names[0], ""); err != nil {
+			return fmt.Errorf("invalid slot pattern %q: %w", names[0], err)
+		}
 	}
-	slot := resolveSlotName(args[0])
 
 	backend, err := newRemoteBackendFromConfig()
 	if err != nil {
 		return err
 	}
 
-	if err := backend.Delete(slot); err != nil {
+	if keepLast >= 0 {
+		return rmKeepLast(backend, names[0], keepLast, assumeYes)
+	}
+
+	if len(names) == 1 {
+		slot := resolveSlotName(names[0])
+		err := backend.Delete(slot)
+		recordAudit(AuditRecord{Op: "rm", Slot: slot}, err)
+		if err != nil {
+			return err
+		}
+		printInfo("deleted slot %q\n", slot)
+		return nil
+	}
+
+	slots := make([]string, len(names))
+	for i, name := range names {
+		slots[i] = resolveSlotName(name)
+	}
+	return deleteSlots(backend, slots)
+}
+
+// deleteSlots deletes several slots, reporting each result and keeping
+// going past failures
+func deleteSlots(backend RemoteBackend, slots []string) error {
+	failed := 0
+	done := func(slot string, err error) {
+		recordAudit(AuditRecord{Op: "rm", Slot: slot}, err)
+		if err != nil {
+			printError(err)
+			failed++
+			return
+		}
+		printInfo("deleted slot %q\n", slot)
+	}
+	if batch, ok := backend.(BatchDeleter); ok {
+		batch.DeleteBatch(slots, done)
+	} else {
+		for _, slot := range slots {
+			done(slot, backend.Delete(slot))
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to delete %d of %d slots", failed, len(slots))
+	}
+	return nil
+}
+
+// confirmRemove asks whether rm --keep-last should delete the listed slots
+var confirmRemove = func(prompt string) bool {
+	return promptYesNo(prompt, false)
+}
+
+// rmKeepLast deletes the slots matching a glob pattern except the newest
+// keep, by creation time, after listing them and asking for confirmation
+func rmKeepLast(backend RemoteBackend, pattern string, keep int, assumeYes bool) error {
+	slots, err := listSlotsMatching(backend, pattern)
+	if err != nil {
+		return err
+	}
+	if len(slots) <= keep {
+		printInfo("%d slot(s) match %q; nothing to delete\n", len(slots), pattern)
+		return nil
+	}
+	sortSlots(slots, "age", false)
+	doomed := make([]string, 0, len(slots)-keep)
+	for _, s := range slots[keep:] {
+		doomed = append(doomed, s.Name)
+	}
+
+	if !assumeYes {
+		if quietMode || !stdinIsTerminal() {
+			return fmt.Errorf("rm --keep-last would delete %d slot(s) matching %q; use --yes to confirm", len(doomed), pattern)
+		}
+		for _, s := range slots[keep:] {
+			fmt.Printf("  %s (%s)\n", s.Name, formatAge(s.CreatedAt))
+		}
+		if !confirmRemove(fmt.Sprintf("Delete these %d slot(s), keeping the newest %d?", len(doomed), keep)) {
+			return errors.New("rm cancelled")
+		}
+	}
+	return deleteSlots(backend, doomed)
+}
+
+// defaultMultipartAge is how old an incomplete upload must be before
+// prune --s3-multipart aborts it, so uploads still in progress are left alone
+const defaultMultipartAge = 24 * time.Hour
+
+func cmdPrune(args []string) error {
+	const usage = "usage: pipeboard prune --s3-multipart [--older-than <duration>] [--dry-run]"
+	var multipart, dryRun bool
+	olderThan := defaultMultipartAge
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; arg {
+		case "--s3-multipart":
+			multipart = true
+		case "--dry-run", "-n":
+			dryRun = true
+		case "--older-than":
+			if i+1 >= len(args) {
+				return fmt.Errorf("--older-than requires a duration\n%s", usage)
+			}
+			i++
+			d, err := time.ParseDuration(args[i])
+			if err != nil || d < 0 {
+				return fmt.Errorf("invalid duration: %s", args[i])
+			}
+			olderThan = d
+		default:
+			return fmt.Errorf("unknown argument: %s\n%s", arg, usage)
+		}
+	}
+	if !multipart {
+		return errors.New(usage)
+	}
+
+	backend, err := newRemoteBackendFromConfig()
+	if err != nil {
 		return err
 	}
+	s3b, ok := backend.(*S3Backend)
+	if !ok {
+		return fmt.Errorf("prune --s3-multipart requires the s3 sync backend")
+	}
 
-	printInfo("deleted slot %q\n", slot)
+	n, err := pruneMultipartUploads(s3b, olderThan, dryRun)
+	if err != nil {
+		return err
+	}
+	if dryRun {
+		printInfo("%d stale multipart upload(s) would be aborted\n", n)
+	} else {
+		printInfo("aborted %d stale multipart upload(s)\n", n)
+	}
 	return nil
 }
+
+// pruneMultipartUploads aborts incomplete multipart uploads started more
+// than olderThan ago and returns how many were (or would be) aborted
+func pruneMultipartUploads(b *S3Backend, olderThan time.Duration, dryRun bool) (int, error) {
+	uploads, err := b.listMultipartUploads()
+	if err != nil {
+		return 0, err
+	}
+
+	cutoff := time.Now().Add(-olderThan)
+	n := 0
+	for _, u := range uploads {
+		if u.Initiated.After(cutoff) {
+			debugLog("skipping recent multipart upload of %s (%s)", u.Key, formatAge(u.Initiated))
+			continue
+		}
+		if dryRun {
+			fmt.Printf("would abort %s (started %s)\n", u.Key, formatAge(u.Initiated))
+			n++
+			continue
+		}
+		if err := b.abortMultipartUpload(u); err != nil {
+			return n, err
+		}
+		printInfo("aborted %s (started %s)\n", u.Key, formatAge(u.Initiated))
+		n++
+	}
+	return n, nil
+}
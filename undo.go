@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// lastClipboard is the snapshot undo restores: what the clipboard held
+// before the last copy or recall replaced it
+type lastClipboard struct {
+	Saved     time.Time `json:"saved"`
+	Encrypted bool      `json:"encrypted,omitempty"`
+	Content   []byte    `json:"content"`
+}
+
+func getLastClipboardPath() string {
+	configDir := os.Getenv("XDG_CONFIG_HOME")
+	if configDir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		configDir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(configDir, "pipeboard", ".last_clipboard")
+}
+
+// snapshotClipboard saves the current clipboard for undo before it is
+// replaced with next. Nothing is saved when the clipboard can't be read,
+// is empty, already holds next, or is larger than history.max_entry_bytes.
+func snapshotClipboard(next []byte) {
+	current, err := readClipboard()
+	if err != nil {
+		debugLog("undo snapshot skipped: %v", err)
+		return
+	}
+	if len(current) == 0 || bytes.Equal(current, next) {
+		return
+	}
+	if int64(len(current)) > getHistoryConfig().maxEntryBytes() {
+		debugLog("undo snapshot skipped: %d bytes exceeds max_entry_bytes", len(current))
+		return
+	}
+	if err := saveLastClipboard(current); err != nil {
+		debugLog("undo snapshot failed: %v", err)
+	}
+}
+
+// saveLastClipboard writes the undo snapshot, encrypted like clipboard
+// history when sync encryption is configured
+func saveLastClipboard(content []byte) error {
+	path := getLastClipboardPath()
+	if path == "" {
+		return errors.New("could not determine config directory")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	snap := lastClipboard{Saved: time.Now(), Content: content}
+	if enabled, passphrase := getHistoryEncryptionConfig(); enabled {
+		enc, err := encrypt(content, passphrase)
+		if err != nil {
+			return err
+		}
+		snap.Content, snap.Encrypted = enc, true
+	}
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+func loadLastClipboard() (lastClipboard, error) {
+	var snap lastClipboard
+	data, err := os.ReadFile(getLastClipboardPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return snap, errors.New("nothing to undo (no earlier clipboard saved by copy or recall)")
+		}
+		return snap, err
+	}
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return snap, fmt.Errorf("reading undo snapshot: %w", err)
+	}
+	if snap.Encrypted {
+		_, passphrase := getHistoryEncryptionConfig()
+		if passphrase == "" {
+			return snap, errors.New("undo snapshot is encrypted but no passphrase configured in sync settings")
+		}
+		content, err := decrypt(snap.Content, passphrase)
+		if err != nil {
+			return snap, fmt.Errorf("decrypting undo snapshot: %w", err)
+		}
+		snap.Content, snap.Encrypted = content, false
+	}
+	return snap, nil
+}
+
+// cmdUndo restores the clipboard saved before the last copy or recall.
+// The replaced content becomes the new snapshot, so a second undo
+// redoes.
+func cmdUndo(args []string) error {
+	if len(args) > 0 {
+		return errors.New("usage: pipeboard undo")
+	}
+	snap, err := loadLastClipboard()
+	if err != nil {
+		return err
+	}
+
+	current, readErr := readClipboard()
+	if err := writeClipboard(snap.Content); err != nil {
+		return err
+	}
+	if readErr == nil && len(current) > 0 {
+		if err := saveLastClipboard(current); err != nil {
+			debugLog("saving redo snapshot failed: %v", err)
+		}
+	} else {
+		_ = os.Remove(getLastClipboardPath())
+	}
+
+	printInfo("restored clipboard saved %s (%s)\n", formatAge(snap.Saved), formatSize(int64(len(snap.Content))))
+	return nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+// Test undo restores the clipboard replaced by recall, and a second undo
+// brings the recalled entry back
+func TestCmdUndoAfterRecall(t *testing.T) {
+	defer setupSlotsTestConfig(t, "version: 1\n")()
+	recordClipboardHistory([]byte("from history"))
+	clipPath := useFileClipboard(t, "original")
+
+	captureOutput(func() {
+		if err := cmdRecall([]string{"1"}); err != nil {
+			t.Fatalf("recall: %v", err)
+		}
+	})
+	if got, _ := os.ReadFile(clipPath); string(got) != "from history" {
+		t.Fatalf("clipboard after recall = %q", got)
+	}
+
+	if err := cmdUndo(nil); err != nil {
+		t.Fatalf("undo: %v", err)
+	}
+	if got, _ := os.ReadFile(clipPath); string(got) != "original" {
+		t.Errorf("clipboard after undo = %q, want original", got)
+	}
+
+	if err := cmdUndo(nil); err != nil {
+		t.Fatalf("second undo: %v", err)
+	}
+	if got, _ := os.ReadFile(clipPath); string(got) != "from history" {
+		t.Errorf("clipboard after second undo = %q, want the recalled entry", got)
+	}
+}
+
+// Test undo restores the clipboard replaced by copy
+func TestCmdUndoAfterCopy(t *testing.T) {
+	defer setupSlotsTestConfig(t, "version: 1\n")()
+	clipPath := useFileClipboard(t, "before copy")
+
+	captureOutput(func() {
+		if err := cmdCopy([]string{"copied"}); err != nil {
+			t.Fatalf("copy: %v", err)
+		}
+	})
+	if err := cmdUndo(nil); err != nil {
+		t.Fatalf("undo: %v", err)
+	}
+	if got, _ := os.ReadFile(clipPath); string(got) != "before copy" {
+		t.Errorf("clipboard after undo = %q, want before copy", got)
+	}
+}
+
+// Test undo without a snapshot, and that an empty clipboard isn't saved
+func TestCmdUndoNothingSaved(t *testing.T) {
+	defer setupSlotsTestConfig(t, "version: 1\n")()
+	useFileClipboard(t, "")
+
+	if err := cmdUndo(nil); err == nil || !strings.Contains(err.Error(), "nothing to undo") {
+		t.Errorf("err = %v, want nothing to undo", err)
+	}
+
+	snapshotClipboard([]byte("next"))
+	if _, err := os.Stat(getLastClipboardPath()); !os.IsNotExist(err) {
+		t.Errorf("empty clipboard should not be snapshotted (stat err: %v)", err)
+	}
+
+	if err := cmdUndo([]string{"extra"}); err == nil || !strings.Contains(err.Error(), "usage") {
+		t.Errorf("err = %v, want usage", err)
+	}
+}
@@ -3,20 +3,112 @@ package main
 import (
 	"bytes"
 	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 )
 
 const (
-	defaultWatchInterval = 500 * time.Millisecond
-	minWatchInterval     = 100 * time.Millisecond
+	defaultWatchInterval    = 500 * time.Millisecond
+	minWatchInterval        = 100 * time.Millisecond
+	defaultMaxWatchInterval = 4 * time.Second
 )
 
+// watchMaxIterations stops the watch loop after N polls (0 = run until
+// interrupted). Used by tests to run a single iteration.
+var watchMaxIterations = 0
+
 func cmdWatch(args []string) error {
+	const usage = "usage: pipeboard watch [peer] [--replace] [--since-last] [--debounce <duration>] [--max-rate <n>] [--to-slot-prefix <prefix>]\n       pipeboard watch --clipboard [--interval <duration>] [--max <n>]"
+	var replace, status, stop, sinceLast, clipboardMode bool
+	var debounceFlag, maxRateFlag, slotPrefix, intervalFlag, maxFlag string
+	var positional []string
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; arg {
+		case "--to-slot-prefix":
+			if i+1 >= len(args) || args[i+1] == "" {
+				return fmt.Errorf("%s requires a value\n%s", arg, usage)
+			}
+			i++
+			slotPrefix = args[i]
+		case "--replace":
+			replace = true
+		case "--status":
+			status = true
+		case "--stop":
+			stop = true
+		case "--since-last":
+			sinceLast = true
+		case "--clipboard":
+			clipboardMode = true
+		case "--interval", "--max":
+			if i+1 >= len(args) {
+				return fmt.Errorf("%s requires a value\n%s", arg, usage)
+			}
+			i++
+			if arg == "--interval" {
+				intervalFlag = args[i]
+			} else {
+				maxFlag = args[i]
+			}
+		case "--debounce", "--max-rate":
+			if i+1 >= len(args) {
+				return fmt.Errorf("%s requires a value\n%s", arg, usage)
+			}
+			i++
+			if arg == "--debounce" {
+				debounceFlag = args[i]
+			} else {
+				maxRateFlag = args[i]
+			}
+		default:
+			positional = append(positional, arg)
+		}
+	}
+	args = positional
+
+	if status {
+		return watchStatus()
+	}
+	if stop {
+		return stopWatch()
+	}
+	if (intervalFlag != "" || maxFlag != "") && !clipboardMode {
+		return fmt.Errorf("--interval and --max require --clipboard\n%s", usage)
+	}
+	if clipboardMode {
+		if len(args) > 0 || replace || sinceLast || slotPrefix != "" || debounceFlag != "" || maxRateFlag != "" {
+			return fmt.Errorf("--clipboard watches the local clipboard only and takes no peer or peer options\n%s", usage)
+		}
+		interval := time.Second
+		if intervalFlag != "" {
+			d, err := time.ParseDuration(intervalFlag)
+			if err != nil || d < minWatchInterval {
+				return fmt.Errorf("invalid --interval: %q (use a duration of at least %s)", intervalFlag, minWatchInterval)
+			}
+			interval = d
+		}
+		maxCaptures := 0
+		if maxFlag != "" {
+			n, err := strconv.Atoi(maxFlag)
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid --max: %q (use a positive number of captures)", maxFlag)
+			}
+			maxCaptures = n
+		}
+		return watchClipboard(interval, maxCaptures)
+	}
+
 	cfg, err := loadConfigForPeers()
 	if err != nil {
 		return err
@@ -26,12 +118,12 @@ func cmdWatch(args []string) error {
 	if len(args) == 0 {
 		peerName, err = cfg.getDefaultPeer()
 		if err != nil {
-			return fmt.Errorf("usage: pipeboard watch [peer]\n%w", err)
+			return fmt.Errorf("%s\n%w", usage, err)
 		}
 	} else if len(args) == 1 {
 		peerName = args[0]
 	} else {
-		return fmt.Errorf("usage: pipeboard watch [peer]")
+		return errors.New(usage)
 	}
 
 	peer, err := cfg.getPeer(peerName)
@@ -39,6 +131,38 @@ func cmdWatch(args []string) error {
 		return err
 	}
 
+	throttle, err := newWatchThrottle(cfg.Watch, debounceFlag, maxRateFlag)
+	if err != nil {
+		return err
+	}
+	backoff, err := newWatchBackoff(cfg.Watch)
+	if err != nil {
+		return err
+	}
+
+	// Relay mode archives to slots, so the backend must work before we start
+	var backend RemoteBackend
+	if slotPrefix != "" {
+		if backend, err = newRemoteBackendFromConfig(); err != nil {
+			return fmt.Errorf("--to-slot-prefix needs a sync backend: %w", err)
+		}
+	}
+
+	// Only one watcher may run at a time, otherwise both poll and sync
+	// the same changes and history gets duplicate entries
+	release, err := acquireWatchLock(replace)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	if backend != nil {
+		fmt.Printf("Archiving clipboard changes from peer %q (%s) to slots %s-<time>\n", peerName, peer.SSH, slotPrefix)
+		fmt.Println("Press Ctrl+C to stop")
+		fmt.Println()
+		return watchToSlots(peerName, peer, backend, slotPrefix, sinceLast, throttle, backoff)
+	}
+
 	fmt.Printf("Watching clipboard with peer %q (%s)\n", peerName, peer.SSH)
 	fmt.Println("Press Ctrl+C to stop")
 	fmt.Println()
@@ -50,10 +174,205 @@ func cmdWatch(args []string) error {
 	fmt.Println(sshMultiplexingTip)
 	fmt.Println()
 
-	return watchLoop(peerName, peer)
+	return watchLoop(peerName, peer, sinceLast, throttle, backoff)
+}
+
+// watchThrottle debounces clipboard changes and limits the sync rate, so
+// a burst of copies (e.g. a script copying in a loop) produces one sync
+// of the final value instead of one per intermediate value
+type watchThrottle struct {
+	debounce time.Duration // a change must be stable this long before acting
+	minGap   time.Duration // minimum time between syncs (from max_rate)
+	last     time.Time     // time of the last sync
+	pending  map[string]pendingChange
+}
+
+// pendingChange is a changed clipboard value waiting to become stable
+type pendingChange struct {
+	hash  [32]byte
+	since time.Time
+}
+
+// newWatchThrottle builds the throttle from the watch config section,
+// with --debounce/--max-rate flag values taking precedence
+func newWatchThrottle(cfg *WatchConfig, debounceFlag, maxRateFlag string) (*watchThrottle, error) {
+	debounce, maxRate := debounceFlag, maxRateFlag
+	if cfg != nil {
+		if debounce == "" {
+			debounce = cfg.Debounce
+		}
+		if maxRate == "" && cfg.MaxRate != 0 {
+			maxRate = strconv.Itoa(cfg.MaxRate)
+		}
+	}
+
+	t := &watchThrottle{pending: make(map[string]pendingChange)}
+	if debounce != "" {
+		d, err := time.ParseDuration(debounce)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("invalid watch debounce: %q (use a duration like 1s or 500ms)", debounce)
+		}
+		t.debounce = d
+	}
+	if maxRate != "" {
+		n, err := strconv.Atoi(maxRate)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid watch max rate: %q (syncs per minute)", maxRate)
+		}
+		if n > 0 {
+			t.minGap = time.Minute / time.Duration(n)
+		}
+	}
+	return t, nil
+}
+
+// ready reports whether a change to hash on side ("local" or "remote")
+// should be synced now. A new value restarts the debounce window.
+func (t *watchThrottle) ready(side string, hash [32]byte, now time.Time) bool {
+	p, ok := t.pending[side]
+	if !ok || p.hash != hash {
+		p = pendingChange{hash: hash, since: now}
+		t.pending[side] = p
+	}
+	if now.Sub(p.since) < t.debounce {
+		return false
+	}
+	return t.last.IsZero() || now.Sub(t.last) >= t.minGap
 }
 
-func watchLoop(peerName string, peer PeerConfig) error {
+// synced records that a pending change on side was acted on
+func (t *watchThrottle) synced(side string, now time.Time) {
+	delete(t.pending, side)
+	t.last = now
+}
+
+// clear drops a pending change on side, e.g. when the clipboard reverts
+func (t *watchThrottle) clear(side string) {
+	delete(t.pending, side)
+}
+
+// watchBackoff adapts the poll interval to activity: polling stays at
+// min while the clipboards change and doubles up to max while idle, so
+// a long-running watch costs little CPU and few SSH round-trips
+type watchBackoff struct {
+	min, max time.Duration
+	current  time.Duration
+}
+
+// newWatchBackoff builds the backoff from the watch config section
+func newWatchBackoff(cfg *WatchConfig) (*watchBackoff, error) {
+	b := &watchBackoff{min: defaultWatchInterval, max: defaultMaxWatchInterval}
+	if cfg != nil {
+		if cfg.MinInterval != "" {
+			d, err := time.ParseDuration(cfg.MinInterval)
+			if err != nil || d < minWatchInterval {
+				return nil, fmt.Errorf("invalid watch min_interval: %q (use a duration of at least %s)", cfg.MinInterval, minWatchInterval)
+			}
+			b.min = d
+		}
+		if cfg.MaxInterval != "" {
+			d, err := time.ParseDuration(cfg.MaxInterval)
+			if err != nil || d <= 0 {
+				return nil, fmt.Errorf("invalid watch max_interval: %q (use a duration like 4s)", cfg.MaxInterval)
+			}
+			b.max = d
+		}
+	}
+	if b.max < b.min {
+		if cfg != nil && cfg.MaxInterval != "" {
+			return nil, fmt.Errorf("watch max_interval (%s) is less than min_interval (%s)", b.max, b.min)
+		}
+		// Only min was raised; don't back off below it
+		b.max = b.min
+	}
+	b.current = b.min
+	return b, nil
+}
+
+// next returns the delay before the next poll. Activity resets it to
+// min; each idle poll doubles it, capped at max.
+func (b *watchBackoff) next(active bool) time.Duration {
+	if active {
+		b.current = b.min
+	} else if b.current < b.max {
+		b.current = min(b.current*2, b.max)
+	}
+	return b.current
+}
+
+// watchState is the last-seen clipboard state, persisted so a restarted
+// watch can pick up where the previous one left off
+type watchState struct {
+	Peer       string    `json:"peer"`
+	LocalHash  string    `json:"local_hash"`
+	RemoteHash string    `json:"remote_hash"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// getWatchStatePath returns the path of the persisted watch state
+func getWatchStatePath() string {
+	pidPath := getWatchPidPath()
+	if pidPath == "" {
+		return ""
+	}
+	return filepath.Join(filepath.Dir(pidPath), "watch.state")
+}
+
+// loadWatchState returns the saved hashes for peerName, or ok=false if none
+func loadWatchState(peerName string) (local, remote [32]byte, ok bool) {
+	path := getWatchStatePath()
+	if path == "" {
+		return local, remote, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return local, remote, false
+	}
+	var state watchState
+	if err := json.Unmarshal(data, &state); err != nil || state.Peer != peerName {
+		return local, remote, false
+	}
+	l, err1 := hex.DecodeString(state.LocalHash)
+	r, err2 := hex.DecodeString(state.RemoteHash)
+	if err1 != nil || err2 != nil || len(l) != 32 || len(r) != 32 {
+		return local, remote, false
+	}
+	copy(local[:], l)
+	copy(remote[:], r)
+	return local, remote, true
+}
+
+// saveWatchState persists the last-seen hashes (best-effort)
+func saveWatchState(peerName string, local, remote [32]byte) {
+	path := getWatchStatePath()
+	if path == "" {
+		return
+	}
+	data, err := json.Marshal(watchState{
+		Peer:       peerName,
+		LocalHash:  hex.EncodeToString(local[:]),
+		RemoteHash: hex.EncodeToString(remote[:]),
+		UpdatedAt:  time.Now(),
+	})
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		debugLog("failed to save watch state: %v", err)
+	}
+}
+
+// saveWatchRemoteHash updates the saved remote hash, keeping the local hash
+// saved for the peer
+func saveWatchRemoteHash(peerName string, remote [32]byte) {
+	local, _, _ := loadWatchState(peerName)
+	saveWatchState(peerName, local, remote)
+}
+
+func watchLoop(peerName string, peer PeerConfig, sinceLast bool, throttle *watchThrottle, backoff *watchBackoff) error {
 	// Set up signal handling for graceful shutdown
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
@@ -62,80 +381,263 @@ func watchLoop(peerName string, peer PeerConfig) error {
 	var lastLocalHash [32]byte
 	var lastRemoteHash [32]byte
 
-	// Initialize with current states
-	localData, err := readClipboard()
-	if err == nil {
-		lastLocalHash = sha256.Sum256(localData)
+	// With --since-last, start from the state saved by the previous run so
+	// changes made while watch was stopped are synced and unchanged content
+	// is not recorded again. Otherwise, or without saved state, start from
+	// the current states: treating both sides as new would push the local
+	// clipboard over the peer's.
+	restored := false
+	if sinceLast {
+		lastLocalHash, lastRemoteHash, restored = loadWatchState(peerName)
+		debugLog("watch state restored for %s: %v", peerName, restored)
 	}
+	if !restored {
+		localData, err := readClipboard()
+		if err == nil {
+			lastLocalHash = sha256.Sum256(localData)
+		}
 
-	remoteData, err := readRemoteClipboard(peer)
-	if err == nil {
-		lastRemoteHash = sha256.Sum256(remoteData)
+		remoteData, err := readRemoteClipboard(peer)
+		if err == nil {
+			lastRemoteHash = sha256.Sum256(remoteData)
+		}
+		saveWatchState(peerName, lastLocalHash, lastRemoteHash)
 	}
 
-	ticker := time.NewTicker(defaultWatchInterval)
-	defer ticker.Stop()
+	// Persist hashes whenever they change
+	savedLocal, savedRemote := lastLocalHash, lastRemoteHash
+	persist := func() {
+		if lastLocalHash != savedLocal || lastRemoteHash != savedRemote {
+			saveWatchState(peerName, lastLocalHash, lastRemoteHash)
+			savedLocal, savedRemote = lastLocalHash, lastRemoteHash
+		}
+	}
+
+	// poll checks both clipboards once and syncs any change. It reports
+	// whether anything changed or is pending, which keeps polling fast.
+	poll := func() bool {
+		// Check local clipboard
+		localData, err := readClipboard()
+		if err != nil {
+			return false // Skip this iteration on error
+		}
+		localHash := sha256.Sum256(localData)
+
+		// Check if local clipboard changed
+		if localHash != lastLocalHash && localHash != lastRemoteHash {
+			// Wait for the change to settle (and for the rate limit)
+			if !throttle.ready("local", localHash, time.Now()) {
+				return true
+			}
+			// Local changed, send to peer
+			if err := sendToRemote(peer, localData); err != nil {
+				fmt.Fprintf(os.Stderr, "watch: failed to send: %v\n", err)
+			} else {
+				fmt.Printf("→ sent %s to %s\n", formatSize(int64(len(localData))), peerName)
+				lastLocalHash = localHash
+				lastRemoteHash = localHash // Prevent echo
+				throttle.synced("local", time.Now())
+				recordHistory("watch:send", peerName, int64(len(localData)))
+				persist()
+			}
+			return true
+		}
+		throttle.clear("local")
+
+		// Check remote clipboard
+		remoteData, err := readRemoteClipboard(peer)
+		if err != nil {
+			return false // Skip this iteration on error
+		}
+		remoteHash := sha256.Sum256(remoteData)
 
+		// Check if remote clipboard changed
+		if remoteHash != lastRemoteHash && remoteHash != lastLocalHash {
+			// Keep the change pending (don't fold it into the hashes
+			// below) until it settles
+			if !throttle.ready("remote", remoteHash, time.Now()) {
+				return true
+			}
+			// Remote changed, copy to local
+			if err := writeClipboard(remoteData); err != nil {
+				fmt.Fprintf(os.Stderr, "watch: failed to receive: %v\n", err)
+			} else {
+				fmt.Printf("← received %s from %s\n", formatSize(int64(len(remoteData))), peerName)
+				lastRemoteHash = remoteHash
+				lastLocalHash = remoteHash // Prevent echo
+				throttle.synced("remote", time.Now())
+				recordHistory("watch:recv", peerName, int64(len(remoteData)))
+				persist()
+				return true // Skip hash update below to preserve echo prevention
+			}
+		}
+		throttle.clear("remote")
+
+		// Update hashes only when no sync action was taken
+		// This preserves echo prevention set in the sync blocks above
+		changed := localHash != lastLocalHash || remoteHash != lastRemoteHash
+		lastLocalHash = localHash
+		lastRemoteHash = remoteHash
+		persist()
+		return changed
+	}
+
+	return runWatchPolls(sigChan, poll, backoff)
+}
+
+// runWatchPolls calls poll on the backoff schedule until interrupted
+// (or watchMaxIterations is reached)
+func runWatchPolls(sigChan <-chan os.Signal, poll func() bool, backoff *watchBackoff) error {
+	timer := time.NewTimer(backoff.min)
+	defer timer.Stop()
+
+	iterations := 0
 	for {
+		if watchMaxIterations > 0 && iterations >= watchMaxIterations {
+			return nil
+		}
 		select {
 		case <-sigChan:
 			fmt.Println("\nStopping watch...")
 			return nil
-		case <-ticker.C:
-			// Check local clipboard
-			localData, err := readClipboard()
-			if err != nil {
-				continue // Skip this iteration on error
-			}
-			localHash := sha256.Sum256(localData)
-
-			// Check if local clipboard changed
-			if localHash != lastLocalHash && localHash != lastRemoteHash {
-				// Local changed, send to peer
-				if err := sendToRemote(peer, localData); err != nil {
-					fmt.Fprintf(os.Stderr, "watch: failed to send: %v\n", err)
-				} else {
-					fmt.Printf("→ sent %s to %s\n", formatSize(int64(len(localData))), peerName)
-					lastLocalHash = localHash
-					lastRemoteHash = localHash // Prevent echo
-					recordHistory("watch:send", peerName, int64(len(localData)))
-				}
-				continue
-			}
+		case <-timer.C:
+			iterations++
+			timer.Reset(backoff.next(poll()))
+		}
+	}
+}
 
-			// Check remote clipboard
-			remoteData, err := readRemoteClipboard(peer)
-			if err != nil {
-				continue // Skip this iteration on error
-			}
-			remoteHash := sha256.Sum256(remoteData)
-
-			// Check if remote clipboard changed
-			if remoteHash != lastRemoteHash && remoteHash != lastLocalHash {
-				// Remote changed, copy to local
-				if err := writeClipboard(remoteData); err != nil {
-					fmt.Fprintf(os.Stderr, "watch: failed to receive: %v\n", err)
-				} else {
-					fmt.Printf("← received %s from %s\n", formatSize(int64(len(remoteData))), peerName)
-					lastRemoteHash = remoteHash
-					lastLocalHash = remoteHash // Prevent echo
-					recordHistory("watch:recv", peerName, int64(len(remoteData)))
-					continue // Skip hash update below to preserve echo prevention
+// watchToSlots is the one-way relay mode of watch: each change to the
+// peer's clipboard is pushed to a new slot named <prefix>-<UTC time>.
+// The local clipboard is never read or written, so this works on a
+// headless box.
+func watchToSlots(peerName string, peer PeerConfig, backend RemoteBackend, prefix string, sinceLast bool, throttle *watchThrottle, backoff *watchBackoff) error {
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+
+	// Only the remote hash is tracked; state is shared with the --since-last
+	// of a bidirectional watch, whose local hash is left as it was
+	var lastHash [32]byte
+	if sinceLast {
+		_, lastHash, _ = loadWatchState(peerName)
+	} else {
+		if data, err := readRemoteClipboard(peer); err == nil {
+			lastHash = sha256.Sum256(data)
+		}
+		saveWatchRemoteHash(peerName, lastHash)
+	}
+
+	meta := map[string]string{"hostname": peerName}
+	var lastBase string
+	var taken map[string]bool // slot names in use under lastBase
+
+	poll := func() bool {
+		data, err := readRemoteClipboard(peer)
+		if err != nil {
+			return false
+		}
+		hash := sha256.Sum256(data)
+		if hash == lastHash || len(data) == 0 {
+			throttle.clear("remote")
+			return false
+		}
+		if !throttle.ready("remote", hash, time.Now()) {
+			return true
+		}
+
+		// A blocked change is skipped, not retried on every poll
+		if err := checkSecretPolicy(data, "push"); err != nil {
+			fmt.Fprintf(os.Stderr, "watch: not archived: %v\n", err)
+			lastHash = hash
+			saveWatchRemoteHash(peerName, lastHash)
+			return true
+		}
+
+		// Changes within the same second get a counter, as auto-named push
+		// does. The backend is checked once per second used, so a restart
+		// doesn't overwrite what the previous run archived.
+		base := prefix + "-" + time.Now().UTC().Format("20060102-150405")
+		if base != lastBase {
+			lastBase, taken = base, map[string]bool{}
+			if existing, err := listSlotsMatching(backend, base+"*"); err == nil {
+				for _, s := range existing {
+					taken[s.Name] = true
 				}
 			}
+		}
+		name := base
+		for n := 2; taken[name]; n++ {
+			name = fmt.Sprintf("%s-%d", base, n)
+		}
+		taken[name] = true
+
+		err = backend.Push(name, data, meta)
+		recordAudit(AuditRecord{Op: "push", Slot: name, Peer: peerName, Size: int64(len(data))}, err)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "watch: failed to push to slot %q: %v\n", name, err)
+			return true
+		}
+		fmt.Printf("← archived %s from %s to slot %q\n", formatSize(int64(len(data))), peerName, name)
+		lastHash = hash
+		throttle.synced("remote", time.Now())
+		recordHistory("watch:push", name, int64(len(data)))
+		saveWatchRemoteHash(peerName, lastHash)
+		return true
+	}
+
+	return runWatchPolls(sigChan, poll, backoff)
+}
+
+// watchClipboard is the local mode of watch: the clipboard is polled every
+// interval and each new value is added to clipboard history, which dedupes
+// it against the last entry as copy does. It stops on SIGINT or after
+// maxCaptures recorded changes (0 = no limit).
+func watchClipboard(interval time.Duration, maxCaptures int) error {
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
+
+	// The content at startup isn't a change
+	var lastHash [32]byte
+	if data, err := readClipboard(); err == nil {
+		lastHash = sha256.Sum256(data)
+	}
 
-			// Update hashes only when no sync action was taken
-			// This preserves echo prevention set in the sync blocks above
-			lastLocalHash = localHash
-			lastRemoteHash = remoteHash
+	fmt.Printf("Recording clipboard changes to history every %s\n", interval)
+	fmt.Println("Press Ctrl+C to stop")
+	fmt.Println()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for captures := 0; maxCaptures == 0 || captures < maxCaptures; {
+		select {
+		case <-sigChan:
+			fmt.Println("\nStopping watch...")
+			return nil
+		case <-ticker.C:
+		}
+		data, err := readClipboard()
+		if err != nil {
+			debugLog("watch: reading clipboard: %v", err)
+			continue
 		}
+		hash := sha256.Sum256(data)
+		if hash == lastHash || len(data) == 0 {
+			continue
+		}
+		lastHash = hash
+		recordClipboardHistory(data)
+		captures++
+		fmt.Printf("+ recorded %s\n", formatSize(int64(len(data))))
 	}
+	return nil
 }
 
 // readRemoteClipboard reads clipboard contents from a peer via SSH
 func readRemoteClipboard(peer PeerConfig) ([]byte, error) {
 	var out bytes.Buffer
-	cmd := exec.Command("ssh", peer.SSH, peer.RemoteCmd, "paste")
+	argv := peerCommand(peer, "paste")
+	cmd := exec.Command(argv[0], argv[1:]...)
 	cmd.Stdin = nil
 	cmd.Stdout = &out
 	cmd.Stderr = nil // Suppress errors for polling
@@ -148,10 +650,183 @@ func readRemoteClipboard(peer PeerConfig) ([]byte, error) {
 
 // sendToRemote sends data to a peer's clipboard via SSH
 func sendToRemote(peer PeerConfig, data []byte) error {
-	cmd := exec.Command("ssh", peer.SSH, peer.RemoteCmd, "copy")
+	argv := peerCommand(peer, "copy")
+	cmd := exec.Command(argv[0], argv[1:]...)
 	cmd.Stdin = bytes.NewReader(data)
 	cmd.Stdout = nil
 	cmd.Stderr = nil
 
 	return cmd.Run()
 }
+
+// getWatchPidPath returns the pidfile used to keep a single watcher running
+func getWatchPidPath() string {
+	configDir := os.Getenv("XDG_CONFIG_HOME")
+	if configDir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		configDir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(configDir, "pipeboard", "watch.pid")
+}
+
+// readWatchPid returns the pid recorded in the watch pidfile
+func readWatchPid() (int, error) {
+	pid, _, err := readWatchPidfile()
+	return pid, err
+}
+
+// readWatchPidfile returns the pid in the watch pidfile and the start time
+// recorded with it (empty for pidfiles written without one)
+func readWatchPidfile() (int, string, error) {
+	data, err := os.ReadFile(getWatchPidPath())
+	if err != nil {
+		return 0, "", err
+	}
+	pidLine, start, _ := strings.Cut(string(data), "\n")
+	pid, err := strconv.Atoi(strings.TrimSpace(pidLine))
+	return pid, strings.TrimSpace(start), err
+}
+
+// processStartTime identifies when a process started, so a pidfile left by
+// a crashed watch can't point at an unrelated process that was given the
+// same pid. ok is false where it can't be determined.
+func processStartTime(pid int) (string, bool) {
+	if data, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid)); err == nil {
+		// starttime is field 22; count from the end of the command name,
+		// which is in parentheses and may hold spaces
+		if i := bytes.LastIndexByte(data, ')'); i >= 0 {
+			if fields := strings.Fields(string(data[i+1:])); len(fields) > 19 {
+				return fields[19], true
+			}
+		}
+	}
+	if runtime.GOOS == "windows" {
+		return "", false
+	}
+	out, err := exec.Command("ps", "-o", "lstart=", "-p", strconv.Itoa(pid)).Output()
+	if start := strings.TrimSpace(string(out)); err == nil && start != "" {
+		return start, true
+	}
+	return "", false
+}
+
+// processAlive reports whether a process with the given pid exists
+func processAlive(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	// On Windows FindProcess already fails for missing processes
+	if runtime.GOOS == "windows" {
+		return true
+	}
+	return p.Signal(syscall.Signal(0)) == nil
+}
+
+// runningWatchPid returns the pid of a live watcher, or 0 if none is running.
+// A pid recorded with its start time only counts while the process with
+// that pid still has that start time; otherwise the pid has been reused.
+func runningWatchPid() int {
+	pid, start, err := readWatchPidfile()
+	if err != nil || pid <= 0 || !processAlive(pid) {
+		return 0
+	}
+	if start != "" {
+		if current, ok := processStartTime(pid); !ok || current != start {
+			debugLog("watch pid %d now belongs to another process", pid)
+			return 0
+		}
+	}
+	return pid
+}
+
+// acquireWatchLock writes the watch pidfile, refusing if another watcher is
+// alive unless replace is set. Stale pidfiles from crashed watchers are
+// taken over. The returned function releases the lock.
+func acquireWatchLock(replace bool) (func(), error) {
+	path := getWatchPidPath()
+	if path == "" {
+		return nil, errors.New("could not determine watch pidfile path")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("creating config directory: %w", err)
+	}
+
+	if pid := runningWatchPid(); pid != 0 && pid != os.Getpid() {
+		if !replace {
+			return nil, fmt.Errorf("watch already running (pid %d); use --replace to take over or --stop to stop it", pid)
+		}
+		if err := stopWatch(); err != nil {
+			return nil, err
+		}
+	}
+	// Any remaining pidfile is stale
+	_ = os.Remove(path)
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		if os.IsExist(err) {
+			return nil, errors.New("another watch started concurrently; try again")
+		}
+		return nil, fmt.Errorf("creating watch pidfile: %w", err)
+	}
+	pid := os.Getpid()
+	start, _ := processStartTime(pid)
+	_, err = fmt.Fprintf(f, "%d\n%s\n", pid, start)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(path)
+		return nil, fmt.Errorf("writing watch pidfile: %w", err)
+	}
+	debugLog("acquired watch lock: %s (pid %d)", path, pid)
+
+	return func() {
+		// Only remove the pidfile if it is still ours (not a --replace successor)
+		if current, err := readWatchPid(); err == nil && current == pid {
+			_ = os.Remove(path)
+		}
+	}, nil
+}
+
+// watchStatus reports whether a watcher is running
+func watchStatus() error {
+	if pid := runningWatchPid(); pid != 0 {
+		fmt.Printf("watch running (pid %d)\n", pid)
+		return nil
+	}
+	fmt.Println("watch not running")
+	return nil
+}
+
+// stopWatch signals the running watcher to stop and clears its pidfile.
+// A stale pidfile is only removed: runningWatchPid checks the process is
+// still the watcher that wrote it before anything is signalled.
+func stopWatch() error {
+	pid := runningWatchPid()
+	if pid == 0 {
+		_ = os.Remove(getWatchPidPath())
+		printInfo("watch not running\n")
+		return nil
+	}
+
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return fmt.Errorf("finding watch process %d: %w", pid, err)
+	}
+	if runtime.GOOS == "windows" {
+		err = p.Kill()
+	} else {
+		err = p.Signal(syscall.SIGTERM)
+	}
+	if err != nil {
+		return fmt.Errorf("stopping watch (pid %d): %w", pid, err)
+	}
+	_ = os.Remove(getWatchPidPath())
+	printInfo("stopped watch (pid %d)\n", pid)
+	return nil
+}
@@ -1,9 +1,18 @@
 package main
 
 import (
+	"crypto/sha256"
+	"encoding/json"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
 	"strings"
+	"sync"
+	"syscall"
 	"testing"
+	"time"
 )
 
 // Test cmdWatch with too many arguments
@@ -268,3 +277,687 @@ func TestSendToRemoteLargeData(t *testing.T) {
 		t.Error("sendToRemote should error with invalid SSH host")
 	}
 }
+
+// startSleeper starts a long-lived child process to stand in for a watcher
+func startSleeper(t *testing.T) *exec.Cmd {
+	t.Helper()
+	cmd := exec.Command("sleep", "30")
+	if err := cmd.Start(); err != nil {
+		t.Skipf("cannot start sleep: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	})
+	return cmd
+}
+
+// writeWatchPid writes a pidfile as a running watcher would
+func writeWatchPid(t *testing.T, pid int) {
+	t.Helper()
+	start, _ := processStartTime(pid)
+	writeWatchPidfile(t, strconv.Itoa(pid)+"\n"+start+"\n")
+}
+
+func writeWatchPidfile(t *testing.T, content string) {
+	t.Helper()
+	path := getWatchPidPath()
+	_ = os.MkdirAll(filepath.Dir(path), 0700)
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("writing pidfile: %v", err)
+	}
+}
+
+// Test a second watch is refused while one is running
+func TestCmdWatchAlreadyRunning(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sleep")
+	}
+	cleanup := setupPeerTestConfig(t, `version: 1
+peers:
+  work:
+    ssh: user@host
+`)
+	defer cleanup()
+
+	sleeper := startSleeper(t)
+	writeWatchPid(t, sleeper.Process.Pid)
+
+	err := cmdWatch([]string{"work"})
+	if err == nil || !strings.Contains(err.Error(), "already running") {
+		t.Fatalf("expected already running error, got %v", err)
+	}
+	if !strings.Contains(err.Error(), strconv.Itoa(sleeper.Process.Pid)) {
+		t.Errorf("error should include pid: %v", err)
+	}
+
+	out := captureOutput(func() {
+		err = cmdWatch([]string{"--status"})
+	})
+	if err != nil {
+		t.Fatalf("--status failed: %v", err)
+	}
+	if !strings.Contains(out, "running (pid "+strconv.Itoa(sleeper.Process.Pid)+")") {
+		t.Errorf("unexpected status output: %q", out)
+	}
+}
+
+// Test --stop terminates the running watch and releases the lock
+func TestCmdWatchStop(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sleep")
+	}
+	cleanup := setupPeerTestConfig(t, "")
+	defer cleanup()
+
+	sleeper := startSleeper(t)
+	writeWatchPid(t, sleeper.Process.Pid)
+
+	if err := cmdWatch([]string{"--stop"}); err != nil {
+		t.Fatalf("--stop failed: %v", err)
+	}
+	if err := sleeper.Wait(); err == nil {
+		t.Error("expected sleeper to be terminated by signal")
+	}
+	if _, err := os.Stat(getWatchPidPath()); !os.IsNotExist(err) {
+		t.Error("pidfile should be removed after --stop")
+	}
+
+	release, err := acquireWatchLock(false)
+	if err != nil {
+		t.Fatalf("lock should be free after --stop: %v", err)
+	}
+	release()
+}
+
+// Test a stale pidfile from a crashed watcher is taken over
+func TestAcquireWatchLockStale(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sleep")
+	}
+	cleanup := setupPeerTestConfig(t, "")
+	defer cleanup()
+
+	// Start and reap a process so its pid is known to be dead
+	sleeper := exec.Command("true")
+	if err := sleeper.Run(); err != nil {
+		t.Skipf("cannot run true: %v", err)
+	}
+	writeWatchPid(t, sleeper.Process.Pid)
+
+	release, err := acquireWatchLock(false)
+	if err != nil {
+		t.Fatalf("stale pidfile should be reclaimed: %v", err)
+	}
+	pid, err := readWatchPid()
+	if err != nil || pid != os.Getpid() {
+		t.Errorf("pidfile should hold our pid, got %d (%v)", pid, err)
+	}
+	release()
+	if _, err := os.Stat(getWatchPidPath()); !os.IsNotExist(err) {
+		t.Error("release should remove pidfile")
+	}
+}
+
+// Test a pidfile whose pid was reused by an unrelated process is treated as
+// stale: the process is never signalled
+func TestCmdWatchStopReusedPid(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sleep")
+	}
+	cleanup := setupPeerTestConfig(t, "")
+	defer cleanup()
+	quietMode = true
+	defer func() { quietMode = false }()
+
+	sleeper := startSleeper(t)
+	if _, ok := processStartTime(sleeper.Process.Pid); !ok {
+		t.Skip("process start times not available")
+	}
+	// Written by a watch that has since died and whose pid the sleeper got
+	writeWatchPidfile(t, strconv.Itoa(sleeper.Process.Pid)+"\nearlier\n")
+
+	if pid := runningWatchPid(); pid != 0 {
+		t.Errorf("reused pid %d reported as the running watch", pid)
+	}
+	if err := cmdWatch([]string{"--stop"}); err != nil {
+		t.Fatalf("--stop failed: %v", err)
+	}
+	release, err := acquireWatchLock(true)
+	if err != nil {
+		t.Fatalf("stale pidfile should be reclaimed: %v", err)
+	}
+	release()
+	if err := sleeper.Process.Signal(syscall.Signal(0)); err != nil {
+		t.Errorf("unrelated process was signalled: %v", err)
+	}
+}
+
+// Test --replace stops the running watch and takes over
+func TestAcquireWatchLockReplace(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sleep")
+	}
+	cleanup := setupPeerTestConfig(t, "")
+	defer cleanup()
+	quietMode = true
+	defer func() { quietMode = false }()
+
+	sleeper := startSleeper(t)
+	writeWatchPid(t, sleeper.Process.Pid)
+
+	release, err := acquireWatchLock(true)
+	if err != nil {
+		t.Fatalf("--replace should take over: %v", err)
+	}
+	defer release()
+	if err := sleeper.Wait(); err == nil {
+		t.Error("expected previous watch to be terminated")
+	}
+	if pid, _ := readWatchPid(); pid != os.Getpid() {
+		t.Errorf("pidfile should hold our pid, got %d", pid)
+	}
+}
+
+// Test the watch loop holds the lock while running and releases it on exit
+func TestCmdWatchReleasesLock(t *testing.T) {
+	cleanup := setupPeerTestConfig(t, `version: 1
+peers:
+  work:
+    ssh: user@host
+`)
+	defer cleanup()
+
+	mockDir := createMockSSH(t, "", true)
+	origPath := os.Getenv("PATH")
+	_ = os.Setenv("PATH", mockDir+string(os.PathListSeparator)+origPath)
+	defer func() { _ = os.Setenv("PATH", origPath) }()
+
+	watchMaxIterations = 1
+	defer func() { watchMaxIterations = 0 }()
+
+	var err error
+	captureOutput(func() {
+		err = cmdWatch([]string{"work"})
+	})
+	if err != nil {
+		t.Fatalf("cmdWatch failed: %v", err)
+	}
+	if _, err := os.Stat(getWatchPidPath()); !os.IsNotExist(err) {
+		t.Error("pidfile should be removed when watch exits")
+	}
+}
+
+// Test --status when nothing is running
+func TestCmdWatchStatusNotRunning(t *testing.T) {
+	cleanup := setupPeerTestConfig(t, "")
+	defer cleanup()
+
+	out := captureOutput(func() {
+		if err := cmdWatch([]string{"--status"}); err != nil {
+			t.Errorf("--status failed: %v", err)
+		}
+	})
+	if !strings.Contains(out, "not running") {
+		t.Errorf("expected not running, got %q", out)
+	}
+}
+
+// useFileClipboard replaces the detected backend with one backed by a file
+func useFileClipboard(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "clipboard")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("writing clipboard file: %v", err)
+	}
+	cachedBackendOnce = sync.Once{}
+	cachedBackendOnce.Do(func() {
+		cachedBackend = &Backend{
+			Kind:     BackendUnknown,
+			CopyCmd:  []string{"sh", "-c", "cat > " + path},
+			PasteCmd: []string{"cat", path},
+		}
+		cachedBackendErr = nil
+	})
+	t.Cleanup(func() { cachedBackendOnce = sync.Once{} })
+	return path
+}
+
+// countWatchHistory counts watch:send/watch:recv entries in operation history
+func countWatchHistory(t *testing.T) int {
+	t.Helper()
+	data, err := os.ReadFile(getHistoryPath())
+	if os.IsNotExist(err) {
+		return 0
+	}
+	if err != nil {
+		t.Fatalf("reading history: %v", err)
+	}
+	var entries []HistoryEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		t.Fatalf("parsing history: %v", err)
+	}
+	n := 0
+	for _, e := range entries {
+		if strings.HasPrefix(e.Command, "watch:") {
+			n++
+		}
+	}
+	return n
+}
+
+// runWatchOnce runs a single watch iteration against the "work" peer
+func runWatchOnce(t *testing.T, args ...string) {
+	t.Helper()
+	watchMaxIterations = 1
+	defer func() { watchMaxIterations = 0 }()
+	var err error
+	captureOutput(func() {
+		err = cmdWatch(append([]string{"work"}, args...))
+	})
+	if err != nil {
+		t.Fatalf("cmdWatch failed: %v", err)
+	}
+}
+
+// Test restarting with --since-last does not record unchanged content again
+func TestCmdWatchSinceLastSkipsUnchanged(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	cleanup := setupPeerTestConfig(t, `version: 1
+peers:
+  work:
+    ssh: user@host
+`)
+	defer cleanup()
+
+	mockDir := createMockSSH(t, "shared", false)
+	origPath := os.Getenv("PATH")
+	_ = os.Setenv("PATH", mockDir+string(os.PathListSeparator)+origPath)
+	defer func() { _ = os.Setenv("PATH", origPath) }()
+
+	clip := useFileClipboard(t, "shared\n")
+
+	runWatchOnce(t, "--since-last")
+	if n := countWatchHistory(t); n != 0 {
+		t.Fatalf("first run without saved state should start from the current clipboards, got %d entries", n)
+	}
+
+	runWatchOnce(t, "--since-last")
+	if n := countWatchHistory(t); n != 0 {
+		t.Errorf("restart with unchanged clipboard should not record, got %d entries", n)
+	}
+
+	// A change made while watch was stopped is picked up on restart
+	if err := os.WriteFile(clip, []byte("changed offline\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	runWatchOnce(t, "--since-last")
+	if n := countWatchHistory(t); n != 1 {
+		t.Errorf("offline change should be synced on restart, got %d entries", n)
+	}
+}
+
+// Test --since-last without saved state starts from the current clipboards
+// rather than pushing the local one over the peer's
+func TestCmdWatchSinceLastWithoutState(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	cleanup := setupPeerTestConfig(t, `version: 1
+peers:
+  work:
+    ssh: user@host
+`)
+	defer cleanup()
+
+	// The mock ssh logs every remote command it is asked to run
+	mockDir := t.TempDir()
+	logPath := filepath.Join(mockDir, "log")
+	script := "#!/bin/sh\necho \"$*\" >> " + logPath + "\ncat > /dev/null\necho 'peer content'\n"
+	if err := os.WriteFile(filepath.Join(mockDir, "ssh"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", mockDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	clip := useFileClipboard(t, "local content\n")
+
+	runWatchOnce(t, "--since-last")
+	if n := countWatchHistory(t); n != 0 {
+		t.Errorf("expected no syncs, got %d history entries", n)
+	}
+	log, _ := os.ReadFile(logPath)
+	if strings.Contains(string(log), " copy") {
+		t.Errorf("local clipboard was sent to the peer: %s", log)
+	}
+	if data, _ := os.ReadFile(clip); string(data) != "local content\n" {
+		t.Errorf("local clipboard overwritten: %q", data)
+	}
+	local, remote, ok := loadWatchState("work")
+	if !ok || local != sha256.Sum256([]byte("local content\n")) || remote != sha256.Sum256([]byte("peer content\n")) {
+		t.Error("state should be initialized from the current clipboards")
+	}
+}
+
+// Test a plain watch saves state that --since-last resumes from
+func TestCmdWatchSavesState(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	cleanup := setupPeerTestConfig(t, `version: 1
+peers:
+  work:
+    ssh: user@host
+`)
+	defer cleanup()
+
+	mockDir := createMockSSH(t, "shared", false)
+	origPath := os.Getenv("PATH")
+	_ = os.Setenv("PATH", mockDir+string(os.PathListSeparator)+origPath)
+	defer func() { _ = os.Setenv("PATH", origPath) }()
+
+	useFileClipboard(t, "shared\n")
+
+	runWatchOnce(t)
+	local, remote, ok := loadWatchState("work")
+	if !ok {
+		t.Fatal("watch should save state")
+	}
+	want := sha256.Sum256([]byte("shared\n"))
+	if local != want || remote != want {
+		t.Error("saved hashes should match current clipboards")
+	}
+	if _, _, ok := loadWatchState("other"); ok {
+		t.Error("state for a different peer should be ignored")
+	}
+
+	runWatchOnce(t, "--since-last")
+	if n := countWatchHistory(t); n != 0 {
+		t.Errorf("expected no history entries, got %d", n)
+	}
+}
+
+// Test rapid changes within the debounce window only act on the final
+// stable value
+func TestWatchThrottleDebounce(t *testing.T) {
+	throttle, err := newWatchThrottle(&WatchConfig{Debounce: "1s"}, "", "")
+	if err != nil {
+		t.Fatalf("newWatchThrottle: %v", err)
+	}
+
+	start := time.Now()
+	var synced []string
+	poll := func(value string, at time.Duration) {
+		now := start.Add(at)
+		if throttle.ready("local", sha256.Sum256([]byte(value)), now) {
+			synced = append(synced, value)
+			throttle.synced("local", now)
+		}
+	}
+
+	// A script copying in a loop: every poll sees a new value
+	for i, v := range []string{"a", "b", "c", "d"} {
+		poll(v, time.Duration(i)*200*time.Millisecond)
+	}
+	// The final value stays put
+	poll("d", 1200*time.Millisecond)
+	poll("d", 1800*time.Millisecond)
+
+	if len(synced) != 1 || synced[0] != "d" {
+		t.Errorf("expected only the final stable value to sync, got %v", synced)
+	}
+}
+
+// Test max_rate spaces out syncs
+func TestWatchThrottleMaxRate(t *testing.T) {
+	throttle, err := newWatchThrottle(nil, "", "6") // one sync per 10s
+	if err != nil {
+		t.Fatalf("newWatchThrottle: %v", err)
+	}
+
+	start := time.Now()
+	if !throttle.ready("local", sha256.Sum256([]byte("a")), start) {
+		t.Fatal("first change should sync immediately without debounce")
+	}
+	throttle.synced("local", start)
+
+	b := sha256.Sum256([]byte("b"))
+	if throttle.ready("remote", b, start.Add(5*time.Second)) {
+		t.Error("change within the rate limit should wait")
+	}
+	if !throttle.ready("remote", b, start.Add(10*time.Second)) {
+		t.Error("change should sync once the rate limit allows")
+	}
+}
+
+// Test flags override the config and invalid values are rejected
+func TestNewWatchThrottle(t *testing.T) {
+	throttle, err := newWatchThrottle(&WatchConfig{Debounce: "5s", MaxRate: 30}, "250ms", "")
+	if err != nil {
+		t.Fatalf("newWatchThrottle: %v", err)
+	}
+	if throttle.debounce != 250*time.Millisecond {
+		t.Errorf("flag should override config debounce, got %v", throttle.debounce)
+	}
+	if throttle.minGap != 2*time.Second {
+		t.Errorf("max_rate 30 should give a 2s gap, got %v", throttle.minGap)
+	}
+
+	for _, tc := range []struct{ debounce, rate string }{
+		{"soon", ""},
+		{"-1s", ""},
+		{"", "fast"},
+		{"", "-2"},
+	} {
+		if _, err := newWatchThrottle(nil, tc.debounce, tc.rate); err == nil {
+			t.Errorf("expected error for debounce=%q rate=%q", tc.debounce, tc.rate)
+		}
+	}
+}
+
+// Test the poll interval grows while idle and resets on change
+func TestWatchBackoff(t *testing.T) {
+	backoff, err := newWatchBackoff(&WatchConfig{MinInterval: "200ms", MaxInterval: "1s"})
+	if err != nil {
+		t.Fatalf("newWatchBackoff: %v", err)
+	}
+
+	var got []time.Duration
+	for _, active := range []bool{false, false, false, false, true, false} {
+		got = append(got, backoff.next(active))
+	}
+	want := []time.Duration{
+		400 * time.Millisecond,
+		800 * time.Millisecond,
+		time.Second, // capped at max
+		time.Second,
+		200 * time.Millisecond, // change resets to min
+		400 * time.Millisecond,
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("poll %d: expected %v, got %v", i, want[i], got[i])
+		}
+	}
+}
+
+// Test backoff defaults and validation
+func TestNewWatchBackoff(t *testing.T) {
+	backoff, err := newWatchBackoff(nil)
+	if err != nil {
+		t.Fatalf("newWatchBackoff: %v", err)
+	}
+	if backoff.min != defaultWatchInterval || backoff.max != defaultMaxWatchInterval {
+		t.Errorf("unexpected defaults: min=%v max=%v", backoff.min, backoff.max)
+	}
+
+	// Raising only min above the default max disables backoff
+	backoff, err = newWatchBackoff(&WatchConfig{MinInterval: "10s"})
+	if err != nil {
+		t.Fatalf("newWatchBackoff: %v", err)
+	}
+	if backoff.next(false) != 10*time.Second {
+		t.Errorf("expected interval to stay at min, got %v", backoff.current)
+	}
+
+	for _, cfg := range []WatchConfig{
+		{MinInterval: "often"},
+		{MinInterval: "10ms"}, // below minWatchInterval
+		{MaxInterval: "0s"},
+		{MinInterval: "2s", MaxInterval: "1s"},
+	} {
+		if _, err := newWatchBackoff(&cfg); err == nil {
+			t.Errorf("expected error for %+v", cfg)
+		}
+	}
+}
+
+// Test cmdWatch rejects a --debounce flag without a value
+func TestCmdWatchDebounceMissingValue(t *testing.T) {
+	err := cmdWatch([]string{"--debounce"})
+	if err == nil || !strings.Contains(err.Error(), "requires a value") {
+		t.Errorf("expected missing value error, got %v", err)
+	}
+}
+
+// Test --to-slot-prefix archives each peer clipboard change to a new slot
+// and leaves the local clipboard alone
+func TestCmdWatchToSlotPrefix(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	slotDir := t.TempDir()
+	defer setupSlotsTestConfig(t, slotsConfigAt(slotDir, `peers:
+  work:
+    ssh: user@host
+watch:
+  min_interval: 100ms
+`))()
+
+	// Fake transport: the peer clipboard changes on every read
+	mockDir := t.TempDir()
+	script := "#!/bin/sh\nn=$(cat " + mockDir + "/n 2>/dev/null || echo 0)\nn=$((n+1))\necho $n > " + mockDir + "/n\nprintf 'change-%s' $n\n"
+	if err := os.WriteFile(mockDir+"/ssh", []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", mockDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	clip := useFileClipboard(t, "local only")
+	// State left by a bidirectional watch of the same peer
+	localHash := sha256.Sum256([]byte("local only"))
+	saveWatchState("work", localHash, [32]byte{})
+
+	runWatchOnce(t, "--to-slot-prefix", "relay")
+	watchMaxIterations = 2
+	captureOutput(func() {
+		if err := cmdWatch([]string{"work", "--to-slot-prefix", "relay", "--since-last"}); err != nil {
+			t.Errorf("watch: %v", err)
+		}
+	})
+	watchMaxIterations = 0
+
+	backend, err := newRemoteBackendFromConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	slots, err := backend.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// change-1 is the starting state; each later read is a change
+	got := map[string]bool{}
+	for _, s := range slots {
+		if !strings.HasPrefix(s.Name, "relay-") {
+			t.Errorf("slot %q lacks the prefix", s.Name)
+		}
+		data, meta, err := backend.Pull(s.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if meta["hostname"] != "work" {
+			t.Errorf("slot %q hostname = %q, want the peer", s.Name, meta["hostname"])
+		}
+		got[string(data)] = true
+	}
+	if len(slots) != 3 || !got["change-2"] || !got["change-3"] || !got["change-4"] {
+		t.Errorf("archived %d slots %v, want change-2..4 each once", len(slots), got)
+	}
+
+	if data, _ := os.ReadFile(clip); string(data) != "local only" {
+		t.Errorf("local clipboard = %q, want it untouched", data)
+	}
+	local, remote, ok := loadWatchState("work")
+	if !ok || local != localHash || remote != sha256.Sum256([]byte("change-4")) {
+		t.Error("relay should save the peer hash and keep the saved local hash")
+	}
+}
+
+// Test --to-slot-prefix needs a value and a sync backend
+func TestCmdWatchToSlotPrefixErrors(t *testing.T) {
+	defer setupSlotsTestConfig(t, "version: 1\npeers:\n  work:\n    ssh: user@host\n")()
+
+	if err := cmdWatch([]string{"work", "--to-slot-prefix"}); err == nil || !strings.Contains(err.Error(), "requires a value") {
+		t.Errorf("err = %v, want missing value", err)
+	}
+	if err := cmdWatch([]string{"work", "--to-slot-prefix", "relay"}); err == nil || !strings.Contains(err.Error(), "needs a sync backend") {
+		t.Errorf("err = %v, want sync backend error", err)
+	}
+}
+
+// Test watch --clipboard records each new clipboard value once and stops
+// after --max captures
+func TestCmdWatchClipboard(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	defer setupSlotsTestConfig(t, "version: 1\n")()
+
+	// Each value is read twice before the clipboard changes
+	dir := t.TempDir()
+	script := "n=$(cat " + dir + "/n 2>/dev/null || echo 0); n=$((n+1)); echo $n > " + dir + "/n; printf 'clip-%s' $((n/2))"
+	cachedBackendOnce = sync.Once{}
+	cachedBackendOnce.Do(func() {
+		cachedBackend = &Backend{Kind: BackendUnknown, CopyCmd: []string{"true"}, PasteCmd: []string{"sh", "-c", script}}
+		cachedBackendErr = nil
+	})
+	t.Cleanup(func() { cachedBackendOnce = sync.Once{} })
+
+	var err error
+	out := captureOutput(func() {
+		err = cmdWatch([]string{"--clipboard", "--interval", "100ms", "--max", "3"})
+	})
+	if err != nil {
+		t.Fatalf("watch --clipboard: %v", err)
+	}
+	if n := strings.Count(out, "+ recorded"); n != 3 {
+		t.Errorf("recorded %d changes, want 3:\n%s", n, out)
+	}
+
+	data, err := os.ReadFile(getClipboardHistoryPath())
+	if err != nil {
+		t.Fatalf("reading clipboard history: %v", err)
+	}
+	var entries []ClipboardHistoryEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, e := range entries {
+		got = append(got, string(e.Content))
+	}
+	// clip-0 was on the clipboard at startup
+	if strings.Join(got, ",") != "clip-1,clip-2,clip-3" {
+		t.Errorf("clipboard history = %v, want clip-1..3", got)
+	}
+
+	for _, args := range [][]string{
+		{"--interval", "1s"},
+		{"--clipboard", "work"},
+		{"--clipboard", "--interval", "10ms"},
+		{"--clipboard", "--max", "0"},
+		{"--clipboard", "--to-slot-prefix", "x"},
+	} {
+		if err := cmdWatch(args); err == nil {
+			t.Errorf("watch %v should fail", args)
+		}
+	}
+}
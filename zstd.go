@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// zstdMaxDecodedSize caps how large a zstd slot may decompress to, so a
+// small crafted payload in a shared bucket can't exhaust memory. A var so
+// tests can lower it.
+var zstdMaxDecodedSize uint64 = 1 << 30
+
+// zstdCompress compresses src into a single zstd frame with a checksum
+func zstdCompress(src []byte) ([]byte, error) {
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1), zstd.WithZeroFrames(true))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = enc.Close() }()
+	return enc.EncodeAll(src, nil), nil
+}
+
+// zstdDecompress decodes every frame in src, refusing frames that would
+// decompress to more than zstdMaxDecodedSize
+func zstdDecompress(src []byte) ([]byte, error) {
+	dec, err := zstd.NewReader(nil,
+		zstd.WithDecoderConcurrency(1),
+		zstd.WithDecoderMaxMemory(zstdMaxDecodedSize),
+		zstd.WithDecoderMaxWindow(min(zstdMaxDecodedSize, zstd.MaxWindowSize)))
+	if err != nil {
+		return nil, err
+	}
+	defer dec.Close()
+	out, err := dec.DecodeAll(src, nil)
+	if err != nil {
+		return nil, fmt.Errorf("zstd: %w", err)
+	}
+	return out, nil
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// zstdTestInputs covers empty, tiny, repetitive and incompressible data
+func zstdTestInputs() map[string][]byte {
+	var text strings.Builder
+	for i := 0; text.Len() < 300<<10; i++ {
+		fmt.Fprintf(&text, "%05d pipeboard slot %d synced from host-%d\n", i, i%7, i%3)
+	}
+	noise := make([]byte, 5000)
+	x := uint32(1)
+	for i := range noise {
+		x = x*1664525 + 1013904223
+		noise[i] = byte(x >> 24)
+	}
+	return map[string][]byte{
+		"empty":    {},
+		"one byte": []byte("a"),
+		"run":      bytes.Repeat([]byte("x"), 200<<10),
+		"text":     []byte(text.String()),
+		"noise":    noise,
+		"utf-8":    bytes.Repeat([]byte("héllo wörld ✓ "), 500),
+	}
+}
+
+func mustZstdCompress(t *testing.T, data []byte) []byte {
+	t.Helper()
+	frame, err := zstdCompress(data)
+	if err != nil {
+		t.Fatalf("compress: %v", err)
+	}
+	return frame
+}
+
+func TestZstdRoundTrip(t *testing.T) {
+	for name, data := range zstdTestInputs() {
+		t.Run(name, func(t *testing.T) {
+			compressed := mustZstdCompress(t, data)
+			got, err := zstdDecompress(compressed)
+			if err != nil {
+				t.Fatalf("decompress: %v", err)
+			}
+			if !bytes.Equal(got, data) {
+				t.Fatalf("round trip changed %d bytes into %d", len(data), len(got))
+			}
+			if len(data) > 1024 && name != "noise" && len(compressed) >= len(data)/2 {
+				t.Errorf("compressed %d bytes to %d", len(data), len(compressed))
+			}
+		})
+	}
+}
+
+// Test decoding a frame written by the zstd CLI (zstd -19), which uses
+// table modes the encoder here doesn't
+func TestZstdDecompressReference(t *testing.T) {
+	frame, _ := hex.DecodeString(strings.Join([]string{
+		"28b52ffd64e02d8d13003672631a70692a39eee6cd174854d10c0654f50ed9bb",
+		"22226c1da23a812c6d005400540012e16369f0b31a8c2fac68e04c14f1db2dcf",
+		"7dba42ccc5d1d3abe8982f3b74280b3dfc9aed4a754113f2f06b76a4c6101f3c",
+		"15c62e47cf79abc6b09144fc764b729b04800258a0e0c00083040e12146060e0",
+		"00010a2ca0c040060b0e1050606020810007031c40700002034901bb1c3defbd",
+		"3ac34612f1db2de9cd92237c2c0d7e5683fd6ab5813351c46fb724c7890a3117",
+		"474fafe262bbe6d0a12cf4f06b6a9d46690b9a90875fb363d042283c782a8c5d",
+		"8e9ee34d350c1b49c46fb7a46c2205acd6501fe861850cee9419e5498f1519b6",
+		"996411fb67af1db96dd222e8238b1ae2354b5550fc444b6ce04c14f1db2d0317",
+		"a6a0107371f4f42a4e6cae71e850167af835a5134a1634210fbf66c7d022140f",
+		"9e0a23c1132a8111f6208768388317aac0309b914cc4fc634f3be46c234d04f9",
+		"88451ac2cb2caa04e5134b1a82139350443cee70a30c2ea65078cc2f77bc3ffd",
+		"f5e263bfeee08e295e5877d8af4d77a6bc504d43faa8dfba6c178257a812e0db",
+		"dbff33e2d7683b2208fc0203840021f4fd33a7415c9f33d056e538e5ebe2b6b7",
+		"ff377fcfdbdefedffe9eb77dfb7ffb7bdef6f6fff6ef79dbdbffdbdff3b66fff",
+		"6f7fcfdbdefedffe3d6f7bfb7ffb7bdef6edffedef79db1848c148c1082f6021",
+		"f622dc194b8b56bb0f617624fa2515b9cbad7f24ef7a09d7dc876465553fe316",
+		"6e48ebe24d846661d639d16c9a966b7eb33396f257a560a460840b5888bd48ee",
+		"8cc5a2d5ee43343b127d4945eef2d63f92bb5ec235f743b232d5cfb8853ba475",
+		"7113a15998eb9c689aa6e59adfd9197bb4f3013802f861b78e8756974bf076",
+	}, ""))
+	var want strings.Builder
+	for i := 0; i < 300; i++ {
+		fmt.Fprintf(&want, "%03d pipeboard slot %d synced from host-%d\n", i, i%7, i%3)
+	}
+
+	got, err := zstdDecompress(frame)
+	if err != nil {
+		t.Fatalf("decompress: %v", err)
+	}
+	if string(got) != want.String() {
+		t.Errorf("decoded %d bytes, want %d", len(got), want.Len())
+	}
+
+	// A skippable frame in front is ignored
+	skippable := []byte{0x50, 0x2a, 0x4d, 0x18, 3, 0, 0, 0, 'p', 'b', '!'}
+	if got, err := zstdDecompress(append(skippable, frame...)); err != nil || string(got) != want.String() {
+		t.Errorf("with skippable frame: err = %v", err)
+	}
+}
+
+func TestZstdDecompressErrors(t *testing.T) {
+	frame := mustZstdCompress(t, []byte(strings.Repeat("pipeboard ", 500)))
+
+	corrupt := bytes.Clone(frame)
+	corrupt[len(corrupt)/2] ^= 0x55
+	if _, err := zstdDecompress(corrupt); err == nil {
+		t.Error("expected an error for corrupted data")
+	}
+
+	// Flipping a byte of the checksum is caught even though the blocks decode
+	badSum := bytes.Clone(frame)
+	badSum[len(badSum)-1] ^= 1
+	if _, err := zstdDecompress(badSum); err == nil {
+		t.Error("expected a checksum error")
+	}
+
+	if _, err := zstdDecompress(frame[:len(frame)-6]); err == nil {
+		t.Error("expected an error for a truncated frame")
+	}
+	if _, err := zstdDecompress([]byte("not zstd at all")); err == nil || !strings.Contains(err.Error(), "magic") {
+		t.Errorf("expected magic number error, got %v", err)
+	}
+}
+
+// Test a small frame that expands past the cap is refused instead of
+// being decoded into memory
+func TestZstdDecompressLimit(t *testing.T) {
+	orig := zstdMaxDecodedSize
+	zstdMaxDecodedSize = 1 << 20
+	defer func() { zstdMaxDecodedSize = orig }()
+
+	bomb := mustZstdCompress(t, make([]byte, 8<<20))
+	if len(bomb) > 4096 {
+		t.Fatalf("expected a small frame, got %d bytes", len(bomb))
+	}
+	if out, err := zstdDecompress(bomb); err == nil {
+		t.Errorf("expected an error decoding %d bytes past the limit", len(out))
+	}
+	if _, err := zstdDecompress(mustZstdCompress(t, make([]byte, 512<<10))); err != nil {
+		t.Errorf("frame under the limit: %v", err)
+	}
+}
+
+// Test frames interoperate with the zstd CLI both ways
+func TestZstdCLI(t *testing.T) {
+	if _, err := exec.LookPath("zstd"); err != nil {
+		t.Skip("zstd not installed")
+	}
+	dir := t.TempDir()
+	for name, data := range zstdTestInputs() {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(dir, strings.ReplaceAll(name, " ", "-"))
+			if err := os.WriteFile(path+".zst", mustZstdCompress(t, data), 0600); err != nil {
+				t.Fatal(err)
+			}
+			got, err := exec.Command("zstd", "-d", "-q", "-c", path+".zst").Output()
+			if err != nil || !bytes.Equal(got, data) {
+				t.Errorf("zstd -d: err = %v, got %d bytes, want %d", err, len(got), len(data))
+			}
+
+			if err := os.WriteFile(path, data, 0600); err != nil {
+				t.Fatal(err)
+			}
+			for _, level := range []string{"-1", "-19"} {
+				frame, err := exec.Command("zstd", level, "-q", "-c", path).Output()
+				if err != nil {
+					t.Fatalf("zstd %s: %v", level, err)
+				}
+				got, err := zstdDecompress(frame)
+				if err != nil || !bytes.Equal(got, data) {
+					t.Errorf("decoding zstd %s: err = %v", level, err)
+				}
+			}
+		})
+	}
+}